@@ -5,19 +5,28 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
 	agentServer "github.com/hashicorp/nomad-autoscaler/agent/http"
+	"github.com/hashicorp/nomad-autoscaler/agent/leader"
+	push "github.com/hashicorp/nomad-autoscaler/plugins/builtin/apm/push/plugin"
 	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
 	"github.com/hashicorp/nomad-autoscaler/policy"
+	apiPolicy "github.com/hashicorp/nomad-autoscaler/policy/api"
+	consulPolicy "github.com/hashicorp/nomad-autoscaler/policy/consul"
 	filePolicy "github.com/hashicorp/nomad-autoscaler/policy/file"
+	httpPolicy "github.com/hashicorp/nomad-autoscaler/policy/http"
 	nomadPolicy "github.com/hashicorp/nomad-autoscaler/policy/nomad"
 	"github.com/hashicorp/nomad-autoscaler/policyeval"
 	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/clock"
 	nomadHelper "github.com/hashicorp/nomad-autoscaler/sdk/helper/nomad"
 	"github.com/hashicorp/nomad/api"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 type Agent struct {
@@ -28,18 +37,57 @@ type Agent struct {
 	policyManager *policy.Manager
 	httpServer    *agentServer.Server
 	evalBroker    *policyeval.Broker
+
+	// apiPolicySource holds the policies registered at runtime using the
+	// HTTP API.
+	apiPolicySource *apiPolicy.Source
+
+	// elector is used to elect the agent which scales targets when several
+	// agents are run. It is nil if leader election is disabled.
+	elector *leader.ConsulElector
+
+	// maintenanceGate defers scaling while the external maintenance
+	// condition says to hold. It is nil if no condition is configured.
+	maintenanceGate *policyeval.MaintenanceGate
+
+	// previewWorker evaluates the policies for the scaling preview, sharing
+	// the guards of the workers so the preview reflects their state. It is
+	// nil until the workers are started, and protected by previewLock as the
+	// HTTP server is started first.
+	previewLock   sync.RWMutex
+	previewWorker *policyeval.BaseWorker
+
+	// startTime is when the agent started running and is used to report the
+	// agent uptime.
+	startTime time.Time
+
+	// tracerProvider exports the policy evaluation traces. It is nil if
+	// tracing is disabled.
+	tracerProvider *sdktrace.TracerProvider
+
+	// clock is used to read the current time and schedule policy
+	// evaluations, allowing tests to control time.
+	clock clock.Clock
+
+	// pushStore holds the metric values pushed to the agent HTTP API, which
+	// are served by the push APM plugins.
+	pushStore *push.Store
 }
 
 func NewAgent(c *config.Agent, logger hclog.Logger) *Agent {
 	return &Agent{
-		logger: logger,
-		config: c,
+		logger:    logger,
+		config:    c,
+		clock:     clock.Real(),
+		pushStore: push.NewStore(push.DefaultMaxNames),
 	}
 }
 
 func (a *Agent) Run() error {
 	defer a.stop()
 
+	a.startTime = a.clock.Now()
+
 	// Create context to handle propagation to downstream routines.
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -59,9 +107,30 @@ func (a *Agent) Run() error {
 	if err != nil {
 		return fmt.Errorf("failed to setup telemetry: %v", err)
 	}
+	if err := a.setupTracing(a.config.Telemetry); err != nil {
+		return fmt.Errorf("failed to setup tracing: %v", err)
+	}
+
+	// Start leader election before the workers, so followers never scale.
+	if a.config.LeaderElection != nil && a.config.LeaderElection.Enabled {
+		a.elector = leader.NewConsulElector(a.logger, a.config.LeaderElection.ConsulAddress,
+			a.config.LeaderElection.ConsulToken, a.config.LeaderElection.Key, a.config.LeaderElection.SessionTTL)
+		go a.elector.Run(ctx)
+	}
+
+	// Setup the maintenance gate before the HTTP server, as its state is
+	// reported by the status endpoint.
+	a.maintenanceGate = a.newMaintenanceGate()
+
+	// Setup the policy manager before the HTTP server, as the server exposes
+	// endpoints to manage policy overrides.
+	policyEvalCh, err := a.setupPolicyManager()
+	if err != nil {
+		return fmt.Errorf("failed to setup policy manager: %v", err)
+	}
 
 	// Setup and start the HTTP server.
-	httpServer, err := agentServer.NewHTTPServer(a.config.HTTP, a.logger, inMem)
+	httpServer, err := agentServer.NewHTTPServer(a.config.HTTP, a.logger, inMem, a.pluginManager, a.policyManager, a)
 	if err != nil {
 		return fmt.Errorf("failed to setup HTTP getHealth server: %v", err)
 	}
@@ -69,7 +138,6 @@ func (a *Agent) Run() error {
 	a.httpServer = httpServer
 	go a.httpServer.Start()
 
-	policyEvalCh := a.setupPolicyManager()
 	go a.policyManager.Run(ctx, policyEvalCh)
 
 	// Launch eval broker and workers.
@@ -104,26 +172,189 @@ func (a *Agent) runEvalHandler(ctx context.Context, evalCh chan *sdk.ScalingEval
 func (a *Agent) initWorkers(ctx context.Context) {
 	policyEvalLogger := a.logger.ResetNamed("policy_eval")
 
+	// The query cache is shared by all workers so identical queries from
+	// policies in different queues are coalesced.
+	queryCache := policyeval.NewQueryCache(a.config.PolicyEval.QueryCoalesceWindow)
+
+	// The result cache is also shared so results are reused across policies
+	// running the same query.
+	resultCache := policyeval.NewResultCache(a.config.PolicyEval.QueryCacheTTL)
+
+	// Track policy evaluation failures across all workers if alerting is
+	// configured.
+	var failureTracker *policyeval.FailureTracker
+	if a.config.Alerting != nil && a.config.Alerting.WebhookAddress != "" {
+		alerter := policyeval.NewWebhookAlerter(policyEvalLogger,
+			a.config.Alerting.WebhookAddress, a.config.Alerting.WebhookHeaders)
+		failureTracker = policyeval.NewFailureTracker(a.config.Alerting.FailureThreshold,
+			a.config.Alerting.Severity, alerter)
+	}
+
+	// Limit the total count of all policy targets if a capacity budget is
+	// configured.
+	var capacityBudget *policyeval.CapacityBudget
+	if a.config.CapacityBudget != nil && a.config.CapacityBudget.MaxCount > 0 {
+		capacityBudget = policyeval.NewCapacityBudget(a.config.CapacityBudget.MaxCount,
+			a.config.CapacityBudget.Enforcement)
+	}
+
+	// Restrict the targets the agent may scale if target access is
+	// configured.
+	var targetAccess *policyeval.TargetAccess
+	if a.config.TargetAccess != nil {
+		targetAccess = policyeval.NewTargetAccess(a.config.TargetAccess.Allow, a.config.TargetAccess.Deny)
+	}
+
+	// Serialize the scaling of each target across all workers, so policies
+	// targeting the same resource do not issue conflicting scaling actions.
+	targetLocks := policyeval.NewTargetLocks()
+
+	// Run the scale hook commands if any are configured.
+	var scaleHooks *policyeval.ScaleHooks
+	if a.config.ScaleHooks != nil {
+		scaleHooks = policyeval.NewScaleHooks(a.config.ScaleHooks.PreScale,
+			a.config.ScaleHooks.PostScale, a.config.ScaleHooks.Timeout)
+	}
+
+	// Limit the rate of scaling actions across all policies if a scale
+	// throttle is configured.
+	var scaleThrottle *policyeval.ScaleThrottle
+	if a.config.ScaleThrottle != nil {
+		scaleThrottle = policyeval.NewScaleThrottle(a.config.ScaleThrottle.Rate, a.config.ScaleThrottle.Burst)
+	}
+
+	// Stream the scaling decisions of all policies to the configured sinks.
+	decisionSink := a.decisionSink(policyEvalLogger)
+	go decisionSink.Run(ctx)
+
+	// Deduplicate the errors logged by all workers, so a policy failing in
+	// the same way on each evaluation does not flood the logs.
+	errLogs := policy.NewErrorLogDeduper(policy.DefaultErrorLogSummaryInterval, policy.DefaultErrorLogMaxEntries)
+
+	// Avoid storing a typed nil within the interface.
+	var leadership policyeval.Leadership
+	if a.elector != nil {
+		leadership = a.elector
+	}
+
+	workerConfig := policyeval.BaseWorkerConfig{
+		Logger:          policyEvalLogger,
+		PluginManager:   a.pluginManager,
+		PolicyManager:   a.policyManager,
+		Broker:          a.evalBroker,
+		QueryCache:      queryCache,
+		ResultCache:     resultCache,
+		FailureTracker:  failureTracker,
+		CapacityBudget:  capacityBudget,
+		TargetAccess:    targetAccess,
+		TargetLocks:     targetLocks,
+		ScaleHooks:      scaleHooks,
+		ScaleThrottle:   scaleThrottle,
+		MaintenanceGate: a.maintenanceGate,
+		DecisionSink:    decisionSink,
+		ErrorLogs:       errLogs,
+		Leadership:      leadership,
+		MultipleActions: a.config.PolicyEval.MultipleActions,
+		PolicyDefaults:  a.policyDefaults(),
+		Clock:           a.clock,
+	}
+
 	for i := 0; i < a.config.PolicyEval.Workers["horizontal"]; i++ {
-		w := policyeval.NewBaseWorker(
-			policyEvalLogger, a.pluginManager, a.policyManager, a.evalBroker, "horizontal")
+		w := policyeval.NewBaseWorker(workerConfig, "horizontal")
 		go w.Run(ctx)
 	}
 
 	for i := 0; i < a.config.PolicyEval.Workers["cluster"]; i++ {
-		w := policyeval.NewBaseWorker(
-			policyEvalLogger, a.pluginManager, a.policyManager, a.evalBroker, "cluster")
+		w := policyeval.NewBaseWorker(workerConfig, "cluster")
 		go w.Run(ctx)
 	}
+
+	// The preview worker never runs, so it does not take evaluations from
+	// the broker.
+	previewWorker := policyeval.NewBaseWorker(workerConfig, "preview")
+
+	a.previewLock.Lock()
+	a.previewWorker = previewWorker
+	a.previewLock.Unlock()
+}
+
+// decisionSink returns the sink which streams scaling decisions to the
+// publishers configured within the agent config, or nil if none are. An
+// invalid publisher is logged and skipped, so it does not stop the agent.
+func (a *Agent) decisionSink(logger hclog.Logger) *policyeval.DecisionSink {
+	cfg := a.config.DecisionEvents
+	if cfg == nil {
+		return nil
+	}
+
+	var publishers []policyeval.DecisionPublisher
+	if cfg.WebhookAddress != "" {
+		publishers = append(publishers, policyeval.NewWebhookPublisher(cfg.WebhookAddress, cfg.WebhookHeaders))
+	}
+	if cfg.NATSAddress != "" {
+		nats, err := policyeval.NewNATSPublisher(cfg.NATSAddress, cfg.NATSSubject)
+		if err != nil {
+			logger.Error("failed to setup NATS decision event publisher", "error", err)
+		} else {
+			publishers = append(publishers, nats)
+		}
+	}
+
+	return policyeval.NewDecisionSink(logger, cfg.BufferSize, publishers...)
+}
+
+// newMaintenanceGate returns the gate which reads the maintenance condition
+// from the source configured within the agent config, or nil if none is.
+func (a *Agent) newMaintenanceGate() *policyeval.MaintenanceGate {
+	cfg := a.config.Maintenance
+	if cfg == nil {
+		return nil
+	}
+
+	logger := a.logger.ResetNamed("policy_eval")
+	switch {
+	case cfg.HTTPAddress != "":
+		return policyeval.NewHTTPMaintenanceGate(logger, cfg.HTTPAddress, cfg.HTTPHeaders, cfg.CacheTTL, cfg.OnError)
+	case cfg.FilePath != "":
+		return policyeval.NewFileMaintenanceGate(logger, cfg.FilePath, cfg.CacheTTL, cfg.OnError)
+	default:
+		return nil
+	}
+}
+
+// policyDefaults returns the agent level default values applied to policies
+// which leave them unset.
+func (a *Agent) policyDefaults() policyeval.PolicyDefaults {
+	if a.config.Policy == nil {
+		return policyeval.PolicyDefaults{}
+	}
+	return policyeval.PolicyDefaults{
+		Min: a.config.Policy.DefaultMin,
+		Max: a.config.Policy.DefaultMax,
+	}
 }
 
-func (a *Agent) setupPolicyManager() chan *sdk.ScalingEvaluation {
+// policyConflictResolution returns how the policy manager handles policies
+// from different sources which share an ID or a target.
+func (a *Agent) policyConflictResolution() policy.ConflictResolution {
+	c := policy.ConflictResolution{
+		Mode:      policy.ConflictMode(a.config.Policy.ConflictMode),
+		TargetKey: policyeval.TargetKey,
+	}
+	for _, name := range a.config.Policy.SourcePriority {
+		c.SourcePriority = append(c.SourcePriority, policy.SourceName(name))
+	}
+	return c
+}
+
+func (a *Agent) setupPolicyManager() (chan *sdk.ScalingEvaluation, error) {
 
 	// Create our processor, a shared method for performing basic policy
 	// actions.
 	cfgDefaults := policy.ConfigDefaults{
 		DefaultEvaluationInterval: a.config.Policy.DefaultEvaluationInterval,
 		DefaultCooldown:           a.config.Policy.DefaultCooldown,
+		DefaultWarmupPeriod:       a.config.Policy.DefaultWarmupPeriod,
 	}
 	policyProcessor := policy.NewProcessor(&cfgDefaults, a.getNomadAPMNames())
 
@@ -138,9 +369,46 @@ func (a *Agent) setupPolicyManager() chan *sdk.ScalingEvaluation {
 		sources[policy.SourceNameFile] = filePolicy.NewFileSource(a.logger, a.config.Policy.Dir, policyProcessor)
 	}
 
-	a.policyManager = policy.NewManager(a.logger, sources, a.pluginManager, a.config.Telemetry.CollectionInterval)
+	// If the operators has configured a remote HTTP endpoint to read scaling
+	// policies from then setup the HTTP source.
+	if a.config.Policy.HTTPAddress != "" {
+		sources[policy.SourceNameHTTP] = httpPolicy.NewHTTPSource(a.logger, a.config.Policy.HTTPAddress,
+			a.config.Policy.HTTPHeaders, a.config.Policy.HTTPPollInterval, policyProcessor)
+	}
 
-	return make(chan *sdk.ScalingEvaluation, 10)
+	// If the operators has configured a Consul agent to read scaling policies
+	// from then setup the Consul source.
+	if a.config.Policy.ConsulAddress != "" {
+		consulSource, err := consulPolicy.NewConsulSource(a.logger, &consulPolicy.Config{
+			Address:       a.config.Policy.ConsulAddress,
+			Prefix:        a.config.Policy.ConsulPrefix,
+			Token:         a.config.Policy.ConsulToken,
+			CACert:        a.config.Policy.ConsulCACert,
+			ClientCert:    a.config.Policy.ConsulClientCert,
+			ClientKey:     a.config.Policy.ConsulClientKey,
+			TLSServerName: a.config.Policy.ConsulTLSServerName,
+			SkipVerify:    a.config.Policy.ConsulSkipVerify,
+		}, policyProcessor)
+		if err != nil {
+			return nil, err
+		}
+		sources[policy.SourceNameConsul] = consulSource
+	}
+
+	// Policies can always be registered at runtime using the HTTP API.
+	a.apiPolicySource = apiPolicy.NewAPISource(a.logger, policyProcessor)
+	sources[policy.SourceNameAPI] = a.apiPolicySource
+
+	a.policyManager = policy.NewManager(a.logger, sources, a.pluginManager,
+		a.config.Telemetry.CollectionInterval, a.config.Policy.MinEvaluationInterval,
+		a.config.Policy.RemovalGracePeriod)
+	a.policyManager.SetClock(a.clock)
+	a.policyManager.SetConflictResolution(a.policyConflictResolution())
+	a.policyManager.SetQueryValidator(func(p *sdk.ScalingPolicy) error {
+		return policyeval.ValidateQueries(a.pluginManager, p)
+	})
+
+	return make(chan *sdk.ScalingEvaluation, 10), nil
 }
 
 func (a *Agent) stop() {
@@ -153,6 +421,8 @@ func (a *Agent) stop() {
 	if a.pluginManager != nil {
 		a.pluginManager.KillPlugins()
 	}
+
+	a.stopTracing()
 }
 
 // generateNomadClient takes the internal Nomad configuration, translates and
@@ -202,6 +472,13 @@ func (a *Agent) generateNomadClient() error {
 		cfg.TLSConfig.Insecure = a.config.Nomad.SkipVerify
 	}
 
+	// Apply the request timeout and retries.
+	err := nomadHelper.ConfigureHTTPClient(cfg, a.config.Nomad.RequestTimeout,
+		a.config.Nomad.Retries, a.config.Nomad.RetryBackoff)
+	if err != nil {
+		return fmt.Errorf("failed to configure Nomad HTTP client: %v", err)
+	}
+
 	// Generate the Nomad client.
 	client, err := api.NewClient(cfg)
 	if err != nil {
@@ -214,7 +491,7 @@ func (a *Agent) generateNomadClient() error {
 
 // reload triggers the reload of sub-routines based on the operator sending a
 // SIGHUP signal to the agent.
-func (a Agent) reload() {
+func (a *Agent) reload() {
 	a.policyManager.ReloadSources()
 }
 
@@ -222,7 +499,8 @@ func (a Agent) reload() {
 func (a *Agent) handleSignals() {
 
 	signalCh := make(chan os.Signal, 3)
-	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	signals := append([]os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}, dumpStateSignals...)
+	signal.Notify(signalCh, signals...)
 
 	// Wait to receive a signal. This blocks until we are notified.
 WAIT:
@@ -231,12 +509,15 @@ WAIT:
 	a.logger.Info("caught signal", "signal", sig.String())
 
 	// Check the signal we received. If it was a SIGHUP perform the reload
-	// tasks and then continue to wait for another signal. Everything else
-	// means exit.
-	switch sig {
-	case syscall.SIGHUP:
+	// tasks and then continue to wait for another signal. A dump state signal
+	// logs the agent internal state. Everything else means exit.
+	switch {
+	case sig == syscall.SIGHUP:
 		a.reload()
 		goto WAIT
+	case isDumpStateSignal(sig):
+		a.dumpState()
+		goto WAIT
 	default:
 		return
 	}
@@ -1,12 +1,19 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl/v2/hclsimple"
 	"github.com/hashicorp/nomad-autoscaler/plugins"
@@ -51,6 +58,38 @@ type Agent struct {
 	// Telemetry is the configuration used to setup metrics collection.
 	Telemetry *Telemetry `hcl:"telemetry,block"`
 
+	// Alerting is the configuration used to alert on repeated policy
+	// evaluation failures.
+	Alerting *Alerting `hcl:"alerting,block"`
+
+	// LeaderElection is the configuration used to elect a single agent, out
+	// of several running for high availability, to perform scaling.
+	LeaderElection *LeaderElection `hcl:"leader_election,block"`
+
+	// CapacityBudget is the configuration used to limit the total count of
+	// the targets of all policies.
+	CapacityBudget *CapacityBudget `hcl:"capacity_budget,block"`
+
+	// TargetAccess is the configuration used to restrict the targets the
+	// agent is allowed to scale.
+	TargetAccess *TargetAccess `hcl:"target_access,block"`
+
+	// ScaleHooks is the configuration of the commands run before and after
+	// the agent scales a target.
+	ScaleHooks *ScaleHooks `hcl:"scale_hooks,block"`
+
+	// ScaleThrottle is the configuration used to limit the rate at which the
+	// agent performs scaling actions across all policies.
+	ScaleThrottle *ScaleThrottle `hcl:"scale_throttle,block"`
+
+	// DecisionEvents is the configuration of the sinks which the scaling
+	// decisions of all policies are streamed to.
+	DecisionEvents *DecisionEvents `hcl:"decision_events,block"`
+
+	// Maintenance is the configuration of the external condition consulted
+	// before scaling, which defers scaling during maintenance windows.
+	Maintenance *Maintenance `hcl:"maintenance,block"`
+
 	APMs       []*Plugin `hcl:"apm,block"`
 	Targets    []*Plugin `hcl:"target,block"`
 	Strategies []*Plugin `hcl:"strategy,block"`
@@ -65,6 +104,33 @@ type HTTP struct {
 
 	// BindPort is the port used to run the HTTP server.
 	BindPort int `hcl:"bind_port,optional"`
+
+	// EnableDebug exposes the net/http/pprof profiling endpoints under
+	// /debug/pprof/ on the HTTP server. It is disabled by default, as the
+	// endpoints are unauthenticated.
+	EnableDebug bool `hcl:"enable_debug,optional"`
+
+	// AuthToken, if set, is the bearer token requests to the HTTP server
+	// must present within the Authorization header.
+	AuthToken string `hcl:"auth_token,optional"`
+
+	// TLSCertFile and TLSKeyFile are the paths to the PEM-encoded certificate
+	// and key used to serve HTTPS. TLSCAFile, if set, is the path to the CA
+	// certificate used to verify client certificates, which then
+	// authenticate requests in the same way as the AuthToken.
+	TLSCertFile string `hcl:"tls_cert_file,optional"`
+	TLSKeyFile  string `hcl:"tls_key_file,optional"`
+	TLSCAFile   string `hcl:"tls_ca_file,optional"`
+
+	// MetricsAllowedCIDRs are the networks allowed to read the metrics
+	// endpoint without authenticating, such as those of metrics scrapers.
+	MetricsAllowedCIDRs []string `hcl:"metrics_allowed_cidrs,optional"`
+}
+
+// AuthEnabled returns whether requests to the HTTP server must authenticate.
+// The health endpoint is always unauthenticated.
+func (h *HTTP) AuthEnabled() bool {
+	return h.AuthToken != "" || h.TLSCAFile != ""
 }
 
 // Nomad holds the user specified configuration for connectivity to the Nomad
@@ -107,6 +173,21 @@ type Nomad struct {
 
 	// SkipVerify enables or disables SSL verification.
 	SkipVerify bool `hcl:"skip_verify,optional"`
+
+	// RequestTimeout is the time limit of a single request to the Nomad API.
+	// Blocking queries may additionally wait for up to their wait time.
+	RequestTimeout    time.Duration
+	RequestTimeoutHCL string `hcl:"request_timeout,optional" json:"-"`
+
+	// Retries is the number of times a failed read request to the Nomad API
+	// is retried. Zero disables retries.
+	RetriesPtr *int `hcl:"retries,optional"`
+	Retries    int
+
+	// RetryBackoff is the time to wait before the first retry of a request.
+	// It doubles for each subsequent retry.
+	RetryBackoff    time.Duration
+	RetryBackoffHCL string `hcl:"retry_backoff,optional" json:"-"`
 }
 
 // Telemetry holds the user specified configuration for metrics collection.
@@ -222,15 +303,231 @@ type Telemetry struct {
 	// instance is running. (e.g. a specific geo location or datacenter, dc:sfo)
 	// Defaults to none.
 	CirconusBrokerSelectTag string `hcl:"circonus_broker_select_tag,optional"`
+
+	// OTLPEndpoint is the host and port of an OpenTelemetry collector which
+	// policy evaluation traces are exported to, using OTLP over HTTP. Tracing
+	// is disabled if it is empty.
+	OTLPEndpoint string `hcl:"otlp_endpoint,optional"`
+
+	// OTLPInsecure disables TLS when exporting traces to the OTLPEndpoint.
+	OTLPInsecure bool `hcl:"otlp_insecure,optional"`
+}
+
+// Alerting holds the configuration used to alert operators when a policy fails
+// to evaluate repeatedly.
+type Alerting struct {
+
+	// WebhookAddress is the URL which alert payloads are sent to as HTTP POST
+	// requests. Alerting is disabled if it is empty.
+	WebhookAddress string `hcl:"webhook_address,optional"`
+
+	// WebhookHeaders are added to every request made to WebhookAddress, which
+	// allows setting authentication headers.
+	WebhookHeaders map[string]string `hcl:"webhook_headers,optional"`
+
+	// FailureThreshold is the number of consecutive evaluation failures of a
+	// policy which trigger an alert.
+	FailureThreshold int `hcl:"failure_threshold,optional"`
+
+	// Severity is included in the alert payload to allow the receiver to
+	// route the alert.
+	Severity string `hcl:"severity,optional"`
+}
+
+// CapacityBudget holds the configuration of the agent wide budget which limits
+// the sum of the target counts of all policies, so that independent policies
+// cannot collectively scale beyond what the cluster can afford.
+type CapacityBudget struct {
+
+	// MaxCount is the maximum sum of the target counts of all policies. The
+	// budget is disabled if it is zero. As the counts of all policies are
+	// summed, policies of different types should not share an agent which
+	// enforces a budget.
+	MaxCount int64 `hcl:"max_count,optional"`
+
+	// Enforcement controls how a scale up exceeding the budget is handled.
+	// The "deny" mode skips the scale up entirely, while the "reduce" mode
+	// reduces it to the count remaining within the budget.
+	Enforcement string `hcl:"enforcement,optional"`
+}
+
+// TargetAccess holds the glob patterns restricting the targets the agent is
+// allowed to scale, regardless of the policies it evaluates. The patterns are
+// matched against the name of the target plugin and, for Nomad task group
+// targets, the "<namespace>/<job>/<group>" path.
+type TargetAccess struct {
+
+	// Allow are the patterns of the targets the agent may scale. All targets
+	// are allowed if it is empty.
+	Allow []string `hcl:"allow,optional"`
+
+	// Deny are the patterns of the targets the agent must never scale. They
+	// take precedence over Allow.
+	Deny []string `hcl:"deny,optional"`
+}
+
+// ScaleHooks holds the commands run before and after the agent scales a
+// target, which receive the details of the scaling action as environment
+// variables. Each command is the path of the executable followed by its
+// arguments, and is not run by a shell.
+type ScaleHooks struct {
+
+	// PreScale is run before a target is scaled. The target is not scaled if
+	// the command fails, allowing it to gate scaling actions.
+	PreScale []string `hcl:"pre_scale,optional"`
+
+	// PostScale is run after a target is scaled successfully. Its failure is
+	// logged, but does not affect the scaling action.
+	PostScale []string `hcl:"post_scale,optional"`
+
+	// Timeout is the time a command is allowed to run before it is killed
+	// and considered failed.
+	Timeout    time.Duration
+	TimeoutHCL string `hcl:"timeout,optional" json:"-"`
+}
+
+// ScaleThrottle holds the configuration of the agent wide token bucket which
+// limits the number of scaling actions performed across all policies. Scaling
+// actions exceeding the limit are skipped, and deferred to the next evaluation
+// of their policy.
+type ScaleThrottle struct {
+
+	// Rate is the number of scaling actions allowed per minute. The throttle
+	// is disabled if it is zero.
+	Rate int `hcl:"rate,optional"`
+
+	// Burst is the number of scaling actions which can be performed at once
+	// before the rate applies.
+	Burst int `hcl:"burst,optional"`
+}
+
+// DecisionEvents holds the configuration of the sinks which the agent streams
+// an event to for each scaling decision, including suppressed and advisory
+// actions. Events are published in the background and retried on failure, so
+// an unavailable sink never delays policy evaluation.
+type DecisionEvents struct {
+
+	// WebhookAddress is the URL which events are sent to as HTTP POST
+	// requests. The webhook sink is disabled if it is empty.
+	WebhookAddress string `hcl:"webhook_address,optional"`
+
+	// WebhookHeaders are added to every request made to WebhookAddress, which
+	// allows setting authentication headers.
+	WebhookHeaders map[string]string `hcl:"webhook_headers,optional"`
+
+	// NATSAddress is the address of the NATS server which events are
+	// published to, in the nats://[user:pass@]host:port form. The NATS sink is
+	// disabled if it is empty.
+	NATSAddress string `hcl:"nats_address,optional"`
+
+	// NATSSubject is the subject which events are published to.
+	NATSSubject string `hcl:"nats_subject,optional"`
+
+	// BufferSize is the number of events buffered for each sink while they
+	// cannot be delivered. The oldest events are dropped once it is full.
+	BufferSize int `hcl:"buffer_size,optional"`
+}
+
+// Maintenance holds the configuration of the external condition the agent
+// consults before scaling any target, allowing an external system to hold all
+// scaling during maintenance windows. The condition is read from an HTTP
+// endpoint or a file, whose content must be "allow" or "hold".
+type Maintenance struct {
+
+	// HTTPAddress is the URL which the condition is read from, using GET
+	// requests.
+	HTTPAddress string `hcl:"http_address,optional"`
+
+	// HTTPHeaders are added to every request made to HTTPAddress, which
+	// allows setting authentication headers.
+	HTTPHeaders map[string]string `hcl:"http_headers,optional"`
+
+	// FilePath is the path of the file which the condition is read from.
+	// Scaling is allowed while the file does not exist. Only one of
+	// HTTPAddress and FilePath can be set.
+	FilePath string `hcl:"file_path,optional"`
+
+	// CacheTTL is the time the condition is cached for once read.
+	CacheTTL    time.Duration
+	CacheTTLHCL string `hcl:"cache_ttl,optional" json:"-"`
+
+	// OnError is the condition used when it cannot be read, either "hold"
+	// or "allow".
+	OnError string `hcl:"on_error,optional"`
+}
+
+// LeaderElection holds the configuration used to elect a leader amongst
+// multiple agents using a Consul session lock. Only the leader scales targets;
+// the other agents evaluate policies but do not scale, ready to take over.
+type LeaderElection struct {
+
+	// Enabled turns on leader election. When disabled, the agent always
+	// scales targets.
+	Enabled bool `hcl:"enabled,optional"`
+
+	// ConsulAddress is the address of the Consul agent used for the lock.
+	ConsulAddress string `hcl:"consul_address,optional"`
+
+	// ConsulToken is the ACL token used for the Consul requests.
+	ConsulToken string `hcl:"consul_token,optional"`
+
+	// Key is the Consul KV key used as the leadership lock. All agents which
+	// should elect a single leader must use the same key.
+	Key string `hcl:"key,optional"`
+
+	// SessionTTL is the TTL of the Consul session holding the lock. If the
+	// leader fails, another agent takes over once the session expires.
+	SessionTTL    time.Duration
+	SessionTTLHCL string `hcl:"session_ttl,optional" json:"-"`
 }
 
 // Plugin is an individual configured plugin and holds all the required params
 // to successfully dispense the driver.
 type Plugin struct {
-	Name   string            `hcl:"name,label"`
-	Driver string            `hcl:"driver"`
-	Args   []string          `hcl:"args,optional"`
+	Name   string   `hcl:"name,label"`
+	Driver string   `hcl:"driver"`
+	Args   []string `hcl:"args,optional"`
+
+	// Config is passed to the plugin. Values of the form file:///path are
+	// replaced with the content of the file, without trailing newlines, so
+	// secrets can be mounted as files rather than written into the config.
 	Config map[string]string `hcl:"config,optional"`
+
+	// Env holds additional environment variables the external plugin binary
+	// is launched with, on top of the agent environment. Variables which are
+	// also set in the agent environment keep the agent value. It is ignored
+	// for built-in plugins.
+	Env map[string]string `hcl:"env,optional"`
+
+	// SHA256 is the optional hex encoded SHA-256 checksum of the external
+	// plugin binary. When set, the binary is verified against the checksum
+	// as it is launched and the plugin is not launched on mismatch. It is
+	// ignored for built-in plugins, which have no binary to verify.
+	SHA256 string `hcl:"sha256,optional"`
+
+	// MaxLifetime is the optional duration after which the external plugin
+	// process is recycled, by launching and configuring a new process once
+	// no calls to the plugin are in flight. This mitigates plugins which leak
+	// memory or accumulate state over time. It is ignored for built-in
+	// plugins, which do not run as a separate process.
+	MaxLifetime    time.Duration
+	MaxLifetimeHCL string `hcl:"max_lifetime,optional" json:"-"`
+
+	// SetConfigRetries is the number of times setting the config of the
+	// plugin is retried when it fails, as a plugin which has just started
+	// may not be ready to accept it. The plugin manager default is used if
+	// it is not set.
+	SetConfigRetries *int `hcl:"set_config_retries,optional"`
+
+	// SetConfigRetryBackoff is the time to wait before the first retry of
+	// setting the config, which doubles for each subsequent retry.
+	SetConfigRetryBackoff    time.Duration
+	SetConfigRetryBackoffHCL string `hcl:"set_config_retry_backoff,optional" json:"-"`
+
+	// Optional allows the agent to start when the plugin fails to launch or
+	// to accept its config. The plugin is then reported as unavailable, and
+	// policies using it fail to evaluate.
+	Optional bool `hcl:"optional,optional"`
 }
 
 // Policy holds the configuration information specific to the policy manager
@@ -250,6 +547,82 @@ type Policy struct {
 	// `evaluation_interval` is not defined in a policy.
 	DefaultEvaluationInterval    time.Duration
 	DefaultEvaluationIntervalHCL string `hcl:"default_evaluation_interval,optional" json:"-"`
+
+	// DefaultWarmupPeriod is the warmup period used when `warmup_period` is
+	// not defined in a policy.
+	DefaultWarmupPeriod    time.Duration
+	DefaultWarmupPeriodHCL string `hcl:"default_warmup_period,optional" json:"-"`
+
+	// MinEvaluationInterval is the shortest evaluation interval a policy is
+	// allowed to use. Policies requesting a shorter interval are evaluated at
+	// this interval instead. A value of zero means no minimum is enforced.
+	MinEvaluationInterval    time.Duration
+	MinEvaluationIntervalHCL string `hcl:"min_evaluation_interval,optional" json:"-"`
+
+	// RemovalGracePeriod is how long the handler of a policy which is no
+	// longer listed by its source keeps running before it is stopped. A
+	// policy which reappears within the period keeps its handler and state,
+	// such as its cooldown. A value of zero stops the handler immediately.
+	RemovalGracePeriod    time.Duration
+	RemovalGracePeriodHCL string `hcl:"removal_grace_period,optional" json:"-"`
+
+	// DefaultMin and DefaultMax are applied during the policy evaluation to
+	// policies which omit the min or max values. A policy which explicitly
+	// sets a value to zero keeps it. A value of zero means no default is
+	// applied, in which case file policies must set max.
+	DefaultMin int64 `hcl:"default_min,optional"`
+	DefaultMax int64 `hcl:"default_max,optional"`
+
+	// HTTPAddress is the URL of a remote HTTP endpoint which lists the IDs of
+	// the scaling policies to be loaded. Each policy is read from the same
+	// URL suffixed with the policy ID.
+	HTTPAddress string `hcl:"http_address,optional"`
+
+	// HTTPHeaders are added to every request made to HTTPAddress, which
+	// allows setting authentication headers.
+	HTTPHeaders map[string]string `hcl:"http_headers,optional"`
+
+	// HTTPPollInterval is the interval at which HTTPAddress is polled for
+	// changes to the list of policies and the policies themselves.
+	HTTPPollInterval    time.Duration
+	HTTPPollIntervalHCL string `hcl:"http_poll_interval,optional" json:"-"`
+
+	// ConsulAddress is the URL of the Consul HTTP API which scaling policies
+	// are read from. Each key directly under ConsulPrefix in the KV store is
+	// loaded as a policy.
+	ConsulAddress string `hcl:"consul_address,optional"`
+
+	// ConsulPrefix is the KV prefix which holds the scaling policies.
+	// Defaults to nomad-autoscaler/policies.
+	ConsulPrefix string `hcl:"consul_prefix,optional"`
+
+	// ConsulToken is the ACL token used to read the scaling policies.
+	ConsulToken string `hcl:"consul_token,optional"`
+
+	// ConsulCACert, ConsulClientCert and ConsulClientKey are paths to the
+	// PEM-encoded files used for TLS communication with Consul.
+	ConsulCACert     string `hcl:"consul_ca_cert,optional"`
+	ConsulClientCert string `hcl:"consul_client_cert,optional"`
+	ConsulClientKey  string `hcl:"consul_client_key,optional"`
+
+	// ConsulTLSServerName, if set, is used to set the SNI host when
+	// connecting to Consul.
+	ConsulTLSServerName string `hcl:"consul_tls_server_name,optional"`
+
+	// ConsulSkipVerify disables verification of the Consul certificate.
+	ConsulSkipVerify bool `hcl:"consul_skip_verify,optional"`
+
+	// ConflictMode is how policies from different sources which share an ID
+	// or a target are handled. With "first-wins" the policy seen first is
+	// used, with "error" none of the conflicting policies are used, and with
+	// "source-priority" the policy from the source listed first in
+	// SourcePriority is used. Defaults to first-wins.
+	ConflictMode string `hcl:"conflict_mode,optional"`
+
+	// SourcePriority orders the policy sources by priority, highest first,
+	// when ConflictMode is source-priority. Sources which are not listed
+	// have the lowest priority.
+	SourcePriority []string `hcl:"source_priority,optional"`
 }
 
 // PolicyEval holds the configuration related to the policy evaluation process.
@@ -271,6 +644,31 @@ type PolicyEval struct {
 
 	// Workers hold the number of workers to initialize for each queue.
 	Workers map[string]int `hcl:"workers,optional"`
+
+	// MultipleActions controls how a worker behaves when more than one check
+	// within a policy produces a scaling action. Supported values are
+	// "conservative", "last" and "error", and defaults to "conservative".
+	//
+	// The conservative mode picks the safest action, preferring a scale up
+	// over no change over a scale down. Between actions in the same direction
+	// the highest count is picked, so capacity is never removed while any
+	// check asks for it. The last mode picks the action of the last check
+	// which returned a result, and the error mode fails the evaluation when
+	// the actions do not agree.
+	MultipleActions string `hcl:"multiple_actions,optional"`
+
+	// QueryCoalesceWindow is the period for which the result of an APM query
+	// is reused by other checks running the same query against the same
+	// source. Identical queries which are in-flight at the same time are
+	// always coalesced.
+	QueryCoalesceWindow    time.Duration
+	QueryCoalesceWindowHCL string `hcl:"query_coalesce_window,optional" json:"-"`
+
+	// QueryCacheTTL is the period for which the result of an APM query is
+	// reused by subsequent evaluations of the same query, rather than querying
+	// the APM again. A value of zero disables the cache.
+	QueryCacheTTL    time.Duration
+	QueryCacheTTLHCL string `hcl:"query_cache_ttl,optional" json:"-"`
 }
 
 const (
@@ -299,6 +697,18 @@ const (
 	// Nomad API calls.
 	defaultNomadRegion = "global"
 
+	// defaultNomadRequestTimeout is the default time limit of a single Nomad
+	// API request.
+	defaultNomadRequestTimeout = 30 * time.Second
+
+	// defaultNomadRetries is the default number of times a failed Nomad API
+	// read request is retried.
+	defaultNomadRetries = 2
+
+	// defaultNomadRetryBackoff is the default time to wait before the first
+	// retry of a failed Nomad API request.
+	defaultNomadRetryBackoff = 1 * time.Second
+
 	// defaultPolicyCooldown is the default time duration applied to policies
 	// which do not explicitly configure a cooldown.
 	defaultPolicyCooldown = 5 * time.Minute
@@ -307,6 +717,10 @@ const (
 	// collection interval.
 	defaultTelemetryCollectionInterval = 1 * time.Second
 
+	// defaultPolicyHTTPPollInterval is the default interval at which the HTTP
+	// policy source polls for changes.
+	defaultPolicyHTTPPollInterval = 30 * time.Second
+
 	// defaultPolicyWorkerDeliveryLimit is the default value for the delivery
 	// limit count for the policy eval broker.
 	defaultPolicyEvalDeliveryLimit = 1
@@ -314,6 +728,64 @@ const (
 	// defaultPolicyWorkerAckTimeout is the default time limit that a policy
 	// eval must be ACK'd.
 	defaultPolicyEvalAckTimeout = 5 * time.Minute
+
+	// defaultAlertingFailureThreshold is the default number of consecutive
+	// policy evaluation failures which trigger an alert.
+	defaultAlertingFailureThreshold = 3
+
+	// defaultAlertingSeverity is the default severity of the alerts sent on
+	// repeated policy evaluation failures.
+	defaultAlertingSeverity = "critical"
+
+	// defaultCapacityBudgetEnforcement is the default handling of scale ups
+	// which exceed the capacity budget.
+	defaultCapacityBudgetEnforcement = "deny"
+
+	// defaultLeaderElectionConsulAddress is the default address of the Consul
+	// agent used for leader election.
+	defaultLeaderElectionConsulAddress = "http://127.0.0.1:8500"
+
+	// defaultLeaderElectionKey is the default Consul KV key used as the
+	// leadership lock.
+	defaultLeaderElectionKey = "nomad-autoscaler/leader"
+
+	// defaultLeaderElectionSessionTTL is the default TTL of the Consul session
+	// holding the leadership lock.
+	defaultLeaderElectionSessionTTL = 15 * time.Second
+
+	// defaultScaleHooksTimeout is the default time a scale hook command is
+	// allowed to run.
+	defaultScaleHooksTimeout = 30 * time.Second
+
+	// defaultScaleThrottleBurst is the default number of scaling actions
+	// which can be performed at once when the scale throttle is enabled.
+	defaultScaleThrottleBurst = 1
+
+	// defaultDecisionEventsNATSSubject is the default NATS subject which
+	// decision events are published to.
+	defaultDecisionEventsNATSSubject = "nomad-autoscaler.decisions"
+
+	// defaultDecisionEventsBufferSize is the default number of decision
+	// events buffered for each sink.
+	defaultDecisionEventsBufferSize = 1024
+
+	// defaultMaintenanceCacheTTL is the default time the maintenance
+	// condition is cached for.
+	defaultMaintenanceCacheTTL = 10 * time.Second
+
+	// defaultPolicyConflictMode is the default mode used to handle policies
+	// from different sources which share an ID or a target.
+	defaultPolicyConflictMode = "first-wins"
+
+	// defaultMaintenanceOnError is the default condition used when the
+	// maintenance condition cannot be read.
+	defaultMaintenanceOnError = "hold"
+
+	// defaultPolicyEvalMultipleActions is the default behaviour used when
+	// multiple checks within a policy produce a scaling action. The
+	// conservative mode picks the safest action using the sdk preemption
+	// rules.
+	defaultPolicyEvalMultipleActions = "conservative"
 )
 
 var defaultPolicyEvalWorkers = map[string]int{
@@ -339,20 +811,52 @@ func Default() (*Agent, error) {
 			BindPort:    defaultHTTPBindPort,
 		},
 		Nomad: &Nomad{
-			Address: defaultNomadAddress,
-			Region:  defaultNomadRegion,
+			Address:        defaultNomadAddress,
+			Region:         defaultNomadRegion,
+			RequestTimeout: defaultNomadRequestTimeout,
+			Retries:        defaultNomadRetries,
+			RetryBackoff:   defaultNomadRetryBackoff,
 		},
 		Telemetry: &Telemetry{
 			CollectionInterval: defaultTelemetryCollectionInterval,
 		},
+		Alerting: &Alerting{
+			FailureThreshold: defaultAlertingFailureThreshold,
+			Severity:         defaultAlertingSeverity,
+		},
+		CapacityBudget: &CapacityBudget{
+			Enforcement: defaultCapacityBudgetEnforcement,
+		},
+		ScaleHooks: &ScaleHooks{
+			Timeout: defaultScaleHooksTimeout,
+		},
+		ScaleThrottle: &ScaleThrottle{
+			Burst: defaultScaleThrottleBurst,
+		},
+		DecisionEvents: &DecisionEvents{
+			NATSSubject: defaultDecisionEventsNATSSubject,
+			BufferSize:  defaultDecisionEventsBufferSize,
+		},
+		Maintenance: &Maintenance{
+			CacheTTL: defaultMaintenanceCacheTTL,
+			OnError:  defaultMaintenanceOnError,
+		},
+		LeaderElection: &LeaderElection{
+			ConsulAddress: defaultLeaderElectionConsulAddress,
+			Key:           defaultLeaderElectionKey,
+			SessionTTL:    defaultLeaderElectionSessionTTL,
+		},
 		Policy: &Policy{
 			DefaultCooldown:           defaultPolicyCooldown,
 			DefaultEvaluationInterval: defaultEvaluationInterval,
+			HTTPPollInterval:          defaultPolicyHTTPPollInterval,
+			ConflictMode:              defaultPolicyConflictMode,
 		},
 		PolicyEval: &PolicyEval{
-			DeliveryLimit: defaultPolicyEvalDeliveryLimit,
-			AckTimeout:    defaultPolicyEvalAckTimeout,
-			Workers:       defaultPolicyEvalWorkers,
+			DeliveryLimit:   defaultPolicyEvalDeliveryLimit,
+			AckTimeout:      defaultPolicyEvalAckTimeout,
+			Workers:         defaultPolicyEvalWorkers,
+			MultipleActions: defaultPolicyEvalMultipleActions,
 		},
 		APMs:       []*Plugin{{Name: plugins.InternalAPMNomad, Driver: plugins.InternalAPMNomad}},
 		Strategies: []*Plugin{{Name: plugins.InternalStrategyTargetValue, Driver: plugins.InternalStrategyTargetValue}},
@@ -360,7 +864,13 @@ func Default() (*Agent, error) {
 	}, nil
 }
 
-// Merge is used to merge two agent configurations.
+// Merge is used to merge two agent configurations, with the values set in b
+// taking precedence. Scalar values set in b override those of a, while unset
+// values in b leave those of a in place. Maps, such as headers and plugin
+// config, are merged key by key. Lists of values, such as the target access
+// patterns and metrics CIDRs, are appended to, skipping duplicates, whereas
+// commands and plugin args are replaced as a whole. Plugins are merged by
+// name. The result is deterministic for the same inputs.
 func (a *Agent) Merge(b *Agent) *Agent {
 	result := *a
 
@@ -385,6 +895,38 @@ func (a *Agent) Merge(b *Agent) *Agent {
 		result.Telemetry = result.Telemetry.merge(b.Telemetry)
 	}
 
+	if b.Alerting != nil {
+		result.Alerting = result.Alerting.merge(b.Alerting)
+	}
+
+	if b.LeaderElection != nil {
+		result.LeaderElection = result.LeaderElection.merge(b.LeaderElection)
+	}
+
+	if b.CapacityBudget != nil {
+		result.CapacityBudget = result.CapacityBudget.merge(b.CapacityBudget)
+	}
+
+	if b.TargetAccess != nil {
+		result.TargetAccess = result.TargetAccess.merge(b.TargetAccess)
+	}
+
+	if b.ScaleHooks != nil {
+		result.ScaleHooks = result.ScaleHooks.merge(b.ScaleHooks)
+	}
+
+	if b.ScaleThrottle != nil {
+		result.ScaleThrottle = result.ScaleThrottle.merge(b.ScaleThrottle)
+	}
+
+	if b.DecisionEvents != nil {
+		result.DecisionEvents = result.DecisionEvents.merge(b.DecisionEvents)
+	}
+
+	if b.Maintenance != nil {
+		result.Maintenance = result.Maintenance.merge(b.Maintenance)
+	}
+
 	if b.Policy != nil {
 		result.Policy = result.Policy.merge(b.Policy)
 	}
@@ -426,9 +968,67 @@ func (a *Agent) Merge(b *Agent) *Agent {
 	return &result
 }
 
+// Validate checks the agent configuration for errors. All problems found are
+// returned together within a multierror, rather than stopping at the first.
 func (a *Agent) Validate() error {
 	var result *multierror.Error
 
+	if a.LogLevel != "" && hclog.LevelFromString(a.LogLevel) == hclog.NoLevel {
+		result = multierror.Append(result, fmt.Errorf("log_level %q is not a valid log level", a.LogLevel))
+	}
+
+	if a.HTTP != nil {
+		result = multierror.Append(result, a.HTTP.validate())
+	}
+
+	if a.Nomad != nil {
+		result = multierror.Append(result, a.Nomad.validate())
+	}
+
+	if a.Telemetry != nil {
+		result = multierror.Append(result, a.Telemetry.validate())
+	}
+
+	if a.Alerting != nil {
+		result = multierror.Append(result, a.Alerting.validate())
+	}
+
+	if a.LeaderElection != nil {
+		result = multierror.Append(result, a.LeaderElection.validate())
+	}
+
+	if a.CapacityBudget != nil {
+		result = multierror.Append(result, a.CapacityBudget.validate())
+	}
+
+	if a.TargetAccess != nil {
+		result = multierror.Append(result, a.TargetAccess.validate())
+	}
+
+	if a.ScaleHooks != nil {
+		result = multierror.Append(result, a.ScaleHooks.validate())
+	}
+
+	if a.ScaleThrottle != nil {
+		result = multierror.Append(result, a.ScaleThrottle.validate())
+	}
+
+	if a.DecisionEvents != nil {
+		result = multierror.Append(result, a.DecisionEvents.validate())
+	}
+
+	if a.Maintenance != nil {
+		result = multierror.Append(result, a.Maintenance.validate())
+	}
+
+	result = multierror.Append(result, validatePlugins("apm", a.APMs))
+	result = multierror.Append(result, validatePlugins("target", a.Targets))
+	result = multierror.Append(result, validatePlugins("strategy", a.Strategies))
+
+	if a.Policy != nil {
+		result = multierror.Append(result, a.Policy.validate())
+	}
+
 	if a.PolicyEval != nil {
 		result = multierror.Append(result, a.PolicyEval.validate())
 	}
@@ -436,6 +1036,36 @@ func (a *Agent) Validate() error {
 	return result.ErrorOrNil()
 }
 
+func (h *HTTP) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "http ->"
+
+	if h.BindPort < 0 || h.BindPort > 65535 {
+		result = multierror.Append(result, fmt.Errorf("bind_port must be between 0 and 65535"))
+	}
+
+	if (h.TLSCertFile == "") != (h.TLSKeyFile == "") {
+		result = multierror.Append(result, fmt.Errorf("tls_cert_file and tls_key_file must be set together"))
+	}
+	if h.TLSCAFile != "" && h.TLSCertFile == "" {
+		result = multierror.Append(result, fmt.Errorf("tls_ca_file requires tls_cert_file and tls_key_file"))
+	}
+
+	for _, cidr := range h.MetricsAllowedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			result = multierror.Append(result, fmt.Errorf("invalid metrics_allowed_cidrs entry %q: %v", cidr, err))
+		}
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
 func (h *HTTP) merge(b *HTTP) *HTTP {
 	result := *h
 
@@ -445,10 +1075,79 @@ func (h *HTTP) merge(b *HTTP) *HTTP {
 	if b.BindPort != 0 {
 		result.BindPort = b.BindPort
 	}
+	if b.EnableDebug {
+		result.EnableDebug = b.EnableDebug
+	}
+	if b.AuthToken != "" {
+		result.AuthToken = b.AuthToken
+	}
+	if b.TLSCertFile != "" {
+		result.TLSCertFile = b.TLSCertFile
+	}
+	if b.TLSKeyFile != "" {
+		result.TLSKeyFile = b.TLSKeyFile
+	}
+	if b.TLSCAFile != "" {
+		result.TLSCAFile = b.TLSCAFile
+	}
+	if len(b.MetricsAllowedCIDRs) > 0 {
+		result.MetricsAllowedCIDRs = mergeStringSlices(h.MetricsAllowedCIDRs, b.MetricsAllowedCIDRs)
+	}
 
 	return &result
 }
 
+func (n *Nomad) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "nomad ->"
+
+	if n.Address != "" {
+		if err := validateURL(n.Address, "http", "https", "unix"); err != nil {
+			result = multierror.Append(result, fmt.Errorf("address is not valid: %v", err))
+		}
+	}
+
+	if n.RequestTimeout < 0 {
+		result = multierror.Append(result, fmt.Errorf("request_timeout must not be negative"))
+	}
+
+	if n.Retries < 0 {
+		result = multierror.Append(result, fmt.Errorf("retries must not be negative"))
+	}
+
+	if n.RetryBackoff < 0 {
+		result = multierror.Append(result, fmt.Errorf("retry_backoff must not be negative"))
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+// validateURL checks that the address is an absolute URL using one of the
+// supported schemes.
+func validateURL(address string, schemes ...string) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range schemes {
+		if u.Scheme != s {
+			continue
+		}
+		if u.Host == "" && s != "unix" {
+			return fmt.Errorf("missing host in %q", address)
+		}
+		return nil
+	}
+	return fmt.Errorf("scheme of %q must be one of %s", address, strings.Join(schemes, ", "))
+}
+
 func (n *Nomad) merge(b *Nomad) *Nomad {
 	result := *n
 
@@ -485,10 +1184,58 @@ func (n *Nomad) merge(b *Nomad) *Nomad {
 	if b.SkipVerify {
 		result.SkipVerify = b.SkipVerify
 	}
+	if b.RequestTimeout != 0 {
+		result.RequestTimeout = b.RequestTimeout
+	}
+	if b.RetriesPtr != nil {
+		result.RetriesPtr = b.RetriesPtr
+		result.Retries = b.Retries
+	}
+	if b.RetryBackoff != 0 {
+		result.RetryBackoff = b.RetryBackoff
+	}
 
 	return &result
 }
 
+func (t *Telemetry) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "telemetry ->"
+
+	if t.CollectionInterval < 0 {
+		result = multierror.Append(result, fmt.Errorf("collection_interval must not be negative"))
+	}
+
+	if t.PrometheusRetentionTime < 0 {
+		result = multierror.Append(result, fmt.Errorf("prometheus_retention_time must not be negative"))
+	}
+
+	addrs := []struct {
+		key  string
+		addr string
+	}{
+		{"statsite_address", t.StatsiteAddr},
+		{"statsd_address", t.StatsdAddr},
+		{"dogstatsd_address", t.DogStatsDAddr},
+	}
+	for _, a := range addrs {
+		if a.addr == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(a.addr); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%s is not a valid address: %v", a.key, err))
+		}
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
 func (t *Telemetry) merge(b *Telemetry) *Telemetry {
 	result := *t
 
@@ -502,7 +1249,7 @@ func (t *Telemetry) merge(b *Telemetry) *Telemetry {
 		result.DogStatsDAddr = b.DogStatsDAddr
 	}
 	if b.DogStatsDTags != nil {
-		result.DogStatsDTags = b.DogStatsDTags
+		result.DogStatsDTags = mergeStringSlices(t.DogStatsDTags, b.DogStatsDTags)
 	}
 	if b.PrometheusMetrics {
 		result.PrometheusMetrics = b.PrometheusMetrics
@@ -555,69 +1302,688 @@ func (t *Telemetry) merge(b *Telemetry) *Telemetry {
 	if b.CirconusBrokerSelectTag != "" {
 		result.CirconusBrokerSelectTag = b.CirconusBrokerSelectTag
 	}
-
-	return &result
-}
-
-func (p *Plugin) merge(o *Plugin) *Plugin {
-	m := *p
-
-	if len(o.Name) != 0 {
-		m.Name = o.Name
-	}
-	if len(o.Args) != 0 {
-		m.Args = o.Args
+	if b.OTLPEndpoint != "" {
+		result.OTLPEndpoint = b.OTLPEndpoint
 	}
-	if len(o.Config) != 0 {
-		m.Config = o.Config
+	if b.OTLPInsecure {
+		result.OTLPInsecure = b.OTLPInsecure
 	}
 
-	return m.copy()
+	return &result
 }
 
-func (p *Plugin) copy() *Plugin {
-	c := *p
-	if i, err := copystructure.Copy(p.Config); err != nil {
-		panic(err.Error())
-	} else {
-		c.Config = i.(map[string]string)
+func (al *Alerting) merge(b *Alerting) *Alerting {
+	if al == nil {
+		return b
 	}
-	return &c
-}
 
-func (p *Policy) merge(b *Policy) *Policy {
-	result := *p
+	result := *al
 
-	if b.Dir != "" {
-		result.Dir = b.Dir
+	if b.WebhookAddress != "" {
+		result.WebhookAddress = b.WebhookAddress
 	}
-	if b.DefaultCooldown != 0 {
-		result.DefaultCooldown = b.DefaultCooldown
+	if b.WebhookHeaders != nil {
+		result.WebhookHeaders = mergeStringMaps(al.WebhookHeaders, b.WebhookHeaders)
 	}
-	if b.DefaultEvaluationInterval != 0 {
-		result.DefaultEvaluationInterval = b.DefaultEvaluationInterval
+	if b.FailureThreshold != 0 {
+		result.FailureThreshold = b.FailureThreshold
+	}
+	if b.Severity != "" {
+		result.Severity = b.Severity
 	}
 	return &result
 }
 
-func (pw *PolicyEval) merge(in *PolicyEval) *PolicyEval {
-	result := *pw
-
-	if in.AckTimeout != 0 {
-		result.AckTimeout = in.AckTimeout
-	}
+func (al *Alerting) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "alerting ->"
 
-	if in.DeliveryLimitPtr != nil {
-		result.DeliveryLimitPtr = in.DeliveryLimitPtr
-		result.DeliveryLimit = in.DeliveryLimit
+	if al.FailureThreshold < 0 {
+		result = multierror.Append(result, fmt.Errorf("failure_threshold must not be negative"))
 	}
 
-	for k, v := range in.Workers {
-		result.Workers[k] = v
+	if al.WebhookAddress != "" {
+		if err := validateURL(al.WebhookAddress, "http", "https"); err != nil {
+			result = multierror.Append(result, fmt.Errorf("webhook_address is not valid: %v", err))
+		}
 	}
 
-	if in.EvaluateAfter != 0 {
-		result.EvaluateAfter = in.EvaluateAfter
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+func (c *CapacityBudget) merge(b *CapacityBudget) *CapacityBudget {
+	if c == nil {
+		return b
+	}
+
+	result := *c
+
+	if b.MaxCount != 0 {
+		result.MaxCount = b.MaxCount
+	}
+	if b.Enforcement != "" {
+		result.Enforcement = b.Enforcement
+	}
+	return &result
+}
+
+func (c *CapacityBudget) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "capacity_budget ->"
+
+	if c.MaxCount < 0 {
+		result = multierror.Append(result, fmt.Errorf("max_count must not be negative"))
+	}
+
+	switch c.Enforcement {
+	case "", "deny", "reduce":
+	default:
+		result = multierror.Append(result, fmt.Errorf("enforcement must be one of deny or reduce"))
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+func (t *TargetAccess) merge(b *TargetAccess) *TargetAccess {
+	if t == nil {
+		return b
+	}
+
+	result := *t
+
+	if len(b.Allow) > 0 {
+		result.Allow = mergeStringSlices(t.Allow, b.Allow)
+	}
+	if len(b.Deny) > 0 {
+		result.Deny = mergeStringSlices(t.Deny, b.Deny)
+	}
+	return &result
+}
+
+func (t *TargetAccess) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "target_access ->"
+
+	for _, pattern := range append(append([]string(nil), t.Allow...), t.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			result = multierror.Append(result, fmt.Errorf("invalid pattern %q: %v", pattern, err))
+		}
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+func (h *ScaleHooks) merge(b *ScaleHooks) *ScaleHooks {
+	if h == nil {
+		return b
+	}
+
+	result := *h
+
+	if len(b.PreScale) > 0 {
+		result.PreScale = append([]string(nil), b.PreScale...)
+	}
+	if len(b.PostScale) > 0 {
+		result.PostScale = append([]string(nil), b.PostScale...)
+	}
+	if b.Timeout != 0 {
+		result.Timeout = b.Timeout
+	}
+	return &result
+}
+
+func (h *ScaleHooks) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "scale_hooks ->"
+
+	if len(h.PreScale) > 0 && h.PreScale[0] == "" {
+		result = multierror.Append(result, fmt.Errorf("pre_scale command must not be empty"))
+	}
+	if len(h.PostScale) > 0 && h.PostScale[0] == "" {
+		result = multierror.Append(result, fmt.Errorf("post_scale command must not be empty"))
+	}
+	if h.Timeout < 0 {
+		result = multierror.Append(result, fmt.Errorf("timeout must not be negative"))
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+func (t *ScaleThrottle) merge(b *ScaleThrottle) *ScaleThrottle {
+	if t == nil {
+		return b
+	}
+
+	result := *t
+
+	if b.Rate != 0 {
+		result.Rate = b.Rate
+	}
+	if b.Burst != 0 {
+		result.Burst = b.Burst
+	}
+	return &result
+}
+
+func (t *ScaleThrottle) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "scale_throttle ->"
+
+	if t.Rate < 0 {
+		result = multierror.Append(result, fmt.Errorf("rate must not be negative"))
+	}
+	if t.Burst < 0 {
+		result = multierror.Append(result, fmt.Errorf("burst must not be negative"))
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+func (d *DecisionEvents) merge(b *DecisionEvents) *DecisionEvents {
+	if d == nil {
+		return b
+	}
+
+	result := *d
+
+	if b.WebhookAddress != "" {
+		result.WebhookAddress = b.WebhookAddress
+	}
+	if b.WebhookHeaders != nil {
+		result.WebhookHeaders = mergeStringMaps(d.WebhookHeaders, b.WebhookHeaders)
+	}
+	if b.NATSAddress != "" {
+		result.NATSAddress = b.NATSAddress
+	}
+	if b.NATSSubject != "" {
+		result.NATSSubject = b.NATSSubject
+	}
+	if b.BufferSize != 0 {
+		result.BufferSize = b.BufferSize
+	}
+	return &result
+}
+
+func (d *DecisionEvents) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "decision_events ->"
+
+	if d.WebhookAddress != "" {
+		if err := validateURL(d.WebhookAddress, "http", "https"); err != nil {
+			result = multierror.Append(result, fmt.Errorf("webhook_address is not valid: %v", err))
+		}
+	}
+	if d.NATSAddress != "" {
+		if err := validateURL(d.NATSAddress, "nats"); err != nil {
+			result = multierror.Append(result, fmt.Errorf("nats_address is not valid: %v", err))
+		}
+		if d.NATSSubject == "" || strings.ContainsAny(d.NATSSubject, " \t\r\n") {
+			result = multierror.Append(result, fmt.Errorf("nats_subject %q is not valid", d.NATSSubject))
+		}
+	}
+	if d.BufferSize < 0 {
+		result = multierror.Append(result, fmt.Errorf("buffer_size must not be negative"))
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+func (m *Maintenance) merge(b *Maintenance) *Maintenance {
+	if m == nil {
+		return b
+	}
+
+	result := *m
+
+	if b.HTTPAddress != "" {
+		result.HTTPAddress = b.HTTPAddress
+	}
+	if b.HTTPHeaders != nil {
+		result.HTTPHeaders = mergeStringMaps(m.HTTPHeaders, b.HTTPHeaders)
+	}
+	if b.FilePath != "" {
+		result.FilePath = b.FilePath
+	}
+	if b.CacheTTL != 0 {
+		result.CacheTTL = b.CacheTTL
+	}
+	if b.OnError != "" {
+		result.OnError = b.OnError
+	}
+	return &result
+}
+
+func (m *Maintenance) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "maintenance ->"
+
+	if m.HTTPAddress != "" {
+		if err := validateURL(m.HTTPAddress, "http", "https"); err != nil {
+			result = multierror.Append(result, fmt.Errorf("http_address is not valid: %v", err))
+		}
+		if m.FilePath != "" {
+			result = multierror.Append(result, fmt.Errorf("only one of http_address and file_path can be set"))
+		}
+	}
+	if m.CacheTTL < 0 {
+		result = multierror.Append(result, fmt.Errorf("cache_ttl must not be negative"))
+	}
+	switch m.OnError {
+	case "", "hold", "allow":
+	default:
+		result = multierror.Append(result, fmt.Errorf("on_error must be one of hold or allow"))
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+func (l *LeaderElection) merge(b *LeaderElection) *LeaderElection {
+	if l == nil {
+		return b
+	}
+
+	result := *l
+
+	if b.Enabled {
+		result.Enabled = true
+	}
+	if b.ConsulAddress != "" {
+		result.ConsulAddress = b.ConsulAddress
+	}
+	if b.ConsulToken != "" {
+		result.ConsulToken = b.ConsulToken
+	}
+	if b.Key != "" {
+		result.Key = b.Key
+	}
+	if b.SessionTTL != 0 {
+		result.SessionTTL = b.SessionTTL
+	}
+	return &result
+}
+
+func (l *LeaderElection) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "leader_election ->"
+
+	if l.Enabled {
+		if err := validateURL(l.ConsulAddress, "http", "https"); err != nil {
+			result = multierror.Append(result, fmt.Errorf("consul_address is not valid: %v", err))
+		}
+		if l.Key == "" {
+			result = multierror.Append(result, fmt.Errorf("key must not be empty"))
+		}
+	}
+
+	// Consul only accepts session TTLs between 10s and 24h.
+	if l.SessionTTL != 0 && (l.SessionTTL < 10*time.Second || l.SessionTTL > 24*time.Hour) {
+		result = multierror.Append(result, fmt.Errorf("session_ttl must be between 10s and 24h"))
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+// reservedPluginEnv are the environment variables set when launching external
+// plugins to perform the plugin handshake, so they cannot be configured.
+var reservedPluginEnv = map[string]bool{
+	plugins.Handshake.MagicCookieKey: true,
+	"PLUGIN_PROTOCOL_VERSIONS":       true,
+	"PLUGIN_MIN_PORT":                true,
+	"PLUGIN_MAX_PORT":                true,
+	"PLUGIN_CLIENT_CERT":             true,
+}
+
+// validatePlugins validates the configuration of all the plugins of a type.
+func validatePlugins(pluginType string, cfgs []*Plugin) *multierror.Error {
+	var result *multierror.Error
+	prefix := fmt.Sprintf("%s ->", pluginType)
+
+	seen := make(map[string]bool, len(cfgs))
+	for _, p := range cfgs {
+		if p.Name == "" {
+			result = multierror.Append(result, fmt.Errorf("plugin name must not be empty"))
+		} else if seen[p.Name] {
+			result = multierror.Append(result, fmt.Errorf("plugin %q is defined more than once", p.Name))
+		}
+		seen[p.Name] = true
+
+		if p.Driver == "" {
+			result = multierror.Append(result, fmt.Errorf("plugin %q driver must not be empty", p.Name))
+		}
+
+		if p.SHA256 != "" {
+			if b, err := hex.DecodeString(p.SHA256); err != nil || len(b) != sha256.Size {
+				result = multierror.Append(result, fmt.Errorf("plugin %q sha256 must be a hex encoded SHA-256 checksum", p.Name))
+			}
+		}
+
+		if p.MaxLifetime < 0 {
+			result = multierror.Append(result, fmt.Errorf("plugin %q max_lifetime must not be negative", p.Name))
+		}
+
+		if p.SetConfigRetries != nil && *p.SetConfigRetries < 0 {
+			result = multierror.Append(result, fmt.Errorf("plugin %q set_config_retries must not be negative", p.Name))
+		}
+
+		if p.SetConfigRetryBackoff < 0 {
+			result = multierror.Append(result, fmt.Errorf("plugin %q set_config_retry_backoff must not be negative", p.Name))
+		}
+
+		for _, arg := range p.Args {
+			if strings.ContainsRune(arg, 0) {
+				result = multierror.Append(result, fmt.Errorf("plugin %q args must not contain NUL characters", p.Name))
+				break
+			}
+		}
+
+		// Sort the variables so the errors are reported deterministically.
+		names := make([]string, 0, len(p.Env))
+		for k := range p.Env {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		for _, k := range names {
+			switch {
+			case k == "" || strings.ContainsAny(k, "=\x00"):
+				result = multierror.Append(result, fmt.Errorf("plugin %q env variable name %q is invalid", p.Name, k))
+			case reservedPluginEnv[k]:
+				result = multierror.Append(result, fmt.Errorf("plugin %q env variable %q is reserved for the plugin handshake", p.Name, k))
+			case strings.ContainsRune(p.Env[k], 0):
+				result = multierror.Append(result, fmt.Errorf("plugin %q env variable %q must not contain NUL characters", p.Name, k))
+			}
+		}
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+func (p *Plugin) merge(o *Plugin) *Plugin {
+	m := *p
+
+	if len(o.Name) != 0 {
+		m.Name = o.Name
+	}
+	if len(o.Args) != 0 {
+		m.Args = o.Args
+	}
+	if len(o.Config) != 0 {
+		m.Config = mergeStringMaps(p.Config, o.Config)
+	}
+	if len(o.Env) != 0 {
+		m.Env = mergeStringMaps(p.Env, o.Env)
+	}
+	if o.SHA256 != "" {
+		m.SHA256 = o.SHA256
+	}
+	if o.MaxLifetime != 0 {
+		m.MaxLifetime = o.MaxLifetime
+	}
+	if o.MaxLifetimeHCL != "" {
+		m.MaxLifetimeHCL = o.MaxLifetimeHCL
+	}
+	if o.SetConfigRetries != nil {
+		m.SetConfigRetries = o.SetConfigRetries
+	}
+	if o.SetConfigRetryBackoff != 0 {
+		m.SetConfigRetryBackoff = o.SetConfigRetryBackoff
+	}
+	if o.SetConfigRetryBackoffHCL != "" {
+		m.SetConfigRetryBackoffHCL = o.SetConfigRetryBackoffHCL
+	}
+	if o.Optional {
+		m.Optional = o.Optional
+	}
+
+	return m.copy()
+}
+
+func (p *Plugin) copy() *Plugin {
+	c := *p
+	if i, err := copystructure.Copy(p.Config); err != nil {
+		panic(err.Error())
+	} else {
+		c.Config = i.(map[string]string)
+	}
+	if i, err := copystructure.Copy(p.Env); err != nil {
+		panic(err.Error())
+	} else {
+		c.Env = i.(map[string]string)
+	}
+	return &c
+}
+
+func (p *Policy) merge(b *Policy) *Policy {
+	result := *p
+
+	if b.Dir != "" {
+		result.Dir = b.Dir
+	}
+	if b.DefaultCooldown != 0 {
+		result.DefaultCooldown = b.DefaultCooldown
+	}
+	if b.DefaultEvaluationInterval != 0 {
+		result.DefaultEvaluationInterval = b.DefaultEvaluationInterval
+	}
+	if b.DefaultWarmupPeriod != 0 {
+		result.DefaultWarmupPeriod = b.DefaultWarmupPeriod
+	}
+	if b.MinEvaluationInterval != 0 {
+		result.MinEvaluationInterval = b.MinEvaluationInterval
+	}
+	if b.RemovalGracePeriod != 0 {
+		result.RemovalGracePeriod = b.RemovalGracePeriod
+	}
+	if b.DefaultMin != 0 {
+		result.DefaultMin = b.DefaultMin
+	}
+	if b.DefaultMax != 0 {
+		result.DefaultMax = b.DefaultMax
+	}
+	if b.HTTPAddress != "" {
+		result.HTTPAddress = b.HTTPAddress
+	}
+	if b.HTTPHeaders != nil {
+		result.HTTPHeaders = mergeStringMaps(p.HTTPHeaders, b.HTTPHeaders)
+	}
+	if b.HTTPPollInterval != 0 {
+		result.HTTPPollInterval = b.HTTPPollInterval
+	}
+	if b.ConsulAddress != "" {
+		result.ConsulAddress = b.ConsulAddress
+	}
+	if b.ConsulPrefix != "" {
+		result.ConsulPrefix = b.ConsulPrefix
+	}
+	if b.ConsulToken != "" {
+		result.ConsulToken = b.ConsulToken
+	}
+	if b.ConsulCACert != "" {
+		result.ConsulCACert = b.ConsulCACert
+	}
+	if b.ConsulClientCert != "" {
+		result.ConsulClientCert = b.ConsulClientCert
+	}
+	if b.ConsulClientKey != "" {
+		result.ConsulClientKey = b.ConsulClientKey
+	}
+	if b.ConsulTLSServerName != "" {
+		result.ConsulTLSServerName = b.ConsulTLSServerName
+	}
+	if b.ConsulSkipVerify {
+		result.ConsulSkipVerify = b.ConsulSkipVerify
+	}
+	if b.ConflictMode != "" {
+		result.ConflictMode = b.ConflictMode
+	}
+
+	// The priority is an ordered list, so it is replaced rather than merged.
+	if len(b.SourcePriority) > 0 {
+		result.SourcePriority = b.SourcePriority
+	}
+	return &result
+}
+
+func (p *Policy) validate() *multierror.Error {
+	var result *multierror.Error
+	prefix := "policy ->"
+
+	durations := []struct {
+		key string
+		d   time.Duration
+	}{
+		{"default_cooldown", p.DefaultCooldown},
+		{"default_evaluation_interval", p.DefaultEvaluationInterval},
+		{"default_warmup_period", p.DefaultWarmupPeriod},
+		{"min_evaluation_interval", p.MinEvaluationInterval},
+		{"removal_grace_period", p.RemovalGracePeriod},
+		{"http_poll_interval", p.HTTPPollInterval},
+	}
+	for _, d := range durations {
+		if d.d < 0 {
+			result = multierror.Append(result, fmt.Errorf("%s must not be negative", d.key))
+		}
+	}
+
+	if p.HTTPAddress != "" {
+		if err := validateURL(p.HTTPAddress, "http", "https"); err != nil {
+			result = multierror.Append(result, fmt.Errorf("http_address is not valid: %v", err))
+		}
+	}
+
+	if p.ConsulAddress != "" {
+		if err := validateURL(p.ConsulAddress, "http", "https"); err != nil {
+			result = multierror.Append(result, fmt.Errorf("consul_address is not valid: %v", err))
+		}
+	}
+
+	if (p.ConsulClientCert == "") != (p.ConsulClientKey == "") {
+		result = multierror.Append(result, fmt.Errorf("consul_client_cert and consul_client_key must be set together"))
+	}
+
+	switch p.ConflictMode {
+	case "", "first-wins", "error":
+	case "source-priority":
+		if len(p.SourcePriority) == 0 {
+			result = multierror.Append(result, fmt.Errorf("source_priority must be set when conflict_mode is source-priority"))
+		}
+	default:
+		result = multierror.Append(result, fmt.Errorf("conflict_mode must be one of first-wins, error or source-priority"))
+	}
+
+	for _, name := range p.SourcePriority {
+		switch name {
+		case "nomad", "file", "http", "api", "consul":
+		default:
+			result = multierror.Append(result, fmt.Errorf("source_priority contains unknown source %q", name))
+		}
+	}
+
+	if p.DefaultMin < 0 {
+		result = multierror.Append(result, fmt.Errorf("default_min must not be negative"))
+	}
+
+	if p.DefaultMax < 0 {
+		result = multierror.Append(result, fmt.Errorf("default_max must not be negative"))
+	}
+
+	if p.DefaultMin != 0 && p.DefaultMax != 0 && p.DefaultMin > p.DefaultMax {
+		result = multierror.Append(result, fmt.Errorf("default_min must not be greater than default_max"))
+	}
+
+	// Prefix all errors.
+	if result != nil {
+		for i, err := range result.Errors {
+			result.Errors[i] = multierror.Prefix(err, prefix)
+		}
+	}
+	return result
+}
+
+func (pw *PolicyEval) merge(in *PolicyEval) *PolicyEval {
+	result := *pw
+
+	if in.AckTimeout != 0 {
+		result.AckTimeout = in.AckTimeout
+	}
+
+	if in.DeliveryLimitPtr != nil {
+		result.DeliveryLimitPtr = in.DeliveryLimitPtr
+		result.DeliveryLimit = in.DeliveryLimit
+	}
+
+	for k, v := range in.Workers {
+		result.Workers[k] = v
+	}
+
+	if in.EvaluateAfter != 0 {
+		result.EvaluateAfter = in.EvaluateAfter
+	}
+
+	if in.MultipleActions != "" {
+		result.MultipleActions = in.MultipleActions
+	}
+
+	if in.QueryCoalesceWindow != 0 {
+		result.QueryCoalesceWindow = in.QueryCoalesceWindow
+	}
+
+	if in.QueryCacheTTL != 0 {
+		result.QueryCacheTTL = in.QueryCacheTTL
 	}
 
 	return &result
@@ -627,6 +1993,14 @@ func (pw *PolicyEval) validate() *multierror.Error {
 	var result *multierror.Error
 	prefix := "policy_workers ->"
 
+	if pw.AckTimeout < 0 {
+		result = multierror.Append(result, fmt.Errorf("ack_timeout must not be negative"))
+	}
+
+	if pw.EvaluateAfter < 0 {
+		result = multierror.Append(result, fmt.Errorf("evaluate_after must not be negative"))
+	}
+
 	if pw.DeliveryLimitPtr != nil && pw.DeliveryLimit <= 0 {
 		result = multierror.Append(result, fmt.Errorf("delivery_limit must be bigger than 0"))
 	}
@@ -637,6 +2011,20 @@ func (pw *PolicyEval) validate() *multierror.Error {
 		}
 	}
 
+	switch pw.MultipleActions {
+	case "", "conservative", "last", "error":
+	default:
+		result = multierror.Append(result, fmt.Errorf("multiple_actions must be one of conservative, last or error"))
+	}
+
+	if pw.QueryCoalesceWindow < 0 {
+		result = multierror.Append(result, fmt.Errorf("query_coalesce_window must not be negative"))
+	}
+
+	if pw.QueryCacheTTL < 0 {
+		result = multierror.Append(result, fmt.Errorf("query_cache_ttl must not be negative"))
+	}
+
 	// Prefix all errors.
 	if result != nil {
 		for i, err := range result.Errors {
@@ -647,41 +2035,68 @@ func (pw *PolicyEval) validate() *multierror.Error {
 }
 
 // pluginConfigSetMerge merges two sets of plugin configs. For plugins with the
-// same name, the configs are merged.
+// same name, the configs are merged. The plugins of the first set keep their
+// order, followed by the plugins only found in the second set in the order
+// they are declared, so the result does not depend on map iteration.
 func pluginConfigSetMerge(first, second []*Plugin) []*Plugin {
-	findex := make(map[string]*Plugin, len(first))
-	for _, p := range first {
-		findex[p.Name] = p
-	}
-
 	sindex := make(map[string]*Plugin, len(second))
 	for _, p := range second {
 		sindex[p.Name] = p
 	}
 
-	var out []*Plugin
+	out := make([]*Plugin, 0, len(first)+len(second))
+	seen := make(map[string]bool, len(first))
 
 	// Go through the first set and merge any value that exist in both
-	for pluginName, original := range findex {
-		second, ok := sindex[pluginName]
-		if !ok {
+	for _, original := range first {
+		seen[original.Name] = true
+
+		if second, ok := sindex[original.Name]; ok {
+			out = append(out, original.merge(second))
+		} else {
 			out = append(out, original.copy())
-			continue
 		}
-
-		out = append(out, original.merge(second))
 	}
 
 	// Go through the second set and add any value that didn't exist in both
-	for pluginName, plugin := range sindex {
-		_, ok := findex[pluginName]
-		if ok {
+	for _, plugin := range second {
+		if seen[plugin.Name] {
 			continue
 		}
+		seen[plugin.Name] = true
+		out = append(out, plugin.copy())
+	}
+
+	return out
+}
 
-		out = append(out, plugin)
+// mergeStringSlices returns the entries of a followed by the entries of b
+// which are not already present, so merging the same configuration twice does
+// not duplicate entries.
+func mergeStringSlices(a, b []string) []string {
+	out := make([]string, 0, len(a)+len(b))
+	seen := make(map[string]bool, len(a)+len(b))
+
+	for _, v := range append(append([]string(nil), a...), b...) {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
 	}
+	return out
+}
 
+// mergeStringMaps returns a new map holding the entries of a and b, with the
+// value from b used for keys found in both.
+func mergeStringMaps(a, b map[string]string) map[string]string {
+	out := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		out[k] = v
+	}
 	return out
 }
 
@@ -706,6 +2121,60 @@ func parseFile(file string, cfg *Agent) error {
 			}
 			cfg.Policy.DefaultEvaluationInterval = d
 		}
+
+		if cfg.Policy.DefaultWarmupPeriodHCL != "" {
+			d, err := time.ParseDuration(cfg.Policy.DefaultWarmupPeriodHCL)
+			if err != nil {
+				return err
+			}
+			cfg.Policy.DefaultWarmupPeriod = d
+		}
+
+		if cfg.Policy.MinEvaluationIntervalHCL != "" {
+			d, err := time.ParseDuration(cfg.Policy.MinEvaluationIntervalHCL)
+			if err != nil {
+				return err
+			}
+			cfg.Policy.MinEvaluationInterval = d
+		}
+
+		if cfg.Policy.RemovalGracePeriodHCL != "" {
+			d, err := time.ParseDuration(cfg.Policy.RemovalGracePeriodHCL)
+			if err != nil {
+				return err
+			}
+			cfg.Policy.RemovalGracePeriod = d
+		}
+
+		if cfg.Policy.HTTPPollIntervalHCL != "" {
+			d, err := time.ParseDuration(cfg.Policy.HTTPPollIntervalHCL)
+			if err != nil {
+				return err
+			}
+			cfg.Policy.HTTPPollInterval = d
+		}
+	}
+
+	if cfg.Nomad != nil {
+		if cfg.Nomad.RequestTimeoutHCL != "" {
+			d, err := time.ParseDuration(cfg.Nomad.RequestTimeoutHCL)
+			if err != nil {
+				return err
+			}
+			cfg.Nomad.RequestTimeout = d
+		}
+
+		if cfg.Nomad.RetriesPtr != nil {
+			cfg.Nomad.Retries = *cfg.Nomad.RetriesPtr
+		}
+
+		if cfg.Nomad.RetryBackoffHCL != "" {
+			d, err := time.ParseDuration(cfg.Nomad.RetryBackoffHCL)
+			if err != nil {
+				return err
+			}
+			cfg.Nomad.RetryBackoff = d
+		}
 	}
 
 	if cfg.Telemetry != nil {
@@ -725,6 +2194,55 @@ func parseFile(file string, cfg *Agent) error {
 		}
 	}
 
+	if cfg.LeaderElection != nil {
+		if cfg.LeaderElection.SessionTTLHCL != "" {
+			d, err := time.ParseDuration(cfg.LeaderElection.SessionTTLHCL)
+			if err != nil {
+				return err
+			}
+			cfg.LeaderElection.SessionTTL = d
+		}
+	}
+
+	for _, cfgs := range [][]*Plugin{cfg.APMs, cfg.Targets, cfg.Strategies} {
+		for _, p := range cfgs {
+			if p.MaxLifetimeHCL != "" {
+				d, err := time.ParseDuration(p.MaxLifetimeHCL)
+				if err != nil {
+					return err
+				}
+				p.MaxLifetime = d
+			}
+			if p.SetConfigRetryBackoffHCL != "" {
+				d, err := time.ParseDuration(p.SetConfigRetryBackoffHCL)
+				if err != nil {
+					return err
+				}
+				p.SetConfigRetryBackoff = d
+			}
+		}
+	}
+
+	if cfg.ScaleHooks != nil {
+		if cfg.ScaleHooks.TimeoutHCL != "" {
+			d, err := time.ParseDuration(cfg.ScaleHooks.TimeoutHCL)
+			if err != nil {
+				return err
+			}
+			cfg.ScaleHooks.Timeout = d
+		}
+	}
+
+	if cfg.Maintenance != nil {
+		if cfg.Maintenance.CacheTTLHCL != "" {
+			d, err := time.ParseDuration(cfg.Maintenance.CacheTTLHCL)
+			if err != nil {
+				return err
+			}
+			cfg.Maintenance.CacheTTL = d
+		}
+	}
+
 	if cfg.PolicyEval != nil {
 		if cfg.PolicyEval.AckTimeoutHCL != "" {
 			t, err := time.ParseDuration(cfg.PolicyEval.AckTimeoutHCL)
@@ -745,6 +2263,22 @@ func parseFile(file string, cfg *Agent) error {
 			}
 			cfg.PolicyEval.EvaluateAfter = t
 		}
+
+		if cfg.PolicyEval.QueryCoalesceWindowHCL != "" {
+			t, err := time.ParseDuration(cfg.PolicyEval.QueryCoalesceWindowHCL)
+			if err != nil {
+				return err
+			}
+			cfg.PolicyEval.QueryCoalesceWindow = t
+		}
+
+		if cfg.PolicyEval.QueryCacheTTLHCL != "" {
+			t, err := time.ParseDuration(cfg.PolicyEval.QueryCacheTTLHCL)
+			if err != nil {
+				return err
+			}
+			cfg.PolicyEval.QueryCacheTTL = t
+		}
 	}
 
 	return nil
@@ -772,7 +2306,7 @@ func Load(path string) (*Agent, error) {
 }
 
 // loadDir loads all the configurations in the given directory in alphabetical
-// order.
+// order, with each file merged over the ones before it.
 func loadDir(dir string) (*Agent, error) {
 
 	files, err := file.GetFileListFromDir(dir, ".hcl", ".json")
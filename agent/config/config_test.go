@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad-autoscaler/sdk/helper/ptr"
 	"github.com/stretchr/testify/assert"
 )
@@ -24,13 +25,29 @@ func Test_Default(t *testing.T) {
 	assert.Equal(t, "127.0.0.1", def.HTTP.BindAddress)
 	assert.Equal(t, 8080, def.HTTP.BindPort)
 	assert.Equal(t, def.Policy.DefaultCooldown, 5*time.Minute)
+	assert.Equal(t, 30*time.Second, def.Policy.HTTPPollInterval)
 	assert.Equal(t, defaultPolicyEvalDeliveryLimit, def.PolicyEval.DeliveryLimit)
 	assert.Equal(t, defaultPolicyEvalAckTimeout, def.PolicyEval.AckTimeout)
 	assert.Equal(t, defaultPolicyEvalWorkers, def.PolicyEval.Workers)
+	assert.Equal(t, "conservative", def.PolicyEval.MultipleActions)
 	assert.Len(t, def.APMs, 1)
 	assert.Len(t, def.Targets, 1)
 	assert.Len(t, def.Strategies, 1)
 	assert.Equal(t, 1*time.Second, def.Telemetry.CollectionInterval)
+	assert.Equal(t, 3, def.Alerting.FailureThreshold)
+	assert.Equal(t, "critical", def.Alerting.Severity)
+	assert.False(t, def.LeaderElection.Enabled)
+	assert.Equal(t, "nomad-autoscaler/leader", def.LeaderElection.Key)
+	assert.Equal(t, 15*time.Second, def.LeaderElection.SessionTTL)
+	assert.Zero(t, def.CapacityBudget.MaxCount)
+	assert.Equal(t, "deny", def.CapacityBudget.Enforcement)
+	assert.Equal(t, 30*time.Second, def.ScaleHooks.Timeout)
+	assert.Zero(t, def.ScaleThrottle.Rate)
+	assert.Equal(t, 1, def.ScaleThrottle.Burst)
+	assert.Equal(t, "nomad-autoscaler.decisions", def.DecisionEvents.NATSSubject)
+	assert.Equal(t, 1024, def.DecisionEvents.BufferSize)
+	assert.Equal(t, 10*time.Second, def.Maintenance.CacheTTL)
+	assert.Equal(t, "hold", def.Maintenance.OnError)
 }
 
 func TestAgent_Merge(t *testing.T) {
@@ -65,25 +82,43 @@ func TestAgent_Merge(t *testing.T) {
 		LogJson:   true,
 		PluginDir: "/var/lib/nomad-autoscaler/plugins",
 		HTTP: &HTTP{
-			BindPort: 4646,
+			BindPort:            4646,
+			EnableDebug:         true,
+			AuthToken:           "agent-token",
+			TLSCertFile:         "/etc/nomad-autoscaler.d/agent.crt",
+			TLSKeyFile:          "/etc/nomad-autoscaler.d/agent.key",
+			TLSCAFile:           "/etc/nomad-autoscaler.d/ca.crt",
+			MetricsAllowedCIDRs: []string{"10.0.0.0/8"},
 		},
 		Nomad: &Nomad{
-			Address:       "https://nomad-new.systems:4646",
-			Region:        "moon-base-1",
-			Namespace:     "fra-mauro",
-			Token:         "super-secret-tokeny-thing",
-			HTTPAuth:      "admin:admin",
-			CACert:        "/etc/nomad.d/ca.crt",
-			CAPath:        "/etc/nomad.d/ca/",
-			ClientCert:    "/etc/nomad.d/client.crt",
-			ClientKey:     "/etc/nomad.d/client-key.crt",
-			TLSServerName: "cows-or-pets",
-			SkipVerify:    true,
+			Address:        "https://nomad-new.systems:4646",
+			Region:         "moon-base-1",
+			Namespace:      "fra-mauro",
+			Token:          "super-secret-tokeny-thing",
+			HTTPAuth:       "admin:admin",
+			CACert:         "/etc/nomad.d/ca.crt",
+			CAPath:         "/etc/nomad.d/ca/",
+			ClientCert:     "/etc/nomad.d/client.crt",
+			ClientKey:      "/etc/nomad.d/client-key.crt",
+			TLSServerName:  "cows-or-pets",
+			SkipVerify:     true,
+			RequestTimeout: 10 * time.Second,
+			RetriesPtr:     ptr.IntToPtr(0),
 		},
 		Policy: &Policy{
 			Dir:                       "/etc/scaling/policies",
 			DefaultCooldown:           20 * time.Minute,
 			DefaultEvaluationInterval: 10 * time.Second,
+			DefaultWarmupPeriod:       time.Minute,
+			MinEvaluationInterval:     5 * time.Second,
+			RemovalGracePeriod:        time.Minute,
+			DefaultMin:                1,
+			DefaultMax:                50,
+			HTTPAddress:               "https://policies.example.com/v1/policies",
+			HTTPHeaders:               map[string]string{"Authorization": "Bearer token"},
+			HTTPPollInterval:          time.Minute,
+			ConflictMode:              "source-priority",
+			SourcePriority:            []string{"api", "nomad"},
 		},
 		PolicyEval: &PolicyEval{
 			DeliveryLimitPtr: ptr.IntToPtr(10),
@@ -93,6 +128,26 @@ func TestAgent_Merge(t *testing.T) {
 				"cluster":    8,
 				"horizontal": 7,
 			},
+			MultipleActions:     "last",
+			QueryCoalesceWindow: 2 * time.Second,
+			QueryCacheTTL:       time.Minute,
+		},
+		Alerting: &Alerting{
+			WebhookAddress:   "https://alerts.example.com/hook",
+			FailureThreshold: 5,
+		},
+		LeaderElection: &LeaderElection{
+			Enabled: true,
+			Key:     "autoscaler/leader",
+		},
+		CapacityBudget: &CapacityBudget{
+			MaxCount: 100,
+		},
+		ScaleThrottle: &ScaleThrottle{
+			Rate: 30,
+		},
+		TargetAccess: &TargetAccess{
+			Deny: []string{"aws-*"},
 		},
 		Telemetry: &Telemetry{
 			StatsiteAddr:                       "some-address",
@@ -105,6 +160,8 @@ func TestAgent_Merge(t *testing.T) {
 			CollectionInterval:                 3 * time.Second,
 			CirconusAPIToken:                   "super-secret",
 			CirconusAPIApp:                     "secret-app",
+			OTLPEndpoint:                       "localhost:4318",
+			OTLPInsecure:                       true,
 			CirconusAPIURL:                     "some-url",
 			CirconusSubmissionInterval:         "30s",
 			CirconusCheckSubmissionURL:         "some-other-url",
@@ -127,6 +184,7 @@ func TestAgent_Merge(t *testing.T) {
 				Driver: "prometheus",
 				Config: map[string]string{"address": "http://prometheus-new.systems:9090"},
 				Args:   []string{"all-the-encryption"},
+				Env:    map[string]string{"PROMETHEUS_TOKEN_FILE": "/secrets/token"},
 			},
 		},
 		Strategies: []*Plugin{
@@ -142,26 +200,46 @@ func TestAgent_Merge(t *testing.T) {
 		LogJson:   true,
 		PluginDir: "/var/lib/nomad-autoscaler/plugins",
 		HTTP: &HTTP{
-			BindAddress: "scaler.nomad",
-			BindPort:    4646,
+			BindAddress:         "scaler.nomad",
+			BindPort:            4646,
+			EnableDebug:         true,
+			AuthToken:           "agent-token",
+			TLSCertFile:         "/etc/nomad-autoscaler.d/agent.crt",
+			TLSKeyFile:          "/etc/nomad-autoscaler.d/agent.key",
+			TLSCAFile:           "/etc/nomad-autoscaler.d/ca.crt",
+			MetricsAllowedCIDRs: []string{"10.0.0.0/8"},
 		},
 		Nomad: &Nomad{
-			Address:       "https://nomad-new.systems:4646",
-			Region:        "moon-base-1",
-			Namespace:     "fra-mauro",
-			Token:         "super-secret-tokeny-thing",
-			HTTPAuth:      "admin:admin",
-			CACert:        "/etc/nomad.d/ca.crt",
-			CAPath:        "/etc/nomad.d/ca/",
-			ClientCert:    "/etc/nomad.d/client.crt",
-			ClientKey:     "/etc/nomad.d/client-key.crt",
-			TLSServerName: "cows-or-pets",
-			SkipVerify:    true,
+			Address:        "https://nomad-new.systems:4646",
+			Region:         "moon-base-1",
+			Namespace:      "fra-mauro",
+			Token:          "super-secret-tokeny-thing",
+			HTTPAuth:       "admin:admin",
+			CACert:         "/etc/nomad.d/ca.crt",
+			CAPath:         "/etc/nomad.d/ca/",
+			ClientCert:     "/etc/nomad.d/client.crt",
+			ClientKey:      "/etc/nomad.d/client-key.crt",
+			TLSServerName:  "cows-or-pets",
+			SkipVerify:     true,
+			RequestTimeout: 10 * time.Second,
+			RetriesPtr:     ptr.IntToPtr(0),
+			Retries:        0,
+			RetryBackoff:   time.Second,
 		},
 		Policy: &Policy{
 			Dir:                       "/etc/scaling/policies",
 			DefaultCooldown:           20 * time.Minute,
 			DefaultEvaluationInterval: 10 * time.Second,
+			DefaultWarmupPeriod:       time.Minute,
+			MinEvaluationInterval:     5 * time.Second,
+			RemovalGracePeriod:        time.Minute,
+			DefaultMin:                1,
+			DefaultMax:                50,
+			HTTPAddress:               "https://policies.example.com/v1/policies",
+			HTTPHeaders:               map[string]string{"Authorization": "Bearer token"},
+			HTTPPollInterval:          time.Minute,
+			ConflictMode:              "source-priority",
+			SourcePriority:            []string{"api", "nomad"},
 		},
 		PolicyEval: &PolicyEval{
 			DeliveryLimitPtr: ptr.IntToPtr(10),
@@ -172,6 +250,30 @@ func TestAgent_Merge(t *testing.T) {
 				"horizontal": 7,
 				"some-other": 3,
 			},
+			MultipleActions:     "last",
+			QueryCoalesceWindow: 2 * time.Second,
+			QueryCacheTTL:       time.Minute,
+		},
+		Alerting: &Alerting{
+			WebhookAddress:   "https://alerts.example.com/hook",
+			FailureThreshold: 5,
+		},
+		LeaderElection: &LeaderElection{
+			Enabled:       true,
+			ConsulAddress: "http://127.0.0.1:8500",
+			Key:           "autoscaler/leader",
+			SessionTTL:    15 * time.Second,
+		},
+		CapacityBudget: &CapacityBudget{
+			MaxCount:    100,
+			Enforcement: "deny",
+		},
+		ScaleThrottle: &ScaleThrottle{
+			Rate:  30,
+			Burst: 1,
+		},
+		TargetAccess: &TargetAccess{
+			Deny: []string{"aws-*"},
 		},
 		Telemetry: &Telemetry{
 			StatsiteAddr:                       "some-address",
@@ -185,6 +287,8 @@ func TestAgent_Merge(t *testing.T) {
 			CollectionInterval:                 3 * time.Second,
 			CirconusAPIToken:                   "super-secret",
 			CirconusAPIApp:                     "secret-app",
+			OTLPEndpoint:                       "localhost:4318",
+			OTLPInsecure:                       true,
 			CirconusAPIURL:                     "some-url",
 			CirconusSubmissionInterval:         "30s",
 			CirconusCheckSubmissionURL:         "some-other-url",
@@ -207,6 +311,7 @@ func TestAgent_Merge(t *testing.T) {
 				Driver: "prometheus",
 				Config: map[string]string{"address": "http://prometheus-new.systems:9090"},
 				Args:   []string{"all-the-encryption"},
+				Env:    map[string]string{"PROMETHEUS_TOKEN_FILE": "/secrets/token"},
 			},
 			{
 				Name:   "influx-db",
@@ -235,17 +340,101 @@ func TestAgent_Merge(t *testing.T) {
 	actualResult = actualResult.Merge(cfg2)
 
 	assert.Equal(t, expectedResult.HTTP, actualResult.HTTP)
+	assert.Equal(t, expectedResult.LeaderElection, actualResult.LeaderElection)
 	assert.Equal(t, expectedResult.LogJson, actualResult.LogJson)
 	assert.Equal(t, expectedResult.LogLevel, actualResult.LogLevel)
 	assert.Equal(t, expectedResult.Nomad, actualResult.Nomad)
 	assert.Equal(t, expectedResult.PluginDir, actualResult.PluginDir)
 	assert.Equal(t, expectedResult.Policy, actualResult.Policy)
 	assert.Equal(t, expectedResult.PolicyEval, actualResult.PolicyEval)
+	assert.Equal(t, expectedResult.ScaleThrottle, actualResult.ScaleThrottle)
+	assert.Equal(t, expectedResult.TargetAccess, actualResult.TargetAccess)
 	assert.ElementsMatch(t, expectedResult.APMs, actualResult.APMs)
 	assert.ElementsMatch(t, expectedResult.Targets, actualResult.Targets)
 	assert.ElementsMatch(t, expectedResult.Strategies, actualResult.Strategies)
 }
 
+func TestAgent_Merge_collections(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputFirst     *Agent
+		inputSecond    *Agent
+		expectedOutput *Agent
+	}{
+		{
+			name: "lists appended",
+			inputFirst: &Agent{
+				HTTP:         &HTTP{MetricsAllowedCIDRs: []string{"10.0.0.0/8"}},
+				TargetAccess: &TargetAccess{Allow: []string{"default/*/*"}, Deny: []string{"aws-*"}},
+			},
+			inputSecond: &Agent{
+				HTTP:         &HTTP{MetricsAllowedCIDRs: []string{"192.168.0.0/16", "10.0.0.0/8"}},
+				TargetAccess: &TargetAccess{Deny: []string{"gce-*"}},
+			},
+			expectedOutput: &Agent{
+				HTTP:         &HTTP{MetricsAllowedCIDRs: []string{"10.0.0.0/8", "192.168.0.0/16"}},
+				TargetAccess: &TargetAccess{Allow: []string{"default/*/*"}, Deny: []string{"aws-*", "gce-*"}},
+			},
+		},
+		{
+			name: "maps merged",
+			inputFirst: &Agent{
+				Alerting: &Alerting{WebhookHeaders: map[string]string{"Authorization": "old", "X-Team": "infra"}},
+			},
+			inputSecond: &Agent{
+				Alerting: &Alerting{WebhookHeaders: map[string]string{"Authorization": "new"}},
+			},
+			expectedOutput: &Agent{
+				Alerting: &Alerting{WebhookHeaders: map[string]string{"Authorization": "new", "X-Team": "infra"}},
+			},
+		},
+		{
+			name: "commands replaced",
+			inputFirst: &Agent{
+				ScaleHooks: &ScaleHooks{PreScale: []string{"/usr/local/bin/gate", "--check"}},
+			},
+			inputSecond: &Agent{
+				ScaleHooks: &ScaleHooks{PreScale: []string{"/usr/local/bin/other-gate"}},
+			},
+			expectedOutput: &Agent{
+				ScaleHooks: &ScaleHooks{PreScale: []string{"/usr/local/bin/other-gate"}},
+			},
+		},
+		{
+			name: "plugins merged by name in order",
+			inputFirst: &Agent{
+				APMs: []*Plugin{
+					{Name: "prometheus", Driver: "prometheus", Config: map[string]string{"address": "a", "timeout": "5s"}},
+					{Name: "datadog", Driver: "datadog"},
+				},
+			},
+			inputSecond: &Agent{
+				APMs: []*Plugin{
+					{Name: "influx-db", Driver: "influx-db"},
+					{Name: "prometheus", Config: map[string]string{"address": "b"}, Args: []string{"-v"}},
+				},
+			},
+			expectedOutput: &Agent{
+				APMs: []*Plugin{
+					{Name: "prometheus", Driver: "prometheus", Config: map[string]string{"address": "b", "timeout": "5s"}, Args: []string{"-v"}},
+					{Name: "datadog", Driver: "datadog"},
+					{Name: "influx-db", Driver: "influx-db"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput := tc.inputFirst.Merge(tc.inputSecond)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+
+			// Merging the same configuration again does not change the result.
+			assert.Equal(t, tc.expectedOutput, actualOutput.Merge(tc.inputSecond), tc.name)
+		})
+	}
+}
+
 func TestAgent_parseFile(t *testing.T) {
 	// Should receive a non-nil response as the file doesn't exist.
 	assert.NotNil(t, parseFile("/honeybadger/", &Agent{}))
@@ -277,6 +466,23 @@ func TestAgent_parseFile(t *testing.T) {
 	}
 	assert.Nil(t, parseFile(fh.Name(), cfg))
 	assert.Equal(t, "/opt/nomad-autoscaler/plugins", cfg.PluginDir)
+
+	// Write a plugin block, and ensure its durations are parsed.
+	if err := fh.Truncate(0); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := fh.Seek(0, 0); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := fh.WriteString("apm \"prometheus\" {\n  driver = \"prometheus\"\n  max_lifetime = \"24h\"\n  set_config_retry_backoff = \"5s\"\n}\n"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cfg = &Agent{}
+	assert.Nil(t, parseFile(fh.Name(), cfg))
+	assert.Len(t, cfg.APMs, 1)
+	assert.Equal(t, 24*time.Hour, cfg.APMs[0].MaxLifetime)
+	assert.Equal(t, 5*time.Second, cfg.APMs[0].SetConfigRetryBackoff)
 }
 
 func TestConfig_Load(t *testing.T) {
@@ -344,4 +550,495 @@ func TestAgent_loadDir(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "trace", cfg.LogLevel)
 	assert.Equal(t, "/opt/nomad-autoscaler/plugins", cfg.PluginDir)
+
+	// Later files in lexical order override and append to earlier ones.
+	file4 := filepath.Join(dir, "config4.hcl")
+	assert.Nil(t, ioutil.WriteFile(file4, []byte(`
+log_level = "debug"
+target_access {
+  deny = ["aws-*"]
+}
+`), 0600))
+
+	file5 := filepath.Join(dir, "config5.json")
+	assert.Nil(t, ioutil.WriteFile(file5, []byte(`{"target_access": {"deny": ["gce-*"]}}`), 0600))
+
+	cfg, err = loadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, "/opt/nomad-autoscaler/plugins", cfg.PluginDir)
+	assert.Equal(t, []string{"aws-*", "gce-*"}, cfg.TargetAccess.Deny)
+}
+
+func TestAgent_Validate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       *Agent
+		expectError bool
+	}{
+		{
+			name:        "empty config",
+			input:       &Agent{},
+			expectError: false,
+		},
+		{
+			name:        "default policy",
+			input:       &Agent{Policy: &Policy{}},
+			expectError: false,
+		},
+		{
+			name:        "valid default min and max",
+			input:       &Agent{Policy: &Policy{DefaultMin: 1, DefaultMax: 10}},
+			expectError: false,
+		},
+		{
+			name:        "negative default min",
+			input:       &Agent{Policy: &Policy{DefaultMin: -1}},
+			expectError: true,
+		},
+		{
+			name:        "default min greater than default max",
+			input:       &Agent{Policy: &Policy{DefaultMin: 10, DefaultMax: 1}},
+			expectError: true,
+		},
+		{
+			name:        "negative policy duration",
+			input:       &Agent{Policy: &Policy{DefaultCooldown: -time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "valid policy http address",
+			input:       &Agent{Policy: &Policy{HTTPAddress: "https://policies.example.com/v1/policies"}},
+			expectError: false,
+		},
+		{
+			name:        "negative min evaluation interval",
+			input:       &Agent{Policy: &Policy{MinEvaluationInterval: -time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "negative removal grace period",
+			input:       &Agent{Policy: &Policy{RemovalGracePeriod: -time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "policy http address without scheme",
+			input:       &Agent{Policy: &Policy{HTTPAddress: "policies.example.com"}},
+			expectError: true,
+		},
+		{
+			name:        "valid policy conflict mode",
+			input:       &Agent{Policy: &Policy{ConflictMode: "source-priority", SourcePriority: []string{"api", "file", "nomad"}}},
+			expectError: false,
+		},
+		{
+			name:        "invalid policy conflict mode",
+			input:       &Agent{Policy: &Policy{ConflictMode: "last-wins"}},
+			expectError: true,
+		},
+		{
+			name:        "policy source priority not set",
+			input:       &Agent{Policy: &Policy{ConflictMode: "source-priority"}},
+			expectError: true,
+		},
+		{
+			name:        "policy source priority unknown source",
+			input:       &Agent{Policy: &Policy{ConflictMode: "source-priority", SourcePriority: []string{"api", "s3"}}},
+			expectError: true,
+		},
+		{
+			name:        "valid policy consul address",
+			input:       &Agent{Policy: &Policy{ConsulAddress: "https://127.0.0.1:8501", ConsulClientCert: "/etc/consul/client.pem", ConsulClientKey: "/etc/consul/client-key.pem"}},
+			expectError: false,
+		},
+		{
+			name:        "policy consul address without scheme",
+			input:       &Agent{Policy: &Policy{ConsulAddress: "127.0.0.1:8500"}},
+			expectError: true,
+		},
+		{
+			name:        "policy consul client cert without key",
+			input:       &Agent{Policy: &Policy{ConsulAddress: "https://127.0.0.1:8501", ConsulClientCert: "/etc/consul/client.pem"}},
+			expectError: true,
+		},
+		{
+			name:        "invalid log level",
+			input:       &Agent{LogLevel: "loud"},
+			expectError: true,
+		},
+		{
+			name:        "valid log level",
+			input:       &Agent{LogLevel: "DEBUG"},
+			expectError: false,
+		},
+		{
+			name:        "invalid http bind port",
+			input:       &Agent{HTTP: &HTTP{BindPort: 70000}},
+			expectError: true,
+		},
+		{
+			name:        "valid http tls",
+			input:       &Agent{HTTP: &HTTP{TLSCertFile: "agent.crt", TLSKeyFile: "agent.key", TLSCAFile: "ca.crt"}},
+			expectError: false,
+		},
+		{
+			name:        "http tls cert without key",
+			input:       &Agent{HTTP: &HTTP{TLSCertFile: "agent.crt"}},
+			expectError: true,
+		},
+		{
+			name:        "http tls ca without cert",
+			input:       &Agent{HTTP: &HTTP{TLSCAFile: "ca.crt"}},
+			expectError: true,
+		},
+		{
+			name:        "invalid http metrics allowed cidr",
+			input:       &Agent{HTTP: &HTTP{MetricsAllowedCIDRs: []string{"10.0.0.0"}}},
+			expectError: true,
+		},
+		{
+			name:        "valid nomad address",
+			input:       &Agent{Nomad: &Nomad{Address: "https://nomad.example.com:4646"}},
+			expectError: false,
+		},
+		{
+			name:        "valid nomad unix socket address",
+			input:       &Agent{Nomad: &Nomad{Address: "unix:///var/run/nomad.sock"}},
+			expectError: false,
+		},
+		{
+			name:        "nomad address without scheme",
+			input:       &Agent{Nomad: &Nomad{Address: "127.0.0.1:4646"}},
+			expectError: true,
+		},
+		{
+			name:        "nomad address with unsupported scheme",
+			input:       &Agent{Nomad: &Nomad{Address: "ftp://127.0.0.1:4646"}},
+			expectError: true,
+		},
+		{
+			name:        "nomad address without host",
+			input:       &Agent{Nomad: &Nomad{Address: "http://"}},
+			expectError: true,
+		},
+		{
+			name:        "valid nomad timeout and retries",
+			input:       &Agent{Nomad: &Nomad{RequestTimeout: time.Minute, Retries: 3, RetryBackoff: time.Second}},
+			expectError: false,
+		},
+		{
+			name:        "negative nomad request timeout",
+			input:       &Agent{Nomad: &Nomad{RequestTimeout: -time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "negative nomad retries",
+			input:       &Agent{Nomad: &Nomad{Retries: -1}},
+			expectError: true,
+		},
+		{
+			name:        "negative nomad retry backoff",
+			input:       &Agent{Nomad: &Nomad{RetryBackoff: -time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "valid telemetry addresses",
+			input:       &Agent{Telemetry: &Telemetry{StatsdAddr: "127.0.0.1:8125", DogStatsDAddr: "localhost:8125"}},
+			expectError: false,
+		},
+		{
+			name:        "telemetry address without port",
+			input:       &Agent{Telemetry: &Telemetry{StatsiteAddr: "127.0.0.1"}},
+			expectError: true,
+		},
+		{
+			name:        "negative telemetry collection interval",
+			input:       &Agent{Telemetry: &Telemetry{CollectionInterval: -time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "plugin without driver",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus"}}},
+			expectError: true,
+		},
+		{
+			name:        "plugin without name",
+			input:       &Agent{Targets: []*Plugin{{Driver: "aws-asg"}}},
+			expectError: true,
+		},
+		{
+			name: "duplicate plugin names",
+			input: &Agent{Strategies: []*Plugin{
+				{Name: "target-value", Driver: "target-value"},
+				{Name: "target-value", Driver: "target-value"},
+			}},
+			expectError: true,
+		},
+		{
+			name:        "valid plugin checksum",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}}},
+			expectError: false,
+		},
+		{
+			name:        "valid plugin max lifetime",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", MaxLifetime: 24 * time.Hour}}},
+			expectError: false,
+		},
+		{
+			name:        "negative plugin max lifetime",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", MaxLifetime: -time.Hour}}},
+			expectError: true,
+		},
+		{
+			name:        "valid plugin set config retries",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", SetConfigRetries: ptr.IntToPtr(3), SetConfigRetryBackoff: time.Second}}},
+			expectError: false,
+		},
+		{
+			name:        "negative plugin set config retries",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", SetConfigRetries: ptr.IntToPtr(-1)}}},
+			expectError: true,
+		},
+		{
+			name:        "negative plugin set config retry backoff",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", SetConfigRetryBackoff: -time.Second}}},
+			expectError: true,
+		},
+		{
+			name:        "invalid plugin checksum",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", SHA256: "e3b0c442"}}},
+			expectError: true,
+		},
+		{
+			name: "valid plugin args and env",
+			input: &Agent{APMs: []*Plugin{{
+				Name:   "prometheus",
+				Driver: "prometheus",
+				Args:   []string{"-config", "/etc/prometheus-apm.hcl"},
+				Env:    map[string]string{"HTTPS_PROXY": "http://proxy:3128"},
+			}}},
+			expectError: false,
+		},
+		{
+			name:        "plugin args with NUL character",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", Args: []string{"-config\x00"}}}},
+			expectError: true,
+		},
+		{
+			name:        "plugin env with invalid name",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", Env: map[string]string{"A=B": "value"}}}},
+			expectError: true,
+		},
+		{
+			name:        "plugin env with empty name",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", Env: map[string]string{"": "value"}}}},
+			expectError: true,
+		},
+		{
+			name:        "plugin env with NUL character",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", Env: map[string]string{"TOKEN": "a\x00b"}}}},
+			expectError: true,
+		},
+		{
+			name:        "plugin env with reserved name",
+			input:       &Agent{APMs: []*Plugin{{Name: "prometheus", Driver: "prometheus", Env: map[string]string{"PLUGIN_MIN_PORT": "10000"}}}},
+			expectError: true,
+		},
+		{
+			name: "same plugin name for different types",
+			input: &Agent{
+				APMs:    []*Plugin{{Name: "nomad", Driver: "nomad-apm"}},
+				Targets: []*Plugin{{Name: "nomad", Driver: "nomad-target"}},
+			},
+			expectError: false,
+		},
+		{
+			name:        "valid alerting",
+			input:       &Agent{Alerting: &Alerting{WebhookAddress: "https://alerts.example.com/hook", FailureThreshold: 3}},
+			expectError: false,
+		},
+		{
+			name:        "invalid alerting webhook address",
+			input:       &Agent{Alerting: &Alerting{WebhookAddress: "alerts.example.com"}},
+			expectError: true,
+		},
+		{
+			name:        "negative alerting failure threshold",
+			input:       &Agent{Alerting: &Alerting{FailureThreshold: -1}},
+			expectError: true,
+		},
+		{
+			name:        "valid leader election",
+			input:       &Agent{LeaderElection: &LeaderElection{Enabled: true, ConsulAddress: "http://127.0.0.1:8500", Key: "leader", SessionTTL: 15 * time.Second}},
+			expectError: false,
+		},
+		{
+			name:        "valid capacity budget",
+			input:       &Agent{CapacityBudget: &CapacityBudget{MaxCount: 100, Enforcement: "reduce"}},
+			expectError: false,
+		},
+		{
+			name:        "negative capacity budget max count",
+			input:       &Agent{CapacityBudget: &CapacityBudget{MaxCount: -1}},
+			expectError: true,
+		},
+		{
+			name:        "invalid capacity budget enforcement",
+			input:       &Agent{CapacityBudget: &CapacityBudget{Enforcement: "block"}},
+			expectError: true,
+		},
+		{
+			name:        "valid scale hooks",
+			input:       &Agent{ScaleHooks: &ScaleHooks{PreScale: []string{"/usr/local/bin/gate", "--check"}, Timeout: time.Minute}},
+			expectError: false,
+		},
+		{
+			name:        "empty scale hook command",
+			input:       &Agent{ScaleHooks: &ScaleHooks{PostScale: []string{""}}},
+			expectError: true,
+		},
+		{
+			name:        "negative scale hooks timeout",
+			input:       &Agent{ScaleHooks: &ScaleHooks{Timeout: -time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "valid scale throttle",
+			input:       &Agent{ScaleThrottle: &ScaleThrottle{Rate: 10, Burst: 5}},
+			expectError: false,
+		},
+		{
+			name:        "negative scale throttle rate",
+			input:       &Agent{ScaleThrottle: &ScaleThrottle{Rate: -1}},
+			expectError: true,
+		},
+		{
+			name:        "negative scale throttle burst",
+			input:       &Agent{ScaleThrottle: &ScaleThrottle{Rate: 10, Burst: -1}},
+			expectError: true,
+		},
+		{
+			name: "valid decision events",
+			input: &Agent{DecisionEvents: &DecisionEvents{
+				WebhookAddress: "https://events.example.com/hook",
+				NATSAddress:    "nats://127.0.0.1:4222",
+				NATSSubject:    "autoscaler.decisions",
+			}},
+			expectError: false,
+		},
+		{
+			name:        "invalid decision events nats address",
+			input:       &Agent{DecisionEvents: &DecisionEvents{NATSAddress: "127.0.0.1:4222", NATSSubject: "decisions"}},
+			expectError: true,
+		},
+		{
+			name:        "invalid decision events nats subject",
+			input:       &Agent{DecisionEvents: &DecisionEvents{NATSAddress: "nats://127.0.0.1:4222", NATSSubject: "scaling decisions"}},
+			expectError: true,
+		},
+		{
+			name:        "negative decision events buffer size",
+			input:       &Agent{DecisionEvents: &DecisionEvents{BufferSize: -1}},
+			expectError: true,
+		},
+		{
+			name:        "valid maintenance http address",
+			input:       &Agent{Maintenance: &Maintenance{HTTPAddress: "https://maintenance.example.com/status", OnError: "allow"}},
+			expectError: false,
+		},
+		{
+			name:        "valid maintenance file path",
+			input:       &Agent{Maintenance: &Maintenance{FilePath: "/etc/nomad-autoscaler/maintenance"}},
+			expectError: false,
+		},
+		{
+			name:        "invalid maintenance http address",
+			input:       &Agent{Maintenance: &Maintenance{HTTPAddress: "maintenance.example.com"}},
+			expectError: true,
+		},
+		{
+			name:        "maintenance http address and file path",
+			input:       &Agent{Maintenance: &Maintenance{HTTPAddress: "https://maintenance.example.com", FilePath: "/tmp/maintenance"}},
+			expectError: true,
+		},
+		{
+			name:        "negative maintenance cache ttl",
+			input:       &Agent{Maintenance: &Maintenance{CacheTTL: -time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "invalid maintenance on error",
+			input:       &Agent{Maintenance: &Maintenance{OnError: "ignore"}},
+			expectError: true,
+		},
+		{
+			name:        "valid target access",
+			input:       &Agent{TargetAccess: &TargetAccess{Allow: []string{"default/*/*"}, Deny: []string{"aws-*"}}},
+			expectError: false,
+		},
+		{
+			name:        "invalid target access pattern",
+			input:       &Agent{TargetAccess: &TargetAccess{Deny: []string{"default/[web"}}},
+			expectError: true,
+		},
+		{
+			name:        "leader election without key",
+			input:       &Agent{LeaderElection: &LeaderElection{Enabled: true, ConsulAddress: "http://127.0.0.1:8500"}},
+			expectError: true,
+		},
+		{
+			name:        "leader election session ttl too short",
+			input:       &Agent{LeaderElection: &LeaderElection{SessionTTL: time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "negative policy eval ack timeout",
+			input:       &Agent{PolicyEval: &PolicyEval{AckTimeout: -time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "negative policy eval query cache ttl",
+			input:       &Agent{PolicyEval: &PolicyEval{QueryCacheTTL: -time.Second}},
+			expectError: true,
+		},
+		{
+			name:        "invalid policy eval multiple actions",
+			input:       &Agent{PolicyEval: &PolicyEval{MultipleActions: "random"}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.input.Validate()
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAgent_Validate_allErrors(t *testing.T) {
+	cfg := &Agent{
+		LogLevel:  "loud",
+		Nomad:     &Nomad{Address: "127.0.0.1:4646"},
+		Telemetry: &Telemetry{StatsdAddr: "127.0.0.1"},
+		APMs:      []*Plugin{{Name: "prometheus"}},
+		Policy:    &Policy{DefaultMin: -1},
+	}
+
+	err := cfg.Validate()
+	assert.NotNil(t, err)
+
+	mErr, ok := err.(*multierror.Error)
+	assert.True(t, ok)
+	assert.Len(t, mErr.Errors, 5)
+}
+
+func TestDefault_Validate(t *testing.T) {
+	def, err := Default()
+	assert.Nil(t, err)
+	assert.Nil(t, def.Merge(DefaultEntConfig()).Validate())
 }
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+)
+
+// authenticator authenticates the requests made to the HTTP server using a
+// bearer token or a verified client certificate. Requests to the metrics
+// endpoint are also allowed from the configured networks, so metrics scrapers
+// do not need to hold a credential.
+type authenticator struct {
+	token        []byte
+	metricsCIDRs []*net.IPNet
+}
+
+// newAuthenticator returns the authenticator configured by cfg, or nil if
+// requests do not need to authenticate.
+func newAuthenticator(cfg *config.HTTP) (*authenticator, error) {
+	if !cfg.AuthEnabled() {
+		return nil, nil
+	}
+
+	a := &authenticator{}
+	if cfg.AuthToken != "" {
+		a.token = []byte(cfg.AuthToken)
+	}
+
+	for _, cidr := range cfg.MetricsAllowedCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metrics allowed CIDR %q: %v", cidr, err)
+		}
+		a.metricsCIDRs = append(a.metricsCIDRs, ipNet)
+	}
+
+	return a, nil
+}
+
+// authenticated returns whether the request is allowed to be served.
+func (a *authenticator) authenticated(r *http.Request) bool {
+
+	// The health endpoint is used by orchestrators to probe the agent and is
+	// always available.
+	if r.URL.Path == healthRoutePattern {
+		return true
+	}
+
+	if r.URL.Path == metricsRoutePattern && a.metricsAllowed(r.RemoteAddr) {
+		return true
+	}
+
+	// The TLS configuration only verifies client certificates against the
+	// configured CA, so any verified chain authenticates the request.
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+
+	if a.token == nil {
+		return false
+	}
+
+	// The token must be sent using the bearer scheme, so a bare token in the
+	// header is rejected.
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+
+	token := strings.TrimPrefix(header, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), a.token) == 1
+}
+
+// metricsAllowed returns whether the remote address is within the networks
+// allowed to read metrics without authenticating.
+func (a *authenticator) metricsAllowed(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, ipNet := range a.metricsCIDRs {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// authHandler wraps the handler, responding with 401 Unauthorized to requests
+// which fail to authenticate. The handler is returned as is if authentication
+// is disabled.
+func (s *Server) authHandler(a *authenticator, next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.authenticated(r) {
+			s.handleHTTPError(w, r, newCodedError(http.StatusUnauthorized, "unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newTLSConfig returns the TLS configuration used to serve HTTPS, or nil if
+// no certificate is configured. Client certificates are verified against the
+// configured CA, but are not required, so requests can authenticate using
+// the token instead.
+func newTLSConfig(cfg *config.HTTP) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+	}
+
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.TLSCAFile != "" {
+		caPEM, err := ioutil.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %v", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("failed to parse TLS CA file %q", cfg.TLSCAFile)
+		}
+
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_authHandler(t *testing.T) {
+	testCases := []struct {
+		inputConfig      *config.HTTP
+		inputPath        string
+		inputRemoteAddr  string
+		inputAuthHeader  string
+		inputVerifiedTLS bool
+		expectedRespCode int
+		name             string
+	}{
+		{
+			inputConfig:      &config.HTTP{},
+			inputPath:        "/v1/metrics",
+			expectedRespCode: 200,
+			name:             "auth disabled",
+		},
+		{
+			inputConfig:      &config.HTTP{AuthToken: "secret"},
+			inputPath:        "/v1/metrics",
+			expectedRespCode: 401,
+			name:             "missing token",
+		},
+		{
+			inputConfig:      &config.HTTP{AuthToken: "secret"},
+			inputPath:        "/v1/metrics",
+			inputAuthHeader:  "Bearer wrong",
+			expectedRespCode: 401,
+			name:             "incorrect token",
+		},
+		{
+			inputConfig:      &config.HTTP{AuthToken: "secret"},
+			inputPath:        "/v1/metrics",
+			inputAuthHeader:  "Bearer secret",
+			expectedRespCode: 200,
+			name:             "correct token",
+		},
+		{
+			inputConfig:      &config.HTTP{AuthToken: "secret"},
+			inputPath:        "/v1/metrics",
+			inputAuthHeader:  "secret",
+			expectedRespCode: 401,
+			name:             "token without bearer scheme",
+		},
+		{
+			inputConfig:      &config.HTTP{AuthToken: "secret"},
+			inputPath:        "/v1/health",
+			expectedRespCode: 200,
+			name:             "health is unauthenticated",
+		},
+		{
+			inputConfig:      &config.HTTP{AuthToken: "secret", MetricsAllowedCIDRs: []string{"192.0.2.0/24"}},
+			inputPath:        "/v1/metrics",
+			inputRemoteAddr:  "192.0.2.10:45678",
+			expectedRespCode: 200,
+			name:             "metrics from allowed network",
+		},
+		{
+			inputConfig:      &config.HTTP{AuthToken: "secret", MetricsAllowedCIDRs: []string{"192.0.2.0/24"}},
+			inputPath:        "/v1/metrics",
+			inputRemoteAddr:  "198.51.100.10:45678",
+			expectedRespCode: 401,
+			name:             "metrics from other network",
+		},
+		{
+			inputConfig:      &config.HTTP{AuthToken: "secret", MetricsAllowedCIDRs: []string{"192.0.2.0/24"}},
+			inputPath:        "/v1/plugins",
+			inputRemoteAddr:  "192.0.2.10:45678",
+			expectedRespCode: 401,
+			name:             "allowed network only applies to metrics",
+		},
+		{
+			inputConfig:      &config.HTTP{TLSCAFile: "ca.crt"},
+			inputPath:        "/v1/metrics",
+			inputVerifiedTLS: true,
+			expectedRespCode: 200,
+			name:             "verified client certificate",
+		},
+		{
+			inputConfig:      &config.HTTP{TLSCAFile: "ca.crt"},
+			inputPath:        "/v1/metrics",
+			inputAuthHeader:  "Bearer secret",
+			expectedRespCode: 401,
+			name:             "missing client certificate without token",
+		},
+	}
+
+	inm := metrics.NewInmemSink(10*time.Second, time.Minute)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auth, err := newAuthenticator(tc.inputConfig)
+			assert.Nil(t, err)
+
+			srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), inm, nil, nil, nil)
+			assert.Nil(t, err)
+			defer srv.ln.Close()
+			atomic.StoreInt32(&srv.aliveness, healthAlivenessReady)
+
+			req := httptest.NewRequest("GET", tc.inputPath, nil)
+			if tc.inputRemoteAddr != "" {
+				req.RemoteAddr = tc.inputRemoteAddr
+			}
+			if tc.inputAuthHeader != "" {
+				req.Header.Set("Authorization", tc.inputAuthHeader)
+			}
+			if tc.inputVerifiedTLS {
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+			}
+
+			w := httptest.NewRecorder()
+			srv.authHandler(auth, srv.mux).ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedRespCode, w.Code, tc.name)
+		})
+	}
+}
+
+func Test_newAuthenticator(t *testing.T) {
+	auth, err := newAuthenticator(&config.HTTP{})
+	assert.Nil(t, err)
+	assert.Nil(t, auth)
+
+	_, err = newAuthenticator(&config.HTTP{AuthToken: "secret", MetricsAllowedCIDRs: []string{"192.0.2.1"}})
+	assert.NotNil(t, err)
+}
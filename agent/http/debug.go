@@ -0,0 +1,19 @@
+package http
+
+import "net/http/pprof"
+
+// debugRoutePattern is the Autoscaler HTTP router pattern which is used to
+// register the pprof profiling endpoints.
+const debugRoutePattern = "/debug/pprof/"
+
+// registerDebugHandlers registers the net/http/pprof handlers, which expose
+// the agent runtime profiles. They are only registered if enabled in the
+// agent config, as the profiles reveal internal details of the agent and
+// collecting them impacts its performance.
+func (s *Server) registerDebugHandlers() {
+	s.mux.HandleFunc(debugRoutePattern, pprof.Index)
+	s.mux.HandleFunc(debugRoutePattern+"cmdline", pprof.Cmdline)
+	s.mux.HandleFunc(debugRoutePattern+"profile", pprof.Profile)
+	s.mux.HandleFunc(debugRoutePattern+"symbol", pprof.Symbol)
+	s.mux.HandleFunc(debugRoutePattern+"trace", pprof.Trace)
+}
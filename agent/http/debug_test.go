@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_debugHandlers(t *testing.T) {
+	testCases := []struct {
+		inputEnableDebug bool
+		inputPath        string
+		expectedCode     int
+		name             string
+	}{
+		{
+			inputEnableDebug: false,
+			inputPath:        "/debug/pprof/",
+			expectedCode:     404,
+			name:             "disabled index",
+		},
+		{
+			inputEnableDebug: false,
+			inputPath:        "/debug/pprof/goroutine",
+			expectedCode:     404,
+			name:             "disabled profile",
+		},
+		{
+			inputEnableDebug: true,
+			inputPath:        "/debug/pprof/",
+			expectedCode:     200,
+			name:             "enabled index",
+		},
+		{
+			inputEnableDebug: true,
+			inputPath:        "/debug/pprof/goroutine?debug=1",
+			expectedCode:     200,
+			name:             "enabled profile",
+		},
+		{
+			inputEnableDebug: true,
+			inputPath:        "/debug/pprof/cmdline",
+			expectedCode:     200,
+			name:             "enabled cmdline",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080, EnableDebug: tc.inputEnableDebug}
+			srv, err := NewHTTPServer(cfg, hclog.NewNullLogger(), nil, nil, nil, nil)
+			assert.Nil(t, err)
+			defer srv.ln.Close()
+
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, httptest.NewRequest("GET", tc.inputPath, nil))
+			assert.Equal(t, tc.expectedCode, w.Code, tc.name)
+		})
+	}
+}
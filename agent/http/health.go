@@ -2,19 +2,32 @@ package http
 
 import (
 	"net/http"
+	"strconv"
 	"sync/atomic"
 )
 
+// headerLeader is the health response header which details whether the agent
+// is the leader, when leader election is enabled.
+const headerLeader = "X-Nomad-Autoscaler-Leader"
+
 // getHealth is the HTTP handler used to respond when a request is made to the
 // health endpoint. The response is based on the aliveness parameter within the
 // httpServer struct.
-func (s *Server) getHealth(_ http.ResponseWriter, r *http.Request) (interface{}, error) {
+func (s *Server) getHealth(w http.ResponseWriter, r *http.Request) (interface{}, error) {
 
 	// Only allow GET requests on this endpoint.
 	if r.Method != http.MethodGet {
 		return nil, newCodedError(http.StatusMethodNotAllowed, errInvalidMethod)
 	}
 
+	// Followers are healthy, so leadership is reported using a header rather
+	// than the response code.
+	if l, ok := s.status.(leadershipReporter); ok {
+		if enabled, leader := l.Leadership(); enabled {
+			w.Header().Set(headerLeader, strconv.FormatBool(leader))
+		}
+	}
+
 	if atomic.LoadInt32(&s.aliveness) == healthAlivenessReady {
 		return nil, nil
 	}
@@ -43,7 +43,7 @@ func TestServer_getHealth(t *testing.T) {
 	}
 
 	// Create our HTTP server.
-	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil)
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
 	assert.Nil(t, err)
 	defer srv.ln.Close()
 
@@ -55,3 +55,56 @@ func TestServer_getHealth(t *testing.T) {
 		})
 	}
 }
+
+// fakeLeadershipReporter is a statusReporter which also reports leadership.
+type fakeLeadershipReporter struct {
+	fakeStatusReporter
+	enabled, leader bool
+}
+
+func (f *fakeLeadershipReporter) Leadership() (bool, bool) { return f.enabled, f.leader }
+
+func TestServer_getHealth_leadership(t *testing.T) {
+	testCases := []struct {
+		inputStatus    statusReporter
+		expectedHeader string
+		name           string
+	}{
+		{
+			inputStatus:    nil,
+			expectedHeader: "",
+			name:           "no status reporter",
+		},
+		{
+			inputStatus:    &fakeLeadershipReporter{enabled: false, leader: true},
+			expectedHeader: "",
+			name:           "leader election disabled",
+		},
+		{
+			inputStatus:    &fakeLeadershipReporter{enabled: true, leader: true},
+			expectedHeader: "true",
+			name:           "leader",
+		},
+		{
+			inputStatus:    &fakeLeadershipReporter{enabled: true, leader: false},
+			expectedHeader: "false",
+			name:           "follower",
+		},
+	}
+
+	// Create our HTTP server.
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+	atomic.StoreInt32(&srv.aliveness, healthAlivenessReady)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv.status = tc.inputStatus
+			w := httptest.NewRecorder()
+			srv.mux.ServeHTTP(w, httptest.NewRequest("GET", "/v1/health", nil))
+			assert.Equal(t, 200, w.Code, tc.name)
+			assert.Equal(t, tc.expectedHeader, w.Header().Get(headerLeader), tc.name)
+		})
+	}
+}
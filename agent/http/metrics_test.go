@@ -49,7 +49,7 @@ func TestServer_getMetrics(t *testing.T) {
 	metrics.DefaultInmemSignal(inm)
 
 	// Create our HTTP server.
-	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), inm)
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), inm, nil, nil, nil)
 	assert.Nil(t, err)
 	defer srv.ln.Close()
 
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
+)
+
+// pluginStateReporter is the interface used by the plugins endpoint to read
+// the state of the plugins managed by the agent.
+type pluginStateReporter interface {
+	PluginStates() []manager.PluginState
+}
+
+// getPlugins is the HTTP handler used to respond when a request is made to the
+// plugins endpoint. The response details the state of each plugin dispensed
+// by the agent, including its driver, health, the redacted config set on it,
+// the most recent output of external plugins and the restarts of their
+// process.
+func (s *Server) getPlugins(_ http.ResponseWriter, r *http.Request) (interface{}, error) {
+
+	// Only allow GET requests on this endpoint.
+	if r.Method != http.MethodGet {
+		return nil, newCodedError(http.StatusMethodNotAllowed, errInvalidMethod)
+	}
+
+	if s.plugins == nil {
+		return []manager.PluginState{}, nil
+	}
+	return s.plugins.PluginStates(), nil
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_getPlugins(t *testing.T) {
+	testCases := []struct {
+		inputReq         *http.Request
+		inputWriter      *httptest.ResponseRecorder
+		expectedRespCode int
+		expectedBody     string
+		name             string
+	}{
+		{
+			inputReq:         httptest.NewRequest("GET", "/v1/plugins", nil),
+			inputWriter:      httptest.NewRecorder(),
+			expectedRespCode: 200,
+			expectedBody:     "[]",
+			name:             "no plugins",
+		},
+		{
+			inputReq:         httptest.NewRequest("PUT", "/v1/plugins", nil),
+			inputWriter:      httptest.NewRecorder(),
+			expectedRespCode: 405,
+			expectedBody:     errInvalidMethod,
+			name:             "incorrect request method",
+		},
+	}
+
+	// Create our HTTP server.
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv.mux.ServeHTTP(tc.inputWriter, tc.inputReq)
+			assert.Equal(t, tc.expectedRespCode, tc.inputWriter.Code, tc.name)
+			assert.Equal(t, tc.expectedBody, tc.inputWriter.Body.String(), tc.name)
+		})
+	}
+}
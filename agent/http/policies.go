@@ -0,0 +1,426 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/policy/file"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/uuid"
+)
+
+// policyOverrider is the interface used by the policies endpoint to manage
+// the count overrides of the policies handled by the agent.
+type policyOverrider interface {
+	SetOverride(id string, o policy.Override) error
+	RemoveOverride(id string) error
+	ActiveOverride(id string) *policy.Override
+}
+
+// policyDescriber is optionally implemented by the statusReporter to allow the
+// policies endpoint to describe the effective configuration of a policy.
+type policyDescriber interface {
+	DescribePolicy(id string) (*PolicyDescription, error)
+}
+
+// scaleDownResetter is optionally implemented by the policyOverrider to allow
+// operators to acknowledge the consecutive scale downs of a policy which
+// reached its limit.
+type scaleDownResetter interface {
+	ResetScaleDowns(id string) error
+}
+
+// policyRegistrar is optionally implemented by the statusReporter to allow
+// policies to be registered and unregistered at runtime.
+type policyRegistrar interface {
+	RegisterPolicy(p *sdk.ScalingPolicy) error
+	UnregisterPolicy(id string) error
+}
+
+// PolicyRegistration is the response of the policy register endpoint.
+type PolicyRegistration struct {
+
+	// ID is the ID of the registered policy, which is used to unregister it.
+	ID string
+}
+
+// PolicyDescription is the response of the policy describe endpoint. It is
+// the effective configuration of the policy as currently applied by the
+// agent, once the agent defaults have been resolved. Durations are formatted
+// as duration strings.
+type PolicyDescription struct {
+	ID       string
+	Source   string
+	Type     string
+	Enabled  bool
+	Priority int
+
+	// Revision is the version of the policy as read from its source, if the
+	// source provides one.
+	Revision string `json:",omitempty"`
+
+	// Min and Max are the effective bounds of the policy. MinDefaulted and
+	// MaxDefaulted indicate the agent default replaced a value omitted by
+	// the policy.
+	Min          int64
+	Max          int64
+	MinDefaulted bool
+	MaxDefaulted bool
+
+	// EvaluationInterval is the effective evaluation interval of the policy.
+	// RequestedEvaluationInterval is the interval set by the policy, only
+	// reported when it was raised to the agent minimum.
+	EvaluationInterval          string
+	RequestedEvaluationInterval string `json:",omitempty"`
+
+	// Cooldown is the cooldown of the policy, and InCooldown indicates the
+	// policy is currently within it until CooldownUntil.
+	Cooldown      string
+	InCooldown    bool
+	CooldownUntil time.Time
+
+	// WarmupPeriod is the warmup period of the policy, and WarmingUp
+	// indicates the policy is currently within it until WarmupUntil.
+	WarmupPeriod string
+	WarmingUp    bool
+	WarmupUntil  time.Time
+
+	// Override is the active count override of the policy, if any.
+	Override *policy.Override `json:",omitempty"`
+
+	// InvalidQueries is the reason the queries of the policy checks are
+	// invalid, which stops the policy from being evaluated, if any.
+	InvalidQueries string `json:",omitempty"`
+
+	LastEvaluation          time.Time
+	ReconcileOnStart        bool
+	MaxScaleStep            int64
+	MaxScalePercent         float64
+	VerifyScaleAfter        string
+	RollbackOnVerifyFailure bool
+	FallbackStrategy        string            `json:",omitempty"`
+	OutOfBoundsAction       string            `json:",omitempty"`
+	Rounding                string            `json:",omitempty"`
+	Labels                  map[string]string `json:",omitempty"`
+
+	// StabilizeCount indicates the target count must be stable across two
+	// reads, StabilizeCountDelay apart, before it is acted upon.
+	StabilizeCount      bool
+	StabilizeCountDelay string `json:",omitempty"`
+
+	// ScaleDownStabilizationWindow is the window over which the highest
+	// recommended count limits scale downs, if configured.
+	ScaleDownStabilizationWindow string `json:",omitempty"`
+
+	// MinHealthyPercentage is the percentage of the target count which must
+	// be healthy for the target to be scaled down, if configured.
+	MinHealthyPercentage float64 `json:",omitempty"`
+
+	// MaxEvaluationInterval is the longest the evaluation interval of a stable
+	// policy grows to, and StableEvaluations the number of evaluations in a
+	// row without a scaling action after which it starts growing, if the
+	// adaptive evaluation interval is configured.
+	MaxEvaluationInterval string `json:",omitempty"`
+	StableEvaluations     int64  `json:",omitempty"`
+
+	// MaxConsecutiveScaleDowns is the number of scale downs the policy can
+	// perform in a row, and ConsecutiveScaleDownsReset the interval after
+	// which the count is reset, if configured.
+	MaxConsecutiveScaleDowns   int64  `json:",omitempty"`
+	ConsecutiveScaleDownsReset string `json:",omitempty"`
+
+	// ScaleDownStep is the most a single scale down removes from the count,
+	// and ScaleDownStepDelay the time waited before the next step down, if
+	// configured.
+	ScaleDownStep      int64  `json:",omitempty"`
+	ScaleDownStepDelay string `json:",omitempty"`
+
+	// ScaleUpProbation is the time further scale ups are suppressed after a
+	// scale up, if configured.
+	ScaleUpProbation string `json:",omitempty"`
+
+	// DependsOn lists the IDs of the policies which must be stable before
+	// the policy is evaluated, and DependsOnWindow the time they must not
+	// have scaled for, if configured.
+	DependsOn       []string `json:",omitempty"`
+	DependsOnWindow string   `json:",omitempty"`
+
+	// DeferDuringDeployment indicates scaling is skipped while a deployment
+	// of the target is in progress.
+	DeferDuringDeployment bool
+
+	// Advisory indicates the policy only recommends scaling actions, and
+	// Advice is its last recommendation, if any.
+	Advisory bool
+	Advice   *policy.Advice `json:",omitempty"`
+
+	// MetricMin and MetricMax are the range of acceptable APM values, if
+	// configured.
+	MetricMin *float64 `json:",omitempty"`
+	MetricMax *float64 `json:",omitempty"`
+
+	// MetricUnit is the unit used to format the APM values within the logs
+	// and events of the agent, if configured.
+	MetricUnit string `json:",omitempty"`
+
+	// Cron is the schedule evaluating the policy in place of the evaluation
+	// interval, and CronTimeZone the time zone it is evaluated in, if set.
+	Cron         string `json:",omitempty"`
+	CronTimeZone string `json:",omitempty"`
+
+	// ScheduledMins are the soft minimum counts applied on top of Min during
+	// the windows of their schedules, if configured.
+	ScheduledMins []PolicyScheduledMinDescription `json:",omitempty"`
+
+	// Target and Checks identify the plugins referenced by the policy along
+	// with their configuration, and ShadowTarget the target mirroring the
+	// scaling actions of Target, if configured.
+	Target       *sdk.ScalingPolicyTarget
+	ShadowTarget *sdk.ScalingPolicyTarget `json:",omitempty"`
+	Checks       []PolicyCheckDescription
+}
+
+// PolicyScheduledMinDescription is a single scheduled minimum within the
+// policy describe endpoint response. Active indicates whether a window of its
+// schedule is in progress.
+type PolicyScheduledMinDescription struct {
+	Name     string
+	Cron     string
+	TimeZone string `json:",omitempty"`
+	Duration string
+	Min      int64
+	Active   bool
+}
+
+// PolicyCheckDescription is a single check within the policy describe
+// endpoint response.
+type PolicyCheckDescription struct {
+	Name            string
+	Source          string
+	FailoverSources []string `json:",omitempty"`
+	Query           string
+	QueryWindow     string
+	Strategy        *sdk.ScalingPolicyStrategy
+	Transforms      []*sdk.ScalingPolicyTransform `json:",omitempty"`
+
+	// TargetPercentage is the percentage of the metric the target is scaled
+	// to, if configured.
+	TargetPercentage float64 `json:",omitempty"`
+}
+
+// overrideRequest is the request body used to set a policy count override.
+type overrideRequest struct {
+
+	// Count is the exact count the policy target is scaled to.
+	Count *int64
+
+	// Duration is the time the override remains active for, in the format
+	// accepted by time.ParseDuration.
+	Duration string
+}
+
+// policySpecificRequest is the HTTP handler used to respond to requests made
+// to a specific policy. The supported paths are /v1/policies/{id}/override
+// which allows reading, setting and removing the policy count override,
+// /v1/policies/{id}/describe which reports the effective policy config, and
+// /v1/policies/{id} which unregisters a policy registered at runtime.
+func (s *Server) policySpecificRequest(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	path := strings.TrimPrefix(r.URL.Path, policiesRoutePattern)
+
+	var handler func(string, *http.Request) (interface{}, error)
+	switch {
+	case strings.HasSuffix(path, "/override"):
+		path, handler = strings.TrimSuffix(path, "/override"), s.policyOverrideRequest
+	case strings.HasSuffix(path, "/acknowledge"):
+		path, handler = strings.TrimSuffix(path, "/acknowledge"), s.policyAcknowledgeRequest
+	case strings.HasSuffix(path, "/describe"):
+		path, handler = strings.TrimSuffix(path, "/describe"), s.policyDescribeRequest
+	case r.Method == http.MethodDelete && !strings.Contains(path, "/"):
+		handler = s.unregisterPolicyRequest
+	default:
+		return nil, newCodedError(http.StatusNotFound, "Invalid policy path")
+	}
+
+	if path == "" {
+		return nil, newCodedError(http.StatusBadRequest, "Missing policy ID")
+	}
+	return handler(path, r)
+}
+
+// policyOverrideRequest handles the requests made to the override path of the
+// policy.
+func (s *Server) policyOverrideRequest(id string, r *http.Request) (interface{}, error) {
+	if s.policies == nil {
+		return nil, newCodedError(http.StatusNotFound, policy.ErrPolicyNotFound.Error())
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		return s.getPolicyOverride(id)
+	case http.MethodPost, http.MethodPut:
+		return s.setPolicyOverride(id, r)
+	case http.MethodDelete:
+		return s.removePolicyOverride(id)
+	default:
+		return nil, newCodedError(http.StatusMethodNotAllowed, errInvalidMethod)
+	}
+}
+
+// policyAcknowledgeRequest handles the requests made to the acknowledge path
+// of the policy. Acknowledging a policy resets its count of consecutive scale
+// downs, so it can scale the target down again once the limit was reached.
+func (s *Server) policyAcknowledgeRequest(id string, r *http.Request) (interface{}, error) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		return nil, newCodedError(http.StatusMethodNotAllowed, errInvalidMethod)
+	}
+
+	resetter, ok := s.policies.(scaleDownResetter)
+	if !ok {
+		return nil, newCodedError(http.StatusNotFound, policy.ErrPolicyNotFound.Error())
+	}
+
+	if err := resetter.ResetScaleDowns(id); err != nil {
+		return nil, policyError(err)
+	}
+	return nil, nil
+}
+
+// policyDescribeRequest handles the requests made to the describe path of the
+// policy. Describing a policy only reads the agent state, it does not trigger
+// an evaluation.
+func (s *Server) policyDescribeRequest(id string, r *http.Request) (interface{}, error) {
+	if r.Method != http.MethodGet {
+		return nil, newCodedError(http.StatusMethodNotAllowed, errInvalidMethod)
+	}
+
+	d, ok := s.status.(policyDescriber)
+	if !ok {
+		return nil, newCodedError(http.StatusNotFound, policy.ErrPolicyNotFound.Error())
+	}
+
+	desc, err := d.DescribePolicy(id)
+	if err != nil {
+		return nil, policyError(err)
+	}
+	return desc, nil
+}
+
+// registerPolicyRequest is the HTTP handler used to register a policy at
+// runtime. The policy is written in the same format as file policies and is
+// decoded as JSON if the request Content-Type is application/json, otherwise
+// as HCL. The policy ID is read from the id query parameter, or generated if
+// it is omitted. Registering an ID again replaces the policy.
+func (s *Server) registerPolicyRequest(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		return nil, newCodedError(http.StatusMethodNotAllowed, errInvalidMethod)
+	}
+
+	reg, ok := s.status.(policyRegistrar)
+	if !ok {
+		return nil, newCodedError(http.StatusNotFound, "Policy registration not supported")
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		id = uuid.Generate()
+	}
+	if strings.Contains(id, "/") {
+		return nil, newCodedError(http.StatusBadRequest, "Policy ID must not contain '/'")
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, newCodedError(http.StatusBadRequest, fmt.Sprintf("Failed to read request: %v", err))
+	}
+
+	// The filename suffix is used by the decoder to identify the format.
+	filename := id + ".hcl"
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		filename = id + ".json"
+	}
+
+	p := &sdk.ScalingPolicy{}
+	if err := file.Decode(filename, body, p); err != nil {
+		return nil, newCodedError(http.StatusBadRequest, fmt.Sprintf("Failed to decode policy: %v", err))
+	}
+	p.ID = id
+
+	if err := reg.RegisterPolicy(p); err != nil {
+		if err == policy.ErrPolicyConflict {
+			return nil, newCodedError(http.StatusConflict, err.Error())
+		}
+		return nil, newCodedError(http.StatusBadRequest, fmt.Sprintf("Invalid policy: %v", err))
+	}
+	return &PolicyRegistration{ID: id}, nil
+}
+
+// unregisterPolicyRequest handles the requests made to unregister a policy
+// registered at runtime. Policies from other sources are not found.
+func (s *Server) unregisterPolicyRequest(id string, r *http.Request) (interface{}, error) {
+	reg, ok := s.status.(policyRegistrar)
+	if !ok {
+		return nil, newCodedError(http.StatusNotFound, policy.ErrPolicyNotFound.Error())
+	}
+
+	if err := reg.UnregisterPolicy(id); err != nil {
+		return nil, policyError(err)
+	}
+	return nil, nil
+}
+
+func (s *Server) getPolicyOverride(id string) (interface{}, error) {
+	o := s.policies.ActiveOverride(id)
+	if o == nil {
+		return nil, newCodedError(http.StatusNotFound, "Policy override not found")
+	}
+	return o, nil
+}
+
+func (s *Server) setPolicyOverride(id string, r *http.Request) (interface{}, error) {
+	var req overrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, newCodedError(http.StatusBadRequest, fmt.Sprintf("Failed to decode request: %v", err))
+	}
+
+	if req.Count == nil {
+		return nil, newCodedError(http.StatusBadRequest, "Count must be set")
+	}
+	if *req.Count < 0 {
+		return nil, newCodedError(http.StatusBadRequest, "Count must not be negative")
+	}
+
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return nil, newCodedError(http.StatusBadRequest, fmt.Sprintf("Invalid duration: %v", err))
+	}
+	if d <= 0 {
+		return nil, newCodedError(http.StatusBadRequest, "Duration must be positive")
+	}
+
+	o := policy.Override{Count: *req.Count, Expiry: time.Now().Add(d)}
+	if err := s.policies.SetOverride(id, o); err != nil {
+		return nil, policyError(err)
+	}
+	return o, nil
+}
+
+func (s *Server) removePolicyOverride(id string) (interface{}, error) {
+	if err := s.policies.RemoveOverride(id); err != nil {
+		return nil, policyError(err)
+	}
+	return nil, nil
+}
+
+// policyError translates errors returned by the policy manager into coded
+// errors.
+func policyError(err error) error {
+	if err == policy.ErrPolicyNotFound {
+		return newCodedError(http.StatusNotFound, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,545 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePolicyOverrider is an in-memory implementation of policyOverrider which
+// handles a single policy with the ID "policy1".
+type fakePolicyOverrider struct {
+	override *policy.Override
+}
+
+func (f *fakePolicyOverrider) SetOverride(id string, o policy.Override) error {
+	if id != "policy1" {
+		return policy.ErrPolicyNotFound
+	}
+	f.override = &o
+	return nil
+}
+
+func (f *fakePolicyOverrider) RemoveOverride(id string) error {
+	if id != "policy1" {
+		return policy.ErrPolicyNotFound
+	}
+	f.override = nil
+	return nil
+}
+
+func (f *fakePolicyOverrider) ActiveOverride(id string) *policy.Override {
+	if id != "policy1" {
+		return nil
+	}
+	return f.override
+}
+
+func TestServer_policySpecificRequest(t *testing.T) {
+	testCases := []struct {
+		inputMethod      string
+		inputPath        string
+		inputBody        string
+		inputOverride    *policy.Override
+		expectedRespCode int
+		expectedOverride *policy.Override
+		name             string
+	}{
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies/policy1/override",
+			inputBody:        `{"Count": 3, "Duration": "1h"}`,
+			expectedRespCode: 200,
+			expectedOverride: &policy.Override{Count: 3},
+			name:             "set override",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies/policy2/override",
+			inputBody:        `{"Count": 3, "Duration": "1h"}`,
+			expectedRespCode: 404,
+			name:             "set override on unknown policy",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies/policy1/override",
+			inputBody:        `{"Duration": "1h"}`,
+			expectedRespCode: 400,
+			name:             "missing count",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies/policy1/override",
+			inputBody:        `{"Count": -1, "Duration": "1h"}`,
+			expectedRespCode: 400,
+			name:             "negative count",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies/policy1/override",
+			inputBody:        `{"Count": 3, "Duration": "soon"}`,
+			expectedRespCode: 400,
+			name:             "invalid duration",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies/policy1/override",
+			inputBody:        `{"Count": 3, "Duration": "-1h"}`,
+			expectedRespCode: 400,
+			name:             "negative duration",
+		},
+		{
+			inputMethod:      "GET",
+			inputPath:        "/v1/policies/policy1/override",
+			inputOverride:    &policy.Override{Count: 3},
+			expectedRespCode: 200,
+			expectedOverride: &policy.Override{Count: 3},
+			name:             "get override",
+		},
+		{
+			inputMethod:      "GET",
+			inputPath:        "/v1/policies/policy1/override",
+			expectedRespCode: 404,
+			name:             "get missing override",
+		},
+		{
+			inputMethod:      "DELETE",
+			inputPath:        "/v1/policies/policy1/override",
+			inputOverride:    &policy.Override{Count: 3},
+			expectedRespCode: 200,
+			expectedOverride: nil,
+			name:             "remove override",
+		},
+		{
+			inputMethod:      "PATCH",
+			inputPath:        "/v1/policies/policy1/override",
+			expectedRespCode: 405,
+			name:             "incorrect request method",
+		},
+		{
+			inputMethod:      "GET",
+			inputPath:        "/v1/policies/policy1",
+			expectedRespCode: 404,
+			name:             "unknown path",
+		},
+	}
+
+	// Create our HTTP server.
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			overrider := &fakePolicyOverrider{override: tc.inputOverride}
+			srv.policies = overrider
+
+			req := httptest.NewRequest(tc.inputMethod, tc.inputPath, strings.NewReader(tc.inputBody))
+			w := httptest.NewRecorder()
+
+			srv.mux.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedRespCode, w.Code, tc.name)
+
+			// The expiry is set relative to the request time, so only
+			// compare the count.
+			if tc.expectedOverride == nil {
+				if tc.expectedRespCode == 200 {
+					assert.Nil(t, overrider.override, tc.name)
+				}
+				return
+			}
+			assert.NotNil(t, overrider.override, tc.name)
+			assert.Equal(t, tc.expectedOverride.Count, overrider.override.Count, tc.name)
+		})
+	}
+}
+
+func TestServer_policySpecificRequest_expiry(t *testing.T) {
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+
+	overrider := &fakePolicyOverrider{}
+	srv.policies = overrider
+
+	req := httptest.NewRequest("POST", "/v1/policies/policy1/override", strings.NewReader(`{"Count": 3, "Duration": "30m"}`))
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	srv.mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.WithinDuration(t, start.Add(30*time.Minute), overrider.override.Expiry, time.Minute)
+}
+
+// fakeScaleDownResetter is a policyOverrider which also resets the
+// consecutive scale downs of a single policy with the ID "policy1".
+type fakeScaleDownResetter struct {
+	fakePolicyOverrider
+	reset bool
+}
+
+func (f *fakeScaleDownResetter) ResetScaleDowns(id string) error {
+	if id != "policy1" {
+		return policy.ErrPolicyNotFound
+	}
+	f.reset = true
+	return nil
+}
+
+func TestServer_policySpecificRequest_acknowledge(t *testing.T) {
+	testCases := []struct {
+		inputMethod      string
+		inputPath        string
+		inputResetter    bool
+		expectedRespCode int
+		expectedReset    bool
+		name             string
+	}{
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies/policy1/acknowledge",
+			inputResetter:    true,
+			expectedRespCode: 200,
+			expectedReset:    true,
+			name:             "acknowledge policy",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies/policy2/acknowledge",
+			inputResetter:    true,
+			expectedRespCode: 404,
+			name:             "acknowledge unknown policy",
+		},
+		{
+			inputMethod:      "GET",
+			inputPath:        "/v1/policies/policy1/acknowledge",
+			inputResetter:    true,
+			expectedRespCode: 405,
+			name:             "incorrect request method",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies/policy1/acknowledge",
+			expectedRespCode: 404,
+			name:             "acknowledge not supported",
+		},
+	}
+
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetter := &fakeScaleDownResetter{}
+			srv.policies = &resetter.fakePolicyOverrider
+			if tc.inputResetter {
+				srv.policies = resetter
+			}
+
+			req := httptest.NewRequest(tc.inputMethod, tc.inputPath, nil)
+			w := httptest.NewRecorder()
+
+			srv.mux.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedRespCode, w.Code, tc.name)
+			assert.Equal(t, tc.expectedReset, resetter.reset, tc.name)
+		})
+	}
+}
+
+// fakePolicyDescriber is a statusReporter which also describes a single
+// policy with the ID "policy1".
+type fakePolicyDescriber struct {
+	fakeStatusReporter
+	err error
+}
+
+func (f *fakePolicyDescriber) DescribePolicy(id string) (*PolicyDescription, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if id != "policy1" {
+		return nil, policy.ErrPolicyNotFound
+	}
+	return &PolicyDescription{ID: id, Min: 1, Max: 5, EvaluationInterval: "10s"}, nil
+}
+
+func TestServer_policySpecificRequest_describe(t *testing.T) {
+	testCases := []struct {
+		inputMethod      string
+		inputPath        string
+		inputStatus      statusReporter
+		expectedRespCode int
+		expectedRespBody string
+		name             string
+	}{
+		{
+			inputMethod:      "GET",
+			inputPath:        "/v1/policies/policy1/describe",
+			inputStatus:      &fakePolicyDescriber{},
+			expectedRespCode: 200,
+			expectedRespBody: `"ID":"policy1"`,
+			name:             "describe policy",
+		},
+		{
+			inputMethod:      "GET",
+			inputPath:        "/v1/policies/policy2/describe",
+			inputStatus:      &fakePolicyDescriber{},
+			expectedRespCode: 404,
+			name:             "describe unknown policy",
+		},
+		{
+			inputMethod:      "GET",
+			inputPath:        "/v1/policies/policy1/describe",
+			inputStatus:      &fakePolicyDescriber{err: errors.New("policy max not set")},
+			expectedRespCode: 500,
+			expectedRespBody: "policy max not set",
+			name:             "describe error",
+		},
+		{
+			inputMethod:      "GET",
+			inputPath:        "/v1/policies/policy1/describe",
+			inputStatus:      &fakeStatusReporter{},
+			expectedRespCode: 404,
+			name:             "describe not supported",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies/policy1/describe",
+			inputStatus:      &fakePolicyDescriber{},
+			expectedRespCode: 405,
+			name:             "incorrect request method",
+		},
+	}
+
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv.status = tc.inputStatus
+
+			req := httptest.NewRequest(tc.inputMethod, tc.inputPath, nil)
+			w := httptest.NewRecorder()
+
+			srv.mux.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedRespCode, w.Code, tc.name)
+			assert.Contains(t, w.Body.String(), tc.expectedRespBody, tc.name)
+		})
+	}
+}
+
+// fakePolicyRegistrar is a statusReporter which also registers policies in
+// memory. The ID "policy-file" is used by a policy from another source.
+type fakePolicyRegistrar struct {
+	fakeStatusReporter
+	policies map[string]*sdk.ScalingPolicy
+}
+
+func (f *fakePolicyRegistrar) RegisterPolicy(p *sdk.ScalingPolicy) error {
+	if p.ID == "policy-file" {
+		return policy.ErrPolicyConflict
+	}
+	if p.Max < p.Min {
+		return errors.New("policy Min must not be greater Max")
+	}
+	f.policies[p.ID] = p
+	return nil
+}
+
+func (f *fakePolicyRegistrar) UnregisterPolicy(id string) error {
+	if _, ok := f.policies[id]; !ok {
+		return policy.ErrPolicyNotFound
+	}
+	delete(f.policies, id)
+	return nil
+}
+
+func TestServer_registerPolicyRequest(t *testing.T) {
+	hclPolicy := `
+min = 1
+max = 5
+
+policy {
+  target "aws-asg" {}
+}
+`
+	jsonPolicy := `{"min": 1, "max": 5, "policy": [{"target": [{"aws-asg": [{}]}]}]}`
+
+	testCases := []struct {
+		inputMethod      string
+		inputPath        string
+		inputBody        string
+		inputContentType string
+		inputStatus      statusReporter
+		expectedRespCode int
+		expectedRespBody string
+		expectedMax      int64
+		name             string
+	}{
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies?id=policy1",
+			inputBody:        hclPolicy,
+			expectedRespCode: 200,
+			expectedRespBody: `"ID":"policy1"`,
+			expectedMax:      5,
+			name:             "register hcl policy",
+		},
+		{
+			inputMethod:      "PUT",
+			inputPath:        "/v1/policies?id=policy1",
+			inputBody:        jsonPolicy,
+			inputContentType: "application/json",
+			expectedRespCode: 200,
+			expectedRespBody: `"ID":"policy1"`,
+			expectedMax:      5,
+			name:             "register json policy",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies",
+			inputBody:        hclPolicy,
+			expectedRespCode: 200,
+			expectedRespBody: `"ID":"`,
+			name:             "register policy with generated id",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies?id=policy1",
+			inputBody:        "max = ",
+			expectedRespCode: 400,
+			expectedRespBody: "Failed to decode policy",
+			name:             "undecodable policy",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies?id=policy1",
+			inputBody:        strings.Replace(hclPolicy, "min = 1", "min = 8", 1),
+			expectedRespCode: 400,
+			expectedRespBody: "Invalid policy",
+			name:             "invalid policy",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies?id=policy-file",
+			inputBody:        hclPolicy,
+			expectedRespCode: 409,
+			name:             "policy from another source",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies?id=a/b",
+			inputBody:        hclPolicy,
+			expectedRespCode: 400,
+			name:             "invalid id",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/policies?id=policy1",
+			inputBody:        hclPolicy,
+			inputStatus:      &fakeStatusReporter{},
+			expectedRespCode: 404,
+			name:             "registration not supported",
+		},
+		{
+			inputMethod:      "GET",
+			inputPath:        "/v1/policies",
+			expectedRespCode: 405,
+			name:             "incorrect request method",
+		},
+	}
+
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registrar := &fakePolicyRegistrar{policies: map[string]*sdk.ScalingPolicy{}}
+			srv.status = registrar
+			if tc.inputStatus != nil {
+				srv.status = tc.inputStatus
+			}
+
+			req := httptest.NewRequest(tc.inputMethod, tc.inputPath, strings.NewReader(tc.inputBody))
+			if tc.inputContentType != "" {
+				req.Header.Set("Content-Type", tc.inputContentType)
+			}
+			w := httptest.NewRecorder()
+
+			srv.mux.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedRespCode, w.Code, tc.name)
+			assert.Contains(t, w.Body.String(), tc.expectedRespBody, tc.name)
+
+			if tc.expectedMax != 0 {
+				assert.Equal(t, tc.expectedMax, registrar.policies["policy1"].Max, tc.name)
+			}
+		})
+	}
+}
+
+func TestServer_unregisterPolicyRequest(t *testing.T) {
+	testCases := []struct {
+		inputPath        string
+		inputStatus      statusReporter
+		expectedRespCode int
+		name             string
+	}{
+		{
+			inputPath:        "/v1/policies/policy1",
+			expectedRespCode: 200,
+			name:             "unregister policy",
+		},
+		{
+			inputPath:        "/v1/policies/policy2",
+			expectedRespCode: 404,
+			name:             "unregister unknown policy",
+		},
+		{
+			inputPath:        "/v1/policies/",
+			expectedRespCode: 400,
+			name:             "missing policy id",
+		},
+		{
+			inputPath:        "/v1/policies/policy1",
+			inputStatus:      &fakeStatusReporter{},
+			expectedRespCode: 404,
+			name:             "registration not supported",
+		},
+	}
+
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registrar := &fakePolicyRegistrar{policies: map[string]*sdk.ScalingPolicy{"policy1": {ID: "policy1"}}}
+			srv.status = registrar
+			if tc.inputStatus != nil {
+				srv.status = tc.inputStatus
+			}
+
+			req := httptest.NewRequest("DELETE", tc.inputPath, nil)
+			w := httptest.NewRecorder()
+
+			srv.mux.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedRespCode, w.Code, tc.name)
+
+			if tc.expectedRespCode == 200 {
+				assert.NotContains(t, registrar.policies, "policy1", tc.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	// scalingPreviewRoutePattern is the Autoscaler HTTP router pattern which
+	// is used to register the scaling preview endpoint.
+	scalingPreviewRoutePattern = "/v1/scaling/preview"
+
+	// scalingPreviewTimeout is the time allowed to evaluate the policies for
+	// the scaling preview, which is kept below the server write timeout.
+	scalingPreviewTimeout = 10 * time.Second
+)
+
+// scalingPreviewer is optionally implemented by the statusReporter to allow
+// the scaling preview endpoint to evaluate the policies without scaling.
+type scalingPreviewer interface {
+	ScalingPreview(ctx context.Context) (*ScalingPreview, error)
+}
+
+// ScalingPreview is the response of the scaling preview endpoint. It is the
+// outcome each policy evaluation would have if it ran now.
+type ScalingPreview struct {
+	Policies []PolicyPreview
+}
+
+// PolicyPreview is the outcome of a single policy within the scaling preview
+// endpoint response.
+type PolicyPreview struct {
+	ID     string
+	Target string
+
+	// Outcome is one of none, scale, suppressed, advisory or error.
+	Outcome string
+
+	// Count is the current count of the target, DesiredCount the count
+	// wanted by the policy, and ProposedCount the count the target would be
+	// scaled to once the policy limits and guards are applied.
+	Count         int64
+	DesiredCount  int64
+	ProposedCount int64
+	Direction     string
+
+	// Check is the check which decided the action and Reason its reason, if
+	// any.
+	Check  string `json:",omitempty"`
+	Reason string `json:",omitempty"`
+
+	// Guards are the guards which would suppress or modify the action.
+	Guards []PolicyPreviewGuard `json:",omitempty"`
+
+	// Error is the reason the policy, or some of its checks, could not be
+	// evaluated.
+	Error string `json:",omitempty"`
+}
+
+// PolicyPreviewGuard is a guard within the scaling preview endpoint response.
+// Effect is suppress or modify, and Count the count allowed by a guard which
+// modifies the action.
+type PolicyPreviewGuard struct {
+	Name   string
+	Effect string
+	Count  int64 `json:",omitempty"`
+}
+
+// getScalingPreview is the HTTP handler used to respond when a request is
+// made to the scaling preview endpoint. The policies are evaluated once
+// without scaling their targets, so the preview is safe to request at any
+// time.
+func (s *Server) getScalingPreview(_ http.ResponseWriter, r *http.Request) (interface{}, error) {
+	if r.Method != http.MethodGet {
+		return nil, newCodedError(http.StatusMethodNotAllowed, errInvalidMethod)
+	}
+
+	p, ok := s.status.(scalingPreviewer)
+	if !ok {
+		return nil, newCodedError(http.StatusNotFound, "Scaling preview not supported")
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), scalingPreviewTimeout)
+	defer cancel()
+
+	preview, err := p.ScalingPreview(ctx)
+	if err != nil {
+		return nil, newCodedError(http.StatusServiceUnavailable, err.Error())
+	}
+	return preview, nil
+}
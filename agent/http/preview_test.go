@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeScalingPreviewer is a statusReporter which also returns a fixed scaling
+// preview.
+type fakeScalingPreviewer struct {
+	fakeStatusReporter
+	err error
+}
+
+func (f *fakeScalingPreviewer) ScalingPreview(_ context.Context) (*ScalingPreview, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ScalingPreview{Policies: []PolicyPreview{{
+		ID:            "policy1",
+		Target:        "nomad-target",
+		Outcome:       "suppressed",
+		Count:         2,
+		DesiredCount:  5,
+		ProposedCount: 5,
+		Direction:     "up",
+		Guards:        []PolicyPreviewGuard{{Name: "not_leader", Effect: "suppress"}},
+	}}}, nil
+}
+
+func TestServer_getScalingPreview(t *testing.T) {
+	testCases := []struct {
+		inputMethod      string
+		inputStatus      statusReporter
+		expectedRespCode int
+		expectedRespBody string
+		name             string
+	}{
+		{
+			inputMethod:      "GET",
+			inputStatus:      &fakeScalingPreviewer{},
+			expectedRespCode: 200,
+			expectedRespBody: `{"Policies":[{"Count":2,"DesiredCount":5,"Direction":"up",` +
+				`"Guards":[{"Effect":"suppress","Name":"not_leader"}],"ID":"policy1",` +
+				`"Outcome":"suppressed","ProposedCount":5,"Target":"nomad-target"}]}`,
+			name: "preview",
+		},
+		{
+			inputMethod:      "GET",
+			inputStatus:      &fakeScalingPreviewer{err: errors.New("policy evaluation has not started")},
+			expectedRespCode: 503,
+			expectedRespBody: "policy evaluation has not started",
+			name:             "preview unavailable",
+		},
+		{
+			inputMethod:      "GET",
+			inputStatus:      &fakeStatusReporter{},
+			expectedRespCode: 404,
+			name:             "preview not supported",
+		},
+		{
+			inputMethod:      "POST",
+			inputStatus:      &fakeScalingPreviewer{},
+			expectedRespCode: 405,
+			name:             "incorrect request method",
+		},
+	}
+
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv.status = tc.inputStatus
+
+			req := httptest.NewRequest(tc.inputMethod, scalingPreviewRoutePattern, nil)
+			w := httptest.NewRecorder()
+
+			srv.mux.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedRespCode, w.Code, tc.name)
+			assert.Contains(t, w.Body.String(), tc.expectedRespBody, tc.name)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// pushMetricRoutePattern is the Autoscaler HTTP router pattern which is used
+// to register the metric push endpoint. Metric values are pushed to
+// /v1/metrics/{name}.
+const pushMetricRoutePattern = metricsRoutePattern + "/"
+
+// maxPushMetricBodySize is the maximum size in bytes of the request body used
+// to push a metric value.
+const maxPushMetricBodySize = 4096
+
+// metricPusher is optionally implemented by the statusReporter to allow
+// external systems to push metric values to the agent, which are served to
+// policies by the push APM.
+type metricPusher interface {
+	PushMetric(name string, value float64) error
+}
+
+// pushMetricRequest is the request body used to push a metric value.
+type pushMetricRequest struct {
+	Value *float64
+}
+
+// pushMetric is the HTTP handler used to respond when a metric value is
+// pushed to the agent. The value replaces any previous value of the metric.
+func (s *Server) pushMetric(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		return nil, newCodedError(http.StatusMethodNotAllowed, errInvalidMethod)
+	}
+
+	p, ok := s.status.(metricPusher)
+	if !ok {
+		return nil, newCodedError(http.StatusNotFound, "Metric push not supported")
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, pushMetricRoutePattern)
+	if name == "" {
+		return nil, newCodedError(http.StatusBadRequest, "Missing metric name")
+	}
+
+	var req pushMetricRequest
+	body := http.MaxBytesReader(w, r.Body, maxPushMetricBodySize)
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		return nil, newCodedError(http.StatusBadRequest, fmt.Sprintf("Failed to decode request: %v", err))
+	}
+	if req.Value == nil {
+		return nil, newCodedError(http.StatusBadRequest, "Value must be set")
+	}
+
+	if err := p.PushMetric(name, *req.Value); err != nil {
+		return nil, newCodedError(http.StatusBadRequest, fmt.Sprintf("Invalid metric: %v", err))
+	}
+	return nil, nil
+}
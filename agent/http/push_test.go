@@ -0,0 +1,143 @@
+package http
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMetricPusher is a statusReporter which also records the pushed metric
+// values.
+type fakeMetricPusher struct {
+	fakeStatusReporter
+	err    error
+	values map[string]float64
+}
+
+func (f *fakeMetricPusher) PushMetric(name string, value float64) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.values[name] = value
+	return nil
+}
+
+func TestServer_pushMetric(t *testing.T) {
+	testCases := []struct {
+		inputMethod      string
+		inputPath        string
+		inputBody        string
+		inputErr         error
+		inputUnsupported bool
+		expectedRespCode int
+		expectedRespBody string
+		expectedValues   map[string]float64
+		name             string
+	}{
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/metrics/events_per_second",
+			inputBody:        `{"Value": 12.5}`,
+			expectedRespCode: 200,
+			expectedValues:   map[string]float64{"events_per_second": 12.5},
+			name:             "push value",
+		},
+		{
+			inputMethod:      "PUT",
+			inputPath:        "/v1/metrics/queue_depth",
+			inputBody:        `{"Value": 0}`,
+			expectedRespCode: 200,
+			expectedValues:   map[string]float64{"queue_depth": 0},
+			name:             "push zero value",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/metrics/",
+			inputBody:        `{"Value": 1}`,
+			expectedRespCode: 400,
+			expectedRespBody: "Missing metric name",
+			expectedValues:   map[string]float64{},
+			name:             "missing name",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/metrics/events_per_second",
+			inputBody:        `{}`,
+			expectedRespCode: 400,
+			expectedRespBody: "Value must be set",
+			expectedValues:   map[string]float64{},
+			name:             "missing value",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/metrics/events_per_second",
+			inputBody:        `{"Value": "high"}`,
+			expectedRespCode: 400,
+			expectedRespBody: "Failed to decode request",
+			expectedValues:   map[string]float64{},
+			name:             "malformed value",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/metrics/events_per_second",
+			inputBody:        `{"Value": 1, "Padding": "` + strings.Repeat("a", maxPushMetricBodySize) + `"}`,
+			expectedRespCode: 400,
+			expectedRespBody: "request body too large",
+			expectedValues:   map[string]float64{},
+			name:             "body too large",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/metrics/a/b",
+			inputBody:        `{"Value": 1}`,
+			inputErr:         errors.New(`metric name "a/b" must not contain '/'`),
+			expectedRespCode: 400,
+			expectedRespBody: "Invalid metric",
+			expectedValues:   map[string]float64{},
+			name:             "rejected metric",
+		},
+		{
+			inputMethod:      "POST",
+			inputPath:        "/v1/metrics/events_per_second",
+			inputBody:        `{"Value": 1}`,
+			inputUnsupported: true,
+			expectedRespCode: 404,
+			expectedValues:   map[string]float64{},
+			name:             "push not supported",
+		},
+		{
+			inputMethod:      "GET",
+			inputPath:        "/v1/metrics/events_per_second",
+			expectedRespCode: 405,
+			expectedValues:   map[string]float64{},
+			name:             "incorrect request method",
+		},
+	}
+
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pusher := &fakeMetricPusher{err: tc.inputErr, values: map[string]float64{}}
+			srv.status = pusher
+			if tc.inputUnsupported {
+				srv.status = &fakeStatusReporter{}
+			}
+
+			req := httptest.NewRequest(tc.inputMethod, tc.inputPath, strings.NewReader(tc.inputBody))
+			w := httptest.NewRecorder()
+
+			srv.mux.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedRespCode, w.Code, tc.name)
+			assert.Contains(t, w.Body.String(), tc.expectedRespBody, tc.name)
+			assert.Equal(t, tc.expectedValues, pusher.values, tc.name)
+		})
+	}
+}
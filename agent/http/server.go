@@ -13,6 +13,8 @@ import (
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-msgpack/codec"
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
+	"github.com/hashicorp/nomad-autoscaler/policy"
 )
 
 const (
@@ -24,6 +26,22 @@ const (
 	// to register the metrics server endpoint.
 	metricsRoutePattern = "/v1/metrics"
 
+	// pluginsRoutePattern is the Autoscaler HTTP router pattern which is used
+	// to register the plugins server endpoint.
+	pluginsRoutePattern = "/v1/plugins"
+
+	// policiesRoutePattern is the Autoscaler HTTP router pattern which is
+	// used to register the policy specific server endpoints.
+	policiesRoutePattern = "/v1/policies/"
+
+	// registerPolicyRoutePattern is the Autoscaler HTTP router pattern which
+	// is used to register the endpoint registering policies at runtime.
+	registerPolicyRoutePattern = "/v1/policies"
+
+	// statusRoutePattern is the Autoscaler HTTP router pattern which is used
+	// to register the status server endpoint.
+	statusRoutePattern = "/v1/status"
+
 	// healthAliveness is used to define the health of the Autoscaler agent. It
 	// currently can only be in two states; ready or unavailable and depends
 	// entirely on whether the server is serving or not.
@@ -45,25 +63,69 @@ type Server struct {
 	// inMemSink is our in-memory telemetry sink used to server metrics
 	// endpoint requests.
 	inMemSink *metrics.InmemSink
+
+	// plugins is used to read the state of the agent plugins when serving
+	// plugins endpoint requests.
+	plugins pluginStateReporter
+
+	// policies is used to manage the policy count overrides when serving
+	// policy endpoint requests.
+	policies policyOverrider
+
+	// status is used to read the agent status when serving status endpoint
+	// requests.
+	status statusReporter
 }
 
 // NewHTTPServer creates a new agent HTTP server.
-func NewHTTPServer(cfg *config.HTTP, log hclog.Logger, inmSink *metrics.InmemSink) (*Server, error) {
+func NewHTTPServer(cfg *config.HTTP, log hclog.Logger, inmSink *metrics.InmemSink,
+	pm *manager.PluginManager, policyManager *policy.Manager, status statusReporter) (*Server, error) {
 
 	srv := &Server{
 		inMemSink: inmSink,
 		log:       log.Named("http_server"),
 		mux:       http.NewServeMux(),
+		status:    status,
+	}
+
+	// Avoid storing a typed nil within the interface.
+	if pm != nil {
+		srv.plugins = pm
+	}
+	if policyManager != nil {
+		srv.policies = policyManager
 	}
 
 	// Setup our handlers.
 	srv.mux.HandleFunc(healthRoutePattern, srv.wrap(srv.getHealth))
 	srv.mux.HandleFunc(metricsRoutePattern, srv.wrap(srv.getMetrics))
+	srv.mux.HandleFunc(pushMetricRoutePattern, srv.wrap(srv.pushMetric))
+	srv.mux.HandleFunc(pluginsRoutePattern, srv.wrap(srv.getPlugins))
+	srv.mux.HandleFunc(policiesRoutePattern, srv.wrap(srv.policySpecificRequest))
+	srv.mux.HandleFunc(registerPolicyRoutePattern, srv.wrap(srv.registerPolicyRequest))
+	srv.mux.HandleFunc(statusRoutePattern, srv.wrap(srv.getStatus))
+	srv.mux.HandleFunc(scalingPreviewRoutePattern, srv.wrap(srv.getScalingPreview))
+
+	if cfg.EnableDebug {
+		srv.log.Warn("debug endpoints are enabled")
+		srv.registerDebugHandlers()
+	}
+
+	auth, err := newAuthenticator(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsCfg, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Configure the HTTP server to the most basic level.
 	srv.srv = &http.Server{
 		Addr:         fmt.Sprintf("%s:%v", cfg.BindAddress, cfg.BindPort),
-		Handler:      srv.mux,
+		Handler:      srv.authHandler(auth, srv.mux),
+		TLSConfig:    tlsCfg,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  15 * time.Second,
@@ -92,7 +154,16 @@ func (s *Server) Start() {
 
 	// Call serve, checking whether the error return is the one we expect. If
 	// we do get an unexpected error, set our aliveness as unavailable.
-	if err := s.srv.Serve(s.ln); err != nil && err != http.ErrServerClosed {
+	// The certificate is already loaded within the TLS configuration, so the
+	// file arguments are left empty.
+	var err error
+	if s.srv.TLSConfig != nil {
+		err = s.srv.ServeTLS(s.ln, "", "")
+	} else {
+		err = s.srv.Serve(s.ln)
+	}
+
+	if err != nil && err != http.ErrServerClosed {
 		atomic.StoreInt32(&s.aliveness, healthAlivenessUnavailable)
 		s.log.Error("failed to serve HTTP", "addr", s.srv.Addr, "error", err)
 	}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/policy"
+)
+
+// errStatusUnavailable is the error message used when the agent status is
+// requested before the agent is able to report it.
+const errStatusUnavailable = "Agent status unavailable"
+
+// AgentStatus is the response of the status endpoint. It is a lightweight
+// summary of the running agent intended for tooling, so fields should only be
+// added, never renamed or removed.
+type AgentStatus struct {
+
+	// Version is the human readable version of the agent, and GitCommit the
+	// commit it was built from if known.
+	Version   string
+	GitCommit string
+
+	// GoVersion is the version of Go the agent was built with.
+	GoVersion string
+
+	// StartTime is when the agent started and Uptime the time since then,
+	// formatted as a duration string.
+	StartTime time.Time
+	Uptime    string
+
+	// DefaultEvaluationInterval is the evaluation interval used by policies
+	// which do not set their own, formatted as a duration string.
+	DefaultEvaluationInterval string
+
+	// Plugins is the number of loaded plugins keyed by plugin type.
+	Plugins map[string]int
+
+	// Policies is the number of policies currently being monitored.
+	Policies int
+
+	// PolicySources lists the policy sources the agent reads policies from.
+	PolicySources []string
+
+	// PolicyStates reports the warmup state, active override and advice of
+	// each monitored policy, ordered by policy ID.
+	PolicyStates []PolicyStatus
+
+	// PolicyConflicts lists the policies from different sources which share
+	// an ID or a target, and which of them are used.
+	PolicyConflicts []policy.Conflict
+
+	// LeaderElection indicates whether leader election is enabled, and Leader
+	// whether the agent scales targets. Leader is always true when leader
+	// election is disabled.
+	LeaderElection bool
+	Leader         bool
+
+	// Maintenance is the state of the external maintenance condition, if
+	// one is configured.
+	Maintenance *MaintenanceStatus `json:",omitempty"`
+}
+
+// MaintenanceStatus is the state of the maintenance condition within the
+// status endpoint response.
+type MaintenanceStatus struct {
+	// Source is the address or path the condition is read from.
+	Source string
+
+	// Hold indicates the agent defers scaling actions.
+	Hold bool
+
+	// CheckedAt is when the condition was last read, and Error the reason it
+	// could not be read, if any.
+	CheckedAt time.Time
+	Error     string `json:",omitempty"`
+}
+
+// PolicyStatus is the status of a single policy within the status endpoint
+// response.
+type PolicyStatus struct {
+	ID string
+
+	// WarmingUp indicates the policy is within its warmup period, which ends
+	// at WarmupUntil, and is not evaluated until then.
+	WarmingUp   bool
+	WarmupUntil time.Time
+
+	// Override is the active count override of the policy, if any.
+	Override *policy.Override `json:",omitempty"`
+
+	// Advice is the last scaling action recommended by the policy, if it is
+	// advisory.
+	Advice *policy.Advice `json:",omitempty"`
+
+	// InvalidQueries is the reason the queries of the policy checks are
+	// invalid, which stops the policy from being evaluated, if any.
+	InvalidQueries string `json:",omitempty"`
+}
+
+// statusReporter is the interface used by the status endpoint to read the
+// status of the agent.
+type statusReporter interface {
+	AgentStatus() *AgentStatus
+}
+
+// leadershipReporter is optionally implemented by the statusReporter to allow
+// the health endpoint to report the leadership state of the agent.
+type leadershipReporter interface {
+	Leadership() (enabled, leader bool)
+}
+
+// getStatus is the HTTP handler used to respond when a request is made to the
+// status endpoint.
+func (s *Server) getStatus(_ http.ResponseWriter, r *http.Request) (interface{}, error) {
+
+	// Only allow GET requests on this endpoint.
+	if r.Method != http.MethodGet {
+		return nil, newCodedError(http.StatusMethodNotAllowed, errInvalidMethod)
+	}
+
+	if s.status == nil {
+		return nil, newCodedError(http.StatusServiceUnavailable, errStatusUnavailable)
+	}
+	return s.status.AgentStatus(), nil
+}
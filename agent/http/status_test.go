@@ -0,0 +1,115 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStatusReporter is a statusReporter which returns a fixed status.
+type fakeStatusReporter struct {
+	status *AgentStatus
+}
+
+func (f *fakeStatusReporter) AgentStatus() *AgentStatus { return f.status }
+
+func TestServer_getStatus(t *testing.T) {
+	status := &AgentStatus{
+		Version:                   "v0.2.0",
+		GoVersion:                 "go1.14",
+		StartTime:                 time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC),
+		Uptime:                    "1h0m0s",
+		DefaultEvaluationInterval: "10s",
+		Plugins:                   map[string]int{"apm": 1},
+		Policies:                  2,
+		PolicySources:             []string{"nomad"},
+		PolicyStates: []PolicyStatus{
+			{
+				ID:          "policy-a",
+				WarmingUp:   true,
+				WarmupUntil: time.Date(2020, 10, 1, 12, 5, 0, 0, time.UTC),
+			},
+			{
+				ID:          "policy-b",
+				WarmupUntil: time.Date(2020, 10, 1, 11, 5, 0, 0, time.UTC),
+				Override: &policy.Override{
+					Count:  5,
+					Expiry: time.Date(2020, 10, 1, 13, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		PolicyConflicts: []policy.Conflict{
+			{
+				Type: policy.ConflictTypeTarget,
+				Key:  "default/web/cache",
+				Policies: []policy.ConflictPolicy{
+					{ID: "policy-a", Source: policy.SourceNameNomad, Active: true},
+					{ID: "policy-c", Source: policy.SourceNameFile},
+				},
+			},
+		},
+		LeaderElection: true,
+		Leader:         true,
+	}
+
+	testCases := []struct {
+		inputReq         *http.Request
+		inputWriter      *httptest.ResponseRecorder
+		inputStatus      statusReporter
+		expectedRespCode int
+		expectedBody     string
+		name             string
+	}{
+		{
+			inputReq:         httptest.NewRequest("GET", "/v1/status", nil),
+			inputWriter:      httptest.NewRecorder(),
+			inputStatus:      &fakeStatusReporter{status: status},
+			expectedRespCode: 200,
+			expectedBody: `{"DefaultEvaluationInterval":"10s","GitCommit":"","GoVersion":"go1.14",` +
+				`"Leader":true,"LeaderElection":true,"Plugins":{"apm":1},"Policies":2,` +
+				`"PolicyConflicts":[{"Key":"default/web/cache","Policies":[{"Active":true,"ID":"policy-a","Source":"nomad"},` +
+				`{"Active":false,"ID":"policy-c","Source":"file"}],"Type":"target"}],"PolicySources":["nomad"],` +
+				`"PolicyStates":[{"ID":"policy-a","WarmingUp":true,"WarmupUntil":"2020-10-01T12:05:00Z"},` +
+				`{"ID":"policy-b","Override":{"Count":5,"Expiry":"2020-10-01T13:00:00Z"},"WarmingUp":false,` +
+				`"WarmupUntil":"2020-10-01T11:05:00Z"}],` +
+				`"StartTime":"2020-10-01T12:00:00Z","Uptime":"1h0m0s","Version":"v0.2.0"}`,
+			name: "status",
+		},
+		{
+			inputReq:         httptest.NewRequest("GET", "/v1/status", nil),
+			inputWriter:      httptest.NewRecorder(),
+			inputStatus:      nil,
+			expectedRespCode: 503,
+			expectedBody:     errStatusUnavailable,
+			name:             "status unavailable",
+		},
+		{
+			inputReq:         httptest.NewRequest("PUT", "/v1/status", nil),
+			inputWriter:      httptest.NewRecorder(),
+			inputStatus:      &fakeStatusReporter{status: status},
+			expectedRespCode: 405,
+			expectedBody:     errInvalidMethod,
+			name:             "incorrect request method",
+		},
+	}
+
+	// Create our HTTP server.
+	srv, err := NewHTTPServer(&config.HTTP{BindAddress: "127.0.0.1", BindPort: 8080}, hclog.NewNullLogger(), nil, nil, nil, nil)
+	assert.Nil(t, err)
+	defer srv.ln.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv.status = tc.inputStatus
+			srv.mux.ServeHTTP(tc.inputWriter, tc.inputReq)
+			assert.Equal(t, tc.expectedRespCode, tc.inputWriter.Code, tc.name)
+			assert.Equal(t, tc.expectedBody, tc.inputWriter.Body.String(), tc.name)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+// sessionName is the name given to the Consul sessions created by the agent.
+const sessionName = "nomad-autoscaler"
+
+// ConsulElector elects a leader amongst multiple agents using a Consul KV
+// lock held by a session. The session is renewed at half its TTL; if the
+// leader fails, the session expires and another agent acquires the lock.
+type ConsulElector struct {
+	logger  hclog.Logger
+	client  *http.Client
+	address string
+	token   string
+	key     string
+	ttl     time.Duration
+
+	// leader is set atomically to 1 when this agent holds the lock.
+	leader int32
+
+	// sessionID is the ID of the current Consul session. It is only accessed
+	// from the Run routine.
+	sessionID string
+}
+
+// NewConsulElector returns a new ConsulElector which uses the Consul agent at
+// address to acquire the lock on key.
+func NewConsulElector(logger hclog.Logger, address, token, key string, ttl time.Duration) *ConsulElector {
+	return &ConsulElector{
+		logger:  logger.Named("leader_election"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		key:     strings.TrimPrefix(key, "/"),
+		ttl:     ttl,
+	}
+}
+
+// IsLeader returns whether this agent currently holds the leadership lock.
+func (e *ConsulElector) IsLeader() bool {
+	return atomic.LoadInt32(&e.leader) == 1
+}
+
+// Run attempts to acquire and hold the leadership lock until the context is
+// canceled, at which point the lock is released so another agent can take
+// over without waiting for the session to expire.
+func (e *ConsulElector) Run(ctx context.Context) {
+	e.logger.Info("starting leader election", "key", e.key)
+
+	interval := e.ttl / 2
+
+	for {
+		e.runOnce()
+
+		select {
+		case <-ctx.Done():
+			e.stop()
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// runOnce performs a single round of the election, renewing the session and
+// attempting to acquire the lock if it is not already held.
+func (e *ConsulElector) runOnce() {
+	if e.sessionID != "" {
+		if err := e.renewSession(); err != nil {
+			e.logger.Warn("failed to renew session", "error", err)
+			e.sessionID = ""
+			e.setLeader(false)
+		}
+	}
+
+	if e.sessionID == "" {
+		id, err := e.createSession()
+		if err != nil {
+			e.logger.Error("failed to create session", "error", err)
+			return
+		}
+		e.sessionID = id
+	}
+
+	if e.IsLeader() {
+		return
+	}
+
+	acquired, err := e.acquire()
+	if err != nil {
+		e.logger.Error("failed to acquire leadership lock", "error", err)
+		return
+	}
+	e.setLeader(acquired)
+}
+
+// stop releases the lock and destroys the session.
+func (e *ConsulElector) stop() {
+	if e.sessionID == "" {
+		return
+	}
+
+	if e.IsLeader() {
+		if _, err := e.put(fmt.Sprintf("/v1/kv/%s?release=%s", e.key, url.QueryEscape(e.sessionID)), nil); err != nil {
+			e.logger.Warn("failed to release leadership lock", "error", err)
+		}
+	}
+	if _, err := e.put("/v1/session/destroy/"+e.sessionID, nil); err != nil {
+		e.logger.Warn("failed to destroy session", "error", err)
+	}
+
+	e.sessionID = ""
+	e.setLeader(false)
+}
+
+// setLeader updates the leadership state, logging any change.
+func (e *ConsulElector) setLeader(leader bool) {
+	var v int32
+	if leader {
+		v = 1
+	}
+
+	if atomic.SwapInt32(&e.leader, v) == v {
+		return
+	}
+
+	if leader {
+		e.logger.Info("acquired leadership, scaling enabled")
+	} else {
+		e.logger.Warn("lost leadership, scaling disabled")
+	}
+}
+
+func (e *ConsulElector) createSession() (string, error) {
+	body, err := json.Marshal(map[string]string{
+		"Name":     sessionName,
+		"TTL":      e.ttl.String(),
+		"Behavior": "release",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := e.put("/v1/session/create", body)
+	if err != nil {
+		return "", err
+	}
+
+	var out struct{ ID string }
+	if err := json.Unmarshal(resp, &out); err != nil {
+		return "", fmt.Errorf("failed to decode session response: %v", err)
+	}
+	if out.ID == "" {
+		return "", fmt.Errorf("session response did not include an ID")
+	}
+	return out.ID, nil
+}
+
+func (e *ConsulElector) renewSession() error {
+	_, err := e.put("/v1/session/renew/"+e.sessionID, nil)
+	return err
+}
+
+// acquire attempts to acquire the lock using the current session. The value
+// of the key is set to the hostname of the agent to help operators identify
+// the leader.
+func (e *ConsulElector) acquire() (bool, error) {
+	hostname, _ := os.Hostname()
+
+	resp, err := e.put(fmt.Sprintf("/v1/kv/%s?acquire=%s", e.key, url.QueryEscape(e.sessionID)), []byte(hostname))
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(resp)) == "true", nil
+}
+
+// put performs a PUT request against the Consul HTTP API, returning the body
+// of a successful response.
+func (e *ConsulElector) put(path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPut, e.address+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if e.token != "" {
+		req.Header.Set("X-Consul-Token", e.token)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response code %d: %s", resp.StatusCode, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
@@ -0,0 +1,124 @@
+package leader
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConsul implements the subset of the Consul HTTP API used by the
+// ConsulElector.
+type fakeConsul struct {
+	l        sync.Mutex
+	nextID   int
+	sessions map[string]bool
+	holder   string
+}
+
+func newFakeConsul() *fakeConsul {
+	return &fakeConsul{sessions: make(map[string]bool)}
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	switch {
+	case r.URL.Path == "/v1/session/create":
+		f.nextID++
+		id := fmt.Sprintf("session-%d", f.nextID)
+		f.sessions[id] = true
+		fmt.Fprintf(w, `{"ID": %q}`, id)
+
+	case strings.HasPrefix(r.URL.Path, "/v1/session/renew/"):
+		if !f.sessions[strings.TrimPrefix(r.URL.Path, "/v1/session/renew/")] {
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+	case strings.HasPrefix(r.URL.Path, "/v1/session/destroy/"):
+		f.invalidate(strings.TrimPrefix(r.URL.Path, "/v1/session/destroy/"))
+
+	case strings.HasPrefix(r.URL.Path, "/v1/kv/"):
+		if id := r.URL.Query().Get("acquire"); id != "" {
+			if f.sessions[id] && (f.holder == "" || f.holder == id) {
+				f.holder = id
+				fmt.Fprint(w, "true")
+			} else {
+				fmt.Fprint(w, "false")
+			}
+		}
+		if id := r.URL.Query().Get("release"); id != "" && f.holder == id {
+			f.holder = ""
+		}
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// invalidate removes the session and releases any lock it holds, as Consul
+// does for sessions using the release behavior.
+func (f *fakeConsul) invalidate(id string) {
+	delete(f.sessions, id)
+	if f.holder == id {
+		f.holder = ""
+	}
+}
+
+func TestConsulElector(t *testing.T) {
+	consul := newFakeConsul()
+	srv := httptest.NewServer(consul)
+	defer srv.Close()
+
+	e1 := NewConsulElector(hclog.NewNullLogger(), srv.URL, "", "nomad-autoscaler/leader", 10*time.Second)
+	e2 := NewConsulElector(hclog.NewNullLogger(), srv.URL, "", "nomad-autoscaler/leader", 10*time.Second)
+
+	// The first agent acquires the lock and the second becomes a follower.
+	e1.runOnce()
+	e2.runOnce()
+	assert.True(t, e1.IsLeader())
+	assert.False(t, e2.IsLeader())
+
+	// Leadership is kept across rounds.
+	e1.runOnce()
+	e2.runOnce()
+	assert.True(t, e1.IsLeader())
+	assert.False(t, e2.IsLeader())
+
+	// The leader stopping releases the lock for the follower to acquire.
+	e1.stop()
+	e2.runOnce()
+	assert.False(t, e1.IsLeader())
+	assert.True(t, e2.IsLeader())
+
+	// A leader whose session is invalidated loses leadership and must
+	// acquire the lock again using a new session.
+	consul.l.Lock()
+	consul.invalidate(e2.sessionID)
+	consul.l.Unlock()
+
+	e1.runOnce()
+	assert.True(t, e1.IsLeader())
+
+	e2.runOnce()
+	assert.False(t, e2.IsLeader())
+}
+
+func TestConsulElector_consulUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	e := NewConsulElector(hclog.NewNullLogger(), srv.URL, "", "nomad-autoscaler/leader", 10*time.Second)
+	e.runOnce()
+	assert.False(t, e.IsLeader())
+	assert.Equal(t, "", e.sessionID)
+}
@@ -14,6 +14,7 @@ import (
 func (a *Agent) setupPlugins() error {
 
 	a.pluginManager = manager.NewPluginManager(a.logger, a.config.PluginDir, a.setupPluginsConfig())
+	a.pluginManager.SetPushStore(a.pushStore)
 
 	// Trigger the loading of the plugins which will be available to the agent.
 	// Any errors here will cause the agent to fail, but will include wrapped
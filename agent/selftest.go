@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/policyeval"
+)
+
+// SelfTest loads the configured plugins and runs a synthetic dry-run
+// evaluation using them, returning the result of each plugin. An error is
+// returned if the plugins cannot be loaded.
+func (a *Agent) SelfTest(cfg policyeval.SelfTestConfig) ([]*policyeval.SelfTestResult, error) {
+	if err := a.setupPlugins(); err != nil {
+		return nil, fmt.Errorf("failed to setup plugins: %v", err)
+	}
+	defer a.pluginManager.KillPlugins()
+
+	names := map[string][]string{
+		plugins.PluginTypeAPM:      pluginNames(a.config.APMs),
+		plugins.PluginTypeStrategy: pluginNames(a.config.Strategies),
+		plugins.PluginTypeTarget:   pluginNames(a.config.Targets),
+	}
+
+	return policyeval.SelfTest(context.Background(), a.logger, a.pluginManager, names, cfg), nil
+}
+
+// pluginNames returns the names of the configured plugins.
+func pluginNames(cfgs []*config.Plugin) []string {
+	names := make([]string, 0, len(cfgs))
+	for _, c := range cfgs {
+		names = append(names, c.Name)
+	}
+	return names
+}
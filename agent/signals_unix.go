@@ -0,0 +1,18 @@
+// +build !windows
+
+package agent
+
+import (
+	"os"
+	"syscall"
+)
+
+// dumpStateSignals are the signals which trigger a dump of the agent internal
+// state.
+var dumpStateSignals = []os.Signal{syscall.SIGUSR1}
+
+// isDumpStateSignal returns whether the signal should trigger a dump of the
+// agent internal state.
+func isDumpStateSignal(sig os.Signal) bool {
+	return sig == syscall.SIGUSR1
+}
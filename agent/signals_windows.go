@@ -0,0 +1,15 @@
+// +build windows
+
+package agent
+
+import "os"
+
+// dumpStateSignals are the signals which trigger a dump of the agent internal
+// state. Windows does not support SIGUSR1, so dumping state is unavailable.
+var dumpStateSignals []os.Signal
+
+// isDumpStateSignal returns whether the signal should trigger a dump of the
+// agent internal state.
+func isDumpStateSignal(_ os.Signal) bool {
+	return false
+}
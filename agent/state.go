@@ -0,0 +1,365 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	agentServer "github.com/hashicorp/nomad-autoscaler/agent/http"
+	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/policyeval"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/version"
+)
+
+// agentState is a point-in-time snapshot of the agent internal state. It is
+// purely diagnostic and is dumped to the log on request.
+type agentState struct {
+	Goroutines int
+	Policies   []policy.HandlerState
+	Plugins    []manager.PluginState
+	EvalBroker *policyeval.BrokerStats `json:",omitempty"`
+}
+
+// state builds a snapshot of the agent internal state. Components which have
+// not been setup yet are omitted.
+func (a *Agent) state() *agentState {
+	s := &agentState{Goroutines: runtime.NumGoroutine()}
+
+	if a.policyManager != nil {
+		s.Policies = a.policyManager.HandlerStates()
+	}
+	if a.pluginManager != nil {
+		s.Plugins = a.pluginManager.PluginStates()
+	}
+	if a.evalBroker != nil {
+		stats := a.evalBroker.Stats()
+		s.EvalBroker = &stats
+	}
+	return s
+}
+
+// dumpState writes a snapshot of the agent internal state to the log. It only
+// reads state, so is safe to call while the agent is running.
+func (a *Agent) dumpState() {
+	out, err := json.Marshal(a.state())
+	if err != nil {
+		a.logger.Error("failed to encode agent state", "error", err)
+		return
+	}
+	a.logger.Info("agent state dump", "state", string(out))
+}
+
+// AgentStatus satisfies the AgentStatus function of the status endpoint
+// reporter, summarising the running agent and its configuration.
+func (a *Agent) AgentStatus() *agentServer.AgentStatus {
+	s := &agentServer.AgentStatus{
+		Version:         version.GetHumanVersion(),
+		GitCommit:       version.GitCommit,
+		GoVersion:       runtime.Version(),
+		StartTime:       a.startTime,
+		Uptime:          a.clock.Since(a.startTime).Round(time.Second).String(),
+		Plugins:         make(map[string]int),
+		PolicySources:   []string{},
+		PolicyStates:    []agentServer.PolicyStatus{},
+		PolicyConflicts: []policy.Conflict{},
+	}
+
+	s.LeaderElection, s.Leader = a.Leadership()
+
+	if a.maintenanceGate != nil {
+		state := a.maintenanceGate.State(context.Background())
+		s.Maintenance = &agentServer.MaintenanceStatus{
+			Source:    state.Source,
+			Hold:      state.Hold,
+			CheckedAt: state.CheckedAt,
+			Error:     state.Error,
+		}
+	}
+
+	if a.config.Policy != nil {
+		s.DefaultEvaluationInterval = a.config.Policy.DefaultEvaluationInterval.String()
+	}
+	if a.pluginManager != nil {
+		for _, p := range a.pluginManager.PluginStates() {
+			if !p.Unavailable {
+				s.Plugins[p.Type]++
+			}
+		}
+	}
+	if a.policyManager != nil {
+		s.Policies = a.policyManager.PolicyCount()
+		for _, name := range a.policyManager.SourceNames() {
+			s.PolicySources = append(s.PolicySources, string(name))
+		}
+		s.PolicyStates = policyStatuses(a.policyManager.HandlerStates())
+		s.PolicyConflicts = append(s.PolicyConflicts, a.policyManager.Conflicts()...)
+	}
+	return s
+}
+
+// policyStatuses converts the policy handler states into their status
+// endpoint representation.
+func policyStatuses(states []policy.HandlerState) []agentServer.PolicyStatus {
+	out := make([]agentServer.PolicyStatus, 0, len(states))
+	for _, state := range states {
+		out = append(out, agentServer.PolicyStatus{
+			ID:             string(state.PolicyID),
+			WarmingUp:      state.WarmingUp,
+			WarmupUntil:    state.WarmupUntil,
+			Override:       state.Override,
+			Advice:         state.Advice,
+			InvalidQueries: state.InvalidQueries,
+		})
+	}
+	return out
+}
+
+// DescribePolicy satisfies the DescribePolicy function of the policies
+// endpoint describer, resolving the effective configuration of the policy
+// including the agent defaults.
+func (a *Agent) DescribePolicy(id string) (*agentServer.PolicyDescription, error) {
+	if a.policyManager == nil {
+		return nil, policy.ErrPolicyNotFound
+	}
+
+	desc, err := a.policyManager.DescribePolicy(id)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := a.policyDefaults().Apply(hclog.NewNullLogger(), desc.Policy)
+	if err != nil {
+		return nil, err
+	}
+	return policyDescription(desc, p), nil
+}
+
+// RegisterPolicy satisfies the RegisterPolicy function of the policies
+// endpoint registrar. The policy is rejected if its ID is used by a policy
+// from another source.
+func (a *Agent) RegisterPolicy(p *sdk.ScalingPolicy) error {
+	if a.policyManager == nil || a.apiPolicySource == nil {
+		return policy.ErrPolicyNotFound
+	}
+
+	if source, ok := a.policyManager.PolicySource(p.ID); ok && source != policy.SourceNameAPI {
+		return policy.ErrPolicyConflict
+	}
+	return a.apiPolicySource.Register(p)
+}
+
+// UnregisterPolicy satisfies the UnregisterPolicy function of the policies
+// endpoint registrar. Only policies registered at runtime can be removed.
+func (a *Agent) UnregisterPolicy(id string) error {
+	if a.apiPolicySource == nil {
+		return policy.ErrPolicyNotFound
+	}
+	return a.apiPolicySource.Unregister(id)
+}
+
+// PushMetric satisfies the PushMetric function of the metric push endpoint,
+// recording the value so it is served by the push APM.
+func (a *Agent) PushMetric(name string, value float64) error {
+	return a.pushStore.Push(name, value, a.clock.Now())
+}
+
+// scalingPreviewConcurrency is the number of policies evaluated at once for
+// the scaling preview.
+const scalingPreviewConcurrency = 8
+
+// ScalingPreview satisfies the ScalingPreview function of the scaling preview
+// endpoint, evaluating each policy once without scaling its target. Policies
+// which have not yet been read from their source are omitted.
+func (a *Agent) ScalingPreview(ctx context.Context) (*agentServer.ScalingPreview, error) {
+	a.previewLock.RLock()
+	w := a.previewWorker
+	a.previewLock.RUnlock()
+
+	if w == nil || a.policyManager == nil {
+		return nil, errors.New("policy evaluation has not started")
+	}
+
+	states := a.policyManager.HandlerStates()
+	previews := make([]*policyeval.PolicyPreview, len(states))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, scalingPreviewConcurrency)
+
+	for i, state := range states {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			desc, err := a.policyManager.DescribePolicy(id)
+			if err != nil {
+				return
+			}
+			previews[i] = w.Preview(ctx, desc)
+		}(i, string(state.PolicyID))
+	}
+	wg.Wait()
+
+	out := &agentServer.ScalingPreview{Policies: []agentServer.PolicyPreview{}}
+	for _, pv := range previews {
+		if pv != nil {
+			out.Policies = append(out.Policies, policyPreview(pv))
+		}
+	}
+	return out, nil
+}
+
+// policyPreview converts the policy preview into its scaling preview endpoint
+// representation.
+func policyPreview(pv *policyeval.PolicyPreview) agentServer.PolicyPreview {
+	out := agentServer.PolicyPreview{
+		ID:            pv.PolicyID,
+		Target:        pv.Target,
+		Outcome:       pv.Outcome,
+		Count:         pv.Count,
+		DesiredCount:  pv.DesiredCount,
+		ProposedCount: pv.ProposedCount,
+		Direction:     pv.Direction,
+		Check:         pv.Check,
+		Reason:        pv.Reason,
+		Error:         pv.Error,
+	}
+	for _, g := range pv.Guards {
+		out.Guards = append(out.Guards, agentServer.PolicyPreviewGuard{
+			Name:   g.Name,
+			Effect: g.Effect,
+			Count:  g.Count,
+		})
+	}
+	return out
+}
+
+// policyDescription converts the policy description, along with the policy
+// with the agent defaults applied, into its describe endpoint representation.
+func policyDescription(desc *policy.PolicyDescription, p *sdk.ScalingPolicy) *agentServer.PolicyDescription {
+	out := &agentServer.PolicyDescription{
+		ID:                       p.ID,
+		Source:                   string(desc.Source),
+		Revision:                 p.Revision,
+		Type:                     p.Type,
+		Enabled:                  p.Enabled,
+		Priority:                 p.Priority,
+		Min:                      p.Min,
+		Max:                      p.Max,
+		MinDefaulted:             p.MinOmitted && p.Min != desc.Policy.Min,
+		MaxDefaulted:             p.MaxOmitted,
+		EvaluationInterval:       p.EvaluationInterval.String(),
+		Cooldown:                 p.Cooldown.String(),
+		InCooldown:               time.Now().Before(desc.CooldownUntil),
+		CooldownUntil:            desc.CooldownUntil,
+		WarmupPeriod:             p.WarmupPeriod.String(),
+		WarmingUp:                desc.WarmingUp,
+		WarmupUntil:              desc.WarmupUntil,
+		Override:                 desc.Override,
+		InvalidQueries:           desc.InvalidQueries,
+		LastEvaluation:           desc.LastEvaluation,
+		ReconcileOnStart:         p.ReconcileOnStart,
+		MaxScaleStep:             p.MaxScaleStep,
+		MaxScalePercent:          p.MaxScalePercent,
+		VerifyScaleAfter:         p.VerifyScaleAfter.String(),
+		RollbackOnVerifyFailure:  p.RollbackOnVerifyFailure,
+		FallbackStrategy:         p.FallbackStrategy,
+		OutOfBoundsAction:        p.OutOfBoundsAction,
+		Rounding:                 p.Rounding,
+		StabilizeCount:           p.StabilizeCount,
+		MinHealthyPercentage:     p.MinHealthyPercentage,
+		MaxConsecutiveScaleDowns: p.MaxConsecutiveScaleDowns,
+		ScaleDownStep:            p.ScaleDownStep,
+		DeferDuringDeployment:    p.DeferDuringDeployment,
+		Advisory:                 p.Advisory,
+		DependsOn:                p.DependsOn,
+		Advice:                   desc.Advice,
+		MetricMin:                p.MetricMin,
+		MetricMax:                p.MetricMax,
+		MetricUnit:               p.MetricUnit,
+		Cron:                     p.Cron,
+		CronTimeZone:             p.CronTimeZone,
+		Labels:                   p.Labels,
+		Target:                   p.Target,
+		ShadowTarget:             p.ShadowTarget,
+		Checks:                   make([]agentServer.PolicyCheckDescription, 0, len(p.Checks)),
+	}
+
+	if p.StabilizeCount {
+		out.StabilizeCountDelay = p.StabilizeCountDelay.String()
+	}
+
+	if p.ScaleDownStabilizationWindow > 0 {
+		out.ScaleDownStabilizationWindow = p.ScaleDownStabilizationWindow.String()
+	}
+
+	if p.MaxEvaluationInterval > 0 {
+		out.MaxEvaluationInterval = p.MaxEvaluationInterval.String()
+		out.StableEvaluations = p.StableEvaluations
+	}
+
+	if p.ConsecutiveScaleDownsReset > 0 {
+		out.ConsecutiveScaleDownsReset = p.ConsecutiveScaleDownsReset.String()
+	}
+
+	if p.ScaleDownStepDelay > 0 {
+		out.ScaleDownStepDelay = p.ScaleDownStepDelay.String()
+	}
+
+	if p.ScaleUpProbation > 0 {
+		out.ScaleUpProbation = p.ScaleUpProbation.String()
+	}
+
+	if p.DependsOnWindow > 0 {
+		out.DependsOnWindow = p.DependsOnWindow.String()
+	}
+
+	for _, s := range p.ScheduledMins {
+		active, _ := policy.ScheduledMinActive(s, time.Now())
+		out.ScheduledMins = append(out.ScheduledMins, agentServer.PolicyScheduledMinDescription{
+			Name:     s.Name,
+			Cron:     s.Cron,
+			TimeZone: s.TimeZone,
+			Duration: s.Duration.String(),
+			Min:      s.Min,
+			Active:   active,
+		})
+	}
+
+	if desc.RequestedEvaluationInterval != 0 {
+		out.RequestedEvaluationInterval = desc.RequestedEvaluationInterval.String()
+	}
+
+	for _, c := range p.Checks {
+		out.Checks = append(out.Checks, agentServer.PolicyCheckDescription{
+			Name:             c.Name,
+			Source:           c.Source,
+			FailoverSources:  c.FailoverSources,
+			Query:            c.Query,
+			QueryWindow:      c.QueryWindow.String(),
+			Strategy:         c.Strategy,
+			Transforms:       c.Transforms,
+			TargetPercentage: c.TargetPercentage,
+		})
+	}
+	return out
+}
+
+// Leadership returns whether leader election is enabled and whether the agent
+// is the leader. The agent is always the leader when election is disabled.
+func (a *Agent) Leadership() (enabled, leader bool) {
+	if a.elector == nil {
+		return false, true
+	}
+	return true, a.elector.IsLeader()
+}
@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	agentServer "github.com/hashicorp/nomad-autoscaler/agent/http"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/ptr"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_policyStatuses(t *testing.T) {
+	warmupUntil := time.Date(2020, 10, 1, 12, 5, 0, 0, time.UTC)
+	override := &policy.Override{Count: 5, Expiry: time.Date(2020, 10, 1, 13, 0, 0, 0, time.UTC)}
+	advice := &policy.Advice{Count: 4, CurrentCount: 2, Direction: "up", Time: time.Date(2020, 10, 1, 12, 1, 0, 0, time.UTC)}
+
+	testCases := []struct {
+		inputStates    []policy.HandlerState
+		expectedOutput []agentServer.PolicyStatus
+		name           string
+	}{
+		{
+			inputStates:    nil,
+			expectedOutput: []agentServer.PolicyStatus{},
+			name:           "no policies",
+		},
+		{
+			inputStates: []policy.HandlerState{
+				{
+					PolicyID:       "policy-a",
+					Source:         policy.SourceNameFile,
+					LastEvaluation: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC),
+					WarmupUntil:    warmupUntil,
+					WarmingUp:      true,
+				},
+				{
+					PolicyID:    "policy-b",
+					Source:      policy.SourceNameNomad,
+					WarmupUntil: warmupUntil,
+					Override:    override,
+				},
+				{
+					PolicyID: "policy-c",
+					Source:   policy.SourceNameFile,
+					Advice:   advice,
+				},
+			},
+			expectedOutput: []agentServer.PolicyStatus{
+				{ID: "policy-a", WarmingUp: true, WarmupUntil: warmupUntil},
+				{ID: "policy-b", WarmupUntil: warmupUntil, Override: override},
+				{ID: "policy-c", Advice: advice},
+			},
+			name: "warming up, overridden and advisory policies",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, policyStatuses(tc.inputStates), tc.name)
+		})
+	}
+}
+
+func Test_policyDescription(t *testing.T) {
+	cooldownUntil := time.Now().Add(time.Hour)
+	override := &policy.Override{Count: 5, Expiry: time.Date(2020, 10, 1, 13, 0, 0, 0, time.UTC)}
+	advice := &policy.Advice{Count: 4, CurrentCount: 2, Direction: "up", Time: time.Date(2020, 10, 1, 12, 1, 0, 0, time.UTC)}
+
+	received := &sdk.ScalingPolicy{
+		ID:                           "policy-a",
+		Revision:                     "42",
+		Type:                         sdk.ScalingPolicyTypeHorizontal,
+		Enabled:                      true,
+		MinOmitted:                   true,
+		Max:                          10,
+		Cooldown:                     time.Minute,
+		EvaluationInterval:           30 * time.Second,
+		Rounding:                     sdk.RoundingModeCeil,
+		StabilizeCount:               true,
+		StabilizeCountDelay:          5 * time.Second,
+		ScaleDownStabilizationWindow: 5 * time.Minute,
+		MinHealthyPercentage:         75,
+		MaxConsecutiveScaleDowns:     3,
+		ConsecutiveScaleDownsReset:   time.Hour,
+		Cron:                         "0 8 * * *",
+		CronTimeZone:                 "Europe/Amsterdam",
+		DeferDuringDeployment:        true,
+		Advisory:                     true,
+		MetricMin:                    ptr.Float64ToPtr(0),
+		Target:                       &sdk.ScalingPolicyTarget{Name: "nomad-target", Config: map[string]string{"Job": "web"}},
+		ShadowTarget:                 &sdk.ScalingPolicyTarget{Name: "new-target", Config: map[string]string{"dry-run": "true"}},
+		Checks: []*sdk.ScalingPolicyCheck{{
+			Name:            "cpu",
+			Source:          "prometheus",
+			FailoverSources: []string{"datadog"},
+			Query:           "avg(cpu)",
+			QueryWindow:     time.Minute,
+			Strategy:        &sdk.ScalingPolicyStrategy{Name: "target-value", Config: map[string]string{"target": "70"}},
+			Transforms:      []*sdk.ScalingPolicyTransform{{Name: "scale", Config: map[string]string{"factor": "100"}}},
+		}},
+	}
+
+	// The agent default min has been applied to the received policy.
+	resolved := *received
+	resolved.Min = 2
+
+	desc := &policy.PolicyDescription{
+		HandlerState: policy.HandlerState{
+			PolicyID:      "policy-a",
+			Source:        policy.SourceNameFile,
+			CooldownUntil: cooldownUntil,
+			Override:      override,
+			Advice:        advice,
+		},
+		Policy:                      received,
+		RequestedEvaluationInterval: 5 * time.Second,
+	}
+
+	actual := policyDescription(desc, &resolved)
+	assert.Equal(t, &agentServer.PolicyDescription{
+		ID:                           "policy-a",
+		Source:                       "file",
+		Revision:                     "42",
+		Type:                         sdk.ScalingPolicyTypeHorizontal,
+		Enabled:                      true,
+		Min:                          2,
+		Max:                          10,
+		MinDefaulted:                 true,
+		EvaluationInterval:           "30s",
+		RequestedEvaluationInterval:  "5s",
+		Cooldown:                     "1m0s",
+		InCooldown:                   true,
+		CooldownUntil:                cooldownUntil,
+		WarmupPeriod:                 "0s",
+		Override:                     override,
+		VerifyScaleAfter:             "0s",
+		Rounding:                     sdk.RoundingModeCeil,
+		StabilizeCount:               true,
+		StabilizeCountDelay:          "5s",
+		ScaleDownStabilizationWindow: "5m0s",
+		MinHealthyPercentage:         75,
+		MaxConsecutiveScaleDowns:     3,
+		ConsecutiveScaleDownsReset:   "1h0m0s",
+		Cron:                         "0 8 * * *",
+		CronTimeZone:                 "Europe/Amsterdam",
+		DeferDuringDeployment:        true,
+		Advisory:                     true,
+		Advice:                       advice,
+		MetricMin:                    ptr.Float64ToPtr(0),
+		Target:                       received.Target,
+		ShadowTarget:                 received.ShadowTarget,
+		Checks: []agentServer.PolicyCheckDescription{{
+			Name:            "cpu",
+			Source:          "prometheus",
+			FailoverSources: []string{"datadog"},
+			Query:           "avg(cpu)",
+			QueryWindow:     "1m0s",
+			Strategy:        received.Checks[0].Strategy,
+			Transforms:      received.Checks[0].Transforms,
+		}},
+	}, actual)
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -9,6 +10,12 @@ import (
 	"github.com/armon/go-metrics/datadog"
 	"github.com/armon/go-metrics/prometheus"
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // setupTelemetry is used to setup the telemetry sub-systems and returns the
@@ -124,3 +131,47 @@ func (a *Agent) setupTelemetry(cfg *config.Telemetry) (*metrics.InmemSink, error
 	}
 	return inm, nil
 }
+
+// setupTracing configures the OpenTelemetry tracer provider used to trace
+// policy evaluations. Tracing is opt-in, without an OTLP endpoint the global
+// tracer provider is left as a no-op.
+func (a *Agent) setupTracing(cfg *config.Telemetry) error {
+	if cfg == nil || cfg.OTLPEndpoint == "" {
+		return nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+	if cfg.OTLPInsecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	// The HTTP exporter only connects when spans are exported, so an
+	// unavailable collector does not prevent the agent from starting.
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to setup OTLP trace exporter: %v", err)
+	}
+
+	a.tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "nomad-autoscaler"))),
+	)
+	otel.SetTracerProvider(a.tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return nil
+}
+
+// stopTracing flushes the pending spans to the OTLP endpoint, if tracing is
+// enabled.
+func (a *Agent) stopTracing() {
+	if a.tracerProvider == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := a.tracerProvider.Shutdown(ctx); err != nil {
+		a.logger.Warn("failed to flush traces", "error", err)
+	}
+}
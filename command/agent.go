@@ -3,6 +3,7 @@ package command
 import (
 	"flag"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -30,11 +31,29 @@ Usage: nomad-autoscaler agent [options] [args]
   files used, but a subset of the options may also be passed directly as CLI
   arguments or environment variables, listed below.
 
+  Each option may be set using an environment variable named after it,
+  prefixed with NOMAD_AUTOSCALER_, upper-cased and with dashes replaced by
+  underscores. For example, -log-level may be set using
+  NOMAD_AUTOSCALER_LOG_LEVEL and -policy-default-evaluation-interval using
+  NOMAD_AUTOSCALER_POLICY_DEFAULT_EVALUATION_INTERVAL. Options which may be
+  specified multiple times accept a single value from the environment.
+
+  CLI arguments take precedence over environment variables, which take
+  precedence over the config files, which take precedence over the defaults.
+  The agent fails to start if any value is invalid.
+
 Options:
 
   -config=<path>
     The path to either a single config file or a directory of config
-    files to use for configuring the Nomad Autoscaler agent.
+    files to use for configuring the Nomad Autoscaler agent. This option
+    may be specified multiple times, in which case the files are merged in
+    the order given, and the .hcl and .json files of a directory are merged
+    in lexical order. Values set in later files override those of earlier
+    files, maps such as headers and plugin config are merged key by key,
+    and lists such as target access patterns are appended to. Commands and
+    plugin args are replaced as a whole. The merged configuration is
+    validated as a whole.
 
   -log-level=<level>
     Specify the verbosity level of Nomad Autoscaler's logs. Valid values
@@ -58,6 +77,31 @@ HTTP Options:
   -http-bind-port=<port>
     The port that the health server will bind to. The default is 8080.
 
+  -http-enable-debug
+    Expose the pprof profiling endpoints under /debug/pprof/ on the HTTP
+    server. CPU profiles and traces must be shorter than 15 seconds, which
+    can be set using the seconds query parameter. The default is false.
+
+  -http-auth-token=<token>
+    The bearer token requests to the HTTP server must present within the
+    Authorization header. The health endpoint is always unauthenticated.
+
+  -http-tls-cert-file=<path>
+    The path to the PEM-encoded certificate used to serve HTTPS. Must be set
+    along with -http-tls-key-file.
+
+  -http-tls-key-file=<path>
+    The path to the PEM-encoded private key used to serve HTTPS.
+
+  -http-tls-ca-file=<path>
+    The path to the PEM-encoded CA certificate used to verify client
+    certificates. Requests presenting a verified client certificate are
+    authenticated, and all other requests require the auth token.
+
+  -http-metrics-allowed-cidr=<cidr>
+    A network allowed to read the metrics endpoint without authenticating,
+    such as that of a metrics scraper. Can be specified multiple times.
+
 Nomad Options:
 
   -nomad-address=<addr>
@@ -100,6 +144,18 @@ Nomad Options:
   -nomad-skip-verify
     Do not verify TLS certificates. This is strongly discouraged.
 
+  -nomad-request-timeout=<dur>
+    The time limit of a single request to the Nomad API. Blocking queries
+    may additionally wait for up to their wait time. Defaults to 30s.
+
+  -nomad-retries=<num>
+    The number of times a failed read request to the Nomad API is retried.
+    Scaling requests are never retried. Defaults to 2.
+
+  -nomad-retry-backoff=<dur>
+    The time to wait before the first retry of a failed Nomad API request,
+    which doubles for each subsequent retry. Defaults to 1s.
+
 Policy Options:
 
   -policy-dir=<path>
@@ -113,6 +169,103 @@ Policy Options:
     The default evaluation interval that will be applied to all scaling policies
     which do not specify an evaluation interval.
 
+  -policy-default-warmup-period=<dur>
+    The default warmup period that will be applied to all scaling policies
+    which do not specify a warmup period. No evaluations are performed during
+    the warmup period after a policy is first loaded.
+
+  -policy-min-evaluation-interval=<dur>
+    The minimum evaluation interval allowed for scaling policies. Policies
+    which specify a shorter evaluation interval are evaluated at this interval
+    instead. Defaults to no minimum.
+
+  -policy-removal-grace-period=<dur>
+    The time a scaling policy which is no longer listed by its source keeps
+    being handled before it is removed. A policy which reappears within the
+    grace period keeps its state, such as its cooldown. Defaults to removing
+    policies immediately.
+
+  -policy-default-min=<num>
+    The default min value applied during evaluation to scaling policies which
+    do not specify a min value.
+
+  -policy-default-max=<num>
+    The default max value applied during evaluation to scaling policies which
+    do not specify a max value.
+
+  -policy-http-address=<url>
+    The URL of a remote HTTP endpoint used to load scaling policies. The
+    endpoint must list the policy IDs and serve each policy at the URL
+    suffixed with its ID.
+
+  -policy-consul-address=<url>
+    The URL of the Consul HTTP API used to load scaling policies from the KV
+    store. Each key directly under the prefix is loaded as a policy.
+
+  -policy-consul-prefix=<prefix>
+    The KV prefix which holds the scaling policies. Defaults to
+    nomad-autoscaler/policies.
+
+  -policy-consul-token=<token>
+    The ACL token used to read the scaling policies from Consul.
+
+Alerting Options:
+
+  -alerting-webhook-address=<url>
+    The URL which alerts are sent to when a scaling policy fails to evaluate
+    repeatedly, and when it subsequently recovers. Alerting is disabled if
+    this is not set.
+
+  -alerting-failure-threshold=<num>
+    The number of consecutive evaluation failures of a scaling policy which
+    trigger an alert. Defaults to 3.
+
+  -alerting-severity=<string>
+    The severity included in the alert payload. Defaults to critical.
+
+Capacity Budget Options:
+
+  -capacity-budget-max-count=<num>
+    The maximum sum of the target counts of all scaling policies. Scale ups
+    which would exceed it are handled according to the enforcement mode.
+    The budget is disabled if this is not set.
+
+  -capacity-budget-enforcement=<mode>
+    How scale ups exceeding the capacity budget are handled. The deny mode
+    skips the scale up, while the reduce mode reduces it to the count
+    remaining within the budget. Defaults to deny.
+
+Target Access Options:
+
+  -target-access-allow=<pattern>
+    A glob pattern of the targets the agent is allowed to scale, matched
+    against the target plugin name and, for Nomad task groups, the
+    <namespace>/<job>/<group> path. This can be specified multiple times. All
+    targets are allowed if this is not set.
+
+  -target-access-deny=<pattern>
+    A glob pattern of the targets the agent must never scale, taking
+    precedence over the allowed patterns. This can be specified multiple
+    times.
+
+Leader Election Options:
+
+  -leader-election-enabled
+    Enable leader election so that only one of several agents scales
+    targets. The other agents evaluate policies without scaling, ready to
+    take over if the leader fails.
+
+  -leader-election-consul-address=<url>
+    The address of the Consul agent used for leader election. Defaults to
+    http://127.0.0.1:8500.
+
+  -leader-election-consul-token=<token>
+    The Consul ACL token used for leader election requests.
+
+  -leader-election-key=<key>
+    The Consul KV key used as the leadership lock. Defaults to
+    nomad-autoscaler/leader.
+
 Telemetry Options:
 
   -telemetry-disable-hostname
@@ -193,6 +346,14 @@ Telemetry Options:
   -telemetry-circonus-broker-select-tag
     A tag which is used to select a broker ID when an explicit broker ID is not
     provided.
+
+  -telemetry-otlp-endpoint=<addr>
+    The host and port of an OpenTelemetry collector which policy evaluation
+    traces are exported to using OTLP over HTTP. Tracing is disabled if not
+    set.
+
+  -telemetry-otlp-insecure
+    Disables TLS when exporting traces to the OpenTelemetry collector.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -240,10 +401,14 @@ func (c *AgentCommand) readConfig() *config.Agent {
 
 	// cmdConfig is used to store any passed CLI flags.
 	cmdConfig := &config.Agent{
-		HTTP:      &config.HTTP{},
-		Nomad:     &config.Nomad{},
-		Policy:    &config.Policy{},
-		Telemetry: &config.Telemetry{},
+		HTTP:           &config.HTTP{},
+		Nomad:          &config.Nomad{},
+		Policy:         &config.Policy{},
+		Telemetry:      &config.Telemetry{},
+		Alerting:       &config.Alerting{},
+		LeaderElection: &config.LeaderElection{},
+		CapacityBudget: &config.CapacityBudget{},
+		TargetAccess:   &config.TargetAccess{},
 	}
 
 	flags := flag.NewFlagSet("agent", flag.ContinueOnError)
@@ -258,6 +423,12 @@ func (c *AgentCommand) readConfig() *config.Agent {
 	// Specify our HTTP bind flags.
 	flags.StringVar(&cmdConfig.HTTP.BindAddress, "http-bind-address", "", "")
 	flags.IntVar(&cmdConfig.HTTP.BindPort, "http-bind-port", 0, "")
+	flags.BoolVar(&cmdConfig.HTTP.EnableDebug, "http-enable-debug", false, "")
+	flags.StringVar(&cmdConfig.HTTP.AuthToken, "http-auth-token", "", "")
+	flags.StringVar(&cmdConfig.HTTP.TLSCertFile, "http-tls-cert-file", "", "")
+	flags.StringVar(&cmdConfig.HTTP.TLSKeyFile, "http-tls-key-file", "", "")
+	flags.StringVar(&cmdConfig.HTTP.TLSCAFile, "http-tls-ca-file", "", "")
+	flags.Var((*flaghelper.StringFlag)(&cmdConfig.HTTP.MetricsAllowedCIDRs), "http-metrics-allowed-cidr", "")
 
 	// Specify our Nomad client CLI flags.
 	flags.StringVar(&cmdConfig.Nomad.Address, "nomad-address", "", "")
@@ -271,6 +442,19 @@ func (c *AgentCommand) readConfig() *config.Agent {
 	flags.StringVar(&cmdConfig.Nomad.ClientKey, "nomad-client-key", "", "")
 	flags.StringVar(&cmdConfig.Nomad.TLSServerName, "nomad-tls-server-name", "", "")
 	flags.BoolVar(&cmdConfig.Nomad.SkipVerify, "nomad-skip-verify", false, "")
+	flags.Var((flaghelper.FuncDurationVar)(func(d time.Duration) error {
+		cmdConfig.Nomad.RequestTimeout = d
+		return nil
+	}), "nomad-request-timeout", "")
+	flags.Var((flaghelper.FuncIntVar)(func(i int) error {
+		cmdConfig.Nomad.RetriesPtr = &i
+		cmdConfig.Nomad.Retries = i
+		return nil
+	}), "nomad-retries", "")
+	flags.Var((flaghelper.FuncDurationVar)(func(d time.Duration) error {
+		cmdConfig.Nomad.RetryBackoff = d
+		return nil
+	}), "nomad-retry-backoff", "")
 
 	// Specify our Policy CLI flags.
 	flags.StringVar(&cmdConfig.Policy.Dir, "policy-dir", "", "")
@@ -282,6 +466,43 @@ func (c *AgentCommand) readConfig() *config.Agent {
 		cmdConfig.Policy.DefaultEvaluationInterval = d
 		return nil
 	}), "policy-default-evaluation-interval", "")
+	flags.Var((flaghelper.FuncDurationVar)(func(d time.Duration) error {
+		cmdConfig.Policy.DefaultWarmupPeriod = d
+		return nil
+	}), "policy-default-warmup-period", "")
+	flags.Var((flaghelper.FuncDurationVar)(func(d time.Duration) error {
+		cmdConfig.Policy.MinEvaluationInterval = d
+		return nil
+	}), "policy-min-evaluation-interval", "")
+	flags.Var((flaghelper.FuncDurationVar)(func(d time.Duration) error {
+		cmdConfig.Policy.RemovalGracePeriod = d
+		return nil
+	}), "policy-removal-grace-period", "")
+	flags.Int64Var(&cmdConfig.Policy.DefaultMin, "policy-default-min", 0, "")
+	flags.Int64Var(&cmdConfig.Policy.DefaultMax, "policy-default-max", 0, "")
+	flags.StringVar(&cmdConfig.Policy.HTTPAddress, "policy-http-address", "", "")
+	flags.StringVar(&cmdConfig.Policy.ConsulAddress, "policy-consul-address", "", "")
+	flags.StringVar(&cmdConfig.Policy.ConsulPrefix, "policy-consul-prefix", "", "")
+	flags.StringVar(&cmdConfig.Policy.ConsulToken, "policy-consul-token", "", "")
+
+	// Specify our Alerting CLI flags.
+	flags.StringVar(&cmdConfig.Alerting.WebhookAddress, "alerting-webhook-address", "", "")
+	flags.IntVar(&cmdConfig.Alerting.FailureThreshold, "alerting-failure-threshold", 0, "")
+	flags.StringVar(&cmdConfig.Alerting.Severity, "alerting-severity", "", "")
+
+	// Specify our Capacity Budget CLI flags.
+	flags.Int64Var(&cmdConfig.CapacityBudget.MaxCount, "capacity-budget-max-count", 0, "")
+	flags.StringVar(&cmdConfig.CapacityBudget.Enforcement, "capacity-budget-enforcement", "", "")
+
+	// Specify our Target Access CLI flags.
+	flags.Var((*flaghelper.StringFlag)(&cmdConfig.TargetAccess.Allow), "target-access-allow", "")
+	flags.Var((*flaghelper.StringFlag)(&cmdConfig.TargetAccess.Deny), "target-access-deny", "")
+
+	// Specify our Leader Election CLI flags.
+	flags.BoolVar(&cmdConfig.LeaderElection.Enabled, "leader-election-enabled", false, "")
+	flags.StringVar(&cmdConfig.LeaderElection.ConsulAddress, "leader-election-consul-address", "", "")
+	flags.StringVar(&cmdConfig.LeaderElection.ConsulToken, "leader-election-consul-token", "", "")
+	flags.StringVar(&cmdConfig.LeaderElection.Key, "leader-election-key", "", "")
 
 	// Specify our Telemetry CLI flags.
 	flags.BoolVar(&cmdConfig.Telemetry.DisableHostname, "telemetry-disable-hostname", false, "")
@@ -312,11 +533,139 @@ func (c *AgentCommand) readConfig() *config.Agent {
 	flags.StringVar(&cmdConfig.Telemetry.CirconusCheckDisplayName, "telemetry-circonus-check-display-name", "", "")
 	flags.StringVar(&cmdConfig.Telemetry.CirconusBrokerID, "telemetry-circonus-broker-id", "", "")
 	flags.StringVar(&cmdConfig.Telemetry.CirconusBrokerSelectTag, "telemetry-circonus-broker-select-tag", "", "")
+	flags.StringVar(&cmdConfig.Telemetry.OTLPEndpoint, "telemetry-otlp-endpoint", "", "")
+	flags.BoolVar(&cmdConfig.Telemetry.OTLPInsecure, "telemetry-otlp-insecure", false, "")
 
 	if err := flags.Parse(c.args); err != nil {
 		return nil
 	}
 
+	// Set the flags which were not passed from the environment, so the CLI
+	// arguments take precedence.
+	if err := setFlagsFromEnv(flags, os.LookupEnv); err != nil {
+		fmt.Printf("Invalid environment variables. %v\n", err)
+		return nil
+	}
+
+	// Track the flags set by either CLI arguments or environment variables,
+	// so their values are applied even when zero.
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	return loadConfig(configPath, cmdConfig, set)
+}
+
+// envVarPrefix is the prefix of the environment variables which set the
+// agent CLI flags.
+const envVarPrefix = "NOMAD_AUTOSCALER_"
+
+// envVarName returns the name of the environment variable which sets the
+// flag, such as NOMAD_AUTOSCALER_LOG_LEVEL for -log-level.
+func envVarName(flagName string) string {
+	return envVarPrefix + strings.ToUpper(strings.Replace(flagName, "-", "_", -1))
+}
+
+// setFlagsFromEnv sets the flags which were not passed as CLI arguments from
+// their environment variable, if it is set and not empty. The values are
+// parsed in the same way as the CLI arguments, and all invalid values are
+// returned as an error. lookupEnv is used to read the environment, allowing
+// tests to control it.
+func setFlagsFromEnv(flags *flag.FlagSet, lookupEnv func(string) (string, bool)) error {
+	passed := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { passed[f.Name] = true })
+
+	var mErr *multierror.Error
+
+	flags.VisitAll(func(f *flag.Flag) {
+		if passed[f.Name] {
+			return
+		}
+
+		name := envVarName(f.Name)
+		value, ok := lookupEnv(name)
+		if !ok || value == "" {
+			return
+		}
+
+		if err := flags.Set(f.Name, value); err != nil {
+			mErr = multierror.Append(mErr, fmt.Errorf("invalid value %q for %s: %v", value, name, err))
+		}
+	})
+
+	return mErr.ErrorOrNil()
+}
+
+// explicitFlags maps the flags whose zero value is meaningful to a func which
+// copies the flag value from the CLI config into the merged config. Merging
+// only applies non-zero values, so without these a flag could not set false,
+// a zero count or a zero duration over the config files. Empty strings are
+// not meaningful and are ignored, as they are for environment variables.
+var explicitFlags = map[string]func(dst, src *config.Agent){
+	"log-json":          func(dst, src *config.Agent) { dst.LogJson = src.LogJson },
+	"http-bind-port":    func(dst, src *config.Agent) { dst.HTTP.BindPort = src.HTTP.BindPort },
+	"http-enable-debug": func(dst, src *config.Agent) { dst.HTTP.EnableDebug = src.HTTP.EnableDebug },
+	"nomad-skip-verify": func(dst, src *config.Agent) { dst.Nomad.SkipVerify = src.Nomad.SkipVerify },
+	"nomad-request-timeout": func(dst, src *config.Agent) {
+		dst.Nomad.RequestTimeout = src.Nomad.RequestTimeout
+	},
+	"nomad-retries": func(dst, src *config.Agent) {
+		dst.Nomad.RetriesPtr = src.Nomad.RetriesPtr
+		dst.Nomad.Retries = src.Nomad.Retries
+	},
+	"nomad-retry-backoff": func(dst, src *config.Agent) { dst.Nomad.RetryBackoff = src.Nomad.RetryBackoff },
+	"policy-default-cooldown": func(dst, src *config.Agent) {
+		dst.Policy.DefaultCooldown = src.Policy.DefaultCooldown
+	},
+	"policy-default-evaluation-interval": func(dst, src *config.Agent) {
+		dst.Policy.DefaultEvaluationInterval = src.Policy.DefaultEvaluationInterval
+	},
+	"policy-default-warmup-period": func(dst, src *config.Agent) {
+		dst.Policy.DefaultWarmupPeriod = src.Policy.DefaultWarmupPeriod
+	},
+	"policy-min-evaluation-interval": func(dst, src *config.Agent) {
+		dst.Policy.MinEvaluationInterval = src.Policy.MinEvaluationInterval
+	},
+	"policy-removal-grace-period": func(dst, src *config.Agent) {
+		dst.Policy.RemovalGracePeriod = src.Policy.RemovalGracePeriod
+	},
+	"policy-default-min": func(dst, src *config.Agent) { dst.Policy.DefaultMin = src.Policy.DefaultMin },
+	"policy-default-max": func(dst, src *config.Agent) { dst.Policy.DefaultMax = src.Policy.DefaultMax },
+	"alerting-failure-threshold": func(dst, src *config.Agent) {
+		dst.Alerting.FailureThreshold = src.Alerting.FailureThreshold
+	},
+	"capacity-budget-max-count": func(dst, src *config.Agent) {
+		dst.CapacityBudget.MaxCount = src.CapacityBudget.MaxCount
+	},
+	"leader-election-enabled": func(dst, src *config.Agent) {
+		dst.LeaderElection.Enabled = src.LeaderElection.Enabled
+	},
+	"telemetry-disable-hostname": func(dst, src *config.Agent) {
+		dst.Telemetry.DisableHostname = src.Telemetry.DisableHostname
+	},
+	"telemetry-enable-hostname-label": func(dst, src *config.Agent) {
+		dst.Telemetry.EnableHostnameLabel = src.Telemetry.EnableHostnameLabel
+	},
+	"telemetry-collection-interval": func(dst, src *config.Agent) {
+		dst.Telemetry.CollectionInterval = src.Telemetry.CollectionInterval
+	},
+	"telemetry-prometheus-metrics": func(dst, src *config.Agent) {
+		dst.Telemetry.PrometheusMetrics = src.Telemetry.PrometheusMetrics
+	},
+	"telemetry-prometheus-retention-time": func(dst, src *config.Agent) {
+		dst.Telemetry.PrometheusRetentionTime = src.Telemetry.PrometheusRetentionTime
+	},
+	"telemetry-otlp-insecure": func(dst, src *config.Agent) {
+		dst.Telemetry.OTLPInsecure = src.Telemetry.OTLPInsecure
+	},
+}
+
+// loadConfig builds the agent configuration by merging the config files found
+// at configPath, followed by the passed CLI flags and environment variables,
+// on top of the defaults. set holds the names of the flags which were set, so
+// their zero values also override the config files. Any errors are printed
+// and nil returned.
+func loadConfig(configPath []string, cmdConfig *config.Agent, set map[string]bool) *config.Agent {
+
 	// Grab a default config as the base.
 	cfg, err := config.Default()
 	if err != nil {
@@ -324,40 +673,34 @@ func (c *AgentCommand) readConfig() *config.Agent {
 		return nil
 	}
 
-	var validationErr *multierror.Error
-
 	// Merge in the enterprise overlay.
 	cfg = cfg.Merge(config.DefaultEntConfig())
 
+	// Merge the config files in the order they were passed. Files are only
+	// validated once merged, so a file may depend on values set in another.
 	for _, path := range configPath {
 		current, err := config.Load(path)
 		if err != nil {
 			fmt.Printf("Error loading configuration from %s: %s\n", path, err)
 			return nil
 		}
+		cfg = cfg.Merge(current)
+	}
 
-		if err := current.Validate(); err != nil {
-			errPrefix := fmt.Sprintf("%s:", path)
-			validationErr = multierror.Append(validationErr, multierror.Prefix(err, errPrefix))
-
-			// Continue looping so we can validate other files.
-			continue
-		}
-
-		if cfg == nil {
-			cfg = current
-		} else {
-			cfg = cfg.Merge(current)
+	// Merge the read file based configuration with the passed CLI args.
+	cfg = cfg.Merge(cmdConfig)
+	for name := range set {
+		if apply, ok := explicitFlags[name]; ok {
+			apply(cfg, cmdConfig)
 		}
 	}
 
-	if validationErr != nil {
-		fmt.Printf("Invalid configuration. %v", validationErr)
+	// Validate the final configuration, which includes the CLI args, so the
+	// agent refuses to start with any invalid values.
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("Invalid configuration. %v\n", err)
 		return nil
 	}
 
-	// Merge the read file based configuration with the passed CLI args.
-	cfg = cfg.Merge(cmdConfig)
-
 	return cfg
 }
@@ -0,0 +1,197 @@
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	flaghelper "github.com/hashicorp/nomad-autoscaler/sdk/helper/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_envVarName(t *testing.T) {
+	assert.Equal(t, "NOMAD_AUTOSCALER_LOG_LEVEL", envVarName("log-level"))
+	assert.Equal(t, "NOMAD_AUTOSCALER_POLICY_DEFAULT_EVALUATION_INTERVAL",
+		envVarName("policy-default-evaluation-interval"))
+}
+
+func Test_setFlagsFromEnv(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputArgs        []string
+		inputEnv         map[string]string
+		expectedLevel    string
+		expectedJSON     bool
+		expectedInterval time.Duration
+		expectError      bool
+	}{
+		{
+			name:          "not set",
+			inputArgs:     nil,
+			inputEnv:      nil,
+			expectedLevel: "",
+		},
+		{
+			name:      "set from env",
+			inputArgs: nil,
+			inputEnv: map[string]string{
+				"NOMAD_AUTOSCALER_LOG_LEVEL":                          "debug",
+				"NOMAD_AUTOSCALER_LOG_JSON":                           "true",
+				"NOMAD_AUTOSCALER_POLICY_DEFAULT_EVALUATION_INTERVAL": "30s",
+			},
+			expectedLevel:    "debug",
+			expectedJSON:     true,
+			expectedInterval: 30 * time.Second,
+		},
+		{
+			name:      "flag takes precedence",
+			inputArgs: []string{"-log-level=warn", "-policy-default-evaluation-interval=1m"},
+			inputEnv: map[string]string{
+				"NOMAD_AUTOSCALER_LOG_LEVEL":                          "debug",
+				"NOMAD_AUTOSCALER_POLICY_DEFAULT_EVALUATION_INTERVAL": "30s",
+			},
+			expectedLevel:    "warn",
+			expectedInterval: time.Minute,
+		},
+		{
+			name:      "empty value ignored",
+			inputArgs: nil,
+			inputEnv: map[string]string{
+				"NOMAD_AUTOSCALER_POLICY_DEFAULT_EVALUATION_INTERVAL": "",
+			},
+		},
+		{
+			name:      "invalid duration",
+			inputArgs: nil,
+			inputEnv: map[string]string{
+				"NOMAD_AUTOSCALER_POLICY_DEFAULT_EVALUATION_INTERVAL": "often",
+			},
+			expectError: true,
+		},
+		{
+			name:      "invalid bool",
+			inputArgs: nil,
+			inputEnv: map[string]string{
+				"NOMAD_AUTOSCALER_LOG_JSON": "sometimes",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var level string
+			var json bool
+			var interval time.Duration
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.StringVar(&level, "log-level", "", "")
+			flags.BoolVar(&json, "log-json", false, "")
+			flags.Var((flaghelper.FuncDurationVar)(func(d time.Duration) error {
+				interval = d
+				return nil
+			}), "policy-default-evaluation-interval", "")
+			assert.NoError(t, flags.Parse(tc.inputArgs), tc.name)
+
+			lookupEnv := func(key string) (string, bool) {
+				v, ok := tc.inputEnv[key]
+				return v, ok
+			}
+
+			err := setFlagsFromEnv(flags, lookupEnv)
+			if tc.expectError {
+				assert.Error(t, err, tc.name)
+				return
+			}
+
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expectedLevel, level, tc.name)
+			assert.Equal(t, tc.expectedJSON, json, tc.name)
+			assert.Equal(t, tc.expectedInterval, interval, tc.name)
+		})
+	}
+}
+
+func Test_loadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomad-autoscaler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	confDir := filepath.Join(dir, "conf.d")
+	assert.NoError(t, os.Mkdir(confDir, 0700))
+
+	writeFile := func(path, content string) {
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	}
+
+	base := filepath.Join(dir, "base.hcl")
+	writeFile(base, `
+log_level = "debug"
+plugin_dir = "/opt/nomad-autoscaler/plugins"
+log_json = true
+policy {
+  default_min = 2
+}
+alerting {
+  webhook_headers = {
+    Authorization = "Bearer base"
+    X-Team        = "infra"
+  }
+}
+`)
+	writeFile(filepath.Join(confDir, "10-access.hcl"), `
+target_access {
+  deny = ["aws-*"]
+}
+`)
+	writeFile(filepath.Join(confDir, "20-override.hcl"), `
+log_level = "warn"
+alerting {
+  webhook_headers = {
+    Authorization = "Bearer override"
+  }
+}
+target_access {
+  deny = ["gce-*"]
+}
+`)
+
+	// Files are merged in the order given, and directories in lexical order.
+	cfg := loadConfig([]string{base, confDir}, &config.Agent{}, nil)
+	if assert.NotNil(t, cfg) {
+		assert.Equal(t, "warn", cfg.LogLevel)
+		assert.Equal(t, "/opt/nomad-autoscaler/plugins", cfg.PluginDir)
+		assert.Equal(t, map[string]string{"Authorization": "Bearer override", "X-Team": "infra"}, cfg.Alerting.WebhookHeaders)
+		assert.Equal(t, []string{"aws-*", "gce-*"}, cfg.TargetAccess.Deny)
+	}
+
+	// CLI arguments take precedence over all files.
+	cfg = loadConfig([]string{base, confDir}, &config.Agent{LogLevel: "trace"}, nil)
+	if assert.NotNil(t, cfg) {
+		assert.Equal(t, "trace", cfg.LogLevel)
+	}
+
+	// Flags which were set override the files even with zero values.
+	set := map[string]bool{"log-json": true, "policy-default-min": true}
+	cfg = loadConfig([]string{base, confDir}, &config.Agent{Policy: &config.Policy{}}, set)
+	if assert.NotNil(t, cfg) {
+		assert.False(t, cfg.LogJson)
+		assert.Equal(t, int64(0), cfg.Policy.DefaultMin)
+	}
+
+	// Flags which were not set leave the files untouched.
+	cfg = loadConfig([]string{base, confDir}, &config.Agent{Policy: &config.Policy{}}, nil)
+	if assert.NotNil(t, cfg) {
+		assert.True(t, cfg.LogJson)
+		assert.Equal(t, int64(2), cfg.Policy.DefaultMin)
+	}
+
+	// The merged configuration is validated.
+	invalid := filepath.Join(dir, "invalid.hcl")
+	writeFile(invalid, `log_level = "loud"`)
+	assert.Nil(t, loadConfig([]string{base, invalid}, &config.Agent{}, nil))
+}
@@ -0,0 +1,184 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/agent"
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/hashicorp/nomad-autoscaler/policyeval"
+	flaghelper "github.com/hashicorp/nomad-autoscaler/sdk/helper/flag"
+)
+
+type SelfTestCommand struct {
+	args []string
+}
+
+// Help should return long-form help text that includes the command-line
+// usage, a brief few sentences explaining the function of the command,
+// and the complete list of flags the command accepts.
+func (c *SelfTestCommand) Help() string {
+	helpText := `
+Usage: nomad-autoscaler selftest [options]
+
+  Loads the configured plugins and runs a synthetic dry-run evaluation using
+  them, reporting whether each plugin succeeded and the time taken by its
+  calls. A policy is evaluated for each target plugin, with a check for each
+  pair of APM and strategy plugins.
+
+  Strategies are run as normal. APM plugins run the query set with -query,
+  and target plugins read their status using the config set with
+  -target-config. Without them, APM and target plugins are only called to
+  confirm they respond, and report the configured value and count instead of
+  querying real metrics or targets. No target is scaled.
+
+  The command exits with a non-zero status if any plugin fails or does not
+  participate in the evaluation.
+
+Options:
+
+  -config=<path>
+    The path to either a single config file or a directory of config
+    files to load the plugin configuration from.
+
+  -log-level=<level>
+    Specify the verbosity level of the logs written while running the
+    evaluation. The default is WARN.
+
+  -plugin-dir=<path>
+    The plugin directory is used to discover Nomad Autoscaler plugins. If not
+    specified, the plugin directory defaults to be that of
+    <current-dir>/plugins/.
+
+  -count=<num>
+    The current count reported for the synthetic targets. The default is 1.
+    It is not used if -target-config is set.
+
+  -value=<num>
+    The metric value returned for the synthetic queries. The default is 1.
+    It is not used if -query is set.
+
+  -query=<query>
+    The query run against the APM plugins by the checks. The query is run
+    against every APM plugin, so it must be valid for each of them.
+
+  -target-config=<key=value>
+    A config value passed to the target plugins to read their status, such as
+    the Job and Group of a Nomad task group. This can be specified
+    multiple times. Targets are never scaled.
+
+  -strategy-config=<key=value>
+    A config value passed to the strategies of the synthetic checks. This
+    can be specified multiple times. Strategies which require config, such
+    as target-value, fail without it.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis should return a one-line, short synopsis of the command.
+func (c *SelfTestCommand) Synopsis() string {
+	return "Runs a synthetic evaluation to check the configured plugins"
+}
+
+// Run should run the actual command with the given CLI instance and
+// command-line arguments. It should return the exit status when it is
+// finished.
+func (c *SelfTestCommand) Run(args []string) int {
+	c.args = args
+
+	var configPath, strategyConfig, targetConfig []string
+	var logLevel string
+	testCfg := policyeval.SelfTestConfig{}
+	cmdConfig := &config.Agent{}
+
+	flags := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	flags.Usage = func() { c.Help() }
+
+	flags.Var((*flaghelper.StringFlag)(&configPath), "config", "")
+	flags.StringVar(&logLevel, "log-level", "WARN", "")
+	flags.StringVar(&cmdConfig.PluginDir, "plugin-dir", "", "")
+	flags.Int64Var(&testCfg.Count, "count", 1, "")
+	flags.Float64Var(&testCfg.Value, "value", 1, "")
+	flags.Var((*flaghelper.StringFlag)(&strategyConfig), "strategy-config", "")
+	flags.StringVar(&testCfg.Query, "query", "", "")
+	flags.Var((*flaghelper.StringFlag)(&targetConfig), "target-config", "")
+
+	if err := flags.Parse(c.args); err != nil {
+		return 1
+	}
+
+	var err error
+	testCfg.StrategyConfig, err = parseKeyValues(strategyConfig)
+	if err != nil {
+		fmt.Printf("Invalid strategy config. %v\n", err)
+		return 1
+	}
+
+	if len(targetConfig) > 0 {
+		testCfg.TargetConfig, err = parseKeyValues(targetConfig)
+		if err != nil {
+			fmt.Printf("Invalid target config. %v\n", err)
+			return 1
+		}
+	}
+
+	cfg := loadConfig(configPath, cmdConfig, nil)
+	if cfg == nil {
+		fmt.Println("Run 'nomad-autoscaler selftest --help' for more information.")
+		return 1
+	}
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:  "selftest",
+		Level: hclog.LevelFromString(logLevel),
+	})
+
+	results, err := agent.NewAgent(cfg, logger).SelfTest(testCfg)
+	if err != nil {
+		fmt.Printf("Self test failed. %v\n", err)
+		return 1
+	}
+
+	if !printSelfTestResults(results) {
+		return 1
+	}
+	return 0
+}
+
+// printSelfTestResults prints a row for each plugin and returns whether all
+// the plugins succeeded.
+func printSelfTestResults(results []*policyeval.SelfTestResult) bool {
+	ok := true
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Type\tName\tStatus\tCalls\tDuration\tError")
+
+	for _, r := range results {
+		status, errMsg := "ok", ""
+		if r.Error != nil {
+			ok = false
+			status, errMsg = "failed", r.Error.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", r.Type, r.Name, status, r.Calls, r.Duration, errMsg)
+	}
+
+	w.Flush()
+	return ok
+}
+
+// parseKeyValues parses the key=value pairs into a map.
+func parseKeyValues(pairs []string) (map[string]string, error) {
+	out := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("expected key=value, found %q", pair)
+		}
+		out[kv[0]] = kv[1]
+	}
+	return out, nil
+}
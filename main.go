@@ -18,6 +18,9 @@ func main() {
 		"agent": func() (cli.Command, error) {
 			return &command.AgentCommand{}, nil
 		},
+		"selftest": func() (cli.Command, error) {
+			return &command.SelfTestCommand{}, nil
+		},
 		"version": func() (cli.Command, error) {
 			return &command.VersionCommand{Version: versionString}, nil
 		},
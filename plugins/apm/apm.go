@@ -2,6 +2,7 @@ package apm
 
 import (
 	"net/rpc"
+	"strings"
 
 	plugin "github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/nomad-autoscaler/plugins/base"
@@ -15,6 +16,20 @@ type APM interface {
 	SetConfig(config map[string]string) error
 }
 
+// QueryValidator is optionally implemented by APM plugins which can check a
+// query without running it. The agent validates the queries of the policy
+// checks when a policy is loaded, so syntax errors are reported immediately
+// rather than when the policy is evaluated. Plugins which do not implement it
+// skip the validation.
+type QueryValidator interface {
+	ValidateQuery(q string) error
+}
+
+// rpcErrMethodNotFound is the prefix of the net/rpc error returned when the
+// plugin does not serve a method, such as plugins built against an older
+// version of the SDK.
+const rpcErrMethodNotFound = "rpc: can't find method "
+
 type QueryRPCReq struct {
 	Query string
 	Range sdk.TimeRange
@@ -45,6 +60,21 @@ func (r *RPC) Query(q string, rng sdk.TimeRange) (sdk.TimestampedMetrics, error)
 	return resp, nil
 }
 
+// ValidateQuery satisfies the QueryValidator interface. Plugins which do not
+// implement it, including those which do not serve the method, skip the
+// validation.
+func (r *RPC) ValidateQuery(q string) error {
+	var resp error
+	err := r.client.Call("Plugin.ValidateQuery", q, &resp)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), rpcErrMethodNotFound) {
+			return nil
+		}
+		return err
+	}
+	return resp
+}
+
 func (r *RPC) PluginInfo() (*base.PluginInfo, error) {
 	var resp base.PluginInfo
 	err := r.client.Call("Plugin.PluginInfo", new(interface{}), &resp)
@@ -74,6 +104,16 @@ func (s *RPCServer) Query(req QueryRPCReq, resp *sdk.TimestampedMetrics) error {
 	return nil
 }
 
+func (s *RPCServer) ValidateQuery(q string, resp *error) error {
+	v, ok := s.Impl.(QueryValidator)
+	if !ok {
+		return nil
+	}
+	err := v.ValidateQuery(q)
+	*resp = err
+	return err
+}
+
 func (s *RPCServer) PluginInfo(_ interface{}, r *base.PluginInfo) error {
 	resp, err := s.Impl.PluginInfo()
 	if resp != nil {
@@ -58,22 +58,38 @@ func (a *APMPlugin) getTaskGroupResourceUsage(query *taskGroupQuery) ([]float64,
 	var resp []float64
 
 	// Define a function that manages updating our response.
-	metricFunc := func(m *[]float64, ru *api.ResourceUsage) {}
+	metricFunc := func(m *[]float64, ru *api.ResourceUsage, ar *api.AllocatedResources) {}
 
 	// Depending on the desired metric, the function will append different data
 	// to the response. Using a function means we only have to perform the
 	// switch a single time, rather than on a per allocation basis.
 	switch query.metric {
 	case queryMetricCPU:
-		metricFunc = func(m *[]float64, ru *api.ResourceUsage) {
+		metricFunc = func(m *[]float64, ru *api.ResourceUsage, _ *api.AllocatedResources) {
 			*m = append(*m, ru.CpuStats.Percent)
 		}
 	case queryMetricMem:
-		metricFunc = func(m *[]float64, ru *api.ResourceUsage) {
+		metricFunc = func(m *[]float64, ru *api.ResourceUsage, _ *api.AllocatedResources) {
 			*m = append(*m, float64(ru.MemoryStats.Usage))
 		}
+	case queryMetricCPUUtilization:
+		metricFunc = func(m *[]float64, ru *api.ResourceUsage, ar *api.AllocatedResources) {
+			if u, ok := cpuUtilization(ru, ar); ok {
+				*m = append(*m, u)
+			}
+		}
+	case queryMetricMemUtilization:
+		metricFunc = func(m *[]float64, ru *api.ResourceUsage, ar *api.AllocatedResources) {
+			if u, ok := memoryUtilization(ru, ar); ok {
+				*m = append(*m, u)
+			}
+		}
 	}
 
+	// The utilization metrics are relative to the resources allocated to
+	// each allocation.
+	needResources := query.metric == queryMetricCPUUtilization || query.metric == queryMetricMemUtilization
+
 	for _, alloc := range allocs {
 
 		// If the allocation is not running, or is not part of the target task
@@ -95,18 +111,69 @@ func (a *APMPlugin) getTaskGroupResourceUsage(query *taskGroupQuery) ([]float64,
 		}
 
 		// Be safe, be sensible.
-		if allocStats == nil {
+		if allocStats == nil || allocStats.ResourceUsage == nil {
 			continue
 		}
 
+		// The allocation listing does not always include the allocated
+		// resources, in which case they are read from the allocation.
+		resources := alloc.AllocatedResources
+		if needResources && resources == nil {
+			info, _, err := a.client.Allocations().Info(alloc.ID, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get alloc info: %v", err)
+			}
+			resources = info.AllocatedResources
+		}
+
 		// Call the metric function to append the allocation resource metric to
 		// the response.
-		metricFunc(&resp, allocStats.ResourceUsage)
+		metricFunc(&resp, allocStats.ResourceUsage, resources)
 	}
 
 	return resp, nil
 }
 
+// cpuUtilization returns the CPU usage of an allocation as a percentage of
+// the CPU allocated to its tasks. The returned bool is false if the allocated
+// CPU is unknown.
+func cpuUtilization(ru *api.ResourceUsage, ar *api.AllocatedResources) (float64, bool) {
+	if ru.CpuStats == nil || ar == nil {
+		return 0, false
+	}
+
+	var allocated int64
+	for _, t := range ar.Tasks {
+		if t != nil {
+			allocated += t.Cpu.CpuShares
+		}
+	}
+	if allocated <= 0 {
+		return 0, false
+	}
+	return ru.CpuStats.TotalTicks / float64(allocated) * 100, true
+}
+
+// memoryUtilization returns the memory usage of an allocation as a percentage
+// of the memory allocated to its tasks. The returned bool is false if the
+// allocated memory is unknown.
+func memoryUtilization(ru *api.ResourceUsage, ar *api.AllocatedResources) (float64, bool) {
+	if ru.MemoryStats == nil || ar == nil {
+		return 0, false
+	}
+
+	var allocated int64
+	for _, t := range ar.Tasks {
+		if t != nil {
+			allocated += t.Memory.MemoryMB
+		}
+	}
+	if allocated <= 0 {
+		return 0, false
+	}
+	return float64(ru.MemoryStats.Usage) / float64(allocated*1024*1024) * 100, true
+}
+
 // calculateTaskGroupResult determines the query result based on the metrics
 // and operation to perform.
 func calculateTaskGroupResult(op string, metrics []float64) sdk.TimestampedMetrics {
@@ -169,7 +236,7 @@ func parseTaskGroupQuery(q string) (*taskGroupQuery, error) {
 	op := opMetricParts[1]
 	metric := opMetricParts[2]
 
-	if err := validateMetric(metric); err != nil {
+	if err := validateTaskGroupMetric(metric); err != nil {
 		return nil, err
 	}
 	query.metric = metric
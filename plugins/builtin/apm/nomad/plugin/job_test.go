@@ -3,6 +3,7 @@ package plugin
 import (
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -76,6 +77,28 @@ func Test_parseTaskGroupQuery(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name:  "avg_cpu-utilization",
+			input: "taskgroup_avg_cpu-utilization/group/job",
+			expected: &taskGroupQuery{
+				metric:    "cpu-utilization",
+				job:       "job",
+				group:     "group",
+				operation: "avg",
+			},
+			expectError: false,
+		},
+		{
+			name:  "max_memory-utilization",
+			input: "taskgroup_max_memory-utilization/group/job",
+			expected: &taskGroupQuery{
+				metric:    "memory-utilization",
+				job:       "job",
+				group:     "group",
+				operation: "max",
+			},
+			expectError: false,
+		},
 		{
 			name:  "job with fwd slashes",
 			input: "taskgroup_avg_cpu/group/my/super/job//",
@@ -133,3 +156,79 @@ func Test_parseTaskGroupQuery(t *testing.T) {
 		})
 	}
 }
+
+func Test_cpuUtilization(t *testing.T) {
+	testCases := []struct {
+		inputUsage     *api.ResourceUsage
+		inputResources *api.AllocatedResources
+		expectedOutput float64
+		expectedOK     bool
+		name           string
+	}{
+		{
+			inputUsage: &api.ResourceUsage{CpuStats: &api.CpuStats{TotalTicks: 250}},
+			inputResources: &api.AllocatedResources{Tasks: map[string]*api.AllocatedTaskResources{
+				"web":     {Cpu: api.AllocatedCpuResources{CpuShares: 400}},
+				"sidecar": {Cpu: api.AllocatedCpuResources{CpuShares: 100}},
+			}},
+			expectedOutput: 50,
+			expectedOK:     true,
+			name:           "usage across tasks",
+		},
+		{
+			inputUsage:     &api.ResourceUsage{CpuStats: &api.CpuStats{TotalTicks: 250}},
+			inputResources: nil,
+			expectedOK:     false,
+			name:           "unknown resources",
+		},
+		{
+			inputUsage:     &api.ResourceUsage{CpuStats: &api.CpuStats{TotalTicks: 250}},
+			inputResources: &api.AllocatedResources{},
+			expectedOK:     false,
+			name:           "no allocated cpu",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, actualOK := cpuUtilization(tc.inputUsage, tc.inputResources)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedOK, actualOK, tc.name)
+		})
+	}
+}
+
+func Test_memoryUtilization(t *testing.T) {
+	testCases := []struct {
+		inputUsage     *api.ResourceUsage
+		inputResources *api.AllocatedResources
+		expectedOutput float64
+		expectedOK     bool
+		name           string
+	}{
+		{
+			inputUsage: &api.ResourceUsage{MemoryStats: &api.MemoryStats{Usage: 192 * 1024 * 1024}},
+			inputResources: &api.AllocatedResources{Tasks: map[string]*api.AllocatedTaskResources{
+				"web":     {Memory: api.AllocatedMemoryResources{MemoryMB: 256}},
+				"sidecar": {Memory: api.AllocatedMemoryResources{MemoryMB: 128}},
+			}},
+			expectedOutput: 50,
+			expectedOK:     true,
+			name:           "usage across tasks",
+		},
+		{
+			inputUsage:     &api.ResourceUsage{},
+			inputResources: &api.AllocatedResources{},
+			expectedOK:     false,
+			name:           "no memory stats",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, actualOK := memoryUtilization(tc.inputUsage, tc.inputResources)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedOK, actualOK, tc.name)
+		})
+	}
+}
@@ -26,6 +26,12 @@ const (
 	// queryMetrics are the supported resources for querying.
 	queryMetricCPU = "cpu"
 	queryMetricMem = "memory"
+
+	// queryMetricUtilizations are the supported resource utilizations for
+	// task group queries, which are the resource usage of each allocation as
+	// a percentage of the resources allocated to it.
+	queryMetricCPUUtilization = "cpu-utilization"
+	queryMetricMemUtilization = "memory-utilization"
 )
 
 // Query satisfies the Query function on the apm.APM interface.
@@ -46,6 +52,24 @@ func (a *APMPlugin) Query(q string, _ sdk.TimeRange) (sdk.TimestampedMetrics, er
 	}
 }
 
+// ValidateQuery satisfies the ValidateQuery function on the apm.QueryValidator
+// interface, parsing the query without querying Nomad.
+func (a *APMPlugin) ValidateQuery(q string) error {
+	querySplit := strings.Split(q, "_")
+
+	var err error
+
+	switch querySplit[0] {
+	case QueryTypeTaskGroup:
+		_, err = parseTaskGroupQuery(q)
+	case QueryTypeNode:
+		_, err = parseNodePoolQuery(q)
+	default:
+		err = fmt.Errorf("unsupported query type %q", querySplit[0])
+	}
+	return err
+}
+
 func (a *APMPlugin) QueryMultiple(q string, r sdk.TimeRange) ([]sdk.TimestampedMetrics, error) {
 	d, err := a.Query(q, r)
 	if err != nil {
@@ -69,3 +93,19 @@ func validateMetric(metric string) error {
 	}
 	return err
 }
+
+// validateTaskGroupMetric helps ensure the desired metric within a task group
+// query is able to be handled by the plugin. Task group queries support the
+// resource utilizations on top of the resources.
+func validateTaskGroupMetric(metric string) error {
+
+	var err error
+
+	switch metric {
+	case queryMetricCPU, queryMetricMem, queryMetricCPUUtilization, queryMetricMemUtilization:
+	default:
+		err = fmt.Errorf(`invalid metric %q, allowed values are %s, %s, %s or %s`,
+			metric, queryMetricCPU, queryMetricMem, queryMetricCPUUtilization, queryMetricMemUtilization)
+	}
+	return err
+}
@@ -37,3 +37,82 @@ func Test_validateMetric(t *testing.T) {
 		})
 	}
 }
+
+func Test_validateTaskGroupMetric(t *testing.T) {
+	testCases := []struct {
+		inputMetric    string
+		expectedOutput error
+		name           string
+	}{
+		{
+			inputMetric:    "memory",
+			expectedOutput: nil,
+			name:           "memory metric",
+		},
+		{
+			inputMetric:    "cpu-utilization",
+			expectedOutput: nil,
+			name:           "cpu utilization metric",
+		},
+		{
+			inputMetric:    "memory-utilization",
+			expectedOutput: nil,
+			name:           "memory utilization metric",
+		},
+		{
+			inputMetric:    "cost-of-server",
+			expectedOutput: errors.New("invalid metric \"cost-of-server\", allowed values are cpu, memory, cpu-utilization or memory-utilization"),
+			name:           "invalid metric",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput := validateTaskGroupMetric(tc.inputMetric)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+		})
+	}
+}
+
+func TestAPMPlugin_ValidateQuery(t *testing.T) {
+	testCases := []struct {
+		inputQuery  string
+		expectError bool
+		name        string
+	}{
+		{
+			inputQuery:  "taskgroup_avg_cpu/group/job",
+			expectError: false,
+			name:        "valid task group query",
+		},
+		{
+			inputQuery:  "node_percentage-allocated_memory/class/node_class",
+			expectError: false,
+			name:        "valid node query",
+		},
+		{
+			inputQuery:  "taskgroup_median_cpu/group/job",
+			expectError: true,
+			name:        "invalid task group operation",
+		},
+		{
+			inputQuery:  "node_percentage-allocated_cpu",
+			expectError: true,
+			name:        "node query without pool",
+		},
+		{
+			inputQuery:  "cluster_avg_cpu",
+			expectError: true,
+			name:        "unsupported query type",
+		},
+	}
+
+	a := &APMPlugin{}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := a.ValidateQuery(tc.inputQuery)
+			assert.Equal(t, tc.expectError, err != nil, tc.name)
+		})
+	}
+}
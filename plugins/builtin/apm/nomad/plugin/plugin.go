@@ -46,6 +46,9 @@ func NewNomadPlugin(log hclog.Logger) apm.APM {
 func (a *APMPlugin) SetConfig(config map[string]string) error {
 
 	cfg := nomadHelper.ConfigFromNamespacedMap(config)
+	if err := nomadHelper.ConfigureHTTPClientFromNamespacedMap(cfg, config); err != nil {
+		return fmt.Errorf("failed to configure Nomad HTTP client: %v", err)
+	}
 
 	client, err := api.NewClient(cfg)
 	if err != nil {
@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/apm"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+const (
+	// pluginName is the name of the plugin
+	pluginName = "push"
+
+	// configKeyTTL is the accepted configuration key which holds the time a
+	// pushed value is served for.
+	configKeyTTL = "ttl"
+
+	// defaultTTL is the time a pushed value is served for if the ttl config
+	// value is not set.
+	defaultTTL = 5 * time.Minute
+)
+
+var (
+	PluginID = plugins.PluginID{
+		Name:       pluginName,
+		PluginType: plugins.PluginTypeAPM,
+	}
+
+	pluginInfo = &base.PluginInfo{
+		Name:       pluginName,
+		PluginType: plugins.PluginTypeAPM,
+	}
+)
+
+// APMPlugin serves the metric values pushed to the agent HTTP API. The query
+// is the name of the metric, and each query returns the most recent value
+// pushed for it. Values older than the TTL are stale and not returned, so the
+// policy holds its count as it does for any check without metrics.
+type APMPlugin struct {
+	logger hclog.Logger
+	store  *Store
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewPushPlugin returns a push APM plugin serving the values held by the
+// store.
+func NewPushPlugin(log hclog.Logger, store *Store) apm.APM {
+	return &APMPlugin{
+		logger: log,
+		store:  store,
+		ttl:    defaultTTL,
+		now:    time.Now,
+	}
+}
+
+func (a *APMPlugin) SetConfig(config map[string]string) error {
+	a.ttl = defaultTTL
+
+	if val, ok := config[configKeyTTL]; ok {
+		ttl, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q config value: %v", configKeyTTL, err)
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("%q config value must be positive", configKeyTTL)
+		}
+		a.ttl = ttl
+	}
+
+	a.store.registerTTL(a.ttl)
+	return nil
+}
+
+func (a *APMPlugin) PluginInfo() (*base.PluginInfo, error) {
+	return pluginInfo, nil
+}
+
+func (a *APMPlugin) Query(q string, _ sdk.TimeRange) (sdk.TimestampedMetrics, error) {
+	if err := ValidateName(q); err != nil {
+		return nil, err
+	}
+
+	m, ok := a.store.Get(q)
+	if !ok {
+		a.logger.Debug("no value pushed for metric", "metric", q)
+		return sdk.TimestampedMetrics{}, nil
+	}
+
+	if age := a.now().Sub(m.Timestamp); age > a.ttl {
+		a.logger.Debug("pushed value is stale", "metric", q, "age", age, "ttl", a.ttl)
+		return sdk.TimestampedMetrics{}, nil
+	}
+
+	return sdk.TimestampedMetrics{m}, nil
+}
+
+func (a *APMPlugin) QueryMultiple(q string, r sdk.TimeRange) ([]sdk.TimestampedMetrics, error) {
+	m, err := a.Query(q, r)
+	if err != nil {
+		return nil, err
+	}
+	return []sdk.TimestampedMetrics{m}, nil
+}
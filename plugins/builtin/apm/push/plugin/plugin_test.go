@@ -0,0 +1,197 @@
+package plugin
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPMPlugin_SetConfig(t *testing.T) {
+	testCases := []struct {
+		inputConfig map[string]string
+		expectedTTL time.Duration
+		expectError bool
+		name        string
+	}{
+		{
+			inputConfig: map[string]string{},
+			expectedTTL: defaultTTL,
+			name:        "default ttl",
+		},
+		{
+			inputConfig: map[string]string{"ttl": "30s"},
+			expectedTTL: 30 * time.Second,
+			name:        "custom ttl",
+		},
+		{
+			inputConfig: map[string]string{"ttl": "soon"},
+			expectError: true,
+			name:        "malformed ttl",
+		},
+		{
+			inputConfig: map[string]string{"ttl": "0s"},
+			expectError: true,
+			name:        "zero ttl",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			apmPlugin := NewPushPlugin(hclog.NewNullLogger(), NewStore(DefaultMaxNames)).(*APMPlugin)
+			err := apmPlugin.SetConfig(tc.inputConfig)
+			assert.Equal(t, tc.expectError, err != nil, tc.name)
+			if !tc.expectError {
+				assert.Equal(t, tc.expectedTTL, apmPlugin.ttl, tc.name)
+			}
+		})
+	}
+}
+
+func TestAPMPlugin_Query(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewStore(DefaultMaxNames)
+	assert.NoError(t, store.Push("fresh", 12.5, now.Add(-time.Minute)))
+	assert.NoError(t, store.Push("stale", 3, now.Add(-10*time.Minute)))
+
+	testCases := []struct {
+		inputQuery      string
+		expectedMetrics sdk.TimestampedMetrics
+		expectError     bool
+		name            string
+	}{
+		{
+			inputQuery:      "fresh",
+			expectedMetrics: sdk.TimestampedMetrics{{Timestamp: now.Add(-time.Minute), Value: 12.5}},
+			name:            "fresh value",
+		},
+		{
+			inputQuery:      "stale",
+			expectedMetrics: sdk.TimestampedMetrics{},
+			name:            "stale value",
+		},
+		{
+			inputQuery:      "missing",
+			expectedMetrics: sdk.TimestampedMetrics{},
+			name:            "value never pushed",
+		},
+		{
+			inputQuery:  "",
+			expectError: true,
+			name:        "empty metric name",
+		},
+	}
+
+	apmPlugin := &APMPlugin{
+		logger: hclog.NewNullLogger(),
+		store:  store,
+		ttl:    defaultTTL,
+		now:    func() time.Time { return now },
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := apmPlugin.Query(tc.inputQuery, sdk.TimeRange{})
+			assert.Equal(t, tc.expectError, err != nil, tc.name)
+			assert.Equal(t, tc.expectedMetrics, m, tc.name)
+		})
+	}
+}
+
+func TestStore_Push(t *testing.T) {
+	testCases := []struct {
+		inputName   string
+		inputValue  float64
+		expectError bool
+		name        string
+	}{
+		{
+			inputName:  "events_per_second",
+			inputValue: 42,
+			name:       "valid value",
+		},
+		{
+			inputName:  "events_per_second",
+			inputValue: -1,
+			name:       "negative value",
+		},
+		{
+			inputName:   "events_per_second",
+			inputValue:  math.NaN(),
+			expectError: true,
+			name:        "NaN value",
+		},
+		{
+			inputName:   "events_per_second",
+			inputValue:  math.Inf(1),
+			expectError: true,
+			name:        "infinite value",
+		},
+		{
+			inputName:   "",
+			inputValue:  1,
+			expectError: true,
+			name:        "empty name",
+		},
+		{
+			inputName:   "events/second",
+			inputValue:  1,
+			expectError: true,
+			name:        "name with slash",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewStore(DefaultMaxNames)
+			err := store.Push(tc.inputName, tc.inputValue, time.Now())
+			assert.Equal(t, tc.expectError, err != nil, tc.name)
+
+			m, ok := store.Get(tc.inputName)
+			assert.Equal(t, !tc.expectError, ok, tc.name)
+			if ok {
+				assert.Equal(t, tc.inputValue, m.Value, tc.name)
+			}
+		})
+	}
+}
+
+func TestStore_Push_maxNames(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewStore(2)
+	assert.NoError(t, store.Push("a", 1, now.Add(-10*time.Minute)))
+	assert.NoError(t, store.Push("b", 1, now.Add(-time.Minute)))
+
+	// Known names are updated when the store is full.
+	assert.NoError(t, store.Push("b", 2, now))
+
+	// New names evict the values older than the default TTL.
+	assert.NoError(t, store.Push("c", 1, now))
+	_, ok := store.Get("a")
+	assert.False(t, ok)
+
+	// New names are rejected once no value can be evicted.
+	assert.Error(t, store.Push("d", 1, now))
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestStore_registerTTL(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewStore(1)
+	apmPlugin := NewPushPlugin(hclog.NewNullLogger(), store)
+	assert.NoError(t, apmPlugin.SetConfig(map[string]string{"ttl": "1h"}))
+
+	// Values served by a plugin with a longer TTL are not evicted.
+	assert.NoError(t, store.Push("a", 1, now.Add(-30*time.Minute)))
+	assert.Error(t, store.Push("b", 1, now))
+
+	assert.NoError(t, store.Push("c", 1, now.Add(2*time.Hour)))
+	_, ok := store.Get("a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// DefaultMaxNames is the number of metric names a Store holds if it is not
+// configured otherwise.
+const DefaultMaxNames = 10000
+
+// Store holds the most recent value pushed for each metric name. It is owned
+// by the agent, which records the values pushed to its HTTP API, and shared
+// with the push APM plugin instances, which serve them.
+//
+// The number of names is capped, as they are chosen by the clients pushing
+// the values. Values older than the longest TTL of the plugins using the
+// store are never served, so they are evicted to make room for new names.
+type Store struct {
+	lock     sync.RWMutex
+	values   map[string]sdk.TimestampedMetric
+	maxNames int
+	maxAge   time.Duration
+}
+
+// NewStore returns an empty Store holding at most maxNames metric names.
+func NewStore(maxNames int) *Store {
+	return &Store{
+		values:   make(map[string]sdk.TimestampedMetric),
+		maxNames: maxNames,
+		maxAge:   defaultTTL,
+	}
+}
+
+// Factory returns the factory of push APM plugin instances serving the values
+// held by the store.
+func Factory(s *Store) plugins.PluginFactory {
+	return func(l hclog.Logger) interface{} { return NewPushPlugin(l, s) }
+}
+
+// Push records value as the most recent value of the named metric, replacing
+// any previous value. An error is returned if the metric is new and the store
+// is full, even once the stale values are evicted.
+func (s *Store) Push(name string, value float64, ts time.Time) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("value %v is not a finite number", value)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.values[name]; !ok && len(s.values) >= s.maxNames {
+		s.evictLocked(ts)
+		if len(s.values) >= s.maxNames {
+			return fmt.Errorf("store is full, at most %d metric names are held", s.maxNames)
+		}
+	}
+
+	s.values[name] = sdk.TimestampedMetric{Timestamp: ts, Value: value}
+	return nil
+}
+
+// Get returns the most recent value of the named metric, if any was pushed.
+func (s *Store) Get(name string) (sdk.TimestampedMetric, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	m, ok := s.values[name]
+	return m, ok
+}
+
+// Len returns the number of metric names held by the store.
+func (s *Store) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.values)
+}
+
+// registerTTL records the TTL of a plugin instance using the store, so values
+// are only evicted once no plugin instance serves them.
+func (s *Store) registerTTL(ttl time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if ttl > s.maxAge {
+		s.maxAge = ttl
+	}
+}
+
+// evictLocked removes the values older than the longest TTL of the plugin
+// instances using the store.
+//
+// This method is not thread-safe so a lock should be acquired before calling
+// it.
+func (s *Store) evictLocked(now time.Time) {
+	for name, m := range s.values {
+		if now.Sub(m.Timestamp) > s.maxAge {
+			delete(s.values, name)
+		}
+	}
+}
+
+// ValidateName returns an error if name cannot be used as a metric name. Names
+// are used within the push endpoint path, so they must not contain '/'.
+func ValidateName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("metric name must not be empty")
+	case strings.Contains(name, "/"):
+		return fmt.Errorf("metric name %q must not contain '/'", name)
+	}
+	return nil
+}
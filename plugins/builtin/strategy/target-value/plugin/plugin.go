@@ -2,7 +2,6 @@ package plugin
 
 import (
 	"fmt"
-	"math"
 	"strconv"
 
 	"github.com/hashicorp/go-hclog"
@@ -21,6 +20,14 @@ const (
 	runConfigKeyTarget    = "target"
 	runConfigKeyThreshold = "threshold"
 
+	// runConfigKeyItemsPerInstance is set in place of the target when the
+	// metric is a total across all instances, such as the depth of a queue
+	// the instances consume. The new count is the metric divided by the items
+	// per instance, rounded up unless the policy sets another rounding mode,
+	// so there are always enough instances to keep each at or below the
+	// ratio. A total of zero scales to zero, subject to the policy min.
+	runConfigKeyItemsPerInstance = "items_per_instance"
+
 	// defaultThreshold controls how significant is a change in the input
 	// metric value.
 	defaultThreshold = "0.01"
@@ -74,15 +81,32 @@ func (s *StrategyPlugin) PluginInfo() (*base.PluginInfo, error) {
 // Run satisfies the Run function on the strategy.Strategy interface.
 func (s *StrategyPlugin) Run(eval *sdk.ScalingCheckEvaluation, count int64) (*sdk.ScalingCheckEvaluation, error) {
 
-	// Read and parse target value from req.Config.
+	// Read and parse target value from req.Config. Checks using a total
+	// metric set the items per instance instead.
 	t := eval.Check.Strategy.Config[runConfigKeyTarget]
-	if t == "" {
+	ipi := eval.Check.Strategy.Config[runConfigKeyItemsPerInstance]
+
+	var target, itemsPerInstance float64
+	var err error
+
+	switch {
+	case t != "" && ipi != "":
+		return nil, fmt.Errorf("only one of `target` and `items_per_instance` may be set")
+	case ipi != "":
+		itemsPerInstance, err = strconv.ParseFloat(ipi, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for `items_per_instance`: %v (%T)", ipi, ipi)
+		}
+		if itemsPerInstance <= 0 {
+			return nil, fmt.Errorf("`items_per_instance` must be greater than zero")
+		}
+	case t == "":
 		return nil, fmt.Errorf("missing required field `target`")
-	}
-
-	target, err := strconv.ParseFloat(t, 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid value for `target`: %v (%T)", t, t)
+	default:
+		target, err = strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for `target`: %v (%T)", t, t)
+		}
 	}
 
 	// Read and parse threshold value from req.Config.
@@ -100,17 +124,26 @@ func (s *StrategyPlugin) Run(eval *sdk.ScalingCheckEvaluation, count int64) (*sd
 
 	// This shouldn't happen, but check it just in case.
 	if len(eval.Metrics) == 0 {
-		return nil, nil
+		eval.Status = sdk.StrategyStatusNoData
+		return eval, nil
 	}
 
 	// Use only the latest value for now.
 	metric := eval.Metrics[len(eval.Metrics)-1]
 
+	// A total metric is converted into the count it requires, which the
+	// factor compares against the current count in the same way as a per
+	// instance metric is compared against the target.
+	//
 	// Handle cases where the specified target is 0. A potential use case here
 	// is targeting a CI build queue to be 0. Adding in build agents when the
 	// queue has greater than 0 items in it.
-	switch target {
-	case 0:
+	switch {
+	case itemsPerInstance > 0 && count > 0:
+		factor = metric.Value / itemsPerInstance / float64(count)
+	case itemsPerInstance > 0:
+		factor = metric.Value / itemsPerInstance
+	case target == 0:
 		factor = metric.Value
 	default:
 		factor = metric.Value / target
@@ -126,12 +159,17 @@ func (s *StrategyPlugin) Run(eval *sdk.ScalingCheckEvaluation, count int64) (*sd
 
 	// Handle cases were users wish to scale from 0. If the current count is 0,
 	// then just use the factor as the new count to target. Otherwise use our
-	// standard calculation.
-	switch count {
-	case 0:
-		newCount = int64(math.Ceil(factor))
+	// standard calculation. The count required by a total metric is
+	// calculated directly, so rounding errors in the factor cannot add an
+	// instance. Counts are rounded up, unless the policy sets a rounding
+	// mode.
+	switch {
+	case itemsPerInstance > 0:
+		newCount = sdk.RoundCount(metric.Value/itemsPerInstance, eval.Rounding, sdk.RoundingModeCeil)
+	case count == 0:
+		newCount = sdk.RoundCount(factor, eval.Rounding, sdk.RoundingModeCeil)
 	default:
-		newCount = int64(math.Ceil(float64(count) * factor))
+		newCount = sdk.RoundCount(float64(count)*factor, eval.Rounding, sdk.RoundingModeCeil)
 	}
 
 	// Log at trace level the details of the strategy calculation. This is
@@ -151,6 +189,10 @@ func (s *StrategyPlugin) Run(eval *sdk.ScalingCheckEvaluation, count int64) (*sd
 
 	eval.Action.Count = newCount
 	eval.Action.Reason = fmt.Sprintf("scaling %s because factor is %f", eval.Action.Direction, factor)
+	eval.Action.ReasonCode = sdk.ReasonCodeMetricBelowTarget
+	if eval.Action.Direction == sdk.ScaleDirectionUp {
+		eval.Action.ReasonCode = sdk.ReasonCodeMetricAboveTarget
+	}
 
 	return eval, nil
 }
@@ -69,6 +69,28 @@ func TestStrategyPlugin_Run(t *testing.T) {
 			expectedError: fmt.Errorf("invalid value for `threshold`: not-the-float-you're-looking-for (string)"),
 			name:          "incorrect input strategy config threshold value",
 		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"target": "13"},
+					},
+				},
+				Action: &sdk.ScalingAction{},
+			},
+			inputCount: 2,
+			expectedResp: &sdk.ScalingCheckEvaluation{
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"target": "13"},
+					},
+				},
+				Action: &sdk.ScalingAction{},
+				Status: sdk.StrategyStatusNoData,
+			},
+			expectedError: nil,
+			name:          "no metrics",
+		},
 		{
 			inputEval: &sdk.ScalingCheckEvaluation{
 				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 13}},
@@ -113,9 +135,10 @@ func TestStrategyPlugin_Run(t *testing.T) {
 					},
 				},
 				Action: &sdk.ScalingAction{
-					Count:     4,
-					Reason:    "scaling up because factor is 2.000000",
-					Direction: sdk.ScaleDirectionUp,
+					Count:      4,
+					Reason:     "scaling up because factor is 2.000000",
+					ReasonCode: sdk.ReasonCodeMetricAboveTarget,
+					Direction:  sdk.ScaleDirectionUp,
 				},
 			},
 			expectedError: nil,
@@ -140,9 +163,10 @@ func TestStrategyPlugin_Run(t *testing.T) {
 					},
 				},
 				Action: &sdk.ScalingAction{
-					Count:     2,
-					Reason:    "scaling up because factor is 2.000000",
-					Direction: sdk.ScaleDirectionUp,
+					Count:      2,
+					Reason:     "scaling up because factor is 2.000000",
+					ReasonCode: sdk.ReasonCodeMetricAboveTarget,
+					Direction:  sdk.ScaleDirectionUp,
 				},
 			},
 			expectedError: nil,
@@ -192,9 +216,10 @@ func TestStrategyPlugin_Run(t *testing.T) {
 					},
 				},
 				Action: &sdk.ScalingAction{
-					Count:     1,
-					Reason:    "scaling up because factor is 0.100000",
-					Direction: sdk.ScaleDirectionUp,
+					Count:      1,
+					Reason:     "scaling up because factor is 0.100000",
+					ReasonCode: sdk.ReasonCodeMetricAboveTarget,
+					Direction:  sdk.ScaleDirectionUp,
 				},
 			},
 			expectedError: nil,
@@ -219,9 +244,10 @@ func TestStrategyPlugin_Run(t *testing.T) {
 					},
 				},
 				Action: &sdk.ScalingAction{
-					Count:     0,
-					Direction: sdk.ScaleDirectionDown,
-					Reason:    "scaling down because factor is 0.000000",
+					Count:      0,
+					Direction:  sdk.ScaleDirectionDown,
+					Reason:     "scaling down because factor is 0.000000",
+					ReasonCode: sdk.ReasonCodeMetricBelowTarget,
 				},
 			},
 			expectedError: nil,
@@ -271,9 +297,10 @@ func TestStrategyPlugin_Run(t *testing.T) {
 					},
 				},
 				Action: &sdk.ScalingAction{
-					Count:     9,
-					Reason:    "scaling up because factor is 1.000002",
-					Direction: sdk.ScaleDirectionUp,
+					Count:      9,
+					Reason:     "scaling up because factor is 1.000002",
+					ReasonCode: sdk.ReasonCodeMetricAboveTarget,
+					Direction:  sdk.ScaleDirectionUp,
 				},
 			},
 			expectedError: nil,
@@ -314,6 +341,259 @@ func TestStrategyPlugin_Run(t *testing.T) {
 			expectedError: nil,
 			name:          "properly handle multiple input",
 		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 95}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "10"},
+					},
+				},
+				Action: &sdk.ScalingAction{},
+			},
+			inputCount: 3,
+			expectedResp: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 95}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "10"},
+					},
+				},
+				Action: &sdk.ScalingAction{
+					Count:      10,
+					Reason:     "scaling up because factor is 3.166667",
+					ReasonCode: sdk.ReasonCodeMetricAboveTarget,
+					Direction:  sdk.ScaleDirectionUp,
+				},
+			},
+			expectedError: nil,
+			name:          "items per instance rounds up",
+		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 0}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "10"},
+					},
+				},
+				Action: &sdk.ScalingAction{},
+			},
+			inputCount: 4,
+			expectedResp: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 0}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "10"},
+					},
+				},
+				Action: &sdk.ScalingAction{
+					Count:      0,
+					Reason:     "scaling down because factor is 0.000000",
+					ReasonCode: sdk.ReasonCodeMetricBelowTarget,
+					Direction:  sdk.ScaleDirectionDown,
+				},
+			},
+			expectedError: nil,
+			name:          "items per instance with empty queue",
+		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 30}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "10"},
+					},
+				},
+				Action: &sdk.ScalingAction{},
+			},
+			inputCount: 3,
+			expectedResp: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 30}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "10"},
+					},
+				},
+				Action: &sdk.ScalingAction{
+					Direction: sdk.ScaleDirectionNone,
+				},
+			},
+			expectedError: nil,
+			name:          "items per instance at ratio",
+		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 25}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "10"},
+					},
+				},
+				Action: &sdk.ScalingAction{},
+			},
+			inputCount: 0,
+			expectedResp: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 25}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "10"},
+					},
+				},
+				Action: &sdk.ScalingAction{
+					Count:      3,
+					Reason:     "scaling up because factor is 2.500000",
+					ReasonCode: sdk.ReasonCodeMetricAboveTarget,
+					Direction:  sdk.ScaleDirectionUp,
+				},
+			},
+			expectedError: nil,
+			name:          "items per instance with zero count",
+		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 14}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"target": "10"},
+					},
+				},
+				Rounding: sdk.RoundingModeFloor,
+				Action:   &sdk.ScalingAction{},
+			},
+			inputCount: 3,
+			expectedResp: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 14}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"target": "10"},
+					},
+				},
+				Rounding: sdk.RoundingModeFloor,
+				Action: &sdk.ScalingAction{
+					Count:      4,
+					Reason:     "scaling up because factor is 1.400000",
+					ReasonCode: sdk.ReasonCodeMetricAboveTarget,
+					Direction:  sdk.ScaleDirectionUp,
+				},
+			},
+			expectedError: nil,
+			name:          "target with floor rounding",
+		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 14}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"target": "10"},
+					},
+				},
+				Rounding: sdk.RoundingModeRound,
+				Action:   &sdk.ScalingAction{},
+			},
+			inputCount: 3,
+			expectedResp: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 14}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"target": "10"},
+					},
+				},
+				Rounding: sdk.RoundingModeRound,
+				Action: &sdk.ScalingAction{
+					Count:      4,
+					Reason:     "scaling up because factor is 1.400000",
+					ReasonCode: sdk.ReasonCodeMetricAboveTarget,
+					Direction:  sdk.ScaleDirectionUp,
+				},
+			},
+			expectedError: nil,
+			name:          "target with round rounding",
+		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 14}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"target": "10"},
+					},
+				},
+				Rounding: sdk.RoundingModeCeil,
+				Action:   &sdk.ScalingAction{},
+			},
+			inputCount: 3,
+			expectedResp: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 14}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"target": "10"},
+					},
+				},
+				Rounding: sdk.RoundingModeCeil,
+				Action: &sdk.ScalingAction{
+					Count:      5,
+					Reason:     "scaling up because factor is 1.400000",
+					ReasonCode: sdk.ReasonCodeMetricAboveTarget,
+					Direction:  sdk.ScaleDirectionUp,
+				},
+			},
+			expectedError: nil,
+			name:          "target with ceil rounding",
+		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 25}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "10"},
+					},
+				},
+				Rounding: sdk.RoundingModeFloor,
+				Action:   &sdk.ScalingAction{},
+			},
+			inputCount: 0,
+			expectedResp: &sdk.ScalingCheckEvaluation{
+				Metrics: sdk.TimestampedMetrics{sdk.TimestampedMetric{Value: 25}},
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "10"},
+					},
+				},
+				Rounding: sdk.RoundingModeFloor,
+				Action: &sdk.ScalingAction{
+					Count:      2,
+					Reason:     "scaling up because factor is 2.500000",
+					ReasonCode: sdk.ReasonCodeMetricAboveTarget,
+					Direction:  sdk.ScaleDirectionUp,
+				},
+			},
+			expectedError: nil,
+			name:          "items per instance with floor rounding",
+		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"target": "10", "items_per_instance": "10"},
+					},
+				},
+			},
+			expectedResp:  nil,
+			expectedError: fmt.Errorf("only one of `target` and `items_per_instance` may be set"),
+			name:          "target and items per instance both set",
+		},
+		{
+			inputEval: &sdk.ScalingCheckEvaluation{
+				Check: &sdk.ScalingPolicyCheck{
+					Strategy: &sdk.ScalingPolicyStrategy{
+						Config: map[string]string{"items_per_instance": "0"},
+					},
+				},
+			},
+			expectedResp:  nil,
+			expectedError: fmt.Errorf("`items_per_instance` must be greater than zero"),
+			name:          "items per instance zero",
+		},
 	}
 
 	for _, tc := range testCases {
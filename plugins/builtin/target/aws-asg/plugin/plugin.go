@@ -163,6 +163,11 @@ func (t *TargetPlugin) Status(config map[string]string) (*sdk.TargetStatus, erro
 		Meta:  make(map[string]string),
 	}
 
+	// The ASG cannot be scaled beyond its configured MaxSize.
+	if asg.MaxSize != nil {
+		resp.Meta[sdk.TargetStatusMetaKeyCapacity] = strconv.FormatInt(*asg.MaxSize, 10)
+	}
+
 	// If we have previous activities then process the last.
 	if events != nil && len(events) > 0 {
 		processLastActivity(events[0], &resp)
@@ -2,6 +2,9 @@ package nomad
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -23,8 +26,21 @@ const (
 	// processed when performing SetConfig().
 	configKeyJobID     = "Job"
 	configKeyGroup     = "Group"
+	configKeyGroups    = "Groups"
 	configKeyNamespace = "Namespace"
 
+	// configKeyScalingMetadata is the plugin configuration key which enables
+	// the scaling metadata. When enabled, the scaling events recorded by
+	// Nomad include who scaled the group, when and why, on top of the
+	// metadata of the action.
+	configKeyScalingMetadata = "scaling_metadata"
+
+	// The scaling metadata keys added to the scaling events recorded by
+	// Nomad, when enabled.
+	metaKeyScaledBy = "nomad_autoscaler.scaled_by"
+	metaKeyScaledAt = "nomad_autoscaler.scaled_at"
+	metaKeyReason   = "nomad_autoscaler.reason"
+
 	// garbageCollectionNanoSecondThreshold is the nanosecond threshold used
 	// when performing garbage collection of job status handlers.
 	garbageCollectionNanoSecondThreshold = 14400000000000
@@ -66,6 +82,11 @@ type TargetPlugin struct {
 
 	// gcRunning indicates whether the GC loop is running or not.
 	gcRunning bool
+
+	// scalingMetadata indicates the scaling metadata is added to scaling
+	// events, identifying the agent as scaledBy.
+	scalingMetadata bool
+	scaledBy        string
 }
 
 // namespacedJobID encapsulates the namespace and jobID, which together make a
@@ -91,6 +112,9 @@ func (t *TargetPlugin) SetConfig(config map[string]string) error {
 	}
 
 	cfg := nomadHelper.ConfigFromNamespacedMap(config)
+	if err := nomadHelper.ConfigureHTTPClientFromNamespacedMap(cfg, config); err != nil {
+		return fmt.Errorf("failed to configure Nomad HTTP client: %v", err)
+	}
 
 	client, err := api.NewClient(cfg)
 	if err != nil {
@@ -98,16 +122,59 @@ func (t *TargetPlugin) SetConfig(config map[string]string) error {
 	}
 	t.client = client
 
+	if v, ok := config[configKeyScalingMetadata]; ok {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %v", configKeyScalingMetadata, err)
+		}
+		t.scalingMetadata = enabled
+		t.scaledBy = scalingIdentity()
+	}
+
 	return nil
 }
 
+// scalingIdentity returns the identity of the agent recorded as the scaler
+// within the scaling metadata.
+func scalingIdentity() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "nomad-autoscaler"
+	}
+	return "nomad-autoscaler@" + hostname
+}
+
 // PluginInfo satisfies the PluginInfo function on the base.Plugin interface.
 func (t *TargetPlugin) PluginInfo() (*base.PluginInfo, error) {
 	return pluginInfo, nil
 }
 
 // Scale satisfies the Scale function on the target.Target interface.
+//
+// When the target is a set of groups, each group is scaled to the action
+// count. Nomad scales a single group per request, so the groups are scaled in
+// order and scaling stops at the first failure. The returned error details
+// which groups were scaled and which were not, so partial failures are clear.
 func (t *TargetPlugin) Scale(action sdk.ScalingAction, config map[string]string) error {
+	groups, err := groupsFromConfig(config)
+	if err != nil {
+		return err
+	}
+
+	for i, group := range groups {
+		if err := t.scaleGroup(action, config, group); err != nil {
+			if i == 0 {
+				return err
+			}
+			return fmt.Errorf("partially scaled job %s: groups %s were scaled, groups %s were not: %v",
+				config[configKeyJobID], strings.Join(groups[:i], ","), strings.Join(groups[i:], ","), err)
+		}
+	}
+	return nil
+}
+
+// scaleGroup performs the scaling action on a single group of the job.
+func (t *TargetPlugin) scaleGroup(action sdk.ScalingAction, config map[string]string, group string) error {
 	var countIntPtr *int
 	if action.Count != sdk.StrategyActionMetaValueDryRunCount {
 		countInt := int(action.Count)
@@ -123,20 +190,42 @@ func (t *TargetPlugin) Scale(action sdk.ScalingAction, config map[string]string)
 		q.Namespace = namespace
 	}
 
+	meta := action.Meta
+	if t.scalingMetadata {
+		meta = scalingMeta(action, t.scaledBy, time.Now())
+	}
+
 	_, _, err := t.client.Jobs().Scale(config[configKeyJobID],
-		config[configKeyGroup],
+		group,
 		countIntPtr,
 		action.Reason,
 		action.Error,
-		action.Meta,
+		meta,
 		&q)
 
 	if err != nil {
-		return fmt.Errorf("failed to scale group %s/%s: %v", config["job_id"], config["group"], err)
+		return fmt.Errorf("failed to scale group %s/%s: %v", config[configKeyJobID], group, err)
 	}
 	return nil
 }
 
+// scalingMeta returns the metadata of the action with the scaling metadata
+// added. The metadata of the action is copied, as it is shared by the groups
+// scaled by the action.
+func scalingMeta(action sdk.ScalingAction, scaledBy string, now time.Time) map[string]interface{} {
+	meta := make(map[string]interface{}, len(action.Meta)+3)
+	for k, v := range action.Meta {
+		meta[k] = v
+	}
+
+	meta[metaKeyScaledBy] = scaledBy
+	meta[metaKeyScaledAt] = now.UTC().Format(time.RFC3339)
+	if action.Reason != "" {
+		meta[metaKeyReason] = action.Reason
+	}
+	return meta
+}
+
 // Status satisfies the Status function on the target.Target interface.
 func (t *TargetPlugin) Status(config map[string]string) (*sdk.TargetStatus, error) {
 
@@ -147,11 +236,11 @@ func (t *TargetPlugin) Status(config map[string]string) (*sdk.TargetStatus, erro
 		return nil, fmt.Errorf("required config key %q not found", configKeyJobID)
 	}
 
-	// Get the GroupName from the config map. This is a required param and
-	// results in an error if not found or is an empty string.
-	group, ok := config[configKeyGroup]
-	if !ok || group == "" {
-		return nil, fmt.Errorf("required config key %q not found", configKeyGroup)
+	// Get the group names from the config map. A group is a required param
+	// and results in an error if not found or is an empty string.
+	groups, err := groupsFromConfig(config)
+	if err != nil {
+		return nil, err
 	}
 
 	// Attempt to find the namespace config parameter. If this is not included
@@ -179,8 +268,54 @@ func (t *TargetPlugin) Status(config map[string]string) (*sdk.TargetStatus, erro
 		<-t.statusHandlers[nsID].initialDone
 	}
 
+	status, err := t.statusHandlers[nsID].groupsStatus(groups)
+	if err != nil || status == nil {
+		return status, err
+	}
+
+	// Report whether the job is being deployed, so policies can defer
+	// scaling until the deployment completes. Failing to read the deployment
+	// should not prevent policies which do not use it from scaling.
+	active, err := t.statusHandlers[nsID].deploymentActive()
+	if err != nil {
+		t.logger.Warn("failed to determine job deployment status",
+			"namespace", namespace, "job_id", jobID, "error", err)
+	} else {
+		status.Meta[sdk.TargetStatusMetaKeyDeploymentActive] = strconv.FormatBool(active)
+	}
+
 	// Return the status data from the handler to the caller.
-	return t.statusHandlers[nsID].status(group)
+	return status, nil
+}
+
+// groupsFromConfig returns the job groups targeted by the config. The Groups
+// key allows a set of groups to be scaled together and takes precedence over
+// the Group key.
+func groupsFromConfig(config map[string]string) ([]string, error) {
+	if groupsStr := config[configKeyGroups]; groupsStr != "" {
+		var groups []string
+		seen := make(map[string]bool)
+
+		for _, g := range strings.Split(groupsStr, ",") {
+			g = strings.TrimSpace(g)
+			if g == "" || seen[g] {
+				continue
+			}
+			seen[g] = true
+			groups = append(groups, g)
+		}
+
+		if len(groups) == 0 {
+			return nil, fmt.Errorf("config key %q does not contain any groups", configKeyGroups)
+		}
+		return groups, nil
+	}
+
+	group, ok := config[configKeyGroup]
+	if !ok || group == "" {
+		return nil, fmt.Errorf("required config key %q not found", configKeyGroup)
+	}
+	return []string{group}, nil
 }
 
 // garbageCollectionLoop runs a long lived loop, triggering the garbage
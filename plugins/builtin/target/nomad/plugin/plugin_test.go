@@ -5,6 +5,7 @@ import (
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -39,3 +40,95 @@ func TestTargetPlugin_garbageCollect(t *testing.T) {
 		assert.Len(t, targetPlugin.statusHandlers, 4, testName)
 	})
 }
+
+func Test_groupsFromConfig(t *testing.T) {
+	testCases := []struct {
+		inputConfig    map[string]string
+		expectedOutput []string
+		expectedError  bool
+		name           string
+	}{
+		{
+			inputConfig:    map[string]string{"Group": "cache"},
+			expectedOutput: []string{"cache"},
+			expectedError:  false,
+			name:           "single group",
+		},
+		{
+			inputConfig:    map[string]string{"Group": "cache", "Groups": "web, api,web"},
+			expectedOutput: []string{"web", "api"},
+			expectedError:  false,
+			name:           "groups take precedence and are deduplicated",
+		},
+		{
+			inputConfig:    map[string]string{"Groups": " , "},
+			expectedOutput: nil,
+			expectedError:  true,
+			name:           "groups without any group",
+		},
+		{
+			inputConfig:    map[string]string{},
+			expectedOutput: nil,
+			expectedError:  true,
+			name:           "no group",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, err := groupsFromConfig(tc.inputConfig)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedError, err != nil, tc.name)
+		})
+	}
+}
+
+func Test_scalingMeta(t *testing.T) {
+	now := time.Date(2020, 10, 1, 13, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		inputAction    sdk.ScalingAction
+		expectedOutput map[string]interface{}
+		name           string
+	}{
+		{
+			inputAction: sdk.ScalingAction{
+				Reason: "scaling up because factor is 1.500000",
+				Meta:   map[string]interface{}{"nomad_policy_id": "policy-a"},
+			},
+			expectedOutput: map[string]interface{}{
+				"nomad_policy_id":            "policy-a",
+				"nomad_autoscaler.scaled_by": "nomad-autoscaler@host",
+				"nomad_autoscaler.scaled_at": "2020-10-01T13:00:00Z",
+				"nomad_autoscaler.reason":    "scaling up because factor is 1.500000",
+			},
+			name: "metadata added",
+		},
+		{
+			inputAction: sdk.ScalingAction{},
+			expectedOutput: map[string]interface{}{
+				"nomad_autoscaler.scaled_by": "nomad-autoscaler@host",
+				"nomad_autoscaler.scaled_at": "2020-10-01T13:00:00Z",
+			},
+			name: "action without reason or metadata",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inputMeta := make(map[string]interface{}, len(tc.inputAction.Meta))
+			for k, v := range tc.inputAction.Meta {
+				inputMeta[k] = v
+			}
+
+			actualOutput := scalingMeta(tc.inputAction, "nomad-autoscaler@host", now)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+
+			// The metadata of the action is shared by all groups, so must
+			// not be modified.
+			if tc.inputAction.Meta != nil {
+				assert.Equal(t, inputMeta, tc.inputAction.Meta, tc.name)
+			}
+		})
+	}
+}
@@ -22,6 +22,14 @@ const (
 	metaKeyJobStoppedSuffix = ".stopped"
 )
 
+// The terminal statuses of a Nomad deployment. The API package does not
+// export these.
+const (
+	deploymentStatusSuccessful = "successful"
+	deploymentStatusFailed     = "failed"
+	deploymentStatusCancelled  = "cancelled"
+)
+
 // jobScaleStatusHandler is an individual handler on the /v1/job/<job>/scale
 // GET endpoint. It provides methods for obtaining the current scaling state of
 // a job and task group.
@@ -37,6 +45,13 @@ type jobScaleStatusHandler struct {
 	scaleStatus      *api.JobScaleStatusResponse
 	scaleStatusError error
 
+	// deployment is the latest deployment of the job, as read when the job
+	// was at deploymentJobModifyIndex. Deployments are only created when the
+	// job is modified, so a finished deployment does not need to be read
+	// again until the job changes.
+	deployment               *api.Deployment
+	deploymentJobModifyIndex uint64
+
 	// initialDone helps synchronise the caller waiting for the state to be
 	// populated after starting the API query loop.
 	initialDone chan bool
@@ -115,9 +130,109 @@ func (jsh *jobScaleStatusHandler) status(group string) (*sdk.TargetStatus, error
 		resp.Meta[sdk.TargetStatusMetaKeyLastEvent] = strconv.FormatUint(status.Events[0].Time, 10)
 	}
 
+	// The healthy count is only tracked by deployments, so it is only
+	// reported once the group has placed allocations within one. Otherwise
+	// the group would be reported as entirely unhealthy.
+	if status.Placed > 0 {
+		resp.Meta[sdk.TargetStatusMetaKeyHealthyCount] = strconv.Itoa(status.Healthy)
+	}
+
 	return &resp, nil
 }
 
+// groupsStatus returns the cached scaling status of a set of groups which are
+// scaled together. The count of the set is the lowest running count of its
+// groups, as the set is only scaled as far as its smallest group. The set is
+// ready only when all groups are ready and the last event is the most recent
+// event of any group.
+func (jsh *jobScaleStatusHandler) groupsStatus(groups []string) (*sdk.TargetStatus, error) {
+	if len(groups) == 1 {
+		return jsh.status(groups[0])
+	}
+
+	var resp *sdk.TargetStatus
+	var lastEvent uint64
+
+	// The healthy count of the set is the lowest healthy count of its groups,
+	// and is only reported if all groups report it.
+	healthy := int64(-1)
+	healthyReported := true
+
+	for _, group := range groups {
+		status, err := jsh.status(group)
+		if err != nil || status == nil {
+			return status, err
+		}
+
+		if e, err := strconv.ParseUint(status.Meta[sdk.TargetStatusMetaKeyLastEvent], 10, 64); err == nil && e > lastEvent {
+			lastEvent = e
+		}
+
+		if h, err := strconv.ParseInt(status.Meta[sdk.TargetStatusMetaKeyHealthyCount], 10, 64); err != nil {
+			healthyReported = false
+		} else if healthy < 0 || h < healthy {
+			healthy = h
+		}
+
+		if resp == nil {
+			resp = status
+			continue
+		}
+		resp.Ready = resp.Ready && status.Ready
+		if status.Count < resp.Count {
+			resp.Count = status.Count
+		}
+	}
+
+	delete(resp.Meta, sdk.TargetStatusMetaKeyLastEvent)
+	if lastEvent > 0 {
+		resp.Meta[sdk.TargetStatusMetaKeyLastEvent] = strconv.FormatUint(lastEvent, 10)
+	}
+
+	delete(resp.Meta, sdk.TargetStatusMetaKeyHealthyCount)
+	if healthyReported {
+		resp.Meta[sdk.TargetStatusMetaKeyHealthyCount] = strconv.FormatInt(healthy, 10)
+	}
+	return resp, nil
+}
+
+// deploymentActive returns whether a deployment of the job is currently in
+// progress. The latest deployment is only read from the API while it is in
+// progress or once the job has been modified since it was last read.
+func (jsh *jobScaleStatusHandler) deploymentActive() (bool, error) {
+	if jsh.scaleStatus == nil {
+		return false, nil
+	}
+
+	if jsh.deploymentJobModifyIndex == jsh.scaleStatus.JobModifyIndex && !isDeploymentActive(jsh.deployment) {
+		return false, nil
+	}
+
+	deployment, _, err := jsh.client.Jobs().LatestDeployment(jsh.jobID, &api.QueryOptions{Namespace: jsh.namespace})
+	if err != nil {
+		return false, fmt.Errorf("failed to read latest deployment: %v", err)
+	}
+
+	jsh.deployment = deployment
+	jsh.deploymentJobModifyIndex = jsh.scaleStatus.JobModifyIndex
+	return isDeploymentActive(deployment), nil
+}
+
+// isDeploymentActive returns whether the deployment has not yet reached a
+// terminal status.
+func isDeploymentActive(d *api.Deployment) bool {
+	if d == nil {
+		return false
+	}
+
+	switch d.Status {
+	case deploymentStatusSuccessful, deploymentStatusFailed, deploymentStatusCancelled:
+		return false
+	default:
+		return true
+	}
+}
+
 // start runs the blocking query loop that processes changes from the API and
 // reflects the status internally.
 func (jsh *jobScaleStatusHandler) start() {
@@ -99,6 +99,27 @@ func Test_jobStateHandler_status(t *testing.T) {
 			expectedError: nil,
 			name:          "job group found within scale status task groups and job is not running",
 		},
+		{
+			inputJSH: &jobScaleStatusHandler{
+				jobID: "cant-think-of-a-funny-name",
+				scaleStatus: &api.JobScaleStatusResponse{
+					TaskGroups: map[string]api.TaskGroupScaleStatus{
+						"this-does-exist": {Running: 7, Placed: 7, Healthy: 5},
+					},
+				},
+			},
+			inputGroup: "this-does-exist",
+			expectedReturn: &sdk.TargetStatus{
+				Ready: true,
+				Count: 7,
+				Meta: map[string]string{
+					"nomad_autoscaler.target.nomad.cant-think-of-a-funny-name.stopped": "false",
+					"nomad_autoscaler.healthy_count":                                   "5",
+				},
+			},
+			expectedError: nil,
+			name:          "job group within a deployment reports its healthy count",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -110,6 +131,82 @@ func Test_jobStateHandler_status(t *testing.T) {
 	}
 }
 
+func Test_jobStateHandler_groupsStatus(t *testing.T) {
+	jsh := &jobScaleStatusHandler{
+		jobID: "example",
+		scaleStatus: &api.JobScaleStatusResponse{
+			TaskGroups: map[string]api.TaskGroupScaleStatus{
+				"web":   {Running: 5, Placed: 5, Healthy: 4, Events: []api.ScalingEvent{{Time: 20}}},
+				"api":   {Running: 3, Placed: 3, Healthy: 2, Events: []api.ScalingEvent{{Time: 30}}},
+				"cache": {Running: 4},
+			},
+		},
+	}
+
+	testCases := []struct {
+		inputGroups    []string
+		expectedReturn *sdk.TargetStatus
+		expectedError  error
+		name           string
+	}{
+		{
+			inputGroups: []string{"web"},
+			expectedReturn: &sdk.TargetStatus{
+				Ready: true,
+				Count: 5,
+				Meta: map[string]string{
+					"nomad_autoscaler.target.nomad.example.stopped": "false",
+					"nomad_autoscaler.last_event":                   "20",
+					"nomad_autoscaler.healthy_count":                "4",
+				},
+			},
+			expectedError: nil,
+			name:          "single group",
+		},
+		{
+			inputGroups: []string{"web", "api"},
+			expectedReturn: &sdk.TargetStatus{
+				Ready: true,
+				Count: 3,
+				Meta: map[string]string{
+					"nomad_autoscaler.target.nomad.example.stopped": "false",
+					"nomad_autoscaler.last_event":                   "30",
+					"nomad_autoscaler.healthy_count":                "2",
+				},
+			},
+			expectedError: nil,
+			name:          "lowest healthy count of the groups",
+		},
+		{
+			inputGroups: []string{"web", "api", "cache"},
+			expectedReturn: &sdk.TargetStatus{
+				Ready: true,
+				Count: 3,
+				Meta: map[string]string{
+					"nomad_autoscaler.target.nomad.example.stopped": "false",
+					"nomad_autoscaler.last_event":                   "30",
+				},
+			},
+			expectedError: nil,
+			name:          "lowest count and most recent event of the groups",
+		},
+		{
+			inputGroups:    []string{"web", "this-doesnt-exist"},
+			expectedReturn: nil,
+			expectedError:  fmt.Errorf("task group \"this-doesnt-exist\" not found"),
+			name:           "group not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualReturn, actualErr := jsh.groupsStatus(tc.inputGroups)
+			assert.Equal(t, tc.expectedReturn, actualReturn, tc.name)
+			assert.Equal(t, tc.expectedError, actualErr, tc.name)
+		})
+	}
+}
+
 func Test_jobStateHandler_updateStatusState(t *testing.T) {
 	jsh := &jobScaleStatusHandler{}
 
@@ -149,3 +246,90 @@ func Test_jobStateHandler_stop(t *testing.T) {
 	assert.Nil(t, jsh.scaleStatusError)
 	assert.Greater(t, jsh.lastUpdated, int64(0))
 }
+
+func Test_jobStateHandler_deploymentActive(t *testing.T) {
+	testCases := []struct {
+		inputJSH       *jobScaleStatusHandler
+		expectedActive bool
+		name           string
+	}{
+		{
+			inputJSH:       &jobScaleStatusHandler{},
+			expectedActive: false,
+			name:           "job no longer running on cluster",
+		},
+		{
+			inputJSH: &jobScaleStatusHandler{
+				scaleStatus:              &api.JobScaleStatusResponse{JobModifyIndex: 10},
+				deploymentJobModifyIndex: 10,
+			},
+			expectedActive: false,
+			name:           "job without deployment unchanged",
+		},
+		{
+			inputJSH: &jobScaleStatusHandler{
+				scaleStatus:              &api.JobScaleStatusResponse{JobModifyIndex: 10},
+				deployment:               &api.Deployment{Status: deploymentStatusSuccessful},
+				deploymentJobModifyIndex: 10,
+			},
+			expectedActive: false,
+			name:           "finished deployment with job unchanged",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			// The handler has no client, so these cases must not query the
+			// API.
+			actual, err := tc.inputJSH.deploymentActive()
+			assert.Nil(t, err, tc.name)
+			assert.Equal(t, tc.expectedActive, actual, tc.name)
+		})
+	}
+}
+
+func Test_isDeploymentActive(t *testing.T) {
+	testCases := []struct {
+		inputDeployment *api.Deployment
+		expectedActive  bool
+		name            string
+	}{
+		{
+			inputDeployment: nil,
+			expectedActive:  false,
+			name:            "no deployment",
+		},
+		{
+			inputDeployment: &api.Deployment{Status: "running"},
+			expectedActive:  true,
+			name:            "running",
+		},
+		{
+			inputDeployment: &api.Deployment{Status: "paused"},
+			expectedActive:  true,
+			name:            "paused",
+		},
+		{
+			inputDeployment: &api.Deployment{Status: deploymentStatusSuccessful},
+			expectedActive:  false,
+			name:            "successful",
+		},
+		{
+			inputDeployment: &api.Deployment{Status: deploymentStatusFailed},
+			expectedActive:  false,
+			name:            "failed",
+		},
+		{
+			inputDeployment: &api.Deployment{Status: deploymentStatusCancelled},
+			expectedActive:  false,
+			name:            "cancelled",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedActive, isDeploymentActive(tc.inputDeployment), tc.name)
+		})
+	}
+}
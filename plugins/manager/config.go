@@ -0,0 +1,90 @@
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// fileReferencePrefix prefixes plugin config values which reference a file,
+// such as file:///run/secrets/token, whose content is used as the value.
+const fileReferencePrefix = "file://"
+
+// redactedConfigValue replaces the values of redacted plugin config.
+const redactedConfigValue = "<redacted>"
+
+// secretConfigKeyParts are the parts of plugin config keys which indicate
+// the value is a secret, such as token or secret_access_key.
+var secretConfigKeyParts = []string{
+	"auth", "credential", "key", "passphrase", "password", "passwd", "private", "secret", "token",
+}
+
+// resolveConfig returns a copy of the plugin config with the file references
+// replaced by the content of the referenced files, with trailing newlines
+// trimmed. This allows secrets to be mounted as files rather than written
+// into the agent config. The config itself is not modified, so the secrets
+// are only held by the plugin.
+func resolveConfig(cfg map[string]string) (map[string]string, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	// Resolve the keys in order so the first error reported is stable.
+	keys := make([]string, 0, len(cfg))
+	for k := range cfg {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]string, len(cfg))
+	for _, k := range keys {
+		v := cfg[k]
+
+		if strings.HasPrefix(v, fileReferencePrefix) {
+			path := strings.TrimPrefix(v, fileReferencePrefix)
+			if path == "" {
+				return nil, fmt.Errorf("config %q references an empty file path", k)
+			}
+
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("config %q failed to read referenced file: %v", k, err)
+			}
+			v = strings.TrimRight(string(b), "\r\n")
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// redactConfig returns a copy of the resolved plugin config with the values
+// which may be secrets replaced, so it can be reported by the agent. Values
+// are redacted when the key name indicates a secret, or when the raw config
+// value references a file, as these are typically mounted secrets.
+func redactConfig(raw, resolved map[string]string) map[string]string {
+	if resolved == nil {
+		return nil
+	}
+
+	out := make(map[string]string, len(resolved))
+	for k, v := range resolved {
+		if isSecretConfigKey(k) || strings.HasPrefix(raw[k], fileReferencePrefix) {
+			v = redactedConfigValue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// isSecretConfigKey returns whether the plugin config key name indicates the
+// value is a secret.
+func isSecretConfigKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range secretConfigKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
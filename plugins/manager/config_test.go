@@ -0,0 +1,137 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_resolveConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugin-config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	secret := filepath.Join(dir, "secret")
+	assert.Nil(t, ioutil.WriteFile(secret, []byte("s3cr3t\n\n"), 0600))
+
+	multiline := filepath.Join(dir, "multiline")
+	assert.Nil(t, ioutil.WriteFile(multiline, []byte("line1\nline2\r\n"), 0600))
+
+	testCases := []struct {
+		inputConfig    map[string]string
+		expectedOutput map[string]string
+		expectedError  string
+		name           string
+	}{
+		{
+			inputConfig:    nil,
+			expectedOutput: nil,
+			name:           "nil config",
+		},
+		{
+			inputConfig:    map[string]string{"address": "http://prometheus:9090"},
+			expectedOutput: map[string]string{"address": "http://prometheus:9090"},
+			name:           "no file references",
+		},
+		{
+			inputConfig:    map[string]string{"address": "http://prometheus:9090", "token": "file://" + secret},
+			expectedOutput: map[string]string{"address": "http://prometheus:9090", "token": "s3cr3t"},
+			name:           "file reference",
+		},
+		{
+			inputConfig:    map[string]string{"cert": "file://" + multiline},
+			expectedOutput: map[string]string{"cert": "line1\nline2"},
+			name:           "only trailing newlines trimmed",
+		},
+		{
+			inputConfig:   map[string]string{"token": "file://" + filepath.Join(dir, "missing")},
+			expectedError: `config "token" failed to read referenced file`,
+			name:          "missing file",
+		},
+		{
+			inputConfig:   map[string]string{"token": "file://"},
+			expectedError: `config "token" references an empty file path`,
+			name:          "empty file path",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, err := resolveConfig(tc.inputConfig)
+			if tc.expectedError != "" {
+				assert.Error(t, err, tc.name)
+				assert.Contains(t, err.Error(), tc.expectedError, tc.name)
+				return
+			}
+			assert.Nil(t, err, tc.name)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+		})
+	}
+
+	// The input config is left untouched, so the secret is not stored.
+	cfg := map[string]string{"token": "file://" + secret}
+	_, err = resolveConfig(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, "file://"+secret, cfg["token"])
+}
+
+func Test_redactConfig(t *testing.T) {
+	testCases := []struct {
+		inputRaw       map[string]string
+		inputResolved  map[string]string
+		expectedOutput map[string]string
+		name           string
+	}{
+		{
+			inputRaw:       nil,
+			inputResolved:  nil,
+			expectedOutput: nil,
+			name:           "nil config",
+		},
+		{
+			inputRaw:       map[string]string{"address": "http://prometheus:9090"},
+			inputResolved:  map[string]string{"address": "http://prometheus:9090"},
+			expectedOutput: map[string]string{"address": "http://prometheus:9090"},
+			name:           "no secrets",
+		},
+		{
+			inputRaw: map[string]string{
+				"aws_secret_access_key": "s3cr3t",
+				"dd_api_key":            "abc",
+				"nomad_token":           "xyz",
+				"Password":              "hunter2",
+				"region":                "us-east-1",
+			},
+			inputResolved: map[string]string{
+				"aws_secret_access_key": "s3cr3t",
+				"dd_api_key":            "abc",
+				"nomad_token":           "xyz",
+				"Password":              "hunter2",
+				"region":                "us-east-1",
+			},
+			expectedOutput: map[string]string{
+				"aws_secret_access_key": redactedConfigValue,
+				"dd_api_key":            redactedConfigValue,
+				"nomad_token":           redactedConfigValue,
+				"Password":              redactedConfigValue,
+				"region":                "us-east-1",
+			},
+			name: "secret key names",
+		},
+		{
+			inputRaw:       map[string]string{"address": "file:///run/secrets/address"},
+			inputResolved:  map[string]string{"address": "http://prometheus:9090"},
+			expectedOutput: map[string]string{"address": redactedConfigValue},
+			name:           "file reference",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, redactConfig(tc.inputRaw, tc.inputResolved), tc.name)
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package manager
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	plugin "github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
 	"github.com/hashicorp/nomad-autoscaler/plugins"
 )
@@ -13,10 +19,24 @@ import (
 func (pm *PluginManager) loadExternalPlugin(cfg *config.Plugin, pluginType string) {
 
 	info := &pluginInfo{
-		args:    cfg.Args,
-		config:  cfg.Config,
-		driver:  cfg.Driver,
-		exePath: filepath.Join(pm.pluginDir, cleanPluginExecutable(cfg.Driver)),
+		args:        cfg.Args,
+		config:      cfg.Config,
+		driver:      cfg.Driver,
+		exePath:     filepath.Join(pm.pluginDir, cleanPluginExecutable(cfg.Driver)),
+		env:         pluginEnv(cfg.Env),
+		checksum:    cfg.SHA256,
+		maxLifetime: cfg.MaxLifetime,
+	}
+	info.setLaunchOptions(cfg)
+
+	// The agent environment is passed to the plugin after the configured
+	// variables, so it takes precedence. Warn operators as the configured
+	// value is silently ignored otherwise.
+	for k := range cfg.Env {
+		if _, ok := os.LookupEnv(k); ok {
+			pm.logger.Warn("plugin env variable is overridden by the agent environment",
+				"plugin_name", cfg.Name, "variable", k)
+		}
 	}
 
 	// Add the plugin.
@@ -26,6 +46,27 @@ func (pm *PluginManager) loadExternalPlugin(cfg *config.Plugin, pluginType strin
 
 }
 
+// pluginEnv converts the configured plugin environment variables into the
+// KEY=value form used by exec.Cmd, sorted by name so the plugin command is
+// deterministic.
+func pluginEnv(env map[string]string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(env))
+	for k := range env {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	out := make([]string, 0, len(env))
+	for _, k := range names {
+		out = append(out, k+"="+env[k])
+	}
+	return out
+}
+
 // cleanPluginExecutable is a helper function to remove commonly-found binary
 // extensions which are not needed.
 func cleanPluginExecutable(name string) string {
@@ -36,3 +77,18 @@ func cleanPluginExecutable(name string) string {
 		return name
 	}
 }
+
+// secureConfig returns the go-plugin SecureConfig which verifies the plugin
+// binary against the hex encoded SHA-256 checksum. A nil config is returned
+// if no checksum is set.
+func secureConfig(checksum string) (*plugin.SecureConfig, error) {
+	if checksum == "" {
+		return nil, nil
+	}
+
+	sum, err := hex.DecodeString(checksum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode sha256 checksum: %v", err)
+	}
+	return &plugin.SecureConfig{Checksum: sum, Hash: sha256.New()}, nil
+}
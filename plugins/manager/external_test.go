@@ -1,6 +1,11 @@
 package manager
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,3 +24,95 @@ func Test_cleanPluginExecutable(t *testing.T) {
 		assert.Equal(t, tc.expectedOutput, cleanPluginExecutable(tc.inputName))
 	}
 }
+
+func Test_pluginEnv(t *testing.T) {
+	testCases := []struct {
+		inputEnv       map[string]string
+		expectedOutput []string
+		name           string
+	}{
+		{
+			inputEnv:       nil,
+			expectedOutput: nil,
+			name:           "no env",
+		},
+		{
+			inputEnv:       map[string]string{"B": "2", "A0": "1", "A": "0", "EMPTY": ""},
+			expectedOutput: []string{"A=0", "A0=1", "B=2", "EMPTY="},
+			name:           "sorted by name",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, pluginEnv(tc.inputEnv), tc.name)
+		})
+	}
+}
+
+func Test_secureConfig(t *testing.T) {
+	f, err := ioutil.TempFile("", "plugin")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("plugin binary")
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+
+	sum := sha256.Sum256([]byte("plugin binary"))
+	valid := hex.EncodeToString(sum[:])
+
+	testCases := []struct {
+		name             string
+		checksum         string
+		expectedNil      bool
+		expectedError    bool
+		expectedVerified bool
+	}{
+		{
+			name:        "no checksum",
+			checksum:    "",
+			expectedNil: true,
+		},
+		{
+			name:             "matching checksum",
+			checksum:         valid,
+			expectedVerified: true,
+		},
+		{
+			name:             "matching upper case checksum",
+			checksum:         strings.ToUpper(valid),
+			expectedVerified: true,
+		},
+		{
+			name:             "mismatched checksum",
+			checksum:         "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			expectedVerified: false,
+		},
+		{
+			name:          "invalid checksum",
+			checksum:      "not-hex",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := secureConfig(tc.checksum)
+			if tc.expectedError {
+				assert.Error(t, err, tc.name)
+				return
+			}
+			assert.Nil(t, err, tc.name)
+
+			if tc.expectedNil {
+				assert.Nil(t, cfg, tc.name)
+				return
+			}
+
+			verified, err := cfg.Check(f.Name())
+			assert.Nil(t, err, tc.name)
+			assert.Equal(t, tc.expectedVerified, verified, tc.name)
+		})
+	}
+}
@@ -26,6 +26,9 @@ func (p *internalPluginInstance) Plugin() interface{} { return p.instance }
 type externalPluginInstance struct {
 	client   *plugin.Client
 	instance interface{}
+
+	// output retains the most recent output of the plugin process.
+	output *outputBuffer
 }
 
 func (p *externalPluginInstance) Kill()               { p.client.Kill() }
@@ -9,6 +9,7 @@ import (
 	datadog "github.com/hashicorp/nomad-autoscaler/plugins/builtin/apm/datadog/plugin"
 	nomadAPM "github.com/hashicorp/nomad-autoscaler/plugins/builtin/apm/nomad/plugin"
 	prometheus "github.com/hashicorp/nomad-autoscaler/plugins/builtin/apm/prometheus/plugin"
+	push "github.com/hashicorp/nomad-autoscaler/plugins/builtin/apm/push/plugin"
 	targetValue "github.com/hashicorp/nomad-autoscaler/plugins/builtin/strategy/target-value/plugin"
 	awsASG "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/aws-asg/plugin"
 	azureVMSS "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/azure-vmss/plugin"
@@ -20,6 +21,21 @@ import (
 func (pm *PluginManager) loadInternalPlugin(cfg *config.Plugin, pluginType string) {
 
 	info := &pluginInfo{config: cfg.Config}
+	info.setLaunchOptions(cfg)
+
+	// Built-in plugins are part of the agent binary, so there is no plugin
+	// binary to verify.
+	if cfg.SHA256 != "" {
+		pm.logger.Warn("sha256 is only verified for external plugins, ignoring it for built-in plugin",
+			"plugin", cfg.Name, "driver", cfg.Driver)
+	}
+
+	// Built-in plugins run within the agent process, so there is no process
+	// to recycle.
+	if cfg.MaxLifetime > 0 {
+		pm.logger.Warn("max_lifetime only applies to external plugins, ignoring it for built-in plugin",
+			"plugin", cfg.Name, "driver", cfg.Driver)
+	}
 
 	switch cfg.Driver {
 	case plugins.InternalAPMNomad:
@@ -43,6 +59,9 @@ func (pm *PluginManager) loadInternalPlugin(cfg *config.Plugin, pluginType strin
 	case plugins.InternalAPMDatadog:
 		info.factory = datadog.PluginConfig.Factory
 		info.driver = "datadog"
+	case plugins.InternalAPMPush:
+		info.factory = push.Factory(pm.pushStore)
+		info.driver = "push"
 	default:
 		pm.logger.Error("unsupported internal plugin", "plugin", cfg.Driver)
 		return
@@ -85,7 +104,8 @@ func (pm *PluginManager) useInternal(plugin string) bool {
 		plugins.InternalStrategyTargetValue,
 		plugins.InternalTargetAWSASG,
 		plugins.InternalTargetAzureVMSS,
-		plugins.InternalAPMDatadog:
+		plugins.InternalAPMDatadog,
+		plugins.InternalAPMPush:
 		return true
 	default:
 		return false
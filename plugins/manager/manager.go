@@ -3,6 +3,7 @@ package manager
 import (
 	"fmt"
 	"os/exec"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,6 +14,21 @@ import (
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
 	"github.com/hashicorp/nomad-autoscaler/plugins"
 	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	push "github.com/hashicorp/nomad-autoscaler/plugins/builtin/apm/push/plugin"
+)
+
+const (
+	// defaultSetConfigRetries is the default number of times setting the
+	// config of a plugin is retried when it fails.
+	defaultSetConfigRetries = 2
+
+	// defaultSetConfigRetryBackoff is the default time to wait before the
+	// first retry of setting the config of a plugin.
+	defaultSetConfigRetryBackoff = 1 * time.Second
+
+	// maxSetConfigRetryBackoff caps the exponential backoff between retries
+	// of setting the config of a plugin.
+	maxSetConfigRetryBackoff = 30 * time.Second
 )
 
 // PluginManager is the brains of the plugin operation and should be used to
@@ -31,10 +47,21 @@ type PluginManager struct {
 	pluginInstancesLock sync.RWMutex
 	pluginInstances     map[plugins.PluginID]PluginInstance
 
+	// unavailable holds the error of the optional plugins which failed to
+	// launch, and is protected by pluginInstancesLock.
+	unavailable map[plugins.PluginID]error
+
+	// launched holds the driver and redacted config of the plugins as last
+	// launched, and is protected by pluginInstancesLock.
+	launched map[plugins.PluginID]launchedPlugin
+
 	// plugin contains all the information needed to launch and dispense the
 	// Nomad Autoscaler plugins.
 	pluginsLock sync.RWMutex
 	plugins     map[plugins.PluginID]*pluginInfo
+
+	// pushStore holds the metric values served by the push APM plugins.
+	pushStore *push.Store
 }
 
 // pluginInfo contains all the required information to launch an Autoscaler
@@ -44,15 +71,57 @@ type pluginInfo struct {
 	baseInfo *base.PluginInfo
 	config   map[string]string
 
-	// args and exePath are required to execute the external plugin command.
+	// args and exePath are required to execute the external plugin command,
+	// while env holds the optional additional environment variables.
 	driver  string
 	args    []string
+	env     []string
 	exePath string
 
+	// checksum is the optional hex encoded SHA-256 checksum the external
+	// plugin binary must match for it to be executed.
+	checksum string
+
+	// maxLifetime is the optional duration after which the process of the
+	// external plugin is recycled.
+	maxLifetime time.Duration
+
+	// setConfigRetries and setConfigRetryBackoff control the retries of
+	// setting the plugin config when it fails.
+	setConfigRetries      int
+	setConfigRetryBackoff time.Duration
+
+	// optional indicates the agent starts without the plugin if it fails to
+	// launch.
+	optional bool
+
 	// factory is only populated when the plugin is internal.
 	factory plugins.PluginFactory
 }
 
+// launchedPlugin details the driver and the redacted config with which a
+// plugin was launched, for reporting by PluginStates.
+type launchedPlugin struct {
+	driver string
+	config map[string]string
+}
+
+// setLaunchOptions sets the options which control the launch of both
+// internal and external plugins on the plugin info.
+func (p *pluginInfo) setLaunchOptions(cfg *config.Plugin) {
+	p.setConfigRetries = defaultSetConfigRetries
+	if cfg.SetConfigRetries != nil {
+		p.setConfigRetries = *cfg.SetConfigRetries
+	}
+
+	p.setConfigRetryBackoff = defaultSetConfigRetryBackoff
+	if cfg.SetConfigRetryBackoff > 0 {
+		p.setConfigRetryBackoff = cfg.SetConfigRetryBackoff
+	}
+
+	p.optional = cfg.Optional
+}
+
 // NewPluginManager sets up a new PluginManager for use.
 func NewPluginManager(log hclog.Logger, dir string, cfg map[string][]*config.Plugin) *PluginManager {
 	return &PluginManager{
@@ -60,10 +129,20 @@ func NewPluginManager(log hclog.Logger, dir string, cfg map[string][]*config.Plu
 		logger:          log.Named("plugin_manager"),
 		pluginDir:       dir,
 		pluginInstances: make(map[plugins.PluginID]PluginInstance),
+		unavailable:     make(map[plugins.PluginID]error),
+		launched:        make(map[plugins.PluginID]launchedPlugin),
 		plugins:         make(map[plugins.PluginID]*pluginInfo),
+		pushStore:       push.NewStore(push.DefaultMaxNames),
 	}
 }
 
+// SetPushStore configures the store holding the metric values served by the
+// push APM plugins, so they serve the values pushed to the agent. It must be
+// called before the plugins are loaded.
+func (pm *PluginManager) SetPushStore(s *push.Store) {
+	pm.pushStore = s
+}
+
 // Load is responsible for registering and executing the plugins configured for
 // use by the Autoscaler agent.
 func (pm *PluginManager) Load() error {
@@ -112,17 +191,110 @@ func (pm *PluginManager) Dispense(name, pluginType string) (PluginInstance, erro
 	// TODO(jrasell) if we do not find the instance, we should probably try and
 	//  dispense the plugin. We should also check the plugin instance has not
 	//  exited.
-	inst, ok := pm.pluginInstances[plugins.PluginID{Name: name, PluginType: pluginType}]
+	id := plugins.PluginID{Name: name, PluginType: pluginType}
+	inst, ok := pm.pluginInstances[id]
 	if !ok {
+		if err, ok := pm.unavailable[id]; ok {
+			return nil, fmt.Errorf("failed to dispense plugin: %q of type %q is unavailable: %v", name, pluginType, err)
+		}
 		return nil, fmt.Errorf("failed to dispense plugin: %q of type %q is not stored", name, pluginType)
 	}
 	return inst, nil
 }
 
+// PluginState is a point-in-time snapshot of the state of a plugin instance.
+type PluginState struct {
+	Name     string
+	Type     string
+	Driver   string
+	External bool
+
+	// Healthy indicates the plugin is available and, if external, its process
+	// has not exited.
+	Healthy bool
+
+	// Config is the config set on the plugin, with file references resolved
+	// and the values which may be secrets redacted.
+	Config map[string]string `json:",omitempty"`
+
+	// Exited indicates whether the process of an external plugin has exited.
+	Exited bool
+
+	// Output contains the most recent lines of output written by an external
+	// plugin, from oldest to newest.
+	Output []string
+
+	// Unavailable indicates an optional plugin failed to launch, in which
+	// case Error describes the failure.
+	Unavailable bool
+	Error       string `json:",omitempty"`
+
+	// Restarts counts the restarts of the plugin process by reason, and
+	// LastRestart is when it was last restarted. Frequent restarts indicate a
+	// misbehaving plugin.
+	Restarts    map[string]int64 `json:",omitempty"`
+	LastRestart *time.Time       `json:",omitempty"`
+}
+
+// PluginStates returns a snapshot of the state of all the plugin instances.
+func (pm *PluginManager) PluginStates() []PluginState {
+	pm.pluginInstancesLock.RLock()
+	defer pm.pluginInstancesLock.RUnlock()
+
+	states := make([]PluginState, 0, len(pm.pluginInstances)+len(pm.unavailable))
+	for id, inst := range pm.pluginInstances {
+		state := PluginState{
+			Name:   id.Name,
+			Type:   id.PluginType,
+			Driver: pm.launched[id].driver,
+			Config: pm.launched[id].config,
+		}
+
+		// Report the current process of plugins which are recycled, along
+		// with the restarts of the process.
+		if r, ok := inst.(*recyclingPluginInstance); ok {
+			restarts, last := r.restartStats()
+			if len(restarts) > 0 {
+				state.Restarts = restarts
+				state.LastRestart = &last
+			}
+			inst = r.current()
+		}
+
+		if ext, ok := inst.(*externalPluginInstance); ok {
+			state.External = true
+			state.Exited = ext.client.Exited()
+			state.Output = ext.output.Lines()
+		}
+		state.Healthy = !state.Exited
+		states = append(states, state)
+	}
+
+	for id, err := range pm.unavailable {
+		states = append(states, PluginState{
+			Name:        id.Name,
+			Type:        id.PluginType,
+			Driver:      pm.launched[id].driver,
+			Config:      pm.launched[id].config,
+			Unavailable: true,
+			Error:       err.Error(),
+		})
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Type != states[j].Type {
+			return states[i].Type < states[j].Type
+		}
+		return states[i].Name < states[j].Name
+	})
+	return states
+}
+
 // dispensePlugins launches all configured plugins. It is responsible for
 // executing external binaries as well as setting the config on all plugins so
 // they are in a ready state. Any errors from this process will result in the
-// agent failing on startup.
+// agent failing on startup, unless the plugin is optional in which case it is
+// marked as unavailable.
 func (pm *PluginManager) dispensePlugins() error {
 
 	// This function only gets called once currently; but it does perform all
@@ -130,6 +302,8 @@ func (pm *PluginManager) dispensePlugins() error {
 	// future protection blat out our instances map.
 	pm.pluginInstancesLock.Lock()
 	pm.pluginInstances = make(map[plugins.PluginID]PluginInstance)
+	pm.unavailable = make(map[plugins.PluginID]error)
+	pm.launched = make(map[plugins.PluginID]launchedPlugin)
 	pm.pluginInstancesLock.Unlock()
 
 	var mErr multierror.Error
@@ -139,21 +313,20 @@ func (pm *PluginManager) dispensePlugins() error {
 
 	for pID, pInfo := range pm.plugins {
 
-		var (
-			inst PluginInstance
-			info *base.PluginInfo
-			err  error
-		)
-		if pInfo.factory != nil {
-			inst, info, err = pm.launchInternalPlugin(pID, pInfo)
-		} else {
-			inst, info, err = pm.launchExternalPlugin(pID, pInfo)
-		}
-
 		// If we got an error dispensing the plugin, add this to the muilterror
 		// and continue the loop.
+		inst, info, err := pm.launchPlugin(pID, pInfo)
 		if err != nil {
-			_ = multierror.Append(&mErr, fmt.Errorf("failed to dispense plugin %s: %v", pID.Name, err))
+			if pInfo.optional {
+				pm.logger.Error("failed to launch optional plugin, continuing without it",
+					"plugin_name", pID.Name, "error", err)
+
+				pm.pluginInstancesLock.Lock()
+				pm.unavailable[pID] = err
+				pm.pluginInstancesLock.Unlock()
+				continue
+			}
+			_ = multierror.Append(&mErr, err)
 			continue
 		}
 
@@ -161,12 +334,15 @@ func (pm *PluginManager) dispensePlugins() error {
 		// from the plugin itself.
 		pm.plugins[pID].baseInfo = info
 
-		// Perform the SetConfig on the plugin to ensure its state is as the
-		// operator desires.
-		if err := inst.Plugin().(base.Plugin).SetConfig(pInfo.config); err != nil {
-			inst.Kill()
-			_ = multierror.Append(&mErr, fmt.Errorf("failed to set config on plugin %s: %v", pID.Name, err))
-			continue
+		// Recycle the external plugin process once it reaches its max
+		// lifetime, if configured. The replacement is launched in the same
+		// way, so it reads the latest content of referenced config files.
+		if pInfo.factory == nil && pInfo.maxLifetime > 0 {
+			id, launchInfo := pID, pInfo
+			inst = newRecyclingPluginInstance(id, pm.logger, inst, pInfo.maxLifetime, func() (PluginInstance, error) {
+				inst, _, err := pm.launchPlugin(id, launchInfo)
+				return inst, err
+			})
 		}
 
 		// Store our plugin instance.
@@ -182,6 +358,79 @@ func (pm *PluginManager) dispensePlugins() error {
 	return mErr.ErrorOrNil()
 }
 
+// launchPlugin launches the plugin and sets its config, so it is ready for
+// use.
+func (pm *PluginManager) launchPlugin(id plugins.PluginID, pInfo *pluginInfo) (PluginInstance, *base.PluginInfo, error) {
+
+	// Read the files referenced by the config before launching the plugin,
+	// so a missing secret does not leave a plugin running.
+	cfg, err := resolveConfig(pInfo.config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve config of plugin %s: %v", id.Name, err)
+	}
+
+	// Record the config being set, so it can be reported when debugging the
+	// plugin even if setting it fails.
+	pm.pluginInstancesLock.Lock()
+	pm.launched[id] = launchedPlugin{driver: pInfo.driver, config: redactConfig(pInfo.config, cfg)}
+	pm.pluginInstancesLock.Unlock()
+
+	var (
+		inst PluginInstance
+		info *base.PluginInfo
+	)
+	if pInfo.factory != nil {
+		inst, info, err = pm.launchInternalPlugin(id, pInfo)
+	} else {
+		inst, info, err = pm.launchExternalPlugin(id, pInfo)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dispense plugin %s: %v", id.Name, err)
+	}
+
+	// Perform the SetConfig on the plugin to ensure its state is as the
+	// operator desires.
+	if err := pm.setConfig(id, pInfo, inst.Plugin().(base.Plugin), cfg); err != nil {
+		inst.Kill()
+		return nil, nil, fmt.Errorf("failed to set config on plugin %s: %v", id.Name, err)
+	}
+	return inst, info, nil
+}
+
+// setConfig sets the config on the plugin, retrying with an exponential
+// backoff when it fails, as a plugin which has just started may not be ready
+// to accept it yet. The error of the last attempt is returned once the
+// retries are exhausted.
+func (pm *PluginManager) setConfig(id plugins.PluginID, pInfo *pluginInfo, p base.Plugin, cfg map[string]string) error {
+	backoff := pInfo.setConfigRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := p.SetConfig(cfg)
+		if err == nil {
+			if attempt > 0 {
+				pm.logger.Info("set plugin config after retrying",
+					"plugin_name", id.Name, "attempts", attempt+1)
+			}
+			return nil
+		}
+
+		if attempt >= pInfo.setConfigRetries {
+			if attempt > 0 {
+				return fmt.Errorf("giving up after %d attempts: %v", attempt+1, err)
+			}
+			return err
+		}
+
+		pm.logger.Warn("failed to set plugin config, retrying",
+			"plugin_name", id.Name, "attempt", attempt+1, "backoff", backoff, "error", err)
+
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxSetConfigRetryBackoff {
+			backoff = maxSetConfigRetryBackoff
+		}
+	}
+}
+
 // launchInternalPlugin is used to dispense internal plugins.
 func (pm *PluginManager) launchInternalPlugin(id plugins.PluginID, info *pluginInfo) (PluginInstance, *base.PluginInfo, error) {
 
@@ -202,11 +451,35 @@ func (pm *PluginManager) launchExternalPlugin(id plugins.PluginID, info *pluginI
 	// Create a new client for the external plugin. This includes items such as
 	// the command to execute and also the logger to use. The loggers name is
 	// reset to avoid confusion that the log line is from within the agent.
+	//
+	// The plugin stderr is emitted through the logger by the client, and is
+	// also captured along with the synced stdout and stderr streams so the
+	// most recent output is available when troubleshooting the plugin.
+	logger := pm.logger.ResetNamed("external_plugin." + id.Name)
+
+	// The client verifies the plugin binary against the checksum as it is
+	// executed, so a tampered or replaced binary is never run.
+	secure, err := secureConfig(info.checksum)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to verify plugin %s: %v", id.Name, err)
+	}
+
+	output := newOutputBuffer(pluginOutputLines)
+
+	// The client appends the agent environment and the handshake variables
+	// to the command environment.
+	cmd := exec.Command(info.exePath, info.args...)
+	cmd.Env = info.env
+
 	client := plugin.NewClient(&plugin.ClientConfig{
 		HandshakeConfig: plugins.Handshake,
 		Plugins:         getPluginMap(id.PluginType),
-		Cmd:             exec.Command(info.exePath, info.args...),
-		Logger:          pm.logger.ResetNamed("external_plugin"),
+		Cmd:             cmd,
+		SecureConfig:    secure,
+		Logger:          logger,
+		Stderr:          output.writer(nil),
+		SyncStdout:      output.writer(logger.Named("stdout")),
+		SyncStderr:      output.writer(logger.Named("stderr")),
 	})
 
 	// Connect via RPC.
@@ -229,7 +502,7 @@ func (pm *PluginManager) launchExternalPlugin(id plugins.PluginID, info *pluginI
 		return nil, nil, err
 	}
 
-	return &externalPluginInstance{instance: raw, client: client}, pInfo, nil
+	return &externalPluginInstance{instance: raw, client: client, output: output}, pInfo, nil
 }
 
 func (pm *PluginManager) pluginLaunchCheck(id plugins.PluginID, info *pluginInfo, raw interface{}) (*base.PluginInfo, error) {
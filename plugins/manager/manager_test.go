@@ -1,10 +1,19 @@
 package manager
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/ptr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -104,3 +113,236 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestLoad_checksum(t *testing.T) {
+	b, err := ioutil.ReadFile("../test/bin/noop-strategy")
+	assert.NoError(t, err)
+	sum := sha256.Sum256(b)
+
+	cases := []struct {
+		name        string
+		checksum    string
+		expectError bool
+	}{
+		{
+			name:        "matching checksum",
+			checksum:    hex.EncodeToString(sum[:]),
+			expectError: false,
+		},
+		{
+			name:        "mismatched checksum",
+			checksum:    "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := map[string][]*config.Plugin{
+				"strategy": {{Name: "noop", Driver: "noop-strategy", SHA256: tc.checksum}},
+			}
+
+			pm := NewPluginManager(hclog.NewNullLogger(), "../test/bin", cfg)
+			err := pm.Load()
+			defer pm.KillPlugins()
+
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoad_env(t *testing.T) {
+	cfg := map[string][]*config.Plugin{
+		"strategy": {{
+			Name:   "noop",
+			Driver: "noop-strategy",
+			Env:    map[string]string{"NOOP_STRATEGY_SETTING": "value"},
+		}},
+	}
+
+	pm := NewPluginManager(hclog.NewNullLogger(), "../test/bin", cfg)
+	defer pm.KillPlugins()
+	assert.NoError(t, pm.Load())
+
+	info := pm.plugins[plugins.PluginID{Name: "noop", PluginType: "strategy"}]
+	assert.Equal(t, []string{"NOOP_STRATEGY_SETTING=value"}, info.env)
+}
+
+func TestLoad_configFileReference(t *testing.T) {
+	cfg := map[string][]*config.Plugin{
+		"strategy": {{
+			Name:   "noop",
+			Driver: "noop-strategy",
+			Config: map[string]string{"token": "file://./test-fixtures/missing-token"},
+		}},
+	}
+
+	pm := NewPluginManager(hclog.NewNullLogger(), "../test/bin", cfg)
+	defer pm.KillPlugins()
+
+	err := pm.Load()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to resolve config of plugin noop")
+
+	// The plugin is not launched if its config cannot be resolved.
+	assert.Empty(t, pm.pluginInstances)
+}
+
+func TestLoad_checksumBuiltin(t *testing.T) {
+	var buf bytes.Buffer
+	logger := hclog.New(&hclog.LoggerOptions{Output: &buf})
+
+	cfg := map[string][]*config.Plugin{
+		"strategy": {{
+			Name:   "target-value",
+			Driver: "target-value",
+			SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		}},
+	}
+
+	pm := NewPluginManager(logger, "this/doesnt/exist", cfg)
+	assert.NoError(t, pm.Load())
+	defer pm.KillPlugins()
+
+	assert.Contains(t, buf.String(), "sha256 is only verified for external plugins")
+}
+
+// flakyPlugin is a base.Plugin whose SetConfig fails the first failures
+// times it is called.
+type flakyPlugin struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyPlugin) PluginInfo() (*base.PluginInfo, error) { return nil, nil }
+
+func (f *flakyPlugin) SetConfig(_ map[string]string) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return fmt.Errorf("not ready")
+	}
+	return nil
+}
+
+func TestPluginManager_setConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputFailures int
+		inputRetries  int
+		expectedCalls int
+		expectedError string
+	}{
+		{
+			name:          "success",
+			inputFailures: 0,
+			inputRetries:  2,
+			expectedCalls: 1,
+		},
+		{
+			name:          "success after retries",
+			inputFailures: 2,
+			inputRetries:  2,
+			expectedCalls: 3,
+		},
+		{
+			name:          "retries exhausted",
+			inputFailures: 3,
+			inputRetries:  2,
+			expectedCalls: 3,
+			expectedError: "giving up after 3 attempts: not ready",
+		},
+		{
+			name:          "retries disabled",
+			inputFailures: 1,
+			inputRetries:  0,
+			expectedCalls: 1,
+			expectedError: "not ready",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pm := NewPluginManager(hclog.NewNullLogger(), "", nil)
+			p := &flakyPlugin{failures: tc.inputFailures}
+			info := &pluginInfo{setConfigRetries: tc.inputRetries, setConfigRetryBackoff: time.Millisecond}
+
+			err := pm.setConfig(plugins.PluginID{Name: "flaky", PluginType: "apm"}, info, p, nil)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError, tc.name)
+			} else {
+				assert.NoError(t, err, tc.name)
+			}
+			assert.Equal(t, tc.expectedCalls, p.calls, tc.name)
+		})
+	}
+}
+
+func TestLoad_optional(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputOptional bool
+		expectError   bool
+	}{
+		{
+			name:          "required plugin fails startup",
+			inputOptional: false,
+			expectError:   true,
+		},
+		{
+			name:          "optional plugin unavailable",
+			inputOptional: true,
+			expectError:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			// The Prometheus plugin fails to set its config without an address.
+			cfg := map[string][]*config.Plugin{
+				"apm": {{
+					Name:             "prometheus",
+					Driver:           "prometheus",
+					SetConfigRetries: ptr.IntToPtr(0),
+					Optional:         tc.inputOptional,
+				}},
+				"strategy": {{
+					Name:   "target-value",
+					Driver: "target-value",
+				}},
+			}
+
+			pm := NewPluginManager(hclog.NewNullLogger(), "../test/bin", cfg)
+			defer pm.KillPlugins()
+
+			err := pm.Load()
+			if tc.expectError {
+				assert.Error(t, err, tc.name)
+				return
+			}
+			assert.NoError(t, err, tc.name)
+
+			_, err = pm.Dispense("target-value", "strategy")
+			assert.NoError(t, err, tc.name)
+
+			_, err = pm.Dispense("prometheus", "apm")
+			assert.Error(t, err, tc.name)
+			assert.Contains(t, err.Error(), "is unavailable", tc.name)
+
+			assert.Equal(t, []PluginState{
+				{
+					Name:        "prometheus",
+					Type:        "apm",
+					Driver:      "prometheus",
+					Unavailable: true,
+					Error:       `failed to set config on plugin prometheus: "address" config value cannot be empty`,
+				},
+				{Name: "target-value", Type: "strategy", Driver: "target-value", Healthy: true},
+			}, pm.PluginStates(), tc.name)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package manager
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// pluginOutputLines is the number of output lines retained for each external
+// plugin.
+const pluginOutputLines = 100
+
+// outputBuffer retains the most recent lines of output written by an external
+// plugin process so they can be surfaced to operators.
+type outputBuffer struct {
+	size int
+
+	l     sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// newOutputBuffer returns a new outputBuffer which retains size lines.
+func newOutputBuffer(size int) *outputBuffer {
+	return &outputBuffer{
+		size:  size,
+		lines: make([]string, size),
+	}
+}
+
+// add appends a line to the buffer, overwriting the oldest line if the buffer
+// is full.
+func (o *outputBuffer) add(line string) {
+	o.l.Lock()
+	defer o.l.Unlock()
+
+	o.lines[o.next] = line
+	o.next = (o.next + 1) % o.size
+	if o.next == 0 {
+		o.full = true
+	}
+}
+
+// Lines returns a copy of the retained lines, from oldest to newest.
+func (o *outputBuffer) Lines() []string {
+	o.l.Lock()
+	defer o.l.Unlock()
+
+	if !o.full {
+		out := make([]string, o.next)
+		copy(out, o.lines[:o.next])
+		return out
+	}
+
+	out := make([]string, 0, o.size)
+	out = append(out, o.lines[o.next:]...)
+	return append(out, o.lines[:o.next]...)
+}
+
+// writer returns an io.Writer for a single output stream of the plugin.
+// Complete lines written are stored in the buffer and, if logger is not nil,
+// emitted through it.
+func (o *outputBuffer) writer(logger hclog.Logger) *outputWriter {
+	return &outputWriter{buf: o, logger: logger}
+}
+
+// outputWriter splits the data written to it into lines. Each stream requires
+// its own writer so that partial lines from different streams are not mixed.
+type outputWriter struct {
+	buf    *outputBuffer
+	logger hclog.Logger
+
+	l       sync.Mutex
+	partial []byte
+}
+
+// Write satisfies the Write function of the io.Writer interface.
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	w.partial = append(w.partial, p...)
+
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := strings.TrimRight(string(w.partial[:i]), "\r")
+		w.partial = w.partial[i+1:]
+
+		w.buf.add(line)
+		if w.logger != nil && line != "" {
+			w.logger.Info(line)
+		}
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_outputBuffer(t *testing.T) {
+	testCases := []struct {
+		inputWrites   []string
+		expectedLines []string
+		name          string
+	}{
+		{
+			inputWrites:   []string{},
+			expectedLines: []string{},
+			name:          "no output",
+		},
+		{
+			inputWrites:   []string{"line1\nline2\n"},
+			expectedLines: []string{"line1", "line2"},
+			name:          "multiple lines in single write",
+		},
+		{
+			inputWrites:   []string{"li", "ne1", "\n", "line2"},
+			expectedLines: []string{"line1"},
+			name:          "partial lines",
+		},
+		{
+			inputWrites:   []string{"line1\r\n"},
+			expectedLines: []string{"line1"},
+			name:          "carriage return trimmed",
+		},
+		{
+			inputWrites:   []string{"line1\nline2\nline3\nline4\nline5\n"},
+			expectedLines: []string{"line3", "line4", "line5"},
+			name:          "oldest lines overwritten",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := newOutputBuffer(3)
+			w := buf.writer(nil)
+
+			for _, write := range tc.inputWrites {
+				n, err := w.Write([]byte(write))
+				assert.Nil(t, err, tc.name)
+				assert.Equal(t, len(write), n, tc.name)
+			}
+			assert.Equal(t, tc.expectedLines, buf.Lines(), tc.name)
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/apm"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// pluginRecycleRetryInterval is the time waited before trying to recycle a
+// plugin again, once launching its replacement failed.
+const pluginRecycleRetryInterval = time.Minute
+
+// The reasons the process of a plugin is restarted.
+const (
+	// RestartReasonMaxLifetime is the replacement of a process which reached
+	// the max lifetime of the plugin.
+	RestartReasonMaxLifetime = "max_lifetime"
+)
+
+// recyclingPluginInstance wraps an external plugin configured with a maximum
+// lifetime. Once the lifetime of the plugin process has passed, the process
+// is replaced as soon as no calls to the plugin are in flight: a new process
+// is launched and configured, and the old one is killed. Calls made while the
+// process is replaced wait for the replacement, so no call is interrupted.
+//
+// The plugin returned by Plugin forwards each call to the current process, so
+// callers which hold on to it use the replacement transparently.
+type recyclingPluginInstance struct {
+	id          plugins.PluginID
+	logger      hclog.Logger
+	maxLifetime time.Duration
+
+	// launch launches and configures a new instance of the plugin.
+	launch func() (PluginInstance, error)
+
+	// plugin is the wrapper implementing the plugin type interface.
+	plugin interface{}
+
+	// lock protects the fields below, and cond is signalled once a recycle
+	// completes.
+	lock      sync.Mutex
+	cond      *sync.Cond
+	inst      PluginInstance
+	recycleAt time.Time
+	inFlight  int
+	recycling bool
+	killed    bool
+
+	// restarts counts the replacements of the process by reason, and
+	// lastRestart is when the process was last replaced. They are protected
+	// by lock.
+	restarts    map[string]int64
+	lastRestart time.Time
+}
+
+// newRecyclingPluginInstance wraps the plugin instance so it is recycled
+// using launch once maxLifetime has passed. Plugins of an unknown type are
+// returned unwrapped, as their calls cannot be tracked.
+func newRecyclingPluginInstance(id plugins.PluginID, logger hclog.Logger, inst PluginInstance, maxLifetime time.Duration, launch func() (PluginInstance, error)) PluginInstance {
+	r := &recyclingPluginInstance{
+		id:          id,
+		logger:      logger.With("plugin_name", id.Name),
+		maxLifetime: maxLifetime,
+		launch:      launch,
+		inst:        inst,
+		recycleAt:   time.Now().Add(maxLifetime),
+		restarts:    make(map[string]int64),
+	}
+	r.cond = sync.NewCond(&r.lock)
+
+	switch id.PluginType {
+	case plugins.PluginTypeAPM:
+		r.plugin = &recyclingAPM{recyclingBase{r}}
+	case plugins.PluginTypeTarget:
+		r.plugin = &recyclingTarget{recyclingBase{r}}
+	case plugins.PluginTypeStrategy:
+		r.plugin = &recyclingStrategy{recyclingBase{r}}
+	default:
+		return inst
+	}
+	return r
+}
+
+// Kill kills the current plugin process, waiting for a recycle in progress to
+// complete. The plugin is not recycled once killed.
+func (r *recyclingPluginInstance) Kill() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for r.recycling {
+		r.cond.Wait()
+	}
+	r.killed = true
+	r.inst.Kill()
+}
+
+func (r *recyclingPluginInstance) Plugin() interface{} { return r.plugin }
+
+// current returns the plugin instance of the current process.
+func (r *recyclingPluginInstance) current() PluginInstance {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.inst
+}
+
+// acquire returns the plugin of the current process and marks a call as in
+// flight until release is called. It waits for a recycle in progress to
+// complete, so the call is made to the replacement.
+func (r *recyclingPluginInstance) acquire() interface{} {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for r.recycling {
+		r.cond.Wait()
+	}
+	r.inFlight++
+	return r.inst.Plugin()
+}
+
+// release marks a call as complete. The plugin is recycled in the background
+// if its lifetime has passed and no other calls are in flight.
+func (r *recyclingPluginInstance) release() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.inFlight--
+	if r.inFlight > 0 || r.recycling || r.killed || time.Now().Before(r.recycleAt) {
+		return
+	}
+
+	r.recycling = true
+	go r.recycle()
+}
+
+// recycle replaces the current plugin process with a new one. The current
+// process is kept if the replacement fails to launch, and recycling is tried
+// again after pluginRecycleRetryInterval.
+func (r *recyclingPluginInstance) recycle() {
+	r.logger.Info("recycling plugin process which reached its max lifetime", "max_lifetime", r.maxLifetime)
+
+	inst, err := r.launch()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	defer r.cond.Broadcast()
+
+	r.recycling = false
+
+	if err != nil {
+		r.logger.Error("failed to recycle plugin, keeping the current process", "error", err)
+		r.recycleAt = time.Now().Add(pluginRecycleRetryInterval)
+		return
+	}
+
+	r.inst.Kill()
+	r.inst = inst
+	r.recycleAt = time.Now().Add(r.maxLifetime)
+	r.recordRestart(RestartReasonMaxLifetime)
+
+	labels := []metrics.Label{{Name: "plugin_name", Value: r.id.Name}, {Name: "plugin_type", Value: r.id.PluginType}}
+	metrics.IncrCounterWithLabels([]string{"plugin", "manager", "recycle_count"}, 1, labels)
+}
+
+// recordRestart records the replacement of the process for the reason. The
+// caller must hold lock.
+func (r *recyclingPluginInstance) recordRestart(reason string) {
+	r.restarts[reason]++
+	r.lastRestart = time.Now()
+
+	var total int64
+	for _, n := range r.restarts {
+		total += n
+	}
+	r.logger.Info("plugin process restarted", "reason", reason, "restarts", total)
+
+	labels := []metrics.Label{
+		{Name: "plugin_name", Value: r.id.Name},
+		{Name: "plugin_type", Value: r.id.PluginType},
+		{Name: "reason", Value: reason},
+	}
+	metrics.IncrCounterWithLabels([]string{"plugin", "manager", "restart_count"}, 1, labels)
+}
+
+// restartStats returns a copy of the count of restarts by reason, and when
+// the process was last restarted.
+func (r *recyclingPluginInstance) restartStats() (map[string]int64, time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	restarts := make(map[string]int64, len(r.restarts))
+	for reason, n := range r.restarts {
+		restarts[reason] = n
+	}
+	return restarts, r.lastRestart
+}
+
+// recyclingBase forwards the base plugin calls to the current process of a
+// recyclingPluginInstance. It is embedded by the wrappers of each plugin type.
+type recyclingBase struct {
+	r *recyclingPluginInstance
+}
+
+func (b recyclingBase) PluginInfo() (*base.PluginInfo, error) {
+	p := b.r.acquire()
+	defer b.r.release()
+	return p.(base.Plugin).PluginInfo()
+}
+
+func (b recyclingBase) SetConfig(config map[string]string) error {
+	p := b.r.acquire()
+	defer b.r.release()
+	return p.(base.Plugin).SetConfig(config)
+}
+
+// recyclingAPM forwards the calls of an APM plugin.
+type recyclingAPM struct {
+	recyclingBase
+}
+
+func (a *recyclingAPM) Query(q string, rng sdk.TimeRange) (sdk.TimestampedMetrics, error) {
+	p := a.r.acquire()
+	defer a.r.release()
+	return p.(apm.APM).Query(q, rng)
+}
+
+func (a *recyclingAPM) QueryMultiple(q string, rng sdk.TimeRange) ([]sdk.TimestampedMetrics, error) {
+	p := a.r.acquire()
+	defer a.r.release()
+	return p.(apm.APM).QueryMultiple(q, rng)
+}
+
+func (a *recyclingAPM) ValidateQuery(q string) error {
+	p := a.r.acquire()
+	defer a.r.release()
+	if v, ok := p.(apm.QueryValidator); ok {
+		return v.ValidateQuery(q)
+	}
+	return nil
+}
+
+// recyclingTarget forwards the calls of a target plugin.
+type recyclingTarget struct {
+	recyclingBase
+}
+
+func (t *recyclingTarget) Scale(action sdk.ScalingAction, config map[string]string) error {
+	p := t.r.acquire()
+	defer t.r.release()
+	return p.(target.Target).Scale(action, config)
+}
+
+func (t *recyclingTarget) Status(config map[string]string) (*sdk.TargetStatus, error) {
+	p := t.r.acquire()
+	defer t.r.release()
+	return p.(target.Target).Status(config)
+}
+
+// recyclingStrategy forwards the calls of a strategy plugin.
+type recyclingStrategy struct {
+	recyclingBase
+}
+
+func (s *recyclingStrategy) Run(eval *sdk.ScalingCheckEvaluation, count int64) (*sdk.ScalingCheckEvaluation, error) {
+	p := s.r.acquire()
+	defer s.r.release()
+	return p.(strategy.Strategy).Run(eval, count)
+}
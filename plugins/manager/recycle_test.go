@@ -0,0 +1,217 @@
+package manager
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStrategyInstance is a strategy plugin instance which records whether it
+// was killed. Run blocks until block is closed, if set.
+type fakeStrategyInstance struct {
+	block chan struct{}
+
+	lock   sync.Mutex
+	killed bool
+}
+
+func (f *fakeStrategyInstance) Kill() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.killed = true
+}
+
+func (f *fakeStrategyInstance) isKilled() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.killed
+}
+
+func (f *fakeStrategyInstance) Plugin() interface{} { return f }
+
+func (f *fakeStrategyInstance) PluginInfo() (*base.PluginInfo, error) { return &base.PluginInfo{}, nil }
+
+func (f *fakeStrategyInstance) SetConfig(_ map[string]string) error { return nil }
+
+func (f *fakeStrategyInstance) Run(eval *sdk.ScalingCheckEvaluation, _ int64) (*sdk.ScalingCheckEvaluation, error) {
+	if f.block != nil {
+		<-f.block
+	}
+	return eval, nil
+}
+
+// newTestRecyclingInstance returns a recycling strategy plugin whose
+// replacements are returned by launch in order.
+func newTestRecyclingInstance(inst PluginInstance, maxLifetime time.Duration, launch ...func() (PluginInstance, error)) *recyclingPluginInstance {
+	var lock sync.Mutex
+	id := plugins.PluginID{Name: "fake", PluginType: plugins.PluginTypeStrategy}
+
+	return newRecyclingPluginInstance(id, hclog.NewNullLogger(), inst, maxLifetime, func() (PluginInstance, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		next := launch[0]
+		launch = launch[1:]
+		return next()
+	}).(*recyclingPluginInstance)
+}
+
+func TestRecyclingPluginInstance(t *testing.T) {
+	t.Run("not recycled within lifetime", func(t *testing.T) {
+		first := &fakeStrategyInstance{}
+		r := newTestRecyclingInstance(first, time.Hour)
+
+		_, err := r.Plugin().(strategy.Strategy).Run(&sdk.ScalingCheckEvaluation{}, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, first, r.current())
+		assert.False(t, first.isKilled())
+	})
+
+	t.Run("recycled once lifetime passed", func(t *testing.T) {
+		first, second := &fakeStrategyInstance{}, &fakeStrategyInstance{}
+		r := newTestRecyclingInstance(first, time.Nanosecond, func() (PluginInstance, error) { return second, nil })
+
+		_, err := r.Plugin().(strategy.Strategy).Run(&sdk.ScalingCheckEvaluation{}, 1)
+		assert.NoError(t, err)
+
+		assert.Eventually(t, func() bool { return r.current() == second }, 5*time.Second, 10*time.Millisecond)
+		assert.True(t, first.isKilled())
+		assert.False(t, second.isKilled())
+
+		restarts, last := r.restartStats()
+		assert.Equal(t, map[string]int64{RestartReasonMaxLifetime: 1}, restarts)
+		assert.False(t, last.IsZero())
+	})
+
+	t.Run("not recycled while a call is in flight", func(t *testing.T) {
+		first := &fakeStrategyInstance{block: make(chan struct{})}
+		second := &fakeStrategyInstance{}
+		r := newTestRecyclingInstance(first, time.Nanosecond, func() (PluginInstance, error) { return second, nil })
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = r.Plugin().(strategy.Strategy).Run(&sdk.ScalingCheckEvaluation{}, 1)
+		}()
+
+		// Wait for the blocked call to be in flight, then complete another
+		// call which must not recycle the plugin.
+		assert.Eventually(t, func() bool {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			return r.inFlight == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		_, err := r.Plugin().(strategy.Strategy).PluginInfo()
+		assert.NoError(t, err)
+		assert.Equal(t, first, r.current())
+		assert.False(t, first.isKilled())
+
+		close(first.block)
+		<-done
+
+		assert.Eventually(t, func() bool { return r.current() == second }, 5*time.Second, 10*time.Millisecond)
+		assert.True(t, first.isKilled())
+	})
+
+	t.Run("current process kept on launch failure", func(t *testing.T) {
+		first := &fakeStrategyInstance{}
+		r := newTestRecyclingInstance(first, time.Nanosecond, func() (PluginInstance, error) { return nil, errors.New("launch failed") })
+
+		_, err := r.Plugin().(strategy.Strategy).Run(&sdk.ScalingCheckEvaluation{}, 1)
+		assert.NoError(t, err)
+
+		// The retry is scheduled once the failed recycle completes.
+		assert.Eventually(t, func() bool {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			return !r.recycling && time.Until(r.recycleAt) > time.Second
+		}, 5*time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, first, r.current())
+		assert.False(t, first.isKilled())
+
+		// A failed recycle is not a restart.
+		restarts, last := r.restartStats()
+		assert.Empty(t, restarts)
+		assert.True(t, last.IsZero())
+	})
+
+	t.Run("not recycled once killed", func(t *testing.T) {
+		first := &fakeStrategyInstance{}
+		r := newTestRecyclingInstance(first, time.Nanosecond)
+
+		r.Kill()
+		assert.True(t, first.isKilled())
+
+		// The launch func panics if it is called.
+		_, err := r.Plugin().(strategy.Strategy).Run(&sdk.ScalingCheckEvaluation{}, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, first, r.current())
+	})
+}
+
+func TestPluginManager_PluginStates_restarts(t *testing.T) {
+	first, second := &fakeStrategyInstance{}, &fakeStrategyInstance{}
+	r := newTestRecyclingInstance(first, time.Nanosecond, func() (PluginInstance, error) { return second, nil })
+
+	pm := NewPluginManager(hclog.NewNullLogger(), "", nil)
+	pm.pluginInstances[r.id] = r
+
+	// Restarts are only reported once the process was restarted.
+	states := pm.PluginStates()
+	if assert.Len(t, states, 1) {
+		assert.Nil(t, states[0].Restarts)
+		assert.Nil(t, states[0].LastRestart)
+	}
+
+	_, err := r.Plugin().(strategy.Strategy).Run(&sdk.ScalingCheckEvaluation{}, 1)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return r.current() == second }, 5*time.Second, 10*time.Millisecond)
+
+	states = pm.PluginStates()
+	if assert.Len(t, states, 1) {
+		assert.Equal(t, map[string]int64{RestartReasonMaxLifetime: 1}, states[0].Restarts)
+		assert.NotNil(t, states[0].LastRestart)
+		assert.True(t, states[0].Healthy)
+	}
+}
+
+func TestLoad_maxLifetime(t *testing.T) {
+	cfg := map[string][]*config.Plugin{
+		"strategy": {{Name: "noop", Driver: "noop-strategy", MaxLifetime: time.Nanosecond}},
+	}
+
+	pm := NewPluginManager(hclog.NewNullLogger(), "../test/bin", cfg)
+	defer pm.KillPlugins()
+	assert.NoError(t, pm.Load())
+
+	inst, err := pm.Dispense("noop", plugins.PluginTypeStrategy)
+	assert.NoError(t, err)
+
+	r, ok := inst.(*recyclingPluginInstance)
+	if !assert.True(t, ok) {
+		return
+	}
+	first := r.current().(*externalPluginInstance)
+
+	// The call is made to the first process, which is then replaced by a
+	// new configured process.
+	_, err = inst.Plugin().(strategy.Strategy).PluginInfo()
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return r.current() != first }, 10*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, first.client.Exited, 10*time.Second, 10*time.Millisecond)
+
+	info, err := inst.Plugin().(strategy.Strategy).PluginInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "noop-strategy", info.Name)
+}
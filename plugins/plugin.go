@@ -46,6 +46,10 @@ const (
 
 	// InternalAPMDatadog is the Datadog APM plugin name.
 	InternalAPMDatadog = "datadog"
+
+	// InternalAPMPush is the push APM plugin name, which serves the metric
+	// values pushed to the agent HTTP API.
+	InternalAPMPush = "push"
 )
 
 // ConfigKeyNomadConfigInherit is a generic plugin config map key that supports
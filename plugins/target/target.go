@@ -8,6 +8,17 @@ import (
 	"github.com/hashicorp/nomad-autoscaler/sdk"
 )
 
+// Target is the interface implemented by target plugins.
+//
+// Scale is called with the scaling action and the full target config of the
+// policy. The agent guarantees the config is passed through unchanged,
+// including keys it does not use itself, so plugins can accept their own
+// settings such as spread or constraint attributes. The action holds the
+// desired count and the direction, and its Meta holds the count the action
+// was calculated from under sdk.StrategyActionMetaKeyCurrentCount. Plugins
+// which distribute the count, such as across datacenters, can report the
+// resulting distribution from Status using sdk.TargetStatusMetaKeyDistribution,
+// which the agent logs when it scales and verifies the target.
 type Target interface {
 	Scale(action sdk.ScalingAction, config map[string]string) error
 	Status(config map[string]string) (*sdk.TargetStatus, error)
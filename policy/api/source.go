@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// Ensure Source satisfies the Source interface.
+var _ policy.Source = (*Source)(nil)
+
+// Source is the API implementation of the policy.Source interface. Policies
+// are registered and unregistered at runtime through the agent HTTP API and
+// are only held in memory, so they do not survive an agent restart. As with
+// other sources, the policy manager only reconciles the handlers of these
+// policies against the list of IDs of this source.
+type Source struct {
+	log             hclog.Logger
+	policyProcessor *policy.Processor
+
+	// policies are the registered policies keyed by their ID. changeCh is
+	// closed and replaced whenever they are modified, notifying the monitors
+	// of the change. Both are protected by lock.
+	policies map[policy.PolicyID]*sdk.ScalingPolicy
+	changeCh chan struct{}
+	lock     sync.RWMutex
+
+	// reloadChannels help coordinate reloading the of the MonitorIDs routine.
+	reloadCh         chan struct{}
+	reloadCompleteCh chan struct{}
+}
+
+// NewAPISource returns a new API policy source.
+func NewAPISource(log hclog.Logger, policyProcessor *policy.Processor) *Source {
+	return &Source{
+		log:              log.ResetNamed("api_policy_source"),
+		policyProcessor:  policyProcessor,
+		policies:         make(map[policy.PolicyID]*sdk.ScalingPolicy),
+		changeCh:         make(chan struct{}),
+		reloadCh:         make(chan struct{}),
+		reloadCompleteCh: make(chan struct{}, 1),
+	}
+}
+
+// Name satisfies the Name function of the policy.Source interface.
+func (s *Source) Name() policy.SourceName {
+	return policy.SourceNameAPI
+}
+
+// Register validates and stores the policy, replacing any policy previously
+// registered with the same ID. The policy is monitored by the policy manager
+// once the ID monitor sends the updated list of IDs.
+func (s *Source) Register(p *sdk.ScalingPolicy) error {
+	s.policyProcessor.ApplyPolicyDefaults(p)
+
+	if err := s.policyProcessor.ValidatePolicy(p); err != nil {
+		return err
+	}
+
+	for _, c := range p.Checks {
+		s.policyProcessor.CanonicalizeCheck(c, p.Target)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.policies[policy.PolicyID(p.ID)] = p
+	s.notifyLocked()
+
+	s.log.Info("registered policy", "policy_id", p.ID)
+	return nil
+}
+
+// Unregister removes the policy, stopping its handler once the ID monitor
+// sends the updated list of IDs. policy.ErrPolicyNotFound is returned if no
+// policy is registered with the ID.
+func (s *Source) Unregister(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.policies[policy.PolicyID(id)]; !ok {
+		return policy.ErrPolicyNotFound
+	}
+
+	delete(s.policies, policy.PolicyID(id))
+	s.notifyLocked()
+
+	s.log.Info("unregistered policy", "policy_id", id)
+	return nil
+}
+
+// notifyLocked notifies the monitors the registered policies have changed.
+// The caller must hold the lock.
+func (s *Source) notifyLocked() {
+	close(s.changeCh)
+	s.changeCh = make(chan struct{})
+}
+
+// MonitorIDs satisfies the MonitorIDs function of the policy.Source interface.
+// The list of IDs is sent when the monitor starts and whenever a policy is
+// registered or unregistered.
+func (s *Source) MonitorIDs(ctx context.Context, req policy.MonitorIDsReq) {
+	s.log.Debug("starting api policy source ID monitor")
+
+	for {
+		ids, changeCh := s.policyIDs()
+
+		select {
+		case req.ResultCh <- policy.IDMessage{IDs: ids, Source: s.Name()}:
+		case <-ctx.Done():
+			s.log.Trace("stopping api policy source ID monitor")
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			s.log.Trace("stopping api policy source ID monitor")
+			return
+
+		case <-s.reloadCh:
+			// The policies are held in memory, so there is nothing to read
+			// again. The list of IDs is sent regardless, as with the other
+			// sources.
+			s.log.Info("api policy source ID monitor received reload signal")
+			s.reloadCompleteCh <- struct{}{}
+
+		case <-changeCh:
+		}
+	}
+}
+
+// ReloadIDsMonitor satisfies the ReloadIDsMonitor function of the
+// policy.Source interface.
+func (s *Source) ReloadIDsMonitor() {
+	s.reloadCh <- struct{}{}
+	<-s.reloadCompleteCh
+}
+
+// MonitorPolicy satisfies the MonitorPolicy function of the policy.Source
+// interface. The policy is sent when the monitor starts and whenever it is
+// registered again.
+func (s *Source) MonitorPolicy(ctx context.Context, req policy.MonitorPolicyReq) {
+
+	// Close channels when done with the monitoring loop.
+	defer close(req.ResultCh)
+	defer close(req.ErrCh)
+
+	log := s.log.With("policy_id", req.ID)
+	log.Debug("starting api policy monitor")
+
+	var current *sdk.ScalingPolicy
+
+	for {
+		s.lock.RLock()
+		p := s.policies[req.ID]
+		changeCh := s.changeCh
+		s.lock.RUnlock()
+
+		// A missing policy has been unregistered, and its handler is stopped
+		// once the ID monitor sends the updated list of IDs.
+		if p != nil && p != current {
+			current = p
+
+			select {
+			case req.ResultCh <- *p:
+			case <-ctx.Done():
+				log.Debug("stopping api policy monitor due to context done")
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Debug("stopping api policy monitor due to context done")
+			return
+		case <-req.ReloadCh:
+			log.Info("api policy source monitor received reload signal")
+		case <-changeCh:
+		}
+	}
+}
+
+// policyIDs returns the sorted IDs of the registered policies, along with the
+// channel closed on their next change.
+func (s *Source) policyIDs() ([]policy.PolicyID, <-chan struct{}) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	ids := make([]policy.PolicyID, 0, len(s.policies))
+	for id := range s.policies {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids, s.changeCh
+}
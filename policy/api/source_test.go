@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSource() *Source {
+	processor := policy.NewProcessor(&policy.ConfigDefaults{
+		DefaultEvaluationInterval: 10 * time.Second,
+		DefaultCooldown:           time.Minute,
+	}, []string{"nomad-apm"})
+
+	return NewAPISource(hclog.NewNullLogger(), processor)
+}
+
+func newTestPolicy(id string, max int64) *sdk.ScalingPolicy {
+	return &sdk.ScalingPolicy{
+		ID:      id,
+		Enabled: true,
+		Min:     1,
+		Max:     max,
+		Target:  &sdk.ScalingPolicyTarget{Name: "aws-asg", Config: map[string]string{}},
+		Checks: []*sdk.ScalingPolicyCheck{{
+			Name:     "cpu",
+			Source:   "prometheus",
+			Query:    "cpu",
+			Strategy: &sdk.ScalingPolicyStrategy{Name: "target-value", Config: map[string]string{"target": "80"}},
+		}},
+	}
+}
+
+func TestSource_Register(t *testing.T) {
+	s := newTestSource()
+
+	// Invalid policies are rejected.
+	assert.NotNil(t, s.Register(newTestPolicy("", 10)))
+
+	p := newTestPolicy("policy1", 10)
+	assert.Nil(t, s.Register(p))
+
+	// The policy defaults are applied.
+	assert.Equal(t, 10*time.Second, p.EvaluationInterval)
+	assert.Equal(t, time.Minute, p.Cooldown)
+
+	assert.Equal(t, policy.ErrPolicyNotFound, s.Unregister("policy2"))
+	assert.Nil(t, s.Unregister("policy1"))
+	assert.Equal(t, policy.ErrPolicyNotFound, s.Unregister("policy1"))
+}
+
+func TestSource_MonitorIDs(t *testing.T) {
+	s := newTestSource()
+	assert.Nil(t, s.Register(newTestPolicy("policy1", 10)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resultCh := make(chan policy.IDMessage)
+	go s.MonitorIDs(ctx, policy.MonitorIDsReq{ResultCh: resultCh, ErrCh: make(chan error)})
+
+	// The IDs are sent when the monitor starts.
+	msg := <-resultCh
+	assert.Equal(t, policy.SourceNameAPI, msg.Source)
+	assert.Equal(t, []policy.PolicyID{"policy1"}, msg.IDs)
+
+	// And whenever the registered policies change.
+	assert.Nil(t, s.Register(newTestPolicy("policy2", 10)))
+	assert.Equal(t, []policy.PolicyID{"policy1", "policy2"}, (<-resultCh).IDs)
+
+	assert.Nil(t, s.Unregister("policy1"))
+	assert.Equal(t, []policy.PolicyID{"policy2"}, (<-resultCh).IDs)
+
+	// A reload sends the IDs again once it completes.
+	s.ReloadIDsMonitor()
+	assert.Equal(t, []policy.PolicyID{"policy2"}, (<-resultCh).IDs)
+}
+
+func TestSource_MonitorPolicy(t *testing.T) {
+	s := newTestSource()
+	assert.Nil(t, s.Register(newTestPolicy("policy1", 10)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resultCh := make(chan sdk.ScalingPolicy)
+	go s.MonitorPolicy(ctx, policy.MonitorPolicyReq{
+		ID:       "policy1",
+		ErrCh:    make(chan error),
+		ReloadCh: make(chan struct{}),
+		ResultCh: resultCh,
+	})
+
+	// The policy is sent when the monitor starts.
+	assert.Equal(t, int64(10), (<-resultCh).Max)
+
+	// Changes to other policies are not sent.
+	assert.Nil(t, s.Register(newTestPolicy("policy2", 3)))
+
+	// Registering the policy again sends the new version.
+	assert.Nil(t, s.Register(newTestPolicy("policy1", 5)))
+	assert.Equal(t, int64(5), (<-resultCh).Max)
+
+	// The monitor stops, closing the channel, once the context is canceled.
+	cancel()
+	_, ok := <-resultCh
+	assert.False(t, ok)
+}
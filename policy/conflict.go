@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"sort"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// ConflictMode is how policies from different sources which share an ID or a
+// target are handled.
+type ConflictMode string
+
+const (
+	// ConflictModeFirstWins uses the policy which was seen first. For a
+	// shared target, all policies from the source of that policy are used.
+	ConflictModeFirstWins ConflictMode = "first-wins"
+
+	// ConflictModeError uses none of the conflicting policies until the
+	// conflict is resolved.
+	ConflictModeError ConflictMode = "error"
+
+	// ConflictModeSourcePriority uses the policy from the source with the
+	// highest priority. Sources with the same priority fall back to
+	// first-wins.
+	ConflictModeSourcePriority ConflictMode = "source-priority"
+)
+
+// ConflictType is the reason policies conflict.
+type ConflictType string
+
+const (
+	// ConflictTypeID is the conflict of policies from different sources
+	// which use the same ID.
+	ConflictTypeID ConflictType = "id"
+
+	// ConflictTypeTarget is the conflict of policies from different sources
+	// which scale the same target.
+	ConflictTypeTarget ConflictType = "target"
+)
+
+// ConflictResolution configures how the manager handles conflicting policies.
+type ConflictResolution struct {
+	Mode ConflictMode
+
+	// SourcePriority orders the sources by priority, highest first, when the
+	// mode is ConflictModeSourcePriority.
+	SourcePriority []SourceName
+
+	// TargetKey returns the identity of the target of a policy, which is used
+	// to detect policies from different sources scaling the same target.
+	// Target conflicts are not detected if it is nil.
+	TargetKey func(*sdk.ScalingPolicy) string
+}
+
+// Conflict is an active conflict between policies from different sources.
+type Conflict struct {
+	Type ConflictType
+
+	// Key is the shared policy ID or target.
+	Key string
+
+	// Policies are the conflicting policies, in the order they were seen.
+	Policies []ConflictPolicy
+}
+
+// ConflictPolicy is a policy within a conflict.
+type ConflictPolicy struct {
+	ID     PolicyID
+	Source SourceName
+
+	// Active indicates the policy is used despite the conflict.
+	Active bool
+}
+
+// winner returns the index of the source whose policy is used, out of the
+// conflicting sources ordered by when their policy was seen. The returned
+// bool is false if none of the policies are used.
+func (c ConflictResolution) winner(sources []SourceName) (int, bool) {
+	switch {
+	case len(sources) == 0:
+		return 0, false
+	case len(sources) == 1:
+		return 0, true
+	}
+
+	switch c.Mode {
+	case ConflictModeError:
+		return 0, false
+	case ConflictModeSourcePriority:
+		best := 0
+		for i := range sources {
+			if c.priority(sources[i]) < c.priority(sources[best]) {
+				best = i
+			}
+		}
+		return best, true
+	default:
+		return 0, true
+	}
+}
+
+// priority returns the rank of the source, lower being a higher priority.
+func (c ConflictResolution) priority(source SourceName) int {
+	for i, s := range c.SourcePriority {
+		if s == source {
+			return i
+		}
+	}
+	return len(c.SourcePriority)
+}
+
+// sortConflicts orders conflicts by type and key, so they are reported in a
+// stable order.
+func sortConflicts(conflicts []Conflict) {
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Type != conflicts[j].Type {
+			return conflicts[i].Type < conflicts[j].Type
+		}
+		return conflicts[i].Key < conflicts[j].Key
+	})
+}
@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConflictResolution_winner(t *testing.T) {
+	testCases := []struct {
+		inputResolution ConflictResolution
+		inputSources    []SourceName
+		expectedWinner  int
+		expectedOK      bool
+		name            string
+	}{
+		{
+			inputResolution: ConflictResolution{Mode: ConflictModeError},
+			inputSources:    nil,
+			expectedOK:      false,
+			name:            "no sources",
+		},
+		{
+			inputResolution: ConflictResolution{Mode: ConflictModeError},
+			inputSources:    []SourceName{SourceNameFile},
+			expectedWinner:  0,
+			expectedOK:      true,
+			name:            "single source",
+		},
+		{
+			inputResolution: ConflictResolution{Mode: ConflictModeFirstWins},
+			inputSources:    []SourceName{SourceNameFile, SourceNameNomad},
+			expectedWinner:  0,
+			expectedOK:      true,
+			name:            "first wins",
+		},
+		{
+			inputResolution: ConflictResolution{},
+			inputSources:    []SourceName{SourceNameFile, SourceNameNomad},
+			expectedWinner:  0,
+			expectedOK:      true,
+			name:            "mode not set",
+		},
+		{
+			inputResolution: ConflictResolution{Mode: ConflictModeError},
+			inputSources:    []SourceName{SourceNameFile, SourceNameNomad},
+			expectedOK:      false,
+			name:            "error",
+		},
+		{
+			inputResolution: ConflictResolution{
+				Mode:           ConflictModeSourcePriority,
+				SourcePriority: []SourceName{SourceNameNomad, SourceNameFile},
+			},
+			inputSources:   []SourceName{SourceNameFile, SourceNameNomad},
+			expectedWinner: 1,
+			expectedOK:     true,
+			name:           "source priority",
+		},
+		{
+			inputResolution: ConflictResolution{
+				Mode:           ConflictModeSourcePriority,
+				SourcePriority: []SourceName{SourceNameAPI},
+			},
+			inputSources:   []SourceName{SourceNameFile, SourceNameNomad, SourceNameAPI},
+			expectedWinner: 2,
+			expectedOK:     true,
+			name:           "unlisted sources have the lowest priority",
+		},
+		{
+			inputResolution: ConflictResolution{
+				Mode:           ConflictModeSourcePriority,
+				SourcePriority: []SourceName{SourceNameAPI},
+			},
+			inputSources:   []SourceName{SourceNameFile, SourceNameNomad},
+			expectedWinner: 0,
+			expectedOK:     true,
+			name:           "same priority falls back to first wins",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			winner, ok := tc.inputResolution.winner(tc.inputSources)
+			assert.Equal(t, tc.expectedOK, ok, tc.name)
+			assert.Equal(t, tc.expectedWinner, winner, tc.name)
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package consul
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/policy/file"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/blocking"
+)
+
+const (
+	// DefaultPrefix is the KV prefix policies are read from when the source
+	// is not configured with one.
+	DefaultPrefix = "nomad-autoscaler/policies"
+
+	// waitTime is the time a blocking query waits for a change before
+	// returning.
+	waitTime = 5 * time.Minute
+
+	// errorWait is the time to wait before retrying a failed query.
+	errorWait = 10 * time.Second
+
+	// headerIndex and headerToken are the Consul HTTP API headers holding the
+	// index of the response and the ACL token of the request.
+	headerIndex = "X-Consul-Index"
+	headerToken = "X-Consul-Token"
+)
+
+// Ensure Source satisfies the Source interface.
+var _ policy.Source = (*Source)(nil)
+
+// errKeyNotFound is returned by get when the key does not exist.
+var errKeyNotFound = errors.New("key not found")
+
+// Config is the configuration of the Consul policy source.
+type Config struct {
+
+	// Address is the URL of the Consul HTTP API.
+	Address string
+
+	// Prefix is the KV prefix which holds the policies. Each key directly
+	// under the prefix is a policy, and its name is the policy ID.
+	Prefix string
+
+	// Token is the ACL token used to read the policies.
+	Token string
+
+	// CACert, ClientCert and ClientKey are paths to the PEM-encoded files
+	// used for TLS communication with Consul. TLSServerName sets the SNI
+	// host, and SkipVerify disables verification of the Consul certificate.
+	CACert        string
+	ClientCert    string
+	ClientKey     string
+	TLSServerName string
+	SkipVerify    bool
+}
+
+// Source is the Consul implementation of the policy.Source interface. It
+// reads policies from the Consul KV store using blocking queries, so changes
+// are picked up as soon as they are written.
+//
+// Policies are written in the same format as file policies and are decoded
+// as JSON if the value is a JSON object, otherwise as HCL.
+type Source struct {
+	address         string
+	prefix          string
+	token           string
+	client          *http.Client
+	log             hclog.Logger
+	policyProcessor *policy.Processor
+}
+
+// listResponse is the result of a blocking query listing the keys under the
+// prefix.
+type listResponse struct {
+	keys  []string
+	index uint64
+}
+
+// NewConsulSource returns a new Consul policy source.
+func NewConsulSource(log hclog.Logger, cfg *Config, policyProcessor *policy.Processor) (*Source, error) {
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup Consul TLS: %v", err)
+	}
+
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &Source{
+		address: strings.TrimSuffix(cfg.Address, "/"),
+		prefix:  prefix,
+		token:   cfg.Token,
+
+		// Blocking queries are held by Consul for up to the wait time, plus
+		// a random jitter of up to a sixteenth of it.
+		client:          &http.Client{Timeout: waitTime + waitTime/16 + 30*time.Second, Transport: transport},
+		log:             log.ResetNamed("consul_policy_source"),
+		policyProcessor: policyProcessor,
+	}, nil
+}
+
+// Name satisfies the Name function of the policy.Source interface.
+func (s *Source) Name() policy.SourceName {
+	return policy.SourceNameConsul
+}
+
+// ReloadIDsMonitor satisfies the ReloadIDsMonitor function of the
+// policy.Source interface. The blocking queries always reflect the current
+// state of the KV store, so there is nothing to reload.
+func (s *Source) ReloadIDsMonitor() {}
+
+// MonitorIDs satisfies the MonitorIDs function of the policy.Source interface.
+// It lists the keys under the prefix using a blocking query, and sends the
+// policy IDs to the resultCh when the keys change.
+func (s *Source) MonitorIDs(ctx context.Context, req policy.MonitorIDsReq) {
+	s.log.Debug("starting policy blocking query watcher")
+
+	var index uint64 = 1
+
+	for {
+		resp, err := s.listPolicyIDs(ctx, index)
+
+		// Return immediately if context is closed.
+		if ctx.Err() != nil {
+			s.log.Trace("stopping ID subscription")
+			return
+		}
+
+		if err != nil {
+			policy.HandleSourceError(s.Name(), fmt.Errorf("failed to list policies: %v", err), req.ErrCh)
+			if !wait(ctx, errorWait) {
+				return
+			}
+			continue
+		}
+
+		// If the index has not changed, the query returned because the wait
+		// time was reached, therefore start the next query.
+		if !blocking.IndexHasChanged(resp.index, index) {
+			continue
+		}
+		index = blocking.NextIndex(resp.index, index)
+
+		select {
+		case req.ResultCh <- policy.IDMessage{IDs: parsePolicyIDs(s.prefix, resp.keys), Source: s.Name()}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// MonitorPolicy satisfies the MonitorPolicy function of the policy.Source
+// interface. It reads the policy key using a blocking query, and sends the
+// policy to the resultCh when it changes.
+func (s *Source) MonitorPolicy(ctx context.Context, req policy.MonitorPolicyReq) {
+
+	// Close channels when done with the monitoring loop.
+	defer close(req.ResultCh)
+	defer close(req.ErrCh)
+
+	log := s.log.With("policy_id", req.ID)
+	log.Trace("starting policy blocking query watcher")
+
+	var current *sdk.ScalingPolicy
+	var index uint64 = 1
+
+	for {
+		body, newIndex, err := s.get(ctx, s.prefix+"/"+req.ID.String(), url.Values{"raw": {""}}, index)
+
+		// Return immediately if context is closed.
+		if ctx.Err() != nil {
+			log.Trace("done with policy monitoring")
+			return
+		}
+
+		if err != nil {
+			policy.HandleSourceError(s.Name(), fmt.Errorf("failed to get policy %s: %v", req.ID, err), req.ErrCh)
+			if !wait(ctx, errorWait) {
+				return
+			}
+			continue
+		}
+
+		// If the index has not changed, the query returned because the wait
+		// time was reached, therefore start the next query.
+		if !blocking.IndexHasChanged(newIndex, index) {
+			continue
+		}
+		index = blocking.NextIndex(newIndex, index)
+
+		// An invalid policy is reported once, and the next query waits for
+		// the key to change.
+		p, err := s.decodePolicy(req.ID, body, newIndex)
+		if err != nil {
+			policy.HandleSourceError(s.Name(), fmt.Errorf("failed to get policy %s: %v", req.ID, err), req.ErrCh)
+			continue
+		}
+
+		if reflect.DeepEqual(p, current) {
+			continue
+		}
+		current = p
+
+		select {
+		case req.ResultCh <- *p:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// listPolicyIDs performs a blocking query listing the keys under the prefix.
+// A prefix without any keys holds no policies.
+func (s *Source) listPolicyIDs(ctx context.Context, index uint64) (*listResponse, error) {
+	params := url.Values{"keys": {""}, "separator": {"/"}}
+
+	body, newIndex, err := s.get(ctx, s.prefix+"/", params, index)
+	if err != nil {
+		if err == errKeyNotFound {
+			return &listResponse{index: newIndex}, nil
+		}
+		return nil, err
+	}
+
+	var keys []string
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode keys: %v", err)
+	}
+	return &listResponse{keys: keys, index: newIndex}, nil
+}
+
+// decodePolicy decodes and validates an individual policy read from the KV
+// store at index.
+func (s *Source) decodePolicy(ID policy.PolicyID, body []byte, index uint64) (*sdk.ScalingPolicy, error) {
+	// The filename suffix is used by the decoder to identify the format.
+	filename := ID.String() + ".hcl"
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "{") {
+		filename = ID.String() + ".json"
+	}
+
+	p := &sdk.ScalingPolicy{}
+	if err := file.Decode(filename, body, p); err != nil {
+		return nil, fmt.Errorf("failed to decode policy: %v", err)
+	}
+	p.ID = ID.String()
+	s.policyProcessor.ApplyPolicyDefaults(p)
+
+	// The index of a single key is the index it was last modified at, which
+	// identifies the version of the policy.
+	p.Revision = strconv.FormatUint(index, 10)
+
+	if err := s.policyProcessor.ValidatePolicy(p); err != nil {
+		return nil, fmt.Errorf("failed to validate policy: %v", err)
+	}
+
+	for _, c := range p.Checks {
+		s.policyProcessor.CanonicalizeCheck(c, p.Target)
+	}
+
+	return p, nil
+}
+
+// get performs a blocking query reading the key from the KV store, returning
+// the response body and index. The index is also returned along with
+// errKeyNotFound, so the query for a missing key can block.
+func (s *Source) get(ctx context.Context, key string, params url.Values, index uint64) ([]byte, uint64, error) {
+	params.Set("index", strconv.FormatUint(index, 10))
+	params.Set("wait", waitTime.String())
+
+	u := s.address + "/v1/kv/" + key + "?" + params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req = req.WithContext(ctx)
+
+	if s.token != "" {
+		req.Header.Set(headerToken, s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	newIndex, err := parseIndex(resp.Header.Get(headerIndex))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, newIndex, errKeyNotFound
+	default:
+		return nil, 0, fmt.Errorf("unexpected response code %d from Consul", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response body: %v", err)
+	}
+	return body, newIndex, nil
+}
+
+// parseIndex parses the index header of a Consul response.
+func parseIndex(val string) (uint64, error) {
+	if val == "" {
+		return 0, fmt.Errorf("missing %s header", headerIndex)
+	}
+
+	index, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s header: %v", headerIndex, err)
+	}
+	return index, nil
+}
+
+// parsePolicyIDs returns the policy IDs held by the keys listed under the
+// prefix. Only keys directly under the prefix are policies, so the folders
+// listed using the separator are ignored.
+func parsePolicyIDs(prefix string, keys []string) []policy.PolicyID {
+	ids := make([]policy.PolicyID, 0, len(keys))
+
+	for _, key := range keys {
+		id := strings.TrimPrefix(key, prefix+"/")
+		if id == "" || id == key || strings.Contains(id, "/") {
+			continue
+		}
+		ids = append(ids, policy.PolicyID(id))
+	}
+	return ids
+}
+
+// newTLSConfig returns the TLS configuration used to communicate with Consul,
+// or nil if the config does not set any TLS parameters.
+func newTLSConfig(cfg *Config) (*tls.Config, error) {
+	if cfg.CACert == "" && cfg.ClientCert == "" && cfg.ClientKey == "" &&
+		cfg.TLSServerName == "" && !cfg.SkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.TLSServerName,
+		InsecureSkipVerify: cfg.SkipVerify,
+	}
+
+	if cfg.CACert != "" {
+		pem, err := ioutil.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA cert: %v", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA cert %q", cfg.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// wait blocks for d, returning false if the context is closed first.
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,271 @@
+package consul
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPolicy = `
+enabled = true
+min     = 1
+max     = 10
+
+policy {
+  cooldown            = "1m"
+  evaluation_interval = "10s"
+
+  check "cpu" {
+    source = "prometheus"
+    query  = "cpu"
+
+    strategy "target-value" {
+      target = "80"
+    }
+  }
+
+  target "aws-asg" {
+    aws_asg_name = "my-asg"
+  }
+}
+`
+
+const testPolicyJSON = `
+{
+  "enabled": true,
+  "min": 1,
+  "max": 5,
+  "policy": {
+    "check": {
+      "cpu": {
+        "source": "prometheus",
+        "query": "cpu",
+        "strategy": {
+          "target-value": {
+            "target": 80
+          }
+        }
+      }
+    },
+    "target": {
+      "aws-asg": {
+        "aws_asg_name": "my-asg"
+      }
+    }
+  }
+}
+`
+
+func newTestSource(t *testing.T, handler http.HandlerFunc) (*Source, *httptest.Server) {
+	srv := httptest.NewServer(handler)
+
+	processor := policy.NewProcessor(&policy.ConfigDefaults{
+		DefaultEvaluationInterval: 10 * time.Second,
+		DefaultCooldown:           time.Minute,
+	}, []string{"nomad-apm"})
+
+	s, err := NewConsulSource(hclog.NewNullLogger(), &Config{Address: srv.URL, Prefix: "/policies/", Token: "secret"}, processor)
+	assert.Nil(t, err)
+	return s, srv
+}
+
+func TestSource_listPolicyIDs(t *testing.T) {
+	found := true
+
+	s, srv := newTestSource(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerToken) != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "/v1/kv/policies/", r.URL.Path)
+		assert.Equal(t, "/", r.URL.Query().Get("separator"))
+		assert.Equal(t, "3", r.URL.Query().Get("index"))
+
+		w.Header().Set(headerIndex, "7")
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`["policies/a", "policies/b"]`))
+	})
+	defer srv.Close()
+
+	resp, err := s.listPolicyIDs(context.Background(), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, &listResponse{keys: []string{"policies/a", "policies/b"}, index: 7}, resp)
+
+	// A prefix without keys is not an error, and the index is kept so the
+	// next query blocks.
+	found = false
+	resp, err = s.listPolicyIDs(context.Background(), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, &listResponse{index: 7}, resp)
+}
+
+func TestSource_MonitorIDs(t *testing.T) {
+	var lock sync.Mutex
+	var indexes []string
+	blocked := make(chan struct{})
+
+	s, srv := newTestSource(t, func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		indexes = append(indexes, r.URL.Query().Get("index"))
+		n := len(indexes)
+		lock.Unlock()
+
+		switch n {
+		case 1:
+			w.Header().Set(headerIndex, "5")
+			_, _ = w.Write([]byte(`["policies/a"]`))
+		case 2:
+			// The wait time was reached without changes.
+			w.Header().Set(headerIndex, "5")
+			_, _ = w.Write([]byte(`["policies/a"]`))
+		case 3:
+			w.Header().Set(headerIndex, "9")
+			_, _ = w.Write([]byte(`["policies/a", "policies/b"]`))
+		default:
+			close(blocked)
+			<-r.Context().Done()
+		}
+	})
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resultCh := make(chan policy.IDMessage)
+	go s.MonitorIDs(ctx, policy.MonitorIDsReq{ErrCh: make(chan error, 1), ResultCh: resultCh})
+
+	assert.Equal(t, policy.IDMessage{IDs: []policy.PolicyID{"a"}, Source: policy.SourceNameConsul}, <-resultCh)
+	assert.Equal(t, policy.IDMessage{IDs: []policy.PolicyID{"a", "b"}, Source: policy.SourceNameConsul}, <-resultCh)
+
+	<-blocked
+	cancel()
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{"1", "5", "5", "9"}, indexes)
+}
+
+func TestSource_decodePolicy(t *testing.T) {
+	s, srv := newTestSource(t, nil)
+	defer srv.Close()
+
+	p, err := s.decodePolicy("hcl", []byte(testPolicy), 12)
+	assert.Nil(t, err)
+	assert.Equal(t, "hcl", p.ID)
+	assert.Equal(t, "12", p.Revision)
+	assert.Equal(t, int64(10), p.Max)
+	assert.Equal(t, "aws-asg", p.Target.Name)
+	assert.Len(t, p.Checks, 1)
+
+	p, err = s.decodePolicy("json", []byte(testPolicyJSON), 13)
+	assert.Nil(t, err)
+	assert.Equal(t, "json", p.ID)
+	assert.Equal(t, "13", p.Revision)
+	assert.Equal(t, int64(5), p.Max)
+	assert.Len(t, p.Checks, 1)
+
+	_, err = s.decodePolicy("invalid", []byte(`min = `), 14)
+	assert.Error(t, err)
+}
+
+func Test_parsePolicyIDs(t *testing.T) {
+	testCases := []struct {
+		inputPrefix    string
+		inputKeys      []string
+		expectedOutput []policy.PolicyID
+		name           string
+	}{
+		{
+			inputPrefix:    "policies",
+			inputKeys:      []string{"policies/a", "policies/b"},
+			expectedOutput: []policy.PolicyID{"a", "b"},
+			name:           "policy keys",
+		},
+		{
+			inputPrefix:    "policies",
+			inputKeys:      []string{"policies/", "policies/a", "policies/nested/"},
+			expectedOutput: []policy.PolicyID{"a"},
+			name:           "prefix and folder keys ignored",
+		},
+		{
+			inputPrefix:    "policies",
+			inputKeys:      []string{"other/a", "policies/b"},
+			expectedOutput: []policy.PolicyID{"b"},
+			name:           "keys outside prefix ignored",
+		},
+		{
+			inputPrefix:    "policies",
+			inputKeys:      nil,
+			expectedOutput: []policy.PolicyID{},
+			name:           "no keys",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, parsePolicyIDs(tc.inputPrefix, tc.inputKeys), tc.name)
+		})
+	}
+}
+
+func Test_parseIndex(t *testing.T) {
+	testCases := []struct {
+		inputValue     string
+		expectedOutput uint64
+		expectError    bool
+		name           string
+	}{
+		{
+			inputValue:     "42",
+			expectedOutput: 42,
+			name:           "valid index",
+		},
+		{
+			inputValue:  "",
+			expectError: true,
+			name:        "missing index",
+		},
+		{
+			inputValue:  "-1",
+			expectError: true,
+			name:        "negative index",
+		},
+		{
+			inputValue:  "abc",
+			expectError: true,
+			name:        "malformed index",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := parseIndex(tc.inputValue)
+			assert.Equal(t, tc.expectError, err != nil, tc.name)
+			assert.Equal(t, tc.expectedOutput, out, tc.name)
+		})
+	}
+}
+
+func Test_newTLSConfig(t *testing.T) {
+	tlsConfig, err := newTLSConfig(&Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, tlsConfig)
+
+	tlsConfig, err = newTLSConfig(&Config{TLSServerName: "consul.example.com", SkipVerify: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "consul.example.com", tlsConfig.ServerName)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+
+	_, err = newTLSConfig(&Config{CACert: "/does/not/exist.pem"})
+	assert.Error(t, err)
+}
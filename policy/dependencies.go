@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"sync"
+)
+
+// dependencyGraph tracks the policies each policy depends upon, so cycles can
+// be detected as the policies are loaded. It is updated by the policy
+// handlers as they receive their policy, so it has its own lock rather than
+// relying on the lock of the manager.
+type dependencyGraph struct {
+	lock sync.RWMutex
+	deps map[PolicyID][]PolicyID
+}
+
+// newDependencyGraph returns an empty dependencyGraph.
+func newDependencyGraph() *dependencyGraph {
+	return &dependencyGraph{deps: make(map[PolicyID][]PolicyID)}
+}
+
+// set records the policies the policy depends upon, replacing those
+// previously recorded. The cycle the dependencies form, if any, is returned.
+func (g *dependencyGraph) set(id PolicyID, dependsOn []string) []PolicyID {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if len(dependsOn) == 0 {
+		delete(g.deps, id)
+		return nil
+	}
+
+	deps := make([]PolicyID, 0, len(dependsOn))
+	for _, dep := range dependsOn {
+		deps = append(deps, PolicyID(dep))
+	}
+	g.deps[id] = deps
+
+	return g.cycleLocked(id)
+}
+
+// remove removes the dependencies of the policy, such as once it is no
+// longer handled.
+func (g *dependencyGraph) remove(id PolicyID) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	delete(g.deps, id)
+}
+
+// cycle returns the dependency cycle the policy is part of, starting and
+// ending with the policy. Nil is returned if the policy is not part of a
+// cycle.
+func (g *dependencyGraph) cycle(id PolicyID) []PolicyID {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.cycleLocked(id)
+}
+
+// cycleLocked returns the dependency cycle the policy is part of, using a
+// depth-first search for a path from the policy back to itself.
+//
+// This method is not thread-safe so a lock should be acquired before calling
+// it.
+func (g *dependencyGraph) cycleLocked(id PolicyID) []PolicyID {
+	visited := make(map[PolicyID]bool)
+
+	var visit func(path []PolicyID) []PolicyID
+	visit = func(path []PolicyID) []PolicyID {
+		for _, dep := range g.deps[path[len(path)-1]] {
+			if dep == id {
+				return append(path, dep)
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+
+			next := make([]PolicyID, len(path), len(path)+1)
+			copy(next, path)
+			if cycle := visit(append(next, dep)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+
+	return visit([]PolicyID{id})
+}
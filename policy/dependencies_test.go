@@ -0,0 +1,81 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencyGraph_cycle(t *testing.T) {
+	testCases := []struct {
+		inputDeps     map[PolicyID][]string
+		inputID       PolicyID
+		expectedCycle []PolicyID
+		name          string
+	}{
+		{
+			inputDeps:     map[PolicyID][]string{"a": {"b"}, "b": {"c"}},
+			inputID:       "a",
+			expectedCycle: nil,
+			name:          "chain",
+		},
+		{
+			inputDeps:     map[PolicyID][]string{"a": {"b", "c"}, "b": {"c"}, "c": nil},
+			inputID:       "a",
+			expectedCycle: nil,
+			name:          "shared dependency",
+		},
+		{
+			inputDeps:     map[PolicyID][]string{"a": {"a"}},
+			inputID:       "a",
+			expectedCycle: []PolicyID{"a", "a"},
+			name:          "self dependency",
+		},
+		{
+			inputDeps:     map[PolicyID][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}},
+			inputID:       "b",
+			expectedCycle: []PolicyID{"b", "c", "a", "b"},
+			name:          "cycle",
+		},
+		{
+			inputDeps:     map[PolicyID][]string{"a": {"b"}, "b": {"c"}, "c": {"b"}},
+			inputID:       "a",
+			expectedCycle: nil,
+			name:          "depends on cycle",
+		},
+		{
+			inputDeps:     map[PolicyID][]string{"a": {"missing", "b"}, "b": {"a"}},
+			inputID:       "a",
+			expectedCycle: []PolicyID{"a", "b", "a"},
+			name:          "dependency not loaded",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := newDependencyGraph()
+			for id, deps := range tc.inputDeps {
+				g.set(id, deps)
+			}
+			assert.Equal(t, tc.expectedCycle, g.cycle(tc.inputID), tc.name)
+		})
+	}
+}
+
+func TestDependencyGraph_set(t *testing.T) {
+	g := newDependencyGraph()
+
+	assert.Nil(t, g.set("a", []string{"b"}))
+
+	// The policy closing the cycle is told about it.
+	assert.Equal(t, []PolicyID{"b", "a", "b"}, g.set("b", []string{"a"}))
+	assert.Equal(t, []PolicyID{"a", "b", "a"}, g.cycle("a"))
+
+	// Updating either policy breaks the cycle.
+	assert.Nil(t, g.set("b", nil))
+	assert.Nil(t, g.cycle("a"))
+
+	assert.Equal(t, []PolicyID{"b", "a", "b"}, g.set("b", []string{"a"}))
+	g.remove("a")
+	assert.Nil(t, g.cycle("b"))
+}
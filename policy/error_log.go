@@ -0,0 +1,160 @@
+package policy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+)
+
+const (
+	// DefaultErrorLogSummaryInterval is the default interval at which a
+	// summary of suppressed duplicate errors is logged.
+	DefaultErrorLogSummaryInterval = 5 * time.Minute
+
+	// DefaultErrorLogMaxEntries is the default number of keys for which the
+	// last logged error is tracked.
+	DefaultErrorLogMaxEntries = 1024
+)
+
+// ErrorLogDeduper deduplicates errors which are logged repeatedly, such as
+// when a plugin is unavailable and every evaluation of a policy fails in the
+// same way. The first occurrence of an error is logged in full, identical
+// errors logged for the same key are then suppressed, and a summary of the
+// suppressed errors is logged periodically and once the error clears.
+//
+// The number of tracked keys is bounded, evicting the least recently seen
+// key once the limit is reached. It is safe for concurrent use.
+type ErrorLogDeduper struct {
+	interval   time.Duration
+	maxEntries int
+
+	lock    sync.Mutex
+	entries map[string]*errorLogEntry
+}
+
+// errorLogEntry is the last error logged for a key.
+type errorLogEntry struct {
+	level     hclog.Level
+	msg       string
+	args      []interface{}
+	signature string
+
+	// count is the number of occurrences since the error was first logged,
+	// and suppressed the number not logged since periodStart.
+	count       int
+	suppressed  int
+	periodStart time.Time
+	lastSeen    time.Time
+}
+
+// NewErrorLogDeduper returns a new ErrorLogDeduper which logs a summary of
+// suppressed errors every interval and tracks up to maxEntries keys. A
+// maxEntries lower than one is treated as one.
+func NewErrorLogDeduper(interval time.Duration, maxEntries int) *ErrorLogDeduper {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+
+	return &ErrorLogDeduper{
+		interval:   interval,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*errorLogEntry),
+	}
+}
+
+// Log logs the message at the level, unless it is identical to the last
+// message logged for the key, in which case it is suppressed. Messages are
+// identical if both the message and the args match. A nil ErrorLogDeduper
+// logs every message.
+func (d *ErrorLogDeduper) Log(logger hclog.Logger, level hclog.Level, key, msg string, args ...interface{}) {
+	if d == nil {
+		logger.Log(level, msg, args...)
+		return
+	}
+
+	now := time.Now()
+	signature := fmt.Sprintf("%s %v", msg, args)
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	e, ok := d.entries[key]
+	if ok && e.signature == signature {
+		e.count++
+		e.suppressed++
+		e.lastSeen = now
+
+		if now.Sub(e.periodStart) >= d.interval {
+			d.logSummary(logger, e, now)
+		}
+		return
+	}
+
+	if ok {
+		// A different error replaces the last one, so account for the
+		// occurrences of the last error which were not logged yet.
+		d.logSummary(logger, e, now)
+	} else if len(d.entries) >= d.maxEntries {
+		d.evictOldest()
+	}
+
+	logger.Log(level, msg, args...)
+	d.entries[key] = &errorLogEntry{
+		level:       level,
+		msg:         msg,
+		args:        args,
+		signature:   signature,
+		count:       1,
+		periodStart: now,
+		lastSeen:    now,
+	}
+}
+
+// Clear records that the error logged for the key, if any, has cleared. The
+// suppressed occurrences are summarised, and the error is logged as cleared.
+func (d *ErrorLogDeduper) Clear(logger hclog.Logger, key string) {
+	if d == nil {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	e, ok := d.entries[key]
+	if !ok {
+		return
+	}
+	delete(d.entries, key)
+
+	d.logSummary(logger, e, time.Now())
+	logger.Info("error cleared", "error", e.msg, "occurrences", e.count)
+}
+
+// logSummary logs the number of occurrences of the error which were suppressed
+// since the start of the current period, and starts a new period.
+func (d *ErrorLogDeduper) logSummary(logger hclog.Logger, e *errorLogEntry, now time.Time) {
+	if e.suppressed > 0 {
+		args := make([]interface{}, 0, len(e.args)+4)
+		args = append(args, e.args...)
+		args = append(args, "repeated", e.suppressed, "period", now.Sub(e.periodStart).Round(time.Second))
+		logger.Log(e.level, e.msg, args...)
+	}
+
+	e.suppressed = 0
+	e.periodStart = now
+}
+
+// evictOldest stops tracking the least recently seen key.
+func (d *ErrorLogDeduper) evictOldest() {
+	var oldestKey string
+	var oldest *errorLogEntry
+
+	for k, e := range d.entries {
+		if oldest == nil || e.lastSeen.Before(oldest.lastSeen) {
+			oldestKey, oldest = k, e
+		}
+	}
+	delete(d.entries, oldestKey)
+}
@@ -0,0 +1,150 @@
+package policy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLogLogger returns a logger which writes to the returned buffer.
+func newTestLogLogger() (hclog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := hclog.New(&hclog.LoggerOptions{Output: &buf, Level: hclog.Info})
+	return logger, &buf
+}
+
+// logLines returns the lines written to the buffer, stripped of their
+// timestamp so they can be compared.
+func logLines(buf *bytes.Buffer) []string {
+	out := strings.TrimSpace(buf.String())
+	if out == "" {
+		return nil
+	}
+
+	lines := strings.Split(out, "\n")
+	for i, l := range lines {
+		lines[i] = l[strings.Index(l, "["):]
+	}
+	return lines
+}
+
+func TestErrorLogDeduper(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputInterval time.Duration
+		inputRun      func(d *ErrorLogDeduper, l hclog.Logger)
+		expectedLines []string
+	}{
+		{
+			name:          "duplicates suppressed",
+			inputInterval: time.Hour,
+			inputRun: func(d *ErrorLogDeduper, l hclog.Logger) {
+				for i := 0; i < 3; i++ {
+					d.Log(l, hclog.Error, "a", "failed", "err", "boom")
+				}
+			},
+			expectedLines: []string{
+				"[ERROR] failed: err=boom",
+			},
+		},
+		{
+			name:          "keys deduplicated separately",
+			inputInterval: time.Hour,
+			inputRun: func(d *ErrorLogDeduper, l hclog.Logger) {
+				d.Log(l, hclog.Error, "a", "failed", "err", "boom")
+				d.Log(l, hclog.Error, "b", "failed", "err", "boom")
+				d.Log(l, hclog.Error, "a", "failed", "err", "boom")
+			},
+			expectedLines: []string{
+				"[ERROR] failed: err=boom",
+				"[ERROR] failed: err=boom",
+			},
+		},
+		{
+			name:          "summary logged once interval passed",
+			inputInterval: 0,
+			inputRun: func(d *ErrorLogDeduper, l hclog.Logger) {
+				d.Log(l, hclog.Error, "a", "failed", "err", "boom")
+				d.Log(l, hclog.Error, "a", "failed", "err", "boom")
+			},
+			expectedLines: []string{
+				"[ERROR] failed: err=boom",
+				"[ERROR] failed: err=boom repeated=1 period=0s",
+			},
+		},
+		{
+			name:          "different error logged in full",
+			inputInterval: time.Hour,
+			inputRun: func(d *ErrorLogDeduper, l hclog.Logger) {
+				d.Log(l, hclog.Error, "a", "failed", "err", "boom")
+				d.Log(l, hclog.Error, "a", "failed", "err", "boom")
+				d.Log(l, hclog.Warn, "a", "failed", "err", "bang")
+			},
+			expectedLines: []string{
+				"[ERROR] failed: err=boom",
+				"[ERROR] failed: err=boom repeated=1 period=0s",
+				"[WARN]  failed: err=bang",
+			},
+		},
+		{
+			name:          "cleared error",
+			inputInterval: time.Hour,
+			inputRun: func(d *ErrorLogDeduper, l hclog.Logger) {
+				d.Log(l, hclog.Error, "a", "failed", "err", "boom")
+				d.Log(l, hclog.Error, "a", "failed", "err", "boom")
+				d.Clear(l, "a")
+				d.Clear(l, "a")
+				d.Log(l, hclog.Error, "a", "failed", "err", "boom")
+			},
+			expectedLines: []string{
+				"[ERROR] failed: err=boom",
+				"[ERROR] failed: err=boom repeated=1 period=0s",
+				"[INFO]  error cleared: error=failed occurrences=2",
+				"[ERROR] failed: err=boom",
+			},
+		},
+		{
+			name:          "oldest key evicted",
+			inputInterval: time.Hour,
+			inputRun: func(d *ErrorLogDeduper, l hclog.Logger) {
+				d.maxEntries = 2
+				d.Log(l, hclog.Error, "a", "failed a")
+				d.Log(l, hclog.Error, "b", "failed b")
+				d.Log(l, hclog.Error, "c", "failed c")
+				d.Log(l, hclog.Error, "b", "failed b")
+				d.Log(l, hclog.Error, "a", "failed a")
+			},
+			expectedLines: []string{
+				"[ERROR] failed a",
+				"[ERROR] failed b",
+				"[ERROR] failed c",
+				"[ERROR] failed a",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger, buf := newTestLogLogger()
+			d := NewErrorLogDeduper(tc.inputInterval, DefaultErrorLogMaxEntries)
+
+			tc.inputRun(d, logger)
+			assert.Equal(t, tc.expectedLines, logLines(buf), tc.name)
+		})
+	}
+}
+
+func TestErrorLogDeduper_nil(t *testing.T) {
+	logger, buf := newTestLogLogger()
+
+	var d *ErrorLogDeduper
+	d.Log(logger, hclog.Error, "a", "failed")
+	d.Log(logger, hclog.Error, "a", "failed")
+	d.Clear(logger, "a")
+
+	assert.Equal(t, []string{"[ERROR] failed", "[ERROR] failed"}, logLines(buf))
+}
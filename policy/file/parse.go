@@ -1,6 +1,10 @@
 package file
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
 	"time"
 
 	"github.com/hashicorp/hcl/v2/hclsimple"
@@ -8,10 +12,21 @@ import (
 )
 
 func decodeFile(file string, p *sdk.ScalingPolicy) error {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return Decode(file, src, p)
+}
+
+// Decode decodes the scaling policy document held in src into p. The filename
+// is used to determine whether src is HCL or JSON, based on its suffix, and
+// within error messages.
+func Decode(filename string, src []byte, p *sdk.ScalingPolicy) error {
 
 	decodePolicy := &sdk.FileDecodeScalingPolicy{}
 
-	if err := hclsimple.DecodeFile(file, nil, decodePolicy); err != nil {
+	if err := hclsimple.Decode(filename, src, nil, decodePolicy); err != nil {
 		return err
 	}
 
@@ -37,6 +52,78 @@ func decodeFile(file string, p *sdk.ScalingPolicy) error {
 		decodePolicy.Doc.EvaluationInterval = d
 	}
 
+	if decodePolicy.Doc.MaxEvaluationIntervalHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.MaxEvaluationIntervalHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.MaxEvaluationInterval = d
+	}
+
+	if decodePolicy.Doc.WarmupPeriodHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.WarmupPeriodHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.WarmupPeriod = d
+	}
+
+	if decodePolicy.Doc.VerifyScaleAfterHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.VerifyScaleAfterHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.VerifyScaleAfter = d
+	}
+
+	if decodePolicy.Doc.StabilizeCountDelayHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.StabilizeCountDelayHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.StabilizeCountDelay = d
+	}
+
+	if decodePolicy.Doc.ScaleDownStabilizationWindowHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.ScaleDownStabilizationWindowHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.ScaleDownStabilizationWindow = d
+	}
+
+	if decodePolicy.Doc.ConsecutiveScaleDownsResetHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.ConsecutiveScaleDownsResetHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.ConsecutiveScaleDownsReset = d
+	}
+
+	if decodePolicy.Doc.ScaleDownStepDelayHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.ScaleDownStepDelayHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.ScaleDownStepDelay = d
+	}
+
+	if decodePolicy.Doc.ScaleUpProbationHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.ScaleUpProbationHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.ScaleUpProbation = d
+	}
+
+	if decodePolicy.Doc.DependsOnWindowHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.DependsOnWindowHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.DependsOnWindow = d
+	}
+
 	// Parse query window for each check.
 	for i := 0; i < len(decodePolicy.Doc.Checks); i++ {
 		check := decodePolicy.Doc.Checks[i]
@@ -53,9 +140,30 @@ func decodeFile(file string, p *sdk.ScalingPolicy) error {
 		decodePolicy.Doc.Checks[i].QueryWindow = w
 	}
 
+	// Parse the duration of each scheduled minimum.
+	for _, s := range decodePolicy.Doc.ScheduledMins {
+		d, err := time.ParseDuration(s.DurationHCL)
+		if err != nil {
+			return err
+		}
+		s.Duration = d
+	}
+
 	// Translate from our intermediate struct, to our internal flattened
 	// policy.
 	decodePolicy.Translate(p)
+	p.Revision = revision(src)
 
 	return nil
 }
+
+// revision returns the git blob SHA of the policy document, which matches the
+// output of git hash-object for the file it was read from. This allows the
+// scaling performed by a policy to be traced back to the committed version of
+// its file.
+func revision(src []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(src))
+	h.Write(src)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -1,10 +1,12 @@
 package file
 
 import (
+	"io/ioutil"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/ptr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,6 +29,11 @@ func Test_decodeFile(t *testing.T) {
 				Max:                100,
 				Cooldown:           10 * time.Minute,
 				EvaluationInterval: 1 * time.Minute,
+				Priority:           10,
+				Labels: map[string]string{
+					"team":    "platform",
+					"service": "batch",
+				},
 				Checks: []*sdk.ScalingPolicyCheck{
 					{
 						Name:        "cpu_nomad",
@@ -68,13 +75,46 @@ func Test_decodeFile(t *testing.T) {
 			inputFile:   "./test-fixtures/full-task-group-policy.hcl",
 			inputPolicy: &sdk.ScalingPolicy{},
 			expectedOutputPolicy: &sdk.ScalingPolicy{
-				ID:                 "",
-				Type:               sdk.ScalingPolicyTypeHorizontal,
-				Enabled:            true,
-				Min:                1,
-				Max:                10,
-				Cooldown:           1 * time.Minute,
-				EvaluationInterval: 30 * time.Second,
+				ID:                           "",
+				Type:                         sdk.ScalingPolicyTypeHorizontal,
+				Enabled:                      true,
+				Min:                          1,
+				Max:                          10,
+				Cooldown:                     1 * time.Minute,
+				EvaluationInterval:           30 * time.Second,
+				MaxEvaluationInterval:        5 * time.Minute,
+				StableEvaluations:            4,
+				VerifyScaleAfter:             2 * time.Minute,
+				RollbackOnVerifyFailure:      true,
+				FallbackStrategy:             sdk.FallbackStrategyHold,
+				OutOfBoundsAction:            sdk.OutOfBoundsActionWait,
+				Rounding:                     sdk.RoundingModeCeil,
+				StabilizeCount:               true,
+				StabilizeCountDelay:          10 * time.Second,
+				ScaleDownStabilizationWindow: 5 * time.Minute,
+				MinHealthyPercentage:         75,
+				MaxConsecutiveScaleDowns:     3,
+				ConsecutiveScaleDownsReset:   time.Hour,
+				ScaleDownStep:                2,
+				ScaleDownStepDelay:           10 * time.Minute,
+				ScaleUpProbation:             5 * time.Minute,
+				DependsOn:                    []string{"example-upstream"},
+				DependsOnWindow:              2 * time.Minute,
+				Cron:                         "*/10 8-18 * * 1-5",
+				CronTimeZone:                 "Europe/Amsterdam",
+				DeferDuringDeployment:        true,
+				Advisory:                     true,
+				MetricMin:                    ptr.Float64ToPtr(0),
+				MetricMax:                    ptr.Float64ToPtr(100),
+				MetricUnit:                   sdk.MetricUnitPercent,
+				ScheduledMins: []*sdk.ScalingPolicyScheduledMin{{
+					Name:        "business-hours",
+					Cron:        "0 0 8 * * 1-5 *",
+					TimeZone:    "Europe/Amsterdam",
+					Duration:    10 * time.Hour,
+					DurationHCL: "10h",
+					Min:         4,
+				}},
 				Checks: []*sdk.ScalingPolicyCheck{
 					{
 						Name:   "cpu_nomad",
@@ -86,11 +126,16 @@ func Test_decodeFile(t *testing.T) {
 								"target": "80",
 							},
 						},
+						Transforms: []*sdk.ScalingPolicyTransform{
+							{Name: "scale", Config: map[string]string{"factor": "100"}},
+							{Name: "moving_average", Config: map[string]string{"points": "3"}},
+						},
 					},
 					{
-						Name:   "memory_nomad",
-						Source: "nomad_apm",
-						Query:  "avg_memory",
+						Name:            "memory_nomad",
+						Source:          "nomad_apm",
+						FailoverSources: []string{"prometheus"},
+						Query:           "avg_memory",
 						Strategy: &sdk.ScalingPolicyStrategy{
 							Name: "target-value",
 							Config: map[string]string{
@@ -98,6 +143,18 @@ func Test_decodeFile(t *testing.T) {
 							},
 						},
 					},
+					{
+						Name:             "fleet",
+						Source:           "prometheus",
+						Query:            "count(nomad_client_uptime)",
+						TargetPercentage: 20,
+						Strategy: &sdk.ScalingPolicyStrategy{
+							Name: "target-value",
+							Config: map[string]string{
+								"items_per_instance": "1",
+							},
+						},
+					},
 				},
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "nomad",
@@ -106,6 +163,14 @@ func Test_decodeFile(t *testing.T) {
 						"Job":   "example",
 					},
 				},
+				ShadowTarget: &sdk.ScalingPolicyTarget{
+					Name: "nomad-next",
+					Config: map[string]string{
+						"Group":   "cache",
+						"Job":     "example-next",
+						"dry-run": "true",
+					},
+				},
 			},
 			expectedOutputError: nil,
 			name:                "full parsable task group scaling policy",
@@ -115,6 +180,16 @@ func Test_decodeFile(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			actualError := decodeFile(tc.inputFile, tc.inputPolicy)
+
+			// The revision is the hash of the fixture content, which is
+			// covered by Test_revision.
+			if actualError == nil {
+				src, err := ioutil.ReadFile(tc.inputFile)
+				assert.NoError(t, err, tc.name)
+				assert.Equal(t, revision(src), tc.inputPolicy.Revision, tc.name)
+				tc.inputPolicy.Revision = ""
+			}
+
 			assert.Equal(t, tc.expectedOutputPolicy, tc.inputPolicy, tc.name)
 			assert.Equal(t, tc.expectedOutputError, actualError, tc.name)
 
@@ -125,3 +200,69 @@ func Test_decodeFile(t *testing.T) {
 		})
 	}
 }
+
+func TestDecode_minMax(t *testing.T) {
+	testCases := []struct {
+		inputSrc           string
+		expectedMin        int64
+		expectedMax        int64
+		expectedMinOmitted bool
+		expectedMaxOmitted bool
+		name               string
+	}{
+		{
+			inputSrc:    "min = 1\nmax = 10\npolicy {}\n",
+			expectedMin: 1,
+			expectedMax: 10,
+			name:        "min and max set",
+		},
+		{
+			inputSrc:    "min = 0\nmax = 0\npolicy {}\n",
+			expectedMin: 0,
+			expectedMax: 0,
+			name:        "explicit zero min and max",
+		},
+		{
+			inputSrc:           "policy {}\n",
+			expectedMinOmitted: true,
+			expectedMaxOmitted: true,
+			name:               "omitted min and max",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &sdk.ScalingPolicy{}
+			assert.NoError(t, Decode("policy.hcl", []byte(tc.inputSrc), p), tc.name)
+			assert.Equal(t, tc.expectedMin, p.Min, tc.name)
+			assert.Equal(t, tc.expectedMax, p.Max, tc.name)
+			assert.Equal(t, tc.expectedMinOmitted, p.MinOmitted, tc.name)
+			assert.Equal(t, tc.expectedMaxOmitted, p.MaxOmitted, tc.name)
+		})
+	}
+}
+
+func Test_revision(t *testing.T) {
+	testCases := []struct {
+		inputSrc         string
+		expectedRevision string
+		name             string
+	}{
+		{
+			inputSrc:         "",
+			expectedRevision: "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391",
+			name:             "empty document",
+		},
+		{
+			inputSrc:         "policy {}\n",
+			expectedRevision: "48d1e2ea3d9797176b70f64ed2162a1f3855d355",
+			name:             "policy document",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedRevision, revision([]byte(tc.inputSrc)), tc.name)
+		})
+	}
+}
@@ -7,6 +7,12 @@ policy {
 
   cooldown            = "10m"
   evaluation_interval = "1m"
+  priority            = 10
+
+  labels = {
+    team    = "platform"
+    service = "batch"
+  }
 
   check "cpu_nomad" {
     source       = "nomad_apm"
@@ -8,6 +8,47 @@ policy {
   cooldown            = "1m"
   evaluation_interval = "30s"
 
+  max_evaluation_interval = "5m"
+  stable_evaluations      = 4
+
+  verify_scale_after  = "2m"
+
+  rollback_on_verify_failure = true
+  fallback_strategy   = "hold"
+  out_of_bounds_action = "wait"
+  rounding            = "ceil"
+
+  stabilize_count       = true
+  stabilize_count_delay = "10s"
+
+  defer_during_deployment = true
+  advisory                = true
+
+  metric_min  = 0
+  metric_max  = 100
+  metric_unit = "percent"
+
+  scale_down_stabilization_window = "5m"
+  min_healthy_percentage          = 75
+  max_consecutive_scale_downs     = 3
+  consecutive_scale_downs_reset   = "1h"
+  scale_down_step                 = 2
+  scale_down_step_delay           = "10m"
+  scale_up_probation              = "5m"
+
+  depends_on        = ["example-upstream"]
+  depends_on_window = "2m"
+
+  cron      = "*/10 8-18 * * 1-5"
+  time_zone = "Europe/Amsterdam"
+
+  scheduled_min "business-hours" {
+    cron      = "0 0 8 * * 1-5 *"
+    time_zone = "Europe/Amsterdam"
+    duration  = "10h"
+    min       = 4
+  }
+
   check "cpu_nomad" {
     source = "nomad_apm"
     query  = "avg_cpu"
@@ -15,19 +56,44 @@ policy {
     strategy "target-value" {
       target = "80"
     }
+
+    transform "scale" {
+      factor = 100
+    }
+
+    transform "moving_average" {
+      points = 3
+    }
   }
 
   check "memory_nomad" {
-    source = "nomad_apm"
-    query  = "avg_memory"
+    source           = "nomad_apm"
+    failover_sources = ["prometheus"]
+    query            = "avg_memory"
 
     strategy "target-value" {
       target = "80"
     }
   }
 
+  check "fleet" {
+    source            = "prometheus"
+    query             = "count(nomad_client_uptime)"
+    target_percentage = 20
+
+    strategy "target-value" {
+      items_per_instance = "1"
+    }
+  }
+
   target "nomad" {
     Group = "cache"
     Job   = "example"
   }
+
+  shadow_target "nomad-next" {
+    Group     = "cache"
+    Job       = "example-next"
+    dry-run   = "true"
+  }
 }
@@ -3,21 +3,45 @@ package policy
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/cronexpr"
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad-autoscaler/plugins"
 	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
 	targetpkg "github.com/hashicorp/nomad-autoscaler/plugins/target"
 	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/clock"
 )
 
 const (
 	cooldownIgnoreTime = 1 * time.Second
+
+	// defaultStableEvaluations is the number of evaluations in a row without
+	// a scaling action after which the adaptive evaluation interval starts
+	// lengthening, if the policy does not set one.
+	defaultStableEvaluations = 3
+
+	// tickEarlyFactor and tickLateFactor bound the time between ticks, as a
+	// fraction of the tick interval, outside of which the tick is considered
+	// the result of missed or bunched ticks. The wall clock may drift from
+	// the elapsed time by tickEarlyFactor before it is considered to have
+	// jumped.
+	tickEarlyFactor = 0.5
+	tickLateFactor  = 2
+)
+
+// The keys of the errors deduplicated by a handler.
+const (
+	errorLogKeyMonitor = "monitor"
+	errorLogKeyTick    = "tick"
+	errorLogKeyQuery   = "query"
+	errorLogKeyCycle   = "cycle"
 )
 
 // Handler monitors a policy for changes and controls when them are sent for
@@ -25,6 +49,10 @@ const (
 type Handler struct {
 	log hclog.Logger
 
+	// errLogs deduplicates the errors logged each time the policy is
+	// monitored or evaluated, so a sustained failure does not flood the logs.
+	errLogs *ErrorLogDeduper
+
 	// policyID is the ID of the policy the handler is responsible for.
 	policyID PolicyID
 
@@ -36,16 +64,55 @@ type Handler struct {
 	// is responsible for.
 	policySource Source
 
-	// ticker controls the frequency the policy is sent for evaluation.
-	ticker *time.Ticker
+	// validateQueries validates the queries of the policy checks each time
+	// the policy is received, if set. invalidQueries is the reason the
+	// queries of the current policy are invalid, which stops the policy from
+	// being evaluated, and is protected by stateLock.
+	validateQueries func(*sdk.ScalingPolicy) error
+	invalidQueries  string
+
+	// dependencies tracks the policies each policy depends upon, and is
+	// shared by the handlers of the manager. The policy is not evaluated
+	// while its dependencies form a cycle. It is nil if the handler is not
+	// run by a manager.
+	dependencies *dependencyGraph
+
+	// seq orders the handler by when it was created by the manager.
+	seq uint64
+
+	// ticker controls the frequency the policy is sent for evaluation. If the
+	// policy sets an evaluation cron schedule, cronTimer fires at the next
+	// scheduled time instead. tickCh is the channel of whichever is in use.
+	ticker    clock.Ticker
+	cronTimer clock.Timer
+	tickCh    <-chan time.Time
+
+	// tickInterval is the interval of the ticker, which differs from the
+	// policy evaluation interval while the adaptive interval is lengthening
+	// it. It is only accessed by the Run Go routine.
+	tickInterval time.Duration
+
+	// lastTick is when the ticker last fired, or was started, and is used to
+	// detect clock jumps. It is zero when unknown, such as after a cooldown
+	// blocked the ticker. It is only accessed by the Run Go routine.
+	lastTick time.Time
+
+	// clock provides the current time and the timers used to schedule
+	// evaluations and enforce cooldowns, allowing tests to control time.
+	clock clock.Clock
+
+	// intervalCh is used to notify the handler that the adaptive evaluation
+	// interval may have changed.
+	intervalCh chan struct{}
 
 	// cooldownCh is used to notify the handler that it should enter a cooldown
 	// period.
 	cooldownCh chan time.Duration
 
-	// running is used to help keep track if the handler is active or not.
-	running     bool
-	runningLock sync.RWMutex
+	// stopped tracks whether the handler has been stopped. A handler can be
+	// stopped before it starts running, in which case it never runs.
+	stopped  bool
+	stopLock sync.Mutex
 
 	// ch is used to listen for policy updates.
 	ch chan sdk.ScalingPolicy
@@ -59,6 +126,135 @@ type Handler struct {
 	// reloadCh is used to communicate to the MonitorPolicy routine that it
 	// should perform a reload.
 	reloadCh chan struct{}
+
+	// reconciled tracks whether the target count has been reconciled with
+	// the policy bounds. It is set by the worker once the reconciliation
+	// succeeds, so it is protected by stateLock.
+	reconciled bool
+
+	// lastEvaluation and cooldownUntil track the handler state for debugging
+	// purposes and are protected by stateLock.
+	lastEvaluation time.Time
+	cooldownUntil  time.Time
+	warmupUntil    time.Time
+	stateLock      sync.RWMutex
+
+	// minEvaluationInterval is the shortest evaluation interval the policy is
+	// allowed to use, and flooredInterval the last interval requested by the
+	// policy which was raised to it. flooredInterval is used to only log the
+	// adjustment once for each requested interval.
+	minEvaluationInterval time.Duration
+	flooredInterval       time.Duration
+
+	// override is the operator requested count which replaces the strategy
+	// driven evaluation until it expires. It is protected by stateLock.
+	override *Override
+
+	// recommendations are the counts recommended by the recent evaluations
+	// of the policy, oldest first. They are used to stabilize scale downs
+	// and are protected by stateLock.
+	recommendations []recommendation
+
+	// stableEvaluations is the number of evaluations in a row which produced
+	// no scaling action, used to lengthen the adaptive evaluation interval.
+	// It is protected by stateLock.
+	stableEvaluations int64
+
+	// scaleDowns is the number of scale downs performed in a row by the
+	// policy checks, and lastScaleDown the time of the last one. They are
+	// used to limit consecutive scale downs and to delay the next step down,
+	// and are protected by stateLock. A scale up clears lastScaleDown.
+	scaleDowns    int64
+	lastScaleDown time.Time
+
+	// lastScaleUp is the time of the last scale up performed by the policy
+	// checks, used to suppress further scale ups during the probation of the
+	// new capacity. It is protected by stateLock.
+	lastScaleUp time.Time
+
+	// lastAction is the time of the last scaling action performed for the
+	// policy, and scaling whether one is in progress. They are used to defer
+	// the evaluation of the policies which depend upon this policy, and are
+	// protected by stateLock.
+	lastAction time.Time
+	scaling    bool
+
+	// policy is the last policy received from the source, and
+	// requestedInterval its evaluation interval before it was raised to the
+	// minimum. They are used to describe the policy and are protected by
+	// stateLock.
+	policy            *sdk.ScalingPolicy
+	requestedInterval time.Duration
+
+	// advice is the last recommendation of an advisory policy. It is
+	// protected by stateLock.
+	advice *Advice
+}
+
+// Override forces the target of a policy to an exact count, bypassing the
+// policy checks, until the expiry time is reached.
+type Override struct {
+	Count  int64
+	Expiry time.Time
+}
+
+// active returns whether the override has not yet expired.
+func (o *Override) active(now time.Time) bool {
+	return o != nil && now.Before(o.Expiry)
+}
+
+// Advice is the scaling action recommended by an evaluation of an advisory
+// policy, which is published rather than performed.
+type Advice struct {
+
+	// Count is the recommended count of the target, and CurrentCount the
+	// count of the target when the recommendation was made. They are equal
+	// when the evaluation recommends no change.
+	Count        int64
+	CurrentCount int64
+
+	Direction  string
+	Reason     string         `json:",omitempty"`
+	ReasonCode sdk.ReasonCode `json:",omitempty"`
+	Time       time.Time
+}
+
+// HandlerState is a point-in-time snapshot of the state of a policy handler.
+type HandlerState struct {
+	PolicyID       PolicyID
+	Source         SourceName
+	LastEvaluation time.Time
+	CooldownUntil  time.Time
+	WarmupUntil    time.Time
+
+	// WarmingUp indicates the policy is within its warmup period and will not
+	// be evaluated until it ends.
+	WarmingUp bool
+
+	// Override is the active count override of the policy, if any.
+	Override *Override
+
+	// Advice is the last recommendation of the policy, if it is advisory.
+	Advice *Advice
+
+	// InvalidQueries is the reason the queries of the policy checks are
+	// invalid, if they are. The policy is not evaluated until it is updated.
+	InvalidQueries string
+}
+
+// PolicyDescription is the resolved view of a policy as it is currently
+// handled by the agent.
+type PolicyDescription struct {
+	HandlerState
+
+	// Policy is the policy as last received from its source, with the policy
+	// defaults and the minimum evaluation interval applied.
+	Policy *sdk.ScalingPolicy
+
+	// RequestedEvaluationInterval is the evaluation interval set by the
+	// policy before it was raised to the minimum evaluation interval. It is
+	// zero if the interval was not raised.
+	RequestedEvaluationInterval time.Duration
 }
 
 // NewHandler returns a new handler for a policy.
@@ -66,13 +262,16 @@ func NewHandler(ID PolicyID, log hclog.Logger, pm *manager.PluginManager, ps Sou
 	return &Handler{
 		policyID:      ID,
 		log:           log.Named("policy_handler").With("policy_id", ID),
+		errLogs:       NewErrorLogDeduper(DefaultErrorLogSummaryInterval, 2),
 		pluginManager: pm,
 		policySource:  ps,
 		ch:            make(chan sdk.ScalingPolicy),
 		errCh:         make(chan error),
 		doneCh:        make(chan struct{}),
 		cooldownCh:    make(chan time.Duration),
+		intervalCh:    make(chan struct{}, 1),
 		reloadCh:      make(chan struct{}),
+		clock:         clock.Real(),
 	}
 }
 
@@ -83,12 +282,17 @@ func NewHandler(ID PolicyID, log hclog.Logger, pm *manager.PluginManager, ps Sou
 func (h *Handler) Run(ctx context.Context, evalCh chan<- *sdk.ScalingEvaluation) {
 	h.log.Trace("starting policy handler")
 
-	defer h.Stop()
+	// The manager may have stopped the handler while it was being set up,
+	// such as when its policy was removed right after being added.
+	h.stopLock.Lock()
+	stopped := h.stopped
+	h.stopLock.Unlock()
+	if stopped {
+		h.log.Trace("policy handler stopped before starting")
+		return
+	}
 
-	// Mark the handler as running.
-	h.runningLock.Lock()
-	h.running = true
-	h.runningLock.Unlock()
+	defer h.Stop()
 
 	// Store a local copy of the policy so we can compare it for changes.
 	var currentPolicy *sdk.ScalingPolicy
@@ -96,7 +300,11 @@ func (h *Handler) Run(ctx context.Context, evalCh chan<- *sdk.ScalingEvaluation)
 	// Start with a long ticker until we receive the right interval.
 	// TODO(luiz): make this a config param
 	policyReadTimeout := 3 * time.Minute
-	h.ticker = time.NewTicker(policyReadTimeout)
+	h.startTicker(policyReadTimeout)
+
+	// The ticker is only accessed by this Go routine, so it is stopped here
+	// rather than by Stop, which may be called from other Go routines.
+	defer h.stopTicker()
 
 	// Create separate context so we can stop the monitoring Go routine if
 	// doneCh is closed, but ctx is still valid.
@@ -131,29 +339,78 @@ func (h *Handler) Run(ctx context.Context, evalCh chan<- *sdk.ScalingEvaluation)
 				for i, e := range merr.Errors {
 					errors[i] = e.Error()
 				}
-				h.log.Error(errors[0], "errors", errors[1:])
+				h.errLogs.Log(h.log, hclog.Error, errorLogKeyMonitor, errors[0], "errors", errors[1:])
 			} else {
-				h.log.Error(err.Error())
+				h.errLogs.Log(h.log, hclog.Error, errorLogKeyMonitor, err.Error())
 			}
 			continue
 
 		case p := <-h.ch:
+			h.errLogs.Clear(h.log, errorLogKeyMonitor)
+
+			requested := p.EvaluationInterval
+			h.applyMinEvaluationInterval(&p)
 			h.updateHandler(currentPolicy, &p)
 			currentPolicy = &p
+			h.setPolicy(currentPolicy, requested)
+			h.checkQueries(currentPolicy)
+			h.checkDependencies(currentPolicy)
+			h.updateInterval(currentPolicy)
+
+		case <-h.intervalCh:
+			h.updateInterval(currentPolicy)
+
+		case <-h.tickCh:
+			// Cron schedules fire once, so schedule the next evaluation
+			// before handling this one. Ticks of an interval are checked
+			// against clock jumps instead.
+			if currentPolicy != nil && currentPolicy.Cron != "" {
+				h.scheduleCron(currentPolicy, h.clock.Now())
+			} else if h.skipTick(h.clock.Now()) {
+				continue
+			}
+
+			if h.warmingUp() {
+				h.log.Debug("policy is warming up, skipping evaluation")
+				IncrSuppressedCount(string(h.policyID), SuppressionReasonWarmup)
+				continue
+			}
+
+			if h.hasInvalidQueries() {
+				h.log.Debug("policy has invalid queries, skipping evaluation")
+				IncrSuppressedCount(string(h.policyID), SuppressionReasonInvalidQuery)
+				continue
+			}
+
+			if cycle := h.dependencyCycle(); cycle != nil {
+				h.log.Debug("policy dependencies form a cycle, skipping evaluation", "cycle", cycle)
+				IncrSuppressedCount(string(h.policyID), SuppressionReasonDependencyCycle)
+				continue
+			}
 
-		case <-h.ticker.C:
 			eval, err := h.handleTick(ctx, currentPolicy)
 			if err != nil {
 				if err == context.Canceled {
 					// Context was canceled, return to stop the handler.
 					return
 				}
-				h.log.Error(err.Error())
+				h.errLogs.Log(h.log, hclog.Error, errorLogKeyTick, err.Error())
 				continue
 			}
+			h.errLogs.Clear(h.log, errorLogKeyTick)
 
 			if eval != nil {
+				// Request reconciliation on each evaluation until it
+				// succeeds, so a failure or a target which is not ready
+				// does not prevent it.
+				if currentPolicy.ReconcileOnStart && !h.isReconciled() {
+					eval.ReconcileBounds = true
+				}
 				evalCh <- eval
+
+				h.stateLock.Lock()
+				h.lastEvaluation = h.clock.Now()
+				h.stateLock.Unlock()
 			}
 
 		case ts := <-h.cooldownCh:
@@ -166,18 +423,18 @@ func (h *Handler) Run(ctx context.Context, evalCh chan<- *sdk.ScalingEvaluation)
 	}
 }
 
-// Stop stops the handler and the monitoring Go routine.
+// Stop stops the handler and the monitoring Go routine. It is safe to call
+// more than once, and before the handler starts running.
 func (h *Handler) Stop() {
-	h.runningLock.Lock()
-	defer h.runningLock.Unlock()
+	h.stopLock.Lock()
+	defer h.stopLock.Unlock()
 
-	if h.running {
+	if !h.stopped {
 		h.log.Trace("stopping handler")
-		h.ticker.Stop()
 		close(h.doneCh)
 	}
 
-	h.running = false
+	h.stopped = true
 }
 
 func (h *Handler) handleTick(ctx context.Context, policy *sdk.ScalingPolicy) (*sdk.ScalingEvaluation, error) {
@@ -185,7 +442,7 @@ func (h *Handler) handleTick(ctx context.Context, policy *sdk.ScalingPolicy) (*s
 	// Timestamp the invocation of this evaluation run. This can be
 	// used when checking cooldown or emitting metrics to ensure some
 	// consistency.
-	curTime := time.Now().UTC().UnixNano()
+	curTime := h.clock.Now().UTC().UnixNano()
 
 	eval, err := h.generateEvaluation(policy)
 	if err != nil {
@@ -292,6 +549,359 @@ func (h *Handler) generateEvaluation(policy *sdk.ScalingPolicy) (*sdk.ScalingEva
 	return sdk.NewScalingEvaluation(policy, status), nil
 }
 
+// warmingUp returns whether the handler is within the policy warmup period.
+func (h *Handler) warmingUp() bool {
+	h.stateLock.RLock()
+	defer h.stateLock.RUnlock()
+	return h.clock.Now().Before(h.warmupUntil)
+}
+
+// checkQueries validates the queries of the policy checks, if the handler
+// has a query validator. A policy with invalid queries is marked as such, and
+// is not evaluated until it is updated with valid queries.
+func (h *Handler) checkQueries(p *sdk.ScalingPolicy) {
+	if h.validateQueries == nil {
+		return
+	}
+
+	var invalid string
+	if err := h.validateQueries(p); err != nil {
+		invalid = err.Error()
+		h.errLogs.Log(h.log, hclog.Error, errorLogKeyQuery,
+			"policy has invalid queries and will not be evaluated", "error", invalid)
+	} else {
+		h.errLogs.Clear(h.log, errorLogKeyQuery)
+	}
+
+	h.stateLock.Lock()
+	h.invalidQueries = invalid
+	h.stateLock.Unlock()
+}
+
+// checkDependencies records the policies the policy depends upon, if the
+// handler is run by a manager. Dependencies which form a cycle are logged, as
+// the policy is not evaluated until the cycle is broken.
+func (h *Handler) checkDependencies(p *sdk.ScalingPolicy) {
+	if h.dependencies == nil {
+		return
+	}
+
+	if cycle := h.dependencies.set(h.policyID, p.DependsOn); cycle != nil {
+		h.errLogs.Log(h.log, hclog.Error, errorLogKeyCycle,
+			"policy dependencies form a cycle and the policy will not be evaluated", "cycle", cycle)
+	} else {
+		h.errLogs.Clear(h.log, errorLogKeyCycle)
+	}
+}
+
+// dependencyCycle returns the dependency cycle the policy is part of, or nil
+// if it is not part of one. The cycle is looked up on each call, as it is
+// broken by changing any of the policies within it.
+func (h *Handler) dependencyCycle() []PolicyID {
+	if h.dependencies == nil {
+		return nil
+	}
+	return h.dependencies.cycle(h.policyID)
+}
+
+// hasInvalidQueries returns whether the queries of the current policy were
+// found to be invalid.
+func (h *Handler) hasInvalidQueries() bool {
+	h.stateLock.RLock()
+	defer h.stateLock.RUnlock()
+	return h.invalidQueries != ""
+}
+
+// isReconciled returns whether the target count has been reconciled with the
+// policy bounds.
+func (h *Handler) isReconciled() bool {
+	h.stateLock.RLock()
+	defer h.stateLock.RUnlock()
+	return h.reconciled
+}
+
+// markReconciled records that the target count has been reconciled with the
+// policy bounds.
+func (h *Handler) markReconciled() {
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+	h.reconciled = true
+}
+
+// setOverride stores the override, replacing any existing one. A nil override
+// removes the existing one.
+func (h *Handler) setOverride(o *Override) {
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+	h.override = o
+}
+
+// activeOverride returns a copy of the override if one is set and has not
+// expired.
+func (h *Handler) activeOverride() *Override {
+	h.stateLock.RLock()
+	defer h.stateLock.RUnlock()
+	return h.activeOverrideLocked()
+}
+
+// activeOverrideLocked is the lockless implementation of activeOverride. The
+// caller must hold stateLock.
+func (h *Handler) activeOverrideLocked() *Override {
+	if !h.override.active(h.clock.Now()) {
+		return nil
+	}
+	o := *h.override
+	return &o
+}
+
+// setAdvice stores the last recommendation of the advisory policy.
+func (h *Handler) setAdvice(a *Advice) {
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+	h.advice = a
+}
+
+// recommendation is the count recommended by a single evaluation of the
+// policy.
+type recommendation struct {
+	count int64
+	time  time.Time
+}
+
+// recordRecommendation records the count recommended by an evaluation at
+// now, and returns the highest count recommended within the trailing window,
+// including count. Recommendations older than the window are discarded.
+func (h *Handler) recordRecommendation(count int64, window time.Duration, now time.Time) int64 {
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+
+	cutoff := now.Add(-window)
+
+	i := 0
+	for i < len(h.recommendations) && h.recommendations[i].time.Before(cutoff) {
+		i++
+	}
+	h.recommendations = append(h.recommendations[i:], recommendation{count: count, time: now})
+
+	highest := count
+	for _, r := range h.recommendations {
+		if r.count > highest {
+			highest = r.count
+		}
+	}
+	return highest
+}
+
+// highestRecommendation returns the highest count recommended within the
+// trailing window, including count, without recording count.
+func (h *Handler) highestRecommendation(count int64, window time.Duration, now time.Time) int64 {
+	h.stateLock.RLock()
+	defer h.stateLock.RUnlock()
+
+	cutoff := now.Add(-window)
+
+	highest := count
+	for _, r := range h.recommendations {
+		if !r.time.Before(cutoff) && r.count > highest {
+			highest = r.count
+		}
+	}
+	return highest
+}
+
+// scaleDownAllowed returns whether the policy checks are allowed to scale the
+// target down, given they can perform max scale downs in a row. The count of
+// consecutive scale downs is reset once reset has passed since the last one.
+// A max of zero means no limit, and a reset of zero that the count is only
+// reset by a scale up or an acknowledgement.
+func (h *Handler) scaleDownAllowed(max int64, reset time.Duration, now time.Time) bool {
+	if max <= 0 {
+		return true
+	}
+
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+
+	if reset > 0 && h.scaleDowns > 0 && !now.Before(h.lastScaleDown.Add(reset)) {
+		h.scaleDowns = 0
+	}
+	return h.scaleDowns < max
+}
+
+// stepDownDelayedUntil returns the time until which the policy checks must wait
+// before scaling the target down again, given each step down is followed by
+// delay. The zero time is returned if the next step down is not delayed.
+func (h *Handler) stepDownDelayedUntil(delay time.Duration, now time.Time) time.Time {
+	if delay <= 0 {
+		return time.Time{}
+	}
+
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+
+	if h.lastScaleDown.IsZero() {
+		return time.Time{}
+	}
+	if until := h.lastScaleDown.Add(delay); now.Before(until) {
+		return until
+	}
+	return time.Time{}
+}
+
+// scaleUpProbationUntil returns the time until which scale ups of the policy
+// checks are suppressed, given each scale up is followed by a probation. The
+// zero time is returned if the policy is not in probation.
+func (h *Handler) scaleUpProbationUntil(probation time.Duration, now time.Time) time.Time {
+	if probation <= 0 {
+		return time.Time{}
+	}
+
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+
+	if h.lastScaleUp.IsZero() {
+		return time.Time{}
+	}
+	if until := h.lastScaleUp.Add(probation); now.Before(until) {
+		return until
+	}
+	return time.Time{}
+}
+
+// recordScale records a scaling action performed by the policy checks at now.
+// Scale downs increment the count of consecutive scale downs, while scale ups
+// reset it, lift the delay of the next step down and start the probation.
+func (h *Handler) recordScale(direction sdk.ScaleDirection, now time.Time) {
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+
+	switch direction {
+	case sdk.ScaleDirectionDown:
+		h.scaleDowns++
+		h.lastScaleDown = now
+	case sdk.ScaleDirectionUp:
+		h.scaleDowns = 0
+		h.lastScaleDown = time.Time{}
+		h.lastScaleUp = now
+	}
+}
+
+// beginAction records that a scaling action of the policy is in progress.
+func (h *Handler) beginAction() {
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+	h.scaling = true
+}
+
+// endAction records that the scaling action of the policy has finished at
+// now. The time of the last action is only updated if the target was scaled.
+func (h *Handler) endAction(scaled bool, now time.Time) {
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+
+	h.scaling = false
+	if scaled {
+		h.lastAction = now
+	}
+}
+
+// actionBusy returns whether a scaling action of the policy is in progress,
+// or one was performed within the window before now. A window of zero uses
+// the cooldown of the policy, as the target is expected to have settled once
+// it has passed.
+func (h *Handler) actionBusy(window time.Duration, now time.Time) bool {
+	h.stateLock.RLock()
+	defer h.stateLock.RUnlock()
+
+	if h.scaling {
+		return true
+	}
+	if h.lastAction.IsZero() {
+		return false
+	}
+	if window <= 0 && h.policy != nil {
+		window = h.policy.Cooldown
+	}
+	return now.Before(h.lastAction.Add(window))
+}
+
+// recordStability records whether an evaluation of the policy was stable, by
+// producing no scaling action, and notifies the Run Go routine so the adaptive
+// evaluation interval is lengthened or reset.
+func (h *Handler) recordStability(stable bool) {
+	h.stateLock.Lock()
+	if stable {
+		h.stableEvaluations++
+	} else {
+		h.stableEvaluations = 0
+	}
+	h.stateLock.Unlock()
+
+	select {
+	case h.intervalCh <- struct{}{}:
+	default:
+	}
+}
+
+// resetScaleDowns resets the count of consecutive scale downs.
+func (h *Handler) resetScaleDowns() {
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+	h.scaleDowns = 0
+}
+
+// State returns a snapshot of the handler state.
+func (h *Handler) State() HandlerState {
+	h.stateLock.RLock()
+	defer h.stateLock.RUnlock()
+
+	return HandlerState{
+		PolicyID:       h.policyID,
+		Source:         h.policySource.Name(),
+		LastEvaluation: h.lastEvaluation,
+		CooldownUntil:  h.cooldownUntil,
+		WarmupUntil:    h.warmupUntil,
+		WarmingUp:      h.clock.Now().Before(h.warmupUntil),
+		Override:       h.activeOverrideLocked(),
+		Advice:         h.advice,
+		InvalidQueries: h.invalidQueries,
+	}
+}
+
+// setPolicy stores the policy received from the source so it can be
+// described. requested is the evaluation interval set by the policy before
+// the minimum evaluation interval was applied.
+func (h *Handler) setPolicy(p *sdk.ScalingPolicy, requested time.Duration) {
+	h.stateLock.Lock()
+	defer h.stateLock.Unlock()
+
+	h.policy = p
+	h.requestedInterval = 0
+	if requested != p.EvaluationInterval {
+		h.requestedInterval = requested
+	}
+}
+
+// describe returns the resolved view of the policy. The returned bool is false
+// if the policy has not yet been received from its source.
+func (h *Handler) describe() (*PolicyDescription, bool) {
+	state := h.State()
+
+	h.stateLock.RLock()
+	defer h.stateLock.RUnlock()
+
+	if h.policy == nil {
+		return nil, false
+	}
+
+	p := *h.policy
+	return &PolicyDescription{
+		HandlerState:                state,
+		Policy:                      &p,
+		RequestedEvaluationInterval: h.requestedInterval,
+	}, true
+}
+
 // updateHandler updates the handler's internal state based on the changes in
 // the policy being monitored.
 func (h *Handler) updateHandler(current, next *sdk.ScalingPolicy) {
@@ -300,16 +910,289 @@ func (h *Handler) updateHandler(current, next *sdk.ScalingPolicy) {
 	} else {
 		h.log.Trace("received policy change")
 		h.log.Trace(cmp.Diff(current, next))
+
+		// Sources may send the policy again without changes, so only log
+		// when a field operators are likely to care about changed.
+		if changes := policyChanges(current, next); len(changes) > 0 {
+			h.log.Info("policy changed", changes...)
+		}
+	}
+
+	// Start the warmup period when the policy is first received.
+	if current == nil && next.WarmupPeriod > 0 {
+		h.log.Debug("policy warmup period started", "warmup_period", next.WarmupPeriod)
+
+		h.stateLock.Lock()
+		h.warmupUntil = h.clock.Now().Add(next.WarmupPeriod)
+		h.stateLock.Unlock()
 	}
 
 	// Update ticker if it's the first time we receive the policy or if the
-	// policy's evaluation interval has changed.
-	if current == nil || current.EvaluationInterval != next.EvaluationInterval {
+	// policy's evaluation interval or schedule has changed.
+	if current == nil || current.EvaluationInterval != next.EvaluationInterval ||
+		current.Cron != next.Cron || current.CronTimeZone != next.CronTimeZone {
+		h.stopTicker()
+
+		if next.Cron != "" {
+			h.scheduleCron(next, h.clock.Now())
+		} else {
+			h.startTicker(next.EvaluationInterval)
+		}
+	}
+}
+
+// updateInterval restarts the ticker if the adaptive evaluation interval of
+// the policy differs from the interval in use.
+func (h *Handler) updateInterval(p *sdk.ScalingPolicy) {
+	if p == nil || p.Cron != "" {
+		return
+	}
+
+	h.stateLock.RLock()
+	stable := h.stableEvaluations
+	h.stateLock.RUnlock()
+
+	interval := adaptiveInterval(p, stable)
+	if interval == h.tickInterval {
+		return
+	}
+
+	h.log.Debug("adjusting evaluation interval",
+		"evaluation_interval", interval, "stable_evaluations", stable)
+
+	h.startTicker(interval)
+}
+
+// startTicker replaces the ticker or timer currently sending the policy for
+// evaluation with a ticker firing at the interval.
+func (h *Handler) startTicker(interval time.Duration) {
+	h.stopTicker()
+	h.ticker = h.clock.NewTicker(interval)
+	h.tickCh = h.ticker.C()
+	h.tickInterval = interval
+	h.lastTick = h.clock.Now()
+}
+
+// skipTick checks the time elapsed since the last tick against the tick
+// interval, and against the time the wall clock moved by. The ticker is
+// restarted if ticks were missed or bunched while the handler was blocked, or
+// if the wall clock jumped, such as due to an NTP correction or the host
+// being suspended, so the following evaluations are evenly spaced from now
+// rather than fired in a burst. The returned bool indicates whether the tick
+// came early and should be skipped, as the policy was evaluated too recently.
+func (h *Handler) skipTick(now time.Time) bool {
+	last := h.lastTick
+	h.lastTick = now
+
+	interval := h.tickInterval
+	if last.IsZero() || interval <= 0 {
+		return false
+	}
+
+	// Sub uses the monotonic clock readings when both times have one, so
+	// wall clock jumps only show once the readings are stripped.
+	elapsed := now.Sub(last)
+	wallElapsed := now.Round(0).Sub(last.Round(0))
+
+	skip, reschedule := checkTick(interval, elapsed, wallElapsed)
+	if !reschedule {
+		return false
+	}
+
+	h.log.Warn("time since the last evaluation does not match the evaluation interval, the clock may have jumped, rescheduling evaluations",
+		"elapsed", elapsed, "wall_elapsed", wallElapsed, "evaluation_interval", interval)
+	h.startTicker(interval)
+	return skip
+}
+
+// checkTick returns whether a tick should be skipped and whether the ticker
+// should be restarted, from the time elapsed since the last tick and the time
+// the wall clock moved by in the meantime. Only ticks which came early by the
+// elapsed time are skipped, so a wall clock jump never drops an evaluation.
+func checkTick(interval, elapsed, wallElapsed time.Duration) (bool, bool) {
+	early := elapsed < time.Duration(float64(interval)*tickEarlyFactor)
+	late := elapsed > time.Duration(float64(interval)*tickLateFactor)
+
+	drift := wallElapsed - elapsed
+	if drift < 0 {
+		drift = -drift
+	}
+	jumped := drift > time.Duration(float64(interval)*tickEarlyFactor)
+
+	return early, early || late || jumped
+}
+
+// adaptiveInterval returns the evaluation interval of the policy after the
+// number of stable evaluations in a row. The interval doubles with each stable
+// evaluation once the policy StableEvaluations is reached, up to the policy
+// MaxEvaluationInterval.
+func adaptiveInterval(p *sdk.ScalingPolicy, stable int64) time.Duration {
+	threshold := p.StableEvaluations
+	if threshold <= 0 {
+		threshold = defaultStableEvaluations
+	}
+
+	interval := p.EvaluationInterval
+	if p.MaxEvaluationInterval <= interval || interval <= 0 {
+		return interval
+	}
+
+	for i := threshold; i <= stable && interval < p.MaxEvaluationInterval; i++ {
+		interval *= 2
+	}
+	if interval > p.MaxEvaluationInterval {
+		interval = p.MaxEvaluationInterval
+	}
+	return interval
+}
+
+// policyChanges returns the changes to the interval, bounds and checks of the
+// policy as log key/value pairs, with each value describing the change from
+// the current to the next value. Checks are matched by name.
+func policyChanges(current, next *sdk.ScalingPolicy) []interface{} {
+	var changes []interface{}
+
+	add := func(key string, from, to interface{}) {
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, key, fmt.Sprintf("%v -> %v", from, to))
+		}
+	}
+
+	add("enabled", current.Enabled, next.Enabled)
+	add("evaluation_interval", current.EvaluationInterval, next.EvaluationInterval)
+	add("cron", current.Cron, next.Cron)
+	add("cooldown", current.Cooldown, next.Cooldown)
+	add("min", current.Min, next.Min)
+	add("max", current.Max, next.Max)
+
+	currentChecks := make(map[string]*sdk.ScalingPolicyCheck, len(current.Checks))
+	for _, c := range current.Checks {
+		currentChecks[c.Name] = c
+	}
+
+	for _, n := range next.Checks {
+		c, ok := currentChecks[n.Name]
+		if !ok {
+			changes = append(changes, "check."+n.Name, "added")
+			continue
+		}
+		delete(currentChecks, n.Name)
+
+		prefix := "check." + n.Name + "."
+		add(prefix+"source", c.Source, n.Source)
+		add(prefix+"query", c.Query, n.Query)
+		add(prefix+"strategy", checkStrategyName(c), checkStrategyName(n))
+		add(prefix+"strategy_config", checkStrategyConfig(c), checkStrategyConfig(n))
+	}
+
+	// Iterate the checks of the current policy so removals are logged in a
+	// consistent order.
+	for _, c := range current.Checks {
+		if _, ok := currentChecks[c.Name]; ok {
+			changes = append(changes, "check."+c.Name, "removed")
+		}
+	}
+	return changes
+}
+
+func checkStrategyName(c *sdk.ScalingPolicyCheck) string {
+	if c.Strategy == nil {
+		return ""
+	}
+	return c.Strategy.Name
+}
+
+func checkStrategyConfig(c *sdk.ScalingPolicyCheck) map[string]string {
+	if c.Strategy == nil {
+		return nil
+	}
+	return c.Strategy.Config
+}
+
+// scheduleCron sets the handler to send the policy for evaluation at the next
+// time matching its cron schedule after now. The schedule is validated when
+// the policy is loaded, but if it fails to parse the evaluation interval is
+// used instead so the policy is still evaluated.
+func (h *Handler) scheduleCron(p *sdk.ScalingPolicy, now time.Time) {
+	h.stopTicker()
+
+	next, err := nextCronTime(p.Cron, p.CronTimeZone, now)
+	if err != nil {
+		h.log.Error("failed to schedule policy evaluation, using evaluation interval",
+			"cron", p.Cron, "error", err)
+		h.startTicker(p.EvaluationInterval)
+		return
+	}
+
+	// A schedule without any future times never fires again.
+	if next.IsZero() {
+		h.log.Warn("policy cron schedule has no future evaluations", "cron", p.Cron)
+		h.tickCh = nil
+		return
+	}
+
+	h.log.Info("scheduled next policy evaluation", "cron", p.Cron, "next", next)
+	h.cronTimer = h.clock.NewTimer(next.Sub(now))
+	h.tickCh = h.cronTimer.C()
+}
+
+// stopTicker stops the ticker or timer currently sending the policy for
+// evaluation.
+func (h *Handler) stopTicker() {
+	if h.ticker != nil {
 		h.ticker.Stop()
-		h.ticker = time.NewTicker(next.EvaluationInterval)
+	}
+	if h.cronTimer != nil {
+		h.cronTimer.Stop()
 	}
 }
 
+// nextCronTime returns the next time after now matching the cron expression
+// within the time zone, which defaults to UTC. A zero time is returned if the
+// expression has no future times.
+func nextCronTime(cron, timeZone string, now time.Time) (time.Time, error) {
+	expr, err := cronexpr.Parse(cron)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron expression: %v", err)
+	}
+
+	loc := time.UTC
+	if timeZone != "" {
+		if loc, err = time.LoadLocation(timeZone); err != nil {
+			return time.Time{}, fmt.Errorf("invalid time zone: %v", err)
+		}
+	}
+	return expr.Next(now.In(loc)), nil
+}
+
+// ScheduledMinActive returns whether now falls within a window of the
+// scheduled minimum, which is the case if a window started within the last
+// Duration. An error is returned if the schedule is not valid.
+func ScheduledMinActive(s *sdk.ScalingPolicyScheduledMin, now time.Time) (bool, error) {
+	start, err := nextCronTime(s.Cron, s.TimeZone, now.Add(-s.Duration))
+	if err != nil {
+		return false, err
+	}
+	return !start.IsZero() && !start.After(now), nil
+}
+
+// applyMinEvaluationInterval raises the evaluation interval of the policy to
+// the configured minimum, protecting Nomad and the APMs from policies which
+// request aggressive intervals.
+func (h *Handler) applyMinEvaluationInterval(p *sdk.ScalingPolicy) {
+	if h.minEvaluationInterval <= 0 || p.EvaluationInterval >= h.minEvaluationInterval {
+		h.flooredInterval = 0
+		return
+	}
+
+	if h.flooredInterval != p.EvaluationInterval {
+		h.log.Warn("policy evaluation interval is below the configured minimum, using minimum",
+			"evaluation_interval", p.EvaluationInterval, "min_evaluation_interval", h.minEvaluationInterval)
+		h.flooredInterval = p.EvaluationInterval
+	}
+	p.EvaluationInterval = h.minEvaluationInterval
+}
+
 // enforceCooldown blocks until the cooldown period has been reached, or the
 // handler has been instructed to exit. The boolean return details whether or
 // not the cooldown period passed without being interrupted.
@@ -319,17 +1202,26 @@ func (h *Handler) enforceCooldown(ctx context.Context, t time.Duration) (complet
 	// blocks the ticker making this the only indication of cooldown to
 	// operators.
 	h.log.Debug("scaling policy has been placed into cooldown", "cooldown", t)
+	IncrSuppressedCount(string(h.policyID), SuppressionReasonCooldown)
+
+	h.stateLock.Lock()
+	h.cooldownUntil = h.clock.Now().Add(t)
+	h.stateLock.Unlock()
 
 	// Using a timer directly is mentioned to be more efficient than
 	// time.After() as long as we ensure to call Stop(). So setup a timer for
 	// use and defer the stop.
-	timer := time.NewTimer(t)
+	timer := h.clock.NewTimer(t)
 	defer timer.Stop()
 
+	// The cooldown blocks the ticker, so the next tick is not measured
+	// against the last when checking for clock jumps.
+	defer func() { h.lastTick = time.Time{} }()
+
 	// Cooldown should not mean we miss other handler control signals. So wait
 	// on all the channels desired here.
 	select {
-	case <-timer.C:
+	case <-timer.C():
 		complete = true
 		return
 	case <-ctx.Done():
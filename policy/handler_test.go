@@ -1,10 +1,15 @@
 package policy
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/clock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -44,3 +49,801 @@ func TestHandler_calculateRemainingCooldown(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_activeOverride(t *testing.T) {
+	testCases := []struct {
+		inputOverride  *Override
+		expectedOutput *Override
+		name           string
+	}{
+		{
+			inputOverride:  nil,
+			expectedOutput: nil,
+			name:           "no override",
+		},
+		{
+			inputOverride:  &Override{Count: 3, Expiry: time.Unix(1, 0)},
+			expectedOutput: nil,
+			name:           "expired override",
+		},
+		{
+			inputOverride:  &Override{Count: 3, Expiry: time.Unix(4102444800, 0)},
+			expectedOutput: &Override{Count: 3, Expiry: time.Unix(4102444800, 0)},
+			name:           "active override",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+			h.setOverride(tc.inputOverride)
+			assert.Equal(t, tc.expectedOutput, h.activeOverride(), tc.name)
+		})
+	}
+}
+
+func TestHandler_recordRecommendation(t *testing.T) {
+	h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+	now := time.Now()
+	window := 5 * time.Minute
+
+	assert.Equal(t, int64(8), h.recordRecommendation(8, window, now))
+
+	// Lower recommendations within the window return the highest one.
+	assert.Equal(t, int64(8), h.recordRecommendation(3, window, now.Add(2*time.Minute)))
+	assert.Equal(t, int64(8), h.recordRecommendation(4, window, now.Add(window)))
+
+	// Higher recommendations are returned immediately.
+	assert.Equal(t, int64(10), h.recordRecommendation(10, window, now.Add(6*time.Minute)))
+
+	// Recommendations older than the window are discarded.
+	assert.Equal(t, int64(2), h.recordRecommendation(2, window, now.Add(12*time.Minute)))
+	assert.Len(t, h.recommendations, 1)
+}
+
+func TestHandler_scaleDownAllowed(t *testing.T) {
+	h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+	now := time.Now()
+	reset := time.Hour
+
+	// Without a limit scale downs are always allowed.
+	h.recordScale(sdk.ScaleDirectionDown, now)
+	h.recordScale(sdk.ScaleDirectionDown, now)
+	assert.True(t, h.scaleDownAllowed(0, reset, now))
+
+	// Scale downs are suppressed once the limit is reached.
+	assert.False(t, h.scaleDownAllowed(2, reset, now))
+	assert.True(t, h.scaleDownAllowed(3, reset, now))
+
+	// Scale ups reset the count.
+	h.recordScale(sdk.ScaleDirectionUp, now)
+	assert.True(t, h.scaleDownAllowed(2, reset, now))
+
+	// The count is reset once the reset interval has passed since the last
+	// scale down.
+	h.recordScale(sdk.ScaleDirectionDown, now)
+	h.recordScale(sdk.ScaleDirectionDown, now)
+	assert.False(t, h.scaleDownAllowed(2, reset, now.Add(reset-time.Second)))
+	assert.False(t, h.scaleDownAllowed(2, 0, now.Add(2*reset)))
+	assert.True(t, h.scaleDownAllowed(2, reset, now.Add(reset)))
+
+	// The count can be reset explicitly.
+	h.recordScale(sdk.ScaleDirectionDown, now)
+	h.recordScale(sdk.ScaleDirectionDown, now)
+	h.resetScaleDowns()
+	assert.True(t, h.scaleDownAllowed(2, 0, now))
+}
+
+func TestHandler_stepDownDelayedUntil(t *testing.T) {
+	h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+	now := time.Now()
+	delay := 10 * time.Minute
+
+	// Nothing delays the first step down.
+	assert.True(t, h.stepDownDelayedUntil(delay, now).IsZero())
+
+	// A step down delays the next one until the delay has passed.
+	h.recordScale(sdk.ScaleDirectionDown, now)
+	assert.Equal(t, now.Add(delay), h.stepDownDelayedUntil(delay, now.Add(time.Minute)))
+	assert.True(t, h.stepDownDelayedUntil(delay, now.Add(delay)).IsZero())
+	assert.True(t, h.stepDownDelayedUntil(0, now).IsZero())
+
+	// Scale ups lift the delay.
+	h.recordScale(sdk.ScaleDirectionUp, now)
+	assert.True(t, h.stepDownDelayedUntil(delay, now).IsZero())
+}
+
+func TestHandler_scaleUpProbationUntil(t *testing.T) {
+	h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+	now := time.Now()
+	probation := 5 * time.Minute
+
+	// Nothing suppresses the first scale up.
+	assert.True(t, h.scaleUpProbationUntil(probation, now).IsZero())
+
+	// A scale up starts the probation, which lasts until it has passed.
+	h.recordScale(sdk.ScaleDirectionUp, now)
+	assert.Equal(t, now.Add(probation), h.scaleUpProbationUntil(probation, now.Add(time.Minute)))
+	assert.True(t, h.scaleUpProbationUntil(probation, now.Add(probation)).IsZero())
+	assert.True(t, h.scaleUpProbationUntil(0, now).IsZero())
+
+	// Scale downs don't end the probation.
+	h.recordScale(sdk.ScaleDirectionDown, now.Add(time.Minute))
+	assert.Equal(t, now.Add(probation), h.scaleUpProbationUntil(probation, now.Add(2*time.Minute)))
+}
+
+func TestHandler_actionBusy(t *testing.T) {
+	h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+	now := time.Now()
+	window := 5 * time.Minute
+
+	// A policy which never scaled is stable.
+	assert.False(t, h.actionBusy(window, now))
+
+	// A scaling action in progress is busy regardless of the window.
+	h.beginAction()
+	assert.True(t, h.actionBusy(0, now))
+
+	// An action which did not scale the target leaves the policy stable.
+	h.endAction(false, now)
+	assert.False(t, h.actionBusy(window, now))
+
+	h.beginAction()
+	h.endAction(true, now)
+	assert.True(t, h.actionBusy(window, now.Add(time.Minute)))
+	assert.False(t, h.actionBusy(window, now.Add(window)))
+
+	// A zero window uses the cooldown of the policy.
+	assert.False(t, h.actionBusy(0, now.Add(time.Minute)))
+	h.setPolicy(&sdk.ScalingPolicy{Cooldown: 2 * time.Minute}, 0)
+	assert.True(t, h.actionBusy(0, now.Add(time.Minute)))
+	assert.False(t, h.actionBusy(0, now.Add(2*time.Minute)))
+}
+
+func TestHandler_checkDependencies(t *testing.T) {
+	h1 := NewHandler("policy1", hclog.NewNullLogger(), nil, &fakeSource{})
+	h2 := NewHandler("policy2", hclog.NewNullLogger(), nil, &fakeSource{})
+
+	// Dependencies are not tracked without a manager.
+	h1.checkDependencies(&sdk.ScalingPolicy{ID: "policy1", DependsOn: []string{"policy1"}})
+	assert.Nil(t, h1.dependencyCycle())
+
+	g := newDependencyGraph()
+	h1.dependencies, h2.dependencies = g, g
+
+	h1.checkDependencies(&sdk.ScalingPolicy{ID: "policy1", DependsOn: []string{"policy2"}})
+	assert.Nil(t, h1.dependencyCycle())
+
+	// Both policies within the cycle are held once it is formed.
+	h2.checkDependencies(&sdk.ScalingPolicy{ID: "policy2", DependsOn: []string{"policy1"}})
+	assert.Equal(t, []PolicyID{"policy1", "policy2", "policy1"}, h1.dependencyCycle())
+	assert.Equal(t, []PolicyID{"policy2", "policy1", "policy2"}, h2.dependencyCycle())
+
+	h2.checkDependencies(&sdk.ScalingPolicy{ID: "policy2"})
+	assert.Nil(t, h1.dependencyCycle())
+	assert.Nil(t, h2.dependencyCycle())
+}
+
+func Test_nextCronTime(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		inputCron     string
+		inputTimeZone string
+		expectedNext  time.Time
+		expectError   bool
+		name          string
+	}{
+		{
+			inputCron:    "0 8 * * *",
+			expectedNext: time.Date(2020, 10, 2, 8, 0, 0, 0, time.UTC),
+			name:         "defaults to UTC",
+		},
+		{
+			inputCron:     "0 15 * * *",
+			inputTimeZone: "Europe/Amsterdam",
+			expectedNext:  time.Date(2020, 10, 1, 13, 0, 0, 0, time.UTC),
+			name:          "time zone",
+		},
+		{
+			inputCron:   "every day",
+			expectError: true,
+			name:        "invalid cron expression",
+		},
+		{
+			inputCron:     "0 8 * * *",
+			inputTimeZone: "Mars/Olympus_Mons",
+			expectError:   true,
+			name:          "invalid time zone",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next, err := nextCronTime(tc.inputCron, tc.inputTimeZone, now)
+			if tc.expectError {
+				assert.NotNil(t, err, tc.name)
+				return
+			}
+			assert.Nil(t, err, tc.name)
+			assert.True(t, tc.expectedNext.Equal(next), tc.name)
+		})
+	}
+}
+
+func TestScheduledMinActive(t *testing.T) {
+	testCases := []struct {
+		inputSchedule  *sdk.ScalingPolicyScheduledMin
+		inputNow       time.Time
+		expectedActive bool
+		expectError    bool
+		name           string
+	}{
+		{
+			inputSchedule:  &sdk.ScalingPolicyScheduledMin{Cron: "0 8 * * *", Duration: 10 * time.Hour},
+			inputNow:       time.Date(2020, 10, 1, 12, 30, 0, 0, time.UTC),
+			expectedActive: true,
+			name:           "within window",
+		},
+		{
+			inputSchedule:  &sdk.ScalingPolicyScheduledMin{Cron: "0 8 * * *", Duration: 10 * time.Hour},
+			inputNow:       time.Date(2020, 10, 1, 8, 0, 0, 0, time.UTC),
+			expectedActive: true,
+			name:           "window start",
+		},
+		{
+			inputSchedule:  &sdk.ScalingPolicyScheduledMin{Cron: "0 8 * * *", Duration: 10 * time.Hour},
+			inputNow:       time.Date(2020, 10, 1, 7, 59, 0, 0, time.UTC),
+			expectedActive: false,
+			name:           "before window",
+		},
+		{
+			inputSchedule:  &sdk.ScalingPolicyScheduledMin{Cron: "0 8 * * *", Duration: 10 * time.Hour},
+			inputNow:       time.Date(2020, 10, 1, 18, 30, 0, 0, time.UTC),
+			expectedActive: false,
+			name:           "after window",
+		},
+		{
+			inputSchedule:  &sdk.ScalingPolicyScheduledMin{Cron: "0 15 * * *", TimeZone: "Europe/Amsterdam", Duration: time.Hour},
+			inputNow:       time.Date(2020, 10, 1, 13, 30, 0, 0, time.UTC),
+			expectedActive: true,
+			name:           "time zone",
+		},
+		{
+			inputSchedule: &sdk.ScalingPolicyScheduledMin{Cron: "every day", Duration: time.Hour},
+			inputNow:      time.Date(2020, 10, 1, 12, 30, 0, 0, time.UTC),
+			expectError:   true,
+			name:          "invalid cron expression",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			active, err := ScheduledMinActive(tc.inputSchedule, tc.inputNow)
+			if tc.expectError {
+				assert.NotNil(t, err, tc.name)
+				return
+			}
+			assert.Nil(t, err, tc.name)
+			assert.Equal(t, tc.expectedActive, active, tc.name)
+		})
+	}
+}
+
+func TestHandler_updateHandler_cron(t *testing.T) {
+	c := clock.NewFake(time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC))
+	h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+	h.clock = c
+	defer h.stopTicker()
+
+	interval := &sdk.ScalingPolicy{EvaluationInterval: time.Hour}
+	h.updateHandler(nil, interval)
+	assert.Nil(t, h.cronTimer)
+	assert.NotNil(t, h.tickCh)
+
+	// Switching to a cron schedule replaces the ticker with a timer firing at
+	// the next scheduled time.
+	cron := &sdk.ScalingPolicy{EvaluationInterval: time.Hour, Cron: "* 30 * * * * *"}
+	h.updateHandler(interval, cron)
+	assert.NotNil(t, h.cronTimer)
+
+	c.Advance(29*time.Minute + 59*time.Second)
+	select {
+	case <-h.tickCh:
+		t.Fatal("cron schedule fired early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case tick := <-h.tickCh:
+		assert.Equal(t, time.Date(2020, 10, 1, 12, 30, 0, 0, time.UTC), tick)
+	default:
+		t.Fatal("cron schedule did not fire")
+	}
+
+	// Invalid schedules fall back to the evaluation interval.
+	invalid := &sdk.ScalingPolicy{EvaluationInterval: time.Hour, Cron: "every day"}
+	h.updateHandler(cron, invalid)
+	assert.Equal(t, h.ticker.C(), h.tickCh)
+}
+
+func Test_adaptiveInterval(t *testing.T) {
+	testCases := []struct {
+		inputPolicy    *sdk.ScalingPolicy
+		inputStable    int64
+		expectedOutput time.Duration
+		name           string
+	}{
+		{
+			inputPolicy:    &sdk.ScalingPolicy{EvaluationInterval: time.Minute},
+			inputStable:    10,
+			expectedOutput: time.Minute,
+			name:           "adaptive interval disabled",
+		},
+		{
+			inputPolicy:    &sdk.ScalingPolicy{EvaluationInterval: time.Minute, MaxEvaluationInterval: 30 * time.Second},
+			inputStable:    10,
+			expectedOutput: time.Minute,
+			name:           "max below interval",
+		},
+		{
+			inputPolicy:    &sdk.ScalingPolicy{EvaluationInterval: time.Minute, MaxEvaluationInterval: 10 * time.Minute},
+			inputStable:    2,
+			expectedOutput: time.Minute,
+			name:           "below default stable evaluations",
+		},
+		{
+			inputPolicy:    &sdk.ScalingPolicy{EvaluationInterval: time.Minute, MaxEvaluationInterval: 10 * time.Minute},
+			inputStable:    4,
+			expectedOutput: 4 * time.Minute,
+			name:           "lengthened after default stable evaluations",
+		},
+		{
+			inputPolicy:    &sdk.ScalingPolicy{EvaluationInterval: time.Minute, MaxEvaluationInterval: 10 * time.Minute, StableEvaluations: 1},
+			inputStable:    1,
+			expectedOutput: 2 * time.Minute,
+			name:           "custom stable evaluations",
+		},
+		{
+			inputPolicy:    &sdk.ScalingPolicy{EvaluationInterval: time.Minute, MaxEvaluationInterval: 10 * time.Minute},
+			inputStable:    100,
+			expectedOutput: 10 * time.Minute,
+			name:           "capped at max",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, adaptiveInterval(tc.inputPolicy, tc.inputStable), tc.name)
+		})
+	}
+}
+
+func TestHandler_updateInterval(t *testing.T) {
+	h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+	defer h.stopTicker()
+
+	p := &sdk.ScalingPolicy{
+		EvaluationInterval:    time.Minute,
+		MaxEvaluationInterval: 10 * time.Minute,
+		StableEvaluations:     1,
+	}
+	h.updateHandler(nil, p)
+	assert.Equal(t, time.Minute, h.tickInterval)
+
+	// Stable evaluations lengthen the interval.
+	h.recordStability(true)
+	h.recordStability(true)
+	<-h.intervalCh
+	h.updateInterval(p)
+	assert.Equal(t, 4*time.Minute, h.tickInterval)
+
+	// An action resets it to the policy interval.
+	h.recordStability(false)
+	<-h.intervalCh
+	h.updateInterval(p)
+	assert.Equal(t, time.Minute, h.tickInterval)
+}
+
+func TestHandler_skipTick(t *testing.T) {
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name                string
+		inputLastTick       time.Time
+		inputNow            time.Time
+		expectedSkip        bool
+		expectedRescheduled bool
+	}{
+		{
+			name:          "tick on time",
+			inputLastTick: start,
+			inputNow:      start.Add(time.Minute),
+		},
+		{
+			name:          "tick slightly late",
+			inputLastTick: start,
+			inputNow:      start.Add(time.Minute + 20*time.Second),
+		},
+		{
+			name:                "clock jumped forward",
+			inputLastTick:       start,
+			inputNow:            start.Add(time.Hour),
+			expectedRescheduled: true,
+		},
+		{
+			name:                "clock jumped backward",
+			inputLastTick:       start,
+			inputNow:            start.Add(-time.Hour),
+			expectedSkip:        true,
+			expectedRescheduled: true,
+		},
+		{
+			name:                "bunched tick",
+			inputLastTick:       start,
+			inputNow:            start.Add(time.Second),
+			expectedSkip:        true,
+			expectedRescheduled: true,
+		},
+		{
+			name:     "last tick unknown",
+			inputNow: start.Add(time.Hour),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+			defer h.stopTicker()
+
+			// The clock is read when the ticker is restarted.
+			h.clock = clock.NewFake(tc.inputNow)
+
+			h.updateHandler(nil, &sdk.ScalingPolicy{EvaluationInterval: time.Minute})
+			ticker := h.ticker
+			h.lastTick = tc.inputLastTick
+
+			assert.Equal(t, tc.expectedSkip, h.skipTick(tc.inputNow), tc.name)
+			assert.Equal(t, tc.expectedRescheduled, h.ticker != ticker, tc.name)
+			assert.Equal(t, tc.inputNow, h.lastTick, tc.name)
+			assert.Equal(t, time.Minute, h.tickInterval, tc.name)
+		})
+	}
+}
+
+func TestHandler_skipTick_monotonic(t *testing.T) {
+	// Times read from the real clock carry a monotonic reading, which is
+	// kept by Add.
+	start := time.Now()
+
+	testCases := []struct {
+		name                string
+		inputNow            time.Time
+		expectedSkip        bool
+		expectedRescheduled bool
+	}{
+		{
+			name:     "tick on time",
+			inputNow: start.Add(time.Minute),
+		},
+		{
+			name:                "bunched tick",
+			inputNow:            start.Add(time.Second),
+			expectedSkip:        true,
+			expectedRescheduled: true,
+		},
+		{
+			name:                "missed ticks",
+			inputNow:            start.Add(time.Hour),
+			expectedRescheduled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+			defer h.stopTicker()
+
+			h.updateHandler(nil, &sdk.ScalingPolicy{EvaluationInterval: time.Minute})
+			ticker := h.ticker
+			h.lastTick = start
+
+			assert.Equal(t, tc.expectedSkip, h.skipTick(tc.inputNow), tc.name)
+			assert.Equal(t, tc.expectedRescheduled, h.ticker != ticker, tc.name)
+		})
+	}
+}
+
+func Test_checkTick(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputElapsed       time.Duration
+		inputWallElapsed   time.Duration
+		expectedSkip       bool
+		expectedReschedule bool
+	}{
+		{
+			name:             "tick on time",
+			inputElapsed:     time.Minute,
+			inputWallElapsed: time.Minute,
+		},
+		{
+			name:               "bunched tick",
+			inputElapsed:       time.Second,
+			inputWallElapsed:   time.Second,
+			expectedSkip:       true,
+			expectedReschedule: true,
+		},
+		{
+			name:               "missed ticks",
+			inputElapsed:       time.Hour,
+			inputWallElapsed:   time.Hour,
+			expectedReschedule: true,
+		},
+		{
+			name:               "wall clock jumped backward",
+			inputElapsed:       time.Minute,
+			inputWallElapsed:   -time.Hour,
+			expectedReschedule: true,
+		},
+		{
+			name:               "wall clock jumped forward",
+			inputElapsed:       time.Minute,
+			inputWallElapsed:   time.Hour,
+			expectedReschedule: true,
+		},
+		{
+			name:             "wall clock slewed",
+			inputElapsed:     time.Minute,
+			inputWallElapsed: time.Minute + time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			skip, reschedule := checkTick(time.Minute, tc.inputElapsed, tc.inputWallElapsed)
+			assert.Equal(t, tc.expectedSkip, skip, tc.name)
+			assert.Equal(t, tc.expectedReschedule, reschedule, tc.name)
+		})
+	}
+}
+
+func TestHandler_enforceCooldown(t *testing.T) {
+	c := clock.NewFake(time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC))
+	h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+	h.clock = c
+	h.lastTick = c.Now()
+
+	completeCh := make(chan bool)
+	go func() { completeCh <- h.enforceCooldown(context.Background(), 5*time.Minute) }()
+
+	c.BlockUntil(1)
+	h.stateLock.RLock()
+	assert.Equal(t, c.Now().Add(5*time.Minute), h.cooldownUntil)
+	h.stateLock.RUnlock()
+
+	c.Advance(5*time.Minute - time.Second)
+	select {
+	case <-completeCh:
+		t.Fatal("cooldown completed early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case complete := <-completeCh:
+		assert.True(t, complete)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for cooldown to complete")
+	}
+	assert.True(t, h.lastTick.IsZero())
+}
+
+func Test_policyChanges(t *testing.T) {
+	newPolicy := func() *sdk.ScalingPolicy {
+		return &sdk.ScalingPolicy{
+			Enabled:            true,
+			EvaluationInterval: 10 * time.Second,
+			Min:                1,
+			Max:                10,
+			Checks: []*sdk.ScalingPolicyCheck{
+				{
+					Name:     "cpu",
+					Source:   "prometheus",
+					Query:    "avg_cpu",
+					Strategy: &sdk.ScalingPolicyStrategy{Name: "target-value", Config: map[string]string{"target": "70"}},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		inputModify     func(p *sdk.ScalingPolicy)
+		expectedChanges []interface{}
+		name            string
+	}{
+		{
+			inputModify:     func(p *sdk.ScalingPolicy) {},
+			expectedChanges: nil,
+			name:            "no changes",
+		},
+		{
+			inputModify: func(p *sdk.ScalingPolicy) {
+				p.EvaluationInterval = time.Minute
+				p.Max = 20
+			},
+			expectedChanges: []interface{}{"evaluation_interval", "10s -> 1m0s", "max", "10 -> 20"},
+			name:            "interval and bounds",
+		},
+		{
+			inputModify: func(p *sdk.ScalingPolicy) {
+				p.Checks[0].Query = "max_cpu"
+				p.Checks[0].Strategy.Config = map[string]string{"target": "80"}
+			},
+			expectedChanges: []interface{}{
+				"check.cpu.query", "avg_cpu -> max_cpu",
+				"check.cpu.strategy_config", "map[target:70] -> map[target:80]",
+			},
+			name: "check query and strategy config",
+		},
+		{
+			inputModify: func(p *sdk.ScalingPolicy) {
+				p.Checks[0].Name = "memory"
+			},
+			expectedChanges: []interface{}{"check.memory", "added", "check.cpu", "removed"},
+			name:            "check replaced",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next := newPolicy()
+			tc.inputModify(next)
+			assert.Equal(t, tc.expectedChanges, policyChanges(newPolicy(), next), tc.name)
+		})
+	}
+}
+
+func TestHandler_applyMinEvaluationInterval(t *testing.T) {
+	testCases := []struct {
+		inputMin       time.Duration
+		inputInterval  time.Duration
+		expectedOutput time.Duration
+		name           string
+	}{
+		{
+			inputMin:       0,
+			inputInterval:  time.Second,
+			expectedOutput: time.Second,
+			name:           "no minimum",
+		},
+		{
+			inputMin:       10 * time.Second,
+			inputInterval:  time.Minute,
+			expectedOutput: time.Minute,
+			name:           "interval above minimum",
+		},
+		{
+			inputMin:       10 * time.Second,
+			inputInterval:  time.Second,
+			expectedOutput: 10 * time.Second,
+			name:           "interval raised to minimum",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler("", hclog.NewNullLogger(), nil, nil)
+			h.minEvaluationInterval = tc.inputMin
+
+			p := &sdk.ScalingPolicy{EvaluationInterval: tc.inputInterval}
+			h.applyMinEvaluationInterval(p)
+			assert.Equal(t, tc.expectedOutput, p.EvaluationInterval, tc.name)
+		})
+	}
+}
+
+func TestHandler_describe(t *testing.T) {
+	testCases := []struct {
+		inputPolicy               *sdk.ScalingPolicy
+		inputRequestedInterval    time.Duration
+		expectedOK                bool
+		expectedRequestedInterval time.Duration
+		name                      string
+	}{
+		{
+			inputPolicy: nil,
+			expectedOK:  false,
+			name:        "policy not received",
+		},
+		{
+			inputPolicy:               &sdk.ScalingPolicy{ID: "policy1", EvaluationInterval: 10 * time.Second},
+			inputRequestedInterval:    10 * time.Second,
+			expectedOK:                true,
+			expectedRequestedInterval: 0,
+			name:                      "interval not raised",
+		},
+		{
+			inputPolicy:               &sdk.ScalingPolicy{ID: "policy1", EvaluationInterval: 10 * time.Second},
+			inputRequestedInterval:    time.Second,
+			expectedOK:                true,
+			expectedRequestedInterval: time.Second,
+			name:                      "interval raised to minimum",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler("policy1", hclog.NewNullLogger(), nil, &fakeSource{})
+			if tc.inputPolicy != nil {
+				h.setPolicy(tc.inputPolicy, tc.inputRequestedInterval)
+			}
+
+			desc, ok := h.describe()
+			assert.Equal(t, tc.expectedOK, ok, tc.name)
+			if !tc.expectedOK {
+				return
+			}
+
+			assert.Equal(t, tc.inputPolicy, desc.Policy, tc.name)
+			assert.Equal(t, tc.expectedRequestedInterval, desc.RequestedEvaluationInterval, tc.name)
+			assert.Equal(t, PolicyID("policy1"), desc.PolicyID, tc.name)
+			assert.Equal(t, SourceNameFile, desc.Source, tc.name)
+		})
+	}
+}
+
+func TestHandler_checkQueries(t *testing.T) {
+	h := NewHandler("policy1", hclog.NewNullLogger(), nil, &fakeSource{})
+	p := &sdk.ScalingPolicy{ID: "policy1", Checks: []*sdk.ScalingPolicyCheck{{Query: "valid"}}}
+
+	// Queries are not validated without a validator.
+	h.checkQueries(p)
+	assert.False(t, h.hasInvalidQueries())
+
+	h.validateQueries = func(p *sdk.ScalingPolicy) error {
+		if p.Checks[0].Query != "valid" {
+			return errors.New("syntax error")
+		}
+		return nil
+	}
+
+	h.checkQueries(p)
+	assert.False(t, h.hasInvalidQueries())
+	assert.Empty(t, h.State().InvalidQueries)
+
+	// An invalid query marks the policy invalid until it is updated with
+	// valid queries.
+	invalid := &sdk.ScalingPolicy{ID: "policy1", Checks: []*sdk.ScalingPolicyCheck{{Query: "invalid"}}}
+	h.checkQueries(invalid)
+	assert.True(t, h.hasInvalidQueries())
+	assert.Equal(t, "syntax error", h.State().InvalidQueries)
+
+	h.checkQueries(p)
+	assert.False(t, h.hasInvalidQueries())
+	assert.Empty(t, h.State().InvalidQueries)
+}
+
+func TestHandler_Stop_beforeRun(t *testing.T) {
+	s := &fakeSource{}
+	h := NewHandler("policy1", hclog.NewNullLogger(), nil, s)
+
+	// A handler stopped before it starts running, such as when its policy is
+	// removed while it is being set up, must not start monitoring.
+	h.Stop()
+	h.Stop()
+
+	doneCh := make(chan struct{})
+	go func() {
+		h.Run(context.Background(), make(chan *sdk.ScalingEvaluation))
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for stopped handler to return")
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&s.monitoring))
+}
@@ -0,0 +1,335 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/policy/file"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+const (
+	// retryMinWait and retryMaxWait bound the exponential backoff used when
+	// requests to the remote endpoint fail.
+	retryMinWait = 1 * time.Second
+	retryMaxWait = 2 * time.Minute
+
+	// defaultPollInterval is used when the source is not configured with a
+	// poll interval.
+	defaultPollInterval = 30 * time.Second
+)
+
+// Ensure Source satisfies the Source interface.
+var _ policy.Source = (*Source)(nil)
+
+// Source is the HTTP implementation of the policy.Source interface. It polls
+// a remote endpoint for the list of policy IDs and reads each policy from the
+// endpoint URL suffixed with the policy ID.
+//
+// The list endpoint must respond with a JSON array of policy IDs. Policies
+// are written in the same format as file policies and are decoded as JSON if
+// the response Content-Type is application/json, otherwise as HCL.
+type Source struct {
+	address         string
+	headers         map[string]string
+	pollInterval    time.Duration
+	client          *http.Client
+	log             hclog.Logger
+	policyProcessor *policy.Processor
+
+	// reloadChannels help coordinate reloading the of the MonitorIDs routine.
+	reloadCh         chan struct{}
+	reloadCompleteCh chan struct{}
+}
+
+// validator holds the response headers used to detect whether a remote
+// resource has changed since it was last read. It is the HTTP equivalent of
+// the index used by Nomad blocking queries.
+type validator struct {
+	etag         string
+	lastModified string
+}
+
+// fetchResponse is the result of reading a remote resource.
+type fetchResponse struct {
+	body        []byte
+	contentType string
+	notModified bool
+}
+
+// NewHTTPSource returns a new HTTP policy source.
+func NewHTTPSource(log hclog.Logger, address string, headers map[string]string,
+	pollInterval time.Duration, policyProcessor *policy.Processor) policy.Source {
+
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	return &Source{
+		address:          strings.TrimSuffix(address, "/"),
+		headers:          headers,
+		pollInterval:     pollInterval,
+		client:           &http.Client{Timeout: 30 * time.Second},
+		log:              log.ResetNamed("http_policy_source"),
+		policyProcessor:  policyProcessor,
+		reloadCh:         make(chan struct{}),
+		reloadCompleteCh: make(chan struct{}, 1),
+	}
+}
+
+// Name satisfies the Name function of the policy.Source interface.
+func (s *Source) Name() policy.SourceName {
+	return policy.SourceNameHTTP
+}
+
+// MonitorIDs satisfies the MonitorIDs function of the policy.Source interface.
+func (s *Source) MonitorIDs(ctx context.Context, req policy.MonitorIDsReq) {
+	s.log.Debug("starting http policy source ID monitor")
+
+	v := &validator{}
+	attempt := 0
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		reload := false
+
+		select {
+		case <-ctx.Done():
+			s.log.Trace("stopping http policy source ID monitor")
+			return
+
+		case <-s.reloadCh:
+			s.log.Info("http policy source ID monitor received reload signal")
+
+			// Reset the validator so the list is always sent on reload.
+			v = &validator{}
+			reload = true
+
+		case <-timer.C:
+		}
+
+		err := s.identifyPolicyIDs(ctx, v, req.ResultCh)
+		if reload {
+			s.reloadCompleteCh <- struct{}{}
+		}
+
+		timer.Stop()
+		if err != nil {
+			policy.HandleSourceError(s.Name(), err, req.ErrCh)
+			timer.Reset(retryWait(attempt))
+			attempt++
+			continue
+		}
+
+		attempt = 0
+		timer.Reset(s.pollInterval)
+	}
+}
+
+// ReloadIDsMonitor satisfies the ReloadIDsMonitor function of the
+// policy.Source interface.
+func (s *Source) ReloadIDsMonitor() {
+	s.reloadCh <- struct{}{}
+	<-s.reloadCompleteCh
+}
+
+// MonitorPolicy satisfies the MonitorPolicy function of the policy.Source
+// interface.
+func (s *Source) MonitorPolicy(ctx context.Context, req policy.MonitorPolicyReq) {
+
+	// Close channels when done with the monitoring loop.
+	defer close(req.ResultCh)
+	defer close(req.ErrCh)
+
+	log := s.log.With("policy_id", req.ID)
+	log.Debug("starting http policy monitor")
+
+	var current *sdk.ScalingPolicy
+	v := &validator{}
+	attempt := 0
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug("stopping http policy monitor due to context done")
+			return
+
+		case <-req.ReloadCh:
+			log.Info("http policy source monitor received reload signal")
+			v = &validator{}
+
+		case <-timer.C:
+		}
+
+		p, err := s.readPolicy(ctx, req.ID, v)
+
+		timer.Stop()
+		if err != nil {
+			policy.HandleSourceError(s.Name(), fmt.Errorf("failed to get policy %s: %v", req.ID, err), req.ErrCh)
+			timer.Reset(retryWait(attempt))
+			attempt++
+			continue
+		}
+		attempt = 0
+		timer.Reset(s.pollInterval)
+
+		// A nil policy indicates the remote policy has not been modified.
+		if p == nil || reflect.DeepEqual(p, current) {
+			continue
+		}
+
+		if current != nil {
+			log.Info("http policy content has changed")
+		}
+		current = p
+
+		select {
+		case req.ResultCh <- *p:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// identifyPolicyIDs reads the list of policy IDs from the remote endpoint and
+// sends them to the resultCh if the list has changed since it was last read.
+func (s *Source) identifyPolicyIDs(ctx context.Context, v *validator, resultCh chan<- policy.IDMessage) error {
+	resp, err := s.fetch(ctx, s.address, v)
+	if err != nil {
+		return fmt.Errorf("failed to list policies: %v", err)
+	}
+	if resp.notModified {
+		return nil
+	}
+
+	var ids []string
+	if err := json.Unmarshal(resp.body, &ids); err != nil {
+		return fmt.Errorf("failed to decode policy list: %v", err)
+	}
+
+	policyIDs := make([]policy.PolicyID, 0, len(ids))
+	for _, id := range ids {
+		policyIDs = append(policyIDs, policy.PolicyID(id))
+	}
+
+	select {
+	case resultCh <- policy.IDMessage{IDs: policyIDs, Source: s.Name()}:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// readPolicy reads, decodes and validates an individual policy from the remote
+// endpoint. A nil policy is returned if the policy has not been modified since
+// it was last read.
+func (s *Source) readPolicy(ctx context.Context, ID policy.PolicyID, v *validator) (*sdk.ScalingPolicy, error) {
+	resp, err := s.fetch(ctx, s.address+"/"+url.PathEscape(ID.String()), v)
+	if err != nil {
+		return nil, err
+	}
+	if resp.notModified {
+		return nil, nil
+	}
+
+	// The filename suffix is used by the decoder to identify the format.
+	filename := ID.String() + ".hcl"
+	if strings.HasPrefix(resp.contentType, "application/json") {
+		filename = ID.String() + ".json"
+	}
+
+	p := &sdk.ScalingPolicy{}
+	if err := file.Decode(filename, resp.body, p); err != nil {
+		return nil, fmt.Errorf("failed to decode policy: %v", err)
+	}
+	p.ID = ID.String()
+	s.policyProcessor.ApplyPolicyDefaults(p)
+
+	// Prefer the entity tag of the response as the policy revision, as it is
+	// the version identifier of the endpoint. The decoder otherwise uses the
+	// hash of the policy document.
+	if etag := strings.Trim(strings.TrimPrefix(v.etag, "W/"), `"`); etag != "" {
+		p.Revision = etag
+	}
+
+	if err := s.policyProcessor.ValidatePolicy(p); err != nil {
+		return nil, fmt.Errorf("failed to validate policy: %v", err)
+	}
+
+	for _, c := range p.Checks {
+		s.policyProcessor.CanonicalizeCheck(c, p.Target)
+	}
+
+	return p, nil
+}
+
+// fetch performs a conditional GET request against the URL using the stored
+// validator, updating it from the response.
+func (s *Source) fetch(ctx context.Context, u string, v *validator) (*fetchResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	for k, val := range s.headers {
+		req.Header.Set(k, val)
+	}
+	if v.etag != "" {
+		req.Header.Set("If-None-Match", v.etag)
+	}
+	if v.lastModified != "" {
+		req.Header.Set("If-Modified-Since", v.lastModified)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return &fetchResponse{notModified: true}, nil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("unexpected response code %d from %s", resp.StatusCode, u)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	v.etag = resp.Header.Get("ETag")
+	v.lastModified = resp.Header.Get("Last-Modified")
+
+	return &fetchResponse{body: body, contentType: resp.Header.Get("Content-Type")}, nil
+}
+
+// retryWait returns the time to wait before retrying a failed request, using
+// an exponential backoff based on the number of previous attempts.
+func retryWait(attempt int) time.Duration {
+	if attempt > 16 {
+		return retryMaxWait
+	}
+
+	wait := retryMinWait << uint(attempt)
+	if wait > retryMaxWait {
+		return retryMaxWait
+	}
+	return wait
+}
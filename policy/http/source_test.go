@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPolicy = `
+enabled = true
+min     = 1
+max     = 10
+
+policy {
+  cooldown            = "1m"
+  evaluation_interval = "10s"
+
+  check "cpu" {
+    source = "prometheus"
+    query  = "cpu"
+
+    strategy "target-value" {
+      target = "80"
+    }
+  }
+
+  target "aws-asg" {
+    aws_asg_name = "my-asg"
+  }
+}
+`
+
+func newTestSource(handler http.HandlerFunc) (*Source, *httptest.Server) {
+	srv := httptest.NewServer(handler)
+
+	processor := policy.NewProcessor(&policy.ConfigDefaults{
+		DefaultEvaluationInterval: 10 * time.Second,
+		DefaultCooldown:           time.Minute,
+	}, []string{"nomad-apm"})
+
+	s := NewHTTPSource(hclog.NewNullLogger(), srv.URL, map[string]string{"Authorization": "Bearer token"},
+		time.Minute, processor).(*Source)
+	return s, srv
+}
+
+func TestSource_fetch(t *testing.T) {
+	s, srv := newTestSource(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`["policy1"]`))
+	})
+	defer srv.Close()
+
+	v := &validator{}
+
+	resp, err := s.fetch(context.Background(), s.address, v)
+	assert.Nil(t, err)
+	assert.False(t, resp.notModified)
+	assert.Equal(t, `["policy1"]`, string(resp.body))
+	assert.Equal(t, `"v1"`, v.etag)
+
+	resp, err = s.fetch(context.Background(), s.address, v)
+	assert.Nil(t, err)
+	assert.True(t, resp.notModified)
+}
+
+func TestSource_identifyPolicyIDs(t *testing.T) {
+	s, srv := newTestSource(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`["policy1", "policy2"]`))
+	})
+	defer srv.Close()
+
+	resultCh := make(chan policy.IDMessage, 1)
+	err := s.identifyPolicyIDs(context.Background(), &validator{}, resultCh)
+	assert.Nil(t, err)
+
+	expected := policy.IDMessage{IDs: []policy.PolicyID{"policy1", "policy2"}, Source: policy.SourceNameHTTP}
+	assert.Equal(t, expected, <-resultCh)
+}
+
+func TestSource_readPolicy(t *testing.T) {
+	s, srv := newTestSource(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/policy1":
+			w.Header().Set("Last-Modified", "Wed, 21 Oct 2020 07:28:00 GMT")
+			_, _ = w.Write([]byte(testPolicy))
+		case "/policy2":
+			w.Header().Set("ETag", `W/"abc123"`)
+			_, _ = w.Write([]byte(testPolicy))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer srv.Close()
+
+	v := &validator{}
+	p, err := s.readPolicy(context.Background(), "policy1", v)
+	assert.Nil(t, err)
+	assert.Equal(t, "policy1", p.ID)
+	assert.Equal(t, int64(10), p.Max)
+	assert.Equal(t, "aws-asg", p.Target.Name)
+	assert.Len(t, p.Checks, 1)
+	assert.Equal(t, "Wed, 21 Oct 2020 07:28:00 GMT", v.lastModified)
+
+	// Without an ETag, the revision is the hash of the policy document.
+	assert.Len(t, p.Revision, 40)
+
+	p, err = s.readPolicy(context.Background(), "policy2", &validator{})
+	assert.Nil(t, err)
+	assert.Equal(t, "abc123", p.Revision)
+
+	_, err = s.readPolicy(context.Background(), "missing", &validator{})
+	assert.Error(t, err)
+}
+
+func Test_retryWait(t *testing.T) {
+	testCases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 0, expected: 1 * time.Second},
+		{attempt: 3, expected: 8 * time.Second},
+		{attempt: 7, expected: retryMaxWait},
+		{attempt: 100, expected: retryMaxWait},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, retryWait(tc.attempt))
+	}
+}
@@ -2,6 +2,8 @@ package policy
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -10,8 +12,25 @@ import (
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
 	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/clock"
 )
 
+const (
+	// sourceRestartMinWait and sourceRestartMaxWait bound the exponential
+	// backoff used when restarting a policy source which stopped monitoring
+	// the list of policy IDs.
+	sourceRestartMinWait = 1 * time.Second
+	sourceRestartMaxWait = 2 * time.Minute
+)
+
+// ErrPolicyNotFound is returned when an operation targets a policy which is
+// not being handled by the manager.
+var ErrPolicyNotFound = errors.New("policy not found")
+
+// ErrPolicyConflict is returned when registering a policy whose ID is already
+// used by a policy from another source.
+var ErrPolicyConflict = errors.New("policy ID is used by a policy from another source")
+
 // Manager tracks policies and controls the lifecycle of each policy handler.
 type Manager struct {
 	log           hclog.Logger
@@ -24,26 +43,81 @@ type Manager struct {
 	// handlers are used to track the Go routines monitoring policies.
 	handlers map[PolicyID]*Handler
 
-	// keep is used to mark active policies during reconciliation.
-	keep map[PolicyID]bool
+	// removals tracks the policies which are no longer listed by their
+	// source, but whose handlers are kept running until removalGracePeriod
+	// has passed. The removal stops the handler when its timer fires.
+	removals           map[PolicyID]*removal
+	removalGracePeriod time.Duration
 
 	// metricsInterval is the interval at which the agent is configured to emit
 	// metrics. This is used when creating the periodicMetricsReporter.
 	metricsInterval time.Duration
+
+	// minEvaluationInterval is the shortest evaluation interval policies are
+	// allowed to use. Zero means no minimum is enforced.
+	minEvaluationInterval time.Duration
+
+	// clock is used by the manager and its handlers to read the current time
+	// and wait for it to pass.
+	clock clock.Clock
+
+	// claims tracks the sources listing each policy ID, in the order they
+	// listed it, so policies from different sources which share an ID are
+	// detected and resolved using conflicts.
+	claims    map[PolicyID][]SourceName
+	conflicts ConflictResolution
+
+	// handlerSeq orders the handlers by when they were created, which is used
+	// to resolve target conflicts using first-wins.
+	handlerSeq uint64
+
+	// queryValidator validates the queries of the policy checks when a
+	// handler receives a policy, if set.
+	queryValidator func(*sdk.ScalingPolicy) error
+
+	// dependencies tracks the policies each policy depends upon, and is
+	// shared with the handlers which update it as they receive their policy.
+	dependencies *dependencyGraph
 }
 
 // NewManager returns a new Manager.
-func NewManager(log hclog.Logger, ps map[SourceName]Source, pm *manager.PluginManager, mInt time.Duration) *Manager {
+func NewManager(log hclog.Logger, ps map[SourceName]Source, pm *manager.PluginManager, mInt, minEvalInt, removalGrace time.Duration) *Manager {
 	return &Manager{
-		log:             log.ResetNamed("policy_manager"),
-		policySource:    ps,
-		pluginManager:   pm,
-		handlers:        make(map[PolicyID]*Handler),
-		keep:            make(map[PolicyID]bool),
-		metricsInterval: mInt,
+		log:                   log.ResetNamed("policy_manager"),
+		policySource:          ps,
+		pluginManager:         pm,
+		handlers:              make(map[PolicyID]*Handler),
+		removals:              make(map[PolicyID]*removal),
+		removalGracePeriod:    removalGrace,
+		metricsInterval:       mInt,
+		minEvaluationInterval: minEvalInt,
+		clock:                 clock.Real(),
+		claims:                make(map[PolicyID][]SourceName),
+		conflicts:             ConflictResolution{Mode: ConflictModeFirstWins},
+		dependencies:          newDependencyGraph(),
 	}
 }
 
+// SetClock replaces the clock used by the manager and the handlers it
+// creates. It must be called before the manager runs.
+func (m *Manager) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+// SetConflictResolution configures how policies from different sources which
+// share an ID or a target are handled. It must be called before the manager
+// runs.
+func (m *Manager) SetConflictResolution(c ConflictResolution) {
+	m.conflicts = c
+}
+
+// SetQueryValidator configures the function used to validate the queries of
+// the policy checks when a policy is loaded or updated. Policies with invalid
+// queries are not evaluated. It must be called before the manager runs.
+func (m *Manager) SetQueryValidator(fn func(*sdk.ScalingPolicy) error) {
+	m.queryValidator = fn
+}
+
 // Run starts the manager and blocks until the context is canceled.
 // Policies that need to be evaluated are sent in the evalCh.
 func (m *Manager) Run(ctx context.Context, evalCh chan<- *sdk.ScalingEvaluation) {
@@ -63,7 +137,7 @@ func (m *Manager) Run(ctx context.Context, evalCh chan<- *sdk.ScalingEvaluation)
 	// Start the policy source and listen for changes in the list of policy IDs
 	for _, s := range m.policySource {
 		req := MonitorIDsReq{ErrCh: policyIDsErrCh, ResultCh: policyIDsCh}
-		go s.MonitorIDs(monitorCtx, req)
+		go m.monitorSourceIDs(monitorCtx, s, req)
 	}
 
 LOOP:
@@ -73,63 +147,33 @@ LOOP:
 			m.log.Trace("stopping policy manager")
 			return
 
-		case err := <-policyIDsErrCh:
+		case err, ok := <-policyIDsErrCh:
+			// A closed channel means the subscription to the policy sources
+			// is lost and must be re-established.
+			if !ok {
+				m.log.Warn("policy sources error channel closed")
+				break LOOP
+			}
+
 			m.log.Error(err.Error())
 			if isUnrecoverableError(err) {
 				break LOOP
 			}
 			continue
 
-		case policyIDs := <-policyIDsCh:
-			m.log.Trace("received policy IDs listing",
-				"num", len(policyIDs.IDs), "policy_source", policyIDs.Source)
-
-			m.lock.Lock()
-
-			// Reset set of policies to keep. We will remove the policies that
-			// are not in policyIDs to reconcile our state.
-			m.keep = make(map[PolicyID]bool)
-
-			// Iterate over policy IDs and create new handlers if necessary
-			for _, policyID := range policyIDs.IDs {
-
-				// Mark policy as must-keep so it doesn't get removed.
-				m.keep[policyID] = true
-
-				// Check if we already have a handler for this policy.
-				if _, ok := m.handlers[policyID]; ok {
-					m.log.Trace("handler already exists",
-						"policy_id", policyID, "policy_source", policyIDs.Source)
-					continue
-				}
-
-				// Create and store a new handler and use its channels to monitor
-				// the policy for changes.
-				m.log.Trace("creating new handler",
-					"policy_id", policyID, "policy_source", policyIDs.Source)
-
-				h := NewHandler(policyID, m.log, m.pluginManager, m.policySource[policyIDs.Source])
-				m.handlers[policyID] = h
-
-				go func(ID PolicyID) {
-					h.Run(ctx, evalCh)
-
-					// Remove the handler when it stops running.
-					m.lock.Lock()
-					delete(m.handlers, ID)
-					m.lock.Unlock()
-				}(policyID)
+		case policyIDs, ok := <-policyIDsCh:
+			// Do not treat the read of a closed channel as an empty list of
+			// policies, as this would stop the handlers of every policy.
+			if !ok {
+				m.log.Warn("policy sources result channel closed")
+				break LOOP
 			}
 
-			// Remove and stop handlers for policies that don't exist anymore
-			// for the source which manages them.
-			for k, h := range m.handlers {
-				if !m.keep[k] && h.policySource.Name() == policyIDs.Source {
-					m.stopHandler(h)
-				}
+			// Sources may deliver listings faster than they are reconciled,
+			// so only the latest pending listing of each source is applied.
+			for _, msg := range latestIDMessages(policyIDs, policyIDsCh) {
+				m.reconcileIDs(ctx, evalCh, msg)
 			}
-
-			m.lock.Unlock()
 		}
 	}
 
@@ -141,14 +185,267 @@ LOOP:
 	// of relying on the deferred statements, otherwise the next iteration of
 	// m.Run would be executed before they are complete.
 	m.stopHandlers()
+	m.lock.Lock()
 	m.handlers = make(map[PolicyID]*Handler)
+	m.claims = make(map[PolicyID][]SourceName)
+	m.lock.Unlock()
 	cancel()
 
 	// Delay the next iteration of m.Run to avoid re-runs to start too often.
-	time.Sleep(10 * time.Second)
+	m.clock.Sleep(10 * time.Second)
 	go m.Run(ctx, evalCh)
 }
 
+// latestIDMessages returns the latest listing of each source, out of first and
+// the listings already pending on ch, without waiting for more. Applying only
+// the latest listing avoids creating handlers for policies which are removed
+// again by a listing received immediately after. A closed channel is left for
+// the caller to detect.
+func latestIDMessages(first IDMessage, ch <-chan IDMessage) []IDMessage {
+	msgs := []IDMessage{first}
+	index := map[SourceName]int{first.Source: 0}
+
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return msgs
+			}
+			if i, ok := index[msg.Source]; ok {
+				msgs[i] = msg
+				continue
+			}
+			index[msg.Source] = len(msgs)
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+// reconcileSummary counts the changes made to the policy handlers when
+// reconciling a listing of policy IDs.
+type reconcileSummary struct {
+	added     int
+	removed   int
+	unchanged int
+}
+
+// reconcileIDs reconciles the policy handlers with a listing of the policy IDs
+// of a source. Handlers are created for new policies and removed for policies
+// which are no longer listed by any source. Policies listed by more than one
+// source are resolved using the conflict mode. The listing is applied while
+// holding the lock, so it is atomic with regard to other listings and to the
+// handlers stopping.
+func (m *Manager) reconcileIDs(ctx context.Context, evalCh chan<- *sdk.ScalingEvaluation, policyIDs IDMessage) reconcileSummary {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var summary reconcileSummary
+
+	listed := make(map[PolicyID]bool, len(policyIDs.IDs))
+	for _, id := range policyIDs.IDs {
+		listed[id] = true
+	}
+
+	// Update the claims of the source. The handlers of the policies it lists
+	// now, and of those it listed previously, may need to change.
+	affected := make([]PolicyID, 0, len(policyIDs.IDs))
+	for id, sources := range m.claims {
+		if listed[id] || !containsSource(sources, policyIDs.Source) {
+			continue
+		}
+		m.claims[id] = removeSource(sources, policyIDs.Source)
+		if len(m.claims[id]) == 0 {
+			delete(m.claims, id)
+		}
+		affected = append(affected, id)
+	}
+	seen := make(map[PolicyID]bool, len(policyIDs.IDs))
+	for _, id := range policyIDs.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if !containsSource(m.claims[id], policyIDs.Source) {
+			m.claims[id] = append(m.claims[id], policyIDs.Source)
+		}
+		affected = append(affected, id)
+	}
+
+	for _, id := range affected {
+		m.reconcileID(ctx, evalCh, id, &summary)
+	}
+
+	m.log.Info("reconciled policy IDs listing", "policy_source", policyIDs.Source,
+		"num", len(policyIDs.IDs), "added", summary.added, "removed", summary.removed,
+		"unchanged", summary.unchanged)
+
+	return summary
+}
+
+// reconcileID reconciles the handler of the policy with the sources claiming
+// it, recording the changes made in the summary.
+//
+// This method is not thread-safe so a RW lock should be acquired before
+// calling it.
+func (m *Manager) reconcileID(ctx context.Context, evalCh chan<- *sdk.ScalingEvaluation, id PolicyID, summary *reconcileSummary) {
+	sources := m.claims[id]
+	h := m.handlers[id]
+	winner, ok := m.conflicts.winner(sources)
+
+	switch {
+	case len(sources) == 0:
+		// Policies waiting for the removal grace period were counted by a
+		// previous listing.
+		if h != nil {
+			if _, ok := m.removals[id]; !ok {
+				summary.removed++
+			}
+			m.removeHandler(h)
+		}
+		return
+
+	case !ok:
+		m.log.Error("policy ID is listed by multiple sources, none of the policies are used",
+			"policy_id", id, "policy_sources", sources, "conflict_mode", m.conflicts.Mode)
+		if h != nil {
+			m.stopHandler(h)
+			summary.removed++
+		}
+		return
+
+	case len(sources) > 1:
+		m.log.Warn("policy ID is listed by multiple sources",
+			"policy_id", id, "policy_sources", sources, "conflict_mode", m.conflicts.Mode,
+			"active_source", sources[winner])
+	}
+
+	// Check if we already have a handler for this policy. A policy which
+	// reappeared within the removal grace period keeps it.
+	if h != nil {
+		if h.policySource.Name() == sources[winner] {
+			m.log.Trace("handler already exists",
+				"policy_id", id, "policy_source", sources[winner])
+			m.cancelRemoval(id)
+			summary.unchanged++
+			return
+		}
+
+		// The policy is taken over by a source with a higher priority.
+		m.stopHandler(h)
+		summary.removed++
+	}
+
+	m.startHandler(ctx, evalCh, id, sources[winner])
+	summary.added++
+}
+
+// startHandler creates and stores a new handler for the policy, which uses
+// its channels to monitor the policy for changes.
+//
+// This method is not thread-safe so a RW lock should be acquired before
+// calling it.
+func (m *Manager) startHandler(ctx context.Context, evalCh chan<- *sdk.ScalingEvaluation, id PolicyID, source SourceName) {
+	m.log.Trace("creating new handler", "policy_id", id, "policy_source", source)
+
+	h := NewHandler(id, m.log, m.pluginManager, m.policySource[source])
+	h.minEvaluationInterval = m.minEvaluationInterval
+	h.validateQueries = m.queryValidator
+	h.dependencies = m.dependencies
+	h.clock = m.clock
+
+	m.handlerSeq++
+	h.seq = m.handlerSeq
+	m.handlers[id] = h
+
+	go func() {
+		h.Run(ctx, evalCh)
+
+		// Remove the handler when it stops running. The handler may already
+		// have been replaced by a new handler for the same policy, which
+		// must be kept.
+		m.lock.Lock()
+		if m.handlers[h.policyID] == h {
+			delete(m.handlers, h.policyID)
+			m.dependencies.remove(h.policyID)
+		}
+		m.lock.Unlock()
+	}()
+}
+
+// containsSource returns whether the source is within sources.
+func containsSource(sources []SourceName, source SourceName) bool {
+	for _, s := range sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// removeSource returns sources without the source, keeping the order.
+func removeSource(sources []SourceName, source SourceName) []SourceName {
+	out := make([]SourceName, 0, len(sources))
+	for _, s := range sources {
+		if s != source {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// monitorSourceIDs runs the MonitorIDs function of the source, restarting it
+// with an exponential backoff if it returns before the context is canceled.
+// This ensures a transient failure within a source does not silently stop the
+// updates of its policies.
+func (m *Manager) monitorSourceIDs(ctx context.Context, s Source, req MonitorIDsReq) {
+	var attempt int
+
+	for {
+		start := m.clock.Now()
+		s.MonitorIDs(ctx, req)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Reset the backoff if the source had been running for a while, so
+		// occasional failures do not build up the wait time.
+		if m.clock.Since(start) > sourceRestartMaxWait {
+			attempt = 0
+		}
+
+		wait := sourceRestartWait(attempt)
+		attempt++
+
+		m.log.Warn("policy source stopped monitoring policy IDs, restarting",
+			"policy_source", s.Name(), "wait", wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.clock.After(wait):
+		}
+	}
+}
+
+// sourceRestartWait returns the time to wait before restarting a policy
+// source, using an exponential backoff based on the number of previous
+// attempts.
+func sourceRestartWait(attempt int) time.Duration {
+	if attempt > 16 {
+		return sourceRestartMaxWait
+	}
+
+	wait := sourceRestartMinWait << uint(attempt)
+	if wait > sourceRestartMaxWait {
+		return sourceRestartMaxWait
+	}
+	return wait
+}
+
 func (m *Manager) stopHandlers() {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -168,8 +465,90 @@ func (m *Manager) stopHandler(h *Handler) {
 		return
 	}
 
+	if r, ok := m.removals[h.policyID]; ok {
+		r.stop()
+		delete(m.removals, h.policyID)
+	}
+
 	h.Stop()
 	delete(m.handlers, h.policyID)
+	m.dependencies.remove(h.policyID)
+}
+
+// removeHandler stops the handler of a policy which is no longer listed by its
+// source. If a removal grace period is configured, the handler keeps running
+// until the period has passed, so a policy which briefly disappears from its
+// source keeps its state.
+//
+// This method is not thread-safe so a RW lock should be acquired before
+// calling it.
+func (m *Manager) removeHandler(h *Handler) {
+	if m.removalGracePeriod <= 0 {
+		m.stopHandler(h)
+		return
+	}
+
+	if _, ok := m.removals[h.policyID]; ok {
+		return
+	}
+
+	m.log.Debug("policy no longer listed by source, waiting for removal grace period",
+		"policy_id", h.policyID, "grace_period", m.removalGracePeriod)
+
+	r := &removal{
+		timer:  m.clock.NewTimer(m.removalGracePeriod),
+		doneCh: make(chan struct{}),
+	}
+	m.removals[h.policyID] = r
+
+	go func() {
+		select {
+		case <-r.doneCh:
+			return
+		case <-r.timer.C():
+		}
+
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		// The removal may have been canceled while waiting for the lock.
+		if m.removals[h.policyID] != r {
+			return
+		}
+
+		m.log.Debug("removal grace period passed, stopping policy handler", "policy_id", h.policyID)
+		m.stopHandler(m.handlers[h.policyID])
+		delete(m.removals, h.policyID)
+	}()
+}
+
+// cancelRemoval keeps the handler of a policy which reappeared within the
+// removal grace period running.
+//
+// This method is not thread-safe so a RW lock should be acquired before
+// calling it.
+func (m *Manager) cancelRemoval(id PolicyID) {
+	r, ok := m.removals[id]
+	if !ok {
+		return
+	}
+
+	m.log.Info("policy reappeared within removal grace period, keeping its handler", "policy_id", id)
+	r.stop()
+	delete(m.removals, id)
+}
+
+// removal is the pending removal of a policy handler, which happens once its
+// timer fires unless it is stopped first.
+type removal struct {
+	timer  clock.Timer
+	doneCh chan struct{}
+}
+
+// stop cancels the removal.
+func (r *removal) stop() {
+	r.timer.Stop()
+	close(r.doneCh)
 }
 
 // EnforceCooldown attempts to enforce cooldown on the policy handler
@@ -193,6 +572,378 @@ func (m *Manager) EnforceCooldown(id string, t time.Duration) {
 	}
 }
 
+// SetOverride forces the target of the policy to the override count until the
+// override expires. Any existing override of the policy is replaced. An error
+// is returned if the policy is not being handled.
+func (m *Manager) SetOverride(id string, o Override) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	h, ok := m.handlers[PolicyID(id)]
+	if !ok {
+		return ErrPolicyNotFound
+	}
+
+	m.log.Info("setting policy count override", "policy_id", id, "count", o.Count, "expiry", o.Expiry)
+	h.setOverride(&o)
+	return nil
+}
+
+// RemoveOverride removes the override of the policy, resuming the strategy
+// driven evaluation. An error is returned if the policy is not being handled.
+func (m *Manager) RemoveOverride(id string) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	h, ok := m.handlers[PolicyID(id)]
+	if !ok {
+		return ErrPolicyNotFound
+	}
+
+	m.log.Info("removing policy count override", "policy_id", id)
+	h.setOverride(nil)
+	return nil
+}
+
+// ActiveOverride returns the override of the policy if one is set and has not
+// expired, otherwise nil.
+func (m *Manager) ActiveOverride(id string) *Override {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		return h.activeOverride()
+	}
+	return nil
+}
+
+// MarkReconciled records that the target of the policy has been reconciled
+// with the policy bounds, so subsequent evaluations do not request it again.
+func (m *Manager) MarkReconciled(id string) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		h.markReconciled()
+	}
+}
+
+// RecordRecommendation records the count recommended by an evaluation of the
+// policy and returns the highest count recommended within the trailing
+// window, including count. If the policy is not being handled, count is
+// returned.
+func (m *Manager) RecordRecommendation(id string, count int64, window time.Duration) int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		return h.recordRecommendation(count, window, m.clock.Now())
+	}
+	return count
+}
+
+// HighestRecommendation returns the highest count recommended by the
+// evaluations of the policy within the trailing window, including count, in
+// the same way as RecordRecommendation but without recording count.
+func (m *Manager) HighestRecommendation(id string, count int64, window time.Duration) int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		return h.highestRecommendation(count, window, m.clock.Now())
+	}
+	return count
+}
+
+// RecordAdvice records the last recommendation of the advisory policy, so it
+// can be read through the agent API.
+func (m *Manager) RecordAdvice(id string, a Advice) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		h.setAdvice(&a)
+	}
+}
+
+// ScaleDownAllowed returns whether the policy checks are allowed to scale the
+// target of the policy down, given they can perform max scale downs in a row
+// and the count is reset once reset has passed since the last one. Scale
+// downs are always allowed if the policy is not being handled.
+func (m *Manager) ScaleDownAllowed(id string, max int64, reset time.Duration) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		return h.scaleDownAllowed(max, reset, m.clock.Now())
+	}
+	return true
+}
+
+// StepDownDelayedUntil returns the time until which the policy checks must
+// wait before scaling the target of the policy down again, given each step
+// down is followed by delay. The zero time is returned if the next step down
+// is not delayed, or the policy is not being handled.
+func (m *Manager) StepDownDelayedUntil(id string, delay time.Duration) time.Time {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		return h.stepDownDelayedUntil(delay, m.clock.Now())
+	}
+	return time.Time{}
+}
+
+// ScaleUpProbationUntil returns the time until which scale ups of the policy
+// checks are suppressed, given each scale up is followed by probation. The
+// zero time is returned if the policy is not in probation, or is not being
+// handled.
+func (m *Manager) ScaleUpProbationUntil(id string, probation time.Duration) time.Time {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		return h.scaleUpProbationUntil(probation, m.clock.Now())
+	}
+	return time.Time{}
+}
+
+// RecordScale records a scaling action performed by the policy checks, which
+// is used to track the consecutive scale downs of the policy.
+func (m *Manager) RecordScale(id string, direction sdk.ScaleDirection) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		h.recordScale(direction, m.clock.Now())
+	}
+}
+
+// BeginAction records that a scaling action of the policy is in progress, so
+// the policies which depend upon it are not evaluated until it has finished.
+func (m *Manager) BeginAction(id string) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		h.beginAction()
+	}
+}
+
+// EndAction records that the scaling action of the policy has finished, and
+// whether it scaled the target.
+func (m *Manager) EndAction(id string, scaled bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		h.endAction(scaled, m.clock.Now())
+	}
+}
+
+// BusyDependency returns the ID of the first of the dependencies which is
+// scaling its target, or has scaled it within the window. A window of zero
+// uses the cooldown of each dependency. An empty string is returned if all
+// the dependencies are stable. Dependencies which are not being handled are
+// considered stable.
+func (m *Manager) BusyDependency(dependsOn []string, window time.Duration) string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	now := m.clock.Now()
+	for _, dep := range dependsOn {
+		if h, ok := m.handlers[PolicyID(dep)]; ok && h.actionBusy(window, now) {
+			return dep
+		}
+	}
+	return ""
+}
+
+// RecordStability records whether an evaluation of the policy was stable, by
+// producing no scaling action, which drives its adaptive evaluation interval.
+func (m *Manager) RecordStability(id string, stable bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if h, ok := m.handlers[PolicyID(id)]; ok {
+		h.recordStability(stable)
+	}
+}
+
+// ResetScaleDowns acknowledges the consecutive scale downs of the policy,
+// allowing the policy checks to scale the target down again once the limit
+// was reached. An error is returned if the policy is not being handled.
+func (m *Manager) ResetScaleDowns(id string) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	h, ok := m.handlers[PolicyID(id)]
+	if !ok {
+		return ErrPolicyNotFound
+	}
+
+	m.log.Info("resetting policy consecutive scale downs", "policy_id", id)
+	h.resetScaleDowns()
+	return nil
+}
+
+// PolicySource returns the name of the source of the policy with the ID. The
+// returned bool is false if the policy is not being handled.
+func (m *Manager) PolicySource(id string) (SourceName, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	h, ok := m.handlers[PolicyID(id)]
+	if !ok || h.policySource == nil {
+		return "", false
+	}
+	return h.policySource.Name(), true
+}
+
+// HandlerStates returns a snapshot of the state of all the policy handlers,
+// sorted by policy ID.
+func (m *Manager) HandlerStates() []HandlerState {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	states := make([]HandlerState, 0, len(m.handlers))
+	for _, h := range m.handlers {
+		states = append(states, h.State())
+	}
+
+	sort.Slice(states, func(i, j int) bool { return states[i].PolicyID < states[j].PolicyID })
+	return states
+}
+
+// DescribePolicy returns the resolved view of the policy, without triggering
+// an evaluation. ErrPolicyNotFound is returned if the policy is not being
+// handled or has not yet been read from its source.
+func (m *Manager) DescribePolicy(id string) (*PolicyDescription, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	h, ok := m.handlers[PolicyID(id)]
+	if !ok {
+		return nil, ErrPolicyNotFound
+	}
+
+	d, ok := h.describe()
+	if !ok {
+		return nil, ErrPolicyNotFound
+	}
+	return d, nil
+}
+
+// TargetAllowed returns whether the policy is allowed to scale its target. It
+// is false if the target is also scaled by a policy from another source, and
+// the conflict is resolved in favour of the other policy.
+func (m *Manager) TargetAllowed(id string) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	for _, c := range m.targetConflicts() {
+		for _, p := range c.Policies {
+			if p.ID == PolicyID(id) && !p.Active {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Conflicts returns the active conflicts between policies from different
+// sources, sorted by type and key.
+func (m *Manager) Conflicts() []Conflict {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	conflicts := m.targetConflicts()
+	for id, sources := range m.claims {
+		if len(sources) < 2 {
+			continue
+		}
+
+		winner, ok := m.conflicts.winner(sources)
+		c := Conflict{Type: ConflictTypeID, Key: string(id)}
+		for i, source := range sources {
+			c.Policies = append(c.Policies, ConflictPolicy{ID: id, Source: source, Active: ok && i == winner})
+		}
+		conflicts = append(conflicts, c)
+	}
+
+	sortConflicts(conflicts)
+	return conflicts
+}
+
+// targetConflicts returns the conflicts between policies from different
+// sources which scale the same target. Policies from the same source may
+// share a target, as their scaling is serialized by the workers.
+//
+// This method is not thread-safe so a RW lock should be acquired before
+// calling it.
+func (m *Manager) targetConflicts() []Conflict {
+	if m.conflicts.TargetKey == nil {
+		return nil
+	}
+
+	targets := make(map[string][]*Handler)
+	for _, h := range m.handlers {
+		d, ok := h.describe()
+		if !ok {
+			continue
+		}
+		if key := m.conflicts.TargetKey(d.Policy); key != "" {
+			targets[key] = append(targets[key], h)
+		}
+	}
+
+	var conflicts []Conflict
+	for key, handlers := range targets {
+		sort.Slice(handlers, func(i, j int) bool { return handlers[i].seq < handlers[j].seq })
+
+		var sources []SourceName
+		for _, h := range handlers {
+			if !containsSource(sources, h.policySource.Name()) {
+				sources = append(sources, h.policySource.Name())
+			}
+		}
+		if len(sources) < 2 {
+			continue
+		}
+
+		winner, ok := m.conflicts.winner(sources)
+		c := Conflict{Type: ConflictTypeTarget, Key: key}
+		for _, h := range handlers {
+			c.Policies = append(c.Policies, ConflictPolicy{
+				ID:     h.policyID,
+				Source: h.policySource.Name(),
+				Active: ok && h.policySource.Name() == sources[winner],
+			})
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts
+}
+
+// PolicyCount returns the number of policies currently being monitored.
+func (m *Manager) PolicyCount() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return len(m.handlers)
+}
+
+// SourceNames returns the names of the configured policy sources, sorted
+// alphabetically.
+func (m *Manager) SourceNames() []SourceName {
+	names := make([]SourceName, 0, len(m.policySource))
+	for name := range m.policySource {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
 // ReloadSources triggers a reload of all the policy sources.
 func (m *Manager) ReloadSources() {
 	m.lock.Lock()
@@ -213,13 +964,14 @@ func (m *Manager) ReloadSources() {
 // which cannot be performed during inline function calls.
 func (m *Manager) periodicMetricsReporter(ctx context.Context, interval time.Duration) {
 
-	t := time.NewTicker(interval)
+	t := m.clock.NewTicker(interval)
+	defer t.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-t.C:
+		case <-t.C():
 			m.lock.RLock()
 			num := len(m.handlers)
 			m.lock.RUnlock()
@@ -0,0 +1,737 @@
+package policy
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_SetOverride(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	m.handlers["policy1"] = NewHandler("policy1", hclog.NewNullLogger(), nil, nil)
+
+	o := Override{Count: 2, Expiry: time.Now().Add(time.Hour)}
+
+	// Overrides can only be set on policies being handled.
+	assert.Equal(t, ErrPolicyNotFound, m.SetOverride("policy2", o))
+	assert.Equal(t, ErrPolicyNotFound, m.RemoveOverride("policy2"))
+	assert.Nil(t, m.ActiveOverride("policy2"))
+
+	assert.Nil(t, m.SetOverride("policy1", o))
+	assert.Equal(t, &o, m.ActiveOverride("policy1"))
+
+	assert.Nil(t, m.RemoveOverride("policy1"))
+	assert.Nil(t, m.ActiveOverride("policy1"))
+}
+
+func TestManager_MarkReconciled(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	h := NewHandler("policy1", hclog.NewNullLogger(), nil, nil)
+	m.handlers["policy1"] = h
+
+	// Marking a policy which is not being handled is a no-op.
+	m.MarkReconciled("policy2")
+	assert.False(t, h.isReconciled())
+
+	m.MarkReconciled("policy1")
+	assert.True(t, h.isReconciled())
+}
+
+func TestManager_PolicySource(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	m.handlers["policy1"] = NewHandler("policy1", hclog.NewNullLogger(), nil, &fakeSource{})
+
+	_, ok := m.PolicySource("policy2")
+	assert.False(t, ok)
+
+	source, ok := m.PolicySource("policy1")
+	assert.True(t, ok)
+	assert.Equal(t, SourceNameFile, source)
+}
+
+func TestManager_RecordRecommendation(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	m.handlers["policy1"] = NewHandler("policy1", hclog.NewNullLogger(), nil, nil)
+
+	// Policies which are not being handled have no history.
+	assert.Equal(t, int64(5), m.RecordRecommendation("policy2", 5, time.Hour))
+	assert.Equal(t, int64(2), m.RecordRecommendation("policy2", 2, time.Hour))
+
+	assert.Equal(t, int64(5), m.RecordRecommendation("policy1", 5, time.Hour))
+	assert.Equal(t, int64(5), m.RecordRecommendation("policy1", 2, time.Hour))
+}
+
+func TestManager_RecordAdvice(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	h := NewHandler("policy1", hclog.NewNullLogger(), nil, &fakeSource{})
+	m.handlers["policy1"] = h
+
+	a := Advice{Count: 4, CurrentCount: 2, Direction: "up", Time: time.Now()}
+
+	// Recording advice for a policy which is not being handled is a no-op.
+	m.RecordAdvice("policy2", a)
+	assert.Nil(t, h.State().Advice)
+
+	m.RecordAdvice("policy1", a)
+	assert.Equal(t, &a, h.State().Advice)
+}
+
+func TestManager_ScaleDownAllowed(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	m.handlers["policy1"] = NewHandler("policy1", hclog.NewNullLogger(), nil, nil)
+
+	// Policies which are not being handled are not limited.
+	m.RecordScale("policy2", sdk.ScaleDirectionDown)
+	assert.True(t, m.ScaleDownAllowed("policy2", 1, 0))
+	assert.Equal(t, ErrPolicyNotFound, m.ResetScaleDowns("policy2"))
+
+	m.RecordScale("policy1", sdk.ScaleDirectionDown)
+	assert.False(t, m.ScaleDownAllowed("policy1", 1, 0))
+
+	assert.Nil(t, m.ResetScaleDowns("policy1"))
+	assert.True(t, m.ScaleDownAllowed("policy1", 1, 0))
+}
+
+func TestManager_StepDownDelayedUntil(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	m.handlers["policy1"] = NewHandler("policy1", hclog.NewNullLogger(), nil, nil)
+
+	// Policies which are not being handled are not delayed.
+	m.RecordScale("policy2", sdk.ScaleDirectionDown)
+	assert.True(t, m.StepDownDelayedUntil("policy2", time.Hour).IsZero())
+
+	m.RecordScale("policy1", sdk.ScaleDirectionDown)
+	assert.False(t, m.StepDownDelayedUntil("policy1", time.Hour).IsZero())
+	assert.True(t, m.StepDownDelayedUntil("policy1", 0).IsZero())
+}
+
+func TestManager_ScaleUpProbationUntil(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	m.handlers["policy1"] = NewHandler("policy1", hclog.NewNullLogger(), nil, nil)
+
+	// Policies which are not being handled are not in probation.
+	m.RecordScale("policy2", sdk.ScaleDirectionUp)
+	assert.True(t, m.ScaleUpProbationUntil("policy2", time.Hour).IsZero())
+
+	m.RecordScale("policy1", sdk.ScaleDirectionUp)
+	assert.False(t, m.ScaleUpProbationUntil("policy1", time.Hour).IsZero())
+	assert.True(t, m.ScaleUpProbationUntil("policy1", 0).IsZero())
+}
+
+func TestManager_BusyDependency(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	m.handlers["policy1"] = NewHandler("policy1", hclog.NewNullLogger(), nil, nil)
+	m.handlers["policy2"] = NewHandler("policy2", hclog.NewNullLogger(), nil, nil)
+
+	assert.Empty(t, m.BusyDependency(nil, time.Hour))
+	assert.Empty(t, m.BusyDependency([]string{"policy1", "policy2"}, time.Hour))
+
+	// Dependencies which are not being handled are considered stable.
+	m.BeginAction("policy3")
+	assert.Empty(t, m.BusyDependency([]string{"policy3"}, time.Hour))
+
+	m.BeginAction("policy2")
+	assert.Equal(t, "policy2", m.BusyDependency([]string{"policy1", "policy2"}, time.Hour))
+
+	m.EndAction("policy2", true)
+	assert.Equal(t, "policy2", m.BusyDependency([]string{"policy1", "policy2"}, time.Hour))
+	assert.Empty(t, m.BusyDependency([]string{"policy1", "policy2"}, 0))
+}
+
+func TestManager_stopHandler_dependencies(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	h := NewHandler("policy1", hclog.NewNullLogger(), nil, &fakeSource{})
+	h.dependencies = m.dependencies
+	m.handlers["policy1"] = h
+
+	m.dependencies.set("policy1", []string{"policy2"})
+	m.dependencies.set("policy2", []string{"policy1"})
+	assert.NotNil(t, m.dependencies.cycle("policy2"))
+
+	// Removing a policy breaks the cycles it was part of.
+	m.stopHandler(h)
+	assert.Nil(t, m.dependencies.cycle("policy2"))
+}
+
+func TestManager_DescribePolicy(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	h := NewHandler("policy1", hclog.NewNullLogger(), nil, &fakeSource{})
+	m.handlers["policy1"] = h
+
+	_, err := m.DescribePolicy("policy2")
+	assert.Equal(t, ErrPolicyNotFound, err)
+
+	// Policies which have not been read from their source are not described.
+	_, err = m.DescribePolicy("policy1")
+	assert.Equal(t, ErrPolicyNotFound, err)
+
+	p := &sdk.ScalingPolicy{ID: "policy1", Min: 1, Max: 5}
+	h.setPolicy(p, 0)
+
+	desc, err := m.DescribePolicy("policy1")
+	assert.NoError(t, err)
+	assert.Equal(t, p, desc.Policy)
+
+	// The description holds a copy of the policy.
+	desc.Policy.Max = 10
+	assert.Equal(t, int64(5), p.Max)
+}
+
+// fakeSource is a Source which allows the MonitorIDs behaviour to be set by
+// tests.
+type fakeSource struct {
+	name       SourceName
+	monitorIDs func(ctx context.Context, req MonitorIDsReq)
+
+	// monitoring counts the running MonitorPolicy calls.
+	monitoring int32
+}
+
+func (f *fakeSource) MonitorIDs(ctx context.Context, req MonitorIDsReq) { f.monitorIDs(ctx, req) }
+func (f *fakeSource) ReloadIDsMonitor()                                 {}
+
+func (f *fakeSource) Name() SourceName {
+	if f.name == "" {
+		return SourceNameFile
+	}
+	return f.name
+}
+
+func (f *fakeSource) MonitorPolicy(ctx context.Context, _ MonitorPolicyReq) {
+	atomic.AddInt32(&f.monitoring, 1)
+	defer atomic.AddInt32(&f.monitoring, -1)
+	<-ctx.Done()
+}
+
+func TestManager_monitorSourceIDs(t *testing.T) {
+	var calls int32
+
+	// The first call returns immediately, simulating a source failure, and
+	// the second blocks until the context is canceled.
+	s := &fakeSource{monitorIDs: func(ctx context.Context, _ MonitorIDsReq) {
+		if atomic.AddInt32(&calls, 1) > 1 {
+			<-ctx.Done()
+		}
+	}}
+
+	c := clock.NewFake(time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC))
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+	m.SetClock(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	doneCh := make(chan struct{})
+	go func() {
+		m.monitorSourceIDs(ctx, s, MonitorIDsReq{})
+		close(doneCh)
+	}()
+
+	// The source is only restarted once the backoff has passed.
+	c.BlockUntil(1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	c.Advance(sourceRestartMinWait)
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for source monitor to stop")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestManager_Run_closedChannel(t *testing.T) {
+	testCases := []struct {
+		name       string
+		inputClose func(MonitorIDsReq)
+	}{
+		{
+			name:       "error channel",
+			inputClose: func(req MonitorIDsReq) { close(req.ErrCh) },
+		},
+		{
+			name:       "result channel",
+			inputClose: func(req MonitorIDsReq) { close(req.ResultCh) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stoppedCh := make(chan struct{}, 1)
+
+			// The source sends a policy and then closes the channel,
+			// simulating a lost subscription, and reports when its
+			// subscription is canceled.
+			var calls int32
+			s := &fakeSource{monitorIDs: func(ctx context.Context, req MonitorIDsReq) {
+				if atomic.AddInt32(&calls, 1) > 1 {
+					<-ctx.Done()
+					return
+				}
+				req.ResultCh <- IDMessage{IDs: []PolicyID{"policy1"}, Source: SourceNameFile}
+				tc.inputClose(req)
+				<-ctx.Done()
+				select {
+				case stoppedCh <- struct{}{}:
+				default:
+				}
+			}}
+
+			m := NewManager(hclog.NewNullLogger(), map[SourceName]Source{SourceNameFile: s}, nil, time.Second, 0, 0)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go m.Run(ctx, make(chan *sdk.ScalingEvaluation))
+
+			// The manager must detect the closed channel and cancel the
+			// subscription so it can be re-established, rather than panic
+			// or treat it as an empty listing.
+			select {
+			case <-stoppedCh:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for source subscription to be canceled")
+			}
+		})
+	}
+}
+
+func TestManager_Run_sourceRestart(t *testing.T) {
+	var calls int32
+
+	// The first call returns immediately, simulating a source which stopped
+	// monitoring, and the second sends the policy IDs once restarted.
+	s := &fakeSource{monitorIDs: func(ctx context.Context, req MonitorIDsReq) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return
+		}
+		req.ResultCh <- IDMessage{IDs: []PolicyID{"policy1"}, Source: SourceNameFile}
+		<-ctx.Done()
+	}}
+
+	m := NewManager(hclog.NewNullLogger(), map[SourceName]Source{SourceNameFile: s}, nil, time.Second, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx, make(chan *sdk.ScalingEvaluation))
+
+	// The manager must restart the source and handle the policies it sends.
+	assert.Eventually(t, func() bool { return m.PolicyCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestManager_Run_removalGracePeriod(t *testing.T) {
+	idsCh := make(chan []PolicyID)
+	s := &fakeSource{monitorIDs: func(ctx context.Context, req MonitorIDsReq) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ids := <-idsCh:
+				req.ResultCh <- IDMessage{IDs: ids, Source: SourceNameFile}
+			}
+		}
+	}}
+
+	m := NewManager(hclog.NewNullLogger(), map[SourceName]Source{SourceNameFile: s}, nil, time.Second, 0, 500*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx, make(chan *sdk.ScalingEvaluation))
+
+	handler := func() *Handler {
+		m.lock.RLock()
+		defer m.lock.RUnlock()
+		return m.handlers["policy1"]
+	}
+	removing := func() bool {
+		m.lock.RLock()
+		defer m.lock.RUnlock()
+		_, ok := m.removals["policy1"]
+		return ok
+	}
+
+	idsCh <- []PolicyID{"policy1"}
+	assert.Eventually(t, func() bool { return handler() != nil }, 5*time.Second, 10*time.Millisecond)
+	h := handler()
+
+	// A policy which briefly disappears keeps its handler.
+	idsCh <- []PolicyID{}
+	assert.Eventually(t, removing, 5*time.Second, 10*time.Millisecond)
+	idsCh <- []PolicyID{"policy1"}
+	assert.Eventually(t, func() bool { return !removing() }, 5*time.Second, 10*time.Millisecond)
+
+	time.Sleep(time.Second)
+	assert.Same(t, h, handler())
+
+	// A policy which stays removed is stopped once the grace period passes.
+	idsCh <- []PolicyID{}
+	assert.Eventually(t, func() bool { return m.PolicyCount() == 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, removing())
+}
+
+func TestManager_removeHandler_clock(t *testing.T) {
+	c := clock.NewFake(time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC))
+	m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, time.Minute)
+	m.SetClock(c)
+
+	h := &Handler{policyID: "policy1", log: hclog.NewNullLogger(), doneCh: make(chan struct{})}
+
+	m.lock.Lock()
+	m.handlers["policy1"] = h
+	m.removeHandler(h)
+	m.lock.Unlock()
+
+	// The handler is only stopped once the manager clock passes the grace
+	// period.
+	c.BlockUntil(1)
+	c.Advance(30 * time.Second)
+	assert.Equal(t, 1, m.PolicyCount())
+
+	c.Advance(30 * time.Second)
+	assert.Eventually(t, func() bool { return m.PolicyCount() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// A canceled removal never stops the handler.
+	m.lock.Lock()
+	m.handlers["policy1"] = h
+	m.removeHandler(h)
+	m.cancelRemoval("policy1")
+	m.lock.Unlock()
+
+	c.Advance(time.Minute)
+	assert.Equal(t, 1, m.PolicyCount())
+}
+
+func TestManager_Run_rapidUpdates(t *testing.T) {
+	idsCh := make(chan []PolicyID)
+	s := &fakeSource{monitorIDs: func(ctx context.Context, req MonitorIDsReq) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ids := <-idsCh:
+				req.ResultCh <- IDMessage{IDs: ids, Source: SourceNameFile}
+			}
+		}
+	}}
+
+	m := NewManager(hclog.NewNullLogger(), map[SourceName]Source{SourceNameFile: s}, nil, time.Second, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx, make(chan *sdk.ScalingEvaluation))
+
+	handlerIDs := func() []PolicyID {
+		m.lock.RLock()
+		defer m.lock.RUnlock()
+
+		ids := make([]PolicyID, 0, len(m.handlers))
+		for id := range m.handlers {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	// Deliver listings which add and remove policies faster than their
+	// handlers are set up, so handlers are stopped before they start running
+	// and policies are added again while their old handlers are stopping.
+	all := []PolicyID{"policy1", "policy2", "policy3", "policy4", "policy5"}
+	for i := 0; i < 500; i++ {
+		var ids []PolicyID
+		for j, id := range all {
+			if (i+j)%3 != 0 {
+				ids = append(ids, id)
+			}
+		}
+		idsCh <- ids
+	}
+	idsCh <- []PolicyID{"policy1", "policy3"}
+
+	// The handlers, and the policy monitors they run, must match the latest
+	// listing, without duplicates or orphans.
+	assert.Eventually(t, func() bool {
+		return len(handlerIDs()) == 2 && atomic.LoadInt32(&s.monitoring) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.ElementsMatch(t, []PolicyID{"policy1", "policy3"}, handlerIDs())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&s.monitoring))
+}
+
+func TestManager_reconcileIDs(t *testing.T) {
+	testCases := []struct {
+		inputIDs        []PolicyID
+		expectedSummary reconcileSummary
+		name            string
+	}{
+		{
+			inputIDs:        []PolicyID{"policy1", "policy2"},
+			expectedSummary: reconcileSummary{added: 2},
+			name:            "new policies",
+		},
+		{
+			inputIDs:        []PolicyID{"policy1", "policy2"},
+			expectedSummary: reconcileSummary{unchanged: 2},
+			name:            "same policies",
+		},
+		{
+			inputIDs:        []PolicyID{"policy1", "policy3"},
+			expectedSummary: reconcileSummary{added: 1, removed: 1, unchanged: 1},
+			name:            "policy replaced",
+		},
+		{
+			inputIDs:        []PolicyID{"policy1", "policy3"},
+			expectedSummary: reconcileSummary{unchanged: 2},
+			name:            "policy pending removal not counted again",
+		},
+		{
+			inputIDs:        []PolicyID{"policy2"},
+			expectedSummary: reconcileSummary{removed: 2, unchanged: 1},
+			name:            "policy reappeared within grace period",
+		},
+	}
+
+	s := &fakeSource{}
+	m := NewManager(hclog.NewNullLogger(), map[SourceName]Source{SourceNameFile: s}, nil, time.Second, 0, time.Minute)
+	defer m.stopHandlers()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The listings are applied in order to the same manager.
+	for _, tc := range testCases {
+		summary := m.reconcileIDs(ctx, make(chan *sdk.ScalingEvaluation), IDMessage{IDs: tc.inputIDs, Source: SourceNameFile})
+		assert.Equal(t, tc.expectedSummary, summary, tc.name)
+	}
+}
+
+func TestManager_reconcileIDs_conflicts(t *testing.T) {
+	testCases := []struct {
+		inputResolution   ConflictResolution
+		expectedSource    SourceName
+		expectedHandler   bool
+		expectedConflicts []Conflict
+		name              string
+	}{
+		{
+			inputResolution: ConflictResolution{Mode: ConflictModeFirstWins},
+			expectedSource:  SourceNameNomad,
+			expectedHandler: true,
+			expectedConflicts: []Conflict{{
+				Type: ConflictTypeID,
+				Key:  "policy1",
+				Policies: []ConflictPolicy{
+					{ID: "policy1", Source: SourceNameNomad, Active: true},
+					{ID: "policy1", Source: SourceNameFile},
+				},
+			}},
+			name: "first wins",
+		},
+		{
+			inputResolution: ConflictResolution{Mode: ConflictModeError},
+			expectedHandler: false,
+			expectedConflicts: []Conflict{{
+				Type: ConflictTypeID,
+				Key:  "policy1",
+				Policies: []ConflictPolicy{
+					{ID: "policy1", Source: SourceNameNomad},
+					{ID: "policy1", Source: SourceNameFile},
+				},
+			}},
+			name: "error",
+		},
+		{
+			inputResolution: ConflictResolution{
+				Mode:           ConflictModeSourcePriority,
+				SourcePriority: []SourceName{SourceNameFile, SourceNameNomad},
+			},
+			expectedSource:  SourceNameFile,
+			expectedHandler: true,
+			expectedConflicts: []Conflict{{
+				Type: ConflictTypeID,
+				Key:  "policy1",
+				Policies: []ConflictPolicy{
+					{ID: "policy1", Source: SourceNameNomad},
+					{ID: "policy1", Source: SourceNameFile, Active: true},
+				},
+			}},
+			name: "source priority",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sources := map[SourceName]Source{
+				SourceNameNomad: &fakeSource{name: SourceNameNomad},
+				SourceNameFile:  &fakeSource{name: SourceNameFile},
+			}
+			m := NewManager(hclog.NewNullLogger(), sources, nil, time.Second, 0, 0)
+			m.SetConflictResolution(tc.inputResolution)
+			defer m.stopHandlers()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			evalCh := make(chan *sdk.ScalingEvaluation)
+
+			m.reconcileIDs(ctx, evalCh, IDMessage{IDs: []PolicyID{"policy1"}, Source: SourceNameNomad})
+			m.reconcileIDs(ctx, evalCh, IDMessage{IDs: []PolicyID{"policy1"}, Source: SourceNameFile})
+
+			source, ok := m.PolicySource("policy1")
+			assert.Equal(t, tc.expectedHandler, ok, tc.name)
+			assert.Equal(t, tc.expectedSource, source, tc.name)
+			assert.Equal(t, tc.expectedConflicts, m.Conflicts(), tc.name)
+
+			// Once the nomad source no longer lists the policy, the conflict
+			// is resolved and the file source policy is used.
+			m.reconcileIDs(ctx, evalCh, IDMessage{IDs: []PolicyID{}, Source: SourceNameNomad})
+
+			source, ok = m.PolicySource("policy1")
+			assert.True(t, ok, tc.name)
+			assert.Equal(t, SourceNameFile, source, tc.name)
+			assert.Empty(t, m.Conflicts(), tc.name)
+		})
+	}
+}
+
+func TestManager_TargetAllowed(t *testing.T) {
+	testCases := []struct {
+		inputResolution ConflictResolution
+		expectedAllowed map[string]bool
+		name            string
+	}{
+		{
+			inputResolution: ConflictResolution{Mode: ConflictModeFirstWins},
+			expectedAllowed: map[string]bool{"policy1": true, "policy2": true, "policy3": false, "policy4": true},
+			name:            "first wins",
+		},
+		{
+			inputResolution: ConflictResolution{Mode: ConflictModeError},
+			expectedAllowed: map[string]bool{"policy1": false, "policy2": false, "policy3": false, "policy4": true},
+			name:            "error",
+		},
+		{
+			inputResolution: ConflictResolution{
+				Mode:           ConflictModeSourcePriority,
+				SourcePriority: []SourceName{SourceNameAPI},
+			},
+			expectedAllowed: map[string]bool{"policy1": false, "policy2": false, "policy3": true, "policy4": true},
+			name:            "source priority",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewManager(hclog.NewNullLogger(), nil, nil, time.Second, 0, 0)
+
+			tc.inputResolution.TargetKey = func(p *sdk.ScalingPolicy) string { return p.Target.Name }
+			m.SetConflictResolution(tc.inputResolution)
+
+			// Policies from the same source may share a target, and only
+			// policies from different sources conflict.
+			handlers := []struct {
+				id     PolicyID
+				source SourceName
+				target string
+			}{
+				{"policy1", SourceNameNomad, "web"},
+				{"policy2", SourceNameNomad, "web"},
+				{"policy3", SourceNameAPI, "web"},
+				{"policy4", SourceNameAPI, "cache"},
+			}
+			for i, h := range handlers {
+				handler := NewHandler(h.id, hclog.NewNullLogger(), nil, &fakeSource{name: h.source})
+				handler.seq = uint64(i)
+				handler.setPolicy(&sdk.ScalingPolicy{ID: string(h.id), Target: &sdk.ScalingPolicyTarget{Name: h.target}}, 0)
+				m.handlers[h.id] = handler
+			}
+
+			for id, expected := range tc.expectedAllowed {
+				assert.Equal(t, expected, m.TargetAllowed(id), id)
+			}
+			assert.True(t, m.TargetAllowed("missing"), tc.name)
+
+			conflicts := m.Conflicts()
+			if assert.Len(t, conflicts, 1, tc.name) {
+				assert.Equal(t, ConflictTypeTarget, conflicts[0].Type, tc.name)
+				assert.Equal(t, "web", conflicts[0].Key, tc.name)
+				assert.Len(t, conflicts[0].Policies, 3, tc.name)
+			}
+		})
+	}
+}
+
+func Test_latestIDMessages(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputFirst     IDMessage
+		inputPending   []IDMessage
+		inputClosed    bool
+		expectedOutput []IDMessage
+	}{
+		{
+			name:           "nothing pending",
+			inputFirst:     IDMessage{IDs: []PolicyID{"a"}, Source: SourceNameFile},
+			expectedOutput: []IDMessage{{IDs: []PolicyID{"a"}, Source: SourceNameFile}},
+		},
+		{
+			name:       "latest of each source",
+			inputFirst: IDMessage{IDs: []PolicyID{"a"}, Source: SourceNameFile},
+			inputPending: []IDMessage{
+				{IDs: []PolicyID{"b"}, Source: SourceNameNomad},
+				{IDs: []PolicyID{"c"}, Source: SourceNameFile},
+				{IDs: []PolicyID{"d"}, Source: SourceNameNomad},
+			},
+			expectedOutput: []IDMessage{
+				{IDs: []PolicyID{"c"}, Source: SourceNameFile},
+				{IDs: []PolicyID{"d"}, Source: SourceNameNomad},
+			},
+		},
+		{
+			name:         "channel closed",
+			inputFirst:   IDMessage{IDs: []PolicyID{"a"}, Source: SourceNameFile},
+			inputPending: []IDMessage{{IDs: []PolicyID{"b"}, Source: SourceNameFile}},
+			inputClosed:  true,
+			expectedOutput: []IDMessage{
+				{IDs: []PolicyID{"b"}, Source: SourceNameFile},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ch := make(chan IDMessage, len(tc.inputPending))
+			for _, msg := range tc.inputPending {
+				ch <- msg
+			}
+			if tc.inputClosed {
+				close(ch)
+			}
+			assert.Equal(t, tc.expectedOutput, latestIDMessages(tc.inputFirst, ch), tc.name)
+		})
+	}
+}
+
+func Test_sourceRestartWait(t *testing.T) {
+	testCases := []struct {
+		inputAttempt   int
+		expectedOutput time.Duration
+		name           string
+	}{
+		{inputAttempt: 0, expectedOutput: time.Second, name: "first attempt"},
+		{inputAttempt: 3, expectedOutput: 8 * time.Second, name: "exponential backoff"},
+		{inputAttempt: 10, expectedOutput: 2 * time.Minute, name: "capped at max wait"},
+		{inputAttempt: 100, expectedOutput: 2 * time.Minute, name: "large attempt"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, sourceRestartWait(tc.inputAttempt), tc.name)
+		})
+	}
+}
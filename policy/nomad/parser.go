@@ -2,6 +2,8 @@ package nomad
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/hashicorp/nomad-autoscaler/sdk"
@@ -27,9 +29,17 @@ func parsePolicy(p *api.ScalingPolicy) sdk.ScalingPolicy {
 		Checks:  parseChecks(p.Policy[keyChecks]),
 	}
 
+	// The ModifyIndex changes with each update of the policy, so it is used
+	// as the policy revision.
+	if p.ModifyIndex > 0 {
+		to.Revision = strconv.FormatUint(p.ModifyIndex, 10)
+	}
+
 	// Add non-typed values.
 	if p.Min != nil {
 		to.Min = *p.Min
+	} else {
+		to.MinOmitted = true
 	}
 
 	if p.Enabled != nil {
@@ -48,6 +58,154 @@ func parsePolicy(p *api.ScalingPolicy) sdk.ScalingPolicy {
 		to.Cooldown, _ = time.ParseDuration(cooldown)
 	}
 
+	// Parse warmup_period as time.Duration.
+	// Ignore error since we assume policy has been validated.
+	if warmup, ok := p.Policy[keyWarmupPeriod].(string); ok {
+		to.WarmupPeriod, _ = time.ParseDuration(warmup)
+	}
+
+	// Parse max_evaluation_interval as time.Duration and stable_evaluations
+	// as a number. Ignore error since we assume policy has been validated.
+	if max, ok := p.Policy[keyMaxEvaluationInterval].(string); ok {
+		to.MaxEvaluationInterval, _ = time.ParseDuration(max)
+	}
+	if stable, ok := parseNumber(p.Policy[keyStableEvaluations]); ok {
+		to.StableEvaluations = int64(stable)
+	}
+
+	// Parse verify_scale_after as time.Duration.
+	// Ignore error since we assume policy has been validated.
+	if verify, ok := p.Policy[keyVerifyScaleAfter].(string); ok {
+		to.VerifyScaleAfter, _ = time.ParseDuration(verify)
+	}
+
+	// Parse rollback_on_verify_failure as bool.
+	if rollback, ok := p.Policy[keyRollbackOnVerifyFailure].(bool); ok {
+		to.RollbackOnVerifyFailure = rollback
+	}
+
+	// Parse labels, which can be written either as a block or a map.
+	to.Labels = parseLabels(p.Policy[keyLabels])
+
+	// Parse reconcile_on_start as bool.
+	if reconcile, ok := p.Policy[keyReconcileOnStart].(bool); ok {
+		to.ReconcileOnStart = reconcile
+	}
+
+	// Parse max_scale_step and max_scale_percent as numbers.
+	if step, ok := parseNumber(p.Policy[keyMaxScaleStep]); ok {
+		to.MaxScaleStep = int64(step)
+	}
+	if percent, ok := parseNumber(p.Policy[keyMaxScalePercent]); ok {
+		to.MaxScalePercent = percent
+	}
+
+	// Parse min_healthy_percentage as a number.
+	if percent, ok := parseNumber(p.Policy[keyMinHealthyPercentage]); ok {
+		to.MinHealthyPercentage = percent
+	}
+
+	// Parse metric_min and metric_max as numbers.
+	if min, ok := parseNumber(p.Policy[keyMetricMin]); ok {
+		to.MetricMin = &min
+	}
+	if max, ok := parseNumber(p.Policy[keyMetricMax]); ok {
+		to.MetricMax = &max
+	}
+
+	// Parse max_consecutive_scale_downs as a number and
+	// consecutive_scale_downs_reset as time.Duration. Ignore error since we
+	// assume policy has been validated.
+	if max, ok := parseNumber(p.Policy[keyMaxConsecutiveScaleDowns]); ok {
+		to.MaxConsecutiveScaleDowns = int64(max)
+	}
+	if reset, ok := p.Policy[keyConsecutiveScaleDownsReset].(string); ok {
+		to.ConsecutiveScaleDownsReset, _ = time.ParseDuration(reset)
+	}
+
+	// Parse scale_down_step as a number and scale_down_step_delay as
+	// time.Duration. Ignore error since we assume policy has been validated.
+	if step, ok := parseNumber(p.Policy[keyScaleDownStep]); ok {
+		to.ScaleDownStep = int64(step)
+	}
+	if delay, ok := p.Policy[keyScaleDownStepDelay].(string); ok {
+		to.ScaleDownStepDelay, _ = time.ParseDuration(delay)
+	}
+
+	// Parse scale_up_probation as time.Duration. Ignore error since we assume
+	// policy has been validated.
+	if probation, ok := p.Policy[keyScaleUpProbation].(string); ok {
+		to.ScaleUpProbation, _ = time.ParseDuration(probation)
+	}
+
+	// Parse depends_on as a list of policy IDs and depends_on_window as
+	// time.Duration. Ignore error since we assume policy has been validated.
+	to.DependsOn = parseStringList(p.Policy[keyDependsOn])
+	if window, ok := p.Policy[keyDependsOnWindow].(string); ok {
+		to.DependsOnWindow, _ = time.ParseDuration(window)
+	}
+
+	// Parse priority as a number.
+	if priority, ok := parseNumber(p.Policy[keyPriority]); ok {
+		to.Priority = int(priority)
+	}
+
+	// Parse fallback_strategy as string.
+	if fallback, ok := p.Policy[keyFallbackStrategy].(string); ok {
+		to.FallbackStrategy = fallback
+	}
+
+	// Parse out_of_bounds_action as string.
+	if action, ok := p.Policy[keyOutOfBoundsAction].(string); ok {
+		to.OutOfBoundsAction = action
+	}
+
+	// Parse rounding as string.
+	if rounding, ok := p.Policy[keyRounding].(string); ok {
+		to.Rounding = rounding
+	}
+
+	// Parse metric_unit as string.
+	if unit, ok := p.Policy[keyMetricUnit].(string); ok {
+		to.MetricUnit = unit
+	}
+
+	// Parse stabilize_count as bool and stabilize_count_delay as
+	// time.Duration. Ignore error since we assume policy has been validated.
+	if stabilize, ok := p.Policy[keyStabilizeCount].(bool); ok {
+		to.StabilizeCount = stabilize
+	}
+	if delay, ok := p.Policy[keyStabilizeCountDelay].(string); ok {
+		to.StabilizeCountDelay, _ = time.ParseDuration(delay)
+	}
+
+	// Parse scale_down_stabilization_window as time.Duration. Ignore error
+	// since we assume policy has been validated.
+	if window, ok := p.Policy[keyScaleDownStabilizationWindow].(string); ok {
+		to.ScaleDownStabilizationWindow, _ = time.ParseDuration(window)
+	}
+
+	// Parse defer_during_deployment as bool.
+	if deferDeployment, ok := p.Policy[keyDeferDuringDeployment].(bool); ok {
+		to.DeferDuringDeployment = deferDeployment
+	}
+
+	// Parse advisory as bool.
+	if advisory, ok := p.Policy[keyAdvisory].(bool); ok {
+		to.Advisory = advisory
+	}
+
+	// Parse cron and time_zone as strings.
+	if cron, ok := p.Policy[keyCron].(string); ok {
+		to.Cron = cron
+	}
+	if tz, ok := p.Policy[keyTimeZone].(string); ok {
+		to.CronTimeZone = tz
+	}
+
+	// Parse the scheduled_min blocks.
+	to.ScheduledMins = parseScheduledMins(p.Policy[keyScheduledMin])
+
 	// Parse target block.
 	var target *sdk.ScalingPolicyTarget
 
@@ -67,6 +225,16 @@ func parsePolicy(p *api.ScalingPolicy) sdk.ScalingPolicy {
 	}
 	to.Target = target
 
+	// Parse the shadow target block, which does not inherit the values of
+	// the Target field as it usually refers to a different target.
+	for k, v := range parseBlocks(p.Policy[keyShadowTarget]) {
+		if shadow := parseTarget(v, nil); shadow != nil {
+			shadow.Name = k
+			to.ShadowTarget = shadow
+			break
+		}
+	}
+
 	return to
 }
 
@@ -144,14 +312,136 @@ func parseCheck(c interface{}) *sdk.ScalingPolicyCheck {
 		queryWindow, _ = time.ParseDuration(queryWindowStr)
 	}
 
+	// Parse target_percentage as a number.
+	targetPercentage, _ := parseNumber(checkMap[keyTargetPercentage])
+
 	return &sdk.ScalingPolicyCheck{
-		Query:       query,
-		QueryWindow: queryWindow,
-		Source:      source,
-		Strategy:    strategy,
+		Query:            query,
+		QueryWindow:      queryWindow,
+		Source:           source,
+		FailoverSources:  parseStringList(checkMap[keyFailoverSources]),
+		Strategy:         strategy,
+		Transforms:       parseTransforms(checkMap[keyTransform]),
+		TargetPercentage: targetPercentage,
 	}
 }
 
+// parseScheduledMins parses the scheduled_min blocks of a policy, sorted by
+// name so the result is deterministic.
+//
+// It provides best-effort parsing and skips blocks which are not valid.
+//
+//  scaling {
+//    policy {
+//    +---------------------------------+
+//    | scheduled_min "peak" {          |
+//    |   cron     = "0 0 8 * * 1-5 *"  |
+//    |   duration = "10h"              |
+//    |   min      = 5                  |
+//    | }                               |
+//    +---------------------------------+
+//    }
+//  }
+func parseScheduledMins(s interface{}) []*sdk.ScalingPolicyScheduledMin {
+	blocks := parseBlocks(s)
+
+	names := make([]string, 0, len(blocks))
+	for name := range blocks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mins []*sdk.ScalingPolicyScheduledMin
+	for _, name := range names {
+		block := parseBlock(blocks[name])
+		if block == nil {
+			continue
+		}
+
+		cron, _ := block[keyCron].(string)
+		tz, _ := block[keyTimeZone].(string)
+		min, _ := parseNumber(block[keyMin])
+
+		var duration time.Duration
+		if d, ok := block[keyDuration].(string); ok {
+			duration, _ = time.ParseDuration(d)
+		}
+
+		mins = append(mins, &sdk.ScalingPolicyScheduledMin{
+			Name:     name,
+			Cron:     cron,
+			TimeZone: tz,
+			Duration: duration,
+			Min:      int64(min),
+		})
+	}
+	return mins
+}
+
+// parseStringList parses a list of strings from a policy, such as the
+// failover sources of a check.
+//
+// It provides best-effort parsing and skips items which are not strings.
+func parseStringList(l interface{}) []string {
+	list, ok := l.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var out []string
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// parseTransforms parses the transform blocks of a check, keeping the order
+// they are defined in, as it is the order they are applied in.
+//
+// It provides best-effort parsing and skips blocks which cannot be parsed.
+//
+//  scaling {
+//    policy {
+//      check "name" {
+//        transform "transform" {
+//        +---------------+
+//        | key = "value" |
+//        +---------------+
+//        }
+//      }
+//    }
+//  }
+func parseTransforms(t interface{}) []*sdk.ScalingPolicyTransform {
+	list, ok := t.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var transforms []*sdk.ScalingPolicyTransform
+	for _, item := range list {
+		blockMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for name, content := range blockMap {
+			configMap := parseBlock(content)
+			if configMap == nil {
+				continue
+			}
+
+			config := make(map[string]string, len(configMap))
+			for k, v := range configMap {
+				config[k] = fmt.Sprintf("%v", v)
+			}
+			transforms = append(transforms, &sdk.ScalingPolicyTransform{Name: name, Config: config})
+		}
+	}
+	return transforms
+}
+
 // parseStrategy parses the content of the strategy block from a policy.
 //
 // It provides best-effort parsing and will return `nil` in case of errors.
@@ -223,6 +513,54 @@ func parseTarget(targetBlock interface{}, targetAttr map[string]string) *sdk.Sca
 	}
 }
 
+// parseLabels parses the content of the labels block or map from a policy.
+//
+// It provides best-effort parsing and will return `nil` in case of errors.
+//
+//  scaling {
+//    policy {
+//      labels {
+//      +---------------+
+//      | key = "value" |
+//      +---------------+
+//      }
+//    }
+//  }
+func parseLabels(l interface{}) map[string]string {
+	if l == nil {
+		return nil
+	}
+
+	labelsMap, ok := l.(map[string]interface{})
+	if !ok {
+		labelsMap = parseBlock(l)
+	}
+	if labelsMap == nil {
+		return nil
+	}
+
+	labels := make(map[string]string, len(labelsMap))
+	for k, v := range labelsMap {
+		labels[k] = fmt.Sprintf("%v", v)
+	}
+	return labels
+}
+
+// parseNumber parses a numeric policy value. Numbers decoded from the Nomad
+// API are float64, but other numeric types are handled for completeness.
+func parseNumber(n interface{}) (float64, bool) {
+	switch v := n.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
 // parseBlock parses the specific structure of a block into a more usable
 // value of map[string]interface{}.
 func parseBlock(block interface{}) map[string]interface{} {
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/ptr"
+	"github.com/hashicorp/nomad/api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,6 +23,7 @@ func Test_parsePolicy(t *testing.T) {
 			input: "full-scaling",
 			expected: sdk.ScalingPolicy{
 				ID:                 "id",
+				Revision:           "256",
 				Min:                2,
 				Max:                10,
 				Enabled:            false,
@@ -73,11 +76,12 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "minimum valid scaling",
 			input: "minimum-valid-scaling",
 			expected: sdk.ScalingPolicy{
-				ID:      "id",
-				Min:     1,
-				Max:     10,
-				Enabled: true,
-				Type:    "horizontal",
+				ID:       "id",
+				Revision: "281",
+				Min:      1,
+				Max:      10,
+				Enabled:  true,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "",
 					Config: map[string]string{
@@ -111,9 +115,10 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "empty policy",
 			input: "empty-policy",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "218",
+				Max:      10,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "",
 					Config: map[string]string{
@@ -128,9 +133,10 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "invalid evaluation_interval",
 			input: "invalid-evaluation-interval",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "277",
+				Max:      10,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "",
 					Config: map[string]string{
@@ -145,9 +151,10 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "invalid cooldown",
 			input: "invalid-cooldown",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "287",
+				Max:      10,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "",
 					Config: map[string]string{
@@ -162,9 +169,10 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "empty target",
 			input: "empty-target",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "208",
+				Max:      10,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "target",
 					Config: map[string]string{
@@ -179,18 +187,20 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "invalid target",
 			input: "invalid-target",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "228",
+				Max:      10,
+				Type:     "horizontal",
 			},
 		},
 		{
 			name:  "empty check",
 			input: "empty-check",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "252",
+				Max:      10,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "",
 					Config: map[string]string{
@@ -208,9 +218,10 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "single check",
 			input: "single-check",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "273",
+				Max:      10,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "",
 					Config: map[string]string{
@@ -240,9 +251,10 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "invalid check",
 			input: "invalid-check",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "269",
+				Max:      10,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "",
 					Config: map[string]string{
@@ -257,9 +269,10 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "missing strategy",
 			input: "missing-strategy",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "311",
+				Max:      10,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "",
 					Config: map[string]string{
@@ -281,9 +294,10 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "empty strategy",
 			input: "empty-strategy",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "315",
+				Max:      10,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "",
 					Config: map[string]string{
@@ -307,9 +321,10 @@ func Test_parsePolicy(t *testing.T) {
 			name:  "invalid strategy",
 			input: "invalid-strategy",
 			expected: sdk.ScalingPolicy{
-				ID:   "id",
-				Max:  10,
-				Type: "horizontal",
+				ID:       "id",
+				Revision: "204",
+				Max:      10,
+				Type:     "horizontal",
 				Target: &sdk.ScalingPolicyTarget{
 					Name: "",
 					Config: map[string]string{
@@ -351,6 +366,675 @@ func Test_parsePolicy(t *testing.T) {
 	}
 }
 
+func Test_parsePolicy_min(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputMin        *int64
+		expectedMin     int64
+		expectedOmitted bool
+	}{
+		{
+			name:            "omitted min",
+			inputMin:        nil,
+			expectedMin:     0,
+			expectedOmitted: true,
+		},
+		{
+			name:            "explicit zero min",
+			inputMin:        ptr.Int64ToPtr(0),
+			expectedMin:     0,
+			expectedOmitted: false,
+		},
+		{
+			name:            "explicit min",
+			inputMin:        ptr.Int64ToPtr(2),
+			expectedMin:     2,
+			expectedOmitted: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Min: tc.inputMin, Max: ptr.Int64ToPtr(10)})
+			assert.Equal(t, tc.expectedMin, actual.Min, tc.name)
+			assert.Equal(t, tc.expectedOmitted, actual.MinOmitted, tc.name)
+			assert.False(t, actual.MaxOmitted, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_priority(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputPolicy      map[string]interface{}
+		expectedPriority int
+	}{
+		{
+			name:             "omitted priority",
+			inputPolicy:      map[string]interface{}{},
+			expectedPriority: 0,
+		},
+		{
+			name:             "priority",
+			inputPolicy:      map[string]interface{}{keyPriority: float64(10)},
+			expectedPriority: 10,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedPriority, actual.Priority, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_revision(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputIndex       uint64
+		expectedRevision string
+	}{
+		{
+			name:             "modify index",
+			inputIndex:       219,
+			expectedRevision: "219",
+		},
+		{
+			name:             "no modify index",
+			inputIndex:       0,
+			expectedRevision: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), ModifyIndex: tc.inputIndex})
+			assert.Equal(t, tc.expectedRevision, actual.Revision, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_fallbackStrategy(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputPolicy      map[string]interface{}
+		expectedFallback string
+	}{
+		{
+			name:             "omitted fallback strategy",
+			inputPolicy:      map[string]interface{}{},
+			expectedFallback: "",
+		},
+		{
+			name:             "fallback strategy",
+			inputPolicy:      map[string]interface{}{keyFallbackStrategy: "bounds"},
+			expectedFallback: sdk.FallbackStrategyBounds,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedFallback, actual.FallbackStrategy, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_outOfBoundsAction(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputPolicy    map[string]interface{}
+		expectedAction string
+	}{
+		{
+			name:           "omitted out of bounds action",
+			inputPolicy:    map[string]interface{}{},
+			expectedAction: "",
+		},
+		{
+			name:           "out of bounds action",
+			inputPolicy:    map[string]interface{}{keyOutOfBoundsAction: "wait"},
+			expectedAction: sdk.OutOfBoundsActionWait,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedAction, actual.OutOfBoundsAction, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_rounding(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputPolicy      map[string]interface{}
+		expectedRounding string
+	}{
+		{
+			name:             "omitted rounding",
+			inputPolicy:      map[string]interface{}{},
+			expectedRounding: "",
+		},
+		{
+			name:             "rounding",
+			inputPolicy:      map[string]interface{}{keyRounding: "ceil"},
+			expectedRounding: sdk.RoundingModeCeil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedRounding, actual.Rounding, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_metricUnit(t *testing.T) {
+	testCases := []struct {
+		name         string
+		inputPolicy  map[string]interface{}
+		expectedUnit string
+	}{
+		{
+			name:         "omitted metric unit",
+			inputPolicy:  map[string]interface{}{},
+			expectedUnit: "",
+		},
+		{
+			name:         "metric unit",
+			inputPolicy:  map[string]interface{}{keyMetricUnit: "bytes"},
+			expectedUnit: sdk.MetricUnitBytes,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedUnit, actual.MetricUnit, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_shadowTarget(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputPolicy    map[string]interface{}
+		expectedShadow *sdk.ScalingPolicyTarget
+	}{
+		{
+			name:           "omitted shadow target",
+			inputPolicy:    map[string]interface{}{},
+			expectedShadow: nil,
+		},
+		{
+			name: "shadow target",
+			inputPolicy: map[string]interface{}{
+				keyShadowTarget: []interface{}{
+					map[string]interface{}{
+						"nomad-next": []interface{}{
+							map[string]interface{}{
+								"Job":     "example-next",
+								"dry-run": true,
+							},
+						},
+					},
+				},
+			},
+			expectedShadow: &sdk.ScalingPolicyTarget{
+				Name: "nomad-next",
+				Config: map[string]string{
+					"Job":     "example-next",
+					"dry-run": "true",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{
+				ID:     "id",
+				Max:    ptr.Int64ToPtr(10),
+				Target: map[string]string{"Job": "example", "Group": "cache"},
+				Policy: tc.inputPolicy,
+			})
+			assert.Equal(t, tc.expectedShadow, actual.ShadowTarget, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_stabilizeCount(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputPolicy       map[string]interface{}
+		expectedStabilize bool
+		expectedDelay     time.Duration
+	}{
+		{
+			name:        "omitted stabilize count",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name:              "stabilize count with delay",
+			inputPolicy:       map[string]interface{}{keyStabilizeCount: true, keyStabilizeCountDelay: "10s"},
+			expectedStabilize: true,
+			expectedDelay:     10 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedStabilize, actual.StabilizeCount, tc.name)
+			assert.Equal(t, tc.expectedDelay, actual.StabilizeCountDelay, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_deferDuringDeployment(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputPolicy   map[string]interface{}
+		expectedDefer bool
+	}{
+		{
+			name:        "omitted defer during deployment",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name:          "defer during deployment",
+			inputPolicy:   map[string]interface{}{keyDeferDuringDeployment: true},
+			expectedDefer: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedDefer, actual.DeferDuringDeployment, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_metricRange(t *testing.T) {
+	testCases := []struct {
+		name        string
+		inputPolicy map[string]interface{}
+		expectedMin *float64
+		expectedMax *float64
+	}{
+		{
+			name:        "omitted metric range",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name:        "metric range",
+			inputPolicy: map[string]interface{}{keyMetricMin: float64(0), keyMetricMax: 100},
+			expectedMin: ptr.Float64ToPtr(0),
+			expectedMax: ptr.Float64ToPtr(100),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedMin, actual.MetricMin, tc.name)
+			assert.Equal(t, tc.expectedMax, actual.MetricMax, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_advisory(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputPolicy      map[string]interface{}
+		expectedAdvisory bool
+	}{
+		{
+			name:        "omitted advisory",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name:             "advisory",
+			inputPolicy:      map[string]interface{}{keyAdvisory: true},
+			expectedAdvisory: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedAdvisory, actual.Advisory, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_scaleDownStabilizationWindow(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputPolicy    map[string]interface{}
+		expectedWindow time.Duration
+	}{
+		{
+			name:        "omitted window",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name:           "window",
+			inputPolicy:    map[string]interface{}{keyScaleDownStabilizationWindow: "5m"},
+			expectedWindow: 5 * time.Minute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedWindow, actual.ScaleDownStabilizationWindow, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_minHealthyPercentage(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputPolicy     map[string]interface{}
+		expectedPercent float64
+	}{
+		{
+			name:        "omitted percentage",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name:            "percentage",
+			inputPolicy:     map[string]interface{}{keyMinHealthyPercentage: float64(75)},
+			expectedPercent: 75,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedPercent, actual.MinHealthyPercentage, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_consecutiveScaleDowns(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputPolicy   map[string]interface{}
+		expectedMax   int64
+		expectedReset time.Duration
+	}{
+		{
+			name:        "omitted limit",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name: "limit and reset",
+			inputPolicy: map[string]interface{}{
+				keyMaxConsecutiveScaleDowns:   float64(3),
+				keyConsecutiveScaleDownsReset: "1h",
+			},
+			expectedMax:   3,
+			expectedReset: time.Hour,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedMax, actual.MaxConsecutiveScaleDowns, tc.name)
+			assert.Equal(t, tc.expectedReset, actual.ConsecutiveScaleDownsReset, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_scaleDownStep(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputPolicy   map[string]interface{}
+		expectedStep  int64
+		expectedDelay time.Duration
+	}{
+		{
+			name:        "omitted step",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name: "step and delay",
+			inputPolicy: map[string]interface{}{
+				keyScaleDownStep:      float64(2),
+				keyScaleDownStepDelay: "10m",
+			},
+			expectedStep:  2,
+			expectedDelay: 10 * time.Minute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedStep, actual.ScaleDownStep, tc.name)
+			assert.Equal(t, tc.expectedDelay, actual.ScaleDownStepDelay, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_scaleUpProbation(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputPolicy       map[string]interface{}
+		expectedProbation time.Duration
+	}{
+		{
+			name:        "omitted probation",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name: "probation",
+			inputPolicy: map[string]interface{}{
+				keyScaleUpProbation: "5m",
+			},
+			expectedProbation: 5 * time.Minute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedProbation, actual.ScaleUpProbation, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_dependsOn(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputPolicy       map[string]interface{}
+		expectedDependsOn []string
+		expectedWindow    time.Duration
+	}{
+		{
+			name:        "omitted dependencies",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name: "dependencies",
+			inputPolicy: map[string]interface{}{
+				keyDependsOn:       []interface{}{"upstream", "database"},
+				keyDependsOnWindow: "2m",
+			},
+			expectedDependsOn: []string{"upstream", "database"},
+			expectedWindow:    2 * time.Minute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedDependsOn, actual.DependsOn, tc.name)
+			assert.Equal(t, tc.expectedWindow, actual.DependsOnWindow, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_targetPercentage(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputCheck         map[string]interface{}
+		expectedPercentage float64
+	}{
+		{
+			name:       "omitted target percentage",
+			inputCheck: map[string]interface{}{},
+		},
+		{
+			name: "target percentage",
+			inputCheck: map[string]interface{}{
+				keyTargetPercentage: float64(20),
+			},
+			expectedPercentage: 20,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.inputCheck[keyQuery] = "query"
+			actual := parsePolicy(&api.ScalingPolicy{
+				ID:  "id",
+				Max: ptr.Int64ToPtr(10),
+				Policy: map[string]interface{}{
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{tc.inputCheck},
+						},
+					},
+				},
+			})
+			if assert.Len(t, actual.Checks, 1, tc.name) {
+				assert.Equal(t, tc.expectedPercentage, actual.Checks[0].TargetPercentage, tc.name)
+			}
+		})
+	}
+}
+
+func Test_parsePolicy_adaptiveInterval(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputPolicy    map[string]interface{}
+		expectedMax    time.Duration
+		expectedStable int64
+	}{
+		{
+			name:        "omitted adaptive interval",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name: "max interval and stable evaluations",
+			inputPolicy: map[string]interface{}{
+				keyMaxEvaluationInterval: "5m",
+				keyStableEvaluations:     float64(4),
+			},
+			expectedMax:    5 * time.Minute,
+			expectedStable: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedMax, actual.MaxEvaluationInterval, tc.name)
+			assert.Equal(t, tc.expectedStable, actual.StableEvaluations, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_scheduledMins(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputPolicy    map[string]interface{}
+		expectedOutput []*sdk.ScalingPolicyScheduledMin
+	}{
+		{
+			name:        "omitted scheduled mins",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name: "scheduled mins sorted by name",
+			inputPolicy: map[string]interface{}{
+				keyScheduledMin: []interface{}{
+					map[string]interface{}{
+						"weekend": []interface{}{
+							map[string]interface{}{
+								keyCron:     "0 10 * * 0,6",
+								keyDuration: "6h",
+								keyMin:      float64(2),
+							},
+						},
+					},
+					map[string]interface{}{
+						"business-hours": []interface{}{
+							map[string]interface{}{
+								keyCron:     "0 8 * * 1-5",
+								keyTimeZone: "Europe/Amsterdam",
+								keyDuration: "10h",
+								keyMin:      float64(4),
+							},
+						},
+					},
+				},
+			},
+			expectedOutput: []*sdk.ScalingPolicyScheduledMin{
+				{Name: "business-hours", Cron: "0 8 * * 1-5", TimeZone: "Europe/Amsterdam", Duration: 10 * time.Hour, Min: 4},
+				{Name: "weekend", Cron: "0 10 * * 0,6", Duration: 6 * time.Hour, Min: 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedOutput, actual.ScheduledMins, tc.name)
+		})
+	}
+}
+
+func Test_parsePolicy_cron(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputPolicy      map[string]interface{}
+		expectedCron     string
+		expectedTimeZone string
+	}{
+		{
+			name:        "omitted cron",
+			inputPolicy: map[string]interface{}{},
+		},
+		{
+			name: "cron and time zone",
+			inputPolicy: map[string]interface{}{
+				keyCron:     "0 8 * * *",
+				keyTimeZone: "Europe/Amsterdam",
+			},
+			expectedCron:     "0 8 * * *",
+			expectedTimeZone: "Europe/Amsterdam",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parsePolicy(&api.ScalingPolicy{ID: "id", Max: ptr.Int64ToPtr(10), Policy: tc.inputPolicy})
+			assert.Equal(t, tc.expectedCron, actual.Cron, tc.name)
+			assert.Equal(t, tc.expectedTimeZone, actual.CronTimeZone, tc.name)
+		})
+	}
+}
+
 func Test_parseBlock(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -399,3 +1083,162 @@ func Test_parseBlock(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseLabels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    interface{}
+		expected map[string]string
+	}{
+		{
+			name:     "nil labels",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name:     "labels block",
+			input:    []interface{}{map[string]interface{}{"team": "platform", "tier": 1}},
+			expected: map[string]string{"team": "platform", "tier": "1"},
+		},
+		{
+			name:     "labels map",
+			input:    map[string]interface{}{"service": "api"},
+			expected: map[string]string{"service": "api"},
+		},
+		{
+			name:     "invalid labels",
+			input:    "team",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parseLabels(tc.input)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func Test_parseStringList(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    interface{}
+		expected []string
+	}{
+		{
+			name:     "nil list",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name:     "list keeps its order",
+			input:    []interface{}{"secondary", "tertiary"},
+			expected: []string{"secondary", "tertiary"},
+		},
+		{
+			name:     "non-string items skipped",
+			input:    []interface{}{"secondary", 1},
+			expected: []string{"secondary"},
+		},
+		{
+			name:     "invalid list",
+			input:    "secondary",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parseStringList(tc.input)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func Test_parseTransforms(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    interface{}
+		expected []*sdk.ScalingPolicyTransform
+	}{
+		{
+			name:     "nil transforms",
+			input:    nil,
+			expected: nil,
+		},
+		{
+			name: "transforms keep their order",
+			input: []interface{}{
+				map[string]interface{}{
+					"scale": []interface{}{map[string]interface{}{"factor": 0.5}},
+				},
+				map[string]interface{}{
+					"moving_average": []interface{}{map[string]interface{}{"points": 3}},
+				},
+				map[string]interface{}{
+					"scale": []interface{}{map[string]interface{}{"factor": 2}},
+				},
+			},
+			expected: []*sdk.ScalingPolicyTransform{
+				{Name: "scale", Config: map[string]string{"factor": "0.5"}},
+				{Name: "moving_average", Config: map[string]string{"points": "3"}},
+				{Name: "scale", Config: map[string]string{"factor": "2"}},
+			},
+		},
+		{
+			name:     "invalid transforms",
+			input:    "scale",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parseTransforms(tc.input)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func Test_parseNumber(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      interface{}
+		expected   float64
+		expectedOK bool
+	}{
+		{
+			name:       "float",
+			input:      12.5,
+			expected:   12.5,
+			expectedOK: true,
+		},
+		{
+			name:       "int",
+			input:      3,
+			expected:   3,
+			expectedOK: true,
+		},
+		{
+			name:       "string",
+			input:      "3",
+			expected:   0,
+			expectedOK: false,
+		},
+		{
+			name:       "nil",
+			input:      nil,
+			expected:   0,
+			expectedOK: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := parseNumber(tc.input)
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -17,14 +17,52 @@ import (
 // Keys represent the scaling policy document keys and help translate
 // the opaque object into a usable autoscaling policy.
 const (
-	keySource             = "source"
-	keyQuery              = "query"
-	keyQueryWindow        = "query_window"
-	keyEvaluationInterval = "evaluation_interval"
-	keyTarget             = "target"
-	keyChecks             = "check"
-	keyStrategy           = "strategy"
-	keyCooldown           = "cooldown"
+	keySource                       = "source"
+	keyFailoverSources              = "failover_sources"
+	keyQuery                        = "query"
+	keyQueryWindow                  = "query_window"
+	keyEvaluationInterval           = "evaluation_interval"
+	keyMaxEvaluationInterval        = "max_evaluation_interval"
+	keyStableEvaluations            = "stable_evaluations"
+	keyTarget                       = "target"
+	keyShadowTarget                 = "shadow_target"
+	keyChecks                       = "check"
+	keyStrategy                     = "strategy"
+	keyTransform                    = "transform"
+	keyTargetPercentage             = "target_percentage"
+	keyCooldown                     = "cooldown"
+	keyWarmupPeriod                 = "warmup_period"
+	keyVerifyScaleAfter             = "verify_scale_after"
+	keyRollbackOnVerifyFailure      = "rollback_on_verify_failure"
+	keyLabels                       = "labels"
+	keyReconcileOnStart             = "reconcile_on_start"
+	keyMaxScaleStep                 = "max_scale_step"
+	keyMaxScalePercent              = "max_scale_percent"
+	keyFallbackStrategy             = "fallback_strategy"
+	keyOutOfBoundsAction            = "out_of_bounds_action"
+	keyRounding                     = "rounding"
+	keyMetricUnit                   = "metric_unit"
+	keyPriority                     = "priority"
+	keyStabilizeCount               = "stabilize_count"
+	keyStabilizeCountDelay          = "stabilize_count_delay"
+	keyScaleDownStabilizationWindow = "scale_down_stabilization_window"
+	keyMinHealthyPercentage         = "min_healthy_percentage"
+	keyMaxConsecutiveScaleDowns     = "max_consecutive_scale_downs"
+	keyConsecutiveScaleDownsReset   = "consecutive_scale_downs_reset"
+	keyScaleDownStep                = "scale_down_step"
+	keyScaleDownStepDelay           = "scale_down_step_delay"
+	keyScaleUpProbation             = "scale_up_probation"
+	keyDependsOn                    = "depends_on"
+	keyDependsOnWindow              = "depends_on_window"
+	keyCron                         = "cron"
+	keyTimeZone                     = "time_zone"
+	keyDeferDuringDeployment        = "defer_during_deployment"
+	keyMetricMin                    = "metric_min"
+	keyMetricMax                    = "metric_max"
+	keyAdvisory                     = "advisory"
+	keyScheduledMin                 = "scheduled_min"
+	keyMin                          = "min"
+	keyDuration                     = "duration"
 )
 
 // Ensure NomadSource satisfies the Source interface.
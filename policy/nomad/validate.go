@@ -2,9 +2,13 @@ package nomad
 
 import (
 	"fmt"
+	"math"
 	"time"
 
+	"github.com/hashicorp/cronexpr"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
 	"github.com/hashicorp/nomad-autoscaler/sdk/helper/ptr"
 	"github.com/hashicorp/nomad/api"
 )
@@ -85,6 +89,272 @@ func validatePolicy(p map[string]interface{}) error {
 		}
 	}
 
+	// Validate WarmupPeriod, if present.
+	//   1. WarmupPeriod should be a valid duration.
+	if warmup, ok := p[keyWarmupPeriod]; ok {
+		if err := validateDuration(warmup, path+"."+keyWarmupPeriod); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate MaxEvaluationInterval and StableEvaluations, if present.
+	//   1. MaxEvaluationInterval should be a valid duration.
+	//   2. StableEvaluations should be a non-negative whole number.
+	if max, ok := p[keyMaxEvaluationInterval]; ok {
+		if err := validateDuration(max, path+"."+keyMaxEvaluationInterval); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	if v, ok := p[keyStableEvaluations]; ok {
+		if n, ok := parseNumber(v); !ok || n < 0 || n != math.Trunc(n) {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be a non-negative whole number, found %v", path, keyStableEvaluations, v))
+		}
+	}
+
+	// Validate VerifyScaleAfter, if present.
+	//   1. VerifyScaleAfter should be a valid duration.
+	if verify, ok := p[keyVerifyScaleAfter]; ok {
+		if err := validateDuration(verify, path+"."+keyVerifyScaleAfter); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate RollbackOnVerifyFailure, if present.
+	//   1. RollbackOnVerifyFailure should be a bool.
+	if rollback, ok := p[keyRollbackOnVerifyFailure]; ok {
+		if _, ok := rollback.(bool); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be bool, found %T", path, keyRollbackOnVerifyFailure, rollback))
+		}
+	}
+
+	// Validate StabilizeCount and StabilizeCountDelay, if present.
+	//   1. StabilizeCount should be a bool.
+	//   2. StabilizeCountDelay should be a valid duration.
+	if stabilize, ok := p[keyStabilizeCount]; ok {
+		if _, ok := stabilize.(bool); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be bool, found %T", path, keyStabilizeCount, stabilize))
+		}
+	}
+	if delay, ok := p[keyStabilizeCountDelay]; ok {
+		if err := validateDuration(delay, path+"."+keyStabilizeCountDelay); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate DeferDuringDeployment, if present.
+	//   1. DeferDuringDeployment should be a bool.
+	if deferDeployment, ok := p[keyDeferDuringDeployment]; ok {
+		if _, ok := deferDeployment.(bool); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be bool, found %T", path, keyDeferDuringDeployment, deferDeployment))
+		}
+	}
+
+	// Validate Advisory, if present.
+	//   1. Advisory should be a bool.
+	if advisory, ok := p[keyAdvisory]; ok {
+		if _, ok := advisory.(bool); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be bool, found %T", path, keyAdvisory, advisory))
+		}
+	}
+
+	// Validate ScaleDownStabilizationWindow, if present.
+	//   1. ScaleDownStabilizationWindow should be a valid duration.
+	if window, ok := p[keyScaleDownStabilizationWindow]; ok {
+		if err := validateDuration(window, path+"."+keyScaleDownStabilizationWindow); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate Labels, if present.
+	//   1. Labels must be a valid block or map.
+	//   2. Label values must be strings.
+	if labels, ok := p[keyLabels]; ok {
+		if err := validateBlock(labels, path+"."+keyLabels, validateLabels); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate ReconcileOnStart, if present.
+	//   1. ReconcileOnStart should be a bool.
+	if reconcile, ok := p[keyReconcileOnStart]; ok {
+		if _, ok := reconcile.(bool); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be bool, found %T", path, keyReconcileOnStart, reconcile))
+		}
+	}
+
+	// Validate MaxScaleStep and MaxScalePercent, if present.
+	//   1. Values should be non-negative numbers.
+	for _, key := range []string{keyMaxScaleStep, keyMaxScalePercent} {
+		if v, ok := p[key]; ok {
+			if n, ok := parseNumber(v); !ok || n < 0 {
+				result = multierror.Append(result, fmt.Errorf("%s.%s must be a non-negative number, found %v", path, key, v))
+			}
+		}
+	}
+
+	// Validate MinHealthyPercentage, if present.
+	//   1. MinHealthyPercentage should be a number between 0 and 100.
+	if v, ok := p[keyMinHealthyPercentage]; ok {
+		if n, ok := parseNumber(v); !ok || n < 0 || n > 100 {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be a number between 0 and 100, found %v", path, keyMinHealthyPercentage, v))
+		}
+	}
+
+	// Validate MetricMin and MetricMax, if present.
+	//   1. MetricMin should be a number.
+	//   2. MetricMax should be a number.
+	//   3. MetricMin should not be greater than MetricMax.
+	metricMin, hasMetricMin := p[keyMetricMin]
+	if hasMetricMin {
+		if _, ok := parseNumber(metricMin); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be a number, found %v", path, keyMetricMin, metricMin))
+		}
+	}
+	metricMax, hasMetricMax := p[keyMetricMax]
+	if hasMetricMax {
+		if _, ok := parseNumber(metricMax); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be a number, found %v", path, keyMetricMax, metricMax))
+		}
+	}
+	if min, ok := parseNumber(metricMin); ok {
+		if max, ok := parseNumber(metricMax); ok && min > max {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must not be greater than %s.%s", path, keyMetricMin, path, keyMetricMax))
+		}
+	}
+
+	// Validate MaxConsecutiveScaleDowns and ConsecutiveScaleDownsReset, if
+	// present.
+	//   1. MaxConsecutiveScaleDowns should be a non-negative whole number.
+	//   2. ConsecutiveScaleDownsReset should be a valid duration.
+	if v, ok := p[keyMaxConsecutiveScaleDowns]; ok {
+		if n, ok := parseNumber(v); !ok || n < 0 || n != math.Trunc(n) {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be a non-negative whole number, found %v", path, keyMaxConsecutiveScaleDowns, v))
+		}
+	}
+	if reset, ok := p[keyConsecutiveScaleDownsReset]; ok {
+		if err := validateDuration(reset, path+"."+keyConsecutiveScaleDownsReset); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate ScaleDownStep and ScaleDownStepDelay, if present.
+	//   1. ScaleDownStep should be a non-negative whole number.
+	//   2. ScaleDownStepDelay should be a valid duration.
+	if v, ok := p[keyScaleDownStep]; ok {
+		if n, ok := parseNumber(v); !ok || n < 0 || n != math.Trunc(n) {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be a non-negative whole number, found %v", path, keyScaleDownStep, v))
+		}
+	}
+	if delay, ok := p[keyScaleDownStepDelay]; ok {
+		if err := validateDuration(delay, path+"."+keyScaleDownStepDelay); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate ScaleUpProbation, if present, which should be a valid duration.
+	if probation, ok := p[keyScaleUpProbation]; ok {
+		if err := validateDuration(probation, path+"."+keyScaleUpProbation); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate DependsOn and DependsOnWindow, if present.
+	//   1. DependsOn must be a list.
+	//   2. Each DependsOn item must be a non-empty string.
+	//   3. DependsOnWindow should be a valid duration.
+	if dependsOn, ok := p[keyDependsOn]; ok {
+		list, ok := dependsOn.([]interface{})
+		if !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be []interface{}, found %T", path, keyDependsOn, dependsOn))
+		}
+		for i, item := range list {
+			if s, ok := item.(string); !ok {
+				result = multierror.Append(result, fmt.Errorf("%s.%s[%d] must be string, found %T", path, keyDependsOn, i, item))
+			} else if s == "" {
+				result = multierror.Append(result, fmt.Errorf("%s.%s[%d] can't be empty", path, keyDependsOn, i))
+			}
+		}
+	}
+	if window, ok := p[keyDependsOnWindow]; ok {
+		if err := validateDuration(window, path+"."+keyDependsOnWindow); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate Cron and TimeZone, if present.
+	//   1. Cron should be a valid cron expression.
+	//   2. TimeZone should be a valid IANA time zone, and requires Cron.
+	if cron, ok := p[keyCron]; ok {
+		if cronStr, ok := cron.(string); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be string, found %T", path, keyCron, cron))
+		} else if _, err := cronexpr.Parse(cronStr); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%s.%s is not a valid cron expression: %v", path, keyCron, err))
+		}
+	}
+	if tz, ok := p[keyTimeZone]; ok {
+		if tzStr, ok := tz.(string); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be string, found %T", path, keyTimeZone, tz))
+		} else if _, err := time.LoadLocation(tzStr); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%s.%s is not a valid time zone: %v", path, keyTimeZone, err))
+		}
+		if _, ok := p[keyCron]; !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s requires %s", path, keyTimeZone, keyCron))
+		}
+	}
+
+	// Validate Priority, if present.
+	//   1. Priority should be a whole number.
+	if priority, ok := p[keyPriority]; ok {
+		if n, ok := parseNumber(priority); !ok || n != math.Trunc(n) {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be a whole number, found %v", path, keyPriority, priority))
+		}
+	}
+
+	// Validate FallbackStrategy, if present.
+	//   1. FallbackStrategy should be one of the supported fallback strategies.
+	if fallback, ok := p[keyFallbackStrategy]; ok {
+		switch fallback {
+		case sdk.FallbackStrategyHold, sdk.FallbackStrategyBounds:
+		default:
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be %q or %q, found %v",
+				path, keyFallbackStrategy, sdk.FallbackStrategyHold, sdk.FallbackStrategyBounds, fallback))
+		}
+	}
+
+	// Validate OutOfBoundsAction, if present.
+	//   1. OutOfBoundsAction should be one of the supported actions.
+	if action, ok := p[keyOutOfBoundsAction]; ok {
+		switch action {
+		case sdk.OutOfBoundsActionCorrect, sdk.OutOfBoundsActionStrategy, sdk.OutOfBoundsActionWait:
+		default:
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be %q, %q or %q, found %v",
+				path, keyOutOfBoundsAction, sdk.OutOfBoundsActionCorrect, sdk.OutOfBoundsActionStrategy,
+				sdk.OutOfBoundsActionWait, action))
+		}
+	}
+
+	// Validate Rounding, if present.
+	//   1. Rounding should be one of the supported rounding modes.
+	if rounding, ok := p[keyRounding]; ok {
+		switch rounding {
+		case sdk.RoundingModeCeil, sdk.RoundingModeFloor, sdk.RoundingModeRound:
+		default:
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be %q, %q or %q, found %v",
+				path, keyRounding, sdk.RoundingModeCeil, sdk.RoundingModeFloor, sdk.RoundingModeRound, rounding))
+		}
+	}
+
+	// Validate MetricUnit, if present.
+	//   1. MetricUnit should be one of the supported units.
+	if unit, ok := p[keyMetricUnit]; ok {
+		switch unit {
+		case sdk.MetricUnitBytes, sdk.MetricUnitPercent, sdk.MetricUnitCount:
+		default:
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be %q, %q or %q, found %v",
+				path, keyMetricUnit, sdk.MetricUnitBytes, sdk.MetricUnitPercent, sdk.MetricUnitCount, unit))
+		}
+	}
+
 	// Validate Target, if present.
 	if targetInterface, ok := p[keyTarget]; ok {
 		err := validateBlocks(targetInterface, path+"."+keyTarget, validateTarget)
@@ -93,6 +363,22 @@ func validatePolicy(p map[string]interface{}) error {
 		}
 	}
 
+	// Validate shadow Target, if present.
+	if targetInterface, ok := p[keyShadowTarget]; ok {
+		err := validateBlocks(targetInterface, path+"."+keyShadowTarget, validateTarget)
+		if err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate ScheduledMin blocks, if present.
+	if scheduledInterface, ok := p[keyScheduledMin]; ok {
+		err := validateBlocks(scheduledInterface, path+"."+keyScheduledMin, validateScheduledMins)
+		if err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
 	// Validate Check blocks.
 	err := validateBlocks(p[keyChecks], path+"."+keyChecks, validateChecks)
 	if err != nil {
@@ -148,6 +434,89 @@ func validateChecks(in map[string]interface{}, path string) error {
 	return validateLabeledBlocks(in, path, ptr.IntToPtr(1), nil, validateCheck)
 }
 
+// validateScheduledMins validates the set of scheduled_min blocks within
+// policy.
+//
+//  scaling {
+//    policy {
+//    +--------------------------+
+//    | scheduled_min "peak" {   |
+//    |   ...                    |
+//    | }                        |
+//    +--------------------------+
+//      }
+//    }
+//  }
+//
+// Validation rules:
+//   1. All scheduled_min blocks should have labels.
+//   2. All scheduled_min blocks structure should be valid.
+func validateScheduledMins(in map[string]interface{}, path string) error {
+	return validateLabeledBlocks(in, path, nil, nil, validateScheduledMin)
+}
+
+// validateScheduledMin validates the content of a scheduled_min block.
+//
+//  scaling {
+//    policy {
+//      scheduled_min "peak" {
+//      +--------------------------------+
+//      | cron     = "0 0 8 * * 1-5 *"   |
+//      | duration = "10h"               |
+//      | min      = 5                   |
+//      +--------------------------------+
+//      }
+//    }
+//  }
+func validateScheduledMin(s map[string]interface{}, path string) error {
+	var result *multierror.Error
+
+	if s == nil {
+		return multierror.Append(result, fmt.Errorf("%s is nil", path))
+	}
+
+	// Validate Cron.
+	//   1. Cron is required.
+	//   2. Cron should be a valid cron expression.
+	if cron, ok := s[keyCron]; !ok {
+		result = multierror.Append(result, fmt.Errorf("%s.%s is required", path, keyCron))
+	} else if cronStr, ok := cron.(string); !ok {
+		result = multierror.Append(result, fmt.Errorf("%s.%s must be string, found %T", path, keyCron, cron))
+	} else if _, err := cronexpr.Parse(cronStr); err != nil {
+		result = multierror.Append(result, fmt.Errorf("%s.%s is not a valid cron expression: %v", path, keyCron, err))
+	}
+
+	// Validate TimeZone, if present.
+	//   1. TimeZone should be a valid IANA time zone.
+	if tz, ok := s[keyTimeZone]; ok {
+		if tzStr, ok := tz.(string); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be string, found %T", path, keyTimeZone, tz))
+		} else if _, err := time.LoadLocation(tzStr); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%s.%s is not a valid time zone: %v", path, keyTimeZone, err))
+		}
+	}
+
+	// Validate Duration.
+	//   1. Duration is required.
+	//   2. Duration should be a valid duration.
+	if duration, ok := s[keyDuration]; !ok {
+		result = multierror.Append(result, fmt.Errorf("%s.%s is required", path, keyDuration))
+	} else if err := validateDuration(duration, path+"."+keyDuration); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	// Validate Min.
+	//   1. Min is required.
+	//   2. Min should be a non-negative whole number.
+	if min, ok := s[keyMin]; !ok {
+		result = multierror.Append(result, fmt.Errorf("%s.%s is required", path, keyMin))
+	} else if n, ok := parseNumber(min); !ok || n < 0 || n != math.Trunc(n) {
+		result = multierror.Append(result, fmt.Errorf("%s.%s must be a non-negative whole number, found %v", path, keyMin, min))
+	}
+
+	return result.ErrorOrNil()
+}
+
 // validateCheck validates the content of a check block.
 //
 //  scaling {
@@ -176,6 +545,23 @@ func validateCheck(c map[string]interface{}, path string) error {
 		}
 	}
 
+	// Validate FailoverSources, if present.
+	//   1. FailoverSources must be a list.
+	//   2. Each FailoverSource must be a non-empty string.
+	if failoverSources, ok := c[keyFailoverSources]; ok {
+		list, ok := failoverSources.([]interface{})
+		if !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be []interface{}, found %T", path, keyFailoverSources, failoverSources))
+		}
+		for i, item := range list {
+			if s, ok := item.(string); !ok {
+				result = multierror.Append(result, fmt.Errorf("%s.%s[%d] must be string, found %T", path, keyFailoverSources, i, item))
+			} else if s == "" {
+				result = multierror.Append(result, fmt.Errorf("%s.%s[%d] can't be empty", path, keyFailoverSources, i))
+			}
+		}
+	}
+
 	// Validate Query.
 	//   1. Query must have string value.
 	//   2. Query must not be empty.
@@ -209,6 +595,39 @@ func validateCheck(c map[string]interface{}, path string) error {
 		result = multierror.Append(result, strategyErrs)
 	}
 
+	// Validate Transform, if present.
+	//   1. Each Transform must be a valid labeled block.
+	//   2. Each Transform must be known and have a valid config.
+	if transforms, ok := c[keyTransform]; ok {
+		list, ok := transforms.([]interface{})
+		if !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be []interface{}, found %T", path, keyTransform, transforms))
+		}
+		for i, item := range list {
+			blockMap, ok := item.(map[string]interface{})
+			if !ok {
+				result = multierror.Append(result, fmt.Errorf("%s.%s[%d] must be map[string]interface{}, found %T", path, keyTransform, i, item))
+				continue
+			}
+			if err := validateLabeledBlocks(blockMap, fmt.Sprintf("%s.%s[%d]", path, keyTransform, i), nil, nil, nil); err != nil {
+				result = multierror.Append(result, err)
+			}
+		}
+		for _, t := range parseTransforms(transforms) {
+			if err := policy.ValidateTransform(t); err != nil {
+				result = multierror.Append(result, fmt.Errorf("%s.%s: %v", path, keyTransform, err))
+			}
+		}
+	}
+
+	// Validate TargetPercentage, if present.
+	//   1. TargetPercentage must be a non-negative number.
+	if v, ok := c[keyTargetPercentage]; ok {
+		if n, ok := parseNumber(v); !ok || n < 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be a non-negative number, found %v", path, keyTargetPercentage, v))
+		}
+	}
+
 	return result.ErrorOrNil()
 }
 
@@ -234,6 +653,32 @@ func validateStrategy(s map[string]interface{}, path string) error {
 	return validateLabeledBlocks(s, path, ptr.IntToPtr(1), ptr.IntToPtr(1), nil)
 }
 
+// validateLabels validates the content of the labels block within a policy.
+//
+//  scaling {
+//    policy {
+//      labels {
+//      +---------------+
+//      | key = "value" |
+//      +---------------+
+//      }
+//    }
+//  }
+//
+// Validation rules:
+//   1. All values must be strings.
+func validateLabels(l map[string]interface{}, path string) error {
+	var result *multierror.Error
+
+	for k, v := range l {
+		if _, ok := v.(string); !ok {
+			result = multierror.Append(result, fmt.Errorf("%s.%s must be string, found %T", path, k, v))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
 // validateDuration validates if the input has a valid time.Duration format.
 //
 // Validation rules:
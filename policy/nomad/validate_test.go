@@ -454,6 +454,2121 @@ func Test_validateScalingPolicy(t *testing.T) {
 			inputFile:   "invalid-cooldown",
 			expectError: true,
 		},
+		{
+			name: "policy.verify_scale_after has wrong format",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyVerifyScaleAfter: 120,
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.warmup_period has wrong format",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyWarmupPeriod: "five minutes",
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.reconcile_on_start has wrong type",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyReconcileOnStart: "true",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.rollback_on_verify_failure has wrong type",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyRollbackOnVerifyFailure: "true",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.fallback_strategy is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyFallbackStrategy: "hold",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.fallback_strategy is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyFallbackStrategy: "retry",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.out_of_bounds_action is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyOutOfBoundsAction: "correct",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.out_of_bounds_action is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyOutOfBoundsAction: "ignore",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.shadow_target is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyShadowTarget: []interface{}{
+						map[string]interface{}{
+							"nomad-next": []interface{}{
+								map[string]interface{}{
+									"key": "value",
+								},
+							},
+						},
+					},
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.shadow_target.name is empty",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyShadowTarget: []interface{}{
+						map[string]interface{}{
+							"": []interface{}{
+								map[string]interface{}{
+									"key": "value",
+								},
+							},
+						},
+					},
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.rounding is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyRounding: "floor",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.rounding is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyRounding: "up",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.metric_unit is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMetricUnit: "percent",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.metric_unit is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMetricUnit: "megabytes",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.priority is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyPriority: float64(10),
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.priority is not a whole number",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyPriority: 1.5,
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.stabilize_count is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyStabilizeCount:      true,
+					keyStabilizeCountDelay: "10s",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.stabilize_count is not a bool",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyStabilizeCount: "true",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.defer_during_deployment is not a bool",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyDeferDuringDeployment: "true",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.advisory is not a bool",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyAdvisory: "true",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.metric_min and metric_max are valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMetricMin: float64(0),
+					keyMetricMax: float64(100),
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.metric_min is not a number",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMetricMin: "zero",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.metric_min is greater than metric_max",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMetricMin: float64(100),
+					keyMetricMax: float64(0),
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.stabilize_count_delay is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyStabilizeCountDelay: "soon",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.scale_down_stabilization_window is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyScaleDownStabilizationWindow: "5m",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.scale_down_stabilization_window is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyScaleDownStabilizationWindow: "soon",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.min_healthy_percentage is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMinHealthyPercentage: float64(75),
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.min_healthy_percentage is above 100",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMinHealthyPercentage: float64(150),
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.min_healthy_percentage is not a number",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMinHealthyPercentage: "half",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.max_consecutive_scale_downs is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMaxConsecutiveScaleDowns:   float64(3),
+					keyConsecutiveScaleDownsReset: "1h",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.max_consecutive_scale_downs is not a whole number",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMaxConsecutiveScaleDowns: 1.5,
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.consecutive_scale_downs_reset is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyConsecutiveScaleDownsReset: "later",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.scale_down_step is not a whole number",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyScaleDownStep: 1.5,
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.scale_down_step_delay is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyScaleDownStepDelay: "later",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.scale_up_probation is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyScaleUpProbation: "later",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.depends_on is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyDependsOn:       []interface{}{"upstream"},
+					keyDependsOnWindow: "2m",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.depends_on is not a list",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyDependsOn: "upstream",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.depends_on has an empty item",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyDependsOn: []interface{}{"upstream", ""},
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.depends_on_window is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyDependsOnWindow: "later",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.max_evaluation_interval is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMaxEvaluationInterval: "5m",
+					keyStableEvaluations:     float64(4),
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.max_evaluation_interval is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyMaxEvaluationInterval: "later",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.stable_evaluations is negative",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyStableEvaluations: float64(-1),
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.scheduled_min is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyScheduledMin: []interface{}{
+						map[string]interface{}{
+							"peak": []interface{}{
+								map[string]interface{}{
+									keyCron:     "0 8 * * 1-5",
+									keyTimeZone: "Europe/Amsterdam",
+									keyDuration: "10h",
+									keyMin:      float64(4),
+								},
+							},
+						},
+					},
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.scheduled_min is missing cron",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyScheduledMin: []interface{}{
+						map[string]interface{}{
+							"peak": []interface{}{
+								map[string]interface{}{
+									keyDuration: "10h",
+									keyMin:      float64(4),
+								},
+							},
+						},
+					},
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.scheduled_min has invalid duration",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyScheduledMin: []interface{}{
+						map[string]interface{}{
+							"peak": []interface{}{
+								map[string]interface{}{
+									keyCron:     "0 8 * * 1-5",
+									keyDuration: "later",
+									keyMin:      float64(4),
+								},
+							},
+						},
+					},
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.scheduled_min has negative min",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyScheduledMin: []interface{}{
+						map[string]interface{}{
+							"peak": []interface{}{
+								map[string]interface{}{
+									keyCron:     "0 8 * * 1-5",
+									keyDuration: "10h",
+									keyMin:      float64(-1),
+								},
+							},
+						},
+					},
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.cron is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyCron:     "0 8 * * *",
+					keyTimeZone: "Europe/Amsterdam",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.cron is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyCron: "every day",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.time_zone is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyCron:     "0 8 * * *",
+					keyTimeZone: "Mars/Olympus_Mons",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.time_zone without cron",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyTimeZone: "Europe/Amsterdam",
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.check.transform is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+									keyTransform: []interface{}{
+										map[string]interface{}{
+											"scale": []interface{}{
+												map[string]interface{}{
+													"factor": 0.5,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.check.transform is invalid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+									keyTransform: []interface{}{
+										map[string]interface{}{
+											"scale": []interface{}{
+												map[string]interface{}{
+													"factor": "half",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.check.transform is unknown",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource: "source",
+									keyQuery:  "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+									keyTransform: []interface{}{
+										map[string]interface{}{
+											"round": []interface{}{
+												map[string]interface{}{
+													"digits": 1,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.check.target_percentage is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource:           "source",
+									keyQuery:            "query",
+									keyTargetPercentage: float64(20),
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.check.target_percentage is negative",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource:           "source",
+									keyQuery:            "query",
+									keyTargetPercentage: float64(-20),
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.check.target_percentage is not a number",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource:           "source",
+									keyQuery:            "query",
+									keyTargetPercentage: "20%",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.check.failover_sources is valid",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource:          "source",
+									keyFailoverSources: []interface{}{"secondary"},
+									keyQuery:           "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "policy.check.failover_sources has empty source",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource:          "source",
+									keyFailoverSources: []interface{}{"secondary", ""},
+									keyQuery:           "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "policy.check.failover_sources is not a list",
+			input: &api.ScalingPolicy{
+				ID:   "id",
+				Type: "horizontal",
+				Target: map[string]string{
+					"key": "value",
+				},
+				Min: ptr.Int64ToPtr(1),
+				Max: ptr.Int64ToPtr(5),
+				Policy: map[string]interface{}{
+					keyChecks: []interface{}{
+						map[string]interface{}{
+							"check": []interface{}{
+								map[string]interface{}{
+									keySource:          "source",
+									keyFailoverSources: "secondary",
+									keyQuery:           "query",
+									keyStrategy: []interface{}{
+										map[string]interface{}{
+											"strategy": []interface{}{
+												map[string]interface{}{
+													"key": "value",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2,7 +2,9 @@ package policy
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad-autoscaler/plugins"
@@ -35,6 +37,12 @@ func (pr *Processor) ApplyPolicyDefaults(p *sdk.ScalingPolicy) {
 	if p.EvaluationInterval == 0 {
 		p.EvaluationInterval = pr.defaults.DefaultEvaluationInterval
 	}
+	if p.WarmupPeriod == 0 {
+		p.WarmupPeriod = pr.defaults.DefaultWarmupPeriod
+	}
+	if p.StabilizeCount && p.StabilizeCountDelay == 0 {
+		p.StabilizeCountDelay = DefaultStabilizeCountDelay
+	}
 
 	for i := 0; i < len(p.Checks); i++ {
 		c := p.Checks[i]
@@ -59,9 +67,109 @@ func (pr *Processor) ValidatePolicy(p *sdk.ScalingPolicy) error {
 	if p.Max < 0 {
 		mErr = multierror.Append(mErr, fmt.Errorf("policy Max can't be negative"))
 	}
-	if p.Min > p.Max {
+	// The agent default max is applied in place of an omitted max during
+	// evaluation, so the values can only be compared once it is known.
+	if !p.MaxOmitted && p.Min > p.Max {
 		mErr = multierror.Append(mErr, fmt.Errorf("policy Min must not be greater Max"))
 	}
+	switch p.FallbackStrategy {
+	case "", sdk.FallbackStrategyHold, sdk.FallbackStrategyBounds:
+	default:
+		mErr = multierror.Append(mErr, fmt.Errorf("policy FallbackStrategy must be %q or %q, found %q",
+			sdk.FallbackStrategyHold, sdk.FallbackStrategyBounds, p.FallbackStrategy))
+	}
+	switch p.OutOfBoundsAction {
+	case "", sdk.OutOfBoundsActionCorrect, sdk.OutOfBoundsActionStrategy, sdk.OutOfBoundsActionWait:
+	default:
+		mErr = multierror.Append(mErr, fmt.Errorf("policy OutOfBoundsAction must be %q, %q or %q, found %q",
+			sdk.OutOfBoundsActionCorrect, sdk.OutOfBoundsActionStrategy, sdk.OutOfBoundsActionWait, p.OutOfBoundsAction))
+	}
+	switch p.Rounding {
+	case "", sdk.RoundingModeCeil, sdk.RoundingModeFloor, sdk.RoundingModeRound:
+	default:
+		mErr = multierror.Append(mErr, fmt.Errorf("policy Rounding must be %q, %q or %q, found %q",
+			sdk.RoundingModeCeil, sdk.RoundingModeFloor, sdk.RoundingModeRound, p.Rounding))
+	}
+	switch p.MetricUnit {
+	case "", sdk.MetricUnitBytes, sdk.MetricUnitPercent, sdk.MetricUnitCount:
+	default:
+		mErr = multierror.Append(mErr, fmt.Errorf("policy MetricUnit must be %q, %q or %q, found %q",
+			sdk.MetricUnitBytes, sdk.MetricUnitPercent, sdk.MetricUnitCount, p.MetricUnit))
+	}
+	if p.ShadowTarget != nil && p.ShadowTarget.Name == "" {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy ShadowTarget name is empty"))
+	}
+	if p.MaxEvaluationInterval < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy MaxEvaluationInterval can't be negative"))
+	}
+	if p.StableEvaluations < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy StableEvaluations can't be negative"))
+	}
+	if p.StabilizeCountDelay < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy StabilizeCountDelay can't be negative"))
+	}
+	if p.ScaleDownStabilizationWindow < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy ScaleDownStabilizationWindow can't be negative"))
+	}
+	if p.MinHealthyPercentage < 0 || p.MinHealthyPercentage > 100 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy MinHealthyPercentage must be between 0 and 100"))
+	}
+	if p.MaxConsecutiveScaleDowns < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy MaxConsecutiveScaleDowns can't be negative"))
+	}
+	if p.ConsecutiveScaleDownsReset < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy ConsecutiveScaleDownsReset can't be negative"))
+	}
+	if p.ScaleDownStep < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy ScaleDownStep can't be negative"))
+	}
+	if p.ScaleDownStepDelay < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy ScaleDownStepDelay can't be negative"))
+	}
+	if p.ScaleUpProbation < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy ScaleUpProbation can't be negative"))
+	}
+	for _, dep := range p.DependsOn {
+		if dep == "" {
+			mErr = multierror.Append(mErr, fmt.Errorf("policy DependsOn can't contain an empty ID"))
+		} else if dep == p.ID {
+			mErr = multierror.Append(mErr, fmt.Errorf("policy can't depend on itself"))
+		}
+	}
+	if p.DependsOnWindow < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy DependsOnWindow can't be negative"))
+	}
+	if p.MetricMin != nil && p.MetricMax != nil && *p.MetricMin > *p.MetricMax {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy MetricMin must not be greater than MetricMax"))
+	}
+	if p.Cron != "" {
+		if _, err := nextCronTime(p.Cron, p.CronTimeZone, time.Now()); err != nil {
+			mErr = multierror.Append(mErr, fmt.Errorf("policy Cron: %v", err))
+		}
+	} else if p.CronTimeZone != "" {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy CronTimeZone requires Cron"))
+	}
+	for _, s := range p.ScheduledMins {
+		if _, err := nextCronTime(s.Cron, s.TimeZone, time.Now()); err != nil {
+			mErr = multierror.Append(mErr, fmt.Errorf("policy scheduled min %s: %v", s.Name, err))
+		}
+		if s.Duration <= 0 {
+			mErr = multierror.Append(mErr, fmt.Errorf("policy scheduled min %s: duration must be positive", s.Name))
+		}
+		if s.Min < 0 {
+			mErr = multierror.Append(mErr, fmt.Errorf("policy scheduled min %s: min can't be negative", s.Name))
+		}
+	}
+	for _, c := range p.Checks {
+		for _, t := range c.Transforms {
+			if err := ValidateTransform(t); err != nil {
+				mErr = multierror.Append(mErr, fmt.Errorf("policy check %s: %v", c.Name, err))
+			}
+		}
+		if c.TargetPercentage < 0 || math.IsNaN(c.TargetPercentage) || math.IsInf(c.TargetPercentage, 0) {
+			mErr = multierror.Append(mErr, fmt.Errorf("policy check %s: target percentage must be a non-negative number", c.Name))
+		}
+	}
 
 	return mErr.ErrorOrNil()
 }
@@ -7,6 +7,7 @@ import (
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/ptr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -78,6 +79,334 @@ func TestProcessor_ValidatePolicy(t *testing.T) {
 			},
 			name: "negative maximum value which is lower than minimum",
 		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:         "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:        1,
+				MaxOmitted: true,
+			},
+			expectedOutput: nil,
+			name:           "omitted maximum value",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:               "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:              1,
+				Max:              10,
+				FallbackStrategy: sdk.FallbackStrategyBounds,
+			},
+			expectedOutput: nil,
+			name:           "valid fallback strategy",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:                  "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:                 1,
+				Max:                 10,
+				StabilizeCount:      true,
+				StabilizeCountDelay: -time.Second,
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy StabilizeCountDelay can't be negative"),
+				},
+			},
+			name: "negative stabilize count delay",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:                           "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:                          1,
+				Max:                          10,
+				ScaleDownStabilizationWindow: -time.Minute,
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy ScaleDownStabilizationWindow can't be negative"),
+				},
+			},
+			name: "negative scale down stabilization window",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:                   "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:                  1,
+				Max:                  10,
+				MinHealthyPercentage: 101,
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy MinHealthyPercentage must be between 0 and 100"),
+				},
+			},
+			name: "min healthy percentage above 100",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:                         "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:                        1,
+				Max:                        10,
+				MaxConsecutiveScaleDowns:   -1,
+				ConsecutiveScaleDownsReset: -time.Minute,
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy MaxConsecutiveScaleDowns can't be negative"),
+					errors.New("policy ConsecutiveScaleDownsReset can't be negative"),
+				},
+			},
+			name: "negative consecutive scale downs",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:                 "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:                1,
+				Max:                10,
+				ScaleDownStep:      -1,
+				ScaleDownStepDelay: -time.Minute,
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy ScaleDownStep can't be negative"),
+					errors.New("policy ScaleDownStepDelay can't be negative"),
+				},
+			},
+			name: "negative scale down step",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:               "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:              1,
+				Max:              10,
+				ScaleUpProbation: -time.Minute,
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy ScaleUpProbation can't be negative"),
+				},
+			},
+			name: "negative scale up probation",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:              "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:             1,
+				Max:             10,
+				DependsOn:       []string{"", "ce888afe-3dd2-144c-7227-74644434f708"},
+				DependsOnWindow: -time.Minute,
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy DependsOn can't contain an empty ID"),
+					errors.New("policy can't depend on itself"),
+					errors.New("policy DependsOnWindow can't be negative"),
+				},
+			},
+			name: "invalid dependencies",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:  "ce888afe-3dd2-144c-7227-74644434f708",
+				Min: 1,
+				Max: 10,
+				Checks: []*sdk.ScalingPolicyCheck{
+					{Name: "workers", TargetPercentage: -20},
+				},
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy check workers: target percentage must be a non-negative number"),
+				},
+			},
+			name: "negative target percentage",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:                    "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:                   1,
+				Max:                   10,
+				MaxEvaluationInterval: -time.Minute,
+				StableEvaluations:     -1,
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy MaxEvaluationInterval can't be negative"),
+					errors.New("policy StableEvaluations can't be negative"),
+				},
+			},
+			name: "negative adaptive interval",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:  "ce888afe-3dd2-144c-7227-74644434f708",
+				Min: 1,
+				Max: 10,
+				ScheduledMins: []*sdk.ScalingPolicyScheduledMin{
+					{Name: "peak", Cron: "0 8 * * *", Duration: 10 * time.Hour, Min: 4},
+					{Name: "invalid", Cron: "every day", Duration: 0, Min: -1},
+				},
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy scheduled min invalid: invalid cron expression: missing field(s)"),
+					errors.New("policy scheduled min invalid: duration must be positive"),
+					errors.New("policy scheduled min invalid: min can't be negative"),
+				},
+			},
+			name: "invalid scheduled min",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:  "ce888afe-3dd2-144c-7227-74644434f708",
+				Min: 1,
+				Max: 10,
+				Checks: []*sdk.ScalingPolicyCheck{{
+					Name:       "cpu",
+					Transforms: []*sdk.ScalingPolicyTransform{{Name: "round"}},
+				}},
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New(`policy check cpu: unknown transform "round"`),
+				},
+			},
+			name: "unknown transform",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:           "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:          1,
+				Max:          10,
+				Cron:         "0 8 * * *",
+				CronTimeZone: "Europe/Amsterdam",
+			},
+			expectedOutput: nil,
+			name:           "valid cron",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:   "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:  1,
+				Max:  10,
+				Cron: "every day",
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy Cron: invalid cron expression: missing field(s)"),
+				},
+			},
+			name: "invalid cron",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:           "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:          1,
+				Max:          10,
+				CronTimeZone: "Europe/Amsterdam",
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy CronTimeZone requires Cron"),
+				},
+			},
+			name: "time zone without cron",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:        "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:       1,
+				Max:       10,
+				MetricMin: ptr.Float64ToPtr(0),
+				MetricMax: ptr.Float64ToPtr(100),
+			},
+			expectedOutput: nil,
+			name:           "valid metric range",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:        "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:       1,
+				Max:       10,
+				MetricMin: ptr.Float64ToPtr(100),
+				MetricMax: ptr.Float64ToPtr(0),
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy MetricMin must not be greater than MetricMax"),
+				},
+			},
+			name: "metric min greater than metric max",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:               "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:              1,
+				Max:              10,
+				FallbackStrategy: "retry",
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New(`policy FallbackStrategy must be "hold" or "bounds", found "retry"`),
+				},
+			},
+			name: "invalid fallback strategy",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:                "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:               1,
+				Max:               10,
+				OutOfBoundsAction: "ignore",
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New(`policy OutOfBoundsAction must be "correct", "strategy" or "wait", found "ignore"`),
+				},
+			},
+			name: "invalid out of bounds action",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:       "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:      1,
+				Max:      10,
+				Rounding: "up",
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New(`policy Rounding must be "ceil", "floor" or "round", found "up"`),
+				},
+			},
+			name: "invalid rounding",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:         "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:        1,
+				Max:        10,
+				MetricUnit: "megabytes",
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New(`policy MetricUnit must be "bytes", "percent" or "count", found "megabytes"`),
+				},
+			},
+			name: "invalid metric unit",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				ID:           "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:          1,
+				Max:          10,
+				ShadowTarget: &sdk.ScalingPolicyTarget{Config: map[string]string{"Job": "web"}},
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy ShadowTarget name is empty"),
+				},
+			},
+			name: "shadow target without name",
+		},
 	}
 
 	pr := Processor{}
@@ -284,6 +613,52 @@ func TestProcessor_ApplyPolicyDefaults(t *testing.T) {
 			},
 			name: "neither set to default",
 		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				Cooldown:           10 * time.Minute,
+				EvaluationInterval: 5 * time.Minute,
+			},
+			inputDefaults: &ConfigDefaults{
+				DefaultEvaluationInterval: 5 * time.Second,
+				DefaultCooldown:           10 * time.Second,
+				DefaultWarmupPeriod:       2 * time.Minute,
+			},
+			expectedOutputPolicy: &sdk.ScalingPolicy{
+				Cooldown:           10 * time.Minute,
+				EvaluationInterval: 5 * time.Minute,
+				WarmupPeriod:       2 * time.Minute,
+			},
+			name: "warmup period set to default",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				Cooldown:           10 * time.Minute,
+				EvaluationInterval: 5 * time.Minute,
+				StabilizeCount:     true,
+			},
+			inputDefaults: &ConfigDefaults{},
+			expectedOutputPolicy: &sdk.ScalingPolicy{
+				Cooldown:            10 * time.Minute,
+				EvaluationInterval:  5 * time.Minute,
+				StabilizeCount:      true,
+				StabilizeCountDelay: DefaultStabilizeCountDelay,
+			},
+			name: "stabilize count delay set to default",
+		},
+		{
+			inputPolicy: &sdk.ScalingPolicy{
+				Cooldown:            10 * time.Minute,
+				EvaluationInterval:  5 * time.Minute,
+				StabilizeCountDelay: 10 * time.Second,
+			},
+			inputDefaults: &ConfigDefaults{},
+			expectedOutputPolicy: &sdk.ScalingPolicy{
+				Cooldown:            10 * time.Minute,
+				EvaluationInterval:  5 * time.Minute,
+				StabilizeCountDelay: 10 * time.Second,
+			},
+			name: "stabilize count delay kept",
+		},
 	}
 
 	for _, tc := range testCases {
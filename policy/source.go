@@ -12,10 +12,15 @@ import (
 // a policy check.
 const DefaultQueryWindow = time.Minute
 
+// DefaultStabilizeCountDelay is the value used if `stabilize_count_delay` is
+// not specified in a policy which enables `stabilize_count`.
+const DefaultStabilizeCountDelay = 5 * time.Second
+
 // ConfigDefaults holds default configuration for unspecified values.
 type ConfigDefaults struct {
 	DefaultEvaluationInterval time.Duration
 	DefaultCooldown           time.Duration
+	DefaultWarmupPeriod       time.Duration
 }
 
 type MonitorIDsReq struct {
@@ -65,6 +70,18 @@ const (
 
 	// SourceNameFile is the source for policies that are loaded from disk.
 	SourceNameFile SourceName = "file"
+
+	// SourceNameHTTP is the source for policies that are loaded from a remote
+	// HTTP endpoint.
+	SourceNameHTTP SourceName = "http"
+
+	// SourceNameAPI is the source for policies that are registered at
+	// runtime using the agent HTTP API.
+	SourceNameAPI SourceName = "api"
+
+	// SourceNameConsul is the source for policies that are loaded from the
+	// Consul KV store.
+	SourceNameConsul SourceName = "consul"
 )
 
 // HandleSourceError provides common functionality when a policy source
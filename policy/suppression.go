@@ -0,0 +1,47 @@
+package policy
+
+import (
+	"github.com/armon/go-metrics"
+)
+
+// The reasons reported when a policy evaluation, or the scaling action it
+// produced, is suppressed.
+const (
+	SuppressionReasonCooldown         = "cooldown"
+	SuppressionReasonWarmup           = "warmup"
+	SuppressionReasonTargetNotReady   = "target_not_ready"
+	SuppressionReasonNoData           = "no_data"
+	SuppressionReasonBounds           = "bounds"
+	SuppressionReasonNotLeader        = "not_leader"
+	SuppressionReasonCountUnstable    = "count_unstable"
+	SuppressionReasonCapacityBudget   = "capacity_budget"
+	SuppressionReasonStabilization    = "stabilization"
+	SuppressionReasonUnhealthy        = "unhealthy"
+	SuppressionReasonScaleDownLimit   = "scale_down_limit"
+	SuppressionReasonDeployment       = "deployment"
+	SuppressionReasonInvalidMetric    = "invalid_metric"
+	SuppressionReasonTargetNotAllowed = "target_not_allowed"
+	SuppressionReasonScaleHook        = "scale_hook"
+	SuppressionReasonThrottled        = "throttled"
+	SuppressionReasonTargetConflict   = "target_conflict"
+	SuppressionReasonOutOfBounds      = "out_of_bounds"
+	SuppressionReasonMaintenance      = "maintenance"
+	SuppressionReasonStepDownDelay    = "step_down_delay"
+	SuppressionReasonProbation        = "probation"
+	SuppressionReasonPolicyConflict   = "policy_conflict"
+	SuppressionReasonInvalidQuery     = "invalid_query"
+	SuppressionReasonDependency       = "dependency"
+	SuppressionReasonDependencyCycle  = "dependency_cycle"
+)
+
+// IncrSuppressedCount increments the counter tracking the evaluations and
+// scaling actions of the policy which were suppressed for the reason. This
+// helps operators understand why scaling is not happening without parsing
+// the logs.
+func IncrSuppressedCount(id string, reason string) {
+	labels := []metrics.Label{
+		{Name: "policy_id", Value: id},
+		{Name: "reason", Value: reason},
+	}
+	metrics.IncrCounterWithLabels([]string{"scale", "suppressed_count"}, 1, labels)
+}
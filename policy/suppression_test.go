@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestSink replaces the global metrics sink with an in-memory sink, so the
+// metrics emitted by the test can be inspected.
+func newTestSink(t *testing.T) *metrics.InmemSink {
+	inm := metrics.NewInmemSink(10*time.Second, time.Minute)
+
+	cfg := metrics.DefaultConfig("")
+	cfg.EnableHostname = false
+	cfg.EnableRuntimeMetrics = false
+
+	_, err := metrics.NewGlobal(cfg, inm)
+	assert.NoError(t, err)
+	return inm
+}
+
+// suppressedCount returns the value of the suppressed counter of the policy
+// for the reason.
+func suppressedCount(inm *metrics.InmemSink, id, reason string) int {
+	key := "scale.suppressed_count;policy_id=" + id + ";reason=" + reason
+
+	var count int
+	for _, interval := range inm.Data() {
+		if c, ok := interval.Counters[key]; ok {
+			count += c.Count
+		}
+	}
+	return count
+}
+
+func TestIncrSuppressedCount(t *testing.T) {
+	inm := newTestSink(t)
+
+	reasons := []string{
+		SuppressionReasonCooldown,
+		SuppressionReasonWarmup,
+		SuppressionReasonTargetNotReady,
+		SuppressionReasonNoData,
+		SuppressionReasonBounds,
+		SuppressionReasonNotLeader,
+	}
+
+	for i, reason := range reasons {
+		t.Run(reason, func(t *testing.T) {
+			// Increment each reason a different number of times to verify
+			// the counters are kept separately.
+			for j := 0; j <= i; j++ {
+				IncrSuppressedCount("policy", reason)
+			}
+			assert.Equal(t, i+1, suppressedCount(inm, "policy", reason))
+		})
+	}
+
+	assert.Equal(t, 0, suppressedCount(inm, "other-policy", SuppressionReasonCooldown))
+}
+
+func TestHandler_enforceCooldown_suppressedCount(t *testing.T) {
+	inm := newTestSink(t)
+
+	h := NewHandler("policy", hclog.NewNullLogger(), nil, nil)
+	assert.True(t, h.enforceCooldown(context.Background(), time.Millisecond))
+	assert.Equal(t, 1, suppressedCount(inm, "policy", SuppressionReasonCooldown))
+	assert.Equal(t, 0, suppressedCount(inm, "policy", SuppressionReasonWarmup))
+}
@@ -0,0 +1,311 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// The transforms which can be applied to the metrics of a policy check.
+const (
+	// TransformScale multiplies each value by the factor config value, such
+	// as to convert between units.
+	TransformScale = "scale"
+
+	// TransformOffset adds the value config value to each value.
+	TransformOffset = "offset"
+
+	// TransformClamp limits each value to the min and max config values. At
+	// least one of them must be set.
+	TransformClamp = "clamp"
+
+	// TransformMovingAverage replaces each value with the average of the
+	// trailing number of values set by the points config value, including
+	// itself, smoothing spikes in the metrics.
+	TransformMovingAverage = "moving_average"
+
+	// TransformEWMA replaces each value with the exponentially weighted moving
+	// average of the values up to and including itself, weighting recent
+	// values by the alpha config value. An alpha of 1, the default, passes
+	// the values through unchanged, with lower values smoothing more.
+	TransformEWMA = "ewma"
+
+	// TransformRate replaces each value with its rate of change per the per
+	// config value, one second by default. The rate is the least squares
+	// slope of the values within the trailing window config value, including
+	// itself, or of the value and the previous one without a window. Values
+	// without an earlier value to compare against have a rate of zero.
+	//
+	// Differentiating amplifies noise, so noisy metrics should be smoothed
+	// with a preceding moving_average or ewma transform, or a wider window.
+	// The query window of the check must cover the rate window.
+	TransformRate = "rate"
+)
+
+// transformFunc applies a transform to the metrics in place.
+type transformFunc func(m sdk.TimestampedMetrics)
+
+// ValidateTransform returns an error if the transform is unknown or its
+// config is invalid.
+func ValidateTransform(t *sdk.ScalingPolicyTransform) error {
+	_, err := newTransformFunc(t)
+	return err
+}
+
+// ApplyTransforms applies the transforms to the metrics in order, returning
+// the transformed metrics. The metrics are copied rather than modified, as
+// they may be shared with other checks through the query caches. Without
+// transforms the metrics are returned unchanged.
+func ApplyTransforms(transforms []*sdk.ScalingPolicyTransform, m sdk.TimestampedMetrics) (sdk.TimestampedMetrics, error) {
+	if len(transforms) == 0 {
+		return m, nil
+	}
+
+	out := make(sdk.TimestampedMetrics, len(m))
+	copy(out, m)
+
+	for _, t := range transforms {
+		fn, err := newTransformFunc(t)
+		if err != nil {
+			return nil, err
+		}
+		fn(out)
+	}
+	return out, nil
+}
+
+// ApplyTargetPercentage converts the metrics into the counts the target
+// requires to be at the percentage of each value, rounding fractional counts
+// using the rounding mode, or up if it is unset. The metrics are copied rather
+// than modified, as they may be shared with other checks through the query
+// caches.
+func ApplyTargetPercentage(percentage float64, rounding string, m sdk.TimestampedMetrics) sdk.TimestampedMetrics {
+	out := make(sdk.TimestampedMetrics, len(m))
+	copy(out, m)
+
+	for i := range out {
+		out[i].Value = float64(sdk.RoundCount(out[i].Value*percentage/100, rounding, sdk.RoundingModeCeil))
+	}
+	return out
+}
+
+// newTransformFunc returns the function applying the transform.
+func newTransformFunc(t *sdk.ScalingPolicyTransform) (transformFunc, error) {
+	if t == nil {
+		return nil, fmt.Errorf("transform is nil")
+	}
+
+	switch t.Name {
+	case TransformScale:
+		factor, ok, err := transformFloat(t, "factor")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("transform %s requires factor", t.Name)
+		}
+		return func(m sdk.TimestampedMetrics) {
+			for i := range m {
+				m[i].Value *= factor
+			}
+		}, nil
+
+	case TransformOffset:
+		value, ok, err := transformFloat(t, "value")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("transform %s requires value", t.Name)
+		}
+		return func(m sdk.TimestampedMetrics) {
+			for i := range m {
+				m[i].Value += value
+			}
+		}, nil
+
+	case TransformClamp:
+		min, hasMin, err := transformFloat(t, "min")
+		if err != nil {
+			return nil, err
+		}
+		max, hasMax, err := transformFloat(t, "max")
+		if err != nil {
+			return nil, err
+		}
+		if !hasMin && !hasMax {
+			return nil, fmt.Errorf("transform %s requires min or max", t.Name)
+		}
+		if hasMin && hasMax && min > max {
+			return nil, fmt.Errorf("transform %s min must not be greater than max", t.Name)
+		}
+		return func(m sdk.TimestampedMetrics) {
+			for i := range m {
+				if hasMin && m[i].Value < min {
+					m[i].Value = min
+				}
+				if hasMax && m[i].Value > max {
+					m[i].Value = max
+				}
+			}
+		}, nil
+
+	case TransformMovingAverage:
+		points, ok, err := transformFloat(t, "points")
+		if err != nil {
+			return nil, err
+		}
+		if !ok || points < 1 || points != float64(int(points)) {
+			return nil, fmt.Errorf("transform %s requires points to be a whole number greater than zero", t.Name)
+		}
+		return func(m sdk.TimestampedMetrics) {
+			movingAverage(m, int(points))
+		}, nil
+
+	case TransformEWMA:
+		alpha, ok, err := transformFloat(t, "alpha")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			alpha = 1
+		}
+		if alpha <= 0 || alpha > 1 {
+			return nil, fmt.Errorf("transform %s requires alpha to be greater than zero and at most one", t.Name)
+		}
+		return func(m sdk.TimestampedMetrics) {
+			ewma(m, alpha)
+		}, nil
+
+	case TransformRate:
+		window, _, err := transformDuration(t, "window")
+		if err != nil {
+			return nil, err
+		}
+		if window < 0 {
+			return nil, fmt.Errorf("transform %s window must not be negative", t.Name)
+		}
+		per, ok, err := transformDuration(t, "per")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			per = time.Second
+		}
+		if per <= 0 {
+			return nil, fmt.Errorf("transform %s per must be greater than zero", t.Name)
+		}
+		return func(m sdk.TimestampedMetrics) {
+			rate(m, window, per)
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown transform %q", t.Name)
+	}
+}
+
+// movingAverage replaces each value with the average of the trailing number
+// of points values, including itself. The first values are averaged over the
+// values available.
+func movingAverage(m sdk.TimestampedMetrics, points int) {
+	var sum float64
+	values := make([]float64, len(m))
+
+	for i := range m {
+		values[i] = m[i].Value
+		sum += values[i]
+		if i >= points {
+			sum -= values[i-points]
+		}
+
+		n := i + 1
+		if n > points {
+			n = points
+		}
+		m[i].Value = sum / float64(n)
+	}
+}
+
+// ewma replaces each value with the exponentially weighted moving average of
+// the values up to and including itself. The first value is used as is.
+func ewma(m sdk.TimestampedMetrics, alpha float64) {
+	for i := 1; i < len(m); i++ {
+		m[i].Value = alpha*m[i].Value + (1-alpha)*m[i-1].Value
+	}
+}
+
+// rate replaces each value with the least squares slope, per the per
+// duration, of the values within the trailing window, including itself. A
+// zero window uses the value and the previous one. The slope is zero when the
+// values within the window do not span any time.
+func rate(m sdk.TimestampedMetrics, window, per time.Duration) {
+	values := make([]float64, len(m))
+	for i := range m {
+		values[i] = m[i].Value
+	}
+
+	start := 0
+	for i := range m {
+		if window > 0 {
+			for m[i].Timestamp.Sub(m[start].Timestamp) > window {
+				start++
+			}
+		} else if i > 0 {
+			start = i - 1
+		}
+
+		// Times are relative to the newest value, in units of per, to keep
+		// the sums small.
+		var sumT, sumV float64
+		n := float64(i - start + 1)
+		for j := start; j <= i; j++ {
+			sumT += float64(m[j].Timestamp.Sub(m[i].Timestamp)) / float64(per)
+			sumV += values[j]
+		}
+		meanT, meanV := sumT/n, sumV/n
+
+		var cov, variance float64
+		for j := start; j <= i; j++ {
+			dt := float64(m[j].Timestamp.Sub(m[i].Timestamp))/float64(per) - meanT
+			cov += dt * (values[j] - meanV)
+			variance += dt * dt
+		}
+
+		m[i].Value = 0
+		if variance > 0 {
+			m[i].Value = cov / variance
+		}
+	}
+}
+
+// transformFloat parses the config value of the transform as a float. The
+// returned bool indicates whether the value is set.
+func transformFloat(t *sdk.ScalingPolicyTransform, key string) (float64, bool, error) {
+	raw, ok := t.Config[key]
+	if !ok {
+		return 0, false, nil
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("transform %s %s must be a number, found %q", t.Name, key, raw)
+	}
+	return v, true, nil
+}
+
+// transformDuration parses the config value of the transform as a duration.
+// The returned bool indicates whether the value is set.
+func transformDuration(t *sdk.ScalingPolicyTransform, key string) (time.Duration, bool, error) {
+	raw, ok := t.Config[key]
+	if !ok {
+		return 0, false, nil
+	}
+
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, false, fmt.Errorf("transform %s %s must be a duration, found %q", t.Name, key, raw)
+	}
+	return v, true, nil
+}
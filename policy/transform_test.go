@@ -0,0 +1,315 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTransforms(t *testing.T) {
+	testCases := []struct {
+		inputTransforms []*sdk.ScalingPolicyTransform
+		inputValues     []float64
+		expectedValues  []float64
+		expectError     bool
+		name            string
+	}{
+		{
+			inputTransforms: nil,
+			inputValues:     []float64{1, 2, 3},
+			expectedValues:  []float64{1, 2, 3},
+			name:            "no transforms",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformScale, Config: map[string]string{"factor": "0.001"}},
+			},
+			inputValues:    []float64{1000, 2500},
+			expectedValues: []float64{1, 2.5},
+			name:           "scale",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformOffset, Config: map[string]string{"value": "-5"}},
+			},
+			inputValues:    []float64{10, 20},
+			expectedValues: []float64{5, 15},
+			name:           "offset",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformClamp, Config: map[string]string{"min": "0", "max": "100"}},
+			},
+			inputValues:    []float64{-10, 50, 150},
+			expectedValues: []float64{0, 50, 100},
+			name:           "clamp",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformClamp, Config: map[string]string{"max": "100"}},
+			},
+			inputValues:    []float64{-10, 150},
+			expectedValues: []float64{-10, 100},
+			name:           "clamp max only",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformMovingAverage, Config: map[string]string{"points": "2"}},
+			},
+			inputValues:    []float64{10, 20, 60, 20},
+			expectedValues: []float64{10, 15, 40, 40},
+			name:           "moving average",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformEWMA, Config: map[string]string{"alpha": "0.5"}},
+			},
+			inputValues:    []float64{10, 20, 60, 20},
+			expectedValues: []float64{10, 15, 37.5, 28.75},
+			name:           "ewma",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformEWMA, Config: map[string]string{}},
+			},
+			inputValues:    []float64{10, 20, 60, 20},
+			expectedValues: []float64{10, 20, 60, 20},
+			name:           "ewma default alpha passes through",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformRate, Config: map[string]string{}},
+			},
+			inputValues:    []float64{10, 12, 16, 16},
+			expectedValues: []float64{0, 2, 4, 0},
+			name:           "rate",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformRate, Config: map[string]string{"per": "1m"}},
+			},
+			inputValues:    []float64{10, 12},
+			expectedValues: []float64{0, 120},
+			name:           "rate per minute",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformRate, Config: map[string]string{"window": "2s"}},
+			},
+			inputValues:    []float64{0, 1, 4, 9},
+			expectedValues: []float64{0, 1, 2, 4},
+			name:           "rate window",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformMovingAverage, Config: map[string]string{"points": "2"}},
+				{Name: TransformRate, Config: map[string]string{}},
+			},
+			inputValues:    []float64{10, 20, 10, 20},
+			expectedValues: []float64{0, 5, 0, 0},
+			name:           "rate of smoothed values",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformScale, Config: map[string]string{"factor": "2"}},
+				{Name: TransformOffset, Config: map[string]string{"value": "1"}},
+				{Name: TransformClamp, Config: map[string]string{"max": "6"}},
+			},
+			inputValues:    []float64{1, 2, 3},
+			expectedValues: []float64{3, 5, 6},
+			name:           "transforms applied in order",
+		},
+		{
+			inputTransforms: []*sdk.ScalingPolicyTransform{
+				{Name: TransformScale, Config: map[string]string{}},
+			},
+			inputValues: []float64{1},
+			expectError: true,
+			name:        "invalid transform",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Now()
+
+			var input sdk.TimestampedMetrics
+			for i, v := range tc.inputValues {
+				input = append(input, sdk.TimestampedMetric{Timestamp: now.Add(time.Duration(i) * time.Second), Value: v})
+			}
+
+			actual, err := ApplyTransforms(tc.inputTransforms, input)
+			if tc.expectError {
+				assert.NotNil(t, err, tc.name)
+				return
+			}
+			assert.Nil(t, err, tc.name)
+
+			var values []float64
+			for i, m := range actual {
+				values = append(values, m.Value)
+				assert.Equal(t, input[i].Timestamp, m.Timestamp, tc.name)
+			}
+			assert.InDeltaSlice(t, tc.expectedValues, values, 1e-9, tc.name)
+
+			// The input metrics may be shared, so must not be modified.
+			for i, m := range input {
+				assert.Equal(t, tc.inputValues[i], m.Value, tc.name)
+			}
+		})
+	}
+}
+
+func TestApplyTargetPercentage(t *testing.T) {
+	testCases := []struct {
+		inputPercentage float64
+		inputRounding   string
+		inputValues     []float64
+		expectedValues  []float64
+		name            string
+	}{
+		{
+			inputPercentage: 20,
+			inputValues:     []float64{100, 50},
+			expectedValues:  []float64{20, 10},
+			name:            "whole counts",
+		},
+		{
+			inputPercentage: 20,
+			inputValues:     []float64{12, 0},
+			expectedValues:  []float64{3, 0},
+			name:            "rounded up by default",
+		},
+		{
+			inputPercentage: 20,
+			inputRounding:   sdk.RoundingModeFloor,
+			inputValues:     []float64{12},
+			expectedValues:  []float64{2},
+			name:            "policy rounding mode",
+		},
+		{
+			inputPercentage: 150,
+			inputValues:     []float64{3},
+			expectedValues:  []float64{5},
+			name:            "above one hundred percent",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Now()
+
+			var input sdk.TimestampedMetrics
+			for i, v := range tc.inputValues {
+				input = append(input, sdk.TimestampedMetric{Timestamp: now.Add(time.Duration(i) * time.Second), Value: v})
+			}
+
+			actual := ApplyTargetPercentage(tc.inputPercentage, tc.inputRounding, input)
+
+			var values []float64
+			for i, m := range actual {
+				values = append(values, m.Value)
+				assert.Equal(t, input[i].Timestamp, m.Timestamp, tc.name)
+			}
+			assert.Equal(t, tc.expectedValues, values, tc.name)
+
+			// The input metrics may be shared, so must not be modified.
+			for i, m := range input {
+				assert.Equal(t, tc.inputValues[i], m.Value, tc.name)
+			}
+		})
+	}
+}
+
+func TestValidateTransform(t *testing.T) {
+	testCases := []struct {
+		inputTransform *sdk.ScalingPolicyTransform
+		expectError    bool
+		name           string
+	}{
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformScale, Config: map[string]string{"factor": "2"}},
+			expectError:    false,
+			name:           "valid scale",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformScale, Config: map[string]string{"factor": "double"}},
+			expectError:    true,
+			name:           "non-numeric scale factor",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformOffset, Config: map[string]string{}},
+			expectError:    true,
+			name:           "missing offset value",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformClamp, Config: map[string]string{}},
+			expectError:    true,
+			name:           "clamp without limits",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformClamp, Config: map[string]string{"min": "10", "max": "5"}},
+			expectError:    true,
+			name:           "clamp min greater than max",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformMovingAverage, Config: map[string]string{"points": "1.5"}},
+			expectError:    true,
+			name:           "moving average fractional points",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformMovingAverage, Config: map[string]string{"points": "0"}},
+			expectError:    true,
+			name:           "moving average zero points",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformEWMA, Config: map[string]string{"alpha": "0"}},
+			expectError:    true,
+			name:           "ewma zero alpha",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformEWMA, Config: map[string]string{"alpha": "1.5"}},
+			expectError:    true,
+			name:           "ewma alpha greater than one",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformRate, Config: map[string]string{"window": "1m", "per": "1m"}},
+			expectError:    false,
+			name:           "valid rate",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformRate, Config: map[string]string{"window": "60"}},
+			expectError:    true,
+			name:           "rate window without unit",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformRate, Config: map[string]string{"window": "-1m"}},
+			expectError:    true,
+			name:           "rate negative window",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: TransformRate, Config: map[string]string{"per": "0s"}},
+			expectError:    true,
+			name:           "rate zero per",
+		},
+		{
+			inputTransform: &sdk.ScalingPolicyTransform{Name: "round"},
+			expectError:    true,
+			name:           "unknown transform",
+		},
+		{
+			inputTransform: nil,
+			expectError:    true,
+			name:           "nil transform",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateTransform(tc.inputTransform)
+			assert.Equal(t, tc.expectError, err != nil, tc.name)
+		})
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/armon/go-metrics"
 	hclog "github.com/hashicorp/go-hclog"
+	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad-autoscaler/plugins"
 	"github.com/hashicorp/nomad-autoscaler/plugins/apm"
 	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
@@ -16,34 +20,251 @@ import (
 	"github.com/hashicorp/nomad-autoscaler/plugins/target"
 	"github.com/hashicorp/nomad-autoscaler/policy"
 	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/clock"
 	"github.com/hashicorp/nomad-autoscaler/sdk/helper/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // errTargetNotReady is used by a check handler to indicate the policy target
 // is not ready.
 var errTargetNotReady = errors.New("target not ready")
 
+// errCountUnstable is used by a check handler to indicate the target count
+// changed between the reads required by the policy StabilizeCount option.
+var errCountUnstable = errors.New("target count not stable")
+
+// errDeploymentInProgress is used to indicate the target was not scaled
+// because it reported a deployment in progress and the policy defers scaling
+// during deployments.
+var errDeploymentInProgress = errors.New("deployment in progress")
+
+// errTargetNotAllowed is used to indicate the target was not scaled because
+// the agent target access configuration does not allow it.
+var errTargetNotAllowed = errors.New("target not allowed")
+
+// errScaleHookFailed is used to indicate the target was not scaled because
+// the pre-scale hook failed.
+var errScaleHookFailed = errors.New("pre-scale hook failed")
+
+// errScaleThrottled is used to indicate the target was not scaled because
+// the agent scale throttle has no capacity left.
+var errScaleThrottled = errors.New("scaling action throttled")
+
+// errMaintenanceHold is used to indicate the target was not scaled because
+// the agent maintenance condition says to hold scaling.
+var errMaintenanceHold = errors.New("scaling held by maintenance condition")
+
+// errPolicyConflict is used to indicate the target was not scaled because it
+// is also scaled by a policy from another source, and the conflict is
+// resolved in favour of the other policy.
+var errPolicyConflict = errors.New("target scaled by a policy from another source")
+
+// checkError is returned by handlePolicy when one or more of the policy checks
+// failed to evaluate. The checks which succeeded are still acted upon, so the
+// eval is not retried, but the failure counts towards the failure alerts.
+type checkError struct {
+	errs []string
+}
+
+func (e *checkError) Error() string {
+	return fmt.Sprintf("failed to evaluate policy checks: %s", strings.Join(e.errs, "; "))
+}
+
+// errNotLeader is used to indicate the target was not scaled because the
+// agent is not the leader.
+var errNotLeader = errors.New("agent is not the leader")
+
+// isDeferral returns whether the error returned by scaleTarget, or by one of
+// the functions built upon it, means scaling was deferred rather than failed.
+// A deferred scaling action is attempted again with the next evaluation.
+func isDeferral(err error) bool {
+	switch err {
+	case errTargetNotReady, errNotLeader, errDeploymentInProgress, errTargetNotAllowed,
+		errScaleHookFailed, errScaleThrottled, errMaintenanceHold, errPolicyConflict:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	// MultipleActionsConservative picks the safest action out of all the
+	// actions produced by the checks of a policy, as defined by
+	// sdk.PreemptScalingAction: a scale up over no change over a scale down,
+	// and the highest count within the same direction. This is the default
+	// behaviour.
+	MultipleActionsConservative = "conservative"
+
+	// MultipleActionsLast picks the action produced by the last check, in
+	// the order they are defined within the policy, which returned a result.
+	MultipleActionsLast = "last"
+
+	// MultipleActionsError fails the policy evaluation if more than one check
+	// produces a scaling action and the actions do not agree.
+	MultipleActionsError = "error"
+)
+
+// metricPolicyLabels are the policy label keys which are added to the metrics
+// emitted during a policy evaluation. The list is purposely small so that
+// arbitrary labels do not result in high cardinality metrics.
+var metricPolicyLabels = []string{"team", "service", "env"}
+
+// PolicyDefaults holds the agent level default values applied to policies
+// which leave them unset. A zero value means no default is applied.
+type PolicyDefaults struct {
+	Min int64
+	Max int64
+}
+
+// Leadership reports whether the agent is allowed to scale targets. When
+// several agents run for high availability, only the leader scales.
+type Leadership interface {
+	IsLeader() bool
+}
+
+// pluginDispenser dispenses the plugins used to evaluate policies. It is
+// satisfied by the manager.PluginManager.
+type pluginDispenser interface {
+	Dispense(name, pluginType string) (manager.PluginInstance, error)
+}
+
 // Worker is responsible for executing a policy evaluation request.
 type BaseWorker struct {
 	id            string
 	logger        hclog.Logger
-	pluginManager *manager.PluginManager
+	pluginManager pluginDispenser
 	policyManager *policy.Manager
 	broker        *Broker
+	queryCache    *QueryCache
+	resultCache   *ResultCache
 	queue         string
+
+	// failureTracker is used to alert on repeated evaluation failures of a
+	// policy. It is nil if alerting is disabled.
+	failureTracker *FailureTracker
+
+	// capacityBudget limits the sum of the target counts of all policies. It
+	// is nil if the budget is disabled.
+	capacityBudget *CapacityBudget
+
+	// targetAccess restricts the targets the worker is allowed to scale. It
+	// is nil if all targets are allowed.
+	targetAccess *TargetAccess
+
+	// targetLocks serializes the scaling of each target across policies. It
+	// is nil if scaling is not serialized.
+	targetLocks *TargetLocks
+
+	// scaleHooks runs the commands configured to run before and after
+	// targets are scaled. It is nil if no hooks are configured.
+	scaleHooks *ScaleHooks
+
+	// scaleThrottle limits the rate of scaling actions across all policies.
+	// It is nil if the throttle is disabled.
+	scaleThrottle *ScaleThrottle
+
+	// maintenanceGate defers scaling actions while the external maintenance
+	// condition says to hold. It is nil if no condition is configured.
+	maintenanceGate *MaintenanceGate
+
+	// decisionSink streams the scaling decisions of the policies to external
+	// consumers. It is nil if no sink is configured.
+	decisionSink *DecisionSink
+
+	// errLogs deduplicates the errors logged when policies fail to evaluate,
+	// so a sustained failure does not flood the logs. It is shared by all
+	// workers, as the evals of a policy may be handled by any of them.
+	errLogs *policy.ErrorLogDeduper
+
+	// multipleActions controls how the worker selects the action to execute
+	// when more than one check produces a scaling action.
+	multipleActions string
+
+	// policyDefaults are applied to policies which leave min or max unset.
+	policyDefaults PolicyDefaults
+
+	// leadership is used to check whether the agent should scale targets. It
+	// is nil if leader election is disabled, in which case the agent always
+	// scales.
+	leadership Leadership
+
+	// clock provides the current time and the timers used during the
+	// evaluations, allowing tests to control time.
+	clock clock.Clock
+}
+
+// BaseWorkerConfig holds the dependencies of a BaseWorker, most of which are
+// shared by all the workers of the agent. The optional components are nil
+// when the feature they implement is disabled.
+type BaseWorkerConfig struct {
+	Logger        hclog.Logger
+	PluginManager *manager.PluginManager
+	PolicyManager *policy.Manager
+	Broker        *Broker
+	QueryCache    *QueryCache
+	ResultCache   *ResultCache
+
+	FailureTracker  *FailureTracker
+	CapacityBudget  *CapacityBudget
+	TargetAccess    *TargetAccess
+	TargetLocks     *TargetLocks
+	ScaleHooks      *ScaleHooks
+	ScaleThrottle   *ScaleThrottle
+	MaintenanceGate *MaintenanceGate
+	DecisionSink    *DecisionSink
+	ErrorLogs       *policy.ErrorLogDeduper
+	Leadership      Leadership
+
+	// MultipleActions controls how the action to execute is selected when
+	// more than one check produces a scaling action. It defaults to
+	// MultipleActionsConservative.
+	MultipleActions string
+
+	// PolicyDefaults are applied to policies which leave min or max unset.
+	PolicyDefaults PolicyDefaults
+
+	// Clock provides the current time and timers to the worker. It defaults
+	// to the real clock.
+	Clock clock.Clock
 }
 
-// NewBaseWorker returns a new BaseWorker instance.
-func NewBaseWorker(l hclog.Logger, pm *manager.PluginManager, m *policy.Manager, b *Broker, queue string) *BaseWorker {
+// NewBaseWorker returns a new BaseWorker instance which takes evaluations from
+// the named broker queue.
+func NewBaseWorker(cfg BaseWorkerConfig, queue string) *BaseWorker {
 	id := uuid.Generate()
 
+	multipleActions := cfg.MultipleActions
+	if multipleActions == "" {
+		multipleActions = MultipleActionsConservative
+	}
+
+	clk := cfg.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+
 	return &BaseWorker{
-		id:            id,
-		logger:        l.Named("worker").With("id", id, "queue", queue),
-		pluginManager: pm,
-		policyManager: m,
-		broker:        b,
-		queue:         queue,
+		id:              id,
+		logger:          cfg.Logger.Named("worker").With("id", id, "queue", queue),
+		pluginManager:   cfg.PluginManager,
+		policyManager:   cfg.PolicyManager,
+		broker:          cfg.Broker,
+		queryCache:      cfg.QueryCache,
+		resultCache:     cfg.ResultCache,
+		failureTracker:  cfg.FailureTracker,
+		capacityBudget:  cfg.CapacityBudget,
+		targetAccess:    cfg.TargetAccess,
+		targetLocks:     cfg.TargetLocks,
+		scaleHooks:      cfg.ScaleHooks,
+		scaleThrottle:   cfg.ScaleThrottle,
+		maintenanceGate: cfg.MaintenanceGate,
+		decisionSink:    cfg.DecisionSink,
+		errLogs:         cfg.ErrorLogs,
+		leadership:      cfg.Leadership,
+		queue:           queue,
+		multipleActions: multipleActions,
+		policyDefaults:  cfg.PolicyDefaults,
+		clock:           clk,
 	}
 }
 
@@ -69,140 +290,1132 @@ func (w *BaseWorker) Run(ctx context.Context) {
 			continue
 		}
 
-		logger := w.logger.With(
-			"eval_id", eval.ID,
-			"eval_token", token,
-			"policy_id", eval.Policy.ID)
-
-		if err := w.handlePolicy(ctx, eval); err != nil {
-			logger.Error("failed to evaluate policy", "err", err)
+		w.handleEval(ctx, eval, token)
+	}
+}
 
-			// Notify broker that policy eval was not successful.
-			if err := w.broker.Nack(eval.ID, token); err != nil {
-				logger.Warn("failed to NACK policy evaluation", "err", err)
-			}
-			continue
+// handleEval evaluates the policy of a dequeued eval, records the outcome
+// with the failure tracker and notifies the broker.
+func (w *BaseWorker) handleEval(ctx context.Context, eval *sdk.ScalingEvaluation, token string) {
+	logger := w.logger.With(
+		"eval_id", eval.ID,
+		"eval_token", token,
+		"policy_id", eval.Policy.ID)
+
+	err := w.handlePolicy(ctx, eval)
+
+	var checkErr *checkError
+	switch {
+	case errors.As(err, &checkErr):
+		w.errLogs.Log(logger, hclog.Error, eval.Policy.ID, "failed to evaluate policy", "err", err)
+		w.failureTracker.RecordFailure(eval.Policy, err)
+
+	case err != nil:
+		w.errLogs.Log(logger, hclog.Error, eval.Policy.ID, "failed to evaluate policy", "err", err)
+		w.failureTracker.RecordFailure(eval.Policy, err)
+
+		// Notify broker that policy eval was not successful.
+		if err := w.broker.Nack(eval.ID, token); err != nil {
+			logger.Warn("failed to NACK policy evaluation", "err", err)
 		}
+		return
 
-		// Notify broker that policy eval was successful.
-		if err := w.broker.Ack(eval.ID, token); err != nil {
-			logger.Warn("failed to ACK policy evaluation", "err", err)
-		}
+	default:
+		w.errLogs.Clear(logger, eval.Policy.ID)
+		w.failureTracker.RecordSuccess(eval.Policy)
+	}
+
+	// Notify broker that policy eval was successful.
+	if err := w.broker.Ack(eval.ID, token); err != nil {
+		logger.Warn("failed to ACK policy evaluation", "err", err)
 	}
 }
 
 // HandlePolicy evaluates a policy and execute a scaling action if necessary.
-func (w *BaseWorker) handlePolicy(ctx context.Context, eval *sdk.ScalingEvaluation) error {
+// If any of the policy checks fail to evaluate, a checkError is returned once
+// the remaining checks have been acted upon.
+func (w *BaseWorker) handlePolicy(ctx context.Context, eval *sdk.ScalingEvaluation) (err error) {
 
 	// Record the start time of the eval portion of this function. The labels
 	// are also used across multiple metrics, so define them.
-	evalStartTime := time.Now()
+	evalStartTime := w.clock.Now()
 	labels := []metrics.Label{
 		{Name: "policy_id", Value: eval.Policy.ID},
 		{Name: "target_name", Value: eval.Policy.Target.Name},
 	}
+	labels = append(labels, policyMetricLabels(eval.Policy)...)
+
+	logger := w.logger.With(policyLogArgs(eval.Policy)...)
+
+	// Trace the evaluation, with the plugin calls of the checks as child
+	// spans. This is registered before any other deferred function which
+	// modifies err, so the span records the final outcome.
+	ctx, span := startSpan(ctx, "policy.evaluate",
+		attribute.String("policy_id", eval.Policy.ID),
+		attribute.String("target_name", eval.Policy.Target.Name),
+	)
+	defer func() { endSpan(span, err) }()
+
+	// Detect evaluations which take longer than the policy interval once the
+	// evaluation has finished, regardless of the outcome.
+	defer w.checkOverrun(logger, eval.Policy, evalStartTime, labels)
+
+	// Guard against incomplete policies scaling the target unexpectedly by
+	// applying the agent defaults to omitted min and max values.
+	p, err := w.policyDefaults.Apply(logger, eval.Policy)
+	if err != nil {
+		return err
+	}
 
-	logger := w.logger.With("policy_id", eval.Policy.ID, "target", eval.Policy.Target.Name)
-	checks := make(map[string]*checkHandler)
+	// Resolve the templates within the target config, so a single policy
+	// can be reused across targets.
+	p, err = resolveTargetTemplates(p)
+	if err != nil {
+		return err
+	}
+
+	// Layer the active scheduled minimum, if any, on top of the hard Min so
+	// the bounds and checks use the effective minimum.
+	eval.Policy = applyScheduledMin(logger, p, w.clock.Now())
+
+	// Store the handlers in the same order the checks are defined within the
+	// policy, so that the action selection is deterministic.
+	checks := make([]*checkHandler, 0, len(eval.CheckEvaluations))
 
 	logger.Debug("received policy for evaluation")
 
+	// An active override replaces the policy checks entirely until it
+	// expires. Cooldown is not enforced so that a change to the override is
+	// applied on the next evaluation.
+	if o := w.policyManager.ActiveOverride(eval.Policy.ID); o != nil {
+		logger.Info("policy override is active, skipping policy checks",
+			"count", o.Count, "expiry", o.Expiry)
+
+		_, err := w.applyOverride(ctx, logger, eval.Policy, o, labels)
+		if err != nil && !isDeferral(err) {
+			return fmt.Errorf("failed to apply policy override: %v", err)
+		}
+		return nil
+	}
+
+	// Bring the target within the policy bounds before running the checks if
+	// requested. Strategy driven scaling takes over from the next evaluation.
+	// The reconciliation is only recorded once it succeeds, otherwise it is
+	// requested again with the next evaluation.
+	if eval.ReconcileBounds {
+		scaled, err := w.reconcileBounds(ctx, logger, eval.Policy, labels)
+		switch {
+		case err == nil:
+			w.policyManager.MarkReconciled(eval.Policy.ID)
+			if scaled {
+				w.policyManager.EnforceCooldown(eval.Policy.ID, eval.Policy.Cooldown)
+				return nil
+			}
+		case isDeferral(err):
+			logger.Debug("deferring reconciliation with policy bounds", "reason", err)
+		default:
+			return fmt.Errorf("failed to reconcile target with policy bounds: %v", err)
+		}
+	} else if eval.Policy.OutOfBoundsAction == sdk.OutOfBoundsActionCorrect {
+		// Correct a count outside of the policy bounds with every evaluation
+		// if requested, rather than leaving it to the checks.
+		scaled, err := w.correctBounds(ctx, logger, eval.Policy, labels)
+		switch {
+		case err == nil:
+			if scaled {
+				w.policyManager.EnforceCooldown(eval.Policy.ID, eval.Policy.Cooldown)
+				return nil
+			}
+		case isDeferral(err):
+			logger.Debug("deferring correction of count outside policy bounds", "reason", err)
+		default:
+			return fmt.Errorf("failed to correct target count to policy bounds: %v", err)
+		}
+	}
+
+	// A policy with equal Min and Max pins the target count, so there is
+	// nothing for the checks to decide. The APMs and strategies are not
+	// called, and the target is only scaled if its count has drifted.
+	if eval.Policy.Min == eval.Policy.Max {
+		logger.Info("policy is pinned, skipping policy checks", "count", eval.Policy.Min)
+
+		scaled, err := w.pinTarget(ctx, logger, eval.Policy, labels)
+		switch {
+		case err == nil:
+			if scaled {
+				w.policyManager.EnforceCooldown(eval.Policy.ID, eval.Policy.Cooldown)
+			}
+		case isDeferral(err):
+		default:
+			return fmt.Errorf("failed to scale target to pinned count: %v", err)
+		}
+		return nil
+	}
+
+	// Defer the evaluation while any of the policies this policy depends
+	// upon is scaling, or has recently scaled, as the metrics of the policy
+	// are likely to still reflect the previous capacity of the dependency.
+	if dep := w.policyManager.BusyDependency(eval.Policy.DependsOn, eval.Policy.DependsOnWindow); dep != "" {
+		logger.Info("dependency policy is scaling or recently scaled, deferring evaluation",
+			"dependency_policy_id", dep)
+		policy.IncrSuppressedCount(eval.Policy.ID, policy.SuppressionReasonDependency)
+		return nil
+	}
+
 	handlersCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	// Start check handlers.
 	for _, checkEval := range eval.CheckEvaluations {
-		checkHandler := newCheckHandler(logger, eval.Policy, checkEval, w.pluginManager)
-		checks[checkEval.Check.Name] = checkHandler
+		checkHandler := newCheckHandler(logger, eval.Policy, checkEval, w.pluginManager, w.queryCache, w.resultCache, w.clock)
+		checks = append(checks, checkHandler)
 		go checkHandler.start(handlersCtx)
 	}
 
-	// winningAction is the action to be executed after all checks' results are
-	// reconciled.
-	var winningAction *sdk.ScalingAction
-	var winningHandler *checkHandler
+	// actions and counts hold the action produced by each check and the
+	// target count it was calculated from, in the order of the checks. The
+	// action of a check which failed is nil.
+	actions := make([]*sdk.ScalingAction, len(checks))
+	counts := make([]int64, len(checks))
+	desired := make([]*int64, len(checks))
+
+	// currentCount is the count of the target read by the checks, if any of
+	// them succeeded.
+	var currentCount int64
+	var countRead bool
+
+	// suppressed holds the reasons checks did not produce the action their
+	// strategy wanted. They are only recorded if no other check scales the
+	// target, as otherwise the outcome of the evaluation is not suppressed.
+	suppressed := make(map[string]struct{})
+
+	// Collect the errors of the checks which failed to evaluate. They are
+	// returned however the evaluation ends, unless it fails outright.
+	var checkErrs []string
+	defer func() {
+		if err == nil && len(checkErrs) > 0 {
+			err = &checkError{errs: checkErrs}
+		}
+	}()
 
 	// Initial results should return fairly quickly.
 	// Timeout if it is taking too long.
-	resultsTimeout := time.NewTimer(5 * time.Minute)
+	resultsTimeout := w.clock.NewTimer(5 * time.Minute)
 
 	// Wait for check results and pick the winner.
-	for check, handler := range checks {
+	for i, handler := range checks {
+		// Read the check name from the eval, as the handler replaces its
+		// checkEval while running.
+		check := eval.CheckEvaluations[i].Check.Name
+
 		select {
 		case <-ctx.Done():
 			logger.Info("policy evaluation canceled")
 			return nil
-		case <-resultsTimeout.C:
+		case <-resultsTimeout.C():
 			return fmt.Errorf("timeout while waiting for policy check results")
 		case r := <-handler.results():
 			if r.err != nil {
 				if r.err == errTargetNotReady {
 					logger.Info("target not ready")
+					policy.IncrSuppressedCount(eval.Policy.ID, policy.SuppressionReasonTargetNotReady)
+					return nil
+				}
+				if r.err == errDeploymentInProgress {
+					logger.Info("deployment in progress, deferring")
+					policy.IncrSuppressedCount(eval.Policy.ID, policy.SuppressionReasonDeployment)
+					return nil
+				}
+				if r.err == errCountUnstable {
+					logger.Info("target count not stable, skipping evaluation")
+					policy.IncrSuppressedCount(eval.Policy.ID, policy.SuppressionReasonCountUnstable)
 					return nil
 				}
 
-				// TODO(luiz): properly handle errors.
-				logger.Warn("failed to evaluate check", "error", r.err, "check", check)
+				w.errLogs.Log(logger, hclog.Warn, eval.Policy.ID+"/"+check, "failed to evaluate check", "error", r.err, "check", check)
+				checkErrs = append(checkErrs, fmt.Sprintf("check %s: %v", check, r.err))
 				continue
 			}
+			w.errLogs.Clear(logger, eval.Policy.ID+"/"+check)
 
-			winningAction = sdk.PreemptScalingAction(winningAction, r.action)
-			if winningAction == r.action {
-				winningHandler = handler
+			if r.suppressed != "" {
+				suppressed[r.suppressed] = struct{}{}
 			}
+
+			// Keep the count of the policy within the capacity budget up
+			// to date, even if the evaluation does not scale the target.
+			w.capacityBudget.Record(eval.Policy, r.count)
+			currentCount, countRead = r.count, true
+
+			actions[i], counts[i], desired[i] = r.action, r.count, r.desired
 		}
 	}
 
+	// winningAction is the action to be executed once the results of all
+	// checks are reconciled.
+	var winningAction *sdk.ScalingAction
+	var winningHandler *checkHandler
+	var winningCount int64
+
+	winner, err := reconcileActions(w.multipleActions, eval.Policy, actions)
+	if err != nil {
+		return err
+	}
+	if winner >= 0 {
+		winningAction, winningHandler, winningCount = actions[winner], checks[winner], counts[winner]
+	}
+
+	// Report the gap between the count wanted by the strategy of the winning
+	// check and the count applied once the evaluation ends. The applied count
+	// remains the current count unless the target is scaled, and the gap is
+	// not reported if the evaluation does not decide the count.
+	var gapDesired *int64
+	gapApplied := winningCount
+	if winner >= 0 {
+		gapDesired = desired[winner]
+	}
+	defer func() {
+		if gapDesired != nil {
+			setCountGap(*gapDesired, gapApplied, labels)
+		}
+	}()
+
 	// Stop and drain results timeout timer.
 	if !resultsTimeout.Stop() {
-		<-resultsTimeout.C
+		<-resultsTimeout.C()
 	}
 
 	// At this point the checks have finished. Therefore emit of metric data
 	// tracking how long it takes to run all the checks within a policy.
 	metrics.MeasureSinceWithLabels([]string{"scale", "evaluate_ms"}, evalStartTime, labels)
 
-	if winningHandler == nil || winningAction.Direction == sdk.ScaleDirectionNone {
-		logger.Debug("no checks need to be executed")
-		return nil
+	// Hold the target while its count is outside of the policy bounds if
+	// requested, until it is brought back within them by other means.
+	if eval.Policy.OutOfBoundsAction == sdk.OutOfBoundsActionWait && countRead &&
+		boundsAction(eval.Policy, currentCount) != nil {
+		logger.Warn("target count outside policy bounds, waiting for it to be corrected",
+			"out_of_bounds_action", eval.Policy.OutOfBoundsAction, "count", currentCount,
+			"min", eval.Policy.Min, "max", eval.Policy.Max)
+		policy.IncrSuppressedCount(eval.Policy.ID, policy.SuppressionReasonOutOfBounds)
+		if winningHandler != nil && winningAction.Direction != sdk.ScaleDirectionNone {
+			w.emitDecision(eval.Policy, DecisionOutcomeSuppressed, policy.SuppressionReasonOutOfBounds, winningCount, winningAction)
+		}
+		return nil
+	}
+
+	// Track whether the policy is stable, which lengthens its evaluation
+	// interval if it uses the adaptive interval. Any scaling action resets
+	// it, even if the action ends up suppressed.
+	if winningHandler == nil || winningAction.Direction == sdk.ScaleDirectionNone {
+		if countRead {
+			w.policyManager.RecordStability(eval.Policy.ID, true)
+		}
+	} else {
+		w.policyManager.RecordStability(eval.Policy.ID, false)
+	}
+
+	// Record the count recommended by this evaluation, and only scale down
+	// as far as the highest count recommended within the stabilization
+	// window. An evaluation which does not scale recommends the current
+	// count.
+	if window := eval.Policy.ScaleDownStabilizationWindow; window > 0 && countRead {
+		recommended := currentCount
+		if winningHandler != nil && winningAction.Direction != sdk.ScaleDirectionNone {
+			recommended = winningAction.Count
+		}
+		highest := w.policyManager.RecordRecommendation(eval.Policy.ID, recommended, window)
+
+		if winningHandler != nil && winningAction.Direction == sdk.ScaleDirectionDown {
+			if !stabilizeScaleDown(eval.Policy, winningAction, winningCount, highest) {
+				logger.Info("scale down delayed by the stabilization window",
+					"count", winningCount, "desired_count", recommended, "window", window)
+				policy.IncrSuppressedCount(eval.Policy.ID, policy.SuppressionReasonStabilization)
+				w.emitDecision(eval.Policy, DecisionOutcomeSuppressed, policy.SuppressionReasonStabilization, winningCount, winningAction)
+				return nil
+			}
+			if winningAction.Count != recommended {
+				logger.Info("scale down limited by the stabilization window",
+					"count", winningCount, "desired_count", recommended, "allowed_count", winningAction.Count)
+			}
+		}
+	}
+
+	if winningHandler == nil || winningAction.Direction == sdk.ScaleDirectionNone {
+		logger.Debug("no checks need to be executed")
+		for reason := range suppressed {
+			policy.IncrSuppressedCount(eval.Policy.ID, reason)
+		}
+		if eval.Policy.Advisory && countRead {
+			w.publishAdvice(logger, eval.Policy, currentCount, nil, labels)
+		}
+		return nil
+	}
+
+	logger.Trace(fmt.Sprintf("check %s selected", winningHandler.checkEval.Check.Name),
+		"direction", winningAction.Direction, "count", winningAction.Count)
+
+	span.SetAttributes(
+		attribute.String("check", winningHandler.checkEval.Check.Name),
+		attribute.String("direction", winningAction.Direction.String()),
+		attribute.Int64("new_count", winningAction.Count),
+	)
+
+	// Advisory policies publish the recommended action instead of scaling
+	// the target. The remaining guards only protect the target from being
+	// scaled, so they do not apply.
+	if eval.Policy.Advisory {
+		gapApplied = winningAction.Count
+		w.publishAdvice(logger, eval.Policy, winningCount, winningAction, labels)
+		w.emitDecision(eval.Policy, DecisionOutcomeAdvisory, "", winningCount, winningAction)
+		return nil
+	}
+
+	// Apply the guards protecting the target, followed by those limiting how
+	// often the checks change the count. A follower stops here having
+	// evaluated the policy, and the count is not applied by a guard, so the
+	// gap is left to the leader to report.
+	guards := append(w.targetGuards(ctx, eval.Policy), w.checkGuards(eval.Policy, winningAction)...)
+	if g := w.applyGuards(logger, eval.Policy, winningCount, winningAction, guards...); g != nil {
+		if g.reason == policy.SuppressionReasonNotLeader {
+			gapDesired = nil
+		}
+		return nil
+	}
+
+	// Keep scale ups within the capacity budget, if configured. The action
+	// is shared with the winning handler, so a reduced count is used when it
+	// scales the target.
+	if allowed := w.capacityBudget.Reserve(eval.Policy, winningCount, winningAction.Count); allowed != winningAction.Count {
+		if allowed == winningCount {
+			logger.Info("scale up denied by the capacity budget",
+				"count", winningCount, "desired_count", winningAction.Count)
+			policy.IncrSuppressedCount(eval.Policy.ID, policy.SuppressionReasonCapacityBudget)
+			w.emitDecision(eval.Policy, DecisionOutcomeSuppressed, policy.SuppressionReasonCapacityBudget, winningCount, winningAction)
+			return nil
+		}
+
+		logger.Info("scale up reduced by the capacity budget",
+			"count", winningCount, "desired_count", winningAction.Count, "allowed_count", allowed)
+		winningAction.Count = allowed
+	}
+
+	// Serialize scaling of the target with other policies targeting the same
+	// resource. The count read by the checks is outdated if another policy
+	// scaled the target since, so the action is deferred to the next
+	// evaluation rather than overwriting the other policy's update.
+	lease, err := w.targetLocks.Acquire(ctx, eval.Policy)
+	if err != nil {
+		logger.Info("policy evaluation canceled while waiting to scale target")
+		w.capacityBudget.Record(eval.Policy, winningCount)
+		return nil
+	}
+	var scaled bool
+	defer func() { lease.Release(scaled) }()
+
+	// Mark the policy as scaling while it holds the target, so the policies
+	// which depend upon it defer their evaluation.
+	w.policyManager.BeginAction(eval.Policy.ID)
+	defer func() { w.policyManager.EndAction(eval.Policy.ID, scaled) }()
+
+	if other := lease.ScaledByOther(evalStartTime); other != "" {
+		logger.Warn("target was scaled by another policy during the evaluation, deferring to the next evaluation",
+			"other_policy_id", other)
+		w.capacityBudget.Record(eval.Policy, winningCount)
+		policy.IncrSuppressedCount(eval.Policy.ID, policy.SuppressionReasonTargetConflict)
+		w.emitDecision(eval.Policy, DecisionOutcomeSuppressed, policy.SuppressionReasonTargetConflict, winningCount, winningAction)
+		return nil
+	}
+	if other := lease.ScaledByOther(time.Time{}); other != "" {
+		logger.Warn("target is also scaled by another policy, policies targeting the same resource may conflict",
+			"other_policy_id", other)
+	}
+
+	// Defer the scaling action to the next evaluation if the agent has
+	// performed too many recently.
+	if w.applyGuards(logger, eval.Policy, winningCount, winningAction, w.throttleGuard(false)) != nil {
+		w.capacityBudget.Record(eval.Policy, winningCount)
+		return nil
+	}
+
+	// The pre-scale hook can veto the scaling action. The action is copied,
+	// as the winning handler modifies it once unblocked.
+	hookAction := *winningAction
+	if err := w.scaleHooks.Pre(ctx, eval.Policy, winningCount, hookAction); err != nil {
+		logger.Warn("pre-scale hook failed, skipping scaling",
+			"direction", winningAction.Direction, "count", winningAction.Count, "error", err)
+		w.capacityBudget.Record(eval.Policy, winningCount)
+		policy.IncrSuppressedCount(eval.Policy.ID, policy.SuppressionReasonScaleHook)
+		w.emitDecision(eval.Policy, DecisionOutcomeSuppressed, policy.SuppressionReasonScaleHook, winningCount, &hookAction)
+		return nil
+	}
+
+	// Unblock winning handler and cancel the others. The proceedCh is
+	// buffered so the decision is not lost if a handler has not started
+	// waiting for it yet, and the default guards against ever blocking here.
+	for _, handler := range checks {
+		select {
+		case handler.proceedCh <- handler == winningHandler:
+		default:
+		}
+	}
+
+	// Measure how long it takes to invoke the scaling actions. This helps
+	// understand the time taken to interact with the remote target and action
+	// the scaling action.
+	defer metrics.MeasureSinceWithLabels([]string{"scale", "invoke_ms"}, time.Now(), labels)
+
+	// Block until winning handler returns.
+	select {
+	case <-ctx.Done():
+		gapDesired = nil
+		logger.Info("policy evaluation canceled")
+		return nil
+	case r := <-winningHandler.results():
+		if r.err != nil {
+			// Release the capacity reserved for the failed scaling action.
+			w.capacityBudget.Record(eval.Policy, winningCount)
+			w.emitDecision(eval.Policy, DecisionOutcomeFailed, r.err.Error(), winningCount, &hookAction)
+			return r.err
+		}
+		if r.action == nil {
+			return nil
+		}
+		scaled = true
+		gapApplied = hookAction.Count
+		w.emitDecision(eval.Policy, DecisionOutcomeScaled, "", winningCount, &hookAction)
+
+		w.startVerifyScale(ctx, logger, eval.Policy, winningCount, r.action, labels)
+		w.policyManager.RecordScale(eval.Policy.ID, r.action.Direction)
+		w.scaleShadow(ctx, logger, eval.Policy, hookAction)
+
+		if err := w.scaleHooks.Post(ctx, eval.Policy, winningCount, hookAction); err != nil {
+			logger.Warn("post-scale hook failed", "error", err)
+		}
+	}
+
+	// Enforce the cooldown after a successful scaling event.
+	w.policyManager.EnforceCooldown(eval.Policy.ID, eval.Policy.Cooldown)
+
+	logger.Info("policy evaluation complete")
+	return nil
+}
+
+// Apply returns the policy with the default min and max values applied if the
+// policy omits them. A value explicitly set to zero is kept, so policies can
+// still scale to zero. The policy is copied rather than modified, as it is
+// shared with the policy handler.
+func (d PolicyDefaults) Apply(logger hclog.Logger, p *sdk.ScalingPolicy) (*sdk.ScalingPolicy, error) {
+	applyMin := p.MinOmitted && d.Min != 0
+
+	if !p.MaxOmitted && !applyMin {
+		return p, nil
+	}
+
+	out := *p
+
+	if p.MaxOmitted {
+		if d.Max == 0 {
+			return nil, errors.New("policy max not set and no agent default max configured")
+		}
+		logger.Debug("policy max not set, using agent default", "max", d.Max)
+		out.Max = d.Max
+
+		if out.Min > out.Max {
+			return nil, fmt.Errorf("policy min %d is greater than the agent default max %d", out.Min, out.Max)
+		}
+	}
+
+	// Do not apply a default min which would conflict with the policy max.
+	if applyMin && d.Min <= out.Max {
+		logger.Debug("policy min not set, using agent default", "min", d.Min)
+		out.Min = d.Min
+	}
+
+	return &out, nil
+}
+
+// applyScheduledMin returns the policy with Min raised to the highest of its
+// scheduled minimums which are active at now. The bounds resolve in order of
+// precedence:
+//  1. Max is the hard ceiling, and caps an active scheduled minimum.
+//  2. An active scheduled minimum raises the floor above Min.
+//  3. Min is the hard floor, which a lower scheduled minimum does not lower.
+//
+// The policy is copied rather than modified, as it is shared with the policy
+// handler.
+func applyScheduledMin(logger hclog.Logger, p *sdk.ScalingPolicy, now time.Time) *sdk.ScalingPolicy {
+	min, name := p.Min, ""
+	for _, s := range p.ScheduledMins {
+		active, err := policy.ScheduledMinActive(s, now)
+		if err != nil {
+			logger.Warn("failed to evaluate scheduled min, ignoring it", "scheduled_min", s.Name, "error", err)
+			continue
+		}
+		if active && s.Min > min {
+			min, name = s.Min, s.Name
+		}
+	}
+	if name == "" {
+		return p
+	}
+
+	if min > p.Max {
+		logger.Warn("scheduled min is greater than the policy max, using max",
+			"scheduled_min", name, "min", min, "max", p.Max)
+		min = p.Max
+	}
+	logger.Debug("scheduled min is active", "scheduled_min", name, "min", min)
+
+	out := *p
+	out.Min = min
+	return &out
+}
+
+// startVerifyScale verifies the target reaches the count of the successful
+// scaling action in the background, if the policy opts in. The previous count
+// is the count of the target before the action. Dry-run actions do not change
+// the target count so are not verified.
+func (w *BaseWorker) startVerifyScale(ctx context.Context, logger hclog.Logger, p *sdk.ScalingPolicy, previous int64, action *sdk.ScalingAction, labels []metrics.Label) {
+	if p.VerifyScaleAfter <= 0 || action.Count == sdk.StrategyActionMetaValueDryRunCount {
+		return
+	}
+	go w.verifyScale(ctx, logger, p, previous, action, labels)
+}
+
+// verifyScale reads the target count once the policy VerifyScaleAfter duration
+// has passed and records whether the target reached the count requested by a
+// successful scaling action. This detects targets which accept a scaling
+// request but fail to apply it. If the policy opts in, a target which did not
+// converge is rolled back to the previous count, unless the action was itself
+// a rollback.
+func (w *BaseWorker) verifyScale(ctx context.Context, logger hclog.Logger, p *sdk.ScalingPolicy, previous int64, action *sdk.ScalingAction, labels []metrics.Label) {
+	count := action.Count
+
+	timer := w.clock.NewTimer(p.VerifyScaleAfter)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C():
+	}
+
+	targetPlugin, err := w.pluginManager.Dispense(p.Target.Name, plugins.PluginTypeTarget)
+	if err != nil {
+		logger.Warn("failed to verify scaling action", "error", err)
+		return
+	}
+
+	status, err := targetPlugin.Plugin().(target.Target).Status(p.Target.Config)
+	if err != nil {
+		logger.Warn("failed to verify scaling action", "error", err)
+		return
+	}
+	if err := validateTargetStatus(status); err != nil {
+		logger.Warn("failed to verify scaling action", "error", err)
+		return
+	}
+
+	// The distribution reported once the target converged shows how the
+	// new count was spread by the target.
+	logger = withDistribution(logger, status)
+
+	if status.Count == count {
+		logger.Debug("scaling action verified", "count", count)
+		metrics.IncrCounterWithLabels([]string{"scale", "verify", "converged_count"}, 1, labels)
+		return
+	}
+
+	logger.Warn("scale did not converge", "desired_count", count,
+		"current_count", status.Count, "verify_scale_after", p.VerifyScaleAfter)
+	metrics.IncrCounterWithLabels([]string{"scale", "verify", "not_converged_count"}, 1, labels)
+
+	if p.RollbackOnVerifyFailure && action.ReasonCode != sdk.ReasonCodeRollback {
+		w.rollbackScale(ctx, logger, p, previous, count, labels)
+	}
+}
+
+// withDistribution returns the logger with the distribution of the target
+// count, if the target status reports one.
+func withDistribution(logger hclog.Logger, status *sdk.TargetStatus) hclog.Logger {
+	if d := status.Meta[sdk.TargetStatusMetaKeyDistribution]; d != "" {
+		return logger.With("distribution", d)
+	}
+	return logger
+}
+
+// rollbackScale scales the target back to the previous count it had before a
+// scaling action to the desired count which it did not converge to. The
+// rollback is skipped if the previous count is outside the policy bounds, as
+// is the case before the target is brought within them. Cooldown is enforced
+// after a rollback, so the policy checks do not immediately retry the action.
+func (w *BaseWorker) rollbackScale(ctx context.Context, logger hclog.Logger, p *sdk.ScalingPolicy, previous, desired int64, labels []metrics.Label) {
+	logger = logger.With("reason", "rollback")
+
+	if previous < p.Min || previous > p.Max {
+		logger.Warn("previous count is outside the policy bounds, not rolling back",
+			"previous_count", previous, "min", p.Min, "max", p.Max)
+		return
+	}
+
+	logger.Warn("rolling back scaling action which did not converge",
+		"previous_count", previous, "desired_count", desired)
+
+	scaled, err := w.scaleTarget(ctx, logger, p, labels, func(count int64) *sdk.ScalingAction {
+		return rollbackAction(previous, desired, count)
+	})
+	if err != nil {
+		logger.Error("failed to roll back scaling action", "error", err)
+		metrics.IncrCounterWithLabels([]string{"scale", "rollback", "error_count"}, 1, labels)
+		return
+	}
+
+	if scaled {
+		metrics.IncrCounterWithLabels([]string{"scale", "rollback", "success_count"}, 1, labels)
+		w.policyManager.EnforceCooldown(p.ID, p.Cooldown)
+	}
+}
+
+// checkOverrun emits a warning and a metric if the policy evaluation which
+// started at startTime took longer than the policy evaluation interval. Evals
+// generated while the evaluation was running are coalesced by the broker, so
+// the missed intervals are skipped rather than queued.
+func (w *BaseWorker) checkOverrun(logger hclog.Logger, p *sdk.ScalingPolicy, startTime time.Time, labels []metrics.Label) {
+	duration := w.clock.Since(startTime)
+	if !isOverrun(p.EvaluationInterval, duration) {
+		return
+	}
+
+	logger.Warn("policy evaluation took longer than its evaluation interval, consider increasing evaluation_interval",
+		"duration", duration, "evaluation_interval", p.EvaluationInterval)
+	metrics.IncrCounterWithLabels([]string{"scale", "evaluate", "overrun_count"}, 1, labels)
+}
+
+// isOverrun returns whether an evaluation duration exceeds the interval. An
+// unset interval is never overrun.
+func isOverrun(interval, duration time.Duration) bool {
+	return interval > 0 && duration > interval
+}
+
+// reconcileBounds scales the policy target to the nearest of the policy Min
+// and Max bounds if its current count is outside of them. The returned bool
+// indicates whether a scaling action was submitted to the target.
+func (w *BaseWorker) reconcileBounds(ctx context.Context, logger hclog.Logger, p *sdk.ScalingPolicy, labels []metrics.Label) (bool, error) {
+	logger = logger.With("reason", "reconcile_bounds")
+	return w.scaleTarget(ctx, logger, p, labels, func(count int64) *sdk.ScalingAction {
+		action := boundsAction(p, count)
+		if action != nil {
+			action.ReasonCode = sdk.ReasonCodeReconcileBounds
+		}
+		return action
+	})
+}
+
+// correctBounds scales the policy target to the nearest of the policy Min and
+// Max bounds if its current count is outside of them, for policies which use
+// OutOfBoundsActionCorrect. The returned bool indicates whether a scaling
+// action was submitted to the target.
+func (w *BaseWorker) correctBounds(ctx context.Context, logger hclog.Logger, p *sdk.ScalingPolicy, labels []metrics.Label) (bool, error) {
+	logger = logger.With("reason", "out_of_bounds")
+	return w.scaleTarget(ctx, logger, p, labels, func(count int64) *sdk.ScalingAction {
+		action := boundsAction(p, count)
+		if action != nil {
+			logger.Info("target count outside policy bounds, correcting to the nearest bound",
+				"out_of_bounds_action", p.OutOfBoundsAction, "count", count, "min", p.Min, "max", p.Max)
+		}
+		return action
+	})
+}
+
+// applyOverride scales the target of the policy to the override count. It
+// returns whether the target was scaled.
+func (w *BaseWorker) applyOverride(ctx context.Context, logger hclog.Logger, p *sdk.ScalingPolicy, o *policy.Override, labels []metrics.Label) (bool, error) {
+	logger = logger.With("reason", "override")
+	return w.scaleTarget(ctx, logger, p, labels, func(count int64) *sdk.ScalingAction {
+		return overrideAction(o, count)
+	})
+}
+
+// pinTarget scales the target of a policy with equal Min and Max to the
+// pinned count. It returns whether the target was scaled.
+func (w *BaseWorker) pinTarget(ctx context.Context, logger hclog.Logger, p *sdk.ScalingPolicy, labels []metrics.Label) (bool, error) {
+	logger = logger.With("reason", "pinned")
+	return w.scaleTarget(ctx, logger, p, labels, func(count int64) *sdk.ScalingAction {
+		return pinnedAction(p, count)
+	})
+}
+
+// scaleTarget reads the current count of the policy target and scales it
+// using the action returned by actionFn, bypassing the policy checks. No
+// scaling happens if actionFn returns nil, and the action of an advisory
+// policy is only published. It returns whether the target was
+// scaled, and errTargetNotReady, errDeploymentInProgress, errNotLeader,
+// errTargetNotAllowed, errPolicyConflict, errMaintenanceHold,
+// errScaleThrottled or errScaleHookFailed if scaling was not possible.
+// Successful scaling actions are verified in the same way as those of the
+// policy checks. The target lock is held from reading the current count until
+// the target is scaled, so the count cannot be outdated by another policy.
+func (w *BaseWorker) scaleTarget(ctx context.Context, logger hclog.Logger, p *sdk.ScalingPolicy, labels []metrics.Label, actionFn func(count int64) *sdk.ScalingAction) (scaled bool, err error) {
+	targetPlugin, err := w.pluginManager.Dispense(p.Target.Name, plugins.PluginTypeTarget)
+	if err != nil {
+		return false, fmt.Errorf(`target plugin "%s" not initialized: %v`, p.Target.Name, err)
+	}
+	targetInst := targetPlugin.Plugin().(target.Target)
+
+	lease, err := w.targetLocks.Acquire(ctx, p)
+	if err != nil {
+		return false, err
+	}
+	defer func() { lease.Release(scaled) }()
+
+	w.policyManager.BeginAction(p.ID)
+	defer func() { w.policyManager.EndAction(p.ID, scaled) }()
+
+	status, err := targetInst.Status(p.Target.Config)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch current count: %v", err)
+	}
+	if status == nil || !status.Ready {
+		logger.Debug("target not ready, skipping scaling")
+		policy.IncrSuppressedCount(p.ID, policy.SuppressionReasonTargetNotReady)
+		return false, errTargetNotReady
+	}
+	if err := validateTargetStatus(status); err != nil {
+		return false, fmt.Errorf("failed to fetch current count: %v", err)
+	}
+	if deferForDeployment(p, status) {
+		logger.Info("deployment in progress, deferring")
+		policy.IncrSuppressedCount(p.ID, policy.SuppressionReasonDeployment)
+		return false, errDeploymentInProgress
+	}
+
+	action := actionFn(status.Count)
+	if action == nil {
+		logger.Debug("target already at desired count", "count", status.Count)
+		return false, nil
+	}
+
+	if p.Advisory {
+		w.publishAdvice(logger, p, status.Count, action, labels)
+		w.emitDecision(p, DecisionOutcomeAdvisory, "", status.Count, action)
+		return false, nil
+	}
+
+	guards := append(w.targetGuards(ctx, p), w.throttleGuard(false))
+	if g := w.applyGuards(logger, p, status.Count, action, guards...); g != nil {
+		return false, g.err
+	}
+
+	hookAction := *action
+	if err := w.scaleHooks.Pre(ctx, p, status.Count, hookAction); err != nil {
+		logger.Warn("pre-scale hook failed, skipping scaling",
+			"direction", action.Direction, "count", action.Count, "error", err)
+		policy.IncrSuppressedCount(p.ID, policy.SuppressionReasonScaleHook)
+		w.emitDecision(p, DecisionOutcomeSuppressed, policy.SuppressionReasonScaleHook, status.Count, action)
+		return false, errScaleHookFailed
+	}
+
+	action.SetReasonCodeMeta()
+	action.SetPolicyRevisionMeta(p.Revision)
+	action.SetCurrentCountMeta(status.Count)
+
+	if val, ok := p.Target.Config["dry-run"]; ok && val == "true" {
+		logger.Info("scaling dry-run is enabled, using no-op task group count")
+		action.SetDryRun()
+	}
+
+	withDistribution(logger, status).Info("scaling target",
+		"from", status.Count, "to", action.Count, "reason", action.Reason, "reason_code", action.ReasonCode)
+
+	if err := targetInst.Scale(*action, p.Target.Config); err != nil {
+		metrics.IncrCounter([]string{"scale", "invoke", "error_count"}, 1)
+		w.emitDecision(p, DecisionOutcomeFailed, err.Error(), status.Count, &hookAction)
+		return false, err
+	}
+	metrics.IncrCounter([]string{"scale", "invoke", "success_count"}, 1)
+	incrScaleActionCount(p, action)
+	w.emitDecision(p, DecisionOutcomeScaled, "", status.Count, &hookAction)
+
+	if err := w.scaleHooks.Post(ctx, p, status.Count, hookAction); err != nil {
+		logger.Warn("post-scale hook failed", "error", err)
+	}
+
+	w.startVerifyScale(ctx, logger, p, status.Count, action, labels)
+	w.scaleShadow(ctx, logger, p, hookAction)
+	return true, nil
+}
+
+// scaleShadow applies the scaling action which was applied to the policy
+// target to its shadow target, if it has one. The action is registered as a
+// dry-run if the shadow target config enables it. Failures are logged and
+// counted, but not returned, as they must not affect the policy target.
+func (w *BaseWorker) scaleShadow(ctx context.Context, logger hclog.Logger, p *sdk.ScalingPolicy, action sdk.ScalingAction) {
+	if p.ShadowTarget == nil {
+		return
+	}
+
+	logger = logger.With("shadow_target", p.ShadowTarget.Name)
+	labels := []metrics.Label{{Name: "plugin_name", Value: p.ShadowTarget.Name}, {Name: "policy_id", Value: p.ID}}
+
+	targetPlugin, err := w.pluginManager.Dispense(p.ShadowTarget.Name, plugins.PluginTypeTarget)
+	if err != nil {
+		logger.Warn("shadow target plugin not initialized", "error", err)
+		metrics.IncrCounterWithLabels([]string{"scale", "shadow", "error_count"}, 1, labels)
+		return
+	}
+	targetInst := targetPlugin.Plugin().(target.Target)
+
+	// The action shares its meta with the action applied to the policy
+	// target, so copy it before it is modified.
+	meta := make(map[string]interface{}, len(action.Meta)+3)
+	for k, v := range action.Meta {
+		meta[k] = v
+	}
+	action.Meta = meta
+	action.SetReasonCodeMeta()
+	action.SetPolicyRevisionMeta(p.Revision)
+
+	if val, ok := p.ShadowTarget.Config["dry-run"]; ok && val == "true" {
+		action.SetDryRun()
+	}
+
+	_, span := startSpan(ctx, "shadow_target.scale",
+		attribute.String("policy_id", p.ID),
+		attribute.String("plugin_name", p.ShadowTarget.Name),
+		attribute.String("direction", action.Direction.String()),
+		attribute.Int64("new_count", action.Count),
+	)
+	err = targetInst.Scale(action, p.ShadowTarget.Config)
+	endSpan(span, err)
+
+	if err != nil {
+		logger.Warn("failed to scale shadow target", "count", action.Count, "error", err)
+		metrics.IncrCounterWithLabels([]string{"scale", "shadow", "error_count"}, 1, labels)
+		return
+	}
+
+	logger.Info("scaled shadow target", "count", action.Count)
+	metrics.IncrCounterWithLabels([]string{"scale", "shadow", "success_count"}, 1, labels)
+}
+
+// publishAdvice publishes the action recommended by an advisory policy for
+// its target at count through the logs, metrics and API of the agent, in
+// place of scaling the target. A nil action recommends keeping the count.
+func (w *BaseWorker) publishAdvice(logger hclog.Logger, p *sdk.ScalingPolicy, count int64, action *sdk.ScalingAction, labels []metrics.Label) {
+	advice := policy.Advice{
+		Count:        count,
+		CurrentCount: count,
+		Direction:    sdk.ScaleDirection(sdk.ScaleDirectionNone).String(),
+		Time:         w.clock.Now(),
+	}
+
+	if action != nil {
+		advice.Count = action.Count
+		advice.Direction = action.Direction.String()
+		advice.Reason = action.Reason
+		advice.ReasonCode = action.ReasonCode
+		if advice.ReasonCode == "" {
+			advice.ReasonCode = sdk.ReasonCodeStrategy
+		}
+
+		logger.Info("advisory policy recommends scaling target",
+			"from", count, "to", advice.Count, "reason", advice.Reason, "reason_code", advice.ReasonCode)
+
+		actionLabels := []metrics.Label{
+			{Name: "policy_id", Value: p.ID},
+			{Name: "direction", Value: advice.Direction},
+			{Name: "reason_code", Value: string(advice.ReasonCode)},
+			{Name: "advisory", Value: "true"},
+		}
+		metrics.IncrCounterWithLabels([]string{"scale", "recommendation", "action_count"}, 1, actionLabels)
+	}
+
+	w.policyManager.RecordAdvice(p.ID, advice)
+
+	// Copy the labels, as they are shared with the other metrics of the
+	// evaluation.
+	gaugeLabels := append(append([]metrics.Label{}, labels...), metrics.Label{Name: "advisory", Value: "true"})
+	metrics.SetGaugeWithLabels([]string{"scale", "recommendation", "count"}, float32(advice.Count), gaugeLabels)
+}
+
+// setCountGap sets the gauge tracking the difference between the count desired
+// by the strategy of a policy and the count applied by its evaluation. A
+// persistent gap indicates the policy limits and guards prevent the target
+// from reaching the count the strategy wants.
+func setCountGap(desired, applied int64, labels []metrics.Label) {
+	metrics.SetGaugeWithLabels([]string{"scale", "desired_count_gap"}, float32(desired-applied), labels)
+}
+
+// emitDecision publishes the decision on the action scaling the policy target
+// from count to the decision sink, if one is configured.
+func (w *BaseWorker) emitDecision(p *sdk.ScalingPolicy, outcome, reason string, count int64, action *sdk.ScalingAction) {
+	if w.decisionSink == nil {
+		return
+	}
+	w.decisionSink.Emit(NewDecisionEvent(p, outcome, reason, count, *action))
+}
+
+// isLeader returns whether the worker is allowed to scale targets.
+func (w *BaseWorker) isLeader() bool {
+	return w.leadership == nil || w.leadership.IsLeader()
+}
+
+// boundsAction returns the scaling action required to bring the count within
+// the policy Min and Max bounds. A nil action is returned if the count is
+// already within the bounds.
+func boundsAction(p *sdk.ScalingPolicy, count int64) *sdk.ScalingAction {
+	var action *sdk.ScalingAction
+
+	if count < p.Min {
+		action = &sdk.ScalingAction{
+			Count:      p.Min,
+			Direction:  sdk.ScaleDirectionUp,
+			Reason:     fmt.Sprintf("current count (%d) below limit (%d)", count, p.Min),
+			ReasonCode: sdk.ReasonCodeBounds,
+		}
+	} else if count > p.Max {
+		action = &sdk.ScalingAction{
+			Count:      p.Max,
+			Direction:  sdk.ScaleDirectionDown,
+			Reason:     fmt.Sprintf("current count (%d) above limit (%d)", count, p.Max),
+			ReasonCode: sdk.ReasonCodeBounds,
+		}
+	}
+
+	if action != nil {
+		action.Canonicalize()
+	}
+	return action
+}
+
+// reversesDirection returns whether the count of the action moves the target
+// from count in the opposite direction to that of the action.
+func reversesDirection(a *sdk.ScalingAction, count int64) bool {
+	switch a.Direction {
+	case sdk.ScaleDirectionUp:
+		return a.Count < count
+	case sdk.ScaleDirectionDown:
+		return a.Count > count
+	default:
+		return false
+	}
+}
+
+// overrideAction returns the scaling action required to bring the count to the
+// override count. A nil action is returned if the count already matches.
+func overrideAction(o *policy.Override, count int64) *sdk.ScalingAction {
+	if count == o.Count {
+		return nil
+	}
+
+	action := &sdk.ScalingAction{
+		Count:      o.Count,
+		Direction:  sdk.ScaleDirectionUp,
+		Reason:     fmt.Sprintf("policy override active until %s", o.Expiry.Format(time.RFC3339)),
+		ReasonCode: sdk.ReasonCodeOverride,
+	}
+	if o.Count < count {
+		action.Direction = sdk.ScaleDirectionDown
+	}
+
+	action.Canonicalize()
+	return action
+}
+
+// rollbackAction returns the scaling action required to bring the count back
+// to the previous count, after the target did not converge to the desired
+// count. A nil action is returned if the count already matches.
+func rollbackAction(previous, desired, count int64) *sdk.ScalingAction {
+	if count == previous {
+		return nil
+	}
+
+	action := &sdk.ScalingAction{
+		Count:      previous,
+		Direction:  sdk.ScaleDirectionUp,
+		Reason:     fmt.Sprintf("rolling back to count %d as the target did not converge to count %d", previous, desired),
+		ReasonCode: sdk.ReasonCodeRollback,
+	}
+	if previous < count {
+		action.Direction = sdk.ScaleDirectionDown
+	}
+
+	action.Canonicalize()
+	return action
+}
+
+// pinnedAction returns the scaling action required to bring the count to the
+// count pinned by the equal policy Min and Max. A nil action is returned if the
+// count already matches.
+func pinnedAction(p *sdk.ScalingPolicy, count int64) *sdk.ScalingAction {
+	if count == p.Min {
+		return nil
+	}
+
+	action := &sdk.ScalingAction{
+		Count:      p.Min,
+		Direction:  sdk.ScaleDirectionUp,
+		Reason:     fmt.Sprintf("current count (%d) drifted from pinned count (%d)", count, p.Min),
+		ReasonCode: sdk.ReasonCodePinned,
+	}
+	if p.Min < count {
+		action.Direction = sdk.ScaleDirectionDown
+	}
+
+	action.Canonicalize()
+	return action
+}
+
+// incrScaleActionCount increments the counter tracking the scaling actions
+// successfully submitted to the target of the policy, labelled with the
+// direction and reason code of the action, so actions can be counted by
+// cause.
+func incrScaleActionCount(p *sdk.ScalingPolicy, a *sdk.ScalingAction) {
+	labels := []metrics.Label{
+		{Name: "policy_id", Value: p.ID},
+		{Name: "direction", Value: a.Direction.String()},
+		{Name: "reason_code", Value: string(a.ReasonCode)},
+	}
+	if p.Revision != "" {
+		labels = append(labels, metrics.Label{Name: "policy_revision", Value: p.Revision})
+	}
+	metrics.IncrCounterWithLabels([]string{"scale", "action_count"}, 1, labels)
+}
+
+// policyLogArgs returns the key/value pairs used to build the logger context
+// for a policy evaluation. The policy revision is included when known, along
+// with the policy labels, sorted by key so the output is consistent. Label
+// keys are prefixed with "label." so they cannot duplicate the keys logged
+// by the worker, such as policy_id or target.
+func policyLogArgs(p *sdk.ScalingPolicy) []interface{} {
+	args := []interface{}{"policy_id", p.ID, "target", p.Target.Name}
+	if p.Revision != "" {
+		args = append(args, "policy_revision", p.Revision)
 	}
 
-	logger.Trace(fmt.Sprintf("check %s selected", winningHandler.checkEval.Check.Name),
-		"direction", winningAction.Direction, "count", winningAction.Count)
+	keys := make([]string, 0, len(p.Labels))
+	for k := range p.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-	// Unblock winning handler and cancel the others. The default guards
-	// against the possibility of there being no receiver on the proceedCh.
-	for _, handler := range checks {
-		select {
-		case handler.proceedCh <- handler == winningHandler:
-		default:
-		}
+	for _, k := range keys {
+		args = append(args, "label."+k, p.Labels[k])
 	}
+	return args
+}
 
-	// Measure how long it takes to invoke the scaling actions. This helps
-	// understand the time taken to interact with the remote target and action
-	// the scaling action.
-	defer metrics.MeasureSinceWithLabels([]string{"scale", "invoke_ms"}, time.Now(), labels)
+// policyMetricLabels returns the metric labels built from the subset of the
+// policy labels defined within metricPolicyLabels.
+func policyMetricLabels(p *sdk.ScalingPolicy) []metrics.Label {
+	var labels []metrics.Label
 
-	// Block until winning handler returns.
-	select {
-	case <-ctx.Done():
-		logger.Info("policy evaluation canceled")
-		return nil
-	case r := <-winningHandler.results():
-		if r.err != nil {
-			return r.err
-		}
-		if r.action == nil {
-			return nil
+	for _, k := range metricPolicyLabels {
+		if v, ok := p.Labels[k]; ok {
+			labels = append(labels, metrics.Label{Name: k, Value: v})
 		}
 	}
-
-	// Enforce the cooldown after a successful scaling event.
-	w.policyManager.EnforceCooldown(eval.Policy.ID, eval.Policy.Cooldown)
-
-	logger.Info("policy evaluation complete")
-	return nil
+	return labels
 }
 
 // checkHandler evaluates one of the checks of a policy.
@@ -210,7 +1423,10 @@ type checkHandler struct {
 	logger        hclog.Logger
 	policy        *sdk.ScalingPolicy
 	checkEval     *sdk.ScalingCheckEvaluation
-	pluginManager *manager.PluginManager
+	pluginManager pluginDispenser
+	queryCache    *QueryCache
+	resultCache   *ResultCache
+	clock         clock.Clock
 	resultCh      chan checkHandlerResult
 	proceedCh     chan bool
 }
@@ -218,10 +1434,23 @@ type checkHandler struct {
 type checkHandlerResult struct {
 	action *sdk.ScalingAction
 	err    error
+
+	// suppressed is the reason the check did not produce the action its
+	// strategy wanted, if any. It is only recorded by the worker when the
+	// policy evaluation as a whole does not scale the target.
+	suppressed string
+
+	// count is the current count of the target read by the check.
+	count int64
+
+	// desired is the count wanted by the strategy of the check, before the
+	// policy limits and guards are applied. It is nil if the strategy did
+	// not make a decision.
+	desired *int64
 }
 
 // newCheckHandler returns a new checkHandler instance.
-func newCheckHandler(l hclog.Logger, p *sdk.ScalingPolicy, c *sdk.ScalingCheckEvaluation, pm *manager.PluginManager) *checkHandler {
+func newCheckHandler(l hclog.Logger, p *sdk.ScalingPolicy, c *sdk.ScalingCheckEvaluation, pm pluginDispenser, qc *QueryCache, rc *ResultCache, clk clock.Clock) *checkHandler {
 	return &checkHandler{
 		logger: l.Named("check_handler").With(
 			"check", c.Check.Name,
@@ -231,8 +1460,11 @@ func newCheckHandler(l hclog.Logger, p *sdk.ScalingPolicy, c *sdk.ScalingCheckEv
 		policy:        p,
 		checkEval:     c,
 		pluginManager: pm,
+		queryCache:    qc,
+		resultCache:   rc,
+		clock:         clk,
 		resultCh:      make(chan checkHandlerResult),
-		proceedCh:     make(chan bool),
+		proceedCh:     make(chan bool, 1),
 	}
 }
 
@@ -266,9 +1498,12 @@ func (h *checkHandler) start(ctx context.Context) {
 	result := checkHandlerResult{}
 
 	var targetInst target.Target
-	var apmInst apm.APM
 	var strategyInst strategy.Strategy
 
+	// metricValue is the latest APM value of the check, formatted using the
+	// metric unit of the policy. It is empty if the check has no metrics.
+	var metricValue string
+
 	// Dispense plugins.
 	targetPlugin, err := h.pluginManager.Dispense(h.policy.Target.Name, plugins.PluginTypeTarget)
 	if err != nil {
@@ -278,24 +1513,28 @@ func (h *checkHandler) start(ctx context.Context) {
 	}
 	targetInst = targetPlugin.Plugin().(target.Target)
 
-	apmPlugin, err := h.pluginManager.Dispense(h.checkEval.Check.Source, plugins.PluginTypeAPM)
+	sources, err := h.dispenseSources()
 	if err != nil {
-		result.err = fmt.Errorf(`apm plugin "%s" not initialized: %v`, h.checkEval.Check.Source, err)
+		result.err = err
 		h.resultCh <- result
 		return
 	}
-	apmInst = apmPlugin.Plugin().(apm.APM)
 
-	strategyPlugin, err := h.pluginManager.Dispense(h.checkEval.Check.Strategy.Name, plugins.PluginTypeStrategy)
-	if err != nil {
-		result.err = fmt.Errorf(`strategy plugin "%s" not initialized: %v`, h.checkEval.Check.Strategy.Name, err)
-		h.resultCh <- result
-		return
+	// An unavailable strategy plugin fails the check, unless the policy
+	// configures a fallback strategy to use in its place.
+	strategyPlugin, strategyErr := h.pluginManager.Dispense(h.checkEval.Check.Strategy.Name, plugins.PluginTypeStrategy)
+	if strategyErr != nil {
+		if h.policy.FallbackStrategy == "" {
+			result.err = fmt.Errorf(`strategy plugin "%s" not initialized: %v`, h.checkEval.Check.Strategy.Name, strategyErr)
+			h.resultCh <- result
+			return
+		}
+	} else {
+		strategyInst = strategyPlugin.Plugin().(strategy.Strategy)
 	}
-	strategyInst = strategyPlugin.Plugin().(strategy.Strategy)
 
 	// Fetch target status.
-	currentStatus, err := h.runTargetStatus(targetInst)
+	currentStatus, err := h.runTargetStatus(ctx, targetInst)
 	if err != nil {
 		result.err = fmt.Errorf("failed to fetch current count: %v", err)
 		h.resultCh <- result
@@ -306,50 +1545,150 @@ func (h *checkHandler) start(ctx context.Context) {
 		h.resultCh <- result
 		return
 	}
-
-	// Query check's APM.
-	h.checkEval.Metrics, err = h.runAPMQuery(apmInst)
-	if err != nil {
-		result.err = fmt.Errorf("failed to query source: %v", err)
+	if deferForDeployment(h.policy, currentStatus) {
+		result.err = errDeploymentInProgress
 		h.resultCh <- result
 		return
 	}
 
-	// Make sure metrics are sorted consistently.
-	sort.Sort(h.checkEval.Metrics)
-
-	if len(h.checkEval.Metrics) == 0 {
-		h.logger.Warn("no metrics available")
-		return
+	// Targets may report transient counts during a rollout, so read the
+	// count again if the policy requires it to be stable before acting.
+	if h.policy.StabilizeCount {
+		currentStatus, err = h.stabilizeCount(ctx, targetInst, currentStatus)
+		if err == context.Canceled {
+			return
+		}
+		if err != nil {
+			result.err = err
+			h.resultCh <- result
+			return
+		}
 	}
+	result.count = currentStatus.Count
 
-	// Calculate new count using check's Strategy.
-	h.logger.Debug("calculating new count", "count", currentStatus.Count)
-	runResp, err := h.runStrategyRun(strategyInst, currentStatus.Count)
-	if err != nil {
-		result.err = fmt.Errorf("failed to execute strategy: %v", err)
-		h.resultCh <- result
-		return
+	if strategyInst == nil {
+		// The strategy cannot calculate a new count, so use the fallback
+		// strategy. It is logged as a warning on each evaluation so it is not
+		// mistaken for normal operation.
+		h.logger.Warn("strategy plugin not available, using fallback strategy",
+			"fallback_strategy", h.policy.FallbackStrategy, "count", currentStatus.Count, "error", strategyErr)
+
+		if h.policy.FallbackStrategy == sdk.FallbackStrategyHold {
+			result.action = &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone}
+			h.resultCh <- result
+			return
+		}
+
+		// The bounds fallback only keeps the count within the [min, max]
+		// limits, as a strategy reporting no change does.
+		h.checkEval.Action = &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone}
+	} else {
+		// Query check's APM, failing over to the next source on error.
+		h.checkEval.Metrics, err = h.queryMetrics(ctx, sources, currentStatus.Count)
+		if err != nil {
+			result.err = fmt.Errorf("failed to query source: %v", err)
+			h.resultCh <- result
+			return
+		}
+
+		// Make sure metrics are sorted consistently.
+		sort.Sort(h.checkEval.Metrics)
+
+		// Invalid metrics would lead the strategy to calculate a meaningless
+		// count, so hold the current count instead.
+		if err := validateMetrics(h.policy, h.checkEval.Metrics); err != nil {
+			h.logger.Warn("invalid metric value, holding current count",
+				"count", currentStatus.Count, "error", err)
+			result.action = &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone}
+			result.suppressed = policy.SuppressionReasonInvalidMetric
+			h.resultCh <- result
+			return
+		}
+
+		// Transform the metrics before the strategy sees them. Transforms
+		// such as moving averages depend on the order of the metrics, so
+		// this happens once they are sorted.
+		if transforms := h.checkEval.Check.Transforms; len(transforms) > 0 && len(h.checkEval.Metrics) > 0 {
+			raw := h.checkEval.Metrics[len(h.checkEval.Metrics)-1].Value
+
+			h.checkEval.Metrics, err = policy.ApplyTransforms(transforms, h.checkEval.Metrics)
+			if err != nil {
+				result.err = fmt.Errorf("failed to transform metrics: %v", err)
+				h.resultCh <- result
+				return
+			}
+
+			h.logger.Debug("transformed metrics", "value", sdk.FormatMetricValue(raw, h.policy.MetricUnit),
+				"transformed_value", sdk.FormatMetricValue(h.checkEval.Metrics[len(h.checkEval.Metrics)-1].Value, h.policy.MetricUnit))
+		}
+
+		if len(h.checkEval.Metrics) > 0 {
+			metricValue = sdk.FormatMetricValue(h.checkEval.Metrics[len(h.checkEval.Metrics)-1].Value, h.policy.MetricUnit)
+		}
+
+		// Checks scaling the target to a percentage of the metric pass the
+		// strategy the count the target requires, rather than the metric.
+		if pct := h.checkEval.Check.TargetPercentage; pct > 0 && len(h.checkEval.Metrics) > 0 {
+			h.checkEval.Metrics = policy.ApplyTargetPercentage(pct, h.policy.Rounding, h.checkEval.Metrics)
+
+			h.logger.Debug("derived count from target percentage", "value", metricValue, "target_percentage", pct,
+				"derived_count", h.checkEval.Metrics[len(h.checkEval.Metrics)-1].Value)
+		}
+
+		// Without metrics the strategy cannot make a decision, so handle the
+		// check in the same way as a strategy reporting insufficient data.
+		if len(h.checkEval.Metrics) == 0 {
+			h.logger.Warn("no metrics available")
+			h.checkEval.Status = sdk.StrategyStatusNoData
+		} else {
+			// Calculate new count using check's Strategy.
+			h.logger.Debug("calculating new count", "count", currentStatus.Count, "metric_value", metricValue)
+			runResp, err := h.runStrategyRun(ctx, strategyInst, currentStatus.Count)
+			if err != nil {
+				result.err = fmt.Errorf("failed to execute strategy: %v", err)
+				h.resultCh <- result
+				return
+			}
+			h.checkEval = runResp
+		}
+
+		// The strategy does not have enough data to make a decision, so hold
+		// the current count. Unlike a decision of no change, the [min, max]
+		// limits are not enforced either, as the target should not be acted
+		// upon at all.
+		if h.checkEval.Status == sdk.StrategyStatusNoData {
+			h.logger.Info("strategy has insufficient data, holding current count", "count", currentStatus.Count)
+			result.action = &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone}
+			result.suppressed = policy.SuppressionReasonNoData
+			h.resultCh <- result
+			return
+		}
+
+		// Record the count wanted by the strategy before the limits and
+		// guards below change it. A decision of no change wants the current
+		// count.
+		desired := currentStatus.Count
+		if h.checkEval.Action.Direction != sdk.ScaleDirectionNone {
+			desired = h.checkEval.Action.Count
+		}
+		result.desired = &desired
 	}
-	h.checkEval = runResp
 
 	if h.checkEval.Action.Direction == sdk.ScaleDirectionNone {
 		// Make sure we are currently within [min, max] limits even if there's
 		// no action to execute
-		var minMaxAction *sdk.ScalingAction
-
-		if currentStatus.Count < h.policy.Min {
-			minMaxAction = &sdk.ScalingAction{
-				Count:     h.policy.Min,
-				Direction: sdk.ScaleDirectionUp,
-				Reason:    fmt.Sprintf("current count (%d) below limit (%d)", currentStatus.Count, h.policy.Min),
-			}
-		} else if currentStatus.Count > h.policy.Max {
-			minMaxAction = &sdk.ScalingAction{
-				Count:     h.policy.Max,
-				Direction: sdk.ScaleDirectionDown,
-				Reason:    fmt.Sprintf("current count (%d) above limit (%d)", currentStatus.Count, h.policy.Max),
-			}
+		minMaxAction := boundsAction(h.policy, currentStatus.Count)
+
+		// Policies which let the strategy decide hold a count outside of
+		// the bounds if the strategy recommends no change.
+		if minMaxAction != nil && h.policy.OutOfBoundsAction == sdk.OutOfBoundsActionStrategy {
+			h.logger.Info("strategy recommends no change, holding count outside policy bounds",
+				"out_of_bounds_action", h.policy.OutOfBoundsAction, "count", currentStatus.Count,
+				"min", h.policy.Min, "max", h.policy.Max)
+			result.action = &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone}
+			result.suppressed = policy.SuppressionReasonOutOfBounds
+			h.resultCh <- result
+			return
 		}
 
 		if minMaxAction != nil {
@@ -365,18 +1704,67 @@ func (h *checkHandler) start(ctx context.Context) {
 	// Canonicalize action so plugins don't have to.
 	h.checkEval.Action.Canonicalize()
 
+	// Track the count requested by the strategy to detect actions which are
+	// suppressed entirely by the limits below.
+	desiredCount := h.checkEval.Action.Count
+
+	// Limit the change in count to the policy scale step limits. This is done
+	// before applying the [min, max] limits so the bounds always win.
+	limitScaleStep(h.checkEval.Action, currentStatus.Count, h.policy.MaxScaleStep, h.policy.MaxScalePercent, h.policy.Rounding)
+	limitScaleDownStep(h.checkEval.Action, currentStatus.Count, h.policy.ScaleDownStep)
+
 	// Make sure new count value is within [min, max] limits
 	h.checkEval.Action.CapCount(h.policy.Min, h.policy.Max)
 
+	// Policies which let the strategy decide hold a count outside of the
+	// bounds if capping reversed the direction chosen by the strategy, such
+	// as a scale up capped to a Max below the current count.
+	if h.policy.OutOfBoundsAction == sdk.OutOfBoundsActionStrategy &&
+		reversesDirection(h.checkEval.Action, currentStatus.Count) {
+		h.logger.Info("strategy recommends scaling away from policy bounds, holding count outside them",
+			"out_of_bounds_action", h.policy.OutOfBoundsAction, "count", currentStatus.Count,
+			"direction", h.checkEval.Action.Direction, "min", h.policy.Min, "max", h.policy.Max)
+		result.action = &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone}
+		result.suppressed = policy.SuppressionReasonOutOfBounds
+		h.resultCh <- result
+		return
+	}
+
+	// Make sure new count value does not exceed what the target can reach.
+	h.capTargetCapacity(currentStatus)
+
 	// Skip action if count doesn't change.
 	if currentStatus.Count == h.checkEval.Action.Count {
 		h.logger.Debug("nothing to do", "from", currentStatus.Count, "to", h.checkEval.Action.Count)
 
 		result.action = &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone}
+		if desiredCount != currentStatus.Count {
+			result.suppressed = policy.SuppressionReasonBounds
+		}
 		h.resultCh <- result
 		return
 	}
 
+	// Hold scale downs while too few instances of the target are healthy,
+	// as removing more could tip over a struggling target. The target is
+	// still brought within the policy max.
+	if h.checkEval.Action.Count < currentStatus.Count && !h.healthyForScaleDown(currentStatus) {
+		if currentStatus.Count <= h.policy.Max {
+			result.action = &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone}
+			result.suppressed = policy.SuppressionReasonUnhealthy
+			h.resultCh <- result
+			return
+		}
+		if h.checkEval.Action.Count < h.policy.Max {
+			h.checkEval.Action.Count = h.policy.Max
+		}
+	}
+
+	// Record the APM value the action was calculated from within its meta,
+	// so it is included in the decision events and the scaling event.
+	if metricValue != "" {
+		h.checkEval.Action.SetMetricValueMeta(metricValue)
+	}
 	result.action = h.checkEval.Action
 
 	// Send result back and wait to see if we should proceed.
@@ -391,6 +1779,13 @@ func (h *checkHandler) start(ctx context.Context) {
 		}
 	}
 
+	// Record the cause of the action, the policy revision and the count it
+	// was calculated from within its meta, so they are passed to the target
+	// and stored with the scaling event.
+	h.checkEval.Action.SetReasonCodeMeta()
+	h.checkEval.Action.SetPolicyRevisionMeta(h.policy.Revision)
+	h.checkEval.Action.SetCurrentCountMeta(currentStatus.Count)
+
 	// If the policy is configured with dry-run:true then we set the
 	// action count to nil so its no-nop. This allows us to still
 	// submit the job, but not alter its state.
@@ -401,16 +1796,18 @@ func (h *checkHandler) start(ctx context.Context) {
 
 	if h.checkEval.Action.Count == sdk.StrategyActionMetaValueDryRunCount {
 		h.logger.Debug("registering scaling event",
-			"count", currentStatus.Count, "reason", h.checkEval.Action.Reason, "meta", h.checkEval.Action.Meta)
+			"count", currentStatus.Count, "reason", h.checkEval.Action.Reason,
+			"reason_code", h.checkEval.Action.ReasonCode, "meta", h.checkEval.Action.Meta)
 	} else {
-		h.logger.Info("scaling target",
+		withDistribution(h.logger, currentStatus).Info("scaling target",
 			"from", currentStatus.Count, "to", h.checkEval.Action.Count,
-			"reason", h.checkEval.Action.Reason, "meta", h.checkEval.Action.Meta)
+			"reason", h.checkEval.Action.Reason, "reason_code", h.checkEval.Action.ReasonCode,
+			"meta", h.checkEval.Action.Meta)
 	}
 
 	// Scale the target. If we receive an error add this onto the result so the
 	// handler understand what do to.
-	if err = h.runTargetScale(targetInst, *h.checkEval.Action); err != nil {
+	if err = h.runTargetScale(ctx, targetInst, *h.checkEval.Action); err != nil {
 		result.err = fmt.Errorf("failed to scale target: %v", err)
 		h.logger.Error("failed to submit scaling action to target", "error", err)
 		metrics.IncrCounter([]string{"scale", "invoke", "error_count"}, 1)
@@ -418,6 +1815,7 @@ func (h *checkHandler) start(ctx context.Context) {
 		h.logger.Info("successfully submitted scaling action to target",
 			"desired_count", h.checkEval.Action.Count)
 		metrics.IncrCounter([]string{"scale", "invoke", "success_count"}, 1)
+		incrScaleActionCount(h.policy, h.checkEval.Action)
 	}
 
 	// Ensure we send a result otherwise the Worker.HandlePolicy routine will
@@ -425,22 +1823,273 @@ func (h *checkHandler) start(ctx context.Context) {
 	h.resultCh <- result
 }
 
+// stabilizeScaleDown limits the count of the scale down action to the highest
+// count recommended within the scale down stabilization window of the policy,
+// while still enforcing the policy max. It returns false if the action no
+// longer scales the target down from current.
+func stabilizeScaleDown(p *sdk.ScalingPolicy, action *sdk.ScalingAction, current, highest int64) bool {
+	if highest > p.Max {
+		highest = p.Max
+	}
+	if highest <= action.Count {
+		return true
+	}
+	if highest >= current {
+		return false
+	}
+
+	action.Count = highest
+	return true
+}
+
+// limitScaleStep limits the difference between the action count and the
+// current count to the most restrictive of maxStep and maxPercent of the
+// current count. The percentage limit is rounded using the rounding mode, or
+// down if it is unset, but is never less than one so that a warranted change
+// is always performed. Zero values disable the respective limit.
+func limitScaleStep(action *sdk.ScalingAction, current, maxStep int64, maxPercent float64, rounding string) {
+	limit := int64(-1)
+
+	if maxStep > 0 {
+		limit = maxStep
+	}
+
+	if maxPercent > 0 {
+		percentLimit := sdk.RoundCount(float64(current)*maxPercent/100, rounding, sdk.RoundingModeFloor)
+		if percentLimit < 1 {
+			percentLimit = 1
+		}
+		if limit < 0 || percentLimit < limit {
+			limit = percentLimit
+		}
+	}
+
+	if limit < 0 {
+		return
+	}
+
+	switch delta := action.Count - current; {
+	case delta > limit:
+		action.Count = current + limit
+	case delta < -limit:
+		action.Count = current - limit
+	}
+}
+
+// limitScaleDownStep limits a scale down action to remove at most step from
+// the current count. Scale ups are not limited, and a step of zero means no
+// limit.
+func limitScaleDownStep(action *sdk.ScalingAction, current, step int64) {
+	if step > 0 && action.Count < current-step {
+		action.Count = current - step
+	}
+}
+
+// validateMetrics returns an error if any of the metrics is not a finite
+// number or falls outside the policy MetricMin and MetricMax range.
+func validateMetrics(p *sdk.ScalingPolicy, m sdk.TimestampedMetrics) error {
+	for _, metric := range m {
+		switch {
+		case math.IsNaN(metric.Value) || math.IsInf(metric.Value, 0):
+			return fmt.Errorf("value %v at %s is not a finite number", metric.Value, metric.Timestamp)
+		case p.MetricMin != nil && metric.Value < *p.MetricMin:
+			return fmt.Errorf("value %v at %s is below the policy metric min %v", metric.Value, metric.Timestamp, *p.MetricMin)
+		case p.MetricMax != nil && metric.Value > *p.MetricMax:
+			return fmt.Errorf("value %v at %s is above the policy metric max %v", metric.Value, metric.Timestamp, *p.MetricMax)
+		}
+	}
+	return nil
+}
+
+// validateTargetStatus checks the status returned by a target plugin is well
+// formed. Plugin responses cross an RPC boundary, so a misbehaving plugin must
+// not lead to a panic or to scaling based on a meaningless count.
+func validateTargetStatus(status *sdk.TargetStatus) error {
+	if status == nil {
+		return errors.New("target returned no status")
+	}
+	if err := validateCount(status.Count); err != nil {
+		return fmt.Errorf("target returned invalid status: %v", err)
+	}
+	return nil
+}
+
+// validateStrategyResult checks the evaluation returned by a strategy plugin is
+// well formed. Strategies which do not have enough data to make a decision are
+// not required to return an action.
+func validateStrategyResult(eval *sdk.ScalingCheckEvaluation) error {
+	switch {
+	case eval == nil:
+		return errors.New("strategy returned no evaluation")
+	case eval.Status == sdk.StrategyStatusNoData:
+		return nil
+	case eval.Action == nil:
+		return errors.New("strategy returned no action")
+	}
+
+	switch eval.Action.Direction {
+	case sdk.ScaleDirectionNone:
+		return nil
+	case sdk.ScaleDirectionUp, sdk.ScaleDirectionDown:
+	default:
+		return fmt.Errorf("strategy returned invalid direction %d", eval.Action.Direction)
+	}
+
+	if err := validateCount(eval.Action.Count); err != nil {
+		return fmt.Errorf("strategy returned invalid action: %v", err)
+	}
+	return nil
+}
+
+// validateCount checks count is non-negative and can be represented as an int,
+// which is how targets such as Nomad task groups store it.
+func validateCount(count int64) error {
+	if count < 0 {
+		return fmt.Errorf("count %d is negative", count)
+	}
+	if int64(int(count)) != count {
+		return fmt.Errorf("count %d is out of range", count)
+	}
+	return nil
+}
+
+// deferForDeployment returns whether scaling the target of the policy is
+// deferred, as the policy defers scaling during deployments and the target
+// reports one in progress.
+func deferForDeployment(p *sdk.ScalingPolicy, status *sdk.TargetStatus) bool {
+	return p.DeferDuringDeployment && deploymentInProgress(status)
+}
+
+// deploymentInProgress returns whether the target status reports a deployment
+// in progress. Targets which do not report their deployment status are never
+// considered to be deploying.
+func deploymentInProgress(status *sdk.TargetStatus) bool {
+	active, err := strconv.ParseBool(status.Meta[sdk.TargetStatusMetaKeyDeploymentActive])
+	return err == nil && active
+}
+
+// capTargetCapacity limits scale up actions to the capacity reported by the
+// target within its status meta, if any.
+func (h *checkHandler) capTargetCapacity(status *sdk.TargetStatus) {
+	val, ok := status.Meta[sdk.TargetStatusMetaKeyCapacity]
+	if !ok {
+		return
+	}
+
+	capacity, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		h.logger.Debug("failed to parse target capacity", "capacity", val, "error", err)
+		return
+	}
+
+	if h.checkEval.Action.CapCapacity(status.Count, h.policy.Min, capacity) {
+		h.logger.Info("target capacity is limiting scaling action",
+			"capacity", capacity, "policy_max", h.policy.Max)
+	}
+}
+
+// healthyForScaleDown returns whether enough of the current count of the
+// target is healthy for it to be scaled down, as required by the policy
+// MinHealthyPercentage. Targets which do not report their healthy count are
+// not held.
+func (h *checkHandler) healthyForScaleDown(status *sdk.TargetStatus) bool {
+	if h.policy.MinHealthyPercentage <= 0 || status.Count <= 0 {
+		return true
+	}
+
+	val, ok := status.Meta[sdk.TargetStatusMetaKeyHealthyCount]
+	if !ok {
+		h.logger.Debug("target does not report its healthy count, ignoring min healthy percentage")
+		return true
+	}
+
+	healthy, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		h.logger.Debug("failed to parse target healthy count", "healthy_count", val, "error", err)
+		return true
+	}
+
+	percent := float64(healthy) / float64(status.Count) * 100
+	if percent < h.policy.MinHealthyPercentage {
+		h.logger.Info("too few healthy instances, holding scale down",
+			"count", status.Count, "healthy_count", healthy,
+			"min_healthy_percentage", h.policy.MinHealthyPercentage)
+		return false
+	}
+	return true
+}
+
+// stabilizeCount reads the target status again once the policy
+// StabilizeCountDelay has passed. errCountUnstable is returned if the count
+// differs from the one in status, and errTargetNotReady if the target is no
+// longer ready.
+func (h *checkHandler) stabilizeCount(ctx context.Context, targetImpl target.Target, status *sdk.TargetStatus) (*sdk.TargetStatus, error) {
+	timer := h.clock.NewTimer(h.policy.StabilizeCountDelay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C():
+	}
+
+	next, err := h.runTargetStatus(ctx, targetImpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch current count: %v", err)
+	}
+	if !next.Ready {
+		return nil, errTargetNotReady
+	}
+	if next.Count != status.Count {
+		h.logger.Debug("target count changed between reads",
+			"first_count", status.Count, "second_count", next.Count)
+		return nil, errCountUnstable
+	}
+	return next, nil
+}
+
 // runTargetStatus wraps the target.Status call to provide operational
 // functionality.
-func (h *checkHandler) runTargetStatus(targetImpl target.Target) (*sdk.TargetStatus, error) {
+func (h *checkHandler) runTargetStatus(ctx context.Context, targetImpl target.Target) (status *sdk.TargetStatus, err error) {
 
 	h.logger.Debug("fetching current count")
 
+	_, span := startSpan(ctx, "target.count",
+		attribute.String("policy_id", h.policy.ID),
+		attribute.String("plugin_name", h.policy.Target.Name),
+	)
+	defer func() {
+		if status != nil {
+			span.SetAttributes(attribute.Int64("current_count", status.Count))
+		}
+		endSpan(span, err)
+	}()
+
 	// Trigger a metric measure to track latency of the call.
 	labels := []metrics.Label{{Name: "plugin_name", Value: h.policy.Target.Name}, {Name: "policy_id", Value: h.policy.ID}}
 	defer metrics.MeasureSinceWithLabels([]string{"plugin", "target", "status", "invoke_ms"}, time.Now(), labels)
 
-	return targetImpl.Status(h.policy.Target.Config)
+	status, err = targetImpl.Status(h.policy.Target.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateTargetStatus(status); err != nil {
+		return nil, err
+	}
+	return status, nil
 }
 
 // runTargetScale wraps the target.Scale call to provide operational
 // functionality.
-func (h *checkHandler) runTargetScale(targetImpl target.Target, action sdk.ScalingAction) error {
+func (h *checkHandler) runTargetScale(ctx context.Context, targetImpl target.Target, action sdk.ScalingAction) (err error) {
+
+	_, span := startSpan(ctx, "target.scale",
+		attribute.String("policy_id", h.policy.ID),
+		attribute.String("plugin_name", h.policy.Target.Name),
+		attribute.String("direction", action.Direction.String()),
+		attribute.Int64("new_count", action.Count),
+	)
+	defer func() { endSpan(span, err) }()
 
 	// Trigger a metric measure to track latency of the call.
 	labels := []metrics.Label{{Name: "plugin_name", Value: h.policy.Target.Name}, {Name: "policy_id", Value: h.policy.ID}}
@@ -449,25 +2098,174 @@ func (h *checkHandler) runTargetScale(targetImpl target.Target, action sdk.Scali
 	return targetImpl.Scale(action, h.policy.Target.Config)
 }
 
-// runAPMQuery wraps the apm.Query call to provide operational functionality.
-func (h *checkHandler) runAPMQuery(apmImpl apm.APM) (sdk.TimestampedMetrics, error) {
+// checkSource is an APM plugin the check can be queried against.
+type checkSource struct {
+	name string
+	apm  apm.APM
+}
+
+// dispenseSources returns the APM plugins of the check source followed by
+// its failover sources, in order. Unavailable failover sources are skipped,
+// and an error is only returned if none of the sources are available.
+func (h *checkHandler) dispenseSources() ([]checkSource, error) {
+	names := append([]string{h.checkEval.Check.Source}, h.checkEval.Check.FailoverSources...)
+
+	var sources []checkSource
+	var firstErr error
+
+	for _, name := range names {
+		apmPlugin, err := h.pluginManager.Dispense(name, plugins.PluginTypeAPM)
+		if err != nil {
+			err = fmt.Errorf(`apm plugin "%s" not initialized: %v`, name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			if len(names) > 1 {
+				h.logger.Warn("source not available, skipping", "source", name, "error", err)
+			}
+			continue
+		}
+		sources = append(sources, checkSource{name: name, apm: apmPlugin.Plugin().(apm.APM)})
+	}
+
+	if len(sources) == 0 {
+		return nil, firstErr
+	}
+	return sources, nil
+}
+
+// queryMetrics queries the sources in order, returning the metrics of the
+// first one which succeeds. An error is only returned once all sources
+// failed.
+func (h *checkHandler) queryMetrics(ctx context.Context, sources []checkSource, count int64) (sdk.TimestampedMetrics, error) {
+	var mErr *multierror.Error
+
+	for i, s := range sources {
+		m, err := h.runAPMQuery(ctx, s.name, s.apm, count)
+		if err == nil {
+			if s.name != h.checkEval.Check.Source {
+				h.logger.Info("queried failover source", "source", s.name)
+
+				labels := []metrics.Label{{Name: "plugin_name", Value: s.name}, {Name: "policy_id", Value: h.policy.ID}}
+				metrics.IncrCounterWithLabels([]string{"plugin", "apm", "query", "failover_count"}, 1, labels)
+			}
+			return m, nil
+		}
+
+		// The error of a check without failover sources is returned
+		// unchanged.
+		if len(sources) == 1 {
+			return nil, err
+		}
+		mErr = multierror.Append(mErr, fmt.Errorf("%s: %v", s.name, err))
+
+		if ctx.Err() != nil {
+			break
+		}
+		if i < len(sources)-1 {
+			h.logger.Warn("failed to query source, failing over",
+				"source", s.name, "failover_source", sources[i+1].name, "error", err)
+		}
+	}
+
+	return nil, mErr.ErrorOrNil()
+}
+
+// runAPMQuery wraps the apm.Query call against the source to provide
+// operational functionality.
+func (h *checkHandler) runAPMQuery(ctx context.Context, source string, apmImpl apm.APM, count int64) (m sdk.TimestampedMetrics, err error) {
+
+	_, span := startSpan(ctx, "apm.query",
+		attribute.String("policy_id", h.policy.ID),
+		attribute.String("plugin_name", source),
+		attribute.Int64("current_count", count),
+	)
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("metrics", len(m)),
+			attribute.Bool("cached", h.checkEval.MetricsCached),
+		)
+		if len(m) > 0 {
+			span.SetAttributes(attribute.Float64("value", m[len(m)-1].Value))
+		}
+		endSpan(span, err)
+	}()
+
+	// Substitute the template variables so the query run, and the cache
+	// entries for it, reflect the policy and current count.
+	query, err := renderQuery(h.policy, h.checkEval.Check.Query, count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render query: %v", err)
+	}
+	span.SetAttributes(attribute.String("query", query))
 
-	h.logger.Debug("querying source", "query", h.checkEval.Check.Query, "source", h.checkEval.Check.Source)
+	h.logger.Debug("querying source", "query", query, "source", source)
 
 	// Trigger a metric measure to track latency of the call.
-	labels := []metrics.Label{{Name: "plugin_name", Value: h.checkEval.Check.Source}, {Name: "policy_id", Value: h.policy.ID}}
+	labels := []metrics.Label{{Name: "plugin_name", Value: source}, {Name: "policy_id", Value: h.policy.ID}}
 	defer metrics.MeasureSinceWithLabels([]string{"plugin", "apm", "query", "invoke_ms"}, time.Now(), labels)
 
-	// Calculate query range from the query window defined in the check.
-	to := time.Now()
-	from := to.Add(-h.checkEval.Check.QueryWindow)
-	r := sdk.TimeRange{From: from, To: to}
+	check := h.checkEval.Check
 
-	return apmImpl.Query(h.checkEval.Check.Query, r)
+	// Reuse the result of a previous evaluation if it is within the result
+	// cache TTL.
+	if cached, fetchedAt, ok := h.resultCache.Get(source, query, check.QueryWindow); ok {
+		h.logger.Debug("using cached query result", "query", query,
+			"source", source, "age", h.clock.Since(fetchedAt))
+		h.checkEval.MetricsCached = true
+		return cached, nil
+	}
+
+	fetchedAt := h.clock.Now()
+
+	// Identical queries from other checks are coalesced by the query cache,
+	// so the APM is only called once and the result shared.
+	m, err = h.queryCache.Query(source, query, check.QueryWindow, func() (sdk.TimestampedMetrics, error) {
+
+		// Calculate query range from the query window defined in the check.
+		to := h.clock.Now()
+		from := to.Add(-check.QueryWindow)
+		r := sdk.TimeRange{From: from, To: to}
+
+		return apmImpl.Query(query, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.resultCache.Set(source, query, check.QueryWindow, m, fetchedAt)
+	return m, nil
+}
+
+// renderQuery substitutes the policy template variables, and the current
+// count of the target, within query.
+func renderQuery(p *sdk.ScalingPolicy, query string, count int64) (string, error) {
+	if !strings.Contains(query, "${") {
+		return query, nil
+	}
+
+	vars := templateVars(p)
+	vars[sdk.TemplateVarCurrentCount] = strconv.FormatInt(count, 10)
+	return renderTemplate(query, vars)
 }
 
 // runStrategyRun wraps the strategy.Run call to provide operational functionality.
-func (h *checkHandler) runStrategyRun(strategyImpl strategy.Strategy, count int64) (*sdk.ScalingCheckEvaluation, error) {
+func (h *checkHandler) runStrategyRun(ctx context.Context, strategyImpl strategy.Strategy, count int64) (eval *sdk.ScalingCheckEvaluation, err error) {
+
+	_, span := startSpan(ctx, "strategy.run",
+		attribute.String("policy_id", h.policy.ID),
+		attribute.String("plugin_name", h.checkEval.Check.Strategy.Name),
+		attribute.Int64("current_count", count),
+	)
+	defer func() {
+		if eval != nil && eval.Action != nil {
+			span.SetAttributes(
+				attribute.String("direction", eval.Action.Direction.String()),
+				attribute.Int64("new_count", eval.Action.Count),
+			)
+		}
+		endSpan(span, err)
+	}()
 
 	// Trigger a metric measure to track latency of the call.
 	labels := []metrics.Label{
@@ -476,5 +2274,12 @@ func (h *checkHandler) runStrategyRun(strategyImpl strategy.Strategy, count int6
 	}
 	defer metrics.MeasureSinceWithLabels([]string{"plugin", "strategy", "run", "invoke_ms"}, time.Now(), labels)
 
-	return strategyImpl.Run(h.checkEval, count)
+	eval, err = strategyImpl.Run(h.checkEval, count)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateStrategyResult(eval); err != nil {
+		return nil, err
+	}
+	return eval, nil
 }
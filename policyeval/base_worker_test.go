@@ -0,0 +1,3307 @@
+package policyeval
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/policy/file"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/clock"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/ptr"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_boundsAction(t *testing.T) {
+	p := &sdk.ScalingPolicy{Min: 2, Max: 10}
+
+	testCases := []struct {
+		name           string
+		count          int64
+		expectedAction *sdk.ScalingAction
+	}{
+		{
+			name:  "below min",
+			count: 0,
+			expectedAction: &sdk.ScalingAction{
+				Count:      2,
+				Direction:  sdk.ScaleDirectionUp,
+				Reason:     "current count (0) below limit (2)",
+				ReasonCode: sdk.ReasonCodeBounds,
+				Meta:       map[string]interface{}{},
+			},
+		},
+		{
+			name:  "above max",
+			count: 12,
+			expectedAction: &sdk.ScalingAction{
+				Count:      10,
+				Direction:  sdk.ScaleDirectionDown,
+				Reason:     "current count (12) above limit (10)",
+				ReasonCode: sdk.ReasonCodeBounds,
+				Meta:       map[string]interface{}{},
+			},
+		},
+		{
+			name:           "within bounds",
+			count:          5,
+			expectedAction: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedAction, boundsAction(p, tc.count), tc.name)
+		})
+	}
+}
+
+func Test_overrideAction(t *testing.T) {
+	expiry := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	o := &policy.Override{Count: 5, Expiry: expiry}
+
+	testCases := []struct {
+		name           string
+		count          int64
+		expectedAction *sdk.ScalingAction
+	}{
+		{
+			name:  "scale up to override",
+			count: 2,
+			expectedAction: &sdk.ScalingAction{
+				Count:      5,
+				Direction:  sdk.ScaleDirectionUp,
+				Reason:     "policy override active until 2020-10-01T12:00:00Z",
+				ReasonCode: sdk.ReasonCodeOverride,
+				Meta:       map[string]interface{}{},
+			},
+		},
+		{
+			name:  "scale down to override",
+			count: 8,
+			expectedAction: &sdk.ScalingAction{
+				Count:      5,
+				Direction:  sdk.ScaleDirectionDown,
+				Reason:     "policy override active until 2020-10-01T12:00:00Z",
+				ReasonCode: sdk.ReasonCodeOverride,
+				Meta:       map[string]interface{}{},
+			},
+		},
+		{
+			name:           "already at override",
+			count:          5,
+			expectedAction: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedAction, overrideAction(o, tc.count), tc.name)
+		})
+	}
+}
+
+func Test_pinnedAction(t *testing.T) {
+	p := &sdk.ScalingPolicy{Min: 3, Max: 3}
+
+	testCases := []struct {
+		name           string
+		count          int64
+		expectedAction *sdk.ScalingAction
+	}{
+		{
+			name:  "scale up to pinned count",
+			count: 1,
+			expectedAction: &sdk.ScalingAction{
+				Count:      3,
+				Direction:  sdk.ScaleDirectionUp,
+				Reason:     "current count (1) drifted from pinned count (3)",
+				ReasonCode: sdk.ReasonCodePinned,
+				Meta:       map[string]interface{}{},
+			},
+		},
+		{
+			name:  "scale down to pinned count",
+			count: 5,
+			expectedAction: &sdk.ScalingAction{
+				Count:      3,
+				Direction:  sdk.ScaleDirectionDown,
+				Reason:     "current count (5) drifted from pinned count (3)",
+				ReasonCode: sdk.ReasonCodePinned,
+				Meta:       map[string]interface{}{},
+			},
+		},
+		{
+			name:           "already at pinned count",
+			count:          3,
+			expectedAction: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedAction, pinnedAction(p, tc.count), tc.name)
+		})
+	}
+}
+
+func Test_limitScaleStep(t *testing.T) {
+	testCases := []struct {
+		name          string
+		current       int64
+		count         int64
+		maxStep       int64
+		maxPercent    float64
+		rounding      string
+		expectedCount int64
+	}{
+		{
+			name:          "no limits",
+			current:       10,
+			count:         30,
+			expectedCount: 30,
+		},
+		{
+			name:          "step limits scale up",
+			current:       10,
+			count:         30,
+			maxStep:       5,
+			expectedCount: 15,
+		},
+		{
+			name:          "step limits scale down",
+			current:       10,
+			count:         2,
+			maxStep:       5,
+			expectedCount: 5,
+		},
+		{
+			name:          "percent limits scale up",
+			current:       10,
+			count:         30,
+			maxPercent:    50,
+			expectedCount: 15,
+		},
+		{
+			name:          "percent rounds down",
+			current:       5,
+			count:         30,
+			maxPercent:    50,
+			expectedCount: 7,
+		},
+		{
+			name:          "percent rounds up",
+			current:       5,
+			count:         30,
+			maxPercent:    50,
+			rounding:      sdk.RoundingModeCeil,
+			expectedCount: 8,
+		},
+		{
+			name:          "percent rounds to nearest",
+			current:       5,
+			count:         30,
+			maxPercent:    30,
+			rounding:      sdk.RoundingModeRound,
+			expectedCount: 7,
+		},
+		{
+			name:          "percent never below one",
+			current:       0,
+			count:         5,
+			maxPercent:    50,
+			expectedCount: 1,
+		},
+		{
+			name:          "most restrictive limit wins",
+			current:       100,
+			count:         200,
+			maxStep:       10,
+			maxPercent:    50,
+			expectedCount: 110,
+		},
+		{
+			name:          "change within limits",
+			current:       10,
+			count:         12,
+			maxStep:       5,
+			maxPercent:    50,
+			expectedCount: 12,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			action := &sdk.ScalingAction{Count: tc.count}
+			limitScaleStep(action, tc.current, tc.maxStep, tc.maxPercent, tc.rounding)
+			assert.Equal(t, tc.expectedCount, action.Count, tc.name)
+		})
+	}
+}
+
+func Test_limitScaleDownStep(t *testing.T) {
+	testCases := []struct {
+		name          string
+		current       int64
+		count         int64
+		step          int64
+		expectedCount int64
+	}{
+		{
+			name:          "no step",
+			current:       10,
+			count:         2,
+			expectedCount: 2,
+		},
+		{
+			name:          "step limits scale down",
+			current:       10,
+			count:         2,
+			step:          3,
+			expectedCount: 7,
+		},
+		{
+			name:          "scale down within step",
+			current:       10,
+			count:         8,
+			step:          3,
+			expectedCount: 8,
+		},
+		{
+			name:          "scale up not limited",
+			current:       10,
+			count:         30,
+			step:          3,
+			expectedCount: 30,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			action := &sdk.ScalingAction{Count: tc.count}
+			limitScaleDownStep(action, tc.current, tc.step)
+			assert.Equal(t, tc.expectedCount, action.Count, tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_targetPercentage(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputValue      float64
+		inputPercentage float64
+		inputRounding   string
+		expectedMetric  float64
+	}{
+		{
+			name:           "disabled",
+			inputValue:     12,
+			expectedMetric: 12,
+		},
+		{
+			name:            "derived count rounded up",
+			inputValue:      12,
+			inputPercentage: 20,
+			expectedMetric:  3,
+		},
+		{
+			name:            "derived count using policy rounding",
+			inputValue:      12,
+			inputPercentage: 20,
+			inputRounding:   sdk.RoundingModeFloor,
+			expectedMetric:  2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(2, 2)
+			w.apm.metrics = sdk.TimestampedMetrics{{Timestamp: time.Now(), Value: tc.inputValue}}
+
+			p := newTestPolicy()
+			p.Rounding = tc.inputRounding
+			p.Checks[0].TargetPercentage = tc.inputPercentage
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+
+			if assert.Len(t, w.strategy.metrics, 1, tc.name) {
+				assert.Equal(t, tc.expectedMetric, w.strategy.metrics[0].Value, tc.name)
+			}
+
+			// The queried metrics may be shared through the caches, so must
+			// not be modified.
+			assert.Equal(t, tc.inputValue, w.apm.metrics[0].Value, tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_scaleDownStep(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputCount    int64
+		inputDesired  int64
+		expectedCount int64
+	}{
+		{
+			name:          "scale down stepped",
+			inputCount:    8,
+			inputDesired:  2,
+			expectedCount: 6,
+		},
+		{
+			name:          "scale up immediate",
+			inputCount:    2,
+			inputDesired:  8,
+			expectedCount: 8,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(tc.inputCount, tc.inputDesired)
+
+			p := newTestPolicy()
+			p.ScaleDownStep = 2
+			p.ScaleDownStepDelay = time.Minute
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+
+			actions := w.target.scaledActions()
+			if assert.Len(t, actions, 1, tc.name) {
+				assert.Equal(t, tc.expectedCount, actions[0].Count, tc.name)
+			}
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_scaleContext(t *testing.T) {
+	w := newTestWorker(2, 5)
+	w.target.status.Meta = map[string]string{sdk.TargetStatusMetaKeyDistribution: "dc1=1,dc2=1"}
+
+	// Target config keys unknown to the agent, such as those used by spread
+	// aware target plugins, are passed through unchanged.
+	p := newTestPolicy()
+	p.Target.Config = map[string]string{"Job": "web", "Group": "cache", "spread_attribute": "${node.datacenter}"}
+	assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)))
+
+	actions := w.target.scaledActions()
+	if assert.Len(t, actions, 1) {
+		assert.Equal(t, int64(5), actions[0].Count)
+		assert.Equal(t, "up", actions[0].Direction.String())
+		assert.Equal(t, int64(2), actions[0].Meta[sdk.StrategyActionMetaKeyCurrentCount])
+	}
+
+	w.target.l.Lock()
+	defer w.target.l.Unlock()
+	assert.Equal(t, []map[string]string{p.Target.Config}, w.target.configs)
+}
+
+func Test_stabilizeScaleDown(t *testing.T) {
+	testCases := []struct {
+		name            string
+		current         int64
+		count           int64
+		highest         int64
+		expectedProceed bool
+		expectedCount   int64
+	}{
+		{
+			name:            "no higher recommendation",
+			current:         8,
+			count:           4,
+			highest:         4,
+			expectedProceed: true,
+			expectedCount:   4,
+		},
+		{
+			name:            "limited to highest recommendation",
+			current:         8,
+			count:           4,
+			highest:         6,
+			expectedProceed: true,
+			expectedCount:   6,
+		},
+		{
+			name:            "delayed by recommendation of current count",
+			current:         8,
+			count:           4,
+			highest:         8,
+			expectedProceed: false,
+			expectedCount:   4,
+		},
+		{
+			name:            "delayed by recommendation above current count",
+			current:         8,
+			count:           4,
+			highest:         9,
+			expectedProceed: false,
+			expectedCount:   4,
+		},
+		{
+			name:            "max is still enforced",
+			current:         14,
+			count:           10,
+			highest:         14,
+			expectedProceed: true,
+			expectedCount:   10,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &sdk.ScalingPolicy{Min: 1, Max: 10}
+			action := &sdk.ScalingAction{Count: tc.count, Direction: sdk.ScaleDirectionDown}
+			assert.Equal(t, tc.expectedProceed, stabilizeScaleDown(p, action, tc.current, tc.highest), tc.name)
+			assert.Equal(t, tc.expectedCount, action.Count, tc.name)
+		})
+	}
+}
+
+func Test_validateMetrics(t *testing.T) {
+	testCases := []struct {
+		inputMin    *float64
+		inputMax    *float64
+		inputValues []float64
+		expectError bool
+		name        string
+	}{
+		{
+			inputValues: []float64{-5, 0, 1e9},
+			expectError: false,
+			name:        "unbounded range",
+		},
+		{
+			inputValues: []float64{1, math.NaN()},
+			expectError: true,
+			name:        "NaN",
+		},
+		{
+			inputValues: []float64{math.Inf(1)},
+			expectError: true,
+			name:        "positive infinity",
+		},
+		{
+			inputValues: []float64{math.Inf(-1)},
+			expectError: true,
+			name:        "negative infinity",
+		},
+		{
+			inputMin:    ptr.Float64ToPtr(0),
+			inputValues: []float64{0, 3, -1},
+			expectError: true,
+			name:        "below min",
+		},
+		{
+			inputMax:    ptr.Float64ToPtr(100),
+			inputValues: []float64{100.5},
+			expectError: true,
+			name:        "above max",
+		},
+		{
+			inputMin:    ptr.Float64ToPtr(0),
+			inputMax:    ptr.Float64ToPtr(100),
+			inputValues: []float64{0, 50, 100},
+			expectError: false,
+			name:        "within range",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var m sdk.TimestampedMetrics
+			for _, v := range tc.inputValues {
+				m = append(m, sdk.TimestampedMetric{Timestamp: time.Now(), Value: v})
+			}
+
+			p := &sdk.ScalingPolicy{MetricMin: tc.inputMin, MetricMax: tc.inputMax}
+			err := validateMetrics(p, m)
+			assert.Equal(t, tc.expectError, err != nil, tc.name)
+		})
+	}
+}
+
+func Test_validateTargetStatus(t *testing.T) {
+	testCases := []struct {
+		inputStatus *sdk.TargetStatus
+		expectError bool
+		name        string
+	}{
+		{
+			inputStatus: &sdk.TargetStatus{Ready: true, Count: 3},
+			expectError: false,
+			name:        "valid",
+		},
+		{
+			inputStatus: &sdk.TargetStatus{Ready: true},
+			expectError: false,
+			name:        "zero count",
+		},
+		{
+			inputStatus: nil,
+			expectError: true,
+			name:        "nil status",
+		},
+		{
+			inputStatus: &sdk.TargetStatus{Ready: true, Count: -1},
+			expectError: true,
+			name:        "negative count",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTargetStatus(tc.inputStatus)
+			assert.Equal(t, tc.expectError, err != nil, tc.name)
+		})
+	}
+}
+
+func Test_validateStrategyResult(t *testing.T) {
+	testCases := []struct {
+		inputEval   *sdk.ScalingCheckEvaluation
+		expectError bool
+		name        string
+	}{
+		{
+			inputEval:   &sdk.ScalingCheckEvaluation{Action: &sdk.ScalingAction{Direction: sdk.ScaleDirectionUp, Count: 5}},
+			expectError: false,
+			name:        "scale up",
+		},
+		{
+			inputEval:   &sdk.ScalingCheckEvaluation{Action: &sdk.ScalingAction{Direction: sdk.ScaleDirectionDown}},
+			expectError: false,
+			name:        "scale down to zero",
+		},
+		{
+			inputEval:   &sdk.ScalingCheckEvaluation{Action: &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone, Count: -1}},
+			expectError: false,
+			name:        "no change ignores count",
+		},
+		{
+			inputEval:   &sdk.ScalingCheckEvaluation{Status: sdk.StrategyStatusNoData},
+			expectError: false,
+			name:        "no data without action",
+		},
+		{
+			inputEval:   nil,
+			expectError: true,
+			name:        "nil evaluation",
+		},
+		{
+			inputEval:   &sdk.ScalingCheckEvaluation{},
+			expectError: true,
+			name:        "nil action",
+		},
+		{
+			inputEval:   &sdk.ScalingCheckEvaluation{Action: &sdk.ScalingAction{Direction: 7, Count: 5}},
+			expectError: true,
+			name:        "invalid direction",
+		},
+		{
+			inputEval:   &sdk.ScalingCheckEvaluation{Action: &sdk.ScalingAction{Direction: sdk.ScaleDirectionDown, Count: -3}},
+			expectError: true,
+			name:        "negative count",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateStrategyResult(tc.inputEval)
+			assert.Equal(t, tc.expectError, err != nil, tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_invalidPluginResponse(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputCount    int64
+		inputResult   func(eval *sdk.ScalingCheckEvaluation) *sdk.ScalingCheckEvaluation
+		expectedError string
+	}{
+		{
+			name:          "negative target count",
+			inputCount:    -2,
+			expectedError: "count -2 is negative",
+		},
+		{
+			name:          "nil strategy evaluation",
+			inputCount:    2,
+			inputResult:   func(*sdk.ScalingCheckEvaluation) *sdk.ScalingCheckEvaluation { return nil },
+			expectedError: "strategy returned no evaluation",
+		},
+		{
+			name:       "nil strategy action",
+			inputCount: 2,
+			inputResult: func(eval *sdk.ScalingCheckEvaluation) *sdk.ScalingCheckEvaluation {
+				eval.Action = nil
+				return eval
+			},
+			expectedError: "strategy returned no action",
+		},
+		{
+			name:       "invalid strategy direction",
+			inputCount: 2,
+			inputResult: func(eval *sdk.ScalingCheckEvaluation) *sdk.ScalingCheckEvaluation {
+				eval.Action.Direction = 42
+				return eval
+			},
+			expectedError: "strategy returned invalid direction 42",
+		},
+		{
+			name:       "negative strategy count",
+			inputCount: 2,
+			inputResult: func(eval *sdk.ScalingCheckEvaluation) *sdk.ScalingCheckEvaluation {
+				eval.Action.Direction, eval.Action.Count = sdk.ScaleDirectionDown, -4
+				return eval
+			},
+			expectedError: "count -4 is negative",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(tc.inputCount, 5)
+			w.strategy.result = tc.inputResult
+
+			err := w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(newTestPolicy(), nil))
+			if assert.Error(t, err, tc.name) {
+				assert.Contains(t, err.Error(), tc.expectedError, tc.name)
+			}
+
+			// A misbehaving plugin never leads to the target being scaled.
+			assert.Empty(t, w.target.scaledActions(), tc.name)
+		})
+	}
+}
+
+func TestPolicyDefaults_Apply(t *testing.T) {
+	testCases := []struct {
+		name            string
+		defaults        PolicyDefaults
+		inputMin        int64
+		inputMax        int64
+		inputMinOmitted bool
+		inputMaxOmitted bool
+		expectedMin     int64
+		expectedMax     int64
+		expectedErr     string
+	}{
+		{
+			name:        "no defaults",
+			defaults:    PolicyDefaults{},
+			inputMin:    1,
+			inputMax:    10,
+			expectedMin: 1,
+			expectedMax: 10,
+		},
+		{
+			name:        "policy values are authoritative",
+			defaults:    PolicyDefaults{Min: 2, Max: 20},
+			inputMin:    1,
+			inputMax:    10,
+			expectedMin: 1,
+			expectedMax: 10,
+		},
+		{
+			name:        "explicit zero values are kept",
+			defaults:    PolicyDefaults{Min: 2, Max: 20},
+			inputMin:    0,
+			inputMax:    0,
+			expectedMin: 0,
+			expectedMax: 0,
+		},
+		{
+			name:            "defaults applied to omitted values",
+			defaults:        PolicyDefaults{Min: 2, Max: 20},
+			inputMinOmitted: true,
+			inputMaxOmitted: true,
+			expectedMin:     2,
+			expectedMax:     20,
+		},
+		{
+			name:            "omitted min without default",
+			defaults:        PolicyDefaults{Max: 20},
+			inputMinOmitted: true,
+			inputMax:        10,
+			expectedMin:     0,
+			expectedMax:     10,
+		},
+		{
+			name:            "default min above policy max",
+			defaults:        PolicyDefaults{Min: 5},
+			inputMinOmitted: true,
+			inputMax:        3,
+			expectedMin:     0,
+			expectedMax:     3,
+		},
+		{
+			name:            "omitted max without default",
+			defaults:        PolicyDefaults{Min: 2},
+			inputMin:        1,
+			inputMaxOmitted: true,
+			expectedErr:     "policy max not set and no agent default max configured",
+		},
+		{
+			name:            "policy min above default max",
+			defaults:        PolicyDefaults{Max: 5},
+			inputMin:        8,
+			inputMaxOmitted: true,
+			expectedErr:     "policy min 8 is greater than the agent default max 5",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &sdk.ScalingPolicy{
+				Min:        tc.inputMin,
+				Max:        tc.inputMax,
+				MinOmitted: tc.inputMinOmitted,
+				MaxOmitted: tc.inputMaxOmitted,
+			}
+			actual, err := tc.defaults.Apply(hclog.NewNullLogger(), p)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr, tc.name)
+				return
+			}
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expectedMin, actual.Min, tc.name)
+			assert.Equal(t, tc.expectedMax, actual.Max, tc.name)
+
+			// The input policy must not be modified.
+			assert.Equal(t, tc.inputMin, p.Min, tc.name)
+			assert.Equal(t, tc.inputMax, p.Max, tc.name)
+		})
+	}
+}
+
+func Test_applyScheduledMin(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 30, 0, 0, time.UTC)
+
+	// business hours are active at now, while night is not.
+	business := &sdk.ScalingPolicyScheduledMin{Name: "business", Cron: "0 8 * * *", Duration: 10 * time.Hour, Min: 4}
+	peak := &sdk.ScalingPolicyScheduledMin{Name: "peak", Cron: "0 12 * * *", Duration: time.Hour, Min: 6}
+	night := &sdk.ScalingPolicyScheduledMin{Name: "night", Cron: "0 22 * * *", Duration: 8 * time.Hour, Min: 8}
+
+	testCases := []struct {
+		name        string
+		inputMin    int64
+		inputMax    int64
+		inputMins   []*sdk.ScalingPolicyScheduledMin
+		expectedMin int64
+	}{
+		{
+			name:        "no scheduled mins",
+			inputMin:    1,
+			inputMax:    10,
+			expectedMin: 1,
+		},
+		{
+			name:        "inactive scheduled min",
+			inputMin:    1,
+			inputMax:    10,
+			inputMins:   []*sdk.ScalingPolicyScheduledMin{night},
+			expectedMin: 1,
+		},
+		{
+			name:        "active scheduled min raises min",
+			inputMin:    1,
+			inputMax:    10,
+			inputMins:   []*sdk.ScalingPolicyScheduledMin{business, night},
+			expectedMin: 4,
+		},
+		{
+			name:        "highest active scheduled min",
+			inputMin:    1,
+			inputMax:    10,
+			inputMins:   []*sdk.ScalingPolicyScheduledMin{business, peak},
+			expectedMin: 6,
+		},
+		{
+			name:        "min is the hard floor",
+			inputMin:    5,
+			inputMax:    10,
+			inputMins:   []*sdk.ScalingPolicyScheduledMin{business},
+			expectedMin: 5,
+		},
+		{
+			name:        "max caps scheduled min",
+			inputMin:    1,
+			inputMax:    3,
+			inputMins:   []*sdk.ScalingPolicyScheduledMin{business},
+			expectedMin: 3,
+		},
+		{
+			name:        "invalid scheduled min ignored",
+			inputMin:    1,
+			inputMax:    10,
+			inputMins:   []*sdk.ScalingPolicyScheduledMin{{Name: "invalid", Cron: "every day", Duration: time.Hour, Min: 9}},
+			expectedMin: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &sdk.ScalingPolicy{ID: "id", Min: tc.inputMin, Max: tc.inputMax, ScheduledMins: tc.inputMins}
+
+			actual := applyScheduledMin(hclog.NewNullLogger(), p, now)
+			assert.Equal(t, tc.expectedMin, actual.Min, tc.name)
+			assert.Equal(t, tc.inputMax, actual.Max, tc.name)
+
+			// The policy shared with the handler is never modified.
+			assert.Equal(t, tc.inputMin, p.Min, tc.name)
+		})
+	}
+}
+
+func Test_isOverrun(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval time.Duration
+		duration time.Duration
+		expected bool
+	}{
+		{
+			name:     "within interval",
+			interval: 10 * time.Second,
+			duration: 5 * time.Second,
+			expected: false,
+		},
+		{
+			name:     "overrun interval",
+			interval: 5 * time.Second,
+			duration: 10 * time.Second,
+			expected: true,
+		},
+		{
+			name:     "unset interval",
+			interval: 0,
+			duration: 10 * time.Second,
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isOverrun(tc.interval, tc.duration), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_checkOverrun(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputElapsed  time.Duration
+		expectedCount int
+	}{
+		{
+			name:          "within interval",
+			inputElapsed:  5 * time.Second,
+			expectedCount: 0,
+		},
+		{
+			name:          "overrun interval",
+			inputElapsed:  15 * time.Second,
+			expectedCount: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			c := clock.NewFake(time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC))
+			w := newTestWorker(1, 1)
+			w.clock = c
+
+			p := newTestPolicy()
+			p.EvaluationInterval = 10 * time.Second
+			labels := []metrics.Label{{Name: "policy_id", Value: p.ID}}
+
+			start := c.Now()
+			c.Advance(tc.inputElapsed)
+			w.checkOverrun(w.logger, p, start, labels)
+
+			assert.Equal(t, tc.expectedCount,
+				counterValue(inm, "scale.evaluate.overrun_count;policy_id=test-policy"), tc.name)
+		})
+	}
+}
+
+func Test_policyLogArgs(t *testing.T) {
+	p := &sdk.ScalingPolicy{
+		ID:     "policy1",
+		Target: &sdk.ScalingPolicyTarget{Name: "nomad-target"},
+		Labels: map[string]string{"team": "platform", "env": "prod"},
+	}
+
+	expected := []interface{}{"policy_id", "policy1", "target", "nomad-target", "label.env", "prod", "label.team", "platform"}
+	assert.Equal(t, expected, policyLogArgs(p))
+
+	// The revision is included when the policy source provides one.
+	p.Revision = "42"
+	expected = []interface{}{"policy_id", "policy1", "target", "nomad-target", "policy_revision", "42", "label.env", "prod", "label.team", "platform"}
+	assert.Equal(t, expected, policyLogArgs(p))
+
+	// Labels named after the worker keys do not replace them.
+	p.Labels = map[string]string{"target": "other"}
+	expected = []interface{}{"policy_id", "policy1", "target", "nomad-target", "policy_revision", "42", "label.target", "other"}
+	assert.Equal(t, expected, policyLogArgs(p))
+}
+
+func Test_policyMetricLabels(t *testing.T) {
+	p := &sdk.ScalingPolicy{
+		Labels: map[string]string{"team": "platform", "owner": "jrasell"},
+	}
+
+	expected := []metrics.Label{{Name: "team", Value: "platform"}}
+	assert.Equal(t, expected, policyMetricLabels(p))
+}
+
+func Test_renderQuery(t *testing.T) {
+	p := &sdk.ScalingPolicy{
+		ID:     "policy-id",
+		Target: &sdk.ScalingPolicyTarget{Config: map[string]string{"Job": "web"}},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		count         int64
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "no template variables",
+			query:    "sum(rate(http_requests_total[1m]))",
+			count:    3,
+			expected: "sum(rate(http_requests_total[1m]))",
+		},
+		{
+			name:     "current count",
+			query:    "sum(rate(http_requests_total[1m])) / ${current_count}",
+			count:    3,
+			expected: "sum(rate(http_requests_total[1m])) / 3",
+		},
+		{
+			name:     "current count repeated",
+			query:    "${current_count} * ${current_count}",
+			count:    12,
+			expected: "12 * 12",
+		},
+		{
+			name:     "policy variables",
+			query:    `sum(rate(http_requests_total{job="${job}"}[1m])) / ${current_count}`,
+			count:    3,
+			expected: `sum(rate(http_requests_total{job="web"}[1m])) / 3`,
+		},
+		{
+			name:     "unknown variable",
+			query:    "avg(load) / ${unknown}",
+			count:    3,
+			expected: "avg(load) / ${unknown}",
+		},
+		{
+			name:          "unavailable variable",
+			query:         "avg(load{group=\"${group}\"})",
+			count:         3,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := renderQuery(p, tc.query, tc.count)
+			if tc.expectedError {
+				assert.Error(t, err, tc.name)
+				return
+			}
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expected, actual, tc.name)
+		})
+	}
+}
+
+func Test_renderQuery_policyFile(t *testing.T) {
+	testCases := []struct {
+		inputFile string
+		name      string
+	}{
+		{
+			inputFile: "./test-fixtures/query-template-policy.hcl",
+			name:      "hcl policy",
+		},
+		{
+			inputFile: "./test-fixtures/query-template-policy.json",
+			name:      "json policy",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src, err := ioutil.ReadFile(tc.inputFile)
+			assert.NoError(t, err, tc.name)
+
+			p := &sdk.ScalingPolicy{}
+			assert.NoError(t, file.Decode(tc.inputFile, src, p), tc.name)
+			assert.Len(t, p.Checks, 1, tc.name)
+
+			actual, err := renderQuery(p, p.Checks[0].Query, 4)
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, "sum(rate(http_requests_total[1m])) / 4", actual, tc.name)
+		})
+	}
+}
+
+// fakeLeadership is a Leadership which returns a fixed state.
+type fakeLeadership bool
+
+func (f fakeLeadership) IsLeader() bool { return bool(f) }
+
+func TestBaseWorker_isLeader(t *testing.T) {
+	testCases := []struct {
+		name       string
+		leadership Leadership
+		expected   bool
+	}{
+		{
+			name:       "leader election disabled",
+			leadership: nil,
+			expected:   true,
+		},
+		{
+			name:       "leader",
+			leadership: fakeLeadership(true),
+			expected:   true,
+		},
+		{
+			name:       "follower",
+			leadership: fakeLeadership(false),
+			expected:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &BaseWorker{leadership: tc.leadership}
+			assert.Equal(t, tc.expected, w.isLeader(), tc.name)
+		})
+	}
+}
+
+// fakePlugins dispenses fake plugins, keyed by plugin type and name.
+type fakePlugins map[string]interface{}
+
+func (f fakePlugins) Dispense(name, pluginType string) (manager.PluginInstance, error) {
+	p, ok := f[pluginType+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("plugin %s not found", name)
+	}
+	return fakePluginInstance{plugin: p}, nil
+}
+
+type fakePluginInstance struct {
+	plugin interface{}
+}
+
+func (f fakePluginInstance) Kill()               {}
+func (f fakePluginInstance) Plugin() interface{} { return f.plugin }
+
+// fakeTarget is a target.Target which records the scaling actions it receives.
+// Unless ignoreScale is set, the target count is updated to the action count.
+// If counts is set, successive status reads report its values in turn before
+// falling back to the status count. If scaleErr is set, scaling fails.
+type fakeTarget struct {
+	l           sync.Mutex
+	status      sdk.TargetStatus
+	statusErr   error
+	scaleErr    error
+	counts      []int64
+	ignoreScale bool
+	actions     []sdk.ScalingAction
+	configs     []map[string]string
+}
+
+func (f *fakeTarget) Scale(action sdk.ScalingAction, config map[string]string) error {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	if f.scaleErr != nil {
+		return f.scaleErr
+	}
+	f.actions = append(f.actions, action)
+	f.configs = append(f.configs, config)
+	if !f.ignoreScale {
+		f.status.Count = action.Count
+	}
+	return nil
+}
+
+func (f *fakeTarget) Status(_ map[string]string) (*sdk.TargetStatus, error) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	if f.statusErr != nil {
+		return nil, f.statusErr
+	}
+	status := f.status
+	if len(f.counts) > 0 {
+		status.Count, f.counts = f.counts[0], f.counts[1:]
+	}
+	return &status, nil
+}
+
+func (f *fakeTarget) scaledActions() []sdk.ScalingAction {
+	f.l.Lock()
+	defer f.l.Unlock()
+	return append([]sdk.ScalingAction(nil), f.actions...)
+}
+
+func (f *fakeTarget) PluginInfo() (*base.PluginInfo, error) { return nil, nil }
+func (f *fakeTarget) SetConfig(_ map[string]string) error   { return nil }
+
+// fakeAPM is an apm.APM which returns fixed metrics.
+type fakeAPM struct {
+	metrics sdk.TimestampedMetrics
+	err     error
+}
+
+func (f *fakeAPM) Query(_ string, _ sdk.TimeRange) (sdk.TimestampedMetrics, error) {
+	return f.metrics, f.err
+}
+
+func (f *fakeAPM) QueryMultiple(_ string, _ sdk.TimeRange) ([]sdk.TimestampedMetrics, error) {
+	return []sdk.TimestampedMetrics{f.metrics}, f.err
+}
+
+func (f *fakeAPM) PluginInfo() (*base.PluginInfo, error) { return nil, nil }
+func (f *fakeAPM) SetConfig(_ map[string]string) error   { return nil }
+
+// fakeStrategy is a strategy.Strategy which returns a fixed count, or the
+// NoData status if noData is set. It counts the number of times it is run
+// and records the metrics of the last run.
+type fakeStrategy struct {
+	count   int64
+	noData  bool
+	runs    int32
+	metrics sdk.TimestampedMetrics
+
+	// result, if set, replaces the evaluation returned by the strategy so
+	// tests can simulate a misbehaving plugin.
+	result func(eval *sdk.ScalingCheckEvaluation) *sdk.ScalingCheckEvaluation
+}
+
+func (f *fakeStrategy) Run(eval *sdk.ScalingCheckEvaluation, count int64) (*sdk.ScalingCheckEvaluation, error) {
+	atomic.AddInt32(&f.runs, 1)
+	f.metrics = eval.Metrics
+
+	if f.noData {
+		eval.Status = sdk.StrategyStatusNoData
+		return eval, nil
+	}
+
+	eval.Action.Count = f.count
+	switch {
+	case f.count > count:
+		eval.Action.Direction = sdk.ScaleDirectionUp
+	case f.count < count:
+		eval.Action.Direction = sdk.ScaleDirectionDown
+	default:
+		eval.Action.Direction = sdk.ScaleDirectionNone
+	}
+	if f.result != nil {
+		return f.result(eval), nil
+	}
+	return eval, nil
+}
+
+func (f *fakeStrategy) PluginInfo() (*base.PluginInfo, error) { return nil, nil }
+func (f *fakeStrategy) SetConfig(_ map[string]string) error   { return nil }
+
+// testWorker holds a BaseWorker using fake plugins, and the fakes so tests
+// can inspect them.
+type testWorker struct {
+	*BaseWorker
+	target   *fakeTarget
+	apm      *fakeAPM
+	strategy *fakeStrategy
+}
+
+// newTestWorker returns a worker whose plugins report the target at count and
+// a strategy which recommends desired.
+func newTestWorker(count, desired int64) *testWorker {
+	tw := &testWorker{
+		target:   &fakeTarget{status: sdk.TargetStatus{Ready: true, Count: count}},
+		apm:      &fakeAPM{metrics: sdk.TimestampedMetrics{{Timestamp: time.Now(), Value: 1}}},
+		strategy: &fakeStrategy{count: desired},
+	}
+
+	tw.BaseWorker = &BaseWorker{
+		logger: hclog.NewNullLogger(),
+		pluginManager: fakePlugins{
+			plugins.PluginTypeTarget + "/fake-target":     tw.target,
+			plugins.PluginTypeAPM + "/fake-apm":           tw.apm,
+			plugins.PluginTypeStrategy + "/fake-strategy": tw.strategy,
+		},
+		policyManager:   policy.NewManager(hclog.NewNullLogger(), nil, nil, 0, 0, 0),
+		multipleActions: MultipleActionsConservative,
+		clock:           clock.Real(),
+	}
+	return tw
+}
+
+// newTestPolicy returns a policy with a single check using the fake plugins.
+func newTestPolicy() *sdk.ScalingPolicy {
+	return &sdk.ScalingPolicy{
+		ID:      "test-policy",
+		Min:     1,
+		Max:     10,
+		Enabled: true,
+		Target:  &sdk.ScalingPolicyTarget{Name: "fake-target", Config: map[string]string{}},
+		Checks: []*sdk.ScalingPolicyCheck{{
+			Name:     "check",
+			Source:   "fake-apm",
+			Query:    "query",
+			Strategy: &sdk.ScalingPolicyStrategy{Name: "fake-strategy", Config: map[string]string{}},
+		}},
+	}
+}
+
+func Test_isDeferral(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputErr      error
+		expectedDefer bool
+	}{
+		{name: "no error", inputErr: nil},
+		{name: "failure", inputErr: fmt.Errorf("failed to scale")},
+		{name: "target not ready", inputErr: errTargetNotReady, expectedDefer: true},
+		{name: "not leader", inputErr: errNotLeader, expectedDefer: true},
+		{name: "deployment", inputErr: errDeploymentInProgress, expectedDefer: true},
+		{name: "target not allowed", inputErr: errTargetNotAllowed, expectedDefer: true},
+		{name: "scale hook", inputErr: errScaleHookFailed, expectedDefer: true},
+		{name: "throttled", inputErr: errScaleThrottled, expectedDefer: true},
+		{name: "maintenance", inputErr: errMaintenanceHold, expectedDefer: true},
+		{name: "policy conflict", inputErr: errPolicyConflict, expectedDefer: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedDefer, isDeferral(tc.inputErr), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_reconcileBounds(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputCount      int64
+		inputReady      bool
+		inputLeadership Leadership
+		expectedScaled  bool
+		expectedErr     error
+		expectedCount   int64
+	}{
+		{
+			name:           "below min",
+			inputCount:     0,
+			inputReady:     true,
+			expectedScaled: true,
+			expectedCount:  1,
+		},
+		{
+			name:          "within bounds",
+			inputCount:    5,
+			inputReady:    true,
+			expectedCount: 5,
+		},
+		{
+			name:          "target not ready",
+			inputCount:    0,
+			inputReady:    false,
+			expectedErr:   errTargetNotReady,
+			expectedCount: 0,
+		},
+		{
+			name:            "not leader",
+			inputCount:      0,
+			inputReady:      true,
+			inputLeadership: fakeLeadership(false),
+			expectedErr:     errNotLeader,
+			expectedCount:   0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(tc.inputCount, tc.inputCount)
+			w.target.status.Ready = tc.inputReady
+			w.leadership = tc.inputLeadership
+
+			scaled, err := w.reconcileBounds(context.Background(), w.logger, newTestPolicy(), nil)
+			assert.Equal(t, tc.expectedErr, err, tc.name)
+			assert.Equal(t, tc.expectedScaled, scaled, tc.name)
+			assert.Equal(t, tc.expectedCount, w.target.status.Count, tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_pinned(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputCount     int64
+		expectedScaled bool
+	}{
+		{
+			name:           "count drifted from pinned count",
+			inputCount:     5,
+			expectedScaled: true,
+		},
+		{
+			name:           "count at pinned count",
+			inputCount:     3,
+			expectedScaled: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(tc.inputCount, 8)
+
+			p := newTestPolicy()
+			p.Min, p.Max = 3, 3
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+
+			// The checks are skipped entirely.
+			assert.Equal(t, int32(0), atomic.LoadInt32(&w.strategy.runs), tc.name)
+			assert.Equal(t, int64(3), w.target.status.Count, tc.name)
+			assert.Equal(t, tc.expectedScaled, len(w.target.scaledActions()) == 1, tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_scheduledMin(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputCron     string
+		inputNow      time.Time
+		expectedCount int64
+	}{
+		{
+			name:          "scheduled min active",
+			inputCron:     "0 12 * * *",
+			inputNow:      time.Date(2020, 10, 1, 12, 30, 0, 0, time.UTC),
+			expectedCount: 4,
+		},
+		{
+			name:          "scheduled min inactive",
+			inputCron:     "0 12 * * *",
+			inputNow:      time.Date(2020, 10, 1, 14, 0, 0, 0, time.UTC),
+			expectedCount: 1,
+		},
+		{
+			name:          "scheduled min never active",
+			inputCron:     "0 0 1 1 * 2099",
+			inputNow:      time.Date(2020, 10, 1, 12, 30, 0, 0, time.UTC),
+			expectedCount: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(2, 1)
+			w.clock = clock.NewFake(tc.inputNow)
+
+			// The strategy wants to scale down to the hard Min, which the
+			// active scheduled min prevents.
+			p := newTestPolicy()
+			p.ScheduledMins = []*sdk.ScalingPolicyScheduledMin{{Name: "peak", Cron: tc.inputCron, Duration: time.Hour, Min: 4}}
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+			assert.Equal(t, tc.expectedCount, w.target.status.Count, tc.name)
+			assert.Equal(t, int64(1), p.Min, tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_outOfBoundsAction(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputAction        string
+		inputCount         int64
+		inputDesired       int64
+		expectedCount      int64
+		expectedRuns       int32
+		expectedSuppressed int
+	}{
+		{
+			name:          "default corrects when strategy recommends no change",
+			inputCount:    15,
+			inputDesired:  15,
+			expectedCount: 10,
+			expectedRuns:  1,
+		},
+		{
+			name:          "correct skips the checks",
+			inputAction:   sdk.OutOfBoundsActionCorrect,
+			inputCount:    15,
+			inputDesired:  20,
+			expectedCount: 10,
+			expectedRuns:  0,
+		},
+		{
+			name:          "correct within bounds",
+			inputAction:   sdk.OutOfBoundsActionCorrect,
+			inputCount:    5,
+			inputDesired:  7,
+			expectedCount: 7,
+			expectedRuns:  1,
+		},
+		{
+			name:               "strategy recommends no change",
+			inputAction:        sdk.OutOfBoundsActionStrategy,
+			inputCount:         15,
+			inputDesired:       15,
+			expectedCount:      15,
+			expectedRuns:       1,
+			expectedSuppressed: 1,
+		},
+		{
+			name:          "strategy scales toward bounds",
+			inputAction:   sdk.OutOfBoundsActionStrategy,
+			inputCount:    15,
+			inputDesired:  12,
+			expectedCount: 10,
+			expectedRuns:  1,
+		},
+		{
+			name:               "strategy scales away from bounds",
+			inputAction:        sdk.OutOfBoundsActionStrategy,
+			inputCount:         15,
+			inputDesired:       20,
+			expectedCount:      15,
+			expectedRuns:       1,
+			expectedSuppressed: 1,
+		},
+		{
+			name:               "wait outside bounds",
+			inputAction:        sdk.OutOfBoundsActionWait,
+			inputCount:         15,
+			inputDesired:       12,
+			expectedCount:      15,
+			expectedRuns:       1,
+			expectedSuppressed: 1,
+		},
+		{
+			name:          "wait within bounds",
+			inputAction:   sdk.OutOfBoundsActionWait,
+			inputCount:    5,
+			inputDesired:  7,
+			expectedCount: 7,
+			expectedRuns:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+			w := newTestWorker(tc.inputCount, tc.inputDesired)
+
+			p := newTestPolicy()
+			p.OutOfBoundsAction = tc.inputAction
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+
+			assert.Equal(t, tc.expectedCount, w.target.status.Count, tc.name)
+			assert.Equal(t, tc.expectedRuns, atomic.LoadInt32(&w.strategy.runs), tc.name)
+
+			key := "scale.suppressed_count;policy_id=test-policy;reason=" + policy.SuppressionReasonOutOfBounds
+			assert.Equal(t, tc.expectedSuppressed, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func Test_reversesDirection(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputAction    *sdk.ScalingAction
+		inputCount     int64
+		expectedOutput bool
+	}{
+		{
+			name:           "scale up",
+			inputAction:    &sdk.ScalingAction{Count: 5, Direction: sdk.ScaleDirectionUp},
+			inputCount:     3,
+			expectedOutput: false,
+		},
+		{
+			name:           "scale up capped below count",
+			inputAction:    &sdk.ScalingAction{Count: 10, Direction: sdk.ScaleDirectionUp},
+			inputCount:     15,
+			expectedOutput: true,
+		},
+		{
+			name:           "scale down capped above count",
+			inputAction:    &sdk.ScalingAction{Count: 1, Direction: sdk.ScaleDirectionDown},
+			inputCount:     0,
+			expectedOutput: true,
+		},
+		{
+			name:           "no direction",
+			inputAction:    &sdk.ScalingAction{Count: 1, Direction: sdk.ScaleDirectionNone},
+			inputCount:     0,
+			expectedOutput: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, reversesDirection(tc.inputAction, tc.inputCount), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_transforms(t *testing.T) {
+	w := newTestWorker(3, 5)
+
+	p := newTestPolicy()
+	p.Checks[0].Transforms = []*sdk.ScalingPolicyTransform{
+		{Name: policy.TransformScale, Config: map[string]string{"factor": "100"}},
+	}
+	assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)))
+
+	// The strategy receives the transformed metrics, while the metrics
+	// returned by the APM are left untouched.
+	assert.Len(t, w.strategy.metrics, 1)
+	assert.Equal(t, float64(100), w.strategy.metrics[0].Value)
+	assert.Equal(t, float64(1), w.apm.metrics[0].Value)
+
+	// Invalid transforms fail the check.
+	w = newTestWorker(3, 5)
+	p.Checks[0].Transforms = []*sdk.ScalingPolicyTransform{{Name: "round"}}
+	assert.Error(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&w.strategy.runs))
+}
+
+func TestBaseWorker_handlePolicy_targetCapacity(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputCount     int64
+		inputDesired   int64
+		inputCapacity  string
+		expectedScaled bool
+		expectedCount  int64
+		expectedCapped bool
+	}{
+		{
+			name:           "no capacity reported",
+			inputCount:     2,
+			inputDesired:   8,
+			expectedScaled: true,
+			expectedCount:  8,
+		},
+		{
+			name:           "scale up within capacity",
+			inputCount:     2,
+			inputDesired:   5,
+			inputCapacity:  "6",
+			expectedScaled: true,
+			expectedCount:  5,
+		},
+		{
+			name:           "scale up limited by capacity",
+			inputCount:     2,
+			inputDesired:   8,
+			inputCapacity:  "6",
+			expectedScaled: true,
+			expectedCount:  6,
+			expectedCapped: true,
+		},
+		{
+			name:          "capacity below current count",
+			inputCount:    4,
+			inputDesired:  8,
+			inputCapacity: "3",
+		},
+		{
+			name:           "scale down ignores capacity",
+			inputCount:     8,
+			inputDesired:   5,
+			inputCapacity:  "3",
+			expectedScaled: true,
+			expectedCount:  5,
+		},
+		{
+			name:           "invalid capacity",
+			inputCount:     2,
+			inputDesired:   8,
+			inputCapacity:  "lots",
+			expectedScaled: true,
+			expectedCount:  8,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(tc.inputCount, tc.inputDesired)
+			if tc.inputCapacity != "" {
+				w.target.status.Meta = map[string]string{sdk.TargetStatusMetaKeyCapacity: tc.inputCapacity}
+			}
+
+			p := newTestPolicy()
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+
+			actions := w.target.scaledActions()
+			if !tc.expectedScaled {
+				assert.Empty(t, actions, tc.name)
+				return
+			}
+
+			assert.Len(t, actions, 1, tc.name)
+			assert.Equal(t, tc.expectedCount, actions[0].Count, tc.name)
+
+			_, capped := actions[0].Meta["nomad_autoscaler.count.capped"]
+			assert.Equal(t, tc.expectedCapped, capped, tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_minHealthyPercentage(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputCount    int64
+		inputDesired  int64
+		inputPercent  float64
+		inputHealthy  string
+		expectedCount int64
+	}{
+		{
+			name:          "disabled",
+			inputCount:    8,
+			inputDesired:  4,
+			inputHealthy:  "2",
+			expectedCount: 4,
+		},
+		{
+			name:          "scale down with enough healthy instances",
+			inputCount:    8,
+			inputDesired:  4,
+			inputPercent:  75,
+			inputHealthy:  "6",
+			expectedCount: 4,
+		},
+		{
+			name:         "scale down held with too few healthy instances",
+			inputCount:   8,
+			inputDesired: 4,
+			inputPercent: 75,
+			inputHealthy: "5",
+		},
+		{
+			name:          "scale up with too few healthy instances",
+			inputCount:    4,
+			inputDesired:  8,
+			inputPercent:  75,
+			inputHealthy:  "1",
+			expectedCount: 8,
+		},
+		{
+			name:          "scale down to max with too few healthy instances",
+			inputCount:    12,
+			inputDesired:  4,
+			inputPercent:  75,
+			inputHealthy:  "1",
+			expectedCount: 10,
+		},
+		{
+			name:          "healthy count not reported",
+			inputCount:    8,
+			inputDesired:  4,
+			inputPercent:  75,
+			expectedCount: 4,
+		},
+		{
+			name:          "invalid healthy count",
+			inputCount:    8,
+			inputDesired:  4,
+			inputPercent:  75,
+			inputHealthy:  "most",
+			expectedCount: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(tc.inputCount, tc.inputDesired)
+			if tc.inputHealthy != "" {
+				w.target.status.Meta = map[string]string{sdk.TargetStatusMetaKeyHealthyCount: tc.inputHealthy}
+			}
+
+			p := newTestPolicy()
+			p.MinHealthyPercentage = tc.inputPercent
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+
+			actions := w.target.scaledActions()
+			if tc.expectedCount == 0 {
+				assert.Empty(t, actions, tc.name)
+				return
+			}
+
+			assert.Len(t, actions, 1, tc.name)
+			assert.Equal(t, tc.expectedCount, actions[0].Count, tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_deferDuringDeployment(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputDefer         bool
+		inputDeployment    string
+		inputPinned        bool
+		expectedScaled     int
+		expectedSuppressed int
+	}{
+		{
+			name:               "deployment in progress",
+			inputDefer:         true,
+			inputDeployment:    "true",
+			expectedSuppressed: 1,
+		},
+		{
+			name:               "deployment in progress with pinned count",
+			inputDefer:         true,
+			inputDeployment:    "true",
+			inputPinned:        true,
+			expectedSuppressed: 1,
+		},
+		{
+			name:            "deployment completed",
+			inputDefer:      true,
+			inputDeployment: "false",
+			expectedScaled:  1,
+		},
+		{
+			name:           "deployment status not reported",
+			inputDefer:     true,
+			expectedScaled: 1,
+		},
+		{
+			name:            "deferral disabled",
+			inputDeployment: "true",
+			expectedScaled:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, 5)
+			if tc.inputDeployment != "" {
+				w.target.status.Meta = map[string]string{sdk.TargetStatusMetaKeyDeploymentActive: tc.inputDeployment}
+			}
+
+			p := newTestPolicy()
+			p.DeferDuringDeployment = tc.inputDefer
+			if tc.inputPinned {
+				p.Min, p.Max = 3, 3
+			}
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			key := "scale.suppressed_count;policy_id=test-policy;reason=" + policy.SuppressionReasonDeployment
+			assert.Equal(t, tc.expectedSuppressed, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_targetAccess(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputAccess        *TargetAccess
+		inputPinned        bool
+		expectedScaled     int
+		expectedSuppressed int
+	}{
+		{
+			name:           "access not configured",
+			inputAccess:    nil,
+			expectedScaled: 1,
+		},
+		{
+			name:           "target allowed",
+			inputAccess:    NewTargetAccess([]string{"fake-*"}, nil),
+			expectedScaled: 1,
+		},
+		{
+			name:               "target not in allow list",
+			inputAccess:        NewTargetAccess([]string{"nomad-target"}, nil),
+			expectedSuppressed: 1,
+		},
+		{
+			name:               "target denied",
+			inputAccess:        NewTargetAccess([]string{"fake-*"}, []string{"fake-target"}),
+			expectedSuppressed: 1,
+		},
+		{
+			name:               "target denied with pinned count",
+			inputAccess:        NewTargetAccess(nil, []string{"fake-target"}),
+			inputPinned:        true,
+			expectedSuppressed: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, 5)
+			w.targetAccess = tc.inputAccess
+
+			p := newTestPolicy()
+			if tc.inputPinned {
+				p.Min, p.Max = 3, 3
+			}
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			key := "scale.suppressed_count;policy_id=test-policy;reason=" + policy.SuppressionReasonTargetNotAllowed
+			assert.Equal(t, tc.expectedSuppressed, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_advisory(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputAdvisory     bool
+		inputDesired      int64
+		inputPinned       bool
+		expectedScaled    int
+		expectedActionKey string
+		expectedGauge     float32
+	}{
+		{
+			name:           "not advisory",
+			inputDesired:   5,
+			expectedScaled: 1,
+		},
+		{
+			name:              "advisory scale up",
+			inputAdvisory:     true,
+			inputDesired:      5,
+			expectedActionKey: "policy_id=test-policy;direction=up;reason_code=strategy;advisory=true",
+			expectedGauge:     5,
+		},
+		{
+			name:          "advisory no change",
+			inputAdvisory: true,
+			inputDesired:  2,
+			expectedGauge: 2,
+		},
+		{
+			name:              "advisory pinned count",
+			inputAdvisory:     true,
+			inputPinned:       true,
+			expectedActionKey: "policy_id=test-policy;direction=up;reason_code=pinned;advisory=true",
+			expectedGauge:     3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, tc.inputDesired)
+
+			p := newTestPolicy()
+			p.Advisory = tc.inputAdvisory
+			if tc.inputPinned {
+				p.Min, p.Max = 3, 3
+			}
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			if tc.expectedActionKey != "" {
+				assert.Equal(t, 1, counterValue(inm, "scale.recommendation.action_count;"+tc.expectedActionKey), tc.name)
+			}
+
+			key := "scale.recommendation.count;policy_id=test-policy;target_name=fake-target;advisory=true"
+			assert.Equal(t, tc.expectedGauge, gaugeValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_scaleHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scale hook tests use a POSIX shell")
+	}
+
+	testCases := []struct {
+		name               string
+		inputPre           []string
+		inputPost          []string
+		inputPinned        bool
+		expectedScaled     int
+		expectedSuppressed int
+	}{
+		{
+			name:           "pre-scale hook succeeds",
+			inputPre:       []string{"sh", "-c", "exit 0"},
+			expectedScaled: 1,
+		},
+		{
+			name:               "pre-scale hook fails",
+			inputPre:           []string{"sh", "-c", "exit 1"},
+			expectedSuppressed: 1,
+		},
+		{
+			name:               "pre-scale hook fails with pinned count",
+			inputPre:           []string{"sh", "-c", "exit 1"},
+			inputPinned:        true,
+			expectedSuppressed: 1,
+		},
+		{
+			name:           "post-scale hook fails",
+			inputPost:      []string{"sh", "-c", "exit 1"},
+			expectedScaled: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, 5)
+			w.scaleHooks = NewScaleHooks(tc.inputPre, tc.inputPost, 10*time.Second)
+
+			p := newTestPolicy()
+			if tc.inputPinned {
+				p.Min, p.Max = 3, 3
+			}
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			key := "scale.suppressed_count;policy_id=test-policy;reason=" + policy.SuppressionReasonScaleHook
+			assert.Equal(t, tc.expectedSuppressed, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_scaleThrottle(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputThrottle      *ScaleThrottle
+		inputPinned        bool
+		expectedScaled     int
+		expectedSuppressed int
+	}{
+		{
+			name:           "throttle not configured",
+			inputThrottle:  nil,
+			expectedScaled: 2,
+		},
+		{
+			name:           "within burst",
+			inputThrottle:  NewScaleThrottle(1, 2),
+			expectedScaled: 2,
+		},
+		{
+			name:               "burst exhausted",
+			inputThrottle:      NewScaleThrottle(1, 1),
+			expectedScaled:     1,
+			expectedSuppressed: 1,
+		},
+		{
+			name:               "burst exhausted with pinned count",
+			inputThrottle:      NewScaleThrottle(1, 1),
+			inputPinned:        true,
+			expectedScaled:     1,
+			expectedSuppressed: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, 5)
+			w.target.ignoreScale = true
+			w.scaleThrottle = tc.inputThrottle
+
+			p := newTestPolicy()
+			if tc.inputPinned {
+				p.Min, p.Max = 3, 3
+			}
+			for i := 0; i < 2; i++ {
+				assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+			}
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			key := "scale.suppressed_count;policy_id=test-policy;reason=" + policy.SuppressionReasonThrottled
+			assert.Equal(t, tc.expectedSuppressed, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_maintenance(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputCondition     string
+		inputPinned        bool
+		expectedScaled     int
+		expectedSuppressed int
+	}{
+		{
+			name:           "allow",
+			inputCondition: "allow",
+			expectedScaled: 1,
+		},
+		{
+			name:               "hold",
+			inputCondition:     "hold",
+			expectedSuppressed: 1,
+		},
+		{
+			name:               "hold with pinned count",
+			inputCondition:     "hold",
+			inputPinned:        true,
+			expectedSuppressed: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, 5)
+			w.target.ignoreScale = true
+			w.maintenanceGate = newMaintenanceGate(hclog.NewNullLogger(), "test", time.Minute, MaintenanceHold,
+				func(context.Context) (string, error) { return tc.inputCondition, nil })
+
+			p := newTestPolicy()
+			if tc.inputPinned {
+				p.Min, p.Max = 3, 3
+			}
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			key := "scale.suppressed_count;policy_id=test-policy;reason=" + policy.SuppressionReasonMaintenance
+			assert.Equal(t, tc.expectedSuppressed, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_desiredCountGap(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputCount      int64
+		inputDesired    int64
+		inputMaxStep    int64
+		inputDeny       []string
+		inputLeadership Leadership
+		expectedGap     float32
+	}{
+		{
+			name:         "desired count applied",
+			inputCount:   2,
+			inputDesired: 5,
+		},
+		{
+			name:         "capped to max",
+			inputCount:   2,
+			inputDesired: 15,
+			expectedGap:  5,
+		},
+		{
+			name:         "capped to min",
+			inputCount:   5,
+			inputDesired: 0,
+			expectedGap:  -1,
+		},
+		{
+			name:         "limited by scale step",
+			inputCount:   2,
+			inputDesired: 5,
+			inputMaxStep: 1,
+			expectedGap:  2,
+		},
+		{
+			name:         "suppressed by guard",
+			inputCount:   2,
+			inputDesired: 5,
+			inputDeny:    []string{"fake-target"},
+			expectedGap:  3,
+		},
+		{
+			name:            "not reported by followers",
+			inputCount:      2,
+			inputDesired:    5,
+			inputLeadership: fakeLeadership(false),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(tc.inputCount, tc.inputDesired)
+			w.target.ignoreScale = true
+			w.targetAccess = NewTargetAccess(nil, tc.inputDeny)
+			w.leadership = tc.inputLeadership
+
+			p := newTestPolicy()
+			p.MaxScaleStep = tc.inputMaxStep
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+
+			key := "scale.desired_count_gap;policy_id=test-policy;target_name=fake-target"
+			assert.Equal(t, tc.expectedGap, gaugeValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_decisionEvents(t *testing.T) {
+	w := newTestWorker(2, 5)
+	w.target.ignoreScale = true
+	w.scaleThrottle = NewScaleThrottle(1, 1)
+	w.decisionSink = NewDecisionSink(hclog.NewNullLogger(), 10, &fakePublisher{})
+
+	p := newTestPolicy()
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)))
+	}
+
+	// The first action scales the target, while the second is throttled.
+	events := queuedEvents(t, w.decisionSink)
+	assert.Len(t, events, 2)
+	assert.Equal(t, DecisionOutcomeScaled, events[0].Outcome)
+	assert.Equal(t, DecisionOutcomeSuppressed, events[1].Outcome)
+	assert.Equal(t, policy.SuppressionReasonThrottled, events[1].Reason)
+
+	for _, event := range events {
+		assert.Equal(t, "test-policy", event.PolicyID)
+		assert.Equal(t, "fake-target", event.Target)
+		assert.Equal(t, "up", event.Direction)
+		assert.Equal(t, int64(2), event.Count)
+		assert.Equal(t, int64(5), event.DesiredCount)
+	}
+}
+
+func TestBaseWorker_handlePolicy_metricUnit(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputUnit     string
+		expectedValue string
+	}{
+		{
+			name:          "raw value",
+			expectedValue: "536870912",
+		},
+		{
+			name:          "bytes",
+			inputUnit:     sdk.MetricUnitBytes,
+			expectedValue: "512 MiB",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(2, 5)
+			w.apm.metrics = sdk.TimestampedMetrics{{Timestamp: time.Now(), Value: 536870912}}
+			w.decisionSink = NewDecisionSink(hclog.NewNullLogger(), 10, &fakePublisher{})
+
+			p := newTestPolicy()
+			p.MetricUnit = tc.inputUnit
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+
+			// The strategy is still given the raw value.
+			if assert.Len(t, w.strategy.metrics, 1, tc.name) {
+				assert.Equal(t, float64(536870912), w.strategy.metrics[0].Value, tc.name)
+			}
+
+			events := queuedEvents(t, w.decisionSink)
+			if assert.Len(t, events, 1, tc.name) {
+				assert.Equal(t, DecisionOutcomeScaled, events[0].Outcome, tc.name)
+				assert.Equal(t, tc.expectedValue, events[0].MetricValue, tc.name)
+			}
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_targetLocks(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputOtherScaledAt time.Duration
+		expectedScaled     int
+		expectedSuppressed int
+	}{
+		{
+			name:           "not scaled by other policy",
+			expectedScaled: 1,
+		},
+		{
+			name:               "scaled by other policy before evaluation",
+			inputOtherScaledAt: -time.Hour,
+			expectedScaled:     1,
+		},
+		{
+			name:               "scaled by other policy during evaluation",
+			inputOtherScaledAt: time.Hour,
+			expectedSuppressed: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, 5)
+			w.targetLocks = NewTargetLocks()
+
+			p := newTestPolicy()
+
+			// Record another policy scaling the same target.
+			if tc.inputOtherScaledAt != 0 {
+				other := newTestPolicy()
+				other.ID = "other-policy"
+
+				w.targetLocks.now = func() time.Time { return time.Now().Add(tc.inputOtherScaledAt) }
+				lease, err := w.targetLocks.Acquire(context.Background(), other)
+				assert.NoError(t, err, tc.name)
+				lease.Release(true)
+			}
+
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			key := "scale.suppressed_count;policy_id=test-policy;reason=" + policy.SuppressionReasonTargetConflict
+			assert.Equal(t, tc.expectedSuppressed, counterValue(inm, key), tc.name)
+
+			// The lock is released once the evaluation completes.
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			lease, err := w.targetLocks.Acquire(ctx, p)
+			assert.NoError(t, err, tc.name)
+			lease.Release(false)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_shadowTarget(t *testing.T) {
+	testCases := []struct {
+		name                string
+		inputShadowConfig   map[string]string
+		inputShadowErr      error
+		inputShadowMissing  bool
+		inputPinned         bool
+		expectedCount       int64
+		expectedShadowCount []int64
+		expectedErrors      int
+	}{
+		{
+			name:                "shadow scaled",
+			inputShadowConfig:   map[string]string{},
+			expectedCount:       5,
+			expectedShadowCount: []int64{5},
+		},
+		{
+			name:                "shadow dry-run",
+			inputShadowConfig:   map[string]string{"dry-run": "true"},
+			expectedCount:       5,
+			expectedShadowCount: []int64{sdk.StrategyActionMetaValueDryRunCount},
+		},
+		{
+			name:                "shadow scaled with pinned count",
+			inputShadowConfig:   map[string]string{},
+			inputPinned:         true,
+			expectedCount:       3,
+			expectedShadowCount: []int64{3},
+		},
+		{
+			name:              "shadow fails",
+			inputShadowConfig: map[string]string{},
+			inputShadowErr:    fmt.Errorf("shadow unavailable"),
+			expectedCount:     5,
+			expectedErrors:    1,
+		},
+		{
+			name:               "shadow plugin missing",
+			inputShadowConfig:  map[string]string{},
+			inputShadowMissing: true,
+			expectedCount:      5,
+			expectedErrors:     1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, 5)
+			shadow := &fakeTarget{scaleErr: tc.inputShadowErr}
+			if !tc.inputShadowMissing {
+				w.pluginManager.(fakePlugins)[plugins.PluginTypeTarget+"/shadow-target"] = shadow
+			}
+
+			p := newTestPolicy()
+			p.ShadowTarget = &sdk.ScalingPolicyTarget{Name: "shadow-target", Config: tc.inputShadowConfig}
+			if tc.inputPinned {
+				p.Min, p.Max = 3, 3
+			}
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+
+			// The policy target is always scaled, regardless of the shadow.
+			actions := w.target.scaledActions()
+			assert.Len(t, actions, 1, tc.name)
+			assert.Equal(t, tc.expectedCount, actions[0].Count, tc.name)
+			assert.NotContains(t, actions[0].Meta, "nomad_autoscaler.dry_run", tc.name)
+
+			var shadowCounts []int64
+			for _, a := range shadow.scaledActions() {
+				shadowCounts = append(shadowCounts, a.Count)
+			}
+			assert.Equal(t, tc.expectedShadowCount, shadowCounts, tc.name)
+
+			key := "scale.shadow.error_count;plugin_name=shadow-target;policy_id=test-policy"
+			assert.Equal(t, tc.expectedErrors, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_invalidMetric(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputValue         float64
+		inputMin           *float64
+		expectedScaled     int
+		expectedSuppressed int
+	}{
+		{
+			name:           "valid metric",
+			inputValue:     1,
+			expectedScaled: 1,
+		},
+		{
+			name:               "NaN metric",
+			inputValue:         math.NaN(),
+			expectedSuppressed: 1,
+		},
+		{
+			name:               "negative metric with policy min",
+			inputValue:         -1,
+			inputMin:           ptr.Float64ToPtr(0),
+			expectedSuppressed: 1,
+		},
+		{
+			name:           "negative metric without policy min",
+			inputValue:     -1,
+			expectedScaled: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, 5)
+			w.apm.metrics = sdk.TimestampedMetrics{{Timestamp: time.Now(), Value: tc.inputValue}}
+
+			p := newTestPolicy()
+			p.MetricMin = tc.inputMin
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			// The strategy is not run with invalid metrics.
+			assert.Equal(t, int32(tc.expectedScaled), atomic.LoadInt32(&w.strategy.runs), tc.name)
+
+			key := "scale.suppressed_count;policy_id=test-policy;reason=" + policy.SuppressionReasonInvalidMetric
+			assert.Equal(t, tc.expectedSuppressed, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_reasonCode(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputCount         int64
+		inputPinned        bool
+		expectedDirection  string
+		expectedReasonCode sdk.ReasonCode
+	}{
+		{
+			name:               "strategy without reason code",
+			inputCount:         2,
+			expectedDirection:  "up",
+			expectedReasonCode: sdk.ReasonCodeStrategy,
+		},
+		{
+			name:               "pinned count",
+			inputCount:         5,
+			inputPinned:        true,
+			expectedDirection:  "down",
+			expectedReasonCode: sdk.ReasonCodePinned,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(tc.inputCount, 8)
+
+			p := newTestPolicy()
+			if tc.inputPinned {
+				p.Min, p.Max = 3, 3
+			}
+			assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)), tc.name)
+
+			actions := w.target.scaledActions()
+			assert.Len(t, actions, 1, tc.name)
+			assert.Equal(t, tc.expectedReasonCode, actions[0].ReasonCode, tc.name)
+			assert.Equal(t, string(tc.expectedReasonCode), actions[0].Meta["nomad_autoscaler.reason_code"], tc.name)
+
+			key := "scale.action_count;policy_id=test-policy;direction=" + tc.expectedDirection +
+				";reason_code=" + string(tc.expectedReasonCode)
+			assert.Equal(t, 1, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+// newTestSink replaces the global metrics sink with an in-memory sink, so the
+// metrics emitted by the test can be inspected.
+func newTestSink(t *testing.T) *metrics.InmemSink {
+	inm := metrics.NewInmemSink(10*time.Second, time.Minute)
+
+	cfg := metrics.DefaultConfig("")
+	cfg.EnableHostname = false
+	cfg.EnableRuntimeMetrics = false
+
+	_, err := metrics.NewGlobal(cfg, inm)
+	assert.NoError(t, err)
+	return inm
+}
+
+// counterValue returns the value of the counter with the key, which includes
+// the counter labels.
+func counterValue(inm *metrics.InmemSink, key string) int {
+	var count int
+	for _, interval := range inm.Data() {
+		interval.RLock()
+		if c, ok := interval.Counters[key]; ok {
+			count += c.Count
+		}
+		interval.RUnlock()
+	}
+	return count
+}
+
+// gaugeValue returns the last value of the gauge with the key, which includes
+// the gauge labels.
+func gaugeValue(inm *metrics.InmemSink, key string) float32 {
+	var value float32
+	for _, interval := range inm.Data() {
+		interval.RLock()
+		if g, ok := interval.Gauges[key]; ok {
+			value = g.Value
+		}
+		interval.RUnlock()
+	}
+	return value
+}
+
+func TestBaseWorker_verifyScale(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		inputCount           int64
+		inputStatusErr       error
+		expectedConverged    int
+		expectedNotConverged int
+	}{
+		{
+			name:              "converged",
+			inputCount:        5,
+			expectedConverged: 1,
+		},
+		{
+			name:                 "not converged",
+			inputCount:           3,
+			expectedNotConverged: 1,
+		},
+		{
+			name:           "status error",
+			inputCount:     5,
+			inputStatusErr: fmt.Errorf("unavailable"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(tc.inputCount, tc.inputCount)
+			w.target.statusErr = tc.inputStatusErr
+
+			p := newTestPolicy()
+			p.VerifyScaleAfter = time.Millisecond
+			labels := []metrics.Label{{Name: "policy_id", Value: p.ID}}
+
+			w.verifyScale(context.Background(), w.logger, p, 2, &sdk.ScalingAction{Count: 5}, labels)
+
+			assert.Equal(t, tc.expectedConverged,
+				counterValue(inm, "scale.verify.converged_count;policy_id=test-policy"), tc.name)
+			assert.Equal(t, tc.expectedNotConverged,
+				counterValue(inm, "scale.verify.not_converged_count;policy_id=test-policy"), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_verifyScale_clock(t *testing.T) {
+	inm := newTestSink(t)
+
+	c := clock.NewFake(time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC))
+	w := newTestWorker(5, 5)
+	w.clock = c
+
+	p := newTestPolicy()
+	p.VerifyScaleAfter = time.Minute
+	labels := []metrics.Label{{Name: "policy_id", Value: p.ID}}
+
+	doneCh := make(chan struct{})
+	go func() {
+		w.verifyScale(context.Background(), w.logger, p, 2, &sdk.ScalingAction{Count: 5}, labels)
+		close(doneCh)
+	}()
+
+	// The target is only verified once the worker clock has advanced.
+	c.BlockUntil(1)
+	c.Advance(30 * time.Second)
+	select {
+	case <-doneCh:
+		t.Fatal("scale verified before VerifyScaleAfter passed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	c.Advance(30 * time.Second)
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for scale verification")
+	}
+	assert.Equal(t, 1, counterValue(inm, "scale.verify.converged_count;policy_id=test-policy"))
+}
+
+func TestBaseWorker_verifyScale_rollback(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputCount       int64
+		inputPrevious    int64
+		inputRollback    bool
+		inputReasonCode  sdk.ReasonCode
+		expectedActions  []sdk.ScalingAction
+		expectedRollback int
+	}{
+		{
+			name:          "not converged rolls back",
+			inputCount:    3,
+			inputPrevious: 2,
+			inputRollback: true,
+			expectedActions: []sdk.ScalingAction{{
+				Count:      2,
+				Direction:  sdk.ScaleDirectionDown,
+				Reason:     "rolling back to count 2 as the target did not converge to count 5",
+				ReasonCode: sdk.ReasonCodeRollback,
+				Meta: map[string]interface{}{
+					"nomad_autoscaler.reason_code":   "rollback",
+					"nomad_autoscaler.count.current": int64(3),
+				},
+			}},
+			expectedRollback: 1,
+		},
+		{
+			name:          "rollback disabled",
+			inputCount:    3,
+			inputPrevious: 2,
+		},
+		{
+			name:          "converged",
+			inputCount:    5,
+			inputPrevious: 2,
+			inputRollback: true,
+		},
+		{
+			name:          "already at previous count",
+			inputCount:    2,
+			inputPrevious: 2,
+			inputRollback: true,
+		},
+		{
+			name:            "rollback not rolled back",
+			inputCount:      3,
+			inputPrevious:   2,
+			inputRollback:   true,
+			inputReasonCode: sdk.ReasonCodeRollback,
+		},
+		{
+			name:          "previous count outside bounds",
+			inputCount:    3,
+			inputPrevious: 0,
+			inputRollback: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(tc.inputCount, tc.inputCount)
+
+			p := newTestPolicy()
+			p.VerifyScaleAfter = time.Millisecond
+			p.RollbackOnVerifyFailure = tc.inputRollback
+			labels := []metrics.Label{{Name: "policy_id", Value: p.ID}}
+
+			action := &sdk.ScalingAction{Count: 5, ReasonCode: tc.inputReasonCode}
+			w.verifyScale(context.Background(), w.logger, p, tc.inputPrevious, action, labels)
+
+			assert.Equal(t, tc.expectedActions, w.target.scaledActions(), tc.name)
+			assert.Equal(t, tc.expectedRollback,
+				counterValue(inm, "scale.rollback.success_count;policy_id=test-policy"), tc.name)
+
+			// The rollback is verified in the same way as other actions, but
+			// is not itself rolled back.
+			if tc.expectedRollback > 0 {
+				assert.Eventually(t, func() bool {
+					return counterValue(inm, "scale.verify.converged_count;policy_id=test-policy") == 1
+				}, time.Second, 5*time.Millisecond, tc.name)
+			}
+		})
+	}
+}
+
+func Test_rollbackAction(t *testing.T) {
+	testCases := []struct {
+		name           string
+		count          int64
+		expectedAction *sdk.ScalingAction
+	}{
+		{
+			name:  "scale down to previous count",
+			count: 4,
+			expectedAction: &sdk.ScalingAction{
+				Count:      2,
+				Direction:  sdk.ScaleDirectionDown,
+				Reason:     "rolling back to count 2 as the target did not converge to count 5",
+				ReasonCode: sdk.ReasonCodeRollback,
+				Meta:       map[string]interface{}{},
+			},
+		},
+		{
+			name:  "scale up to previous count",
+			count: 1,
+			expectedAction: &sdk.ScalingAction{
+				Count:      2,
+				Direction:  sdk.ScaleDirectionUp,
+				Reason:     "rolling back to count 2 as the target did not converge to count 5",
+				ReasonCode: sdk.ReasonCodeRollback,
+				Meta:       map[string]interface{}{},
+			},
+		},
+		{
+			name:           "already at previous count",
+			count:          2,
+			expectedAction: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedAction, rollbackAction(2, 5, tc.count), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_verifyScale_scalingPaths(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputCount       int64
+		inputIgnoreScale bool
+		scaleFn          func(w *testWorker, p *sdk.ScalingPolicy, labels []metrics.Label) error
+		expectedKey      string
+	}{
+		{
+			name:        "checks converged",
+			inputCount:  2,
+			scaleFn:     scaleWithChecks,
+			expectedKey: "scale.verify.converged_count;policy_id=test-policy;target_name=fake-target",
+		},
+		{
+			name:             "checks not converged",
+			inputCount:       2,
+			inputIgnoreScale: true,
+			scaleFn:          scaleWithChecks,
+			expectedKey:      "scale.verify.not_converged_count;policy_id=test-policy;target_name=fake-target",
+		},
+		{
+			name:        "override converged",
+			inputCount:  2,
+			scaleFn:     scaleWithOverride,
+			expectedKey: "scale.verify.converged_count;policy_id=test-policy;target_name=fake-target",
+		},
+		{
+			name:             "override not converged",
+			inputCount:       2,
+			inputIgnoreScale: true,
+			scaleFn:          scaleWithOverride,
+			expectedKey:      "scale.verify.not_converged_count;policy_id=test-policy;target_name=fake-target",
+		},
+		{
+			name:        "reconcile bounds converged",
+			inputCount:  0,
+			scaleFn:     scaleWithReconcile,
+			expectedKey: "scale.verify.converged_count;policy_id=test-policy;target_name=fake-target",
+		},
+		{
+			name:             "reconcile bounds not converged",
+			inputCount:       0,
+			inputIgnoreScale: true,
+			scaleFn:          scaleWithReconcile,
+			expectedKey:      "scale.verify.not_converged_count;policy_id=test-policy;target_name=fake-target",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(tc.inputCount, 5)
+			w.target.ignoreScale = tc.inputIgnoreScale
+
+			p := newTestPolicy()
+			p.VerifyScaleAfter = time.Millisecond
+			// Match the labels used by handlePolicy.
+			labels := []metrics.Label{
+				{Name: "policy_id", Value: p.ID},
+				{Name: "target_name", Value: p.Target.Name},
+			}
+
+			assert.NoError(t, tc.scaleFn(w, p, labels), tc.name)
+			assert.Len(t, w.target.scaledActions(), 1, tc.name)
+
+			assert.Eventually(t, func() bool {
+				return counterValue(inm, tc.expectedKey) == 1
+			}, time.Second, 5*time.Millisecond, tc.name)
+		})
+	}
+}
+
+func scaleWithChecks(w *testWorker, p *sdk.ScalingPolicy, _ []metrics.Label) error {
+	return w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil))
+}
+
+func scaleWithOverride(w *testWorker, p *sdk.ScalingPolicy, labels []metrics.Label) error {
+	o := &policy.Override{Count: 5, Expiry: time.Now().Add(time.Hour)}
+	_, err := w.applyOverride(context.Background(), w.logger, p, o, labels)
+	return err
+}
+
+func scaleWithReconcile(w *testWorker, p *sdk.ScalingPolicy, labels []metrics.Label) error {
+	_, err := w.reconcileBounds(context.Background(), w.logger, p, labels)
+	return err
+}
+
+func TestBaseWorker_handleEval_checkFailures(t *testing.T) {
+	alerter := &fakeAlerter{}
+
+	w := newTestWorker(2, 5)
+	w.broker = NewBroker(hclog.NewNullLogger(), time.Minute, 2)
+	w.failureTracker = NewFailureTracker(2, "critical", alerter)
+	w.apm.err = fmt.Errorf("apm unavailable")
+
+	evaluate := func() {
+		w.broker.Enqueue(sdk.NewScalingEvaluation(newTestPolicy(), nil))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		eval, token, err := w.broker.Dequeue(ctx, "")
+		assert.NoError(t, err)
+		w.handleEval(ctx, eval, token)
+
+		// Check failures must not leave the eval to be retried.
+		assert.Equal(t, 0, w.broker.Stats().Unacked)
+	}
+
+	// The APM errors count towards the failure threshold.
+	evaluate()
+	assert.Empty(t, alerter.alerts)
+
+	evaluate()
+	assert.Len(t, alerter.alerts, 1)
+	assert.Equal(t, FailureAlertStatusFiring, alerter.alerts[0].Status)
+	assert.Contains(t, alerter.alerts[0].Reason, "apm unavailable")
+	assert.Empty(t, w.target.scaledActions())
+
+	// A successful evaluation resolves the alert.
+	w.apm.err = nil
+	evaluate()
+	assert.Len(t, alerter.alerts, 2)
+	assert.Equal(t, FailureAlertStatusResolved, alerter.alerts[1].Status)
+	assert.Len(t, w.target.scaledActions(), 1)
+}
+
+func TestBaseWorker_handleEval_errorLogs(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := newTestWorker(2, 5)
+	w.logger = hclog.New(&hclog.LoggerOptions{Output: &buf, Level: hclog.Info})
+	w.broker = NewBroker(hclog.NewNullLogger(), time.Minute, 2)
+	w.errLogs = policy.NewErrorLogDeduper(time.Hour, policy.DefaultErrorLogMaxEntries)
+	w.apm.err = fmt.Errorf("apm unavailable")
+
+	evaluate := func() {
+		w.broker.Enqueue(sdk.NewScalingEvaluation(newTestPolicy(), nil))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		eval, token, err := w.broker.Dequeue(ctx, "")
+		assert.NoError(t, err)
+		w.handleEval(ctx, eval, token)
+	}
+
+	// Only the first of the identical failures is logged.
+	for i := 0; i < 3; i++ {
+		evaluate()
+	}
+	assert.Equal(t, 1, strings.Count(buf.String(), "failed to evaluate policy:"))
+	assert.Equal(t, 1, strings.Count(buf.String(), "failed to evaluate check:"))
+
+	// The suppressed failures are summarised once the policy recovers.
+	w.apm.err = nil
+	evaluate()
+	assert.Contains(t, buf.String(), "repeated=2")
+	assert.Contains(t, buf.String(), "error=\"failed to evaluate policy\" occurrences=3")
+}
+
+func TestBaseWorker_handlePolicy_checkFailure(t *testing.T) {
+	w := newTestWorker(2, 5)
+	w.pluginManager.(fakePlugins)[plugins.PluginTypeAPM+"/failing-apm"] = &fakeAPM{err: fmt.Errorf("apm unavailable")}
+
+	// Add a failing check after the one using the working APM.
+	p := newTestPolicy()
+	p.Checks = append(p.Checks, &sdk.ScalingPolicyCheck{
+		Name:     "failing",
+		Source:   "failing-apm",
+		Query:    "query",
+		Strategy: &sdk.ScalingPolicyStrategy{Name: "fake-strategy", Config: map[string]string{}},
+	})
+
+	err := w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil))
+	assert.IsType(t, &checkError{}, err)
+	assert.Contains(t, err.Error(), "check failing:")
+
+	// The working check is still acted upon.
+	assert.Len(t, w.target.scaledActions(), 1)
+}
+
+func TestBaseWorker_handlePolicy_failoverSources(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		inputPrimaryErr       error
+		inputPrimaryMissing   bool
+		inputSecondaryErr     error
+		expectedErr           bool
+		expectedScaled        int
+		expectedFailoverCount int
+	}{
+		{
+			name:           "primary source succeeds",
+			expectedScaled: 1,
+		},
+		{
+			name:                  "primary source fails",
+			inputPrimaryErr:       fmt.Errorf("primary unavailable"),
+			expectedScaled:        1,
+			expectedFailoverCount: 1,
+		},
+		{
+			name:                  "primary source not available",
+			inputPrimaryMissing:   true,
+			expectedScaled:        1,
+			expectedFailoverCount: 1,
+		},
+		{
+			name:              "all sources fail",
+			inputPrimaryErr:   fmt.Errorf("primary unavailable"),
+			inputSecondaryErr: fmt.Errorf("secondary unavailable"),
+			expectedErr:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, 5)
+			w.apm.err = tc.inputPrimaryErr
+			if tc.inputPrimaryMissing {
+				delete(w.pluginManager.(fakePlugins), plugins.PluginTypeAPM+"/fake-apm")
+			}
+			w.pluginManager.(fakePlugins)[plugins.PluginTypeAPM+"/secondary-apm"] = &fakeAPM{
+				metrics: sdk.TimestampedMetrics{{Timestamp: time.Now(), Value: 1}},
+				err:     tc.inputSecondaryErr,
+			}
+
+			p := newTestPolicy()
+			p.Checks[0].FailoverSources = []string{"secondary-apm"}
+
+			err := w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil))
+			if tc.expectedErr {
+				assert.Error(t, err, tc.name)
+				assert.Contains(t, err.Error(), "primary unavailable", tc.name)
+				assert.Contains(t, err.Error(), "secondary unavailable", tc.name)
+			} else {
+				assert.NoError(t, err, tc.name)
+			}
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			key := "plugin.apm.query.failover_count;plugin_name=secondary-apm;policy_id=test-policy"
+			assert.Equal(t, tc.expectedFailoverCount, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_noData(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		inputMetrics         sdk.TimestampedMetrics
+		inputNoData          bool
+		expectedStrategyRuns int32
+	}{
+		{
+			name:                 "strategy reports no data",
+			inputMetrics:         sdk.TimestampedMetrics{{Timestamp: time.Now(), Value: 1}},
+			inputNoData:          true,
+			expectedStrategyRuns: 1,
+		},
+		{
+			name:                 "no metrics returned",
+			inputMetrics:         sdk.TimestampedMetrics{},
+			expectedStrategyRuns: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			// The current count is below the policy min, which would be
+			// corrected if the check were not suppressed.
+			w := newTestWorker(0, 5)
+			w.apm.metrics = tc.inputMetrics
+			w.strategy.noData = tc.inputNoData
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			assert.NoError(t, w.handlePolicy(ctx, sdk.NewScalingEvaluation(newTestPolicy(), nil)), tc.name)
+			assert.NoError(t, ctx.Err(), tc.name)
+			assert.Empty(t, w.target.scaledActions(), tc.name)
+			assert.Equal(t, tc.expectedStrategyRuns, atomic.LoadInt32(&w.strategy.runs), tc.name)
+			assert.Equal(t, 1, counterValue(inm, "scale.suppressed_count;policy_id=test-policy;reason=no_data"), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_suppressed(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputCount         int64
+		inputDesired       int64
+		inputNotReady      bool
+		inputUnhealthy     bool
+		inputLeadership    Leadership
+		inputOtherStrategy *fakeStrategy
+		expectedScaled     int
+		expectedReasons    []string
+	}{
+		{
+			name:            "target not ready",
+			inputCount:      3,
+			inputDesired:    5,
+			inputNotReady:   true,
+			expectedReasons: []string{policy.SuppressionReasonTargetNotReady},
+		},
+		{
+			name:            "bounds",
+			inputCount:      10,
+			inputDesired:    20,
+			expectedReasons: []string{policy.SuppressionReasonBounds},
+		},
+		{
+			name:            "not leader",
+			inputCount:      3,
+			inputDesired:    5,
+			inputLeadership: fakeLeadership(false),
+			expectedReasons: []string{policy.SuppressionReasonNotLeader},
+		},
+		{
+			name:            "unhealthy",
+			inputCount:      5,
+			inputDesired:    3,
+			inputUnhealthy:  true,
+			expectedReasons: []string{policy.SuppressionReasonUnhealthy},
+		},
+		{
+			name:               "bounds with another check scaling",
+			inputCount:         1,
+			inputDesired:       0,
+			inputOtherStrategy: &fakeStrategy{count: 5},
+			expectedScaled:     1,
+		},
+		{
+			name:               "no data with another check scaling",
+			inputCount:         3,
+			inputDesired:       5,
+			inputOtherStrategy: &fakeStrategy{noData: true},
+			expectedScaled:     1,
+		},
+	}
+
+	allReasons := []string{
+		policy.SuppressionReasonTargetNotReady,
+		policy.SuppressionReasonNoData,
+		policy.SuppressionReasonBounds,
+		policy.SuppressionReasonNotLeader,
+		policy.SuppressionReasonCountUnstable,
+		policy.SuppressionReasonCapacityBudget,
+		policy.SuppressionReasonStabilization,
+		policy.SuppressionReasonUnhealthy,
+		policy.SuppressionReasonScaleDownLimit,
+		policy.SuppressionReasonDeployment,
+		policy.SuppressionReasonInvalidMetric,
+		policy.SuppressionReasonTargetNotAllowed,
+		policy.SuppressionReasonScaleHook,
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(tc.inputCount, tc.inputDesired)
+			w.target.status.Ready = !tc.inputNotReady
+			w.leadership = tc.inputLeadership
+
+			p := newTestPolicy()
+			if tc.inputUnhealthy {
+				w.target.status.Meta = map[string]string{sdk.TargetStatusMetaKeyHealthyCount: "0"}
+				p.MinHealthyPercentage = 50
+			}
+			if tc.inputOtherStrategy != nil {
+				w.pluginManager.(fakePlugins)[plugins.PluginTypeStrategy+"/other-strategy"] = tc.inputOtherStrategy
+				p.Checks = append(p.Checks, &sdk.ScalingPolicyCheck{
+					Name:     "other",
+					Source:   "fake-apm",
+					Query:    "query",
+					Strategy: &sdk.ScalingPolicyStrategy{Name: "other-strategy", Config: map[string]string{}},
+				})
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			assert.NoError(t, w.handlePolicy(ctx, sdk.NewScalingEvaluation(p, nil)), tc.name)
+			assert.NoError(t, ctx.Err(), tc.name)
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			for _, reason := range allReasons {
+				expected := 0
+				for _, r := range tc.expectedReasons {
+					if r == reason {
+						expected = 1
+					}
+				}
+				key := "scale.suppressed_count;policy_id=test-policy;reason=" + reason
+				assert.Equal(t, expected, counterValue(inm, key), tc.name+": "+reason)
+			}
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_stabilizeCount(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputStabilize     bool
+		inputCounts        []int64
+		expectedScaled     int
+		expectedSuppressed int
+	}{
+		{
+			name:           "stable count",
+			inputStabilize: true,
+			inputCounts:    []int64{2, 2},
+			expectedScaled: 1,
+		},
+		{
+			name:               "unstable count",
+			inputStabilize:     true,
+			inputCounts:        []int64{2, 3},
+			expectedSuppressed: 1,
+		},
+		{
+			name:           "stabilization disabled",
+			inputStabilize: false,
+			inputCounts:    []int64{2, 3},
+			expectedScaled: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(0, 5)
+			w.target.counts = tc.inputCounts
+
+			p := newTestPolicy()
+			p.StabilizeCount = tc.inputStabilize
+			p.StabilizeCountDelay = time.Millisecond
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			assert.NoError(t, w.handlePolicy(ctx, sdk.NewScalingEvaluation(p, nil)), tc.name)
+			assert.Len(t, w.target.scaledActions(), tc.expectedScaled, tc.name)
+
+			key := "scale.suppressed_count;policy_id=test-policy;reason=" + policy.SuppressionReasonCountUnstable
+			assert.Equal(t, tc.expectedSuppressed, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_capacityBudget(t *testing.T) {
+	testCases := []struct {
+		name               string
+		inputEnforcement   string
+		inputOtherCount    int64
+		expectedCounts     []int64
+		expectedSuppressed int
+	}{
+		{
+			name:             "within budget",
+			inputEnforcement: CapacityBudgetEnforcementDeny,
+			inputOtherCount:  5,
+			expectedCounts:   []int64{5},
+		},
+		{
+			name:               "denied",
+			inputEnforcement:   CapacityBudgetEnforcementDeny,
+			inputOtherCount:    7,
+			expectedSuppressed: 1,
+		},
+		{
+			name:             "reduced",
+			inputEnforcement: CapacityBudgetEnforcementReduce,
+			inputOtherCount:  7,
+			expectedCounts:   []int64{3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inm := newTestSink(t)
+
+			w := newTestWorker(2, 5)
+			w.capacityBudget = NewCapacityBudget(10, tc.inputEnforcement)
+			w.capacityBudget.Record(&sdk.ScalingPolicy{ID: "other", EvaluationInterval: time.Minute}, tc.inputOtherCount)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			assert.NoError(t, w.handlePolicy(ctx, sdk.NewScalingEvaluation(newTestPolicy(), nil)), tc.name)
+
+			var counts []int64
+			for _, a := range w.target.scaledActions() {
+				counts = append(counts, a.Count)
+			}
+			assert.Equal(t, tc.expectedCounts, counts, tc.name)
+
+			key := "scale.suppressed_count;policy_id=test-policy;reason=" + policy.SuppressionReasonCapacityBudget
+			assert.Equal(t, tc.expectedSuppressed, counterValue(inm, key), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_fallbackStrategy(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputFallback   string
+		inputCount      int64
+		expectedErr     bool
+		expectedActions []sdk.ScalingAction
+	}{
+		{
+			name:        "no fallback strategy",
+			inputCount:  0,
+			expectedErr: true,
+		},
+		{
+			name:          "hold below min",
+			inputFallback: sdk.FallbackStrategyHold,
+			inputCount:    0,
+		},
+		{
+			name:          "bounds within limits",
+			inputFallback: sdk.FallbackStrategyBounds,
+			inputCount:    5,
+		},
+		{
+			name:          "bounds below min",
+			inputFallback: sdk.FallbackStrategyBounds,
+			inputCount:    0,
+			expectedActions: []sdk.ScalingAction{{
+				Count:      1,
+				Direction:  sdk.ScaleDirectionUp,
+				Reason:     "current count (0) below limit (1)",
+				ReasonCode: sdk.ReasonCodeBounds,
+				Meta: map[string]interface{}{
+					"nomad_autoscaler.reason_code":   "bounds",
+					"nomad_autoscaler.count.current": int64(0),
+				},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(tc.inputCount, 8)
+			delete(w.pluginManager.(fakePlugins), plugins.PluginTypeStrategy+"/fake-strategy")
+
+			p := newTestPolicy()
+			p.FallbackStrategy = tc.inputFallback
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			err := w.handlePolicy(ctx, sdk.NewScalingEvaluation(p, nil))
+			if tc.expectedErr {
+				assert.IsType(t, &checkError{}, err, tc.name)
+			} else {
+				assert.NoError(t, err, tc.name)
+			}
+			assert.NoError(t, ctx.Err(), tc.name)
+			assert.Equal(t, tc.expectedActions, w.target.scaledActions(), tc.name)
+		})
+	}
+}
@@ -73,6 +73,15 @@ type Broker struct {
 	waiting map[string]chan struct{}
 }
 
+// BrokerStats is a point-in-time snapshot of the broker state.
+type BrokerStats struct {
+	// Pending is the number of evals waiting to be dequeued, keyed by queue.
+	Pending map[string]int
+
+	// Unacked is the number of dequeued evals which have not been ack'd yet.
+	Unacked int
+}
+
 // unackEval tracks an unacknowledged evaluation along with the Nack timer
 type unackEval struct {
 	Eval      *sdk.ScalingEvaluation
@@ -148,9 +157,20 @@ func (b *Broker) enqueueLocked(eval *sdk.ScalingEvaluation, token string) {
 				delete(b.enqueuedEvals, eval.ID)
 				pending[i] = eval
 				heap.Fix(&pending, i)
+			} else {
+				// The eval is superseded by the newer pending eval, such as
+				// when a coalesced in progress eval is nack'd, so drop it.
+				delete(b.enqueuedEvals, eval.ID)
 			}
 			return
 		}
+
+		// The enqueued eval for the policy is currently being evaluated. Queue
+		// this eval behind it and track it as the policy's enqueued eval, so
+		// further evals are coalesced with it rather than building a backlog
+		// when evaluations take longer than the policy interval.
+		logger.Debug("policy eval in progress, queueing eval")
+		b.enqueuedPolicies[eval.Policy.ID] = eval.ID
 	}
 
 	heap.Push(&pending, eval)
@@ -210,6 +230,21 @@ func (b *Broker) Dequeue(ctx context.Context, queue string) (*sdk.ScalingEvaluat
 	return eval, token, nil
 }
 
+// Stats returns a snapshot of the broker state.
+func (b *Broker) Stats() BrokerStats {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	stats := BrokerStats{
+		Pending: make(map[string]int, len(b.pendingEvals)),
+		Unacked: len(b.unack),
+	}
+	for queue, pending := range b.pendingEvals {
+		stats.Pending[queue] = pending.Len()
+	}
+	return stats
+}
+
 // findWork returns an eval from the queue heap or nil if there's no eval available.
 func (b *Broker) findWork(queue string) *sdk.ScalingEvaluation {
 	b.l.Lock()
@@ -277,7 +312,7 @@ func (b *Broker) Ack(evalID, token string) error {
 	// Cleanup.
 	delete(b.unack, evalID)
 	delete(b.enqueuedEvals, evalID)
-	delete(b.enqueuedPolicies, unack.Eval.Policy.ID)
+	b.removeEnqueuedPolicyLocked(unack.Eval.Policy.ID, evalID)
 
 	b.logger.Debug("eval ack'd", "policy_id", unack.Eval.Policy.ID)
 	return nil
@@ -314,7 +349,7 @@ func (b *Broker) Nack(evalID, token string) error {
 		logger.Warn("eval delivery limit reached", "count", dequeues, "limit", b.deliveryLimit)
 
 		delete(b.enqueuedEvals, evalID)
-		delete(b.enqueuedPolicies, unack.Eval.Policy.ID)
+		b.removeEnqueuedPolicyLocked(unack.Eval.Policy.ID, evalID)
 		return nil
 	}
 
@@ -324,6 +359,15 @@ func (b *Broker) Nack(evalID, token string) error {
 	return nil
 }
 
+// removeEnqueuedPolicyLocked removes the policy from the enqueued policies
+// index if it is still tracked against the eval. The policy may have a newer
+// eval queued behind the eval being removed, in which case it must be kept.
+func (b *Broker) removeEnqueuedPolicyLocked(policyID, evalID string) {
+	if b.enqueuedPolicies[policyID] == evalID {
+		delete(b.enqueuedPolicies, policyID)
+	}
+}
+
 // PendingEvaluations is a list of waiting evaluations.
 // We implement the container/heap interface so that this is a
 // priority queue
@@ -167,3 +167,92 @@ func TestBroker(t *testing.T) {
 	assert.Nil(e)
 	assert.NoError(ctx.Err())
 }
+
+func TestBroker_coalesceInProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	b := NewBroker(hclog.NewNullLogger(), time.Minute, 2)
+
+	p := &sdk.ScalingPolicy{ID: "policy1", Type: "horizontal"}
+	evalA := &sdk.ScalingEvaluation{ID: "evalA", Policy: p, CreateTime: time.Date(2020, time.October, 12, 21, 0, 0, 0, time.UTC)}
+	evalB := &sdk.ScalingEvaluation{ID: "evalB", Policy: p, CreateTime: time.Date(2020, time.October, 12, 22, 0, 0, 0, time.UTC)}
+	evalC := &sdk.ScalingEvaluation{ID: "evalC", Policy: p, CreateTime: time.Date(2020, time.October, 12, 23, 0, 0, 0, time.UTC)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Dequeue evalA so it is in progress.
+	b.Enqueue(evalA)
+	e, token, err := b.Dequeue(ctx, "horizontal")
+	assert.NoError(err)
+	assert.Equal(evalA, e)
+
+	// Evals enqueued while evalA is in progress should be coalesced.
+	b.Enqueue(evalB)
+	b.Enqueue(evalC)
+	assert.Equal(1, b.pendingEvals["horizontal"].Len())
+	assert.Equal(evalC, b.pendingEvals["horizontal"][0])
+
+	// Acking evalA must not remove the policy tracked against evalC.
+	assert.NoError(b.Ack(e.ID, token))
+	assert.Equal("evalC", b.enqueuedPolicies["policy1"])
+
+	e, _, err = b.Dequeue(ctx, "horizontal")
+	assert.NoError(err)
+	assert.Equal(evalC, e)
+}
+
+func TestBroker_nackCoalesced(t *testing.T) {
+	assert := assert.New(t)
+
+	b := NewBroker(hclog.NewNullLogger(), time.Minute, 2)
+
+	p := &sdk.ScalingPolicy{ID: "policy1", Type: "horizontal"}
+	evalA := &sdk.ScalingEvaluation{ID: "evalA", Policy: p, CreateTime: time.Date(2020, time.October, 12, 21, 0, 0, 0, time.UTC)}
+	evalB := &sdk.ScalingEvaluation{ID: "evalB", Policy: p, CreateTime: time.Date(2020, time.October, 12, 22, 0, 0, 0, time.UTC)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Dequeue evalA so it is in progress and coalesce evalB behind it.
+	b.Enqueue(evalA)
+	e, token, err := b.Dequeue(ctx, "horizontal")
+	assert.NoError(err)
+	assert.Equal(evalA, e)
+	b.Enqueue(evalB)
+
+	// Nacking evalA must drop it in favour of evalB without leaking its
+	// enqueued entry.
+	assert.NoError(b.Nack(e.ID, token))
+	assert.Equal(1, b.pendingEvals["horizontal"].Len())
+	assert.Equal("evalB", b.enqueuedPolicies["policy1"])
+	assert.NotContains(b.enqueuedEvals, "evalA")
+	assert.Contains(b.enqueuedEvals, "evalB")
+
+	e, token, err = b.Dequeue(ctx, "horizontal")
+	assert.NoError(err)
+	assert.Equal(evalB, e)
+	assert.NoError(b.Ack(e.ID, token))
+	assert.Empty(b.enqueuedEvals)
+}
+
+func TestBroker_Stats(t *testing.T) {
+	assert := assert.New(t)
+
+	b := NewBroker(hclog.NewNullLogger(), time.Minute, 2)
+	b.Enqueue(&sdk.ScalingEvaluation{ID: "eval1", Policy: &sdk.ScalingPolicy{ID: "policy1", Type: "horizontal"}})
+	b.Enqueue(&sdk.ScalingEvaluation{ID: "eval2", Policy: &sdk.ScalingPolicy{ID: "policy2", Type: "horizontal"}})
+	b.Enqueue(&sdk.ScalingEvaluation{ID: "eval3", Policy: &sdk.ScalingPolicy{ID: "policy3", Type: "cluster"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _, err := b.Dequeue(ctx, "cluster")
+	assert.NoError(err)
+
+	expected := BrokerStats{
+		Pending: map[string]int{"horizontal": 2, "cluster": 0},
+		Unacked: 1,
+	}
+	assert.Equal(expected, b.Stats())
+}
@@ -0,0 +1,144 @@
+package policyeval
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+const (
+	// CapacityBudgetEnforcementDeny skips scale ups which exceed the capacity
+	// budget entirely.
+	CapacityBudgetEnforcementDeny = "deny"
+
+	// CapacityBudgetEnforcementReduce reduces scale ups which exceed the
+	// capacity budget to the count remaining within it.
+	CapacityBudgetEnforcementReduce = "reduce"
+)
+
+// capacityBudgetStaleIntervals is the number of evaluation intervals, on top
+// of the cooldown, after which the count of a policy which is no longer
+// evaluated stops counting towards the budget. This releases the capacity of
+// policies which are removed or disabled.
+const capacityBudgetStaleIntervals = 3
+
+// CapacityBudget limits the sum of the target counts of all policies. The
+// count of each policy is recorded on every evaluation, and scale ups which
+// would take the sum above the maximum are denied or reduced depending on the
+// enforcement mode. It is safe for concurrent use by multiple workers.
+type CapacityBudget struct {
+	max         int64
+	enforcement string
+
+	l      sync.Mutex
+	counts map[string]budgetEntry
+
+	// now is used to read the current time, allowing tests to control it.
+	now func() time.Time
+}
+
+// budgetEntry is the count of a single policy, which counts towards the
+// budget until its expiry.
+type budgetEntry struct {
+	count  int64
+	expiry time.Time
+}
+
+// NewCapacityBudget returns a new CapacityBudget. An unknown enforcement mode
+// is treated as CapacityBudgetEnforcementDeny.
+func NewCapacityBudget(max int64, enforcement string) *CapacityBudget {
+	if enforcement != CapacityBudgetEnforcementReduce {
+		enforcement = CapacityBudgetEnforcementDeny
+	}
+
+	return &CapacityBudget{
+		max:         max,
+		enforcement: enforcement,
+		counts:      make(map[string]budgetEntry),
+		now:         time.Now,
+	}
+}
+
+// Record records count as the current target count of the policy.
+func (b *CapacityBudget) Record(p *sdk.ScalingPolicy, count int64) {
+	if b == nil {
+		return
+	}
+
+	b.l.Lock()
+	defer b.l.Unlock()
+	b.set(p, count)
+}
+
+// Reserve returns the count the policy is allowed to scale to from current,
+// given it wants to scale to desired. Scale downs are always allowed, while
+// scale ups are denied, by returning current, or reduced when they would
+// exceed the budget. The allowed count is recorded for the policy, so it is
+// accounted for by evaluations of other policies running concurrently.
+func (b *CapacityBudget) Reserve(p *sdk.ScalingPolicy, current, desired int64) int64 {
+	if b == nil {
+		return desired
+	}
+
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	allowed := b.allowed(p, current, desired)
+	b.set(p, allowed)
+	return allowed
+}
+
+// Allowed returns the count the policy is allowed to scale to from current,
+// given it wants to scale to desired, in the same way as Reserve but without
+// recording it.
+func (b *CapacityBudget) Allowed(p *sdk.ScalingPolicy, current, desired int64) int64 {
+	if b == nil {
+		return desired
+	}
+
+	b.l.Lock()
+	defer b.l.Unlock()
+	return b.allowed(p, current, desired)
+}
+
+// allowed returns the count the policy is allowed to scale to. The caller
+// must hold the lock.
+func (b *CapacityBudget) allowed(p *sdk.ScalingPolicy, current, desired int64) int64 {
+	if desired <= current {
+		return desired
+	}
+
+	available := b.max - b.othersTotal(p.ID)
+	if desired <= available {
+		return desired
+	}
+	if b.enforcement == CapacityBudgetEnforcementReduce && available > current {
+		return available
+	}
+	return current
+}
+
+// set records the count of the policy. The caller must hold the lock.
+func (b *CapacityBudget) set(p *sdk.ScalingPolicy, count int64) {
+	ttl := p.Cooldown + capacityBudgetStaleIntervals*p.EvaluationInterval
+	b.counts[p.ID] = budgetEntry{count: count, expiry: b.now().Add(ttl)}
+}
+
+// othersTotal returns the sum of the counts of all policies other than id,
+// removing the expired ones. The caller must hold the lock.
+func (b *CapacityBudget) othersTotal(id string) int64 {
+	now := b.now()
+
+	var total int64
+	for policyID, e := range b.counts {
+		if now.After(e.expiry) {
+			delete(b.counts, policyID)
+			continue
+		}
+		if policyID != id {
+			total += e.count
+		}
+	}
+	return total
+}
@@ -0,0 +1,131 @@
+package policyeval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapacityBudget_Reserve(t *testing.T) {
+	testCases := []struct {
+		name            string
+		budget          *CapacityBudget
+		otherCount      int64
+		current         int64
+		desired         int64
+		expectedAllowed int64
+	}{
+		{
+			name:            "nil budget",
+			budget:          nil,
+			otherCount:      8,
+			current:         2,
+			desired:         5,
+			expectedAllowed: 5,
+		},
+		{
+			name:            "scale up within budget",
+			budget:          NewCapacityBudget(10, CapacityBudgetEnforcementDeny),
+			otherCount:      5,
+			current:         2,
+			desired:         5,
+			expectedAllowed: 5,
+		},
+		{
+			name:            "scale up denied",
+			budget:          NewCapacityBudget(10, CapacityBudgetEnforcementDeny),
+			otherCount:      8,
+			current:         1,
+			desired:         5,
+			expectedAllowed: 1,
+		},
+		{
+			name:            "scale up reduced",
+			budget:          NewCapacityBudget(10, CapacityBudgetEnforcementReduce),
+			otherCount:      7,
+			current:         1,
+			desired:         5,
+			expectedAllowed: 3,
+		},
+		{
+			name:            "scale up reduced without remaining budget",
+			budget:          NewCapacityBudget(10, CapacityBudgetEnforcementReduce),
+			otherCount:      9,
+			current:         1,
+			desired:         5,
+			expectedAllowed: 1,
+		},
+		{
+			name:            "scale up when already over budget",
+			budget:          NewCapacityBudget(10, CapacityBudgetEnforcementReduce),
+			otherCount:      12,
+			current:         1,
+			desired:         5,
+			expectedAllowed: 1,
+		},
+		{
+			name:            "scale down over budget",
+			budget:          NewCapacityBudget(10, CapacityBudgetEnforcementDeny),
+			otherCount:      12,
+			current:         5,
+			desired:         3,
+			expectedAllowed: 3,
+		},
+		{
+			name:            "unknown enforcement denies",
+			budget:          NewCapacityBudget(10, "unknown"),
+			otherCount:      7,
+			current:         1,
+			desired:         5,
+			expectedAllowed: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &sdk.ScalingPolicy{ID: "policy", EvaluationInterval: time.Minute}
+			other := &sdk.ScalingPolicy{ID: "other", EvaluationInterval: time.Minute}
+
+			tc.budget.Record(other, tc.otherCount)
+			assert.Equal(t, tc.expectedAllowed, tc.budget.Reserve(p, tc.current, tc.desired), tc.name)
+		})
+	}
+}
+
+func TestCapacityBudget_reservations(t *testing.T) {
+	b := NewCapacityBudget(10, CapacityBudgetEnforcementReduce)
+	a := &sdk.ScalingPolicy{ID: "a", EvaluationInterval: time.Minute}
+	c := &sdk.ScalingPolicy{ID: "c", EvaluationInterval: time.Minute}
+
+	// The capacity reserved by a concurrent scale up is accounted for.
+	assert.Equal(t, int64(6), b.Reserve(a, 1, 6))
+	assert.Equal(t, int64(4), b.Reserve(c, 1, 6))
+
+	// Recording the actual count of a policy releases its reservation. The
+	// count of a policy replaces its previous value rather than adding to it.
+	b.Record(a, 1)
+	assert.Equal(t, int64(9), b.Reserve(c, 4, 9))
+	assert.Equal(t, int64(1), b.Reserve(a, 1, 8))
+}
+
+func TestCapacityBudget_expiry(t *testing.T) {
+	now := time.Now()
+
+	b := NewCapacityBudget(10, CapacityBudgetEnforcementDeny)
+	b.now = func() time.Time { return now }
+
+	p := &sdk.ScalingPolicy{ID: "policy", EvaluationInterval: time.Minute}
+	stale := &sdk.ScalingPolicy{ID: "stale", EvaluationInterval: time.Minute, Cooldown: 5 * time.Minute}
+	b.Record(stale, 8)
+
+	// The count of the policy counts towards the budget throughout its
+	// cooldown and the following evaluation intervals.
+	now = now.Add(5*time.Minute + capacityBudgetStaleIntervals*time.Minute)
+	assert.Equal(t, int64(1), b.Reserve(p, 1, 5))
+
+	// Once the policy is no longer evaluated its count is released.
+	now = now.Add(time.Second)
+	assert.Equal(t, int64(5), b.Reserve(p, 1, 5))
+}
@@ -0,0 +1,307 @@
+package policyeval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/uuid"
+)
+
+// The outcomes of the scaling decisions published as decision events.
+const (
+	// DecisionOutcomeScaled is the outcome of a scaling action which was
+	// applied to the target, including dry-run actions.
+	DecisionOutcomeScaled = "scaled"
+
+	// DecisionOutcomeSuppressed is the outcome of a scaling action which was
+	// not applied to the target. The event reason is the suppression reason.
+	DecisionOutcomeSuppressed = "suppressed"
+
+	// DecisionOutcomeAdvisory is the outcome of a scaling action recommended
+	// by an advisory policy.
+	DecisionOutcomeAdvisory = "advisory"
+
+	// DecisionOutcomeFailed is the outcome of a scaling action which failed
+	// to be applied to the target. The event reason is the error.
+	DecisionOutcomeFailed = "failed"
+)
+
+const (
+	// defaultDecisionBufferSize is the number of events buffered for each
+	// publisher if the sink is not given a buffer size.
+	defaultDecisionBufferSize = 1024
+
+	// decisionRetryMin and decisionRetryMax bound the backoff between the
+	// attempts to publish an event which failed to be delivered.
+	decisionRetryMin = 1 * time.Second
+	decisionRetryMax = 30 * time.Second
+
+	// decisionPublishTimeout is the time a single publish attempt is allowed
+	// to take.
+	decisionPublishTimeout = 10 * time.Second
+)
+
+// DecisionEvent is the payload published for each scaling decision made by
+// the agent, so downstream consumers can follow scaling without polling the
+// agent API.
+type DecisionEvent struct {
+	ID             string    `json:"id"`
+	Timestamp      time.Time `json:"timestamp"`
+	PolicyID       string    `json:"policy_id"`
+	PolicyRevision string    `json:"policy_revision,omitempty"`
+	Target         string    `json:"target"`
+	Outcome        string    `json:"outcome"`
+	Reason         string    `json:"reason,omitempty"`
+	Direction      string    `json:"direction"`
+	Count          int64     `json:"count"`
+	DesiredCount   int64     `json:"desired_count"`
+	ActionReason   string    `json:"action_reason,omitempty"`
+	ReasonCode     string    `json:"reason_code,omitempty"`
+	DryRun         bool      `json:"dry_run,omitempty"`
+
+	// MetricValue is the APM value the action was calculated from,
+	// formatted using the metric unit of the policy. It is empty for actions
+	// which are not calculated from a metric, such as overrides.
+	MetricValue string `json:"metric_value,omitempty"`
+}
+
+// NewDecisionEvent returns the event describing the decision on the action
+// scaling the policy target from count. The action must not have been
+// modified for a dry-run, as its count is used as the desired count.
+func NewDecisionEvent(p *sdk.ScalingPolicy, outcome, reason string, count int64, action sdk.ScalingAction) DecisionEvent {
+	event := DecisionEvent{
+		ID:             uuid.Generate(),
+		Timestamp:      time.Now().UTC(),
+		PolicyID:       p.ID,
+		PolicyRevision: p.Revision,
+		Outcome:        outcome,
+		Reason:         reason,
+		Direction:      action.Direction.String(),
+		Count:          count,
+		DesiredCount:   action.Count,
+		ActionReason:   action.Reason,
+		ReasonCode:     string(action.ReasonCode),
+	}
+	if event.ReasonCode == "" {
+		event.ReasonCode = string(sdk.ReasonCodeStrategy)
+	}
+	if v, ok := action.Meta[sdk.StrategyActionMetaKeyMetricValue].(string); ok {
+		event.MetricValue = v
+	}
+	if p.Target != nil {
+		event.Target = p.Target.Name
+		event.DryRun = p.Target.Config["dry-run"] == "true"
+	}
+	return event
+}
+
+// DecisionPublisher publishes encoded decision events to a message bus or
+// endpoint. Publish is only called by a single Go routine at a time.
+type DecisionPublisher interface {
+	// Name identifies the publisher within logs and metrics.
+	Name() string
+
+	// Publish delivers the JSON encoded event, returning an error if it may
+	// not have been delivered, in which case it is retried.
+	Publish(ctx context.Context, payload []byte) error
+}
+
+// DecisionSink streams the scaling decisions of all policies to the
+// configured publishers. Events are buffered for each publisher and
+// delivered in the background, so a slow or unavailable publisher never
+// delays policy evaluations; failed deliveries are retried with backoff, and
+// the oldest events are dropped once the buffer is full. It is safe for
+// concurrent use by multiple workers.
+type DecisionSink struct {
+	logger hclog.Logger
+	queues []*decisionQueue
+}
+
+// decisionQueue buffers the events of a single publisher.
+type decisionQueue struct {
+	publisher DecisionPublisher
+	size      int
+
+	l        sync.Mutex
+	events   [][]byte
+	notifyCh chan struct{}
+}
+
+// NewDecisionSink returns a new DecisionSink which publishes events to each
+// of the publishers, buffering up to bufferSize events for each of them. It
+// returns nil, which discards every event, if there are no publishers. A
+// buffer size lower than one uses the default.
+func NewDecisionSink(logger hclog.Logger, bufferSize int, publishers ...DecisionPublisher) *DecisionSink {
+	if len(publishers) == 0 {
+		return nil
+	}
+	if bufferSize < 1 {
+		bufferSize = defaultDecisionBufferSize
+	}
+
+	s := &DecisionSink{logger: logger.Named("decision_sink")}
+	for _, p := range publishers {
+		s.queues = append(s.queues, &decisionQueue{
+			publisher: p,
+			size:      bufferSize,
+			notifyCh:  make(chan struct{}, 1),
+		})
+	}
+	return s
+}
+
+// Emit buffers the event for publishing. It never blocks, so it is safe to
+// call while evaluating policies.
+func (s *DecisionSink) Emit(event DecisionEvent) {
+	if s == nil {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("failed to encode decision event", "policy_id", event.PolicyID, "error", err)
+		return
+	}
+
+	for _, q := range s.queues {
+		if q.push(payload) {
+			s.logger.Warn("decision event buffer full, dropped oldest event",
+				"publisher", q.publisher.Name())
+			metrics.IncrCounterWithLabels([]string{"decisions", "dropped_count"}, 1,
+				[]metrics.Label{{Name: "publisher", Value: q.publisher.Name()}})
+		}
+	}
+}
+
+// Run publishes the buffered events until the context is canceled.
+func (s *DecisionSink) Run(ctx context.Context) {
+	if s == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, q := range s.queues {
+		wg.Add(1)
+		go func(q *decisionQueue) {
+			defer wg.Done()
+			s.runQueue(ctx, q)
+		}(q)
+	}
+	wg.Wait()
+}
+
+// runQueue publishes the events of the queue in order. An event which fails
+// to be published is retried with backoff before moving on to the next.
+func (s *DecisionSink) runQueue(ctx context.Context, q *decisionQueue) {
+	logger := s.logger.With("publisher", q.publisher.Name())
+	labels := []metrics.Label{{Name: "publisher", Value: q.publisher.Name()}}
+	backoff := decisionRetryMin
+
+	for {
+		payload, ok := q.peek()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.notifyCh:
+				continue
+			}
+		}
+
+		publishCtx, cancel := context.WithTimeout(ctx, decisionPublishTimeout)
+		err := q.publisher.Publish(publishCtx, payload)
+		cancel()
+
+		if err == nil {
+			q.pop(payload)
+			backoff = decisionRetryMin
+			metrics.IncrCounterWithLabels([]string{"decisions", "publish", "success_count"}, 1, labels)
+			continue
+		}
+
+		logger.Warn("failed to publish decision event, retrying", "backoff", backoff, "error", err)
+		metrics.IncrCounterWithLabels([]string{"decisions", "publish", "error_count"}, 1, labels)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > decisionRetryMax {
+			backoff = decisionRetryMax
+		}
+	}
+}
+
+// push adds the payload to the queue, dropping the oldest payload if it is
+// full. It returns true if a payload was dropped.
+func (q *decisionQueue) push(payload []byte) bool {
+	q.l.Lock()
+	dropped := len(q.events) >= q.size
+	if dropped {
+		q.events = q.events[1:]
+	}
+	q.events = append(q.events, payload)
+	q.l.Unlock()
+
+	select {
+	case q.notifyCh <- struct{}{}:
+	default:
+	}
+	return dropped
+}
+
+// peek returns the oldest payload of the queue without removing it.
+func (q *decisionQueue) peek() ([]byte, bool) {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	if len(q.events) == 0 {
+		return nil, false
+	}
+	return q.events[0], true
+}
+
+// pop removes the payload from the head of the queue once it is published.
+// The payload may have been dropped in the meantime, in which case the queue
+// is left unchanged.
+func (q *decisionQueue) pop(payload []byte) {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	if len(q.events) > 0 && &q.events[0][0] == &payload[0] {
+		q.events = q.events[1:]
+	}
+}
+
+// WebhookPublisher is a DecisionPublisher which sends events as JSON payloads
+// within HTTP POST requests.
+type WebhookPublisher struct {
+	address string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookPublisher returns a new WebhookPublisher which sends events to
+// the address, including the headers in each request.
+func NewWebhookPublisher(address string, headers map[string]string) *WebhookPublisher {
+	return &WebhookPublisher{
+		address: address,
+		headers: headers,
+		client:  &http.Client{},
+	}
+}
+
+// Name satisfies the Name function of the DecisionPublisher interface.
+func (w *WebhookPublisher) Name() string { return "webhook" }
+
+// Publish satisfies the Publish function of the DecisionPublisher interface.
+func (w *WebhookPublisher) Publish(ctx context.Context, payload []byte) error {
+	return postJSON(ctx, w.client, w.address, w.headers, payload)
+}
@@ -0,0 +1,180 @@
+package policyeval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePublisher records the payloads published to it, failing the first
+// failures attempts.
+type fakePublisher struct {
+	l        sync.Mutex
+	failures int
+	attempts int
+	payloads []string
+}
+
+func (f *fakePublisher) Name() string { return "fake" }
+
+func (f *fakePublisher) Publish(_ context.Context, payload []byte) error {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("publish failed")
+	}
+	f.payloads = append(f.payloads, string(payload))
+	return nil
+}
+
+func (f *fakePublisher) published() []string {
+	f.l.Lock()
+	defer f.l.Unlock()
+	return append([]string{}, f.payloads...)
+}
+
+// queuedEvents decodes the events buffered by the sink for its first
+// publisher.
+func queuedEvents(t *testing.T, s *DecisionSink) []DecisionEvent {
+	s.queues[0].l.Lock()
+	defer s.queues[0].l.Unlock()
+
+	events := []DecisionEvent{}
+	for _, payload := range s.queues[0].events {
+		var event DecisionEvent
+		assert.NoError(t, json.Unmarshal(payload, &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestNewDecisionEvent(t *testing.T) {
+	p := &sdk.ScalingPolicy{
+		ID:       "policy1",
+		Revision: "42",
+		Target:   &sdk.ScalingPolicyTarget{Name: "nomad-target", Config: map[string]string{"dry-run": "true"}},
+	}
+	action := sdk.ScalingAction{
+		Count:     5,
+		Direction: sdk.ScaleDirectionUp,
+		Reason:    "scaling up",
+		Meta:      map[string]interface{}{sdk.StrategyActionMetaKeyMetricValue: "512 MiB"},
+	}
+
+	event := NewDecisionEvent(p, DecisionOutcomeSuppressed, "throttled", 3, action)
+	assert.NotEmpty(t, event.ID)
+	assert.False(t, event.Timestamp.IsZero())
+
+	event.ID, event.Timestamp = "", time.Time{}
+	assert.Equal(t, DecisionEvent{
+		PolicyID:       "policy1",
+		PolicyRevision: "42",
+		Target:         "nomad-target",
+		Outcome:        DecisionOutcomeSuppressed,
+		Reason:         "throttled",
+		Direction:      "up",
+		Count:          3,
+		DesiredCount:   5,
+		ActionReason:   "scaling up",
+		ReasonCode:     string(sdk.ReasonCodeStrategy),
+		DryRun:         true,
+		MetricValue:    "512 MiB",
+	}, event)
+}
+
+func TestNewDecisionSink(t *testing.T) {
+	assert.Nil(t, NewDecisionSink(hclog.NewNullLogger(), 10))
+
+	// A nil sink discards events.
+	var s *DecisionSink
+	s.Emit(DecisionEvent{})
+	s.Run(context.Background())
+
+	s = NewDecisionSink(hclog.NewNullLogger(), 0, &fakePublisher{})
+	assert.Equal(t, defaultDecisionBufferSize, s.queues[0].size)
+}
+
+func TestDecisionSink_Emit_bufferFull(t *testing.T) {
+	inm := newTestSink(t)
+	s := NewDecisionSink(hclog.NewNullLogger(), 2, &fakePublisher{})
+
+	for _, id := range []string{"policy1", "policy2", "policy3"} {
+		s.Emit(DecisionEvent{PolicyID: id})
+	}
+
+	events := queuedEvents(t, s)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "policy2", events[0].PolicyID)
+	assert.Equal(t, "policy3", events[1].PolicyID)
+	assert.Equal(t, 1, counterValue(inm, "decisions.dropped_count;publisher=fake"))
+}
+
+func TestDecisionSink_Run(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputFailures int
+	}{
+		{
+			name: "published",
+		},
+		{
+			name:          "retried after failure",
+			inputFailures: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pub := &fakePublisher{failures: tc.inputFailures}
+			s := NewDecisionSink(hclog.NewNullLogger(), 10, pub)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go s.Run(ctx)
+
+			s.Emit(DecisionEvent{PolicyID: "policy1"})
+			s.Emit(DecisionEvent{PolicyID: "policy2"})
+
+			// Events are published in order, including the retried event.
+			assert.Eventually(t, func() bool { return len(pub.published()) == 2 }, 5*time.Second, 10*time.Millisecond, tc.name)
+
+			var first DecisionEvent
+			assert.NoError(t, json.Unmarshal([]byte(pub.published()[0]), &first))
+			assert.Equal(t, "policy1", first.PolicyID, tc.name)
+			assert.Empty(t, queuedEvents(t, s), tc.name)
+		})
+	}
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	var body []byte
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		header = r.Header.Get("X-Token")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	pub := NewWebhookPublisher(srv.URL, map[string]string{"X-Token": "secret"})
+	assert.NoError(t, pub.Publish(context.Background(), []byte(`{"policy_id":"policy1"}`)))
+	assert.Equal(t, `{"policy_id":"policy1"}`, string(body))
+	assert.Equal(t, "secret", header)
+
+	pub = NewWebhookPublisher(srv.URL+"/fail", nil)
+	assert.Error(t, pub.Publish(context.Background(), []byte(`{}`)))
+}
@@ -0,0 +1,202 @@
+package policyeval
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultNATSPort is the port of the NATS server used if the address
+	// does not include one.
+	defaultNATSPort = "4222"
+
+	// natsConnectTimeout is the time allowed to connect to the NATS server
+	// when the publish context has no deadline.
+	natsConnectTimeout = 10 * time.Second
+)
+
+// NATSPublisher is a DecisionPublisher which publishes events to a subject of
+// a NATS server, using the core NATS protocol. Each publish is followed by a
+// PING, so an error is returned unless the server processed the message. The
+// connection is established on the first publish and re-established after
+// any error.
+type NATSPublisher struct {
+	address string
+	subject string
+	user    string
+	pass    string
+	token   string
+
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// natsInfo is the subset of the INFO message sent by the NATS server on
+// connect which is used by the publisher.
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// natsConnect is the CONNECT message sent to the NATS server.
+type natsConnect struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	User      string `json:"user,omitempty"`
+	Pass      string `json:"pass,omitempty"`
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+// NewNATSPublisher returns a new NATSPublisher which publishes events to the
+// subject of the NATS server at the address, in the nats://[user:pass@]host:port
+// form. A user without a password is used as the authentication token.
+func NewNATSPublisher(address, subject string) (*NATSPublisher, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "nats" || u.Hostname() == "" {
+		return nil, fmt.Errorf("address %q must be in the nats://host:port form", address)
+	}
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return nil, fmt.Errorf("subject %q is not valid", subject)
+	}
+
+	n := &NATSPublisher{subject: subject}
+
+	n.address = u.Host
+	if u.Port() == "" {
+		n.address = net.JoinHostPort(u.Hostname(), defaultNATSPort)
+	}
+
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			n.user, n.pass = u.User.Username(), pass
+		} else {
+			n.token = u.User.Username()
+		}
+	}
+	return n, nil
+}
+
+// Name satisfies the Name function of the DecisionPublisher interface.
+func (n *NATSPublisher) Name() string { return "nats" }
+
+// Publish satisfies the Publish function of the DecisionPublisher interface.
+func (n *NATSPublisher) Publish(ctx context.Context, payload []byte) error {
+	if err := n.publish(ctx, payload); err != nil {
+		n.close()
+		return err
+	}
+	return nil
+}
+
+func (n *NATSPublisher) publish(ctx context.Context, payload []byte) error {
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return fmt.Errorf("failed to connect to NATS server: %v", err)
+		}
+	}
+	n.setDeadline(ctx)
+
+	msg := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", n.subject, len(payload), payload)
+	if _, err := n.conn.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return n.waitPong()
+}
+
+// connect dials the NATS server and performs the protocol handshake.
+func (n *NATSPublisher) connect(ctx context.Context) error {
+	d := net.Dialer{Timeout: natsConnectTimeout}
+	conn, err := d.DialContext(ctx, "tcp", n.address)
+	if err != nil {
+		return err
+	}
+	n.conn = conn
+	n.reader = bufio.NewReader(conn)
+	n.setDeadline(ctx)
+
+	line, err := n.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected message %q", line)
+	}
+
+	var info natsInfo
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return fmt.Errorf("failed to decode server info: %v", err)
+	}
+	if info.TLSRequired {
+		return errors.New("server requires TLS, which is not supported")
+	}
+
+	connect, err := json.Marshal(natsConnect{
+		Name:      "nomad-autoscaler",
+		Lang:      "go",
+		User:      n.user,
+		Pass:      n.pass,
+		AuthToken: n.token,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(n.conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return err
+	}
+	return n.waitPong()
+}
+
+// waitPong reads from the server until it replies to a PING, answering the
+// PINGs of the server in the meantime.
+func (n *NATSPublisher) waitPong() error {
+	for {
+		line, err := n.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (n *NATSPublisher) readLine() (string, error) {
+	line, err := n.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// setDeadline applies the deadline of the context to the connection.
+func (n *NATSPublisher) setDeadline(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	_ = n.conn.SetDeadline(deadline)
+}
+
+func (n *NATSPublisher) close() {
+	if n.conn != nil {
+		_ = n.conn.Close()
+		n.conn = nil
+		n.reader = nil
+	}
+}
@@ -0,0 +1,205 @@
+package policyeval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+const (
+	// FailureAlertStatusFiring is the status of an alert sent when a policy
+	// reaches the consecutive failure threshold.
+	FailureAlertStatusFiring = "firing"
+
+	// FailureAlertStatusResolved is the status of an alert sent when a policy
+	// which previously fired an alert is evaluated successfully.
+	FailureAlertStatusResolved = "resolved"
+)
+
+// FailureAlert is the payload sent when a policy fails to evaluate repeatedly
+// and when it subsequently recovers.
+type FailureAlert struct {
+	Status              string    `json:"status"`
+	Severity            string    `json:"severity"`
+	PolicyID            string    `json:"policy_id"`
+	Target              string    `json:"target"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Reason              string    `json:"reason,omitempty"`
+	Timestamp           time.Time `json:"timestamp"`
+}
+
+// Alerter sends failure alerts to an external system.
+type Alerter interface {
+	Send(alert FailureAlert)
+}
+
+// FailureTracker counts the consecutive evaluation failures of each policy.
+// An alert is fired once the count reaches the threshold and a resolve alert
+// is sent when the policy is next evaluated successfully. It is safe for
+// concurrent use by multiple workers.
+type FailureTracker struct {
+	threshold int
+	severity  string
+	alerter   Alerter
+
+	l        sync.Mutex
+	failures map[string]*policyFailures
+}
+
+// policyFailures tracks the consecutive failures of a single policy.
+type policyFailures struct {
+	count  int
+	firing bool
+}
+
+// NewFailureTracker returns a new FailureTracker. A threshold lower than one
+// is treated as one.
+func NewFailureTracker(threshold int, severity string, alerter Alerter) *FailureTracker {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &FailureTracker{
+		threshold: threshold,
+		severity:  severity,
+		alerter:   alerter,
+		failures:  make(map[string]*policyFailures),
+	}
+}
+
+// RecordFailure records a failed evaluation of the policy, firing an alert if
+// this failure reaches the threshold.
+func (t *FailureTracker) RecordFailure(p *sdk.ScalingPolicy, reason error) {
+	if t == nil {
+		return
+	}
+
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	f, ok := t.failures[p.ID]
+	if !ok {
+		f = &policyFailures{}
+		t.failures[p.ID] = f
+	}
+	f.count++
+
+	if f.firing || f.count < t.threshold {
+		return
+	}
+
+	f.firing = true
+	t.alerter.Send(t.newAlert(FailureAlertStatusFiring, p, f.count, reason))
+}
+
+// RecordSuccess records a successful evaluation of the policy, resetting its
+// failure count and sending a resolve alert if an alert was fired.
+func (t *FailureTracker) RecordSuccess(p *sdk.ScalingPolicy) {
+	if t == nil {
+		return
+	}
+
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	f, ok := t.failures[p.ID]
+	if !ok {
+		return
+	}
+	delete(t.failures, p.ID)
+
+	if f.firing {
+		t.alerter.Send(t.newAlert(FailureAlertStatusResolved, p, f.count, nil))
+	}
+}
+
+func (t *FailureTracker) newAlert(status string, p *sdk.ScalingPolicy, count int, reason error) FailureAlert {
+	alert := FailureAlert{
+		Status:              status,
+		Severity:            t.severity,
+		PolicyID:            p.ID,
+		ConsecutiveFailures: count,
+		Timestamp:           time.Now().UTC(),
+	}
+	if p.Target != nil {
+		alert.Target = p.Target.Name
+	}
+	if reason != nil {
+		alert.Reason = reason.Error()
+	}
+	return alert
+}
+
+// WebhookAlerter is an Alerter which sends alerts as JSON payloads within HTTP
+// POST requests.
+type WebhookAlerter struct {
+	address string
+	headers map[string]string
+	client  *http.Client
+	logger  hclog.Logger
+}
+
+// NewWebhookAlerter returns a new WebhookAlerter which sends alerts to the
+// address, including the headers in each request.
+func NewWebhookAlerter(logger hclog.Logger, address string, headers map[string]string) *WebhookAlerter {
+	return &WebhookAlerter{
+		address: address,
+		headers: headers,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		logger:  logger.Named("webhook_alerter"),
+	}
+}
+
+// Send satisfies the Send function of the Alerter interface. The request is
+// made in the background so that policy evaluations are not delayed.
+func (w *WebhookAlerter) Send(alert FailureAlert) {
+	go func() {
+		if err := w.send(alert); err != nil {
+			w.logger.Error("failed to send alert", "policy_id", alert.PolicyID,
+				"status", alert.Status, "error", err)
+			return
+		}
+		w.logger.Debug("sent alert", "policy_id", alert.PolicyID, "status", alert.Status)
+	}()
+}
+
+func (w *WebhookAlerter) send(alert FailureAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %v", err)
+	}
+	return postJSON(context.Background(), w.client, w.address, w.headers, body)
+}
+
+// postJSON sends the JSON body to the address within an HTTP POST request,
+// including the headers, and returns an error unless the response code is
+// successful.
+func postJSON(ctx context.Context, client *http.Client, address string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package policyeval
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAlerter records the alerts sent to it.
+type fakeAlerter struct {
+	alerts []FailureAlert
+}
+
+func (f *fakeAlerter) Send(alert FailureAlert) { f.alerts = append(f.alerts, alert) }
+
+func TestFailureTracker(t *testing.T) {
+	p := &sdk.ScalingPolicy{ID: "policy1", Target: &sdk.ScalingPolicyTarget{Name: "nomad-target"}}
+	failErr := errors.New("failed to scale target")
+
+	testCases := []struct {
+		name             string
+		threshold        int
+		events           []bool
+		expectedStatuses []string
+		expectedCounts   []int
+	}{
+		{
+			name:             "failures below threshold",
+			threshold:        3,
+			events:           []bool{false, false, true},
+			expectedStatuses: []string{},
+			expectedCounts:   []int{},
+		},
+		{
+			name:             "failures reach threshold",
+			threshold:        3,
+			events:           []bool{false, false, false, false},
+			expectedStatuses: []string{FailureAlertStatusFiring},
+			expectedCounts:   []int{3},
+		},
+		{
+			name:             "recovery after alert",
+			threshold:        2,
+			events:           []bool{false, false, false, true},
+			expectedStatuses: []string{FailureAlertStatusFiring, FailureAlertStatusResolved},
+			expectedCounts:   []int{2, 3},
+		},
+		{
+			name:             "success resets failure count",
+			threshold:        2,
+			events:           []bool{false, true, false, true},
+			expectedStatuses: []string{},
+			expectedCounts:   []int{},
+		},
+		{
+			name:             "fires again after recovery",
+			threshold:        1,
+			events:           []bool{false, true, false},
+			expectedStatuses: []string{FailureAlertStatusFiring, FailureAlertStatusResolved, FailureAlertStatusFiring},
+			expectedCounts:   []int{1, 1, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			alerter := &fakeAlerter{}
+			tracker := NewFailureTracker(tc.threshold, "critical", alerter)
+
+			for _, success := range tc.events {
+				if success {
+					tracker.RecordSuccess(p)
+				} else {
+					tracker.RecordFailure(p, failErr)
+				}
+			}
+
+			statuses := []string{}
+			counts := []int{}
+			for _, a := range alerter.alerts {
+				statuses = append(statuses, a.Status)
+				counts = append(counts, a.ConsecutiveFailures)
+
+				assert.Equal(t, "critical", a.Severity, tc.name)
+				assert.Equal(t, "policy1", a.PolicyID, tc.name)
+				assert.Equal(t, "nomad-target", a.Target, tc.name)
+				if a.Status == FailureAlertStatusFiring {
+					assert.Equal(t, failErr.Error(), a.Reason, tc.name)
+				}
+			}
+			assert.Equal(t, tc.expectedStatuses, statuses, tc.name)
+			assert.Equal(t, tc.expectedCounts, counts, tc.name)
+		})
+	}
+}
+
+func TestFailureTracker_nil(t *testing.T) {
+	var tracker *FailureTracker
+	p := &sdk.ScalingPolicy{ID: "policy1"}
+
+	// A nil tracker disables alerting and must be safe to use.
+	tracker.RecordFailure(p, errors.New("error"))
+	tracker.RecordSuccess(p)
+}
+
+func TestWebhookAlerter_Send(t *testing.T) {
+	received := make(chan FailureAlert, 1)
+	var authHeader string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+
+		var alert FailureAlert
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&alert))
+		received <- alert
+	}))
+	defer srv.Close()
+
+	alerter := NewWebhookAlerter(hclog.NewNullLogger(), srv.URL, map[string]string{"Authorization": "Bearer token"})
+
+	sent := FailureAlert{
+		Status:              FailureAlertStatusFiring,
+		Severity:            "critical",
+		PolicyID:            "policy1",
+		ConsecutiveFailures: 3,
+		Reason:              "failed to scale target",
+		Timestamp:           time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+	alerter.Send(sent)
+
+	select {
+	case actual := <-received:
+		assert.Equal(t, sent, actual)
+		assert.Equal(t, "Bearer token", authHeader)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for alert")
+	}
+}
@@ -0,0 +1,164 @@
+package policyeval
+
+import (
+	"context"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// actionGuard is a guard which can suppress the scaling action of a policy.
+// The guards are shared by the policy evaluations, which stop at the first
+// guard suppressing the action, and the policy previews, which report all of
+// them, so both apply the same guards in the same order.
+type actionGuard struct {
+	// reason is the suppression reason recorded when the guard suppresses
+	// the action, and err the error returned by scaleTarget.
+	reason string
+	err    error
+
+	// level and message are used to log that the guard suppressed the
+	// action of an evaluation.
+	level   hclog.Level
+	message string
+
+	// suppresses reports whether the guard suppresses the action, along
+	// with any args logged in addition to the action.
+	suppresses func() (bool, []interface{})
+}
+
+// targetGuards returns the guards which protect the target of the policy from
+// being scaled by any scaling action, whether decided by the policy checks or
+// bypassing them.
+func (w *BaseWorker) targetGuards(ctx context.Context, p *sdk.ScalingPolicy) []actionGuard {
+	return []actionGuard{
+		{
+			// Only the leader scales targets. Followers stop here having
+			// evaluated the policy, so they are ready to take over.
+			reason:     policy.SuppressionReasonNotLeader,
+			err:        errNotLeader,
+			level:      hclog.Debug,
+			message:    "agent is not the leader, skipping scaling",
+			suppresses: func() (bool, []interface{}) { return !w.isLeader(), nil },
+		},
+		{
+			// Guard against policies scaling targets the agent must not
+			// touch, such as those managed by other teams within a shared
+			// cluster.
+			reason:     policy.SuppressionReasonTargetNotAllowed,
+			err:        errTargetNotAllowed,
+			level:      hclog.Warn,
+			message:    "target is not allowed by the agent target access config, skipping scaling",
+			suppresses: func() (bool, []interface{}) { return !w.targetAccess.Allowed(p), nil },
+		},
+		{
+			// Guard against policies from different sources scaling the
+			// same target, when the conflict is resolved in favour of
+			// another policy.
+			reason:     policy.SuppressionReasonPolicyConflict,
+			err:        errPolicyConflict,
+			level:      hclog.Warn,
+			message:    "target is also scaled by a policy from another source, skipping scaling",
+			suppresses: func() (bool, []interface{}) { return !w.policyManager.TargetAllowed(p.ID), nil },
+		},
+		{
+			// Defer scaling while the external maintenance condition says
+			// to hold.
+			reason:     policy.SuppressionReasonMaintenance,
+			err:        errMaintenanceHold,
+			level:      hclog.Info,
+			message:    "scaling held by maintenance condition, deferring to the next evaluation",
+			suppresses: func() (bool, []interface{}) { return w.maintenanceGate.Hold(ctx), nil },
+		},
+	}
+}
+
+// checkGuards returns the guards which only apply to the scaling actions
+// decided by the policy checks, as they protect against the checks changing
+// the count too often.
+func (w *BaseWorker) checkGuards(p *sdk.ScalingPolicy, action *sdk.ScalingAction) []actionGuard {
+	return []actionGuard{
+		{
+			// Guard against a cascade of scale downs caused by a misbehaving
+			// metric by limiting how many the checks perform in a row.
+			reason:  policy.SuppressionReasonScaleDownLimit,
+			level:   hclog.Warn,
+			message: "scale down suppressed, policy reached its limit of consecutive scale downs",
+			suppresses: func() (bool, []interface{}) {
+				limited := action.Direction == sdk.ScaleDirectionDown &&
+					!w.policyManager.ScaleDownAllowed(p.ID, p.MaxConsecutiveScaleDowns, p.ConsecutiveScaleDownsReset)
+				return limited, []interface{}{"limit", p.MaxConsecutiveScaleDowns}
+			},
+		},
+		{
+			// Scale down gradually by waiting for the step down delay after
+			// each scale down. The next step is taken by a later
+			// evaluation, and only if the checks still warrant it.
+			reason:  policy.SuppressionReasonStepDownDelay,
+			level:   hclog.Info,
+			message: "scale down delayed until the next step is due",
+			suppresses: func() (bool, []interface{}) {
+				if action.Direction != sdk.ScaleDirectionDown {
+					return false, nil
+				}
+				until := w.policyManager.StepDownDelayedUntil(p.ID, p.ScaleDownStepDelay)
+				return !until.IsZero(), []interface{}{"delayed_until", until}
+			},
+		},
+		{
+			// Give the capacity added by the last scale up time to take
+			// effect before scaling up again, as the metrics may not reflect
+			// it yet.
+			reason:  policy.SuppressionReasonProbation,
+			level:   hclog.Info,
+			message: "scale up suppressed during probation after the last scale up",
+			suppresses: func() (bool, []interface{}) {
+				if action.Direction != sdk.ScaleDirectionUp {
+					return false, nil
+				}
+				until := w.policyManager.ScaleUpProbationUntil(p.ID, p.ScaleUpProbation)
+				return !until.IsZero(), []interface{}{"probation_until", until}
+			},
+		},
+	}
+}
+
+// throttleGuard returns the guard which defers the scaling action if the
+// agent has performed too many recently. The guard of a preview only checks
+// whether the throttle has capacity left, rather than using it.
+func (w *BaseWorker) throttleGuard(preview bool) actionGuard {
+	return actionGuard{
+		reason:  policy.SuppressionReasonThrottled,
+		err:     errScaleThrottled,
+		level:   hclog.Info,
+		message: "scaling action throttled, deferring to the next evaluation",
+		suppresses: func() (bool, []interface{}) {
+			if preview {
+				return !w.scaleThrottle.Available(), nil
+			}
+			return !w.scaleThrottle.Allow(), nil
+		},
+	}
+}
+
+// applyGuards applies the guards in order to the scaling action of the policy
+// target at count, and returns the first guard which suppresses it, if any.
+// The suppression is logged, counted and emitted as a decision.
+func (w *BaseWorker) applyGuards(logger hclog.Logger, p *sdk.ScalingPolicy, count int64, action *sdk.ScalingAction, guards ...actionGuard) *actionGuard {
+	for i := range guards {
+		g := &guards[i]
+
+		suppressed, args := g.suppresses()
+		if !suppressed {
+			continue
+		}
+
+		args = append([]interface{}{"direction", action.Direction, "count", count, "desired_count", action.Count}, args...)
+		logger.Log(g.level, g.message, args...)
+		policy.IncrSuppressedCount(p.ID, g.reason)
+		w.emitDecision(p, DecisionOutcomeSuppressed, g.reason, count, action)
+		return g
+	}
+	return nil
+}
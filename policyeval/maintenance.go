@@ -0,0 +1,190 @@
+package policyeval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/clock"
+)
+
+// The values of the maintenance condition. They are also the values of the
+// on error setting, which decides the condition when it cannot be read.
+const (
+	MaintenanceAllow = "allow"
+	MaintenanceHold  = "hold"
+)
+
+const (
+	// maintenanceReadTimeout is the time allowed to read the maintenance
+	// condition from its source.
+	maintenanceReadTimeout = 10 * time.Second
+
+	// maintenanceMaxSize is the number of bytes read from the maintenance
+	// condition source, which is more than the condition values require.
+	maintenanceMaxSize = 1024
+)
+
+// MaintenanceState is the state of the maintenance condition, as last read
+// from its source.
+type MaintenanceState struct {
+	// Source is the address or path the condition is read from.
+	Source string
+
+	// Hold indicates scaling actions are deferred.
+	Hold bool
+
+	// CheckedAt is when the condition was last read.
+	CheckedAt time.Time
+
+	// Error is the reason the condition could not be read, in which case Hold
+	// follows the on error setting of the gate.
+	Error string
+}
+
+// MaintenanceGate defers scaling actions while an external condition, read
+// from an HTTP endpoint or a file, says to hold. This allows an external
+// system to declare maintenance windows which gate all automated changes. The
+// condition is "allow" or "hold", with "deny" accepted as a synonym of
+// "hold", and is cached for the TTL so it is not read for every scaling
+// action. It is safe for concurrent use by multiple workers.
+type MaintenanceGate struct {
+	logger      hclog.Logger
+	clock       clock.Clock
+	source      string
+	read        func(ctx context.Context) (string, error)
+	ttl         time.Duration
+	holdOnError bool
+
+	// l protects the cached state, and is held while the condition is read
+	// so concurrent scaling actions wait for a single read.
+	l      sync.Mutex
+	state  MaintenanceState
+	expiry time.Time
+}
+
+// NewHTTPMaintenanceGate returns a new MaintenanceGate which reads the
+// condition from the body of the response to a GET request to the address,
+// including the headers in the request. Responses with an unsuccessful code
+// are considered errors.
+func NewHTTPMaintenanceGate(logger hclog.Logger, address string, headers map[string]string, ttl time.Duration, onError string) *MaintenanceGate {
+	client := &http.Client{}
+
+	return newMaintenanceGate(logger, address, ttl, onError, func(ctx context.Context) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return "", fmt.Errorf("unexpected response code %d", resp.StatusCode)
+		}
+
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maintenanceMaxSize))
+		return string(body), err
+	})
+}
+
+// NewFileMaintenanceGate returns a new MaintenanceGate which reads the
+// condition from the contents of the file at the path. Scaling is allowed
+// while the file does not exist.
+func NewFileMaintenanceGate(logger hclog.Logger, path string, ttl time.Duration, onError string) *MaintenanceGate {
+	return newMaintenanceGate(logger, path, ttl, onError, func(_ context.Context) (string, error) {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			return MaintenanceAllow, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		content, err := ioutil.ReadAll(io.LimitReader(f, maintenanceMaxSize))
+		return string(content), err
+	})
+}
+
+func newMaintenanceGate(logger hclog.Logger, source string, ttl time.Duration, onError string, read func(ctx context.Context) (string, error)) *MaintenanceGate {
+	return &MaintenanceGate{
+		logger:      logger.Named("maintenance_gate").With("source", source),
+		clock:       clock.Real(),
+		source:      source,
+		read:        read,
+		ttl:         ttl,
+		holdOnError: onError != MaintenanceAllow,
+	}
+}
+
+// Hold returns whether scaling actions must be deferred. A nil gate never
+// holds.
+func (g *MaintenanceGate) Hold(ctx context.Context) bool {
+	if g == nil {
+		return false
+	}
+	return g.State(ctx).Hold
+}
+
+// State returns the state of the maintenance condition, reading it from its
+// source if the cached state has expired.
+func (g *MaintenanceGate) State(ctx context.Context) MaintenanceState {
+	g.l.Lock()
+	defer g.l.Unlock()
+
+	now := g.clock.Now()
+	if !g.state.CheckedAt.IsZero() && now.Before(g.expiry) {
+		return g.state
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, maintenanceReadTimeout)
+	defer cancel()
+
+	state := MaintenanceState{Source: g.source, CheckedAt: now}
+
+	value, err := g.read(readCtx)
+	if err == nil {
+		state.Hold, err = parseMaintenanceCondition(value)
+	}
+	if err != nil {
+		state.Hold = g.holdOnError
+		state.Error = err.Error()
+		g.logger.Warn("failed to read maintenance condition", "hold", state.Hold, "error", err)
+	}
+
+	if state.Hold != g.state.Hold || g.state.CheckedAt.IsZero() {
+		g.logger.Info("maintenance condition updated", "hold", state.Hold)
+	}
+
+	g.state = state
+	g.expiry = now.Add(g.ttl)
+	return state
+}
+
+// parseMaintenanceCondition returns whether the condition value says to hold
+// scaling actions.
+func parseMaintenanceCondition(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case MaintenanceAllow:
+		return false, nil
+	case MaintenanceHold, "deny":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unexpected maintenance condition %q, must be %q or %q",
+			strings.TrimSpace(value), MaintenanceAllow, MaintenanceHold)
+	}
+}
@@ -0,0 +1,195 @@
+package policyeval
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseMaintenanceCondition(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputValue    string
+		expectedHold  bool
+		expectedError bool
+	}{
+		{
+			name:       "allow",
+			inputValue: "allow",
+		},
+		{
+			name:         "hold",
+			inputValue:   "hold\n",
+			expectedHold: true,
+		},
+		{
+			name:         "deny",
+			inputValue:   " DENY ",
+			expectedHold: true,
+		},
+		{
+			name:          "empty",
+			inputValue:    "",
+			expectedError: true,
+		},
+		{
+			name:          "unknown",
+			inputValue:    "maybe",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hold, err := parseMaintenanceCondition(tc.inputValue)
+			if tc.expectedError {
+				assert.Error(t, err, tc.name)
+				return
+			}
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expectedHold, hold, tc.name)
+		})
+	}
+}
+
+func TestMaintenanceGate_nil(t *testing.T) {
+	var g *MaintenanceGate
+	assert.False(t, g.Hold(context.Background()))
+}
+
+func TestMaintenanceGate_State(t *testing.T) {
+	testCases := []struct {
+		name          string
+		inputValue    string
+		inputErr      error
+		inputOnError  string
+		expectedHold  bool
+		expectedError bool
+	}{
+		{
+			name:       "allow",
+			inputValue: "allow",
+		},
+		{
+			name:         "hold",
+			inputValue:   "hold",
+			expectedHold: true,
+		},
+		{
+			name:          "read error holds",
+			inputErr:      os.ErrPermission,
+			inputOnError:  MaintenanceHold,
+			expectedHold:  true,
+			expectedError: true,
+		},
+		{
+			name:          "read error allows",
+			inputErr:      os.ErrPermission,
+			inputOnError:  MaintenanceAllow,
+			expectedError: true,
+		},
+		{
+			name:          "invalid condition holds",
+			inputValue:    "maybe",
+			inputOnError:  MaintenanceHold,
+			expectedHold:  true,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := newMaintenanceGate(hclog.NewNullLogger(), "test", time.Minute, tc.inputOnError,
+				func(context.Context) (string, error) { return tc.inputValue, tc.inputErr })
+
+			state := g.State(context.Background())
+			assert.Equal(t, "test", state.Source, tc.name)
+			assert.Equal(t, tc.expectedHold, state.Hold, tc.name)
+			assert.Equal(t, tc.expectedError, state.Error != "", tc.name)
+			assert.False(t, state.CheckedAt.IsZero(), tc.name)
+		})
+	}
+}
+
+func TestMaintenanceGate_cache(t *testing.T) {
+	var reads int32
+	value := atomic.Value{}
+	value.Store("hold")
+
+	c := clock.NewFake(time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC))
+	g := newMaintenanceGate(hclog.NewNullLogger(), "test", time.Minute, MaintenanceHold,
+		func(context.Context) (string, error) {
+			atomic.AddInt32(&reads, 1)
+			return value.Load().(string), nil
+		})
+	g.clock = c
+
+	assert.True(t, g.Hold(context.Background()))
+
+	// The cached condition is used until the TTL expires.
+	value.Store("allow")
+	c.Advance(59 * time.Second)
+	assert.True(t, g.Hold(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reads))
+
+	c.Advance(time.Second)
+	assert.False(t, g.Hold(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reads))
+}
+
+func TestNewHTTPMaintenanceGate(t *testing.T) {
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Token")
+		switch r.URL.Path {
+		case "/hold":
+			_, _ = w.Write([]byte("hold\n"))
+		case "/allow":
+			_, _ = w.Write([]byte("allow"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	g := NewHTTPMaintenanceGate(hclog.NewNullLogger(), srv.URL+"/hold", map[string]string{"X-Token": "secret"}, 0, MaintenanceAllow)
+	assert.True(t, g.Hold(context.Background()))
+	assert.Equal(t, "secret", header)
+
+	g = NewHTTPMaintenanceGate(hclog.NewNullLogger(), srv.URL+"/allow", nil, 0, MaintenanceHold)
+	assert.False(t, g.Hold(context.Background()))
+
+	// Unsuccessful responses are errors.
+	g = NewHTTPMaintenanceGate(hclog.NewNullLogger(), srv.URL+"/fail", nil, 0, MaintenanceHold)
+	state := g.State(context.Background())
+	assert.True(t, state.Hold)
+	assert.Contains(t, state.Error, "500")
+}
+
+func TestNewFileMaintenanceGate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomad-autoscaler")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "maintenance")
+	g := NewFileMaintenanceGate(hclog.NewNullLogger(), path, 0, MaintenanceHold)
+
+	// Scaling is allowed while the file does not exist.
+	assert.False(t, g.Hold(context.Background()))
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("hold\n"), 0644))
+	assert.True(t, g.Hold(context.Background()))
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("allow\n"), 0644))
+	assert.False(t, g.Hold(context.Background()))
+}
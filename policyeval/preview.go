@@ -0,0 +1,386 @@
+package policyeval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// The outcomes of a policy preview.
+const (
+	// PreviewOutcomeNone indicates the evaluation would not change the count.
+	PreviewOutcomeNone = "none"
+
+	// PreviewOutcomeScale indicates the evaluation would scale the target.
+	PreviewOutcomeScale = "scale"
+
+	// PreviewOutcomeSuppressed indicates the evaluation would want to change
+	// the count, but a guard would suppress the scaling action.
+	PreviewOutcomeSuppressed = "suppressed"
+
+	// PreviewOutcomeAdvisory indicates the evaluation would only publish the
+	// scaling action, as the policy is advisory.
+	PreviewOutcomeAdvisory = "advisory"
+
+	// PreviewOutcomeError indicates the policy could not be evaluated.
+	PreviewOutcomeError = "error"
+)
+
+// The effects a guard has on the scaling action within a policy preview.
+const (
+	// PreviewEffectSuppress indicates the guard would suppress the action.
+	PreviewEffectSuppress = "suppress"
+
+	// PreviewEffectModify indicates the guard would change the count of the
+	// action.
+	PreviewEffectModify = "modify"
+)
+
+// previewReasonDisabled is the guard reported for disabled policies, which
+// are not evaluated.
+const previewReasonDisabled = "disabled"
+
+// PolicyPreview is the outcome an evaluation of a policy would have if it ran
+// now, as returned by Preview.
+type PolicyPreview struct {
+	PolicyID string
+	Target   string
+
+	// Outcome is one of the PreviewOutcome constants.
+	Outcome string
+
+	// Count is the current count of the target. DesiredCount is the count
+	// wanted by the strategy, or the override, bounds or pinned count which
+	// replaces it, and ProposedCount the count the target would be scaled
+	// to once the policy limits and guards which modify it are applied.
+	Count         int64
+	DesiredCount  int64
+	ProposedCount int64
+	Direction     string
+
+	// Check is the name of the check which decided the action, if the policy
+	// checks were run, and Reason the reason given for the action.
+	Check  string
+	Reason string
+
+	// Guards are the guards which would suppress or modify the action, in
+	// the order they are applied. Unlike an evaluation, which stops at the
+	// first guard suppressing the action, all of them are reported.
+	Guards []PreviewGuard
+
+	// Error is the reason the policy, or some of its checks, could not be
+	// evaluated.
+	Error string
+}
+
+// PreviewGuard is a guard which would suppress or modify the scaling action
+// within a policy preview.
+type PreviewGuard struct {
+	// Name is the suppression reason of the guard, as used by the suppressed
+	// evaluation metrics.
+	Name string
+
+	// Effect is one of the PreviewEffect constants, and Count the count
+	// allowed by a guard which modifies the action.
+	Effect string
+	Count  int64
+}
+
+// Preview evaluates the described policy once, following the same steps as a
+// policy evaluation, and returns the outcome the evaluation would have if it
+// ran now. It does not scale the target or record any state, so the cooldown,
+// budgets and recommendations used by later evaluations are not affected.
+// The pre-scale hook is not run, as it may have side effects, and conflicts
+// with other policies scaling the same target are not detected.
+func (w *BaseWorker) Preview(ctx context.Context, desc *policy.PolicyDescription) *PolicyPreview {
+	now := w.clock.Now()
+	id := desc.Policy.ID
+	logger := w.logger.With(policyLogArgs(desc.Policy)...)
+
+	pv := &PolicyPreview{
+		PolicyID:  id,
+		Outcome:   PreviewOutcomeNone,
+		Direction: sdk.ScaleDirection(sdk.ScaleDirectionNone).String(),
+	}
+	if desc.Policy.Target != nil {
+		pv.Target = desc.Policy.Target.Name
+	}
+
+	p, err := w.policyDefaults.Apply(logger, desc.Policy)
+	if err == nil {
+		p, err = resolveTargetTemplates(p)
+	}
+	if err != nil {
+		pv.fail(err)
+		return pv
+	}
+	p = applyScheduledMin(logger, p, now)
+
+	// The policy handler does not request evaluations of disabled policies,
+	// or of those within their warmup period or cooldown, and the worker
+	// defers those whose dependencies are not stable.
+	if !p.Enabled {
+		pv.suppress(previewReasonDisabled)
+	}
+	if desc.WarmingUp {
+		pv.suppress(policy.SuppressionReasonWarmup)
+	}
+	if desc.CooldownUntil.After(now) {
+		pv.suppress(policy.SuppressionReasonCooldown)
+	}
+	if w.policyManager.BusyDependency(p.DependsOn, p.DependsOnWindow) != "" {
+		pv.suppress(policy.SuppressionReasonDependency)
+	}
+
+	// The override, bounds correction and pinned count bypass the checks in
+	// the same order as they take precedence during an evaluation. A count
+	// within the bounds leaves the correction to the checks.
+	if o := w.policyManager.ActiveOverride(id); o != nil {
+		w.previewBypass(ctx, pv, p, false, func(count int64) *sdk.ScalingAction { return overrideAction(o, count) })
+		return pv
+	}
+	if p.OutOfBoundsAction == sdk.OutOfBoundsActionCorrect &&
+		w.previewBypass(ctx, pv, p, true, func(count int64) *sdk.ScalingAction { return boundsAction(p, count) }) {
+		return pv
+	}
+	if p.Min == p.Max {
+		w.previewBypass(ctx, pv, p, false, func(count int64) *sdk.ScalingAction { return pinnedAction(p, count) })
+		return pv
+	}
+
+	w.previewChecks(ctx, logger, pv, p)
+	return pv
+}
+
+// previewBypass previews the scaling action returned by actionFn, which
+// bypasses the policy checks, in the same way as scaleTarget. If optional is
+// true and actionFn returns nil, nothing is recorded and false is returned so
+// the checks are previewed instead.
+func (w *BaseWorker) previewBypass(ctx context.Context, pv *PolicyPreview, p *sdk.ScalingPolicy, optional bool, actionFn func(count int64) *sdk.ScalingAction) bool {
+	targetPlugin, err := w.pluginManager.Dispense(p.Target.Name, plugins.PluginTypeTarget)
+	if err != nil {
+		pv.fail(fmt.Errorf(`target plugin "%s" not initialized: %v`, p.Target.Name, err))
+		return true
+	}
+
+	status, err := targetPlugin.Plugin().(target.Target).Status(p.Target.Config)
+	if err != nil {
+		pv.fail(fmt.Errorf("failed to fetch current count: %v", err))
+		return true
+	}
+	if status == nil || !status.Ready {
+		pv.suppress(policy.SuppressionReasonTargetNotReady)
+		return true
+	}
+	if err := validateTargetStatus(status); err != nil {
+		pv.fail(fmt.Errorf("failed to fetch current count: %v", err))
+		return true
+	}
+
+	action := actionFn(status.Count)
+	if action == nil && optional {
+		return false
+	}
+
+	pv.Count, pv.DesiredCount, pv.ProposedCount = status.Count, status.Count, status.Count
+	if action == nil {
+		return true
+	}
+	pv.setAction(action)
+	pv.DesiredCount = action.Count
+
+	if deferForDeployment(p, status) {
+		pv.suppress(policy.SuppressionReasonDeployment)
+	}
+	if p.Advisory {
+		pv.advise()
+		return true
+	}
+	pv.applyGuards(append(w.targetGuards(ctx, p), w.throttleGuard(true))...)
+	pv.finish()
+	return true
+}
+
+// previewChecks previews the scaling action decided by the policy checks, in
+// the same way as handlePolicy. The check handlers are cancelled once they
+// return their action, so they never scale the target.
+func (w *BaseWorker) previewChecks(ctx context.Context, logger hclog.Logger, pv *PolicyPreview, p *sdk.ScalingPolicy) {
+	eval := sdk.NewScalingEvaluation(p, nil)
+
+	handlersCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	checks := make([]*checkHandler, 0, len(eval.CheckEvaluations))
+	for _, checkEval := range eval.CheckEvaluations {
+		checkHandler := newCheckHandler(logger, p, checkEval, w.pluginManager, w.queryCache, w.resultCache, w.clock)
+		checks = append(checks, checkHandler)
+		go checkHandler.start(handlersCtx)
+	}
+
+	actions := make([]*sdk.ScalingAction, len(checks))
+	counts := make([]int64, len(checks))
+	desired := make([]*int64, len(checks))
+
+	var currentCount int64
+	var countRead bool
+	var checkErrs []string
+	var suppressed []string
+
+	for i, handler := range checks {
+		check := eval.CheckEvaluations[i].Check.Name
+
+		select {
+		case <-ctx.Done():
+			pv.fail(ctx.Err())
+			return
+		case r := <-handler.results():
+			switch r.err {
+			case nil:
+			case errTargetNotReady:
+				pv.suppress(policy.SuppressionReasonTargetNotReady)
+				return
+			case errDeploymentInProgress:
+				pv.suppress(policy.SuppressionReasonDeployment)
+				return
+			case errCountUnstable:
+				pv.suppress(policy.SuppressionReasonCountUnstable)
+				return
+			default:
+				checkErrs = append(checkErrs, fmt.Sprintf("check %s: %v", check, r.err))
+				continue
+			}
+
+			if r.suppressed != "" {
+				suppressed = append(suppressed, r.suppressed)
+			}
+			currentCount, countRead = r.count, true
+			actions[i], counts[i], desired[i] = r.action, r.count, r.desired
+		}
+	}
+
+	if len(checkErrs) > 0 {
+		pv.Error = fmt.Sprintf("failed to evaluate policy checks: %s", strings.Join(checkErrs, "; "))
+		if !countRead {
+			pv.Outcome = PreviewOutcomeError
+		}
+	}
+	if !countRead {
+		return
+	}
+	pv.Count, pv.DesiredCount, pv.ProposedCount = currentCount, currentCount, currentCount
+
+	winner, err := reconcileActions(w.multipleActions, p, actions)
+	if err != nil {
+		pv.fail(err)
+		return
+	}
+	if winner < 0 {
+		return
+	}
+	pv.Check = eval.CheckEvaluations[winner].Check.Name
+	if desired[winner] != nil {
+		pv.DesiredCount = *desired[winner]
+	}
+
+	// Copy the action, as it is shared with the check handler.
+	action := *actions[winner]
+	count := counts[winner]
+
+	if p.OutOfBoundsAction == sdk.OutOfBoundsActionWait && boundsAction(p, currentCount) != nil {
+		pv.suppress(policy.SuppressionReasonOutOfBounds)
+	}
+
+	if action.Direction == sdk.ScaleDirectionNone {
+		for _, reason := range suppressed {
+			pv.suppress(reason)
+		}
+		return
+	}
+	pv.setAction(&action)
+
+	if window := p.ScaleDownStabilizationWindow; window > 0 && action.Direction == sdk.ScaleDirectionDown {
+		highest := w.policyManager.HighestRecommendation(p.ID, action.Count, window)
+		if !stabilizeScaleDown(p, &action, count, highest) {
+			pv.suppress(policy.SuppressionReasonStabilization)
+		} else if action.Count != pv.ProposedCount {
+			pv.modify(policy.SuppressionReasonStabilization, action.Count)
+		}
+	}
+
+	if p.Advisory {
+		pv.advise()
+		return
+	}
+
+	pv.applyGuards(append(w.targetGuards(ctx, p), w.checkGuards(p, &action)...)...)
+
+	if allowed := w.capacityBudget.Allowed(p, count, pv.ProposedCount); allowed != pv.ProposedCount {
+		if allowed == count {
+			pv.suppress(policy.SuppressionReasonCapacityBudget)
+		} else {
+			pv.modify(policy.SuppressionReasonCapacityBudget, allowed)
+		}
+	}
+
+	pv.applyGuards(w.throttleGuard(true))
+	pv.finish()
+}
+
+// applyGuards records each of the guards which would suppress the action of
+// the preview, in the order they are applied by an evaluation.
+func (pv *PolicyPreview) applyGuards(guards ...actionGuard) {
+	for _, g := range guards {
+		if suppressed, _ := g.suppresses(); suppressed {
+			pv.suppress(g.reason)
+		}
+	}
+}
+
+// setAction records the scaling action within the preview.
+func (pv *PolicyPreview) setAction(a *sdk.ScalingAction) {
+	pv.ProposedCount = a.Count
+	pv.Direction = a.Direction.String()
+	pv.Reason = a.Reason
+}
+
+// suppress records a guard which suppresses the scaling action.
+func (pv *PolicyPreview) suppress(name string) {
+	pv.Guards = append(pv.Guards, PreviewGuard{Name: name, Effect: PreviewEffectSuppress})
+	if pv.Outcome != PreviewOutcomeError {
+		pv.Outcome = PreviewOutcomeSuppressed
+	}
+}
+
+// modify records a guard which changes the count of the scaling action to
+// count.
+func (pv *PolicyPreview) modify(name string, count int64) {
+	pv.Guards = append(pv.Guards, PreviewGuard{Name: name, Effect: PreviewEffectModify, Count: count})
+	pv.ProposedCount = count
+}
+
+// advise records the outcome of an advisory policy, unless a guard applied
+// before the policy is evaluated suppresses it.
+func (pv *PolicyPreview) advise() {
+	if pv.Outcome == PreviewOutcomeNone {
+		pv.Outcome = PreviewOutcomeAdvisory
+	}
+}
+
+// finish records the outcome of a scaling action which no guard suppresses.
+func (pv *PolicyPreview) finish() {
+	if pv.Outcome == PreviewOutcomeNone {
+		pv.Outcome = PreviewOutcomeScale
+	}
+}
+
+// fail records the reason the policy could not be evaluated.
+func (pv *PolicyPreview) fail(err error) {
+	pv.Outcome = PreviewOutcomeError
+	pv.Error = err.Error()
+}
@@ -0,0 +1,210 @@
+package policyeval
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseWorker_Preview(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputCount       int64
+		inputDesired     int64
+		inputPolicy      func(p *sdk.ScalingPolicy)
+		inputWorker      func(w *testWorker)
+		inputCooldown    bool
+		expectedOutcome  string
+		expectedDesired  int64
+		expectedProposed int64
+		expectedCheck    string
+		expectedGuards   []PreviewGuard
+		expectedError    bool
+	}{
+		{
+			name:             "scale up",
+			inputCount:       2,
+			inputDesired:     5,
+			expectedOutcome:  PreviewOutcomeScale,
+			expectedDesired:  5,
+			expectedProposed: 5,
+			expectedCheck:    "check",
+		},
+		{
+			name:             "no change",
+			inputCount:       3,
+			inputDesired:     3,
+			expectedOutcome:  PreviewOutcomeNone,
+			expectedDesired:  3,
+			expectedProposed: 3,
+			expectedCheck:    "check",
+		},
+		{
+			name:             "capped to policy max",
+			inputCount:       2,
+			inputDesired:     20,
+			expectedOutcome:  PreviewOutcomeScale,
+			expectedDesired:  20,
+			expectedProposed: 10,
+			expectedCheck:    "check",
+		},
+		{
+			name:             "pinned",
+			inputCount:       2,
+			inputDesired:     5,
+			inputPolicy:      func(p *sdk.ScalingPolicy) { p.Min, p.Max = 3, 3 },
+			expectedOutcome:  PreviewOutcomeScale,
+			expectedDesired:  3,
+			expectedProposed: 3,
+		},
+		{
+			name:             "advisory",
+			inputCount:       2,
+			inputDesired:     5,
+			inputPolicy:      func(p *sdk.ScalingPolicy) { p.Advisory = true },
+			inputWorker:      func(w *testWorker) { w.leadership = fakeLeadership(false) },
+			expectedOutcome:  PreviewOutcomeAdvisory,
+			expectedDesired:  5,
+			expectedProposed: 5,
+			expectedCheck:    "check",
+		},
+		{
+			name:         "suppressed by all guards",
+			inputCount:   2,
+			inputDesired: 5,
+			inputWorker: func(w *testWorker) {
+				w.leadership = fakeLeadership(false)
+				w.scaleThrottle = NewScaleThrottle(1, 1)
+				w.scaleThrottle.Allow()
+			},
+			inputCooldown:    true,
+			expectedOutcome:  PreviewOutcomeSuppressed,
+			expectedDesired:  5,
+			expectedProposed: 5,
+			expectedCheck:    "check",
+			expectedGuards: []PreviewGuard{
+				{Name: policy.SuppressionReasonCooldown, Effect: PreviewEffectSuppress},
+				{Name: policy.SuppressionReasonNotLeader, Effect: PreviewEffectSuppress},
+				{Name: policy.SuppressionReasonThrottled, Effect: PreviewEffectSuppress},
+			},
+		},
+		{
+			name:         "pinned suppressed by throttle",
+			inputCount:   2,
+			inputDesired: 5,
+			inputPolicy:  func(p *sdk.ScalingPolicy) { p.Min, p.Max = 3, 3 },
+			inputWorker: func(w *testWorker) {
+				w.scaleThrottle = NewScaleThrottle(1, 1)
+				w.scaleThrottle.Allow()
+			},
+			expectedOutcome:  PreviewOutcomeSuppressed,
+			expectedDesired:  3,
+			expectedProposed: 3,
+			expectedGuards: []PreviewGuard{
+				{Name: policy.SuppressionReasonThrottled, Effect: PreviewEffectSuppress},
+			},
+		},
+		{
+			name:         "bounds correction suppressed by throttle",
+			inputCount:   12,
+			inputDesired: 5,
+			inputPolicy:  func(p *sdk.ScalingPolicy) { p.OutOfBoundsAction = sdk.OutOfBoundsActionCorrect },
+			inputWorker: func(w *testWorker) {
+				w.leadership = fakeLeadership(false)
+				w.scaleThrottle = NewScaleThrottle(1, 1)
+				w.scaleThrottle.Allow()
+			},
+			expectedOutcome:  PreviewOutcomeSuppressed,
+			expectedDesired:  10,
+			expectedProposed: 10,
+			expectedGuards: []PreviewGuard{
+				{Name: policy.SuppressionReasonNotLeader, Effect: PreviewEffectSuppress},
+				{Name: policy.SuppressionReasonThrottled, Effect: PreviewEffectSuppress},
+			},
+		},
+		{
+			name:         "reduced by capacity budget",
+			inputCount:   2,
+			inputDesired: 8,
+			inputWorker: func(w *testWorker) {
+				w.capacityBudget = NewCapacityBudget(4, CapacityBudgetEnforcementReduce)
+			},
+			expectedOutcome:  PreviewOutcomeScale,
+			expectedDesired:  8,
+			expectedProposed: 4,
+			expectedCheck:    "check",
+			expectedGuards: []PreviewGuard{
+				{Name: policy.SuppressionReasonCapacityBudget, Effect: PreviewEffectModify, Count: 4},
+			},
+		},
+		{
+			name:         "target status error",
+			inputCount:   2,
+			inputDesired: 5,
+			inputWorker: func(w *testWorker) {
+				w.target.statusErr = errors.New("unavailable")
+			},
+			expectedOutcome: PreviewOutcomeError,
+			expectedError:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorker(tc.inputCount, tc.inputDesired)
+			if tc.inputWorker != nil {
+				tc.inputWorker(w)
+			}
+
+			p := newTestPolicy()
+			if tc.inputPolicy != nil {
+				tc.inputPolicy(p)
+			}
+
+			desc := &policy.PolicyDescription{Policy: p}
+			if tc.inputCooldown {
+				desc.CooldownUntil = time.Now().Add(time.Minute)
+			}
+
+			pv := w.Preview(context.Background(), desc)
+			assert.Equal(t, tc.expectedOutcome, pv.Outcome, tc.name)
+			assert.Equal(t, tc.expectedError, pv.Error != "", tc.name)
+			assert.Equal(t, tc.expectedGuards, pv.Guards, tc.name)
+			if !tc.expectedError {
+				assert.Equal(t, tc.inputCount, pv.Count, tc.name)
+				assert.Equal(t, tc.expectedDesired, pv.DesiredCount, tc.name)
+				assert.Equal(t, tc.expectedProposed, pv.ProposedCount, tc.name)
+				assert.Equal(t, tc.expectedCheck, pv.Check, tc.name)
+			}
+
+			// The preview never scales the target.
+			assert.Empty(t, w.target.scaledActions(), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_Preview_noSideEffects(t *testing.T) {
+	w := newTestWorker(2, 5)
+	w.capacityBudget = NewCapacityBudget(5, CapacityBudgetEnforcementDeny)
+	w.scaleThrottle = NewScaleThrottle(1, 1)
+
+	p := newTestPolicy()
+	for i := 0; i < 2; i++ {
+		pv := w.Preview(context.Background(), &policy.PolicyDescription{Policy: p})
+		assert.Equal(t, PreviewOutcomeScale, pv.Outcome)
+		assert.Empty(t, pv.Guards)
+	}
+
+	// The throttle token and the capacity are still available to the next
+	// evaluation.
+	assert.True(t, w.scaleThrottle.Available())
+	assert.Equal(t, int64(5), w.capacityBudget.Allowed(&sdk.ScalingPolicy{ID: "other"}, 0, 5))
+
+	assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(p, nil)))
+	assert.Len(t, w.target.scaledActions(), 1)
+}
@@ -0,0 +1,114 @@
+package policyeval
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// QueryCache coalesces identical APM queries performed by different policy
+// checks, so that the APM is only queried once and the result is shared with
+// all the checks which requested it.
+//
+// Identical queries that are in-flight at the same time always share the same
+// result. Once a query completes, its result is reused for the duration of
+// the coalesce window.
+type QueryCache struct {
+	window time.Duration
+
+	l       sync.Mutex
+	entries map[string]*queryCacheEntry
+}
+
+// queryCacheEntry holds the result of a single APM query. The done channel is
+// closed once the result is available.
+type queryCacheEntry struct {
+	done    chan struct{}
+	metrics sdk.TimestampedMetrics
+	err     error
+	expiry  time.Time
+}
+
+// NewQueryCache returns a new QueryCache which reuses query results for the
+// duration of the window.
+func NewQueryCache(window time.Duration) *QueryCache {
+	return &QueryCache{
+		window:  window,
+		entries: make(map[string]*queryCacheEntry),
+	}
+}
+
+// Query returns the result of the query identified by source, query and
+// queryWindow, calling fn to perform the query if no reusable result is
+// available.
+func (c *QueryCache) Query(source, query string, queryWindow time.Duration,
+	fn func() (sdk.TimestampedMetrics, error)) (sdk.TimestampedMetrics, error) {
+
+	// A nil cache performs no coalescing.
+	if c == nil {
+		return fn()
+	}
+
+	key := queryCacheKey(source, query, queryWindow)
+	now := time.Now()
+
+	c.l.Lock()
+	c.expireLocked(now)
+
+	if entry, ok := c.entries[key]; ok {
+		c.l.Unlock()
+		<-entry.done
+		return copyMetrics(entry.metrics), entry.err
+	}
+
+	entry := &queryCacheEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.l.Unlock()
+
+	entry.metrics, entry.err = fn()
+
+	c.l.Lock()
+	entry.expiry = time.Now().Add(c.window)
+
+	// Do not keep failed queries around so the next check retries.
+	if entry.err != nil || c.window <= 0 {
+		delete(c.entries, key)
+	}
+	c.l.Unlock()
+
+	close(entry.done)
+
+	return copyMetrics(entry.metrics), entry.err
+}
+
+// expireLocked removes completed entries whose window has passed. The caller
+// must hold the lock.
+func (c *QueryCache) expireLocked(now time.Time) {
+	for k, entry := range c.entries {
+		select {
+		case <-entry.done:
+			if !now.Before(entry.expiry) {
+				delete(c.entries, k)
+			}
+		default:
+		}
+	}
+}
+
+// queryCacheKey returns the key used to identify identical queries.
+func queryCacheKey(source, query string, queryWindow time.Duration) string {
+	return fmt.Sprintf("%s\x00%s\x00%s", source, query, queryWindow)
+}
+
+// copyMetrics returns a copy of the metrics so that each caller can safely
+// modify its own result, such as when sorting it.
+func copyMetrics(m sdk.TimestampedMetrics) sdk.TimestampedMetrics {
+	if m == nil {
+		return nil
+	}
+	out := make(sdk.TimestampedMetrics, len(m))
+	copy(out, m)
+	return out
+}
@@ -0,0 +1,120 @@
+package policyeval
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCache_Query(t *testing.T) {
+	metrics := sdk.TimestampedMetrics{{Value: 1}, {Value: 2}}
+
+	testCases := []struct {
+		name          string
+		cache         *QueryCache
+		queries       [][]string
+		err           error
+		expectedCalls int
+	}{
+		{
+			name:          "nil cache",
+			cache:         nil,
+			queries:       [][]string{{"prometheus", "query"}, {"prometheus", "query"}},
+			expectedCalls: 2,
+		},
+		{
+			name:          "no window",
+			cache:         NewQueryCache(0),
+			queries:       [][]string{{"prometheus", "query"}, {"prometheus", "query"}},
+			expectedCalls: 2,
+		},
+		{
+			name:          "identical queries within window",
+			cache:         NewQueryCache(time.Minute),
+			queries:       [][]string{{"prometheus", "query"}, {"prometheus", "query"}},
+			expectedCalls: 1,
+		},
+		{
+			name:          "different queries within window",
+			cache:         NewQueryCache(time.Minute),
+			queries:       [][]string{{"prometheus", "query"}, {"datadog", "query"}, {"prometheus", "other"}},
+			expectedCalls: 3,
+		},
+		{
+			name:          "errors are not reused",
+			cache:         NewQueryCache(time.Minute),
+			queries:       [][]string{{"prometheus", "query"}, {"prometheus", "query"}},
+			err:           errors.New("query failed"),
+			expectedCalls: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			fn := func() (sdk.TimestampedMetrics, error) {
+				calls++
+				if tc.err != nil {
+					return nil, tc.err
+				}
+				return metrics, nil
+			}
+
+			for _, q := range tc.queries {
+				actual, err := tc.cache.Query(q[0], q[1], time.Minute, fn)
+				assert.Equal(t, tc.err, err, tc.name)
+				if tc.err == nil {
+					assert.Equal(t, metrics, actual, tc.name)
+				}
+			}
+			assert.Equal(t, tc.expectedCalls, calls, tc.name)
+		})
+	}
+}
+
+func TestQueryCache_Query_inFlight(t *testing.T) {
+	cache := NewQueryCache(0)
+
+	var l sync.Mutex
+	calls := 0
+	releaseCh := make(chan struct{})
+
+	fn := func() (sdk.TimestampedMetrics, error) {
+		l.Lock()
+		calls++
+		l.Unlock()
+		<-releaseCh
+		return sdk.TimestampedMetrics{{Value: 1}}, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = cache.Query("prometheus", "query", time.Minute, fn)
+	}()
+
+	// Wait for the first query to be in-flight before starting the second.
+	assert.Eventually(t, func() bool {
+		l.Lock()
+		defer l.Unlock()
+		return calls == 1
+	}, time.Second, 10*time.Millisecond)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = cache.Query("prometheus", "query", time.Minute, fn)
+	}()
+
+	// Give the second query time to find the in-flight entry.
+	time.Sleep(50 * time.Millisecond)
+	close(releaseCh)
+	wg.Wait()
+
+	assert.Equal(t, 1, calls)
+}
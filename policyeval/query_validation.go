@@ -0,0 +1,49 @@
+package policyeval
+
+import (
+	"fmt"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/apm"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// ValidateQueries validates the queries of the policy checks using the APM
+// plugins of their sources, so malformed queries are reported when the policy
+// is loaded rather than when it is evaluated. The template variables are
+// substituted first, using a current count of zero. Sources which are not
+// available, or whose plugin does not implement apm.QueryValidator, are
+// skipped.
+func ValidateQueries(pm pluginDispenser, p *sdk.ScalingPolicy) error {
+	var mErr *multierror.Error
+
+	for _, check := range p.Checks {
+		if check == nil || check.Query == "" {
+			continue
+		}
+
+		query, err := renderQuery(p, check.Query, 0)
+		if err != nil {
+			mErr = multierror.Append(mErr, fmt.Errorf("check %q: failed to render query: %v", check.Name, err))
+			continue
+		}
+
+		for _, name := range append([]string{check.Source}, check.FailoverSources...) {
+			apmPlugin, err := pm.Dispense(name, plugins.PluginTypeAPM)
+			if err != nil {
+				continue
+			}
+
+			v, ok := apmPlugin.Plugin().(apm.QueryValidator)
+			if !ok {
+				continue
+			}
+			if err := v.ValidateQuery(query); err != nil {
+				mErr = multierror.Append(mErr, fmt.Errorf("check %q: invalid query for source %q: %v", check.Name, name, err))
+			}
+		}
+	}
+
+	return mErr.ErrorOrNil()
+}
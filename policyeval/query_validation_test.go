@@ -0,0 +1,99 @@
+package policyeval
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+// validatingAPM is a fakeAPM which implements apm.QueryValidator, rejecting
+// the queries in invalid and recording the queries it validated.
+type validatingAPM struct {
+	fakeAPM
+	invalid map[string]bool
+	queries []string
+}
+
+func (v *validatingAPM) ValidateQuery(q string) error {
+	v.queries = append(v.queries, q)
+	if v.invalid[q] {
+		return errors.New("syntax error")
+	}
+	return nil
+}
+
+func TestValidateQueries(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputQuery      string
+		inputFailovers  []string
+		expectedError   string
+		expectedQueries []string
+	}{
+		{
+			name:            "valid query",
+			inputQuery:      "valid",
+			expectedQueries: []string{"valid"},
+		},
+		{
+			name:            "invalid query",
+			inputQuery:      "invalid",
+			expectedError:   `check "check": invalid query for source "fake-apm": syntax error`,
+			expectedQueries: []string{"invalid"},
+		},
+		{
+			name:            "template variables substituted",
+			inputQuery:      "valid{policy=\"${policy_id}\",count=\"${current_count}\"}",
+			expectedQueries: []string{"valid{policy=\"test-policy\",count=\"0\"}"},
+		},
+		{
+			name:          "unavailable template variable",
+			inputQuery:    "valid{job=\"${job}\"}",
+			expectedError: `check "check": failed to render query: template variable "job" is not available to the policy`,
+		},
+		{
+			name:            "failover sources validated",
+			inputQuery:      "valid",
+			inputFailovers:  []string{"fake-apm-failover", "missing-apm", "fake-apm-plain"},
+			expectedQueries: []string{"valid", "valid"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			validator := &validatingAPM{invalid: map[string]bool{"invalid": true}}
+			pm := fakePlugins{
+				plugins.PluginTypeAPM + "/fake-apm":          validator,
+				plugins.PluginTypeAPM + "/fake-apm-failover": validator,
+				plugins.PluginTypeAPM + "/fake-apm-plain":    &fakeAPM{},
+			}
+
+			p := newTestPolicy()
+			p.Checks[0].Query = tc.inputQuery
+			p.Checks[0].FailoverSources = tc.inputFailovers
+
+			err := ValidateQueries(pm, p)
+			if tc.expectedError == "" {
+				assert.NoError(t, err, tc.name)
+			} else if assert.Error(t, err, tc.name) {
+				assert.Contains(t, err.Error(), tc.expectedError, tc.name)
+			}
+			assert.Equal(t, tc.expectedQueries, validator.queries, tc.name)
+		})
+	}
+}
+
+func TestValidateQueries_noQuery(t *testing.T) {
+	validator := &validatingAPM{}
+	pm := fakePlugins{plugins.PluginTypeAPM + "/fake-apm": validator}
+
+	p := newTestPolicy()
+	p.Checks = append(p.Checks, nil, &sdk.ScalingPolicyCheck{Name: "empty", Source: "fake-apm"})
+	p.Checks[0].Query = ""
+
+	assert.NoError(t, ValidateQueries(pm, p))
+	assert.Empty(t, validator.queries)
+}
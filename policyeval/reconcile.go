@@ -0,0 +1,103 @@
+package policyeval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// reconcileActions selects the single action to execute out of the actions
+// produced by the checks of a policy, which are passed in the order the
+// checks are defined within the policy. It returns the index of the selected
+// action, or -1 if no check produced an action.
+//
+// The selection does not depend on the order the checks finished in, and
+// follows these rules:
+//
+//   - nil actions, produced by checks which failed, are ignored.
+//   - MultipleActionsConservative selects the safest action, preferring
+//     scaling up over no change over scaling down, and the highest count
+//     within the same direction.
+//   - MultipleActionsLast selects the action of the last check.
+//   - MultipleActionsError returns an error if two checks want to scale the
+//     target to different counts.
+//   - Ties between identical actions are won by the first check.
+//
+// The selected action is clamped to the policy bounds. If several checks
+// produced the same scaling action, the selected action reason is merged
+// with their distinct reasons.
+func reconcileActions(mode string, p *sdk.ScalingPolicy, actions []*sdk.ScalingAction) (int, error) {
+	var winner *sdk.ScalingAction
+	winnerIdx := -1
+
+	for i, a := range actions {
+		selected, err := selectAction(mode, winner, a)
+		if err != nil {
+			return -1, err
+		}
+		if a != nil && selected == a {
+			winnerIdx = i
+		}
+		winner = selected
+	}
+
+	if winner == nil || winner.Direction == sdk.ScaleDirectionNone {
+		return winnerIdx, nil
+	}
+
+	winner.Reason = mergeReasons(winner, actions)
+
+	winner.Canonicalize()
+	winner.CapCount(p.Min, p.Max)
+
+	return winnerIdx, nil
+}
+
+// mergeReasons returns the distinct reasons of the actions which scale to the
+// same count in the same direction as the winner, in the order of the
+// actions, joined into a single reason.
+func mergeReasons(winner *sdk.ScalingAction, actions []*sdk.ScalingAction) string {
+	var reasons []string
+	seen := make(map[string]bool)
+
+	for _, a := range actions {
+		if a == nil || a.Direction != winner.Direction || a.Count != winner.Count {
+			continue
+		}
+		if a.Reason == "" || seen[a.Reason] {
+			continue
+		}
+		seen[a.Reason] = true
+		reasons = append(reasons, a.Reason)
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// selectAction returns the action which takes precedence out of the current
+// selection and the next action, as defined by the mode.
+func selectAction(mode string, current, next *sdk.ScalingAction) (*sdk.ScalingAction, error) {
+	switch mode {
+	case MultipleActionsLast:
+		if next == nil {
+			return current, nil
+		}
+		return next, nil
+
+	case MultipleActionsError:
+		if current == nil || next == nil {
+			return sdk.PreemptScalingAction(current, next), nil
+		}
+		if current.Direction == sdk.ScaleDirectionNone || next.Direction == sdk.ScaleDirectionNone {
+			return sdk.PreemptScalingAction(current, next), nil
+		}
+		if current.Direction != next.Direction || current.Count != next.Count {
+			return nil, fmt.Errorf("multiple checks produced conflicting scaling actions: %s to %d and %s to %d",
+				current.Direction, current.Count, next.Direction, next.Count)
+		}
+		return current, nil
+
+	default:
+		return sdk.PreemptScalingAction(current, next), nil
+	}
+}
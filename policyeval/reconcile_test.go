@@ -0,0 +1,252 @@
+package policyeval
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_reconcileActions(t *testing.T) {
+	up := func(count int64, reason string) *sdk.ScalingAction {
+		return &sdk.ScalingAction{Count: count, Direction: sdk.ScaleDirectionUp, Reason: reason}
+	}
+	down := func(count int64, reason string) *sdk.ScalingAction {
+		return &sdk.ScalingAction{Count: count, Direction: sdk.ScaleDirectionDown, Reason: reason}
+	}
+	none := func() *sdk.ScalingAction {
+		return &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone}
+	}
+
+	testCases := []struct {
+		name            string
+		multipleActions string
+		inputActions    []*sdk.ScalingAction
+		expectedIndex   int
+		expectedCount   int64
+		expectedReason  string
+		expectedError   bool
+	}{
+		{
+			name:            "no actions",
+			multipleActions: MultipleActionsConservative,
+			inputActions:    nil,
+			expectedIndex:   -1,
+		},
+		{
+			name:            "all checks failed",
+			multipleActions: MultipleActionsConservative,
+			inputActions:    []*sdk.ScalingAction{nil, nil},
+			expectedIndex:   -1,
+		},
+		{
+			name:            "single action",
+			multipleActions: MultipleActionsConservative,
+			inputActions:    []*sdk.ScalingAction{nil, down(2, "cpu low")},
+			expectedIndex:   1,
+			expectedCount:   2,
+			expectedReason:  "cpu low",
+		},
+		{
+			name:            "conservative prefers scale up",
+			multipleActions: MultipleActionsConservative,
+			inputActions:    []*sdk.ScalingAction{down(2, "cpu low"), none(), up(6, "memory high")},
+			expectedIndex:   2,
+			expectedCount:   6,
+			expectedReason:  "memory high",
+		},
+		{
+			name:            "conservative prefers no change over scale down",
+			multipleActions: MultipleActionsConservative,
+			inputActions:    []*sdk.ScalingAction{down(2, "cpu low"), none()},
+			expectedIndex:   1,
+		},
+		{
+			name:            "conservative prefers highest scale up",
+			multipleActions: MultipleActionsConservative,
+			inputActions:    []*sdk.ScalingAction{up(6, "cpu high"), up(8, "memory high")},
+			expectedIndex:   1,
+			expectedCount:   8,
+			expectedReason:  "memory high",
+		},
+		{
+			name:            "conservative prefers highest scale down",
+			multipleActions: MultipleActionsConservative,
+			inputActions:    []*sdk.ScalingAction{down(2, "cpu low"), down(4, "memory low")},
+			expectedIndex:   1,
+			expectedCount:   4,
+			expectedReason:  "memory low",
+		},
+		{
+			name:            "tie won by first check with merged reasons",
+			multipleActions: MultipleActionsConservative,
+			inputActions:    []*sdk.ScalingAction{up(4, "cpu high"), up(6, "memory high"), up(6, "queue long")},
+			expectedIndex:   1,
+			expectedCount:   6,
+			expectedReason:  "memory high; queue long",
+		},
+		{
+			name:            "duplicate reasons are merged once",
+			multipleActions: MultipleActionsConservative,
+			inputActions:    []*sdk.ScalingAction{up(6, "cpu high"), up(6, "cpu high"), up(6, "")},
+			expectedIndex:   0,
+			expectedCount:   6,
+			expectedReason:  "cpu high",
+		},
+		{
+			name:            "count is clamped to the policy bounds",
+			multipleActions: MultipleActionsConservative,
+			inputActions:    []*sdk.ScalingAction{up(20, "cpu high")},
+			expectedIndex:   0,
+			expectedCount:   10,
+			expectedReason:  "capped count from 20 to 10 to stay within limits",
+		},
+		{
+			name:            "last picks the last check",
+			multipleActions: MultipleActionsLast,
+			inputActions:    []*sdk.ScalingAction{up(6, "cpu high"), down(2, "memory low"), nil},
+			expectedIndex:   1,
+			expectedCount:   2,
+			expectedReason:  "memory low",
+		},
+		{
+			name:            "error with agreeing actions",
+			multipleActions: MultipleActionsError,
+			inputActions:    []*sdk.ScalingAction{up(6, "cpu high"), none(), up(6, "memory high")},
+			expectedIndex:   0,
+			expectedCount:   6,
+			expectedReason:  "cpu high; memory high",
+		},
+		{
+			name:            "error with conflicting actions",
+			multipleActions: MultipleActionsError,
+			inputActions:    []*sdk.ScalingAction{up(6, "cpu high"), up(8, "memory high")},
+			expectedIndex:   -1,
+			expectedError:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &sdk.ScalingPolicy{Min: 1, Max: 10}
+
+			actualIndex, err := reconcileActions(tc.multipleActions, p, tc.inputActions)
+			assert.Equal(t, tc.expectedError, err != nil, tc.name)
+			assert.Equal(t, tc.expectedIndex, actualIndex, tc.name)
+
+			if actualIndex < 0 {
+				return
+			}
+			actual := tc.inputActions[actualIndex]
+			assert.Equal(t, tc.expectedCount, actual.Count, tc.name)
+			assert.Equal(t, tc.expectedReason, actual.Reason, tc.name)
+		})
+	}
+}
+
+func Test_reconcileActions_orderIndependent(t *testing.T) {
+	p := &sdk.ScalingPolicy{Min: 1, Max: 10}
+
+	// The same set of actions is reconciled to the same action, regardless
+	// of the order the checks are defined in.
+	orders := [][]int{{0, 1, 2}, {2, 1, 0}, {1, 0, 2}, {1, 2, 0}}
+	for _, order := range orders {
+		all := []*sdk.ScalingAction{
+			{Count: 3, Direction: sdk.ScaleDirectionDown, Reason: "cpu low"},
+			{Count: 7, Direction: sdk.ScaleDirectionUp, Reason: "memory high"},
+			{Direction: sdk.ScaleDirectionNone},
+		}
+
+		actions := make([]*sdk.ScalingAction, 0, len(order))
+		for _, i := range order {
+			actions = append(actions, all[i])
+		}
+
+		idx, err := reconcileActions(MultipleActionsConservative, p, actions)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), actions[idx].Count)
+		assert.Equal(t, "memory high", actions[idx].Reason)
+	}
+}
+
+func Test_selectAction(t *testing.T) {
+	up := &sdk.ScalingAction{Count: 5, Direction: sdk.ScaleDirectionUp}
+	upMore := &sdk.ScalingAction{Count: 7, Direction: sdk.ScaleDirectionUp}
+	down := &sdk.ScalingAction{Count: 1, Direction: sdk.ScaleDirectionDown}
+	none := &sdk.ScalingAction{Direction: sdk.ScaleDirectionNone}
+
+	testCases := []struct {
+		name            string
+		multipleActions string
+		current         *sdk.ScalingAction
+		next            *sdk.ScalingAction
+		expectedAction  *sdk.ScalingAction
+		expectedError   bool
+	}{
+		{
+			name:            "conservative first action",
+			multipleActions: MultipleActionsConservative,
+			current:         nil,
+			next:            down,
+			expectedAction:  down,
+		},
+		{
+			name:            "conservative prefers scale up",
+			multipleActions: MultipleActionsConservative,
+			current:         down,
+			next:            up,
+			expectedAction:  up,
+		},
+		{
+			name:            "conservative prefers highest count",
+			multipleActions: MultipleActionsConservative,
+			current:         upMore,
+			next:            up,
+			expectedAction:  upMore,
+		},
+		{
+			name:            "last picks next action",
+			multipleActions: MultipleActionsLast,
+			current:         upMore,
+			next:            down,
+			expectedAction:  down,
+		},
+		{
+			name:            "last ignores nil action",
+			multipleActions: MultipleActionsLast,
+			current:         up,
+			next:            nil,
+			expectedAction:  up,
+		},
+		{
+			name:            "error with single action",
+			multipleActions: MultipleActionsError,
+			current:         none,
+			next:            up,
+			expectedAction:  up,
+		},
+		{
+			name:            "error with matching actions",
+			multipleActions: MultipleActionsError,
+			current:         up,
+			next:            &sdk.ScalingAction{Count: 5, Direction: sdk.ScaleDirectionUp},
+			expectedAction:  up,
+		},
+		{
+			name:            "error with conflicting actions",
+			multipleActions: MultipleActionsError,
+			current:         up,
+			next:            down,
+			expectedAction:  nil,
+			expectedError:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualAction, err := selectAction(tc.multipleActions, tc.current, tc.next)
+			assert.Equal(t, tc.expectedError, err != nil, tc.name)
+			assert.Equal(t, tc.expectedAction, actualAction, tc.name)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package policyeval
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// ResultCache stores the results of APM queries so that repeated evaluations
+// within the TTL reuse the last result instead of querying the APM again. It
+// reduces the load on expensive external metrics systems.
+//
+// Unlike QueryCache, which shares the result of identical queries run at the
+// same time, the ResultCache retains results across policy evaluations.
+type ResultCache struct {
+	ttl time.Duration
+
+	l       sync.Mutex
+	entries map[string]resultCacheEntry
+}
+
+// resultCacheEntry holds a cached APM query result and the time the query was
+// performed.
+type resultCacheEntry struct {
+	metrics   sdk.TimestampedMetrics
+	fetchedAt time.Time
+}
+
+// NewResultCache returns a new ResultCache which retains results for the ttl.
+// A ttl of zero disables caching.
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{
+		ttl:     ttl,
+		entries: make(map[string]resultCacheEntry),
+	}
+}
+
+// Get returns the cached result of the query identified by source, query and
+// queryWindow along with the time the query was performed. The returned bool
+// is false if there is no result cached within the TTL.
+func (c *ResultCache) Get(source, query string, queryWindow time.Duration) (sdk.TimestampedMetrics, time.Time, bool) {
+	if c == nil || c.ttl <= 0 {
+		return nil, time.Time{}, false
+	}
+
+	key := queryCacheKey(source, query, queryWindow)
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+
+	if time.Since(entry.fetchedAt) >= c.ttl {
+		delete(c.entries, key)
+		return nil, time.Time{}, false
+	}
+
+	return copyMetrics(entry.metrics), entry.fetchedAt, true
+}
+
+// Set stores the result of the query identified by source, query and
+// queryWindow, which was performed at fetchedAt.
+func (c *ResultCache) Set(source, query string, queryWindow time.Duration, m sdk.TimestampedMetrics, fetchedAt time.Time) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+
+	key := queryCacheKey(source, query, queryWindow)
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	// Remove expired entries so queries which are no longer run do not
+	// remain in memory.
+	for k, entry := range c.entries {
+		if time.Since(entry.fetchedAt) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = resultCacheEntry{metrics: copyMetrics(m), fetchedAt: fetchedAt}
+}
@@ -0,0 +1,94 @@
+package policyeval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultCache(t *testing.T) {
+	metrics := sdk.TimestampedMetrics{{Value: 1}, {Value: 2}}
+	now := time.Now()
+
+	testCases := []struct {
+		name          string
+		cache         *ResultCache
+		fetchedAt     time.Time
+		getSource     string
+		getQuery      string
+		getWindow     time.Duration
+		expectedFound bool
+	}{
+		{
+			name:          "nil cache",
+			cache:         nil,
+			fetchedAt:     now,
+			getSource:     "prometheus",
+			getQuery:      "query",
+			getWindow:     time.Minute,
+			expectedFound: false,
+		},
+		{
+			name:          "disabled cache",
+			cache:         NewResultCache(0),
+			fetchedAt:     now,
+			getSource:     "prometheus",
+			getQuery:      "query",
+			getWindow:     time.Minute,
+			expectedFound: false,
+		},
+		{
+			name:          "result within ttl",
+			cache:         NewResultCache(time.Minute),
+			fetchedAt:     now,
+			getSource:     "prometheus",
+			getQuery:      "query",
+			getWindow:     time.Minute,
+			expectedFound: true,
+		},
+		{
+			name:          "result outside ttl",
+			cache:         NewResultCache(time.Minute),
+			fetchedAt:     now.Add(-2 * time.Minute),
+			getSource:     "prometheus",
+			getQuery:      "query",
+			getWindow:     time.Minute,
+			expectedFound: false,
+		},
+		{
+			name:          "different source",
+			cache:         NewResultCache(time.Minute),
+			fetchedAt:     now,
+			getSource:     "datadog",
+			getQuery:      "query",
+			getWindow:     time.Minute,
+			expectedFound: false,
+		},
+		{
+			name:          "different query window",
+			cache:         NewResultCache(time.Minute),
+			fetchedAt:     now,
+			getSource:     "prometheus",
+			getQuery:      "query",
+			getWindow:     5 * time.Minute,
+			expectedFound: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cache.Set("prometheus", "query", time.Minute, metrics, tc.fetchedAt)
+
+			actual, fetchedAt, found := tc.cache.Get(tc.getSource, tc.getQuery, tc.getWindow)
+			assert.Equal(t, tc.expectedFound, found, tc.name)
+			if tc.expectedFound {
+				assert.Equal(t, metrics, actual, tc.name)
+				assert.Equal(t, tc.fetchedAt, fetchedAt, tc.name)
+			} else {
+				assert.Nil(t, actual, tc.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package policyeval
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// The environment variables describing the scaling action which are passed to
+// the scale hook commands, on top of the agent environment.
+const (
+	scaleHookEnvPolicyID       = "NOMAD_AUTOSCALER_POLICY_ID"
+	scaleHookEnvPolicyRevision = "NOMAD_AUTOSCALER_POLICY_REVISION"
+	scaleHookEnvTarget         = "NOMAD_AUTOSCALER_TARGET"
+	scaleHookEnvFrom           = "NOMAD_AUTOSCALER_FROM"
+	scaleHookEnvTo             = "NOMAD_AUTOSCALER_TO"
+	scaleHookEnvDirection      = "NOMAD_AUTOSCALER_DIRECTION"
+	scaleHookEnvReason         = "NOMAD_AUTOSCALER_REASON"
+	scaleHookEnvReasonCode     = "NOMAD_AUTOSCALER_REASON_CODE"
+	scaleHookEnvDryRun         = "NOMAD_AUTOSCALER_DRY_RUN"
+)
+
+// ScaleHooks runs the commands configured to run before and after a target is
+// scaled. The pre-scale command gates the scaling action, while the failure
+// of the post-scale command only needs to be logged. It is safe for
+// concurrent use by multiple workers.
+type ScaleHooks struct {
+	pre     []string
+	post    []string
+	timeout time.Duration
+}
+
+// NewScaleHooks returns a new ScaleHooks, or nil if neither command is set.
+// The commands are killed if they run for longer than timeout, unless it is
+// zero.
+func NewScaleHooks(pre, post []string, timeout time.Duration) *ScaleHooks {
+	if len(pre) == 0 && len(post) == 0 {
+		return nil
+	}
+	return &ScaleHooks{pre: pre, post: post, timeout: timeout}
+}
+
+// Pre runs the pre-scale command for the action scaling the policy target
+// from the count. An error is returned if the command fails, in which case
+// the target must not be scaled.
+func (h *ScaleHooks) Pre(ctx context.Context, p *sdk.ScalingPolicy, from int64, action sdk.ScalingAction) error {
+	if h == nil {
+		return nil
+	}
+	return h.run(ctx, h.pre, scaleHookEnv(p, from, action))
+}
+
+// Post runs the post-scale command for the action which scaled the policy
+// target from the count.
+func (h *ScaleHooks) Post(ctx context.Context, p *sdk.ScalingPolicy, from int64, action sdk.ScalingAction) error {
+	if h == nil {
+		return nil
+	}
+	return h.run(ctx, h.post, scaleHookEnv(p, from, action))
+}
+
+// run runs the command with the environment variables added to those of the
+// agent. The output of a failed command is included within the error.
+func (h *ScaleHooks) run(ctx context.Context, command, env []string) error {
+	if len(command) == 0 {
+		return nil
+	}
+
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	// The output is written to a file rather than a pipe, so a timed out
+	// command does not block on processes it started which inherited the
+	// pipe.
+	out, err := ioutil.TempFile("", "nomad-autoscaler-hook")
+	if err != nil {
+		return fmt.Errorf("failed to create command output file: %v", err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("command %q timed out after %s", command[0], h.timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("command %q failed: %v: %s", command[0], err, readHookOutput(out))
+	}
+	return nil
+}
+
+// readHookOutput returns the end of the output written by a command, which
+// is included within errors.
+func readHookOutput(f *os.File) string {
+	const maxOutput = 1024
+
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return ""
+	}
+	if len(b) > maxOutput {
+		b = b[len(b)-maxOutput:]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// scaleHookEnv returns the environment variables describing the action.
+func scaleHookEnv(p *sdk.ScalingPolicy, from int64, action sdk.ScalingAction) []string {
+	reasonCode := action.ReasonCode
+	if reasonCode == "" {
+		reasonCode = sdk.ReasonCodeStrategy
+	}
+
+	var target string
+	dryRun := false
+	if p.Target != nil {
+		target = p.Target.Name
+		dryRun = p.Target.Config["dry-run"] == "true"
+	}
+
+	return []string{
+		scaleHookEnvPolicyID + "=" + p.ID,
+		scaleHookEnvPolicyRevision + "=" + p.Revision,
+		scaleHookEnvTarget + "=" + target,
+		scaleHookEnvFrom + "=" + strconv.FormatInt(from, 10),
+		scaleHookEnvTo + "=" + strconv.FormatInt(action.Count, 10),
+		scaleHookEnvDirection + "=" + action.Direction.String(),
+		scaleHookEnvReason + "=" + action.Reason,
+		scaleHookEnvReasonCode + "=" + string(reasonCode),
+		scaleHookEnvDryRun + "=" + strconv.FormatBool(dryRun),
+	}
+}
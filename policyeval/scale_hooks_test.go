@@ -0,0 +1,66 @@
+package policyeval
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleHooks_Pre(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scale hook tests use a POSIX shell")
+	}
+
+	testCases := []struct {
+		inputCommand []string
+		inputTimeout time.Duration
+		expectError  bool
+		name         string
+	}{
+		{
+			inputCommand: []string{"sh", "-c", `test "$NOMAD_AUTOSCALER_POLICY_ID" = test-policy && test "$NOMAD_AUTOSCALER_FROM" = 2 && test "$NOMAD_AUTOSCALER_TO" = 5 && test "$NOMAD_AUTOSCALER_DIRECTION" = up && test "$NOMAD_AUTOSCALER_REASON_CODE" = strategy && test "$NOMAD_AUTOSCALER_POLICY_REVISION" = 42`},
+			expectError:  false,
+			name:         "action passed as environment variables",
+		},
+		{
+			inputCommand: []string{"sh", "-c", "exit 1"},
+			expectError:  true,
+			name:         "non-zero exit",
+		},
+		{
+			inputCommand: []string{"sh", "-c", "sleep 5"},
+			inputTimeout: 100 * time.Millisecond,
+			expectError:  true,
+			name:         "timeout",
+		},
+		{
+			inputCommand: []string{"/does/not/exist"},
+			expectError:  true,
+			name:         "missing executable",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewScaleHooks(tc.inputCommand, nil, tc.inputTimeout)
+			action := sdk.ScalingAction{Count: 5, Direction: sdk.ScaleDirectionUp, Reason: "testing"}
+
+			p := newTestPolicy()
+			p.Revision = "42"
+
+			err := h.Pre(context.Background(), p, 2, action)
+			assert.Equal(t, tc.expectError, err != nil, tc.name)
+		})
+	}
+}
+
+func TestScaleHooks_nil(t *testing.T) {
+	h := NewScaleHooks(nil, nil, time.Second)
+	assert.Nil(t, h)
+	assert.NoError(t, h.Pre(context.Background(), newTestPolicy(), 1, sdk.ScalingAction{}))
+	assert.NoError(t, h.Post(context.Background(), newTestPolicy(), 1, sdk.ScalingAction{}))
+}
@@ -0,0 +1,87 @@
+package policyeval
+
+import (
+	"sync"
+	"time"
+)
+
+// ScaleThrottle limits the number of scaling actions performed across all
+// policies using a token bucket. The bucket holds up to burst tokens and is
+// refilled at the configured rate, with each scaling action taking a token.
+// It is safe for concurrent use by multiple workers.
+type ScaleThrottle struct {
+	rate  float64
+	burst float64
+
+	l      sync.Mutex
+	tokens float64
+	last   time.Time
+
+	// now is used to read the current time, allowing tests to control it.
+	now func() time.Time
+}
+
+// NewScaleThrottle returns a new ScaleThrottle which allows ratePerMinute
+// scaling actions per minute, with up to burst performed at once. It returns
+// nil, which allows every scaling action, if ratePerMinute is not positive.
+// A burst lower than one is treated as one.
+func NewScaleThrottle(ratePerMinute, burst int) *ScaleThrottle {
+	if ratePerMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &ScaleThrottle{
+		rate:   float64(ratePerMinute) / time.Minute.Seconds(),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// Allow takes a token from the bucket and returns true if one is available.
+// Otherwise it returns false, and the scaling action must be deferred.
+func (t *ScaleThrottle) Allow() bool {
+	if t == nil {
+		return true
+	}
+
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	t.refill()
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
+
+// Available returns whether a token is available, without taking it.
+func (t *ScaleThrottle) Available() bool {
+	if t == nil {
+		return true
+	}
+
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	t.refill()
+	return t.tokens >= 1
+}
+
+// refill adds the tokens accumulated since the last refill to the bucket.
+// The caller must hold the lock.
+func (t *ScaleThrottle) refill() {
+	now := t.now()
+	if elapsed := now.Sub(t.last).Seconds(); elapsed > 0 {
+		t.tokens += elapsed * t.rate
+		if t.tokens > t.burst {
+			t.tokens = t.burst
+		}
+	}
+	t.last = now
+}
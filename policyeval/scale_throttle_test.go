@@ -0,0 +1,84 @@
+package policyeval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewScaleThrottle(t *testing.T) {
+	assert.Nil(t, NewScaleThrottle(0, 5))
+	assert.Nil(t, NewScaleThrottle(-1, 5))
+
+	throttle := NewScaleThrottle(60, 0)
+	assert.NotNil(t, throttle)
+	assert.Equal(t, float64(1), throttle.burst)
+}
+
+func TestScaleThrottle_Allow(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inputRate       int
+		inputBurst      int
+		inputElapsed    []time.Duration
+		expectedAllowed []bool
+	}{
+		{
+			name:            "burst exhausted",
+			inputRate:       1,
+			inputBurst:      2,
+			inputElapsed:    []time.Duration{0, 0, 0},
+			expectedAllowed: []bool{true, true, false},
+		},
+		{
+			name:            "token refilled",
+			inputRate:       2,
+			inputBurst:      1,
+			inputElapsed:    []time.Duration{0, 0, 10 * time.Second, 20 * time.Second},
+			expectedAllowed: []bool{true, false, false, true},
+		},
+		{
+			name:            "refill capped at burst",
+			inputRate:       60,
+			inputBurst:      2,
+			inputElapsed:    []time.Duration{time.Hour, 0, 0, 0},
+			expectedAllowed: []bool{true, true, false, false},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			throttle := NewScaleThrottle(tc.inputRate, tc.inputBurst)
+
+			now := time.Now()
+			throttle.last = now
+			throttle.now = func() time.Time { return now }
+
+			var actualAllowed []bool
+			for _, elapsed := range tc.inputElapsed {
+				now = now.Add(elapsed)
+				actualAllowed = append(actualAllowed, throttle.Allow())
+			}
+			assert.Equal(t, tc.expectedAllowed, actualAllowed, tc.name)
+		})
+	}
+}
+
+func TestScaleThrottle_Available(t *testing.T) {
+	throttle := NewScaleThrottle(1, 1)
+
+	// Checking availability does not take the token.
+	assert.True(t, throttle.Available())
+	assert.True(t, throttle.Available())
+	assert.True(t, throttle.Allow())
+	assert.False(t, throttle.Available())
+}
+
+func TestScaleThrottle_nil(t *testing.T) {
+	var throttle *ScaleThrottle
+	for i := 0; i < 3; i++ {
+		assert.True(t, throttle.Allow())
+		assert.True(t, throttle.Available())
+	}
+}
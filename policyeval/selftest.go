@@ -0,0 +1,339 @@
+package policyeval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/apm"
+	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+	"github.com/hashicorp/nomad-autoscaler/policy"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/clock"
+)
+
+// errNotParticipated is recorded against a plugin which was not called during
+// the self test evaluation.
+var errNotParticipated = errors.New("plugin did not participate in the evaluation")
+
+// SelfTestConfig controls the synthetic evaluation run by SelfTest.
+type SelfTestConfig struct {
+
+	// Count is the current count reported for the synthetic targets. It is
+	// only used if TargetConfig is not set.
+	Count int64
+
+	// Value is the metric value returned for the synthetic queries. It is
+	// only used if Query is not set.
+	Value float64
+
+	// Query is the query run against the APMs by the checks. If it is empty,
+	// the APMs are only called to confirm they respond, and Value is used
+	// instead.
+	Query string
+
+	// TargetConfig is the config passed to the targets to read their status.
+	// If it is nil, the targets are only called to confirm they respond, and
+	// Count is used instead.
+	TargetConfig map[string]string
+
+	// StrategyConfig is the config passed to the strategies of the synthetic
+	// checks.
+	StrategyConfig map[string]string
+}
+
+// SelfTestResult is the outcome of a single plugin within the self test.
+type SelfTestResult struct {
+	Type string
+	Name string
+
+	// Calls is the number of times the plugin was called, and Duration the
+	// total time taken by those calls.
+	Calls    int
+	Duration time.Duration
+
+	// Error is the first error returned by the plugin, or errNotParticipated
+	// if it was never called. It is nil if the plugin succeeded.
+	Error error
+}
+
+// SelfTest runs a synthetic dry-run evaluation using the named plugins,
+// mapped by plugin type, and returns the result of each plugin sorted by type
+// and name. A policy is evaluated for each target, with a check for each pair
+// of APM and strategy.
+//
+// Strategies are run as normal. APMs are queried and the status of targets is
+// read if the config sets a query and a target config respectively. Otherwise
+// they are only called to confirm they respond, as the synthetic queries and
+// targets do not exist within the systems they talk to, and report the
+// configured value and count instead. Targets are never scaled.
+func SelfTest(ctx context.Context, l hclog.Logger, pm pluginDispenser, names map[string][]string, cfg SelfTestConfig) []*SelfTestResult {
+	recorder := newSelfTestRecorder(names)
+
+	w := &BaseWorker{
+		logger:          l,
+		pluginManager:   &selfTestDispenser{pm: pm, cfg: cfg, recorder: recorder},
+		policyManager:   policy.NewManager(l, nil, nil, 0, 0, 0),
+		multipleActions: MultipleActionsConservative,
+		clock:           clock.Real(),
+	}
+
+	for _, targetName := range names[plugins.PluginTypeTarget] {
+		p := newSelfTestPolicy(targetName, names, cfg)
+
+		// Failed checks are already recorded against the plugin which
+		// failed, so only other evaluation failures are recorded against
+		// the target.
+		start := time.Now()
+		err := w.handlePolicy(ctx, sdk.NewScalingEvaluation(p, nil))
+		if _, ok := err.(*checkError); err != nil && !ok {
+			recorder.record(plugins.PluginTypeTarget, targetName, start, fmt.Errorf("evaluation failed: %v", err))
+		}
+	}
+
+	return recorder.results()
+}
+
+// newSelfTestPolicy returns the synthetic policy used to evaluate the target.
+func newSelfTestPolicy(targetName string, names map[string][]string, cfg SelfTestConfig) *sdk.ScalingPolicy {
+	p := &sdk.ScalingPolicy{
+		ID:                 "selftest-" + targetName,
+		Min:                0,
+		Max:                math.MaxInt32,
+		Enabled:            true,
+		EvaluationInterval: time.Minute,
+		Target: &sdk.ScalingPolicyTarget{
+			Name:   targetName,
+			Config: map[string]string{"dry-run": "true"},
+		},
+	}
+
+	if cfg.TargetConfig != nil {
+		p.Target.Config = make(map[string]string, len(cfg.TargetConfig))
+		for k, v := range cfg.TargetConfig {
+			p.Target.Config[k] = v
+		}
+	}
+
+	query := "selftest"
+	if cfg.Query != "" {
+		query = cfg.Query
+	}
+
+	for _, apmName := range names[plugins.PluginTypeAPM] {
+		for _, strategyName := range names[plugins.PluginTypeStrategy] {
+			strategyCfg := make(map[string]string, len(cfg.StrategyConfig))
+			for k, v := range cfg.StrategyConfig {
+				strategyCfg[k] = v
+			}
+
+			p.Checks = append(p.Checks, &sdk.ScalingPolicyCheck{
+				Name:        apmName + "-" + strategyName,
+				Source:      apmName,
+				Query:       query,
+				QueryWindow: time.Minute,
+				Strategy:    &sdk.ScalingPolicyStrategy{Name: strategyName, Config: strategyCfg},
+			})
+		}
+	}
+	return p
+}
+
+// selfTestRecorder records the calls made to each plugin during a self test.
+type selfTestRecorder struct {
+	lock    sync.Mutex
+	plugins map[plugins.PluginID]*SelfTestResult
+}
+
+func newSelfTestRecorder(names map[string][]string) *selfTestRecorder {
+	r := &selfTestRecorder{plugins: make(map[plugins.PluginID]*SelfTestResult)}
+	for pluginType, typeNames := range names {
+		for _, name := range typeNames {
+			r.plugins[plugins.PluginID{Name: name, PluginType: pluginType}] = &SelfTestResult{Type: pluginType, Name: name}
+		}
+	}
+	return r
+}
+
+// record records a call to the plugin which started at start and returned
+// err.
+func (r *selfTestRecorder) record(pluginType, name string, start time.Time, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	id := plugins.PluginID{Name: name, PluginType: pluginType}
+	res, ok := r.plugins[id]
+	if !ok {
+		res = &SelfTestResult{Type: pluginType, Name: name}
+		r.plugins[id] = res
+	}
+
+	res.Calls++
+	res.Duration += time.Since(start)
+	if res.Error == nil {
+		res.Error = err
+	}
+}
+
+// results returns the recorded results sorted by type and name, marking the
+// plugins which were never called as failed.
+func (r *selfTestRecorder) results() []*SelfTestResult {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	out := make([]*SelfTestResult, 0, len(r.plugins))
+	for _, res := range r.plugins {
+		if res.Calls == 0 && res.Error == nil {
+			res.Error = errNotParticipated
+		}
+		out = append(out, res)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Type != out[j].Type {
+			return out[i].Type < out[j].Type
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// selfTestDispenser wraps the plugins dispensed to the self test worker, so
+// their calls are recorded, the APMs and targets report synthetic data unless
+// configured otherwise, and the targets are never scaled.
+type selfTestDispenser struct {
+	pm       pluginDispenser
+	cfg      SelfTestConfig
+	recorder *selfTestRecorder
+}
+
+func (d *selfTestDispenser) Dispense(name, pluginType string) (manager.PluginInstance, error) {
+	start := time.Now()
+
+	inst, err := d.pm.Dispense(name, pluginType)
+	if err != nil {
+		d.recorder.record(pluginType, name, start, err)
+		return nil, err
+	}
+
+	var wrapped interface{}
+	switch pluginType {
+	case plugins.PluginTypeAPM:
+		wrapped = &selfTestAPM{
+			APM:      inst.Plugin().(apm.APM),
+			name:     name,
+			value:    d.cfg.Value,
+			query:    d.cfg.Query != "",
+			recorder: d.recorder,
+		}
+	case plugins.PluginTypeTarget:
+		wrapped = &selfTestTarget{
+			Target:   inst.Plugin().(target.Target),
+			name:     name,
+			count:    d.cfg.Count,
+			status:   d.cfg.TargetConfig != nil,
+			recorder: d.recorder,
+		}
+	case plugins.PluginTypeStrategy:
+		wrapped = &selfTestStrategy{Strategy: inst.Plugin().(strategy.Strategy), name: name, recorder: d.recorder}
+	default:
+		return inst, nil
+	}
+	return &selfTestPluginInstance{PluginInstance: inst, plugin: wrapped}, nil
+}
+
+// selfTestPluginInstance replaces the plugin of a dispensed instance.
+type selfTestPluginInstance struct {
+	manager.PluginInstance
+	plugin interface{}
+}
+
+func (i *selfTestPluginInstance) Plugin() interface{} { return i.plugin }
+
+// selfTestAPM runs the queries against the APM if query is set. Otherwise it
+// confirms the APM responds and returns the configured value for all queries.
+type selfTestAPM struct {
+	apm.APM
+	name     string
+	value    float64
+	query    bool
+	recorder *selfTestRecorder
+}
+
+func (a *selfTestAPM) Query(q string, r sdk.TimeRange) (sdk.TimestampedMetrics, error) {
+	start := time.Now()
+	if a.query {
+		m, err := a.APM.Query(q, r)
+		a.recorder.record(plugins.PluginTypeAPM, a.name, start, err)
+		return m, err
+	}
+
+	_, err := a.APM.PluginInfo()
+	a.recorder.record(plugins.PluginTypeAPM, a.name, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return sdk.TimestampedMetrics{{Timestamp: time.Now(), Value: a.value}}, nil
+}
+
+func (a *selfTestAPM) QueryMultiple(q string, r sdk.TimeRange) ([]sdk.TimestampedMetrics, error) {
+	m, err := a.Query(q, r)
+	if err != nil {
+		return nil, err
+	}
+	return []sdk.TimestampedMetrics{m}, nil
+}
+
+// selfTestTarget reads the status of the target if status is set. Otherwise
+// it confirms the target responds and reports the configured count. Scaling
+// actions are recorded but never passed to the target.
+type selfTestTarget struct {
+	target.Target
+	name     string
+	count    int64
+	status   bool
+	recorder *selfTestRecorder
+}
+
+func (t *selfTestTarget) Status(config map[string]string) (*sdk.TargetStatus, error) {
+	start := time.Now()
+	if t.status {
+		status, err := t.Target.Status(config)
+		t.recorder.record(plugins.PluginTypeTarget, t.name, start, err)
+		return status, err
+	}
+
+	_, err := t.Target.PluginInfo()
+	t.recorder.record(plugins.PluginTypeTarget, t.name, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.TargetStatus{Ready: true, Count: t.count, Meta: map[string]string{}}, nil
+}
+
+func (t *selfTestTarget) Scale(_ sdk.ScalingAction, _ map[string]string) error {
+	t.recorder.record(plugins.PluginTypeTarget, t.name, time.Now(), nil)
+	return nil
+}
+
+// selfTestStrategy runs the strategy and records the call.
+type selfTestStrategy struct {
+	strategy.Strategy
+	name     string
+	recorder *selfTestRecorder
+}
+
+func (s *selfTestStrategy) Run(eval *sdk.ScalingCheckEvaluation, count int64) (*sdk.ScalingCheckEvaluation, error) {
+	start := time.Now()
+	out, err := s.Strategy.Run(eval, count)
+	s.recorder.record(plugins.PluginTypeStrategy, s.name, start, err)
+	return out, err
+}
@@ -0,0 +1,103 @@
+package policyeval
+
+import (
+	"context"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelfTest(t *testing.T) {
+	testCases := []struct {
+		inputNames     map[string][]string
+		inputConfig    SelfTestConfig
+		expectedErrors map[string]bool
+		expectedValue  float64
+		name           string
+	}{
+		{
+			inputNames: map[string][]string{
+				plugins.PluginTypeAPM:      {"fake-apm"},
+				plugins.PluginTypeStrategy: {"fake-strategy"},
+				plugins.PluginTypeTarget:   {"fake-target"},
+			},
+			expectedErrors: map[string]bool{
+				"apm/fake-apm":           false,
+				"strategy/fake-strategy": false,
+				"target/fake-target":     false,
+			},
+			expectedValue: 42,
+			name:          "all plugins succeed",
+		},
+		{
+			inputNames: map[string][]string{
+				plugins.PluginTypeAPM:      {"fake-apm"},
+				plugins.PluginTypeStrategy: {"fake-strategy"},
+				plugins.PluginTypeTarget:   {"fake-target"},
+			},
+			inputConfig: SelfTestConfig{
+				Query:        "events_per_second",
+				TargetConfig: map[string]string{"job_id": "web"},
+			},
+			expectedErrors: map[string]bool{
+				"apm/fake-apm":           false,
+				"strategy/fake-strategy": false,
+				"target/fake-target":     false,
+			},
+			expectedValue: 1,
+			name:          "real query and status",
+		},
+		{
+			inputNames: map[string][]string{
+				plugins.PluginTypeAPM:      {"fake-apm", "missing-apm"},
+				plugins.PluginTypeStrategy: {"fake-strategy"},
+				plugins.PluginTypeTarget:   {"fake-target"},
+			},
+			expectedErrors: map[string]bool{
+				"apm/fake-apm":           false,
+				"apm/missing-apm":        true,
+				"strategy/fake-strategy": false,
+				"target/fake-target":     false,
+			},
+			expectedValue: 42,
+			name:          "plugin fails to dispense",
+		},
+		{
+			inputNames: map[string][]string{
+				plugins.PluginTypeAPM:    {"fake-apm"},
+				plugins.PluginTypeTarget: {"fake-target"},
+			},
+			expectedErrors: map[string]bool{
+				"apm/fake-apm":       true,
+				"target/fake-target": true,
+			},
+			name: "plugins do not participate without a strategy",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tw := newTestWorker(7, 3)
+
+			cfg := tc.inputConfig
+			cfg.Count, cfg.Value = 5, 42
+			results := SelfTest(context.Background(), hclog.NewNullLogger(), tw.pluginManager, tc.inputNames, cfg)
+
+			actualErrors := make(map[string]bool)
+			for _, r := range results {
+				actualErrors[r.Type+"/"+r.Name] = r.Error != nil
+			}
+			assert.Equal(t, tc.expectedErrors, actualErrors, tc.name)
+
+			// The synthetic value is used in place of the fake APM unless a
+			// query is set, and the target is never scaled.
+			if !tc.expectedErrors["strategy/fake-strategy"] && len(tc.inputNames[plugins.PluginTypeStrategy]) > 0 {
+				assert.Equal(t, int32(1), tw.strategy.runs, tc.name)
+				assert.Equal(t, tc.expectedValue, tw.strategy.metrics[0].Value, tc.name)
+			}
+			assert.Empty(t, tw.target.scaledActions(), tc.name)
+		})
+	}
+}
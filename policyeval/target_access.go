@@ -0,0 +1,83 @@
+package policyeval
+
+import (
+	"path"
+	"strings"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// defaultTargetNamespace is the namespace of Nomad targets which do not set
+// one.
+const defaultTargetNamespace = "default"
+
+// TargetAccess restricts the targets the agent is allowed to scale,
+// regardless of the policies it evaluates. Targets are matched using glob
+// patterns, as supported by path.Match, against the name of the target plugin
+// and, for Nomad task group targets, the "<namespace>/<job>/<group>" path.
+//
+// A target matching a deny pattern is never scaled. If any allow patterns are
+// set, a target must also match one of them to be scaled.
+type TargetAccess struct {
+	allow []string
+	deny  []string
+}
+
+// NewTargetAccess returns a new TargetAccess, or nil if no patterns are set so
+// all targets are allowed.
+func NewTargetAccess(allow, deny []string) *TargetAccess {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil
+	}
+	return &TargetAccess{allow: allow, deny: deny}
+}
+
+// Allowed returns whether the agent is allowed to scale the target of the
+// policy.
+func (a *TargetAccess) Allowed(p *sdk.ScalingPolicy) bool {
+	if a == nil {
+		return true
+	}
+
+	ids := targetIdentifiers(p)
+
+	if matchAnyTarget(a.deny, ids) {
+		return false
+	}
+	return len(a.allow) == 0 || matchAnyTarget(a.allow, ids)
+}
+
+// targetIdentifiers returns the identifiers of the policy target which the
+// patterns are matched against.
+func targetIdentifiers(p *sdk.ScalingPolicy) []string {
+	if p.Target == nil {
+		return nil
+	}
+
+	ids := []string{p.Target.Name}
+
+	job, ok := p.Target.Config[sdk.TargetConfigKeyJob]
+	if !ok {
+		return ids
+	}
+
+	namespace := p.Target.Config[targetConfigKeyNamespace]
+	if namespace == "" {
+		namespace = defaultTargetNamespace
+	}
+	return append(ids, strings.Join([]string{namespace, job, p.Target.Config[sdk.TargetConfigKeyTaskGroup]}, "/"))
+}
+
+// matchAnyTarget returns whether any of the identifiers match any of the
+// patterns. Malformed patterns are rejected when the agent config is
+// validated, so never match.
+func matchAnyTarget(patterns, ids []string) bool {
+	for _, pattern := range patterns {
+		for _, id := range ids {
+			if ok, _ := path.Match(pattern, id); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
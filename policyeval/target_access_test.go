@@ -0,0 +1,75 @@
+package policyeval
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetAccess_Allowed(t *testing.T) {
+	jobPolicy := &sdk.ScalingPolicy{
+		Target: &sdk.ScalingPolicyTarget{
+			Name: "nomad-target",
+			Config: map[string]string{
+				sdk.TargetConfigKeyJob:       "web",
+				sdk.TargetConfigKeyTaskGroup: "frontend",
+			},
+		},
+	}
+	clusterPolicy := &sdk.ScalingPolicy{
+		Target: &sdk.ScalingPolicyTarget{Name: "aws-asg", Config: map[string]string{}},
+	}
+
+	testCases := []struct {
+		inputAllow     []string
+		inputDeny      []string
+		inputPolicy    *sdk.ScalingPolicy
+		expectedResult bool
+		name           string
+	}{
+		{
+			inputPolicy:    jobPolicy,
+			expectedResult: true,
+			name:           "no patterns",
+		},
+		{
+			inputAllow:     []string{"default/web/*"},
+			inputPolicy:    jobPolicy,
+			expectedResult: true,
+			name:           "allowed by job path with default namespace",
+		},
+		{
+			inputAllow:     []string{"default/api/*"},
+			inputPolicy:    jobPolicy,
+			expectedResult: false,
+			name:           "not matching allow pattern",
+		},
+		{
+			inputAllow:     []string{"nomad-target"},
+			inputDeny:      []string{"*/web/frontend"},
+			inputPolicy:    jobPolicy,
+			expectedResult: false,
+			name:           "deny takes precedence",
+		},
+		{
+			inputDeny:      []string{"aws-*"},
+			inputPolicy:    clusterPolicy,
+			expectedResult: false,
+			name:           "denied by target name",
+		},
+		{
+			inputDeny:      []string{"aws-*"},
+			inputPolicy:    jobPolicy,
+			expectedResult: true,
+			name:           "not matching deny pattern",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewTargetAccess(tc.inputAllow, tc.inputDeny)
+			assert.Equal(t, tc.expectedResult, a.Allowed(tc.inputPolicy), tc.name)
+		})
+	}
+}
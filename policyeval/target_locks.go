@@ -0,0 +1,147 @@
+package policyeval
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// TargetLocks serializes the scaling of each target across policies, so
+// policies which target the same resource do not issue conflicting scaling
+// actions. The lock of a target also tracks which policy last scaled it, so
+// an evaluation can detect the target was scaled by another policy after it
+// read the target count. It is safe for concurrent use by multiple workers.
+type TargetLocks struct {
+	l       sync.Mutex
+	targets map[string]*targetLock
+
+	// now is used to read the current time, allowing tests to control it.
+	now func() time.Time
+}
+
+// targetLock is the lock of a single target. The lock is held while sem holds
+// a value, which allows waiting for it to be canceled.
+type targetLock struct {
+	sem chan struct{}
+
+	// scaledBy and scaledAt are the ID of the policy which last scaled the
+	// target and when, and are only accessed while holding the lock.
+	scaledBy string
+	scaledAt time.Time
+}
+
+// TargetLease is a held lock of a target, which must be released once the
+// target has been scaled, or scaling was skipped. A nil lease is valid and
+// does nothing.
+type TargetLease struct {
+	locks    *TargetLocks
+	lock     *targetLock
+	policyID string
+
+	// scaledBy and scaledAt are copied from the lock when acquired.
+	scaledBy string
+	scaledAt time.Time
+}
+
+// NewTargetLocks returns a new TargetLocks.
+func NewTargetLocks() *TargetLocks {
+	return &TargetLocks{
+		targets: make(map[string]*targetLock),
+		now:     time.Now,
+	}
+}
+
+// Acquire waits for the lock of the target of the policy, returning the lease
+// once it is held, or the context error if it is canceled first. Locks must
+// not be nested, as waiting for a second lock while holding one could
+// deadlock with another worker doing the same.
+func (t *TargetLocks) Acquire(ctx context.Context, p *sdk.ScalingPolicy) (*TargetLease, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	t.l.Lock()
+	key := TargetKey(p)
+	lock, ok := t.targets[key]
+	if !ok {
+		lock = &targetLock{sem: make(chan struct{}, 1)}
+		t.targets[key] = lock
+	}
+	t.l.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case lock.sem <- struct{}{}:
+	}
+
+	return &TargetLease{
+		locks:    t,
+		lock:     lock,
+		policyID: p.ID,
+		scaledBy: lock.scaledBy,
+		scaledAt: lock.scaledAt,
+	}, nil
+}
+
+// ScaledByOther returns the ID of the policy which last scaled the target, if
+// it is a different policy. When since is set, only scaling after it is
+// considered, which allows detecting that the count read at that time is
+// outdated. An empty string is returned otherwise.
+func (l *TargetLease) ScaledByOther(since time.Time) string {
+	if l == nil || l.scaledBy == "" || l.scaledBy == l.policyID {
+		return ""
+	}
+	if !since.IsZero() && !l.scaledAt.After(since) {
+		return ""
+	}
+	return l.scaledBy
+}
+
+// Release releases the lock of the target, recording the policy of the lease
+// as the last to scale the target if scaled is true. Releasing a lease more
+// than once has no effect.
+func (l *TargetLease) Release(scaled bool) {
+	if l == nil || l.lock == nil {
+		return
+	}
+
+	if scaled {
+		l.lock.scaledBy = l.policyID
+		l.lock.scaledAt = l.locks.now()
+	}
+
+	<-l.lock.sem
+	l.lock = nil
+}
+
+// TargetKey returns the identity of the target of the policy, resolved so
+// policies which use different target plugins to scale the same Nomad task
+// group share a lock. Other targets are identified by the target plugin name
+// and config.
+func TargetKey(p *sdk.ScalingPolicy) string {
+	if p.Target == nil {
+		return ""
+	}
+
+	if _, ok := p.Target.Config[sdk.TargetConfigKeyJob]; ok {
+		ids := targetIdentifiers(p)
+		return ids[len(ids)-1]
+	}
+
+	keys := make([]string, 0, len(p.Target.Config))
+	for k := range p.Target.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := []string{p.Target.Name}
+	for _, k := range keys {
+		parts = append(parts, k+"="+p.Target.Config[k])
+	}
+	return strings.Join(parts, ",")
+}
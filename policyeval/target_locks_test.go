@@ -0,0 +1,164 @@
+package policyeval
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetLocks_Acquire(t *testing.T) {
+	locks := NewTargetLocks()
+
+	p1 := &sdk.ScalingPolicy{ID: "policy1", Target: &sdk.ScalingPolicyTarget{
+		Name:   "nomad-target",
+		Config: map[string]string{"Job": "example", "Group": "cache"},
+	}}
+	p2 := &sdk.ScalingPolicy{ID: "policy2", Target: &sdk.ScalingPolicyTarget{
+		Name:   "other-nomad-target",
+		Config: map[string]string{"Job": "example", "Group": "cache", "Namespace": "default"},
+	}}
+	p3 := &sdk.ScalingPolicy{ID: "policy3", Target: &sdk.ScalingPolicyTarget{
+		Name:   "nomad-target",
+		Config: map[string]string{"Job": "example", "Group": "web"},
+	}}
+
+	lease, err := locks.Acquire(context.Background(), p1)
+	assert.NoError(t, err)
+
+	// Policies targeting the same task group wait for the lock, while those
+	// targeting other resources do not.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = locks.Acquire(ctx, p2)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	other, err := locks.Acquire(context.Background(), p3)
+	assert.NoError(t, err)
+	other.Release(true)
+
+	acquired := make(chan *TargetLease)
+	go func() {
+		l, _ := locks.Acquire(context.Background(), p2)
+		acquired <- l
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held by another policy")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lease.Release(true)
+	lease.Release(true)
+
+	select {
+	case l := <-acquired:
+		assert.Equal(t, "policy1", l.ScaledByOther(time.Time{}))
+		l.Release(false)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the released lock")
+	}
+}
+
+func TestTargetLocks_nil(t *testing.T) {
+	var locks *TargetLocks
+
+	lease, err := locks.Acquire(context.Background(), &sdk.ScalingPolicy{})
+	assert.NoError(t, err)
+	assert.Nil(t, lease)
+	assert.Equal(t, "", lease.ScaledByOther(time.Time{}))
+	lease.Release(true)
+}
+
+func TestTargetLease_ScaledByOther(t *testing.T) {
+	scaledAt := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		inputScaledBy  string
+		inputSince     time.Time
+		expectedOutput string
+	}{
+		{
+			name:           "never scaled",
+			inputScaledBy:  "",
+			expectedOutput: "",
+		},
+		{
+			name:           "scaled by same policy",
+			inputScaledBy:  "policy1",
+			expectedOutput: "",
+		},
+		{
+			name:           "scaled by other policy",
+			inputScaledBy:  "policy2",
+			expectedOutput: "policy2",
+		},
+		{
+			name:           "scaled by other policy since",
+			inputScaledBy:  "policy2",
+			inputSince:     scaledAt.Add(-time.Minute),
+			expectedOutput: "policy2",
+		},
+		{
+			name:           "scaled by other policy before",
+			inputScaledBy:  "policy2",
+			inputSince:     scaledAt.Add(time.Minute),
+			expectedOutput: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := &TargetLease{policyID: "policy1", scaledBy: tc.inputScaledBy, scaledAt: scaledAt}
+			assert.Equal(t, tc.expectedOutput, l.ScaledByOther(tc.inputSince), tc.name)
+		})
+	}
+}
+
+func TestTargetKey(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputTarget    *sdk.ScalingPolicyTarget
+		expectedOutput string
+	}{
+		{
+			name:           "no target",
+			inputTarget:    nil,
+			expectedOutput: "",
+		},
+		{
+			name: "nomad task group",
+			inputTarget: &sdk.ScalingPolicyTarget{
+				Name:   "nomad-target",
+				Config: map[string]string{"Job": "example", "Group": "cache", "Namespace": "dev"},
+			},
+			expectedOutput: "dev/example/cache",
+		},
+		{
+			name: "nomad task group default namespace",
+			inputTarget: &sdk.ScalingPolicyTarget{
+				Name:   "nomad-target",
+				Config: map[string]string{"Job": "example", "Group": "cache"},
+			},
+			expectedOutput: "default/example/cache",
+		},
+		{
+			name: "other target",
+			inputTarget: &sdk.ScalingPolicyTarget{
+				Name:   "aws-asg",
+				Config: map[string]string{"aws_asg_name": "workers", "node_class": "compute"},
+			},
+			expectedOutput: "aws-asg,aws_asg_name=workers,node_class=compute",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, TargetKey(&sdk.ScalingPolicy{Target: tc.inputTarget}), tc.name)
+		})
+	}
+}
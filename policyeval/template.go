@@ -0,0 +1,154 @@
+package policyeval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+)
+
+// targetConfigKeyNamespace is the target config key holding the namespace of
+// Nomad targets.
+const targetConfigKeyNamespace = "Namespace"
+
+// templateVars returns the template variables available to the policy. Target
+// config variables are only included when the config key is set, so that a
+// policy referencing them against a target without the key fails clearly.
+func templateVars(p *sdk.ScalingPolicy) map[string]string {
+	vars := map[string]string{sdk.TemplateVarPolicyID: p.ID}
+
+	for k, v := range p.Labels {
+		vars[sdk.TemplateVarLabelPrefix+k] = v
+	}
+
+	if p.Target != nil {
+		for name, key := range map[string]string{
+			sdk.TemplateVarNamespace: targetConfigKeyNamespace,
+			sdk.TemplateVarJob:       sdk.TargetConfigKeyJob,
+			sdk.TemplateVarGroup:     sdk.TargetConfigKeyTaskGroup,
+		} {
+			if v, ok := p.Target.Config[key]; ok {
+				vars[name] = v
+			}
+		}
+	}
+
+	return vars
+}
+
+// isTemplateVar returns whether name is one of the policy template
+// variables, regardless of whether it is available to a particular policy.
+func isTemplateVar(name string) bool {
+	switch name {
+	case sdk.TemplateVarPolicyID, sdk.TemplateVarNamespace, sdk.TemplateVarJob,
+		sdk.TemplateVarGroup, sdk.TemplateVarCurrentCount:
+		return true
+	}
+	return strings.HasPrefix(name, sdk.TemplateVarLabelPrefix)
+}
+
+// renderTemplate substitutes each ${name} reference within s with the value
+// of the variable. References which are not template variables, along with
+// unterminated references, are left untouched as they may be meaningful to
+// the plugin consuming the value, such as an APM query language. An error is
+// returned if a template variable is not available to the policy.
+func renderTemplate(s string, vars map[string]string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start == -1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+
+		end := strings.Index(s[start:], "}")
+		if end == -1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		end += start
+
+		name := s[start+2 : end]
+		b.WriteString(s[:start])
+
+		if v, ok := vars[name]; ok {
+			b.WriteString(v)
+		} else if isTemplateVar(name) {
+			return "", fmt.Errorf("template variable %q is not available to the policy", name)
+		} else {
+			b.WriteString(s[start : end+1])
+		}
+		s = s[end+1:]
+	}
+}
+
+// resolveTargetTemplates substitutes the template variables within the
+// policy Target.Config and ShadowTarget.Config values. The policy is copied
+// before it is modified so the original, which is shared with the policy
+// handler, keeps the templates for subsequent evaluations.
+func resolveTargetTemplates(p *sdk.ScalingPolicy) (*sdk.ScalingPolicy, error) {
+	if p.Target == nil && p.ShadowTarget == nil {
+		return p, nil
+	}
+
+	vars := templateVars(p)
+
+	target, err := renderTarget(p.Target, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render target config %v", err)
+	}
+	shadow, err := renderTarget(p.ShadowTarget, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render shadow target config %v", err)
+	}
+
+	if target == p.Target && shadow == p.ShadowTarget {
+		return p, nil
+	}
+
+	out := *p
+	out.Target = target
+	out.ShadowTarget = shadow
+	return &out, nil
+}
+
+// renderTarget substitutes the template variables within the target config
+// values. The target is returned unchanged if none of the values reference
+// a template variable, and copied otherwise.
+func renderTarget(t *sdk.ScalingPolicyTarget, vars map[string]string) (*sdk.ScalingPolicyTarget, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	var config map[string]string
+
+	for k, v := range t.Config {
+		rendered, err := renderTemplate(v, vars)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", k, err)
+		}
+		if rendered == v {
+			continue
+		}
+
+		if config == nil {
+			config = make(map[string]string, len(t.Config))
+			for ck, cv := range t.Config {
+				config[ck] = cv
+			}
+		}
+		config[k] = rendered
+	}
+
+	if config == nil {
+		return t, nil
+	}
+
+	target := *t
+	target.Config = config
+	return &target, nil
+}
@@ -0,0 +1,179 @@
+package policyeval
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/hashicorp/nomad-autoscaler/policy/file"
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_renderTemplate(t *testing.T) {
+	vars := map[string]string{
+		"policy_id":  "policy-id",
+		"job":        "web",
+		"label.team": "platform",
+	}
+
+	testCases := []struct {
+		name          string
+		input         string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "no template",
+			input:    "web",
+			expected: "web",
+		},
+		{
+			name:     "single variable",
+			input:    "${job}",
+			expected: "web",
+		},
+		{
+			name:     "multiple variables",
+			input:    "${label.team}-${job}-${policy_id}",
+			expected: "platform-web-policy-id",
+		},
+		{
+			name:     "unknown variable",
+			input:    `${job}-${host:"web"}-${policy_id}`,
+			expected: `web-${host:"web"}-policy-id`,
+		},
+		{
+			name:          "unavailable variable",
+			input:         "${job}-${group}",
+			expectedError: `template variable "group" is not available to the policy`,
+		},
+		{
+			name:          "unavailable label",
+			input:         "${job}-${label.owner}",
+			expectedError: `template variable "label.owner" is not available to the policy`,
+		},
+		{
+			name:     "unterminated variable",
+			input:    "${job}-${job",
+			expected: "web-${job",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := renderTemplate(tc.input, vars)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError, tc.name)
+				return
+			}
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expected, actual, tc.name)
+		})
+	}
+}
+
+func Test_resolveTargetTemplates(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputConfig    map[string]string
+		expectedConfig map[string]string
+		expectedError  string
+	}{
+		{
+			name:           "no templates",
+			inputConfig:    map[string]string{"Job": "web", "Group": "cache"},
+			expectedConfig: map[string]string{"Job": "web", "Group": "cache"},
+		},
+		{
+			name: "templates resolved",
+			inputConfig: map[string]string{
+				"Namespace": "${label.team}",
+				"Job":       "web",
+				"Group":     "${job}-cache",
+				"Owner":     "${policy_id}",
+			},
+			expectedConfig: map[string]string{
+				"Namespace": "platform",
+				"Job":       "web",
+				"Group":     "web-cache",
+				"Owner":     "web-policy",
+			},
+		},
+		{
+			name:           "unknown variable",
+			inputConfig:    map[string]string{"Job": "web", "Group": "${unknown}"},
+			expectedConfig: map[string]string{"Job": "web", "Group": "${unknown}"},
+		},
+		{
+			name:          "unavailable variable",
+			inputConfig:   map[string]string{"Job": "web", "Group": "${namespace}"},
+			expectedError: `failed to render target config "Group": template variable "namespace" is not available to the policy`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &sdk.ScalingPolicy{
+				ID:     "web-policy",
+				Labels: map[string]string{"team": "platform"},
+				Target: &sdk.ScalingPolicyTarget{Name: "nomad-target", Config: tc.inputConfig},
+			}
+			original := make(map[string]string)
+			for k, v := range tc.inputConfig {
+				original[k] = v
+			}
+
+			actual, err := resolveTargetTemplates(p)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError, tc.name)
+				return
+			}
+			assert.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expectedConfig, actual.Target.Config, tc.name)
+			assert.Equal(t, "nomad-target", actual.Target.Name, tc.name)
+
+			// The original policy must keep its templates.
+			assert.Equal(t, original, p.Target.Config, tc.name)
+		})
+	}
+}
+
+func Test_resolveTargetTemplates_shadowTarget(t *testing.T) {
+	p := &sdk.ScalingPolicy{
+		ID:           "web-policy",
+		Target:       &sdk.ScalingPolicyTarget{Name: "nomad-target", Config: map[string]string{"Job": "web"}},
+		ShadowTarget: &sdk.ScalingPolicyTarget{Name: "shadow-target", Config: map[string]string{"Job": "${job}-next"}},
+	}
+
+	actual, err := resolveTargetTemplates(p)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Job": "web"}, actual.Target.Config)
+	assert.Equal(t, map[string]string{"Job": "web-next"}, actual.ShadowTarget.Config)
+	assert.Equal(t, "shadow-target", actual.ShadowTarget.Name)
+
+	// The original policy must keep its templates.
+	assert.Equal(t, map[string]string{"Job": "${job}-next"}, p.ShadowTarget.Config)
+
+	p.ShadowTarget.Config["Group"] = "${group}"
+	_, err = resolveTargetTemplates(p)
+	assert.EqualError(t, err, `failed to render shadow target config "Group": template variable "group" is not available to the policy`)
+}
+
+func Test_resolveTargetTemplates_policyFile(t *testing.T) {
+	inputFile := "./test-fixtures/target-template-policy.hcl"
+
+	src, err := ioutil.ReadFile(inputFile)
+	assert.NoError(t, err)
+
+	p := &sdk.ScalingPolicy{}
+	assert.NoError(t, file.Decode(inputFile, src, p))
+	p.ID = "web-policy"
+
+	actual, err := resolveTargetTemplates(p)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Namespace": "platform",
+		"Job":       "web",
+		"Group":     "web-cache",
+	}, actual.Target.Config)
+}
@@ -0,0 +1,23 @@
+enabled = true
+min     = 1
+max     = 10
+type    = "horizontal"
+
+policy {
+
+  check "load_per_instance" {
+    source = "prometheus"
+
+    # The $$ escape stops HCL from interpolating the template variable.
+    query = "sum(rate(http_requests_total[1m])) / $${current_count}"
+
+    strategy "target-value" {
+      target = "100"
+    }
+  }
+
+  target "nomad" {
+    Group = "cache"
+    Job   = "example"
+  }
+}
@@ -0,0 +1,26 @@
+enabled = true
+min     = 1
+max     = 10
+type    = "horizontal"
+
+policy {
+  labels = {
+    team = "platform"
+  }
+
+  check "load" {
+    source = "prometheus"
+    query  = "avg(load)"
+
+    strategy "target-value" {
+      target = "100"
+    }
+  }
+
+  # The $$ escape stops HCL from interpolating the template variables.
+  target "nomad" {
+    Namespace = "$${label.team}"
+    Job       = "web"
+    Group     = "$${job}-cache"
+  }
+}
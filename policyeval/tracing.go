@@ -0,0 +1,30 @@
+package policyeval
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the instrumentation library of the policy evaluation
+// spans.
+const tracerName = "github.com/hashicorp/nomad-autoscaler/policyeval"
+
+// startSpan starts a span for a step of a policy evaluation. The tracer is
+// looked up from the global provider on each call, so spans are no-ops unless
+// the agent has tracing enabled.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, marking it as failed if err is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
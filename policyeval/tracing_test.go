@@ -0,0 +1,121 @@
+package policyeval
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/nomad-autoscaler/sdk"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestSpanRecorder installs a global tracer provider which records the
+// spans, restoring the no-op provider once the test finishes.
+func newTestSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+	return sr
+}
+
+// spanAttributes returns the attributes of span keyed by name.
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestBaseWorker_handlePolicy_tracing(t *testing.T) {
+	testCases := []struct {
+		inputCount         int64
+		inputDesired       int64
+		inputAPMErr        error
+		expectedSpans      []string
+		expectedEvalStatus codes.Code
+		name               string
+	}{
+		{
+			inputCount:         2,
+			inputDesired:       5,
+			expectedSpans:      []string{"target.count", "apm.query", "strategy.run", "target.scale", "policy.evaluate"},
+			expectedEvalStatus: codes.Unset,
+			name:               "scaling evaluation",
+		},
+		{
+			inputCount:         2,
+			inputDesired:       2,
+			expectedSpans:      []string{"target.count", "apm.query", "strategy.run", "policy.evaluate"},
+			expectedEvalStatus: codes.Unset,
+			name:               "evaluation without change",
+		},
+		{
+			inputCount:         2,
+			inputDesired:       5,
+			inputAPMErr:        fmt.Errorf("apm unavailable"),
+			expectedSpans:      []string{"target.count", "apm.query", "policy.evaluate"},
+			expectedEvalStatus: codes.Error,
+			name:               "failed query",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sr := newTestSpanRecorder(t)
+
+			w := newTestWorker(tc.inputCount, tc.inputDesired)
+			w.apm.err = tc.inputAPMErr
+
+			_ = w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(newTestPolicy(), nil))
+
+			spans := sr.Ended()
+			names := make([]string, 0, len(spans))
+			for _, span := range spans {
+				names = append(names, span.Name())
+			}
+			assert.Equal(t, tc.expectedSpans, names, tc.name)
+
+			// The check spans are children of the evaluation span.
+			evalSpan := spans[len(spans)-1]
+			for _, span := range spans[:len(spans)-1] {
+				assert.Equal(t, evalSpan.SpanContext().SpanID(), span.Parent().SpanID(), span.Name())
+				assert.Equal(t, "test-policy", spanAttributes(span)["policy_id"].AsString(), span.Name())
+			}
+			assert.Equal(t, "test-policy", spanAttributes(evalSpan)["policy_id"].AsString(), tc.name)
+			assert.Equal(t, tc.expectedEvalStatus, evalSpan.Status().Code, tc.name)
+
+			count := spanAttributes(spans[0])["current_count"]
+			assert.Equal(t, tc.inputCount, count.AsInt64(), tc.name)
+		})
+	}
+}
+
+func TestBaseWorker_handlePolicy_tracingAttributes(t *testing.T) {
+	sr := newTestSpanRecorder(t)
+
+	w := newTestWorker(2, 5)
+	assert.NoError(t, w.handlePolicy(context.Background(), sdk.NewScalingEvaluation(newTestPolicy(), nil)))
+
+	spans := make(map[string]map[attribute.Key]attribute.Value)
+	for _, span := range sr.Ended() {
+		spans[span.Name()] = spanAttributes(span)
+	}
+
+	assert.Equal(t, "query", spans["apm.query"]["query"].AsString())
+	assert.Equal(t, int64(1), spans["apm.query"]["metrics"].AsInt64())
+	assert.Equal(t, float64(1), spans["apm.query"]["value"].AsFloat64())
+
+	assert.Equal(t, int64(5), spans["strategy.run"]["new_count"].AsInt64())
+	assert.Equal(t, "up", spans["strategy.run"]["direction"].AsString())
+
+	assert.Equal(t, int64(5), spans["target.scale"]["new_count"].AsInt64())
+	assert.Equal(t, int64(5), spans["policy.evaluate"]["new_count"].AsInt64())
+	assert.Equal(t, "check", spans["policy.evaluate"]["check"].AsString())
+}
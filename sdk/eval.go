@@ -14,6 +14,12 @@ type ScalingEvaluation struct {
 	TargetStatus     *TargetStatus
 	CheckEvaluations []*ScalingCheckEvaluation
 	CreateTime       time.Time
+
+	// ReconcileBounds indicates the target count should be brought within
+	// the policy Min and Max bounds before the checks are evaluated. It is
+	// set on the first evaluation of policies which have ReconcileOnStart
+	// enabled.
+	ReconcileBounds bool
 }
 
 // NewScalingEvaluation creates a new ScalingEvaluation based off the passed
@@ -32,7 +38,8 @@ func NewScalingEvaluation(p *ScalingPolicy, status *TargetStatus) *ScalingEvalua
 	// Iterate the policy checks and add then to the eval.
 	for _, check := range p.Checks {
 		checkEval := ScalingCheckEvaluation{
-			Check: check,
+			Check:    check,
+			Rounding: p.Rounding,
 			Action: &ScalingAction{
 				Meta: map[string]interface{}{
 					"nomad_policy_id": p.ID,
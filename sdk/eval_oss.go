@@ -14,6 +14,21 @@ type ScalingCheckEvaluation struct {
 	// Metrics is the metric resulting from querying the APM.
 	Metrics TimestampedMetrics
 
+	// MetricsCached indicates the Metrics were reused from a previous APM
+	// query rather than queried for this evaluation. The metric timestamps
+	// are those of the original query, so staleness can still be detected.
+	MetricsCached bool
+
+	// Rounding is the RoundingMode of the policy, which strategies use when
+	// calculating the new count from a fractional value. When unset, the
+	// strategy applies its own default.
+	Rounding string
+
 	// Action is the calculated desired state and is populated by strategy.Run.
 	Action *ScalingAction
+
+	// Status is optionally populated by strategy.Run to describe the outcome
+	// of the calculation. When set to StrategyStatusNoData, the Action is
+	// ignored and the target is not scaled.
+	Status StrategyStatus
 }
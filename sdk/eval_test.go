@@ -22,6 +22,7 @@ func TestNewScalingEvaluation(t *testing.T) {
 				Enabled:            true,
 				Cooldown:           1 * time.Minute,
 				EvaluationInterval: 1 * time.Second,
+				Rounding:           RoundingModeFloor,
 				Checks: []*ScalingPolicyCheck{
 					{
 						Name:   "first-check",
@@ -60,6 +61,7 @@ func TestNewScalingEvaluation(t *testing.T) {
 					Enabled:            true,
 					Cooldown:           1 * time.Minute,
 					EvaluationInterval: 1 * time.Second,
+					Rounding:           RoundingModeFloor,
 					Checks: []*ScalingPolicyCheck{
 						{
 							Name:   "first-check",
@@ -101,7 +103,8 @@ func TestNewScalingEvaluation(t *testing.T) {
 								Config: map[string]string{"target": "10"},
 							},
 						},
-						Metrics: nil,
+						Metrics:  nil,
+						Rounding: RoundingModeFloor,
 						Action: &ScalingAction{
 							Meta: map[string]interface{}{
 								"nomad_policy_id": "test-test-test",
@@ -118,7 +121,8 @@ func TestNewScalingEvaluation(t *testing.T) {
 								Config: map[string]string{"target": "100"},
 							},
 						},
-						Metrics: nil,
+						Metrics:  nil,
+						Rounding: RoundingModeFloor,
 						Action: &ScalingAction{
 							Meta: map[string]interface{}{
 								"nomad_policy_id": "test-test-test",
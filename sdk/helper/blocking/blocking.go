@@ -3,3 +3,15 @@ package blocking
 // IndexHasChanged is used to check whether a returned blocking query has an
 // updated index, compared to a tracked value.
 func IndexHasChanged(new, old uint64) bool { return new != old }
+
+// NextIndex returns the index to use for the next blocking query, given the
+// index it was made with and the index returned. An index which goes
+// backwards, such as after the server state is restored from a snapshot,
+// restarts the query from the beginning. The index is never lower than one,
+// as a query made with a zero index does not block.
+func NextIndex(new, old uint64) uint64 {
+	if new < old || new < 1 {
+		return 1
+	}
+	return new
+}
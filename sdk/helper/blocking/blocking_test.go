@@ -34,3 +34,43 @@ func Test_indexHasChange(t *testing.T) {
 		assert.Equal(t, tc.expectedReturn, res)
 	}
 }
+
+func Test_NextIndex(t *testing.T) {
+	testCases := []struct {
+		newValue       uint64
+		oldValue       uint64
+		expectedReturn uint64
+		name           string
+	}{
+		{
+			newValue:       13,
+			oldValue:       7,
+			expectedReturn: 13,
+			name:           "index moved forward",
+		},
+		{
+			newValue:       13,
+			oldValue:       13,
+			expectedReturn: 13,
+			name:           "index unchanged",
+		},
+		{
+			newValue:       7,
+			oldValue:       13,
+			expectedReturn: 1,
+			name:           "index went backwards",
+		},
+		{
+			newValue:       0,
+			oldValue:       1,
+			expectedReturn: 1,
+			name:           "zero index",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedReturn, NextIndex(tc.newValue, tc.oldValue), tc.name)
+		})
+	}
+}
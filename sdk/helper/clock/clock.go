@@ -0,0 +1,85 @@
+package clock
+
+import "time"
+
+// Clock provides the current time and the timers used to wait for it to
+// pass. Code which depends on the passage of time uses a Clock instead of the
+// time package directly, so tests can control time using a Fake.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Since returns the time elapsed since t.
+	Since(t time.Time) time.Duration
+
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel.
+	After(d time.Duration) <-chan time.Time
+
+	// Sleep pauses the current Go routine for at least the duration.
+	Sleep(d time.Duration)
+
+	// NewTimer returns a new Timer which sends the current time on its
+	// channel after at least the duration.
+	NewTimer(d time.Duration) Timer
+
+	// NewTicker returns a new Ticker which sends the current time on its
+	// channel at the interval. The duration must be greater than zero.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a single event timer, equivalent to time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing. It returns false if the timer has
+	// already expired or been stopped.
+	Stop() bool
+
+	// Reset changes the timer to expire after the duration. It returns true
+	// if the timer had been active.
+	Reset(d time.Duration) bool
+}
+
+// Ticker delivers ticks at an interval, equivalent to time.Ticker.
+type Ticker interface {
+	// C returns the channel on which the ticks are delivered.
+	C() <-chan time.Time
+
+	// Stop turns off the ticker, after which no more ticks are sent.
+	Stop()
+}
+
+// Real returns a Clock backed by the time package.
+func Real() Clock { return realClock{} }
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{timer: time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{ticker: time.NewTicker(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t *realTimer) C() <-chan time.Time        { return t.timer.C }
+func (t *realTimer) Stop() bool                 { return t.timer.Stop() }
+func (t *realTimer) Reset(d time.Duration) bool { return t.timer.Reset(d) }
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t *realTicker) C() <-chan time.Time { return t.ticker.C }
+func (t *realTicker) Stop()               { t.ticker.Stop() }
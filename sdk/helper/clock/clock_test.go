@@ -0,0 +1,24 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReal(t *testing.T) {
+	c := Real()
+
+	timer := c.NewTimer(time.Millisecond)
+	<-timer.C()
+	assert.False(t, timer.Stop())
+
+	ticker := c.NewTicker(time.Millisecond)
+	<-ticker.C()
+	ticker.Stop()
+
+	start := c.Now()
+	c.Sleep(time.Millisecond)
+	assert.True(t, c.Since(start) >= time.Millisecond)
+}
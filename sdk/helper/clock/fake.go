@@ -0,0 +1,181 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when instructed, allowing tests of
+// time dependent behavior to run deterministically and without waiting. Its
+// timers and tickers fire as Advance moves the time past their deadline,
+// delivering to channels buffered like those of the time package, so ticks
+// which are not received in time are dropped. It is safe for concurrent use.
+type Fake struct {
+	l    sync.Mutex
+	cond *sync.Cond
+
+	// now is the current time returned by Now. elapsed is the time the clock
+	// has advanced since it was created, which the waiter deadlines are based
+	// on, so jumps of the wall clock do not affect them.
+	now     time.Time
+	elapsed time.Duration
+
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a timer or ticker of a Fake clock. A ticker has a period
+// greater than zero and is wrapped by fakeTicker to satisfy the Ticker
+// interface.
+type fakeWaiter struct {
+	clock    *Fake
+	c        chan time.Time
+	deadline time.Duration
+	period   time.Duration
+}
+
+// NewFake returns a new Fake clock set to the time.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.l)
+	return f
+}
+
+// Now satisfies the Now function of the Clock interface.
+func (f *Fake) Now() time.Time {
+	f.l.Lock()
+	defer f.l.Unlock()
+	return f.now
+}
+
+// Since satisfies the Since function of the Clock interface.
+func (f *Fake) Since(t time.Time) time.Duration { return f.Now().Sub(t) }
+
+// After satisfies the After function of the Clock interface.
+func (f *Fake) After(d time.Duration) <-chan time.Time { return f.NewTimer(d).C() }
+
+// Sleep satisfies the Sleep function of the Clock interface. It blocks until
+// the clock is advanced by the duration.
+func (f *Fake) Sleep(d time.Duration) { <-f.After(d) }
+
+// NewTimer satisfies the NewTimer function of the Clock interface.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	w := &fakeWaiter{clock: f, c: make(chan time.Time, 1)}
+	w.Reset(d)
+	return w
+}
+
+// NewTicker satisfies the NewTicker function of the Clock interface.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	w := &fakeWaiter{clock: f, c: make(chan time.Time, 1), period: d}
+	f.l.Lock()
+	f.add(w, d)
+	f.l.Unlock()
+	return fakeTicker{w}
+}
+
+// Advance moves the clock forward by the duration, firing the timers and
+// tickers whose deadline is reached, in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	end := f.elapsed + d
+	for len(f.waiters) > 0 && f.waiters[0].deadline <= end {
+		w := f.waiters[0]
+		f.now = f.now.Add(w.deadline - f.elapsed)
+		f.elapsed = w.deadline
+
+		select {
+		case w.c <- f.now:
+		default:
+		}
+
+		f.remove(w)
+		if w.period > 0 {
+			f.add(w, w.period)
+		}
+	}
+
+	f.now = f.now.Add(end - f.elapsed)
+	f.elapsed = end
+}
+
+// Jump moves the time returned by Now by the duration, which may be negative,
+// without firing any timer or ticker. It simulates a jump of the system
+// clock, which does not affect the timers of the time package.
+func (f *Fake) Jump(d time.Duration) {
+	f.l.Lock()
+	defer f.l.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// BlockUntil blocks until at least n timers and tickers are active. Tests use
+// it to wait for the code under test to start waiting before advancing the
+// clock.
+func (f *Fake) BlockUntil(n int) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// add schedules the waiter to fire after the duration. The lock must be held
+// by the caller.
+func (f *Fake) add(w *fakeWaiter, d time.Duration) {
+	w.deadline = f.elapsed + d
+	f.waiters = append(f.waiters, w)
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].deadline < f.waiters[j].deadline
+	})
+	f.cond.Broadcast()
+}
+
+// remove unschedules the waiter, returning false if it was not active. The
+// lock must be held by the caller.
+func (f *Fake) remove(w *fakeWaiter) bool {
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+func (w *fakeWaiter) C() <-chan time.Time { return w.c }
+
+func (w *fakeWaiter) Stop() bool {
+	w.clock.l.Lock()
+	defer w.clock.l.Unlock()
+	return w.clock.remove(w)
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.clock.l.Lock()
+	defer w.clock.l.Unlock()
+
+	active := w.clock.remove(w)
+	if d <= 0 {
+		select {
+		case w.c <- w.clock.now:
+		default:
+		}
+		return active
+	}
+	w.clock.add(w, d)
+	return active
+}
+
+type fakeTicker struct {
+	*fakeWaiter
+}
+
+func (t fakeTicker) Stop() { t.fakeWaiter.Stop() }
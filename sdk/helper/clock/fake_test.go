@@ -0,0 +1,92 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testStart = time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// received returns the value waiting on the channel, or the zero time if
+// there is none.
+func received(c <-chan time.Time) time.Time {
+	select {
+	case t := <-c:
+		return t
+	default:
+		return time.Time{}
+	}
+}
+
+func TestFake_Timer(t *testing.T) {
+	f := NewFake(testStart)
+	timer := f.NewTimer(time.Minute)
+
+	f.Advance(30 * time.Second)
+	assert.True(t, received(timer.C()).IsZero())
+	assert.Equal(t, testStart.Add(30*time.Second), f.Now())
+
+	f.Advance(time.Minute)
+	assert.Equal(t, testStart.Add(time.Minute), received(timer.C()))
+	assert.Equal(t, testStart.Add(90*time.Second), f.Now())
+	assert.False(t, timer.Stop())
+
+	// A reset timer fires relative to the current time.
+	assert.False(t, timer.Reset(time.Minute))
+	assert.True(t, timer.Stop())
+	f.Advance(time.Hour)
+	assert.True(t, received(timer.C()).IsZero())
+}
+
+func TestFake_Ticker(t *testing.T) {
+	f := NewFake(testStart)
+	ticker := f.NewTicker(time.Minute)
+
+	f.Advance(time.Minute)
+	assert.Equal(t, testStart.Add(time.Minute), received(ticker.C()))
+
+	// Ticks which are not received are dropped.
+	f.Advance(3 * time.Minute)
+	assert.Equal(t, testStart.Add(2*time.Minute), received(ticker.C()))
+	assert.True(t, received(ticker.C()).IsZero())
+
+	ticker.Stop()
+	f.Advance(time.Hour)
+	assert.True(t, received(ticker.C()).IsZero())
+}
+
+func TestFake_Jump(t *testing.T) {
+	f := NewFake(testStart)
+	timer := f.NewTimer(time.Minute)
+
+	// Jumps of the wall clock do not affect timers.
+	f.Jump(-time.Hour)
+	assert.Equal(t, testStart.Add(-time.Hour), f.Now())
+	assert.Equal(t, -time.Hour, f.Since(testStart))
+
+	f.Advance(59 * time.Second)
+	assert.True(t, received(timer.C()).IsZero())
+	f.Advance(time.Second)
+	assert.Equal(t, testStart.Add(-59*time.Minute), received(timer.C()))
+}
+
+func TestFake_Sleep(t *testing.T) {
+	f := NewFake(testStart)
+	doneCh := make(chan struct{})
+
+	go func() {
+		f.Sleep(time.Minute)
+		close(doneCh)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for sleep to return")
+	}
+}
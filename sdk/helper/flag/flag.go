@@ -1,6 +1,7 @@
 package flag
 
 import (
+	"strconv"
 	"strings"
 	"time"
 )
@@ -31,3 +32,17 @@ func (f FuncDurationVar) Set(s string) error {
 }
 func (f FuncDurationVar) String() string   { return "" }
 func (f FuncDurationVar) IsBoolFlag() bool { return false }
+
+// FuncIntVar is a type of flag that accepts a function, converts the user's
+// value to an int, and then calls the given function.
+type FuncIntVar func(i int) error
+
+func (f FuncIntVar) Set(s string) error {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	return f(v)
+}
+func (f FuncIntVar) String() string   { return "" }
+func (f FuncIntVar) IsBoolFlag() bool { return false }
@@ -28,3 +28,18 @@ func TestFuncDurationVar(t *testing.T) {
 	assert.Equal(t, "", sv.String())
 	assert.False(t, sv.IsBoolFlag())
 }
+
+func TestFuncIntVar(t *testing.T) {
+	var i int
+
+	sv := FuncIntVar(func(v int) error {
+		i = v
+		return nil
+	})
+
+	assert.Nil(t, sv.Set("3"))
+	assert.Equal(t, 3, i)
+	assert.Error(t, sv.Set("three"))
+	assert.Equal(t, "", sv.String())
+	assert.False(t, sv.IsBoolFlag())
+}
@@ -0,0 +1,211 @@
+package nomad
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+const (
+	configKeyNomadRequestTimeout = "nomad_request-timeout"
+	configKeyNomadRetries        = "nomad_retries"
+	configKeyNomadRetryBackoff   = "nomad_retry-backoff"
+
+	// defaultBlockingQueryWait is the wait time Nomad applies to blocking
+	// queries which do not specify one.
+	defaultBlockingQueryWait = 5 * time.Minute
+
+	// maxRetryBackoff caps the exponential backoff between retries.
+	maxRetryBackoff = 30 * time.Second
+)
+
+// ConfigureHTTPClient sets the HTTP client of the Nomad API config to one
+// which limits each request to the timeout and retries failed read requests
+// up to retries times, waiting an exponentially increasing backoff between
+// attempts. The config is not modified if neither the timeout nor retries are
+// set, so the Nomad API default client is used.
+func ConfigureHTTPClient(cfg *api.Config, timeout time.Duration, retries int, backoff time.Duration) error {
+	if timeout <= 0 && retries <= 0 {
+		return nil
+	}
+
+	// Mirror the Nomad API default client, which is not exported.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+
+	httpClient := &http.Client{Transport: transport}
+	if cfg.TLSConfig != nil {
+		if err := api.ConfigureTLS(httpClient, cfg.TLSConfig); err != nil {
+			return err
+		}
+	}
+
+	httpClient.Transport = &retryTransport{
+		base:    transport,
+		timeout: timeout,
+		retries: retries,
+		backoff: backoff,
+	}
+	cfg.HttpClient = httpClient
+	return nil
+}
+
+// ConfigureHTTPClientFromNamespacedMap configures the HTTP client of the Nomad
+// API config using the request timeout and retry values within the map
+// representation of a Nomad config.
+func ConfigureHTTPClientFromNamespacedMap(c *api.Config, cfg map[string]string) error {
+	var (
+		timeout, backoff time.Duration
+		retries          int
+		err              error
+	)
+
+	if v, ok := cfg[configKeyNomadRequestTimeout]; ok {
+		if timeout, err = time.ParseDuration(v); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", configKeyNomadRequestTimeout, err)
+		}
+	}
+	if v, ok := cfg[configKeyNomadRetries]; ok {
+		if retries, err = strconv.Atoi(v); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", configKeyNomadRetries, err)
+		}
+	}
+	if v, ok := cfg[configKeyNomadRetryBackoff]; ok {
+		if backoff, err = time.ParseDuration(v); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", configKeyNomadRetryBackoff, err)
+		}
+	}
+
+	return ConfigureHTTPClient(c, timeout, retries, backoff)
+}
+
+// retryTransport is an http.RoundTripper which applies a timeout to each
+// request attempt and retries failed read requests.
+type retryTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+// RoundTrip satisfies the RoundTrip function of the http.RoundTripper
+// interface. Only GET requests are retried, as retrying writes such as
+// scaling requests could apply them twice.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retries := t.retries
+	if req.Method != http.MethodGet {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.roundTrip(req)
+		if attempt >= retries || !retryable(resp, err) {
+			return resp, err
+		}
+
+		// Discard the failed response so the connection can be reused.
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(retryBackoff(t.backoff, attempt)):
+		}
+	}
+}
+
+// roundTrip performs a single request attempt. The timeout is derived from
+// the request context, so a shorter deadline set by the caller still applies.
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	timeout := requestTimeout(req, t.timeout)
+	if timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The body is read after RoundTrip returns, so only release the context
+	// once it is closed.
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// requestTimeout returns the timeout of the request. Blocking queries hold the
+// request open for up to their wait time, plus the jitter Nomad adds to it,
+// so the timeout applies on top of the wait rather than cutting it short.
+func requestTimeout(req *http.Request, timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return 0
+	}
+
+	q := req.URL.Query()
+	if q.Get("index") == "" {
+		return timeout
+	}
+
+	wait := defaultBlockingQueryWait
+	if w, err := time.ParseDuration(q.Get("wait")); err == nil && w > 0 {
+		wait = w
+	}
+	return timeout + wait + wait/16
+}
+
+// retryable returns whether the outcome of a request attempt should be
+// retried. Connection errors and responses indicating the Nomad server is
+// temporarily unavailable are retried.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryBackoff returns the time to wait before retrying the request for the
+// attempt, doubling the backoff for each attempt up to maxRetryBackoff.
+func retryBackoff(backoff time.Duration, attempt int) time.Duration {
+	if backoff <= 0 {
+		return 0
+	}
+
+	for i := 0; i < attempt && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
+
+// cancelReadCloser cancels the context of a request once its response body is
+// closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
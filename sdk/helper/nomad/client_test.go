@@ -0,0 +1,246 @@
+package nomad
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureHTTPClient(t *testing.T) {
+	cfg := api.DefaultConfig()
+	assert.NoError(t, ConfigureHTTPClient(cfg, 0, 0, 0))
+	assert.Nil(t, cfg.HttpClient)
+
+	assert.NoError(t, ConfigureHTTPClient(cfg, time.Second, 2, time.Millisecond))
+	assert.NotNil(t, cfg.HttpClient)
+	assert.IsType(t, &retryTransport{}, cfg.HttpClient.Transport)
+}
+
+func TestConfigureHTTPClientFromNamespacedMap(t *testing.T) {
+	testCases := []struct {
+		inputMap      map[string]string
+		expectedError string
+		name          string
+	}{
+		{
+			inputMap: map[string]string{},
+			name:     "empty map",
+		},
+		{
+			inputMap: map[string]string{
+				"nomad_request-timeout": "30s",
+				"nomad_retries":         "2",
+				"nomad_retry-backoff":   "1s",
+			},
+			name: "valid values",
+		},
+		{
+			inputMap:      map[string]string{"nomad_request-timeout": "soon"},
+			expectedError: `failed to parse nomad_request-timeout: time: invalid duration "soon"`,
+			name:          "invalid request timeout",
+		},
+		{
+			inputMap:      map[string]string{"nomad_retries": "many"},
+			expectedError: `failed to parse nomad_retries: strconv.Atoi: parsing "many": invalid syntax`,
+			name:          "invalid retries",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ConfigureHTTPClientFromNamespacedMap(api.DefaultConfig(), tc.inputMap)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError, tc.name)
+				return
+			}
+			assert.NoError(t, err, tc.name)
+		})
+	}
+}
+
+func Test_retryTransport(t *testing.T) {
+	testCases := []struct {
+		inputMethod      string
+		inputFailures    int32
+		inputRetries     int
+		expectedCode     int
+		expectedRequests int32
+		name             string
+	}{
+		{
+			inputMethod:      http.MethodGet,
+			inputFailures:    0,
+			inputRetries:     2,
+			expectedCode:     http.StatusOK,
+			expectedRequests: 1,
+			name:             "successful request",
+		},
+		{
+			inputMethod:      http.MethodGet,
+			inputFailures:    2,
+			inputRetries:     2,
+			expectedCode:     http.StatusOK,
+			expectedRequests: 3,
+			name:             "read retried until successful",
+		},
+		{
+			inputMethod:      http.MethodGet,
+			inputFailures:    3,
+			inputRetries:     2,
+			expectedCode:     http.StatusServiceUnavailable,
+			expectedRequests: 3,
+			name:             "read retries exhausted",
+		},
+		{
+			inputMethod:      http.MethodPost,
+			inputFailures:    1,
+			inputRetries:     2,
+			expectedCode:     http.StatusServiceUnavailable,
+			expectedRequests: 1,
+			name:             "write not retried",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if atomic.AddInt32(&requests, 1) <= tc.inputFailures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer srv.Close()
+
+			client := &http.Client{Transport: &retryTransport{
+				base:    http.DefaultTransport,
+				timeout: time.Second,
+				retries: tc.inputRetries,
+				backoff: time.Millisecond,
+			}}
+
+			req, err := http.NewRequest(tc.inputMethod, srv.URL, nil)
+			assert.NoError(t, err)
+
+			resp, err := client.Do(req)
+			assert.NoError(t, err, tc.name)
+			assert.NoError(t, resp.Body.Close())
+			assert.Equal(t, tc.expectedCode, resp.StatusCode, tc.name)
+			assert.Equal(t, tc.expectedRequests, atomic.LoadInt32(&requests), tc.name)
+		})
+	}
+}
+
+func Test_retryTransport_timeout(t *testing.T) {
+	done := make(chan struct{})
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-done
+	}))
+
+	// Release the blocked handlers before closing the server, as Close waits
+	// for them to return.
+	defer func() {
+		close(done)
+		srv.Close()
+	}()
+
+	client := &http.Client{Transport: &retryTransport{
+		base:    http.DefaultTransport,
+		timeout: 20 * time.Millisecond,
+		retries: 1,
+		backoff: time.Millisecond,
+	}}
+
+	_, err := client.Get(srv.URL)
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func Test_requestTimeout(t *testing.T) {
+	testCases := []struct {
+		inputURL      string
+		inputTimeout  time.Duration
+		expectedValue time.Duration
+		name          string
+	}{
+		{
+			inputURL:      "http://127.0.0.1:4646/v1/scaling/policies",
+			inputTimeout:  0,
+			expectedValue: 0,
+			name:          "timeout disabled",
+		},
+		{
+			inputURL:      "http://127.0.0.1:4646/v1/scaling/policies",
+			inputTimeout:  30 * time.Second,
+			expectedValue: 30 * time.Second,
+			name:          "non-blocking query",
+		},
+		{
+			inputURL:      "http://127.0.0.1:4646/v1/scaling/policies?index=10&wait=160000ms",
+			inputTimeout:  30 * time.Second,
+			expectedValue: 30*time.Second + 160*time.Second + 10*time.Second,
+			name:          "blocking query with wait",
+		},
+		{
+			inputURL:      "http://127.0.0.1:4646/v1/scaling/policies?index=10",
+			inputTimeout:  30 * time.Second,
+			expectedValue: 30*time.Second + 5*time.Minute + 5*time.Minute/16,
+			name:          "blocking query with default wait",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.inputURL, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedValue, requestTimeout(req, tc.inputTimeout), tc.name)
+		})
+	}
+}
+
+func Test_retryBackoff(t *testing.T) {
+	testCases := []struct {
+		inputBackoff  time.Duration
+		inputAttempt  int
+		expectedValue time.Duration
+		name          string
+	}{
+		{
+			inputBackoff:  0,
+			inputAttempt:  3,
+			expectedValue: 0,
+			name:          "backoff disabled",
+		},
+		{
+			inputBackoff:  time.Second,
+			inputAttempt:  0,
+			expectedValue: time.Second,
+			name:          "first retry",
+		},
+		{
+			inputBackoff:  time.Second,
+			inputAttempt:  3,
+			expectedValue: 8 * time.Second,
+			name:          "fourth retry",
+		},
+		{
+			inputBackoff:  time.Second,
+			inputAttempt:  20,
+			expectedValue: 30 * time.Second,
+			name:          "capped",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedValue, retryBackoff(tc.inputBackoff, tc.inputAttempt), tc.name)
+		})
+	}
+}
@@ -132,4 +132,13 @@ func MergeMapWithAgentConfig(m map[string]string, agentCfg *config.Nomad) {
 	if agentCfg.HTTPAuth != "" && m[configKeyNomadHTTPAuth] == "" {
 		m[configKeyNomadHTTPAuth] = agentCfg.HTTPAuth
 	}
+	if agentCfg.RequestTimeout > 0 && m[configKeyNomadRequestTimeout] == "" {
+		m[configKeyNomadRequestTimeout] = agentCfg.RequestTimeout.String()
+	}
+	if agentCfg.Retries > 0 && m[configKeyNomadRetries] == "" {
+		m[configKeyNomadRetries] = strconv.Itoa(agentCfg.Retries)
+	}
+	if agentCfg.RetryBackoff > 0 && m[configKeyNomadRetryBackoff] == "" {
+		m[configKeyNomadRetryBackoff] = agentCfg.RetryBackoff.String()
+	}
 }
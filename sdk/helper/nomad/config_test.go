@@ -2,6 +2,7 @@ package nomad
 
 import (
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
 	"github.com/hashicorp/nomad/api"
@@ -89,17 +90,20 @@ func Test_MergeMapWithAgentConfig(t *testing.T) {
 		{
 			inputMap: map[string]string{},
 			inputAgentConfig: &config.Nomad{
-				Address:       "test",
-				Region:        "test",
-				Namespace:     "test",
-				Token:         "test",
-				HTTPAuth:      "test",
-				CACert:        "test",
-				CAPath:        "test",
-				ClientCert:    "test",
-				ClientKey:     "test",
-				TLSServerName: "test",
-				SkipVerify:    true,
+				Address:        "test",
+				Region:         "test",
+				Namespace:      "test",
+				Token:          "test",
+				HTTPAuth:       "test",
+				CACert:         "test",
+				CAPath:         "test",
+				ClientCert:     "test",
+				ClientKey:      "test",
+				TLSServerName:  "test",
+				SkipVerify:     true,
+				RequestTimeout: 30 * time.Second,
+				Retries:        2,
+				RetryBackoff:   time.Second,
 			},
 			expectedOutputMap: map[string]string{
 				"nomad_address":         "test",
@@ -113,6 +117,9 @@ func Test_MergeMapWithAgentConfig(t *testing.T) {
 				"nomad_client-key":      "test",
 				"nomad_tls-server-name": "test",
 				"nomad_skip-verify":     "true",
+				"nomad_request-timeout": "30s",
+				"nomad_retries":         "2",
+				"nomad_retry-backoff":   "1s",
 			},
 			name: "empty input map",
 		},
@@ -12,6 +12,10 @@ func Int64ToPtr(i int64) *int64 {
 	return &i
 }
 
+func Float64ToPtr(f float64) *float64 {
+	return &f
+}
+
 func StringToPtr(s string) *string {
 	return &s
 }
@@ -1,6 +1,10 @@
 package sdk
 
-import "time"
+import (
+	"math"
+	"strconv"
+	"time"
+)
 
 const (
 	ScalingPolicyTypeCluster    = "cluster"
@@ -16,10 +20,23 @@ type ScalingPolicy struct {
 	// the policy source this will be sourced in different manners.
 	ID string
 
+	// Revision identifies the version of the policy as read from its source,
+	// such as the git blob SHA of a policy file or the ModifyIndex of a Nomad
+	// policy. It is used to annotate the scaling performed by the policy and
+	// is empty if the source cannot provide one.
+	Revision string
+
 	// Type is the type of scaling this policy will perform.
 	Type string
 
 	// Priority controls the order in which a policy is picked for evaluation.
+	// When more evaluations are pending than there are workers available,
+	// the evaluations of policies with a higher priority are handed to the
+	// workers first, and evaluations of the same priority in the order they
+	// were created. Priority does not change how often a policy is evaluated,
+	// which is controlled by EvaluationInterval, so a high priority policy
+	// with a long interval is still evaluated less often than a low priority
+	// policy with a short interval.
 	Priority int
 
 	// Min forms a lower bound at which the target should never be asked to
@@ -32,6 +49,12 @@ type ScalingPolicy struct {
 	// this value is not violated.
 	Max int64
 
+	// MinOmitted and MaxOmitted indicate the policy source did not specify
+	// the Min or Max value, as opposed to setting it to zero. Agent default
+	// values are only applied in their place.
+	MinOmitted bool
+	MaxOmitted bool
+
 	// Enabled indicates whether the autoscaler should actively evaluate the
 	// policy or not.
 	Enabled bool
@@ -45,6 +68,36 @@ type ScalingPolicy struct {
 	// in a high rate of change in the target.
 	EvaluationInterval time.Duration
 
+	// MaxEvaluationInterval enables the adaptive evaluation interval, which
+	// reduces the load of policies which are stable. Once StableEvaluations
+	// evaluations in a row produced no scaling action, the interval doubles,
+	// and doubles again with each further stable evaluation, up to
+	// MaxEvaluationInterval. It
+	// returns to EvaluationInterval as soon as an evaluation produces an
+	// action. The adaptive interval is disabled unless MaxEvaluationInterval
+	// is greater than EvaluationInterval, and does not apply to Cron. A zero
+	// StableEvaluations uses the default of three.
+	MaxEvaluationInterval time.Duration
+	StableEvaluations     int64
+
+	// WarmupPeriod is the time period after the policy is first loaded,
+	// during which no policy evaluations will be started. This allows APM
+	// metrics to warm up after the agent or the target starts.
+	WarmupPeriod time.Duration
+
+	// VerifyScaleAfter enables post-scale verification. When set, the target
+	// count is read again once this duration has passed after a successful
+	// scaling action, to detect targets which accept the request but do not
+	// reach the requested count. A value of zero disables verification.
+	VerifyScaleAfter time.Duration
+
+	// RollbackOnVerifyFailure enables scaling the target back to its count
+	// before a scaling action when post-scale verification finds it did not
+	// reach the requested count, to avoid leaving it half-scaled. It has no
+	// effect unless VerifyScaleAfter is set, and should not be enabled for
+	// targets which converge more slowly than VerifyScaleAfter by design.
+	RollbackOnVerifyFailure bool
+
 	// Checks is an array of checks which will be triggered in parallel to
 	// determine the desired state of the ScalingPolicyTarget.
 	Checks []*ScalingPolicyCheck
@@ -52,8 +105,303 @@ type ScalingPolicy struct {
 	// Target identifies the scaling target which the autoscaler will interact
 	// with to ensure it meets the desired state as determined by the Checks.
 	Target *ScalingPolicyTarget
+
+	// ShadowTarget is an optional second target which receives the scaling
+	// actions applied to the Target, allowing a new target, or target plugin,
+	// to be validated alongside the real one. The shadow target honours its
+	// own dry-run config, so its actions can also be only registered. Its
+	// failures are logged, but never affect the scaling of the Target.
+	ShadowTarget *ScalingPolicyTarget
+
+	// Labels are arbitrary key/value pairs which operators can attach to a
+	// policy, such as the owning team or service. They are added to the log
+	// context of the policy evaluation, with keys prefixed by "label.", and a
+	// subset is used as metric labels.
+	Labels map[string]string
+
+	// ReconcileOnStart indicates whether the first evaluation of the policy
+	// should immediately bring the target count within the Min and Max
+	// bounds, before any of the Checks are run.
+	ReconcileOnStart bool
+
+	// MaxScaleStep limits the absolute change in count performed by a single
+	// scaling action. A value of zero means no limit.
+	MaxScaleStep int64
+
+	// MaxScalePercent limits the change in count performed by a single
+	// scaling action to a percentage of the current count, rounded using the
+	// Rounding mode but never less than one. A value of zero means no limit. If both
+	// MaxScaleStep and MaxScalePercent are set, the most restrictive limit
+	// is used. The limits are applied before the Min and Max bounds, so the
+	// bounds are always honoured.
+	MaxScalePercent float64
+
+	// Rounding is the RoundingMode applied wherever a count is calculated
+	// from a fractional value, such as by the target-value strategy or the
+	// MaxScalePercent limit. It is either RoundingModeCeil, RoundingModeFloor
+	// or RoundingModeRound. When unset, strategies round counts up and the
+	// MaxScalePercent limit is rounded down.
+	Rounding string
+
+	// FallbackStrategy is the behaviour of a check whose strategy plugin is
+	// not available. It is either FallbackStrategyHold or
+	// FallbackStrategyBounds. When unset the check fails to evaluate.
+	FallbackStrategy string
+
+	// OutOfBoundsAction is the behaviour of an evaluation which finds the
+	// target count already outside of the Min and Max bounds, such as after
+	// manual scaling. It is either OutOfBoundsActionCorrect,
+	// OutOfBoundsActionStrategy or OutOfBoundsActionWait. When unset the
+	// strategy decides, and the count is brought within the bounds if the
+	// strategy recommends no change. Policies with equal Min and Max always
+	// scale the target to the pinned count.
+	OutOfBoundsAction string
+
+	// StabilizeCount requires the target count to be the same across two
+	// reads, StabilizeCountDelay apart, before a check acts upon it. This
+	// avoids scaling on transient counts reported by targets during a
+	// rollout. If the count changes between the reads, the evaluation is
+	// skipped.
+	StabilizeCount      bool
+	StabilizeCountDelay time.Duration
+
+	// ScaleDownStabilizationWindow delays scale downs until they are
+	// warranted throughout the window. The target is only scaled down to the
+	// highest count recommended by the evaluations within the trailing
+	// window, while scale ups are performed immediately. Zero disables it.
+	ScaleDownStabilizationWindow time.Duration
+
+	// MinHealthyPercentage holds scale downs unless at least this percentage
+	// of the current count of the target is healthy, as removing instances
+	// from a struggling target could tip it over. It relies on the target
+	// reporting its healthy count. Zero disables it.
+	MinHealthyPercentage float64
+
+	// MaxConsecutiveScaleDowns limits the number of scale downs the policy
+	// checks can perform in a row, protecting the target from a cascade of
+	// scale downs caused by a misbehaving metric. Once reached, scale downs
+	// are suppressed until the target is scaled up, the limit is
+	// acknowledged through the API, or ConsecutiveScaleDownsReset has passed
+	// since the last scale down. Zero means no limit.
+	MaxConsecutiveScaleDowns   int64
+	ConsecutiveScaleDownsReset time.Duration
+
+	// ScaleDownStep limits each scale down performed by the policy checks to
+	// at most this many instances, and ScaleDownStepDelay is the time the
+	// policy waits after a scale down before stepping down again. The target
+	// is therefore scaled down gradually, continuing only while the checks
+	// still warrant it, while scale ups are performed immediately. Zero
+	// disables either.
+	ScaleDownStep      int64
+	ScaleDownStepDelay time.Duration
+
+	// ScaleUpProbation is the time after a scale up performed by the policy
+	// checks during which further scale ups are suppressed, giving the new
+	// capacity time to take effect before it shows in the metrics. Scale
+	// downs are still allowed. Zero disables the probation.
+	ScaleUpProbation time.Duration
+
+	// DependsOn lists the IDs of the policies this policy depends upon, such
+	// as those of upstream services. The policy is not evaluated while any
+	// of them is scaling its target, or has scaled it within
+	// DependsOnWindow. When DependsOnWindow is zero, the cooldown of each
+	// dependency is used instead.
+	DependsOn       []string
+	DependsOnWindow time.Duration
+
+	// DeferDuringDeployment skips scaling while the target reports a
+	// deployment in progress, as scaling could conflict with it. Scaling
+	// resumes once the deployment has completed. It relies on the target
+	// reporting its deployment status.
+	DeferDuringDeployment bool
+
+	// Advisory marks the policy as only recommending scaling actions. The
+	// policy is evaluated as normal and its recommendations are published
+	// through the logs, metrics and API of the agent, but the target is never
+	// scaled, leaving operators or another system to act upon them.
+	Advisory bool
+
+	// MetricMin and MetricMax define the range of acceptable values returned
+	// by the APMs of the policy checks. The evaluation of a check is skipped,
+	// holding the current count, if any of its metrics falls outside the
+	// range or is not a finite number. A nil limit leaves that side of the
+	// range unbounded.
+	MetricMin *float64
+	MetricMax *float64
+
+	// MetricUnit is the unit of the values returned by the APMs of the
+	// policy checks, used to format them within the logs and events of the
+	// agent. It is either MetricUnitBytes, MetricUnitPercent or
+	// MetricUnitCount. When unset the raw value is used.
+	MetricUnit string
+
+	// Cron is an optional cron expression which schedules the evaluations
+	// of the policy in place of the EvaluationInterval, suiting workloads
+	// with predictable patterns. CronTimeZone is the IANA time zone the
+	// expression is evaluated in, and defaults to UTC.
+	Cron         string
+	CronTimeZone string
+
+	// ScheduledMins are soft minimum counts which apply during the windows
+	// of their schedules, such as peak hours, without changing the hard
+	// floor set by Min. While any are active, the effective minimum used to
+	// evaluate the policy is the highest of Min and the active scheduled
+	// minimums, capped at Max. A scheduled minimum below Min has no effect.
+	ScheduledMins []*ScalingPolicyScheduledMin
+}
+
+// ScalingPolicyScheduledMin is a soft minimum count of a ScalingPolicy which
+// applies during the windows of a schedule. Each window starts at a time
+// matching the Cron expression, evaluated in TimeZone which defaults to UTC,
+// and lasts for Duration.
+type ScalingPolicyScheduledMin struct {
+	Name        string `hcl:"name,label"`
+	Cron        string `hcl:"cron"`
+	TimeZone    string `hcl:"time_zone,optional"`
+	Duration    time.Duration
+	DurationHCL string `hcl:"duration" json:"-"`
+	Min         int64  `hcl:"min"`
 }
 
+const (
+	// FallbackStrategyHold holds the current count of the target.
+	FallbackStrategyHold = "hold"
+
+	// FallbackStrategyBounds only brings the count of the target within the
+	// policy Min and Max bounds.
+	FallbackStrategyBounds = "bounds"
+)
+
+const (
+	// OutOfBoundsActionCorrect immediately scales the target to the nearest
+	// bound, without running the checks.
+	OutOfBoundsActionCorrect = "correct"
+
+	// OutOfBoundsActionStrategy lets the strategy decide, with its action
+	// capped to the bounds. The count is held if the strategy recommends no
+	// change, or a change away from the bounds.
+	OutOfBoundsActionStrategy = "strategy"
+
+	// OutOfBoundsActionWait does not scale the target until its count is
+	// brought back within the bounds by other means.
+	OutOfBoundsActionWait = "wait"
+)
+
+const (
+	// RoundingModeCeil rounds fractional counts up, so targets are never
+	// under-provisioned.
+	RoundingModeCeil = "ceil"
+
+	// RoundingModeFloor rounds fractional counts down.
+	RoundingModeFloor = "floor"
+
+	// RoundingModeRound rounds fractional counts to the nearest whole count,
+	// rounding half away from zero.
+	RoundingModeRound = "round"
+)
+
+// RoundCount converts the fractional count to a whole count using the
+// rounding mode, or using def if the mode is unset.
+func RoundCount(count float64, mode, def string) int64 {
+	if mode == "" {
+		mode = def
+	}
+
+	switch mode {
+	case RoundingModeFloor:
+		return int64(math.Floor(count))
+	case RoundingModeRound:
+		return int64(math.Round(count))
+	default:
+		return int64(math.Ceil(count))
+	}
+}
+
+const (
+	// MetricUnitBytes formats values using binary multiples of bytes, such
+	// as "512 MiB".
+	MetricUnitBytes = "bytes"
+
+	// MetricUnitPercent formats values as a percentage, such as "75.5%".
+	MetricUnitPercent = "percent"
+
+	// MetricUnitCount formats values as a plain number with at most two
+	// decimals.
+	MetricUnitCount = "count"
+)
+
+// byteUnits are the units used to format values in bytes, each 1024 times
+// the previous one.
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// FormatMetricValue formats the APM value using the metric unit, for use
+// within logs and events. Values without a unit, or which are not finite
+// numbers, use the raw numeric formatting.
+func FormatMetricValue(value float64, unit string) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		unit = ""
+	}
+
+	switch unit {
+	case MetricUnitBytes:
+		i := 0
+		for math.Abs(value) >= 1024 && i < len(byteUnits)-1 {
+			value /= 1024
+			i++
+		}
+		return formatDecimal(value) + " " + byteUnits[i]
+	case MetricUnitPercent:
+		return formatDecimal(value) + "%"
+	case MetricUnitCount:
+		return formatDecimal(value)
+	default:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+}
+
+// formatDecimal formats the value rounded to at most two decimals, without
+// trailing zeros.
+func formatDecimal(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// Template variables which can be referenced as ${name} within the policy
+// Target.Config values and check queries, allowing a single policy to be
+// reused across targets. Referencing one of these variables when it is not
+// available to the policy is an error, while any other ${name} reference is
+// left untouched so it can still be consumed by the plugin. Within HCL policy
+// files "${" starts an HCL template, so references must be escaped with an
+// extra dollar sign, such as $${job}. JSON policy files do not need the
+// escape.
+const (
+	// TemplateVarPolicyID is the ID of the policy.
+	TemplateVarPolicyID = "policy_id"
+
+	// TemplateVarNamespace, TemplateVarJob and TemplateVarGroup are the
+	// Namespace, Job and Group values of the policy Target.Config.
+	TemplateVarNamespace = "namespace"
+	TemplateVarJob       = "job"
+	TemplateVarGroup     = "group"
+
+	// TemplateVarLabelPrefix prefixes the policy labels, so the label "team"
+	// is referenced as ${label.team}.
+	TemplateVarLabelPrefix = "label."
+
+	// TemplateVarCurrentCount is the current count of the policy target,
+	// allowing queries to calculate values such as the load per instance. It
+	// is only available within check queries.
+	TemplateVarCurrentCount = "current_count"
+)
+
+// QueryVarCurrentCount is substituted within a check Query with the current
+// count of the policy target. It is the reference form of
+// TemplateVarCurrentCount.
+const QueryVarCurrentCount = "${" + TemplateVarCurrentCount + "}"
+
+// QueryTemplateVars lists the template variables which can be referenced
+// within a check Query in addition to the policy template variables.
+var QueryTemplateVars = []string{QueryVarCurrentCount}
+
 // ScalingPolicyCheck is an individual check within a scaling policy.This check
 // will be executed in isolation alongside other checks within the policy.
 type ScalingPolicyCheck struct {
@@ -66,7 +414,16 @@ type ScalingPolicyCheck struct {
 	// obtain the metric that will be used to perform a calculation.
 	Source string
 
+	// FailoverSources are APM plugins which are queried in order, using the
+	// same Query, when querying the Source fails. The check only fails once
+	// all sources failed.
+	FailoverSources []string
+
 	// Query is run against the Source in order to receive a metric response.
+	// The query may reference the policy template variables, which are
+	// substituted before it is run. Within HCL policy files "${" starts an
+	// HCL template, so references must be escaped with an extra dollar sign,
+	// such as $${current_count}. JSON policy files do not need the escape.
 	Query string
 
 	// QueryWindow is used to define how further back in time to query for
@@ -76,6 +433,38 @@ type ScalingPolicyCheck struct {
 	// Strategy is the ScalingPolicyStrategy to use when performing the
 	// ScalingPolicyCheck evaluation.
 	Strategy *ScalingPolicyStrategy
+
+	// Transforms are applied in order to the metrics returned by the Source
+	// before they are passed to the Strategy, allowing values to be
+	// converted, clamped or smoothed. Without transforms the metrics are
+	// passed unchanged.
+	Transforms []*ScalingPolicyTransform
+
+	// TargetPercentage scales the target to a percentage of the metric
+	// returned by the Source, such as a fleet size reported by another
+	// metric, rather than using the metric to measure the load of the target.
+	// For example, 20 runs one instance for every five units of the metric.
+	//
+	// The required count is derived from the transformed metrics, rounded
+	// using the policy rounding mode, up by default, and passed to the
+	// Strategy as the metric, so the Strategy must treat the metric as the
+	// count the target requires, such as the target-value strategy with
+	// items_per_instance set to 1. If the metric cannot be queried the check
+	// fails as any other, and without metrics the current count is held.
+	// Zero disables it.
+	TargetPercentage float64
+}
+
+// ScalingPolicyTransform is a single step of the transform pipeline applied
+// to the metrics of a ScalingPolicyCheck.
+type ScalingPolicyTransform struct {
+
+	// Name is the transform to apply, such as scale, offset, clamp,
+	// moving_average, ewma or rate.
+	Name string `hcl:"name,label"`
+
+	// Config is the mapping of config values used by the transform.
+	Config map[string]string `hcl:",remain"`
 }
 
 // ScalingPolicyStrategy contains the plugin and configuration details for
@@ -100,7 +489,10 @@ type ScalingPolicyTarget struct {
 	Name string `hcl:"name,label"`
 
 	// Config is the mapping of config values used by the target plugin. Each
-	// plugin has a set of potentially uniquely supported keys.
+	// plugin has a set of potentially uniquely supported keys. Values may
+	// reference the policy template variables, which are substituted before
+	// the target is called. As with queries, references within HCL policy
+	// files must be escaped as $${name}.
 	Config map[string]string `hcl:",remain"`
 }
 
@@ -126,39 +518,125 @@ func (t *ScalingPolicyTarget) IsNodePoolTarget() bool {
 type FileDecodeScalingPolicy struct {
 	Enabled bool                 `hcl:"enabled,optional"`
 	Type    string               `hcl:"type,optional"`
-	Min     int64                `hcl:"min,optional"`
-	Max     int64                `hcl:"max"`
+	Min     *int64               `hcl:"min,optional"`
+	Max     *int64               `hcl:"max,optional"`
 	Doc     *FileDecodePolicyDoc `hcl:"policy,block"`
 }
 
 type FileDecodePolicyDoc struct {
-	Cooldown              time.Duration
-	CooldownHCL           string `hcl:"cooldown,optional"`
-	EvaluationInterval    time.Duration
-	EvaluationIntervalHCL string                      `hcl:"evaluation_interval,optional"`
-	Checks                []*FileDecodePolicyCheckDoc `hcl:"check,block"`
-	Target                *ScalingPolicyTarget        `hcl:"target,block"`
+	Cooldown                        time.Duration
+	CooldownHCL                     string `hcl:"cooldown,optional"`
+	EvaluationInterval              time.Duration
+	EvaluationIntervalHCL           string `hcl:"evaluation_interval,optional"`
+	MaxEvaluationInterval           time.Duration
+	MaxEvaluationIntervalHCL        string `hcl:"max_evaluation_interval,optional"`
+	StableEvaluations               int64  `hcl:"stable_evaluations,optional"`
+	WarmupPeriod                    time.Duration
+	WarmupPeriodHCL                 string `hcl:"warmup_period,optional"`
+	VerifyScaleAfter                time.Duration
+	VerifyScaleAfterHCL             string            `hcl:"verify_scale_after,optional"`
+	RollbackOnVerifyFailure         bool              `hcl:"rollback_on_verify_failure,optional"`
+	Labels                          map[string]string `hcl:"labels,optional"`
+	ReconcileOnStart                bool              `hcl:"reconcile_on_start,optional"`
+	MaxScaleStep                    int64             `hcl:"max_scale_step,optional"`
+	MaxScalePercent                 float64           `hcl:"max_scale_percent,optional"`
+	Rounding                        string            `hcl:"rounding,optional"`
+	Priority                        int               `hcl:"priority,optional"`
+	FallbackStrategy                string            `hcl:"fallback_strategy,optional"`
+	OutOfBoundsAction               string            `hcl:"out_of_bounds_action,optional"`
+	StabilizeCount                  bool              `hcl:"stabilize_count,optional"`
+	StabilizeCountDelay             time.Duration
+	StabilizeCountDelayHCL          string `hcl:"stabilize_count_delay,optional"`
+	ScaleDownStabilizationWindow    time.Duration
+	ScaleDownStabilizationWindowHCL string  `hcl:"scale_down_stabilization_window,optional"`
+	MinHealthyPercentage            float64 `hcl:"min_healthy_percentage,optional"`
+	MaxConsecutiveScaleDowns        int64   `hcl:"max_consecutive_scale_downs,optional"`
+	ConsecutiveScaleDownsReset      time.Duration
+	ConsecutiveScaleDownsResetHCL   string `hcl:"consecutive_scale_downs_reset,optional"`
+	ScaleDownStep                   int64  `hcl:"scale_down_step,optional"`
+	ScaleDownStepDelay              time.Duration
+	ScaleDownStepDelayHCL           string `hcl:"scale_down_step_delay,optional"`
+	ScaleUpProbation                time.Duration
+	ScaleUpProbationHCL             string   `hcl:"scale_up_probation,optional"`
+	DependsOn                       []string `hcl:"depends_on,optional"`
+	DependsOnWindow                 time.Duration
+	DependsOnWindowHCL              string                       `hcl:"depends_on_window,optional"`
+	Cron                            string                       `hcl:"cron,optional"`
+	CronTimeZone                    string                       `hcl:"time_zone,optional"`
+	DeferDuringDeployment           bool                         `hcl:"defer_during_deployment,optional"`
+	Advisory                        bool                         `hcl:"advisory,optional"`
+	MetricMin                       *float64                     `hcl:"metric_min,optional"`
+	MetricMax                       *float64                     `hcl:"metric_max,optional"`
+	MetricUnit                      string                       `hcl:"metric_unit,optional"`
+	Checks                          []*FileDecodePolicyCheckDoc  `hcl:"check,block"`
+	Target                          *ScalingPolicyTarget         `hcl:"target,block"`
+	ShadowTarget                    *ScalingPolicyTarget         `hcl:"shadow_target,block"`
+	ScheduledMins                   []*ScalingPolicyScheduledMin `hcl:"scheduled_min,block"`
 }
 
 type FileDecodePolicyCheckDoc struct {
-	Name           string `hcl:"name,label"`
-	Source         string `hcl:"source,optional"`
-	Query          string `hcl:"query"`
-	QueryWindow    time.Duration
-	QueryWindowHCL string                 `hcl:"query_window,optional"`
-	Strategy       *ScalingPolicyStrategy `hcl:"strategy,block"`
+	Name             string   `hcl:"name,label"`
+	Source           string   `hcl:"source,optional"`
+	FailoverSources  []string `hcl:"failover_sources,optional"`
+	Query            string   `hcl:"query"`
+	QueryWindow      time.Duration
+	QueryWindowHCL   string                    `hcl:"query_window,optional"`
+	Strategy         *ScalingPolicyStrategy    `hcl:"strategy,block"`
+	Transforms       []*ScalingPolicyTransform `hcl:"transform,block"`
+	TargetPercentage float64                   `hcl:"target_percentage,optional"`
 }
 
 // Translate all values from the decoded policy file into our internal policy
 // object.
 func (fpd *FileDecodeScalingPolicy) Translate(p *ScalingPolicy) {
-	p.Min = fpd.Min
-	p.Max = fpd.Max
+	if fpd.Min != nil {
+		p.Min = *fpd.Min
+	} else {
+		p.MinOmitted = true
+	}
+	if fpd.Max != nil {
+		p.Max = *fpd.Max
+	} else {
+		p.MaxOmitted = true
+	}
 	p.Enabled = fpd.Enabled
 	p.Type = fpd.Type
 	p.Cooldown = fpd.Doc.Cooldown
 	p.EvaluationInterval = fpd.Doc.EvaluationInterval
+	p.MaxEvaluationInterval = fpd.Doc.MaxEvaluationInterval
+	p.StableEvaluations = fpd.Doc.StableEvaluations
+	p.WarmupPeriod = fpd.Doc.WarmupPeriod
+	p.VerifyScaleAfter = fpd.Doc.VerifyScaleAfter
+	p.RollbackOnVerifyFailure = fpd.Doc.RollbackOnVerifyFailure
 	p.Target = fpd.Doc.Target
+	p.ShadowTarget = fpd.Doc.ShadowTarget
+	p.Labels = fpd.Doc.Labels
+	p.ReconcileOnStart = fpd.Doc.ReconcileOnStart
+	p.MaxScaleStep = fpd.Doc.MaxScaleStep
+	p.MaxScalePercent = fpd.Doc.MaxScalePercent
+	p.Rounding = fpd.Doc.Rounding
+	p.FallbackStrategy = fpd.Doc.FallbackStrategy
+	p.OutOfBoundsAction = fpd.Doc.OutOfBoundsAction
+	p.Priority = fpd.Doc.Priority
+	p.StabilizeCount = fpd.Doc.StabilizeCount
+	p.StabilizeCountDelay = fpd.Doc.StabilizeCountDelay
+	p.ScaleDownStabilizationWindow = fpd.Doc.ScaleDownStabilizationWindow
+	p.MinHealthyPercentage = fpd.Doc.MinHealthyPercentage
+	p.MaxConsecutiveScaleDowns = fpd.Doc.MaxConsecutiveScaleDowns
+	p.ConsecutiveScaleDownsReset = fpd.Doc.ConsecutiveScaleDownsReset
+	p.ScaleDownStep = fpd.Doc.ScaleDownStep
+	p.ScaleDownStepDelay = fpd.Doc.ScaleDownStepDelay
+	p.ScaleUpProbation = fpd.Doc.ScaleUpProbation
+	p.DependsOn = fpd.Doc.DependsOn
+	p.DependsOnWindow = fpd.Doc.DependsOnWindow
+	p.Cron = fpd.Doc.Cron
+	p.CronTimeZone = fpd.Doc.CronTimeZone
+	p.DeferDuringDeployment = fpd.Doc.DeferDuringDeployment
+	p.Advisory = fpd.Doc.Advisory
+	p.MetricMin = fpd.Doc.MetricMin
+	p.MetricMax = fpd.Doc.MetricMax
+	p.MetricUnit = fpd.Doc.MetricUnit
+	p.ScheduledMins = fpd.Doc.ScheduledMins
 
 	fpd.translateChecks(p)
 }
@@ -179,7 +657,10 @@ func (fpd *FileDecodeScalingPolicy) translateChecks(p *ScalingPolicy) {
 func (fdc *FileDecodePolicyCheckDoc) Translate(c *ScalingPolicyCheck) {
 	c.Name = fdc.Name
 	c.Source = fdc.Source
+	c.FailoverSources = fdc.FailoverSources
 	c.Query = fdc.Query
 	c.QueryWindow = fdc.QueryWindow
 	c.Strategy = fdc.Strategy
+	c.Transforms = fdc.Transforms
+	c.TargetPercentage = fdc.TargetPercentage
 }
@@ -1,9 +1,11 @@
 package sdk
 
 import (
+	"math"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad-autoscaler/sdk/helper/ptr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,8 +19,8 @@ func TestFileDecodePolicy_Translate(t *testing.T) {
 		{
 			inputFileDecodePolicy: &FileDecodeScalingPolicy{
 				Enabled: true,
-				Min:     1,
-				Max:     3,
+				Min:     ptr.Int64ToPtr(1),
+				Max:     ptr.Int64ToPtr(3),
 				Doc: &FileDecodePolicyDoc{
 					Cooldown:              10 * time.Millisecond,
 					CooldownHCL:           "10ms",
@@ -78,6 +80,32 @@ func TestFileDecodePolicy_Translate(t *testing.T) {
 			},
 			name: "fully hydrated decoded policy",
 		},
+		{
+			inputFileDecodePolicy: &FileDecodeScalingPolicy{
+				Enabled: true,
+				Doc:     &FileDecodePolicyDoc{},
+			},
+			inputPolicy: &ScalingPolicy{},
+			expectedOutputPolicy: &ScalingPolicy{
+				MinOmitted: true,
+				MaxOmitted: true,
+				Enabled:    true,
+			},
+			name: "omitted min and max",
+		},
+		{
+			inputFileDecodePolicy: &FileDecodeScalingPolicy{
+				Enabled: true,
+				Min:     ptr.Int64ToPtr(0),
+				Max:     ptr.Int64ToPtr(0),
+				Doc:     &FileDecodePolicyDoc{},
+			},
+			inputPolicy: &ScalingPolicy{},
+			expectedOutputPolicy: &ScalingPolicy{
+				Enabled: true,
+			},
+			name: "explicit zero min and max",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -87,3 +115,133 @@ func TestFileDecodePolicy_Translate(t *testing.T) {
 		})
 	}
 }
+
+func TestRoundCount(t *testing.T) {
+	testCases := []struct {
+		inputCount    float64
+		inputMode     string
+		inputDefault  string
+		expectedCount int64
+		name          string
+	}{
+		{
+			inputCount:    2.4,
+			inputMode:     RoundingModeCeil,
+			inputDefault:  RoundingModeFloor,
+			expectedCount: 3,
+			name:          "ceil",
+		},
+		{
+			inputCount:    2.6,
+			inputMode:     RoundingModeFloor,
+			inputDefault:  RoundingModeCeil,
+			expectedCount: 2,
+			name:          "floor",
+		},
+		{
+			inputCount:    2.4,
+			inputMode:     RoundingModeRound,
+			inputDefault:  RoundingModeCeil,
+			expectedCount: 2,
+			name:          "round down",
+		},
+		{
+			inputCount:    2.5,
+			inputMode:     RoundingModeRound,
+			inputDefault:  RoundingModeFloor,
+			expectedCount: 3,
+			name:          "round half up",
+		},
+		{
+			inputCount:    2.4,
+			inputMode:     "",
+			inputDefault:  RoundingModeCeil,
+			expectedCount: 3,
+			name:          "unset mode uses default",
+		},
+		{
+			inputCount:    3,
+			inputMode:     RoundingModeCeil,
+			inputDefault:  RoundingModeCeil,
+			expectedCount: 3,
+			name:          "whole count unchanged",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := RoundCount(tc.inputCount, tc.inputMode, tc.inputDefault)
+			assert.Equal(t, tc.expectedCount, actual, tc.name)
+		})
+	}
+}
+
+func TestFormatMetricValue(t *testing.T) {
+	testCases := []struct {
+		inputValue    float64
+		inputUnit     string
+		expectedValue string
+		name          string
+	}{
+		{
+			inputValue:    536870912,
+			inputUnit:     "",
+			expectedValue: "536870912",
+			name:          "raw",
+		},
+		{
+			inputValue:    0.123456,
+			inputUnit:     "",
+			expectedValue: "0.123456",
+			name:          "raw fraction",
+		},
+		{
+			inputValue:    536870912,
+			inputUnit:     MetricUnitBytes,
+			expectedValue: "512 MiB",
+			name:          "bytes",
+		},
+		{
+			inputValue:    512,
+			inputUnit:     MetricUnitBytes,
+			expectedValue: "512 B",
+			name:          "bytes below a kibibyte",
+		},
+		{
+			inputValue:    1610612736,
+			inputUnit:     MetricUnitBytes,
+			expectedValue: "1.5 GiB",
+			name:          "fractional bytes",
+		},
+		{
+			inputValue:    75.456,
+			inputUnit:     MetricUnitPercent,
+			expectedValue: "75.46%",
+			name:          "percent",
+		},
+		{
+			inputValue:    12,
+			inputUnit:     MetricUnitCount,
+			expectedValue: "12",
+			name:          "count",
+		},
+		{
+			inputValue:    math.Inf(1),
+			inputUnit:     MetricUnitBytes,
+			expectedValue: "+Inf",
+			name:          "not a finite number",
+		},
+		{
+			inputValue:    42,
+			inputUnit:     "unknown",
+			expectedValue: "42",
+			name:          "unknown unit",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedValue, FormatMetricValue(tc.inputValue, tc.inputUnit), tc.name)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package sdk
+
+// ReasonCode is a machine readable identifier of the cause of a scaling
+// action. Unlike the free text ScalingAction.Reason, the set of codes is
+// small and stable, so it is suitable for use as a metric label.
+type ReasonCode string
+
+// The reason codes assigned to scaling actions.
+const (
+	// ReasonCodeStrategy is the cause of actions produced by a strategy
+	// which does not set a more specific code.
+	ReasonCodeStrategy ReasonCode = "strategy"
+
+	// ReasonCodeMetricAboveTarget and ReasonCodeMetricBelowTarget are the
+	// causes of actions produced by strategies which scale to bring a metric
+	// back to its target value, such as high or low utilization.
+	ReasonCodeMetricAboveTarget ReasonCode = "metric_above_target"
+	ReasonCodeMetricBelowTarget ReasonCode = "metric_below_target"
+
+	// ReasonCodeBounds is the cause of actions bringing the target count
+	// within the policy min and max during an evaluation.
+	ReasonCodeBounds ReasonCode = "bounds"
+
+	// ReasonCodeReconcileBounds is the cause of actions bringing the target
+	// count within the policy min and max when the policy is first loaded,
+	// as requested by its reconcile_on_start option.
+	ReasonCodeReconcileBounds ReasonCode = "reconcile_bounds"
+
+	// ReasonCodeOverride is the cause of actions applying an operator
+	// override of the policy count.
+	ReasonCodeOverride ReasonCode = "override"
+
+	// ReasonCodePinned is the cause of actions restoring the count pinned by
+	// a policy with equal min and max.
+	ReasonCodePinned ReasonCode = "pinned"
+
+	// ReasonCodeRollback is the cause of actions restoring the count of a
+	// target which did not reach the count of the previous scaling action,
+	// as requested by the policy rollback_on_verify_failure option.
+	ReasonCodeRollback ReasonCode = "rollback"
+)
@@ -11,12 +11,25 @@ const (
 	strategyActionMetaKeyCountCapped   = "nomad_autoscaler.count.capped"
 	strategyActionMetaKeyCountOriginal = "nomad_autoscaler.count.original"
 	strategyActionMetaKeyReasonHistory = "nomad_autoscaler.reason_history"
+	strategyActionMetaKeyReasonCode    = "nomad_autoscaler.reason_code"
+	strategyActionMetaKeyRevision      = "nomad_autoscaler.policy_revision"
 
 	// StrategyActionMetaValueDryRunCount is a special count value used when
 	// performing dry-run scaling activities. The Autoscaler will never set a
 	// count to a negative value during normal operation, so the agent is safe
 	// to assume a count set to this value implies dry-run.
 	StrategyActionMetaValueDryRunCount = -1
+
+	// StrategyActionMetaKeyCurrentCount is the meta key holding the count of
+	// the target the action was calculated from. Together with the action
+	// count and direction, it allows target plugins to distribute the change
+	// across the target, such as when it is spread across datacenters.
+	StrategyActionMetaKeyCurrentCount = "nomad_autoscaler.count.current"
+
+	// StrategyActionMetaKeyMetricValue is the meta key holding the APM value
+	// the action was calculated from, formatted using the metric unit of the
+	// policy.
+	StrategyActionMetaKeyMetricValue = "nomad_autoscaler.metric_value"
 )
 
 // ScalingAction represents a strategy plugins intention to change the current
@@ -33,6 +46,12 @@ type ScalingAction struct {
 	// of why the strategy decided the action was required.
 	Reason string
 
+	// ReasonCode is the machine readable cause of the action, allowing
+	// scaling actions to be aggregated by cause. Strategies should set it
+	// alongside Reason. Actions without a code are assigned
+	// ReasonCodeStrategy by the agent.
+	ReasonCode ReasonCode
+
 	// Error indicates whether the Reason string is an error condition. This
 	// allows the Reason to be flexible in its use.
 	Error bool
@@ -47,6 +66,23 @@ type ScalingAction struct {
 	Meta map[string]interface{}
 }
 
+// StrategyStatus is used by strategy plugins to describe the outcome of their
+// calculation. It allows a strategy to distinguish between not having enough
+// data to make a decision and deciding that no change is required.
+type StrategyStatus string
+
+const (
+	// StrategyStatusOK indicates the strategy was able to calculate the
+	// desired state and the Action should be used. This is the zero value, so
+	// strategies which do not set a status keep the existing behaviour.
+	StrategyStatusOK StrategyStatus = ""
+
+	// StrategyStatusNoData indicates the strategy did not have sufficient data
+	// to calculate the desired state. The Autoscaler will not scale the target
+	// and holds its current count.
+	StrategyStatusNoData StrategyStatus = "no_data"
+)
+
 // ScaleDirection is an identifier used by strategy plugins to identify how the
 // target should scale the named resource.
 type ScaleDirection int8
@@ -118,6 +154,74 @@ func (a *ScalingAction) CapCount(min, max int64) {
 	}
 }
 
+// CapCapacity limits a scale up action to the capacity the target is able to
+// reach. The count is never lowered below the current count or min, so the
+// capacity can hold a scale up back but never turn it into a scale down. The
+// direction is updated to match the new count. The returned bool indicates
+// whether the count was capped.
+func (a *ScalingAction) CapCapacity(current, min, capacity int64) bool {
+	if a.Count == StrategyActionMetaValueDryRunCount || a.Direction != ScaleDirectionUp || a.Count <= capacity {
+		return false
+	}
+
+	newCount := capacity
+	if newCount < current {
+		newCount = current
+	}
+	if newCount < min {
+		newCount = min
+	}
+	if newCount >= a.Count {
+		return false
+	}
+
+	// Keep the original count if it was already capped to the limits.
+	if _, ok := a.Meta[strategyActionMetaKeyCountOriginal]; !ok {
+		a.Meta[strategyActionMetaKeyCountOriginal] = a.Count
+	}
+	a.Meta[strategyActionMetaKeyCountCapped] = true
+	a.pushReason(fmt.Sprintf("capped count from %d to %d to stay within target capacity", a.Count, newCount))
+	a.Count = newCount
+
+	if newCount == current {
+		a.Direction = ScaleDirectionNone
+	}
+	return true
+}
+
+// SetReasonCodeMeta defaults an empty ReasonCode to ReasonCodeStrategy and
+// copies it into Meta, so it is recorded by targets which store the Meta,
+// such as within Nomad scaling events.
+func (a *ScalingAction) SetReasonCodeMeta() {
+	if a.ReasonCode == "" {
+		a.ReasonCode = ReasonCodeStrategy
+	}
+	a.Meta[strategyActionMetaKeyReasonCode] = string(a.ReasonCode)
+}
+
+// SetPolicyRevisionMeta copies the revision of the policy which produced the
+// action into Meta, so it is recorded by targets which store the Meta. An
+// empty revision is not recorded.
+func (a *ScalingAction) SetPolicyRevisionMeta(revision string) {
+	if revision == "" {
+		return
+	}
+	a.Meta[strategyActionMetaKeyRevision] = revision
+}
+
+// SetCurrentCountMeta copies the count of the target the action was calculated
+// from into Meta, so it is available to the target plugin and recorded by
+// targets which store the Meta.
+func (a *ScalingAction) SetCurrentCountMeta(count int64) {
+	a.Meta[StrategyActionMetaKeyCurrentCount] = count
+}
+
+// SetMetricValueMeta records the formatted APM value the action was
+// calculated from.
+func (a *ScalingAction) SetMetricValueMeta(value string) {
+	a.Meta[StrategyActionMetaKeyMetricValue] = value
+}
+
 // PushReason updates the Reason value and stores previous Reason into Meta.
 func (a *ScalingAction) pushReason(r string) {
 	history := []string{}
@@ -80,6 +80,106 @@ func TestAction_SetDryRun(t *testing.T) {
 	}
 }
 
+func TestAction_SetReasonCodeMeta(t *testing.T) {
+	testCases := []struct {
+		inputAction          *ScalingAction
+		expectedOutputAction *ScalingAction
+		name                 string
+	}{
+		{
+			inputAction: &ScalingAction{
+				ReasonCode: ReasonCodeOverride,
+				Meta:       map[string]interface{}{},
+			},
+			expectedOutputAction: &ScalingAction{
+				ReasonCode: ReasonCodeOverride,
+				Meta: map[string]interface{}{
+					"nomad_autoscaler.reason_code": "override",
+				},
+			},
+			name: "reason code set",
+		},
+		{
+			inputAction: &ScalingAction{
+				Meta: map[string]interface{}{},
+			},
+			expectedOutputAction: &ScalingAction{
+				ReasonCode: ReasonCodeStrategy,
+				Meta: map[string]interface{}{
+					"nomad_autoscaler.reason_code": "strategy",
+				},
+			},
+			name: "reason code not set",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.inputAction.SetReasonCodeMeta()
+			assert.Equal(t, tc.expectedOutputAction, tc.inputAction)
+		})
+	}
+}
+
+func TestAction_SetPolicyRevisionMeta(t *testing.T) {
+	testCases := []struct {
+		inputRevision        string
+		expectedOutputAction *ScalingAction
+		name                 string
+	}{
+		{
+			inputRevision: "42",
+			expectedOutputAction: &ScalingAction{
+				Meta: map[string]interface{}{
+					"nomad_autoscaler.policy_revision": "42",
+				},
+			},
+			name: "revision set",
+		},
+		{
+			inputRevision:        "",
+			expectedOutputAction: &ScalingAction{Meta: map[string]interface{}{}},
+			name:                 "revision empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &ScalingAction{Meta: map[string]interface{}{}}
+			a.SetPolicyRevisionMeta(tc.inputRevision)
+			assert.Equal(t, tc.expectedOutputAction, a)
+		})
+	}
+}
+
+func TestAction_SetCurrentCountMeta(t *testing.T) {
+	a := &ScalingAction{Count: 5, Meta: map[string]interface{}{"key": "value"}}
+	a.SetCurrentCountMeta(3)
+
+	expected := &ScalingAction{
+		Count: 5,
+		Meta: map[string]interface{}{
+			"key":                            "value",
+			"nomad_autoscaler.count.current": int64(3),
+		},
+	}
+	assert.Equal(t, expected, a)
+}
+
+func TestAction_SetMetricValueMeta(t *testing.T) {
+	a := &ScalingAction{Count: 5, Meta: map[string]interface{}{"key": "value"}}
+	a.SetMetricValueMeta("512 MiB")
+
+	expected := &ScalingAction{
+		Count: 5,
+		Meta: map[string]interface{}{
+			"key":                           "value",
+			"nomad_autoscaler.metric_value": "512 MiB",
+		},
+	}
+	assert.Equal(t, expected, a)
+}
+
 func TestAction_CapCount(t *testing.T) {
 	testCases := []struct {
 		inputAction          *ScalingAction
@@ -173,6 +273,142 @@ func TestAction_CapCount(t *testing.T) {
 	}
 }
 
+func TestAction_CapCapacity(t *testing.T) {
+	testCases := []struct {
+		inputAction          *ScalingAction
+		inputCurrent         int64
+		inputMin             int64
+		inputCapacity        int64
+		expectedCapped       bool
+		expectedOutputAction *ScalingAction
+		name                 string
+	}{
+		{
+			inputAction: &ScalingAction{
+				Count:     8,
+				Direction: ScaleDirectionUp,
+				Meta:      map[string]interface{}{},
+			},
+			inputCurrent:   2,
+			inputMin:       1,
+			inputCapacity:  6,
+			expectedCapped: true,
+			expectedOutputAction: &ScalingAction{
+				Count:     6,
+				Direction: ScaleDirectionUp,
+				Meta: map[string]interface{}{
+					"nomad_autoscaler.count.capped":   true,
+					"nomad_autoscaler.count.original": int64(8),
+					"nomad_autoscaler.reason_history": []string{},
+				},
+				Reason: "capped count from 8 to 6 to stay within target capacity",
+			},
+			name: "scale up above capacity",
+		},
+		{
+			inputAction: &ScalingAction{
+				Count:     8,
+				Direction: ScaleDirectionUp,
+				Meta:      map[string]interface{}{},
+				Reason:    "scale up",
+			},
+			inputCurrent:   4,
+			inputMin:       1,
+			inputCapacity:  3,
+			expectedCapped: true,
+			expectedOutputAction: &ScalingAction{
+				Count:     4,
+				Direction: ScaleDirectionNone,
+				Meta: map[string]interface{}{
+					"nomad_autoscaler.count.capped":   true,
+					"nomad_autoscaler.count.original": int64(8),
+					"nomad_autoscaler.reason_history": []string{"scale up"},
+				},
+				Reason: "capped count from 8 to 4 to stay within target capacity",
+			},
+			name: "capacity below current count",
+		},
+		{
+			inputAction: &ScalingAction{
+				Count:     8,
+				Direction: ScaleDirectionUp,
+				Meta:      map[string]interface{}{},
+			},
+			inputCurrent:   0,
+			inputMin:       3,
+			inputCapacity:  2,
+			expectedCapped: true,
+			expectedOutputAction: &ScalingAction{
+				Count:     3,
+				Direction: ScaleDirectionUp,
+				Meta: map[string]interface{}{
+					"nomad_autoscaler.count.capped":   true,
+					"nomad_autoscaler.count.original": int64(8),
+					"nomad_autoscaler.reason_history": []string{},
+				},
+				Reason: "capped count from 8 to 3 to stay within target capacity",
+			},
+			name: "capacity below min",
+		},
+		{
+			inputAction: &ScalingAction{
+				Count:     5,
+				Direction: ScaleDirectionUp,
+				Meta:      map[string]interface{}{},
+			},
+			inputCurrent:  2,
+			inputMin:      1,
+			inputCapacity: 6,
+			expectedOutputAction: &ScalingAction{
+				Count:     5,
+				Direction: ScaleDirectionUp,
+				Meta:      map[string]interface{}{},
+			},
+			name: "scale up within capacity",
+		},
+		{
+			inputAction: &ScalingAction{
+				Count:     5,
+				Direction: ScaleDirectionDown,
+				Meta:      map[string]interface{}{},
+			},
+			inputCurrent:  8,
+			inputMin:      1,
+			inputCapacity: 3,
+			expectedOutputAction: &ScalingAction{
+				Count:     5,
+				Direction: ScaleDirectionDown,
+				Meta:      map[string]interface{}{},
+			},
+			name: "scale down not capped",
+		},
+		{
+			inputAction: &ScalingAction{
+				Count:     StrategyActionMetaValueDryRunCount,
+				Direction: ScaleDirectionUp,
+				Meta:      map[string]interface{}{},
+			},
+			inputCurrent:  2,
+			inputMin:      1,
+			inputCapacity: 0,
+			expectedOutputAction: &ScalingAction{
+				Count:     StrategyActionMetaValueDryRunCount,
+				Direction: ScaleDirectionUp,
+				Meta:      map[string]interface{}{},
+			},
+			name: "dry-run action",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			capped := tc.inputAction.CapCapacity(tc.inputCurrent, tc.inputMin, tc.inputCapacity)
+			assert.Equal(t, tc.expectedCapped, capped, tc.name)
+			assert.Equal(t, tc.expectedOutputAction, tc.inputAction, tc.name)
+		})
+	}
+}
+
 func TestAction_pushReason(t *testing.T) {
 	testCases := []struct {
 		inputAction          *ScalingAction
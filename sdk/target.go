@@ -27,6 +27,31 @@ const (
 	// cooldown where out-of-band scaling activities have been triggered.
 	TargetStatusMetaKeyLastEvent = "nomad_autoscaler.last_event"
 
+	// TargetStatusMetaKeyCapacity is an optional meta key that can be added
+	// to the status return. The value is the maximum count the target is
+	// currently able to reach, such as when a provider quota is lower than
+	// the policy max. Scale up actions are limited to it.
+	TargetStatusMetaKeyCapacity = "nomad_autoscaler.capacity"
+
+	// TargetStatusMetaKeyHealthyCount is an optional meta key that can be
+	// added to the status return. The value is the number of instances of
+	// the target which are currently healthy. It is used to hold scale downs
+	// when too few instances are healthy.
+	TargetStatusMetaKeyHealthyCount = "nomad_autoscaler.healthy_count"
+
+	// TargetStatusMetaKeyDeploymentActive is an optional meta key that can
+	// be added to the status return. The value is a bool indicating whether
+	// a deployment of the target is currently in progress. Policies can use
+	// it to defer scaling until the deployment has completed.
+	TargetStatusMetaKeyDeploymentActive = "nomad_autoscaler.deployment_active"
+
+	// TargetStatusMetaKeyDistribution is an optional meta key that can be
+	// added to the status return. The value describes how the count of the
+	// target is distributed, as a comma separated list of name=count pairs
+	// such as "dc1=3,dc2=2". It is logged when the target is scaled, so
+	// operators can see how the count is spread.
+	TargetStatusMetaKeyDistribution = "nomad_autoscaler.distribution"
+
 	// TargetConfigKeyJob is the config key used within horizontal app scaling
 	// to identify the Nomad job targeted for autoscaling.
 	TargetConfigKeyJob = "Job"
@@ -35,6 +60,12 @@ const (
 	// scaling to identify the Nomad job group targeted for autoscaling.
 	TargetConfigKeyTaskGroup = "Group"
 
+	// TargetConfigKeyTaskGroups is the config key used within horizontal app
+	// scaling to identify a comma separated set of Nomad job groups which
+	// are scaled together. When set, it takes precedence over
+	// TargetConfigKeyTaskGroup.
+	TargetConfigKeyTaskGroups = "Groups"
+
 	// TargetConfigKeyClass is the config key used with horizontal cluster
 	// scaling to identify Nomad clients as part of a pool of resources. This
 	// pool of resources forms the scalable target.